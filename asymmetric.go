@@ -138,6 +138,12 @@ func (r *RSAEncryptor) GenerateKeyPair() ([]byte, []byte, error) {
 	r.privateKey = privateKey
 	r.publicKey = &privateKey.PublicKey
 	
+	publicKeyPEM, privateKeyPEM := encodeRSAKeyPairPEM(privateKey)
+	return publicKeyPEM, privateKeyPEM, nil
+}
+
+// encodeRSAKeyPairPEM 将RSA密钥对编码为PKCS#1 PEM格式
+func encodeRSAKeyPairPEM(privateKey *rsa.PrivateKey) ([]byte, []byte) {
 	// 将私钥编码为PEM格式
 	privateKeyBytes := x509.MarshalPKCS1PrivateKey(privateKey)
 	privateKeyPEM := pem.EncodeToMemory(&pem.Block{
@@ -152,7 +158,7 @@ func (r *RSAEncryptor) GenerateKeyPair() ([]byte, []byte, error) {
 		Bytes: publicKeyBytes,
 	})
 	
-	return publicKeyPEM, privateKeyPEM, nil
+	return publicKeyPEM, privateKeyPEM
 }
 
 // NoEncoding 设置无编码
@@ -162,12 +168,6 @@ func (r *RSAEncryptor) NoEncoding() IAsymmetric {
 	return r
 }
 
-// WithUID RSA不需要UID，此方法仅为满足接口要求
-func (r *RSAEncryptor) WithUID(uid []byte) IAsymmetric {
-	// RSA不使用UID，此方法不做任何事情
-	return r
-}
-
 // Base64 设置Base64编码
 func (r *RSAEncryptor) Base64() IAsymmetric {
 	r.encoding = Base64Encoding
@@ -206,6 +206,7 @@ func (r *RSAEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
 }
 
 // Decrypt 使用RSA私钥解密数据
+// 失败时的错误会暴露填充是否正确，解密协议中的会话密钥请使用DecryptSessionKey
 func (r *RSAEncryptor) Decrypt(ciphertext []byte) ([]byte, error) {
 	if r.privateKey == nil {
 		return nil, errors.New("未设置私钥")
@@ -269,7 +270,7 @@ func (r *RSAEncryptor) Verify(data []byte, signature []byte) (bool, error) {
 // SM2Encryptor SM2加密实现
 type SM2Encryptor struct {
 	AsymmetricBase
-	privateKey interface{} // 实际类型在sm2.go中使用sm2.PrivateKey
+	privateKey interface{} // 实际类型在sm2.go中使用sm2.PrivateKey，WithDevice时为SM2Device
 	publicKey  interface{} // 实际类型在sm2.go中使用sm2.PublicKey
 	uid        []byte     // SM2签名需要的用户标识
 }
\ No newline at end of file
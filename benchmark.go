@@ -0,0 +1,331 @@
+package encrypt
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 性能基准与回归阈值
+//
+// RunBenchmarks在当前主机上测量各套件、各操作、各载荷大小的吞吐（MB/s，按10^6字节计，与go test一致）
+// 与每次操作的内存分配，不依赖testing包，可以在部署前的自检或运维命令中直接调用。
+//
+// 阈值文件记录每一项允许的最低吞吐与最多分配次数，通常由基准主机上的一次运行生成：
+//
+//	report, _ := encrypt.RunBenchmarks(encrypt.BenchmarkConfig{})
+//	report.Thresholds(0.2).WriteFile("crypto-bench.json") // 允许吞吐下降20%
+//
+// 部署时在目标主机上重新运行并检查，任何一项低于阈值即返回错误：
+//
+//	thresholds, _ := encrypt.LoadBenchmarkThresholds("crypto-bench.json")
+//	if err := report.Check(thresholds); err != nil { ... }
+//
+// 阈值文件中有、本次未测量的项不参与检查，便于只运行部分套件
+
+// BenchmarkThresholdsVersion 阈值文件格式版本
+const BenchmarkThresholdsVersion = 1
+
+// 基准操作
+const (
+	BenchmarkEncrypt = "encrypt"
+	BenchmarkDecrypt = "decrypt"
+)
+
+// 基准默认值
+var (
+	DefaultBenchmarkSuites       = "AES-128-GCM,AES-256-GCM,AES-256-CBC-PKCS7,AES-256-CTR,SM4-GCM,SM4-CBC-PKCS7"
+	DefaultBenchmarkPayloadSizes = []int{64, 1024, 16 * 1024, 1024 * 1024}
+	DefaultBenchmarkDuration     = 200 * time.Millisecond
+)
+
+// BenchmarkConfig 基准配置，零值使用默认套件、载荷大小与测量时长
+type BenchmarkConfig struct {
+	Suites       []Suite       // 待测套件
+	PayloadSizes []int         // 载荷字节数
+	Duration     time.Duration // 每项的最短测量时长
+	Operations   []string      // BenchmarkEncrypt、BenchmarkDecrypt，默认两者都测
+}
+
+// BenchmarkResult 单项测量结果
+type BenchmarkResult struct {
+	Suite       string  `json:"suite"`
+	Operation   string  `json:"operation"`
+	PayloadSize int     `json:"payload_size"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	MBPerSecond float64 `json:"mb_per_sec"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+// BenchmarkReport 基准报告
+type BenchmarkReport struct {
+	GoVersion string            `json:"go_version"`
+	OS        string            `json:"os"`
+	Arch      string            `json:"arch"`
+	CPUs      int               `json:"cpus"`
+	StartedAt time.Time         `json:"started_at"`
+	Results   []BenchmarkResult `json:"results"`
+}
+
+// BenchmarkThreshold 单项阈值
+type BenchmarkThreshold struct {
+	Suite          string  `json:"suite"`
+	Operation      string  `json:"operation"`
+	PayloadSize    int     `json:"payload_size"`
+	MinMBPerSecond float64 `json:"min_mb_per_sec,omitempty"`
+	MaxAllocsPerOp int64   `json:"max_allocs_per_op,omitempty"` // 0表示不检查
+}
+
+// BenchmarkThresholds 回归阈值文件
+type BenchmarkThresholds struct {
+	Version    int                  `json:"version"`
+	Thresholds []BenchmarkThreshold `json:"thresholds"`
+}
+
+// BenchmarkRegression 低于阈值的测量项
+type BenchmarkRegression struct {
+	Threshold BenchmarkThreshold
+	Result    BenchmarkResult
+	Reason    string
+}
+
+// RunBenchmarks 在当前主机上运行基准测试
+func RunBenchmarks(config BenchmarkConfig) (*BenchmarkReport, error) {
+	suites := config.Suites
+	if len(suites) == 0 {
+		var err error
+		if suites, err = ParseSuiteList(DefaultBenchmarkSuites); err != nil {
+			return nil, err
+		}
+	}
+	sizes := config.PayloadSizes
+	if len(sizes) == 0 {
+		sizes = DefaultBenchmarkPayloadSizes
+	}
+	duration := config.Duration
+	if duration <= 0 {
+		duration = DefaultBenchmarkDuration
+	}
+	operations := config.Operations
+	if len(operations) == 0 {
+		operations = []string{BenchmarkEncrypt, BenchmarkDecrypt}
+	}
+	for _, op := range operations {
+		if op != BenchmarkEncrypt && op != BenchmarkDecrypt {
+			return nil, errors.Errorf("不支持的基准操作: %s", op)
+		}
+	}
+
+	report := &BenchmarkReport{
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		StartedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	for _, suite := range suites {
+		for _, size := range sizes {
+			if size <= 0 {
+				return nil, errors.Errorf("载荷大小必须大于0: %d", size)
+			}
+			results, err := benchmarkSuite(suite, size, operations, duration)
+			if err != nil {
+				return nil, errors.Wrapf(err, "套件%s基准测试失败", suite)
+			}
+			report.Results = append(report.Results, results...)
+		}
+	}
+	return report, nil
+}
+
+// benchmarkSuite 测量单个套件在指定载荷大小下的各项操作
+func benchmarkSuite(suite Suite, size int, operations []string, duration time.Duration) ([]BenchmarkResult, error) {
+	key, err := GenerateRandomBytes(suite.KeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+	cipher, err := suite.NewCipher(key, WithEncoding(EncodingNone))
+	if err != nil {
+		return nil, err
+	}
+	defer cipher.Release()
+
+	// 无填充的ECB、CBC要求载荷为分组长度的整数倍
+	blockSize := 16
+	if suite.Algorithm == AlgorithmDES || suite.Algorithm == Algorithm3DES {
+		blockSize = 8
+	}
+	if (suite.Mode == ModeECB || suite.Mode == ModeCBC) && suite.Padding != PaddingPKCS7 && size%blockSize != 0 {
+		size += blockSize - size%blockSize
+	}
+	payload, err := GenerateRandomBytes(size)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := cipher.Encrypt(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []BenchmarkResult
+	for _, op := range operations {
+		run := func() error {
+			_, err := cipher.Encrypt(payload)
+			return err
+		}
+		if op == BenchmarkDecrypt {
+			run = func() error {
+				_, err := cipher.Decrypt(ciphertext)
+				return err
+			}
+		}
+
+		result, err := measureBenchmark(run, duration)
+		if err != nil {
+			return nil, err
+		}
+		result.Suite, result.Operation, result.PayloadSize = suite.String(), op, size
+		if result.NsPerOp > 0 {
+			result.MBPerSecond = float64(size) * 1e3 / float64(result.NsPerOp)
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// measureBenchmark 按倍增的轮次运行，直到耗时不少于duration
+func measureBenchmark(run func() error, duration time.Duration) (BenchmarkResult, error) {
+	// 预热，同时排除首次调用的一次性分配
+	if err := run(); err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	var result BenchmarkResult
+	var before, after runtime.MemStats
+	for n := 1; ; n *= 2 {
+		runtime.GC()
+		runtime.ReadMemStats(&before)
+		start := time.Now()
+		for i := 0; i < n; i++ {
+			if err := run(); err != nil {
+				return BenchmarkResult{}, err
+			}
+		}
+		elapsed := time.Since(start)
+		runtime.ReadMemStats(&after)
+
+		if elapsed >= duration || n >= 1<<30 {
+			result.Iterations = n
+			result.NsPerOp = elapsed.Nanoseconds() / int64(n)
+			result.AllocsPerOp = int64(after.Mallocs-before.Mallocs) / int64(n)
+			result.BytesPerOp = int64(after.TotalAlloc-before.TotalAlloc) / int64(n)
+			return result, nil
+		}
+	}
+}
+
+// WriteText 以表格形式输出报告
+func (r *BenchmarkReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "# %s %s/%s, %d CPU\n", r.GoVersion, r.OS, r.Arch, r.CPUs)
+	fmt.Fprintln(tw, "suite\top\tsize\tMB/s\tns/op\tallocs/op\tB/op\t")
+	for _, res := range r.Results {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.2f\t%d\t%d\t%d\t\n",
+			res.Suite, res.Operation, res.PayloadSize, res.MBPerSecond, res.NsPerOp, res.AllocsPerOp, res.BytesPerOp)
+	}
+	return tw.Flush()
+}
+
+// Thresholds 以本次结果为基线生成阈值，tolerance为允许的吞吐下降比例（如0.2），
+// 分配次数上限在本次结果基础上加一次，避免运行时细微差异导致误报
+func (r *BenchmarkReport) Thresholds(tolerance float64) *BenchmarkThresholds {
+	if tolerance < 0 {
+		tolerance = 0
+	}
+	if tolerance > 1 {
+		tolerance = 1
+	}
+	t := &BenchmarkThresholds{Version: BenchmarkThresholdsVersion}
+	for _, res := range r.Results {
+		t.Thresholds = append(t.Thresholds, BenchmarkThreshold{
+			Suite:          res.Suite,
+			Operation:      res.Operation,
+			PayloadSize:    res.PayloadSize,
+			MinMBPerSecond: res.MBPerSecond * (1 - tolerance),
+			MaxAllocsPerOp: res.AllocsPerOp + 1,
+		})
+	}
+	return t
+}
+
+// Regressions 返回低于阈值的测量项
+func (r *BenchmarkReport) Regressions(t *BenchmarkThresholds) []BenchmarkRegression {
+	var regressions []BenchmarkRegression
+	for _, threshold := range t.Thresholds {
+		for _, res := range r.Results {
+			if !strings.EqualFold(res.Suite, threshold.Suite) || res.Operation != threshold.Operation || res.PayloadSize != threshold.PayloadSize {
+				continue
+			}
+			if threshold.MinMBPerSecond > 0 && res.MBPerSecond < threshold.MinMBPerSecond {
+				regressions = append(regressions, BenchmarkRegression{threshold, res,
+					fmt.Sprintf("吞吐%.2f MB/s低于阈值%.2f MB/s", res.MBPerSecond, threshold.MinMBPerSecond)})
+			}
+			if threshold.MaxAllocsPerOp > 0 && res.AllocsPerOp > threshold.MaxAllocsPerOp {
+				regressions = append(regressions, BenchmarkRegression{threshold, res,
+					fmt.Sprintf("每次操作分配%d次，超过阈值%d次", res.AllocsPerOp, threshold.MaxAllocsPerOp)})
+			}
+		}
+	}
+	return regressions
+}
+
+// Check 检查报告是否满足阈值，存在回归时返回汇总错误
+func (r *BenchmarkReport) Check(t *BenchmarkThresholds) error {
+	regressions := r.Regressions(t)
+	if len(regressions) == 0 {
+		return nil
+	}
+	lines := make([]string, len(regressions))
+	for i, reg := range regressions {
+		lines[i] = fmt.Sprintf("%s %s %dB: %s", reg.Result.Suite, reg.Result.Operation, reg.Result.PayloadSize, reg.Reason)
+	}
+	return errors.Errorf("加密性能低于阈值:\n%s", strings.Join(lines, "\n"))
+}
+
+// WriteFile 写入阈值文件
+func (t *BenchmarkThresholds) WriteFile(path string) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "序列化阈值文件失败")
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return errors.Wrap(err, "写入阈值文件失败")
+	}
+	return nil
+}
+
+// LoadBenchmarkThresholds 读取阈值文件
+func LoadBenchmarkThresholds(path string) (*BenchmarkThresholds, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取阈值文件失败")
+	}
+	var t BenchmarkThresholds
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, errors.Wrap(err, "解析阈值文件失败")
+	}
+	if t.Version != BenchmarkThresholdsVersion {
+		return nil, errors.Errorf("不支持的阈值文件版本: %d", t.Version)
+	}
+	return &t, nil
+}
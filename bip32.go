@@ -0,0 +1,284 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ripemd160" //nolint:staticcheck // BIP32指纹规定使用HASH160
+)
+
+// BIP32 分层确定性密钥
+//
+// 由种子派生主密钥，再按路径（如 m/44'/60'/0'/0/0）逐级派生子密钥，同一种子总能得到同一组密钥。
+// 仅支持私钥派生；带'或h后缀的索引为强化派生，泄露子私钥与父链码也无法推出父私钥。
+// 派生出的节点既可作为secp256k1签名密钥（EthereumSigner），也可经HKDF得到对称密钥
+
+// HDHardened 强化派生索引起点
+const HDHardened uint32 = 0x80000000
+
+// 扩展密钥版本前缀（主网）
+var (
+	hdVersionPrivate = []byte{0x04, 0x88, 0xad, 0xe4}
+	hdVersionPublic  = []byte{0x04, 0x88, 0xb2, 0x1e}
+)
+
+// hdSerializedSize 扩展密钥序列化长度（不含校验和）
+const hdSerializedSize = 78
+
+// HDKey 分层确定性私钥节点
+type HDKey struct {
+	key               *secp256k1.PrivateKey
+	chainCode         []byte
+	depth             uint8
+	parentFingerprint []byte
+	childIndex        uint32
+}
+
+// NewHDMasterKey 由种子（16~64字节，通常为MnemonicToSeed的输出）生成主密钥
+func NewHDMasterKey(seed []byte) (*HDKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, errors.New("种子长度必须在16到64字节之间")
+	}
+
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(sum[:32]); overflow || scalar.IsZero() {
+		return nil, errors.New("种子派生出的主密钥无效，请更换种子")
+	}
+	return &HDKey{
+		key:               secp256k1.NewPrivateKey(&scalar),
+		chainCode:         sum[32:],
+		parentFingerprint: make([]byte, 4),
+	}, nil
+}
+
+// ParseHDKey 解析xprv格式的扩展私钥
+func ParseHDKey(extended string) (*HDKey, error) {
+	data, err := base58CheckDecode(extended)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != hdSerializedSize {
+		return nil, errors.New("扩展密钥长度不正确")
+	}
+	if !bytes.Equal(data[:4], hdVersionPrivate) {
+		return nil, errors.New("只支持xprv扩展私钥")
+	}
+	if data[45] != 0 {
+		return nil, errors.New("扩展私钥格式不正确")
+	}
+
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(data[46:78]); overflow || scalar.IsZero() {
+		return nil, errors.New("扩展私钥无效")
+	}
+	return &HDKey{
+		key:               secp256k1.NewPrivateKey(&scalar),
+		chainCode:         append([]byte(nil), data[13:45]...),
+		depth:             data[4],
+		parentFingerprint: append([]byte(nil), data[5:9]...),
+		childIndex:        binary.BigEndian.Uint32(data[9:13]),
+	}, nil
+}
+
+// Child 派生子密钥，index >= HDHardened 时为强化派生
+func (k *HDKey) Child(index uint32) (*HDKey, error) {
+	if k.depth == 0xff {
+		return nil, errors.New("派生层级超出限制")
+	}
+
+	var data []byte
+	if index >= HDHardened {
+		data = append([]byte{0x00}, k.key.Serialize()...)
+	} else {
+		data = k.PublicKey()
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	zeroBytes(data)
+
+	var tweak secp256k1.ModNScalar
+	if overflow := tweak.SetByteSlice(sum[:32]); overflow {
+		return nil, errors.Errorf("索引%d派生出的子密钥无效，请使用下一个索引", index)
+	}
+	tweak.Add(&k.key.Key)
+	if tweak.IsZero() {
+		return nil, errors.Errorf("索引%d派生出的子密钥无效，请使用下一个索引", index)
+	}
+
+	return &HDKey{
+		key:               secp256k1.NewPrivateKey(&tweak),
+		chainCode:         sum[32:],
+		depth:             k.depth + 1,
+		parentFingerprint: k.Fingerprint(),
+		childIndex:        index,
+	}, nil
+}
+
+// DerivePath 按路径派生，如 m/44'/60'/0'/0/0，'或h表示强化派生
+func (k *HDKey) DerivePath(path string) (*HDKey, error) {
+	parts := strings.Split(strings.TrimSpace(path), "/")
+	if len(parts) == 0 || (parts[0] != "m" && parts[0] != "M") {
+		return nil, errors.New("派生路径必须以m开头")
+	}
+
+	current := k
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h") || strings.HasSuffix(part, "H")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HDHardened {
+			return nil, errors.Errorf("派生路径中的索引不正确: %s", part)
+		}
+		if hardened {
+			index += uint64(HDHardened)
+		}
+
+		if current, err = current.Child(uint32(index)); err != nil {
+			return nil, err
+		}
+	}
+	return current, nil
+}
+
+// PrivateKey 获取32字节私钥
+func (k *HDKey) PrivateKey() []byte {
+	return k.key.Serialize()
+}
+
+// PublicKey 获取33字节压缩公钥
+func (k *HDKey) PublicKey() []byte {
+	return k.key.PubKey().SerializeCompressed()
+}
+
+// ChainCode 获取链码
+func (k *HDKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// Depth 获取派生层级，主密钥为0
+func (k *HDKey) Depth() int {
+	return int(k.depth)
+}
+
+// Fingerprint 获取密钥指纹：HASH160(压缩公钥)的前4字节
+func (k *HDKey) Fingerprint() []byte {
+	sha := sha256.Sum256(k.PublicKey())
+	h := ripemd160.New()
+	h.Write(sha[:])
+	return h.Sum(nil)[:4]
+}
+
+// String 序列化为xprv扩展私钥
+func (k *HDKey) String() string {
+	return base58CheckEncode(k.serialize(hdVersionPrivate, append([]byte{0x00}, k.key.Serialize()...)))
+}
+
+// PublicString 序列化为xpub扩展公钥
+func (k *HDKey) PublicString() string {
+	return base58CheckEncode(k.serialize(hdVersionPublic, k.PublicKey()))
+}
+
+// EthereumSigner 以该节点私钥创建以太坊签名器
+func (k *HDKey) EthereumSigner() (*EthereumSigner, error) {
+	return NewEthereumSigner(k.key.Serialize())
+}
+
+// SymmetricKey 由该节点派生size字节对称密钥，info区分用途，不同info得到互不相关的密钥
+func (k *HDKey) SymmetricKey(info string, size int) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, k.key.Serialize(), k.chainCode, "sylphbyte/encrypt hd "+info, size)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生对称密钥失败")
+	}
+	return key, nil
+}
+
+// serialize 按BIP32格式序列化
+func (k *HDKey) serialize(version, keyData []byte) []byte {
+	out := make([]byte, 0, hdSerializedSize)
+	out = append(out, version...)
+	out = append(out, k.depth)
+	out = append(out, k.parentFingerprint...)
+	out = binary.BigEndian.AppendUint32(out, k.childIndex)
+	out = append(out, k.chainCode...)
+	return append(out, keyData...)
+}
+
+// base58Alphabet 比特币Base58字母表
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// base58CheckEncode Base58Check编码（双SHA-256前4字节作为校验和）
+func base58CheckEncode(data []byte) string {
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	payload := append(append([]byte(nil), data...), second[:4]...)
+
+	value := new(big.Int).SetBytes(payload)
+	radix := big.NewInt(58)
+	mod := new(big.Int)
+	var out []byte
+	for value.Sign() > 0 {
+		value.DivMod(value, radix, mod)
+		out = append(out, base58Alphabet[mod.Int64()])
+	}
+	for _, b := range payload {
+		if b != 0 {
+			break
+		}
+		out = append(out, base58Alphabet[0])
+	}
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+// base58CheckDecode Base58Check解码并校验
+func base58CheckDecode(s string) ([]byte, error) {
+	value := new(big.Int)
+	radix := big.NewInt(58)
+	for _, c := range s {
+		i := strings.IndexRune(base58Alphabet, c)
+		if i < 0 {
+			return nil, errors.Errorf("Base58字符不正确: %c", c)
+		}
+		value.Mul(value, radix)
+		value.Add(value, big.NewInt(int64(i)))
+	}
+
+	payload := value.Bytes()
+	for _, c := range s {
+		if c != rune(base58Alphabet[0]) {
+			break
+		}
+		payload = append([]byte{0}, payload...)
+	}
+	if len(payload) < 4 {
+		return nil, errors.New("Base58Check数据过短")
+	}
+
+	data, checksum := payload[:len(payload)-4], payload[len(payload)-4:]
+	first := sha256.Sum256(data)
+	second := sha256.Sum256(first[:])
+	if !bytes.Equal(second[:4], checksum) {
+		return nil, errors.New("Base58Check校验和不正确")
+	}
+	return data, nil
+}
@@ -0,0 +1,158 @@
+package encrypt
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	_ "embed"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"golang.org/x/text/unicode/norm"
+)
+
+// BIP39 助记词
+//
+// 熵（128~256位）加上SHA-256校验位后每11位对应词表中的一个词。
+// 助记词经PBKDF2-HMAC-SHA512（2048次，盐为"mnemonic"+口令）得到64字节种子，
+// 种子可用于NewHDMasterKey派生分层密钥。助记词与口令在计算前按BIP39要求做NFKD规范化
+
+// MnemonicLanguage 助记词词表语言
+type MnemonicLanguage int
+
+// 助记词词表常量定义
+const (
+	MnemonicEnglish MnemonicLanguage = iota + 1
+	MnemonicChineseSimplified
+)
+
+// BIP39官方词表
+var (
+	//go:embed wordlist/bip39_english.txt
+	bip39EnglishText string
+	//go:embed wordlist/bip39_chinese_simplified.txt
+	bip39ChineseSimplifiedText string
+
+	bip39Once  sync.Once
+	bip39Lists map[MnemonicLanguage][]string
+	bip39Index map[MnemonicLanguage]map[string]int
+)
+
+// GenerateMnemonic 生成随机助记词，bits为熵位数（128、160、192、224或256）
+func GenerateMnemonic(bits int, language MnemonicLanguage) (string, error) {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", errors.New("熵位数必须是128到256之间32的倍数")
+	}
+	entropy, err := GenerateRandomBytes(bits / 8)
+	if err != nil {
+		return "", err
+	}
+	defer zeroBytes(entropy)
+	return NewMnemonic(entropy, language)
+}
+
+// NewMnemonic 由熵生成助记词
+func NewMnemonic(entropy []byte, language MnemonicLanguage) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", errors.New("熵长度必须是16到32字节之间4的倍数")
+	}
+	words, _, err := bip39Wordlist(language)
+	if err != nil {
+		return "", err
+	}
+
+	// 熵后追加 bits/32 位校验和
+	checksumBits := bits / 32
+	checksum := sha256.Sum256(entropy)
+	value := new(big.Int).SetBytes(entropy)
+	value.Lsh(value, uint(checksumBits))
+	value.Or(value, big.NewInt(int64(checksum[0]>>(8-checksumBits))))
+
+	count := (bits + checksumBits) / 11
+	out := make([]string, count)
+	mask := big.NewInt(2047)
+	index := new(big.Int)
+	for i := count - 1; i >= 0; i-- {
+		index.And(value, mask)
+		out[i] = words[index.Int64()]
+		value.Rsh(value, 11)
+	}
+	return strings.Join(out, " "), nil
+}
+
+// MnemonicToEntropy 校验助记词并还原熵
+func MnemonicToEntropy(mnemonic string, language MnemonicLanguage) ([]byte, error) {
+	_, index, err := bip39Wordlist(language)
+	if err != nil {
+		return nil, err
+	}
+
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, errors.New("助记词数量必须是12、15、18、21或24个")
+	}
+
+	value := new(big.Int)
+	for _, word := range words {
+		i, ok := index[word]
+		if !ok {
+			return nil, errors.Errorf("词表中没有单词: %s", word)
+		}
+		value.Lsh(value, 11)
+		value.Or(value, big.NewInt(int64(i)))
+	}
+
+	totalBits := len(words) * 11
+	checksumBits := totalBits / 33
+	entropyBits := totalBits - checksumBits
+
+	checksum := new(big.Int).And(value, big.NewInt(int64(1<<checksumBits-1)))
+	value.Rsh(value, uint(checksumBits))
+	entropy := leftPad(value.Bytes(), entropyBits/8)
+
+	expected := sha256.Sum256(entropy)
+	if int64(expected[0]>>(8-checksumBits)) != checksum.Int64() {
+		return nil, errors.New("助记词校验和不正确")
+	}
+	return entropy, nil
+}
+
+// ValidateMnemonic 校验助记词的单词与校验和
+func ValidateMnemonic(mnemonic string, language MnemonicLanguage) error {
+	entropy, err := MnemonicToEntropy(mnemonic, language)
+	zeroBytes(entropy)
+	return err
+}
+
+// MnemonicToSeed 由助记词与口令生成64字节种子，不校验助记词，需要时先调用ValidateMnemonic
+func MnemonicToSeed(mnemonic, passphrase string) []byte {
+	password := []byte(norm.NFKD.String(strings.Join(strings.Fields(mnemonic), " ")))
+	salt := []byte("mnemonic" + norm.NFKD.String(passphrase))
+	return pbkdf2(password, salt, 2048, 64, sha512.New)
+}
+
+// bip39Wordlist 获取词表与索引
+func bip39Wordlist(language MnemonicLanguage) ([]string, map[string]int, error) {
+	bip39Once.Do(func() {
+		bip39Lists = map[MnemonicLanguage][]string{
+			MnemonicEnglish:           strings.Fields(bip39EnglishText),
+			MnemonicChineseSimplified: strings.Fields(bip39ChineseSimplifiedText),
+		}
+		bip39Index = make(map[MnemonicLanguage]map[string]int, len(bip39Lists))
+		for lang, words := range bip39Lists {
+			index := make(map[string]int, len(words))
+			for i, word := range words {
+				index[norm.NFKD.String(word)] = i
+			}
+			bip39Index[lang] = index
+		}
+	})
+
+	words, ok := bip39Lists[language]
+	if !ok || len(words) != 2048 {
+		return nil, nil, errors.New("不支持的助记词语言")
+	}
+	return words, bip39Index[language], nil
+}
@@ -0,0 +1,139 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"encoding/binary"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// 盲索引（可搜索加密辅助）
+//
+// 字段值使用随机IV加密后无法直接做等值查询，盲索引在密文旁额外保存一列
+// HMAC(字段子密钥, 值) 的截断结果，查询时对条件值计算相同的索引即可匹配。
+// 每个字段使用独立的盐值派生子密钥，不同字段的相同值不会产生相同索引。
+// 截断位数越少，碰撞越多，泄露的信息也越少：查询结果需要解密后再次比对原值以排除碰撞
+
+// 盲索引截断位数的范围与默认值
+const (
+	BlindIndexMinBits     = 16
+	BlindIndexDefaultBits = 64
+)
+
+// blindIndexMinKeySize 盲索引主密钥的最小长度
+const blindIndexMinKeySize = 16
+
+// BlindIndexer 单个字段的盲索引生成器
+type BlindIndexer struct {
+	key      []byte
+	salt     []byte
+	bits     int
+	hashAlgo HashAlgorithm
+	encoding Encoding
+}
+
+// NewBlindIndex 创建盲索引生成器，salt用于区分字段，通常为表名与列名
+func NewBlindIndex(key, salt []byte) *BlindIndexer {
+	return &BlindIndexer{
+		key:      key,
+		salt:     salt,
+		bits:     BlindIndexDefaultBits,
+		hashAlgo: HashSHA256, // 默认使用SHA-256
+		encoding: HexEncoding,
+	}
+}
+
+// SHA256 使用HMAC-SHA256
+func (b *BlindIndexer) SHA256() *BlindIndexer {
+	b.hashAlgo = HashSHA256
+	return b
+}
+
+// SM3 使用HMAC-SM3
+func (b *BlindIndexer) SM3() *BlindIndexer {
+	b.hashAlgo = HashSM3
+	return b
+}
+
+// Bits 设置索引截断位数，用于在碰撞率与信息泄露之间取舍
+func (b *BlindIndexer) Bits(bits int) *BlindIndexer {
+	b.bits = bits
+	return b
+}
+
+// NoEncoding 设置无编码
+func (b *BlindIndexer) NoEncoding() *BlindIndexer {
+	b.encoding = NoEncoding
+	return b
+}
+
+// Base64 设置Base64编码
+func (b *BlindIndexer) Base64() *BlindIndexer {
+	b.encoding = Base64Encoding
+	return b
+}
+
+// Hex 设置十六进制编码
+func (b *BlindIndexer) Hex() *BlindIndexer {
+	b.encoding = HexEncoding
+	return b
+}
+
+// Index 计算值的盲索引
+func (b *BlindIndexer) Index(value []byte) ([]byte, error) {
+	if len(b.key) < blindIndexMinKeySize {
+		return nil, errors.Errorf("盲索引密钥长度至少需要%d字节", blindIndexMinKeySize)
+	}
+
+	h := hashFunc(b.hashAlgo)
+	if b.bits < BlindIndexMinBits || b.bits > h().Size()*8 {
+		return nil, errors.Errorf("截断位数必须在%d到%d之间", BlindIndexMinBits, h().Size()*8)
+	}
+
+	// 字段子密钥 = HMAC(主密钥, "blind-index" || len(salt) || salt)
+	var saltLen [4]byte
+	binary.BigEndian.PutUint32(saltLen[:], uint32(len(b.salt)))
+	mac := hmac.New(h, b.key)
+	mac.Write([]byte("blind-index"))
+	mac.Write(saltLen[:])
+	mac.Write(b.salt)
+	fieldKey := mac.Sum(nil)
+
+	mac = hmac.New(h, fieldKey)
+	mac.Write(value)
+	sum := mac.Sum(nil)
+
+	// 截断到指定位数，多余的低位清零
+	index := sum[:(b.bits+7)/8]
+	if rem := b.bits % 8; rem != 0 {
+		index[len(index)-1] &= byte(0xff << (8 - rem))
+	}
+
+	return b.encoding.Encode(index)
+}
+
+// IndexString 计算字符串的盲索引
+func (b *BlindIndexer) IndexString(value string) (string, error) {
+	index, err := b.Index([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return string(index), nil
+}
+
+// ExpectedCollisions 估算rows行不同值在当前截断位数下的碰撞对数（生日界 n²/2^(bits+1)）
+func (b *BlindIndexer) ExpectedCollisions(rows int) float64 {
+	n := float64(rows)
+	return n * (n - 1) / math.Pow(2, float64(b.bits)+1)
+}
+
+// BlindIndex 使用默认配置计算十六进制盲索引，不区分字段
+// 多个字段共用主密钥时应使用NewBlindIndex并为每个字段设置不同的盐值
+func BlindIndex(key, value []byte) (string, error) {
+	index, err := NewBlindIndex(key, nil).Index(value)
+	if err != nil {
+		return "", err
+	}
+	return string(index), nil
+}
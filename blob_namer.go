@@ -0,0 +1,192 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 加密对象的文件名
+//
+// 对象存储中的路径常按原文件名或明文哈希命名，即使内容已加密，文件名仍会泄露“两个用户上传了同一份文件”
+// 或被用来枚举已知文件。BlobNamer 对密文计算名称：
+//
+//	名称 = hex(HMAC-SHA256(namingKey, "blob v1" || len(keyID)(1) || keyID || SHA-256(密文)))
+//
+// 分块加密的密文头部包含每个文件随机生成的盐，相同明文每次得到不同的名称，名称与明文无关；
+// 名称同时绑定加密密钥ID，密钥轮换后重新加密的对象不会与旧对象同名。
+// 持有命名密钥的一方可以用Verify确认对象内容与名称对应，发现对象被替换或挪用。
+// 命名密钥由传入的密钥经HKDF派生，可以与数据密钥分开管理
+//
+// 名称在密文写完后才能确定，流式上传应先写入临时对象，Close后再按Name重命名
+
+// blobNameInfo 派生命名密钥的上下文
+const blobNameInfo = "sylphbyte/encrypt blob name v1"
+
+// blobNamePrefix 名称MAC的输入前缀
+const blobNamePrefix = "blob v1"
+
+// blobNameSize 名称MAC长度（十六进制编码前）
+const blobNameSize = sha256.Size
+
+// ErrBlobNameMismatch 对象内容与名称不对应，可通过errors.Is判断
+var ErrBlobNameMismatch = errors.New("对象内容与名称不匹配")
+
+// BlobNamer 加密对象命名器
+type BlobNamer struct {
+	key    []byte
+	keyID  string
+	fanout int
+	suffix string
+}
+
+// NewBlobNamer 创建命名器，key至少32字节，keyID为加密对象所用密钥的ID
+func NewBlobNamer(key []byte, keyID string) (*BlobNamer, error) {
+	if len(key) < 32 {
+		return nil, errors.New("命名密钥长度至少32字节")
+	}
+	if len(keyID) == 0 || len(keyID) > 0xff {
+		return nil, errors.New("密钥ID长度必须在1到255字节之间")
+	}
+	derived, err := hkdf.Key(sha256.New, key, nil, blobNameInfo, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生命名密钥失败")
+	}
+	return &BlobNamer{key: derived, keyID: keyID}, nil
+}
+
+// WithFanout 按名称前缀分levels级目录（每级2个十六进制字符），避免单个目录下对象过多
+func (b *BlobNamer) WithFanout(levels int) *BlobNamer {
+	if levels < 0 || levels > 4 {
+		panic("目录层级必须在0到4之间")
+	}
+	b.fanout = levels
+	return b
+}
+
+// WithSuffix 设置名称后缀，如".enc"
+func (b *BlobNamer) WithSuffix(suffix string) *BlobNamer {
+	b.suffix = suffix
+	return b
+}
+
+// KeyID 绑定的密钥ID
+func (b *BlobNamer) KeyID() string {
+	return b.keyID
+}
+
+// Name 计算密文的名称
+func (b *BlobNamer) Name(ciphertext []byte) string {
+	digest := sha256.Sum256(ciphertext)
+	return b.NameDigest(digest[:])
+}
+
+// NameReader 流式读取密文并计算名称
+func (b *BlobNamer) NameReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", errors.Wrap(err, "读取密文失败")
+	}
+	return b.NameDigest(h.Sum(nil)), nil
+}
+
+// NameDigest 按密文的SHA-256摘要计算名称
+func (b *BlobNamer) NameDigest(digest []byte) string {
+	name := hex.EncodeToString(b.mac(digest))
+
+	var path strings.Builder
+	for i := 0; i < b.fanout; i++ {
+		path.WriteString(name[2*i : 2*i+2])
+		path.WriteByte('/')
+	}
+	path.WriteString(name)
+	path.WriteString(b.suffix)
+	return path.String()
+}
+
+// Verify 校验密文与名称是否对应，name可以带目录与后缀
+func (b *BlobNamer) Verify(name string, ciphertext []byte) error {
+	digest := sha256.Sum256(ciphertext)
+	return b.VerifyDigest(name, digest[:])
+}
+
+// VerifyDigest 按密文的SHA-256摘要校验名称
+func (b *BlobNamer) VerifyDigest(name string, digest []byte) error {
+	base := name[strings.LastIndexByte(name, '/')+1:]
+	base = strings.TrimSuffix(base, b.suffix)
+	mac, err := hex.DecodeString(base)
+	if err != nil || len(mac) != blobNameSize {
+		return errors.Wrap(ErrBlobNameMismatch, "名称格式不正确")
+	}
+	if !hmac.Equal(mac, b.mac(digest)) {
+		return ErrBlobNameMismatch
+	}
+	return nil
+}
+
+// mac 计算名称MAC
+func (b *BlobNamer) mac(digest []byte) []byte {
+	mac := hmac.New(sha256.New, b.key)
+	mac.Write([]byte(blobNamePrefix))
+	mac.Write([]byte{byte(len(b.keyID))})
+	mac.Write([]byte(b.keyID))
+	mac.Write(digest)
+	return mac.Sum(nil)
+}
+
+// BlobWriter 分块加密并在写入时计算对象名称
+type BlobWriter struct {
+	*ChunkedWriter
+	namer  *BlobNamer
+	digest hash.Hash
+	name   string
+}
+
+// NewBlobWriter 创建写入器，参数含义同NewChunkedWriter，Close后通过Name获取对象名称
+func NewBlobWriter(w io.Writer, namer *BlobNamer, algorithm Algorithm, key []byte, chunkSize int) (*BlobWriter, error) {
+	digest := sha256.New()
+	writer, err := NewChunkedWriter(io.MultiWriter(w, digest), algorithm, key, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	return &BlobWriter{ChunkedWriter: writer, namer: namer, digest: digest}, nil
+}
+
+// Close 写出最后一块并确定名称，不关闭底层写入器
+func (b *BlobWriter) Close() error {
+	if err := b.ChunkedWriter.Close(); err != nil {
+		return err
+	}
+	if b.name == "" {
+		b.name = b.namer.NameDigest(b.digest.Sum(nil))
+	}
+	return nil
+}
+
+// Name 对象名称，Close之前返回空字符串
+func (b *BlobWriter) Name() string {
+	return b.name
+}
+
+// EncryptBlob 分块加密数据并返回对象名称与密文
+func EncryptBlob(namer *BlobNamer, algorithm Algorithm, key, plaintext []byte, chunkSize int) (string, []byte, error) {
+	var out bytes.Buffer
+	w, err := NewBlobWriter(&out, namer, algorithm, key, chunkSize)
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return "", nil, err
+	}
+	if err := w.Close(); err != nil {
+		return "", nil, err
+	}
+	return w.Name(), out.Bytes(), nil
+}
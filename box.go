@@ -0,0 +1,161 @@
+package encrypt
+
+import (
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// 高层Box/SecretBox接口
+//
+// 面向"加密给这个公钥"或"用这把密钥加密"的场景，不需要选择算法、模式与填充：
+//
+//	BoxSeal:    version(1) | 临时公钥(32) | nonce(24) | 密文 | 标签(16)
+//	SecretSeal: version(1) | nonce(24) | 密文 | 标签(16)
+//
+// 公钥加密使用X25519临时密钥协商，经HKDF-SHA256派生一次性密钥；对称部分统一使用
+// XChaCha20-Poly1305，24字节随机nonce可以放心在同一密钥下加密大量消息。
+// 与libsodium格式兼容的需求请使用SodiumSeal与SecretBoxSeal
+
+// boxVersion Box格式版本
+const boxVersion = 1
+
+// boxInfo HKDF派生密钥使用的上下文
+const boxInfo = "sylphbyte/encrypt box v1"
+
+// BoxKeySize Box公钥、私钥与SecretSeal密钥的长度
+const BoxKeySize = 32
+
+// GenerateBoxKeyPair 生成X25519密钥对
+func GenerateBoxKeyPair() (publicKey, privateKey []byte, err error) {
+	priv, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "生成密钥对失败")
+	}
+	return priv.PublicKey().Bytes(), priv.Bytes(), nil
+}
+
+// BoxSeal 加密给接收方公钥，发送方无需密钥对，加密后发送方自身也无法解密
+func BoxSeal(plaintext, recipientPublicKey []byte) ([]byte, error) {
+	recipient, err := ecdh.X25519().NewPublicKey(recipientPublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "接收方公钥格式不正确")
+	}
+	ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成临时密钥失败")
+	}
+
+	key, err := boxKey(ephemeral, recipient, ephemeral.PublicKey().Bytes(), recipientPublicKey)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+
+	header := append([]byte{boxVersion}, ephemeral.PublicKey().Bytes()...)
+	return xchachaSeal(header, key, plaintext)
+}
+
+// BoxOpen 使用接收方私钥解密BoxSeal的输出
+func BoxOpen(ciphertext, privateKey []byte) ([]byte, error) {
+	priv, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "私钥格式不正确")
+	}
+	if len(ciphertext) < 1+BoxKeySize+chacha20poly1305.NonceSizeX+chacha20poly1305.Overhead {
+		return nil, errors.New("密文长度不足")
+	}
+	if ciphertext[0] != boxVersion {
+		return nil, errors.Errorf("不支持的Box版本: %d", ciphertext[0])
+	}
+
+	ephemeralBytes := ciphertext[1 : 1+BoxKeySize]
+	ephemeral, err := ecdh.X25519().NewPublicKey(ephemeralBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "临时公钥格式不正确")
+	}
+
+	key, err := boxKey(priv, ephemeral, ephemeralBytes, priv.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+
+	return xchachaOpen(ciphertext[:1+BoxKeySize], key, ciphertext[1+BoxKeySize:])
+}
+
+// SecretSeal 使用32字节密钥加密
+func SecretSeal(plaintext, key []byte) ([]byte, error) {
+	if len(key) != BoxKeySize {
+		return nil, errors.New("密钥长度必须是32字节")
+	}
+	return xchachaSeal([]byte{boxVersion}, key, plaintext)
+}
+
+// SecretOpen 解密SecretSeal的输出
+func SecretOpen(ciphertext, key []byte) ([]byte, error) {
+	if len(key) != BoxKeySize {
+		return nil, errors.New("密钥长度必须是32字节")
+	}
+	if len(ciphertext) < 1+chacha20poly1305.NonceSizeX+chacha20poly1305.Overhead {
+		return nil, errors.New("密文长度不足")
+	}
+	if ciphertext[0] != boxVersion {
+		return nil, errors.Errorf("不支持的SecretBox版本: %d", ciphertext[0])
+	}
+	return xchachaOpen(ciphertext[:1], key, ciphertext[1:])
+}
+
+// boxKey 协商共享密钥并派生一次性加密密钥，临时公钥与接收方公钥都参与派生
+func boxKey(priv *ecdh.PrivateKey, peer *ecdh.PublicKey, ephemeralPublicKey, recipientPublicKey []byte) ([]byte, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, errors.Wrap(err, "密钥协商失败")
+	}
+	defer zeroBytes(shared)
+
+	info := append([]byte(boxInfo), ephemeralPublicKey...)
+	info = append(info, recipientPublicKey...)
+
+	key, err := hkdf.Key(sha256.New, shared, nil, string(info), chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生密钥失败")
+	}
+	return key, nil
+}
+
+// xchachaSeal 输出 header | nonce | 密文 | 标签，header作为附加认证数据
+func xchachaSeal(header, key, plaintext []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建XChaCha20-Poly1305失败")
+	}
+	nonce, err := GenerateRandomBytes(aead.NonceSize())
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(header)+len(nonce)+len(plaintext)+aead.Overhead())
+	out = append(out, header...)
+	out = append(out, nonce...)
+	return aead.Seal(out, nonce, plaintext, header), nil
+}
+
+// xchachaOpen 解密 nonce | 密文 | 标签
+func xchachaOpen(header, key, data []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建XChaCha20-Poly1305失败")
+	}
+
+	nonce := data[:aead.NonceSize()]
+	plaintext, err := aead.Open(nil, nonce, data[aead.NonceSize():], header)
+	if err != nil {
+		return nil, errors.New("解密失败")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,355 @@
+package encrypt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// 工作因子自适应校准
+//
+// 固定写死的迭代次数会随硬件升级逐渐变弱，也可能在低配容器中让登录超时。Calibrate 在当前主机上
+// 测量单次派生的耗时，返回最接近且不超过目标耗时的参数：
+//   - PBKDF2：按耗时线性外推迭代次数
+//   - argon2id：内存与并行度固定（默认与PasswordHasher一致），调整迭代次数
+//   - scrypt：r、p固定，N只能取2的幂，逐次加倍直到超过目标
+//
+// 每项取多次测量中的最小值，减少调度抖动的影响；参数不低于各算法的下限，下限本身超过目标时直接返回下限。
+// Recalibrator 定期重新校准，默认只在新参数更强时采用，避免主机繁忙时测得偏低的参数
+
+// KDFAlgorithm 可校准的密钥派生算法
+type KDFAlgorithm int
+
+// 密钥派生算法常量定义
+const (
+	KDFPBKDF2 KDFAlgorithm = iota + 1
+	KDFArgon2id
+	KDFScrypt
+)
+
+// 校准下限与默认值
+const (
+	calibrateMinPBKDF2     = 10000
+	calibrateMinScryptLogN = 10
+	calibrateMaxScryptLogN = 24
+	calibrateSamples       = 3
+	calibrateProbe         = 10 * time.Millisecond
+	calibrateSaltSize      = 16
+	calibrateKeySize       = 32
+)
+
+// KDFParams 密钥派生参数
+type KDFParams struct {
+	Algorithm  KDFAlgorithm
+	Hash       HashAlgorithm // PBKDF2
+	Iterations int           // PBKDF2
+	Time       uint32        // argon2id
+	Memory     uint32        // argon2id，单位KiB
+	Threads    uint8         // argon2id
+	N          int           // scrypt
+	R          int           // scrypt
+	P          int           // scrypt
+	Duration   time.Duration // 校准时测得的单次耗时
+}
+
+// CalibrationOptions 校准选项，零值字段使用默认值
+type CalibrationOptions struct {
+	Hash    HashAlgorithm // PBKDF2哈希算法，默认SHA-256
+	Memory  uint32        // argon2id内存，单位KiB，默认DefaultPasswordArgon2Memory
+	Threads uint8         // argon2id并行度，默认DefaultPasswordArgon2Threads
+	R       int           // scrypt块大小，默认8
+	P       int           // scrypt并行度，默认1
+	Samples int           // 每组参数的测量次数，默认3
+}
+
+// Calibrate 测量当前主机，返回单次派生耗时接近target的参数
+func Calibrate(algorithm KDFAlgorithm, target time.Duration, opts CalibrationOptions) (*KDFParams, error) {
+	if target <= 0 {
+		return nil, errors.New("目标耗时必须大于0")
+	}
+	opts = opts.withDefaults()
+
+	switch algorithm {
+	case KDFPBKDF2:
+		return calibratePBKDF2(target, opts)
+	case KDFArgon2id:
+		return calibrateArgon2id(target, opts)
+	case KDFScrypt:
+		return calibrateScrypt(target, opts)
+	default:
+		return nil, errors.New("不支持的密钥派生算法")
+	}
+}
+
+// withDefaults 填充默认值
+func (o CalibrationOptions) withDefaults() CalibrationOptions {
+	if o.Hash == 0 {
+		o.Hash = HashSHA256
+	}
+	if o.Memory == 0 {
+		o.Memory = DefaultPasswordArgon2Memory
+	}
+	if o.Threads == 0 {
+		o.Threads = DefaultPasswordArgon2Threads
+	}
+	if o.R == 0 {
+		o.R = 8
+	}
+	if o.P == 0 {
+		o.P = 1
+	}
+	if o.Samples <= 0 {
+		o.Samples = calibrateSamples
+	}
+	return o
+}
+
+// calibratePBKDF2 倍增迭代次数直到耗时可测，再按比例外推
+func calibratePBKDF2(target time.Duration, opts CalibrationOptions) (*KDFParams, error) {
+	params := &KDFParams{Algorithm: KDFPBKDF2, Hash: opts.Hash, Iterations: 1000}
+	for {
+		elapsed, err := measureKDF(params, opts.Samples)
+		if err != nil {
+			return nil, err
+		}
+		if elapsed >= calibrateProbe || elapsed >= target {
+			iterations := int(int64(params.Iterations) * int64(target) / int64(elapsed))
+			params.Iterations = max(iterations, calibrateMinPBKDF2)
+			break
+		}
+		params.Iterations *= 2
+	}
+	return params.measure(opts.Samples)
+}
+
+// calibrateArgon2id 以t=1的耗时估算迭代次数
+func calibrateArgon2id(target time.Duration, opts CalibrationOptions) (*KDFParams, error) {
+	params := &KDFParams{Algorithm: KDFArgon2id, Time: 1, Memory: opts.Memory, Threads: opts.Threads}
+	if opts.Threads < 1 || opts.Memory < 8*uint32(opts.Threads) {
+		return nil, errors.New("argon2参数不正确")
+	}
+	elapsed, err := measureKDF(params, opts.Samples)
+	if err != nil {
+		return nil, err
+	}
+	params.Time = uint32(max(int64(target)/int64(elapsed), 1))
+	return params.measure(opts.Samples)
+}
+
+// calibrateScrypt 逐次加倍N，取不超过目标的最大值
+func calibrateScrypt(target time.Duration, opts CalibrationOptions) (*KDFParams, error) {
+	params := &KDFParams{Algorithm: KDFScrypt, N: 1 << calibrateMinScryptLogN, R: opts.R, P: opts.P}
+	elapsed, err := measureKDF(params, opts.Samples)
+	if err != nil {
+		return nil, err
+	}
+	for logN := calibrateMinScryptLogN + 1; logN <= calibrateMaxScryptLogN; logN++ {
+		// 耗时与N成正比，预计超过目标时不必再实际测量
+		if elapsed*2 > target {
+			break
+		}
+		next := &KDFParams{Algorithm: KDFScrypt, N: 1 << logN, R: opts.R, P: opts.P}
+		nextElapsed, err := measureKDF(next, opts.Samples)
+		if err != nil {
+			return nil, err
+		}
+		if nextElapsed > target {
+			break
+		}
+		params, elapsed = next, nextElapsed
+	}
+	params.Duration = elapsed
+	return params, nil
+}
+
+// measure 测量参数的耗时并记录
+func (p *KDFParams) measure(samples int) (*KDFParams, error) {
+	elapsed, err := measureKDF(p, samples)
+	if err != nil {
+		return nil, err
+	}
+	p.Duration = elapsed
+	return p, nil
+}
+
+// measureKDF 多次派生取最短耗时
+func measureKDF(params *KDFParams, samples int) (time.Duration, error) {
+	password, err := GenerateRandomBytes(calibrateKeySize)
+	if err != nil {
+		return 0, err
+	}
+	salt, err := GenerateRandomBytes(calibrateSaltSize)
+	if err != nil {
+		return 0, err
+	}
+
+	var best time.Duration
+	for i := 0; i < samples; i++ {
+		start := time.Now()
+		key, err := params.Derive(password, salt, calibrateKeySize)
+		elapsed := time.Since(start)
+		if err != nil {
+			return 0, err
+		}
+		zeroBytes(key)
+		if i == 0 || elapsed < best {
+			best = elapsed
+		}
+	}
+	return max(best, time.Nanosecond), nil
+}
+
+// Derive 按参数派生密钥
+func (p *KDFParams) Derive(password, salt []byte, keyLen int) ([]byte, error) {
+	switch p.Algorithm {
+	case KDFPBKDF2:
+		if p.Iterations < 1 {
+			return nil, errors.New("迭代次数必须大于0")
+		}
+		return pbkdf2(password, salt, p.Iterations, keyLen, hashFunc(p.Hash)), nil
+	case KDFArgon2id:
+		if p.Time < 1 || p.Threads < 1 || p.Memory < 8*uint32(p.Threads) {
+			return nil, errors.New("argon2参数不正确")
+		}
+		return argon2.IDKey(password, salt, p.Time, p.Memory, p.Threads, uint32(keyLen)), nil
+	case KDFScrypt:
+		key, err := scrypt.Key(password, salt, p.N, p.R, p.P, keyLen)
+		if err != nil {
+			return nil, errors.Wrap(err, "scrypt参数不正确")
+		}
+		return key, nil
+	default:
+		return nil, errors.New("不支持的密钥派生算法")
+	}
+}
+
+// PasswordHasher 创建使用该参数生成哈希的密码哈希器，仅支持PBKDF2与argon2id
+func (p *KDFParams) PasswordHasher() (*PasswordHasher, error) {
+	switch p.Algorithm {
+	case KDFPBKDF2:
+		return NewPasswordHasher().PBKDF2(p.Hash, p.Iterations), nil
+	case KDFArgon2id:
+		return NewPasswordHasher().Argon2id(p.Time, p.Memory, p.Threads), nil
+	default:
+		return nil, errors.New("密码哈希不支持该密钥派生算法")
+	}
+}
+
+// Stronger 判断p的工作因子是否高于other，算法或固定参数不同时返回false
+func (p *KDFParams) Stronger(other *KDFParams) bool {
+	if other == nil {
+		return true
+	}
+	if p.Algorithm != other.Algorithm {
+		return false
+	}
+	switch p.Algorithm {
+	case KDFPBKDF2:
+		return p.Hash == other.Hash && p.Iterations > other.Iterations
+	case KDFArgon2id:
+		return p.Memory == other.Memory && p.Threads == other.Threads && p.Time > other.Time
+	case KDFScrypt:
+		return p.R == other.R && p.P == other.P && p.N > other.N
+	default:
+		return false
+	}
+}
+
+// Recalibrator 定期重新校准，并发安全
+type Recalibrator struct {
+	mu            sync.Mutex
+	algorithm     KDFAlgorithm
+	target        time.Duration
+	opts          CalibrationOptions
+	interval      time.Duration
+	allowDecrease bool
+	current       *KDFParams
+	onChange      func(*KDFParams)
+	onError       func(error)
+}
+
+// NewRecalibrator 创建校准器，默认每24小时重新校准一次
+func NewRecalibrator(algorithm KDFAlgorithm, target time.Duration, opts CalibrationOptions) *Recalibrator {
+	return &Recalibrator{algorithm: algorithm, target: target, opts: opts, interval: 24 * time.Hour}
+}
+
+// WithInterval 设置校准间隔
+func (r *Recalibrator) WithInterval(interval time.Duration) *Recalibrator {
+	if interval <= 0 {
+		panic("校准间隔必须大于0")
+	}
+	r.interval = interval
+	return r
+}
+
+// WithInitial 设置当前使用的参数，新参数更强时才会替换
+func (r *Recalibrator) WithInitial(params *KDFParams) *Recalibrator {
+	r.current = params
+	return r
+}
+
+// AllowDecrease 允许采用更弱的参数，用于迁移到更慢的主机
+func (r *Recalibrator) AllowDecrease() *Recalibrator {
+	r.allowDecrease = true
+	return r
+}
+
+// OnChange 设置参数变化时的回调，通常用于更新PasswordHasher，使旧哈希在登录时升级
+func (r *Recalibrator) OnChange(fn func(params *KDFParams)) *Recalibrator {
+	r.onChange = fn
+	return r
+}
+
+// OnError 设置后台校准失败时的回调
+func (r *Recalibrator) OnError(fn func(err error)) *Recalibrator {
+	r.onError = fn
+	return r
+}
+
+// Current 当前参数，尚未校准且未设置初始参数时为nil
+func (r *Recalibrator) Current() *KDFParams {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.current
+}
+
+// Recalibrate 立即校准一次，返回校准后采用的参数
+func (r *Recalibrator) Recalibrate() (*KDFParams, error) {
+	params, err := Calibrate(r.algorithm, r.target, r.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	changed := params.Stronger(r.current) || (r.allowDecrease && r.current.Stronger(params))
+	if changed {
+		r.current = params
+	}
+	current, onChange := r.current, r.onChange
+	r.mu.Unlock()
+
+	if changed && onChange != nil {
+		onChange(current)
+	}
+	return current, nil
+}
+
+// Run 立即校准一次，之后按间隔重复，直到ctx取消
+func (r *Recalibrator) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.Recalibrate(); err != nil && r.onError != nil {
+			r.onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
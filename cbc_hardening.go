@@ -0,0 +1,154 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+)
+
+// CBC填充预言机加固
+//
+// CBC解密时，如果"填充错误"与"MAC错误"在错误信息或耗时上有差异，攻击者可以逐字节解出明文。
+// HardenCBC 开启后CBC模式的解密：
+//   - 所有失败（长度、MAC、填充）统一返回 ErrDecryptionFailed
+//   - PKCS#7去填充以常量时间进行
+//   - 传入macKey时启用Encrypt-then-MAC：密文（含前置IV）后附加32字节HMAC-SHA256标签，
+//     解密时无论标签是否正确都会完成解密与去填充，再统一判断结果，MAC错误与填充错误耗时一致
+//
+// macKey必须与加密密钥不同且至少16字节。其他模式不受影响：GCM自带认证，流模式没有填充。
+// 新代码应直接使用GCM，该选项用于必须兼容既有CBC密文格式的场景
+
+// ErrDecryptionFailed 加固模式下所有解密失败统一返回的错误
+var ErrDecryptionFailed = errors.New("解密失败")
+
+// cbcHardeningTagSize Encrypt-then-MAC标签长度
+const cbcHardeningTagSize = sha256.Size
+
+// ICBCHardener 支持CBC填充预言机加固的对称算法
+type ICBCHardener interface {
+	HardenCBC(macKey []byte) ISymmetric
+}
+
+// cbcHardening 加固配置
+type cbcHardening struct {
+	enabled bool
+	macKey  []byte
+}
+
+// set 开启加固并保存MAC密钥副本
+func (h *cbcHardening) set(macKey []byte) {
+	h.reset()
+	h.enabled = true
+	if macKey != nil {
+		h.macKey = append([]byte(nil), macKey...)
+	}
+}
+
+// reset 关闭加固并清零MAC密钥
+func (h *cbcHardening) reset() {
+	zeroBytes(h.macKey)
+	h.macKey = nil
+	h.enabled = false
+}
+
+// check 检查MAC密钥
+func (h *cbcHardening) check() error {
+	if h.macKey != nil && len(h.macKey) < 16 {
+		return errors.New("CBC加固的MAC密钥长度至少16字节")
+	}
+	return nil
+}
+
+// seal 在密文后附加MAC标签
+func (h *cbcHardening) seal(ciphertext []byte) ([]byte, error) {
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+	if h.macKey == nil {
+		return ciphertext, nil
+	}
+	mac := hmac.New(sha256.New, h.macKey)
+	mac.Write(ciphertext)
+	return mac.Sum(ciphertext), nil
+}
+
+// open 校验MAC、解密并常量时间去填充，decrypt返回带填充的明文
+func (h *cbcHardening) open(data []byte, blockSize int, padding Padding, decrypt func([]byte) ([]byte, error)) ([]byte, error) {
+	if err := h.check(); err != nil {
+		return nil, err
+	}
+
+	// 长度是公开信息，不足时可以直接失败
+	body, macOK := data, 1
+	if h.macKey != nil {
+		if len(data) < cbcHardeningTagSize {
+			return nil, ErrDecryptionFailed
+		}
+		body = data[:len(data)-cbcHardeningTagSize]
+		mac := hmac.New(sha256.New, h.macKey)
+		mac.Write(body)
+		macOK = subtle.ConstantTimeCompare(mac.Sum(nil), data[len(body):])
+	}
+
+	// MAC错误时同样完成解密与去填充，避免耗时差异
+	padded, err := decrypt(body)
+	if err != nil {
+		return nil, ErrDecryptionFailed
+	}
+	var plaintext []byte
+	padOK := 1
+	if _, ok := padding.(*PKCS7Padding); ok {
+		plaintext, padOK = unpadPKCS7ConstantTime(padded, blockSize)
+	} else if plaintext, err = padding.Unpad(padded, blockSize); err != nil {
+		padOK = 0
+	}
+
+	if macOK&padOK != 1 {
+		zeroBytes(padded)
+		return nil, ErrDecryptionFailed
+	}
+	return plaintext, nil
+}
+
+// unpadPKCS7ConstantTime 常量时间去除PKCS#7填充，总是检查最后一个分组的全部字节，返回结果与是否有效（1有效）
+func unpadPKCS7ConstantTime(data []byte, blockSize int) ([]byte, int) {
+	if blockSize <= 0 || blockSize > 255 || len(data) == 0 || len(data)%blockSize != 0 {
+		return nil, 0
+	}
+	n := len(data)
+	padding := data[n-1]
+	good := subtle.ConstantTimeLessOrEq(1, int(padding)) & subtle.ConstantTimeLessOrEq(int(padding), blockSize)
+	for i := 0; i < blockSize; i++ {
+		// 位于填充范围内的字节必须等于填充长度
+		inPadding := subtle.ConstantTimeLessOrEq(i+1, int(padding))
+		good &= subtle.ConstantTimeSelect(inPadding, subtle.ConstantTimeByteEq(data[n-1-i], padding), 1)
+	}
+	// 无效时按无填充计算长度，保证切片运算不越界
+	return data[:n-subtle.ConstantTimeSelect(good, int(padding), 0)], good
+}
+
+// HardenCBC 开启CBC填充预言机加固，macKey为nil时只统一错误与常量时间去填充，非nil时同时启用Encrypt-then-MAC
+func (a *AESEncryptor) HardenCBC(macKey []byte) ISymmetric {
+	a.hardening.set(macKey)
+	return a
+}
+
+// HardenCBC 开启CBC填充预言机加固，参数见AESEncryptor.HardenCBC
+func (d *DESEncryptor) HardenCBC(macKey []byte) ISymmetric {
+	d.hardening.set(macKey)
+	return d
+}
+
+// HardenCBC 开启CBC填充预言机加固，参数见AESEncryptor.HardenCBC
+func (t *TripleDESEncryptor) HardenCBC(macKey []byte) ISymmetric {
+	t.hardening.set(macKey)
+	return t
+}
+
+// HardenCBC 开启CBC填充预言机加固，参数见AESEncryptor.HardenCBC
+func (s *SM4Encryptor) HardenCBC(macKey []byte) ISymmetric {
+	s.hardening.set(macKey)
+	return s
+}
@@ -0,0 +1,204 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+// CBC + HMAC组合认证加密（RFC 7518 5.2，JOSE的A128CBC-HS256等）
+//
+// 先CBC加密再对密文计算HMAC（Encrypt-then-MAC），实现cipher.AEAD，可以与GCM互换使用：
+//
+//	密钥 = MAC_KEY || ENC_KEY（各占一半）
+//	密文 = CBC(ENC_KEY, IV, PKCS7(明文))
+//	标签 = HMAC(MAC_KEY, AAD || IV || 密文 || AAD的比特长度(8字节大端)) 截取前半
+//
+// NewAESCBCHMAC按密钥长度选择A128CBC-HS256、A192CBC-HS384、A256CBC-HS512，可直接解密JWE的内容密文；
+// 旧系统（如.NET中常见的手写Encrypt-then-MAC）使用其他哈希、标签长度或密钥时，用NewCBCHMAC自行组合。
+// 解密先常量时间比较标签，通过后才解密与去填充，不存在填充预言
+
+// JOSE内容加密算法名称
+const (
+	JOSEA128CBCHS256 = "A128CBC-HS256"
+	JOSEA192CBCHS384 = "A192CBC-HS384"
+	JOSEA256CBCHS512 = "A256CBC-HS512"
+	JOSEA128GCM      = "A128GCM"
+	JOSEA192GCM      = "A192GCM"
+	JOSEA256GCM      = "A256GCM"
+)
+
+// cbcHMAC CBC + HMAC组合AEAD
+type cbcHMAC struct {
+	block   cipher.Block
+	macKey  []byte
+	newHash func() hash.Hash
+	tagSize int
+}
+
+// NewAESCBCHMAC 创建JOSE组合AEAD，key为32、48或64字节，分别对应A128CBC-HS256、A192CBC-HS384、A256CBC-HS512
+func NewAESCBCHMAC(key []byte) (cipher.AEAD, error) {
+	var newHash func() hash.Hash
+	switch len(key) {
+	case 32:
+		newHash = sha256.New
+	case 48:
+		newHash = sha512.New384
+	case 64:
+		newHash = sha512.New
+	default:
+		return nil, errors.New("AES-CBC-HMAC密钥长度必须是32、48或64字节")
+	}
+
+	half := len(key) / 2
+	block, err := aes.NewCipher(key[half:])
+	if err != nil {
+		return nil, errors.Wrap(err, "创建密码块失败")
+	}
+	return NewCBCHMAC(block, key[:half], newHash, half)
+}
+
+// NewCBCHMAC 使用任意分组密码、HMAC密钥与哈希组合AEAD，tagSize为截取的标签字节数
+func NewCBCHMAC(block cipher.Block, macKey []byte, newHash func() hash.Hash, tagSize int) (cipher.AEAD, error) {
+	if len(macKey) == 0 {
+		return nil, errors.New("HMAC密钥不能为空")
+	}
+	if size := newHash().Size(); tagSize < 16 || tagSize > size {
+		return nil, errors.Errorf("标签长度必须在16到%d字节之间", size)
+	}
+	return &cbcHMAC{
+		block:   block,
+		macKey:  append([]byte(nil), macKey...),
+		newHash: newHash,
+		tagSize: tagSize,
+	}, nil
+}
+
+// NewJOSEContentCipher 按JWE的enc算法名创建内容加密AEAD
+func NewJOSEContentCipher(enc string, key []byte) (cipher.AEAD, error) {
+	var size int
+	switch enc {
+	case JOSEA128GCM:
+		size = 16
+	case JOSEA192GCM:
+		size = 24
+	case JOSEA256GCM, JOSEA128CBCHS256:
+		size = 32
+	case JOSEA192CBCHS384:
+		size = 48
+	case JOSEA256CBCHS512:
+		size = 64
+	default:
+		return nil, errors.Errorf("不支持的JWE内容加密算法: %s", enc)
+	}
+	if len(key) != size {
+		return nil, errors.Errorf("%s的密钥长度必须是%d字节", enc, size)
+	}
+
+	switch enc {
+	case JOSEA128GCM, JOSEA192GCM, JOSEA256GCM:
+		return newAESGCM(key)
+	default:
+		return NewAESCBCHMAC(key)
+	}
+}
+
+// AESCBCHMACEncrypt 使用JOSE组合AEAD加密，输出格式为 IV(16字节) || 密文 || 标签
+func AESCBCHMACEncrypt(key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := NewAESCBCHMAC(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(aead, plaintext, aad)
+}
+
+// AESCBCHMACDecrypt 解密AESCBCHMACEncrypt的输出，aad必须与加密时一致
+func AESCBCHMACDecrypt(key, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := NewAESCBCHMAC(key)
+	if err != nil {
+		return nil, err
+	}
+	nonceSize := aead.NonceSize()
+	if len(ciphertext) < nonceSize+aead.Overhead() {
+		return nil, errors.New("密文太短，无法提取IV")
+	}
+	return aead.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], aad)
+}
+
+// NonceSize IV长度，等于分组长度
+func (c *cbcHMAC) NonceSize() int {
+	return c.block.BlockSize()
+}
+
+// Overhead 最大额外长度：一个分组的填充加标签
+func (c *cbcHMAC) Overhead() int {
+	return c.block.BlockSize() + c.tagSize
+}
+
+// Seal 加密并追加 密文 || 标签 到dst
+func (c *cbcHMAC) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	blockSize := c.block.BlockSize()
+	if len(nonce) != blockSize {
+		panic("encrypt: CBC-HMAC的IV长度不正确")
+	}
+
+	padding := blockSize - len(plaintext)%blockSize
+	ctLen := len(plaintext) + padding
+	ret, out := smallAppendSpace(dst, ctLen+c.tagSize)
+	copy(out, plaintext)
+	for i := len(plaintext); i < ctLen; i++ {
+		out[i] = byte(padding)
+	}
+	cipher.NewCBCEncrypter(c.block, nonce).CryptBlocks(out[:ctLen], out[:ctLen])
+	copy(out[ctLen:], c.tag(nonce, out[:ctLen], additionalData))
+	return ret
+}
+
+// Open 先验证标签，再解密并去除填充
+func (c *cbcHMAC) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	blockSize := c.block.BlockSize()
+	if len(nonce) != blockSize {
+		return nil, errors.New("CBC-HMAC的IV长度不正确")
+	}
+	ctLen := len(ciphertext) - c.tagSize
+	if ctLen < blockSize || ctLen%blockSize != 0 {
+		return nil, errors.New("CBC-HMAC解密失败")
+	}
+
+	encrypted, tag := ciphertext[:ctLen], ciphertext[ctLen:]
+	if subtle.ConstantTimeCompare(c.tag(nonce, encrypted, additionalData), tag) != 1 {
+		return nil, errors.New("CBC-HMAC解密失败，可能是数据被篡改")
+	}
+
+	ret, out := smallAppendSpace(dst, ctLen)
+	cipher.NewCBCDecrypter(c.block, nonce).CryptBlocks(out, encrypted)
+	padding := int(out[ctLen-1])
+	if padding < 1 || padding > blockSize || padding > ctLen {
+		return nil, errors.New("CBC-HMAC解密失败")
+	}
+	for _, b := range out[ctLen-padding:] {
+		if int(b) != padding {
+			return nil, errors.New("CBC-HMAC解密失败")
+		}
+	}
+	return ret[:len(ret)-padding], nil
+}
+
+// tag 计算截断的认证标签
+func (c *cbcHMAC) tag(nonce, ciphertext, additionalData []byte) []byte {
+	mac := hmac.New(c.newHash, c.macKey)
+	mac.Write(additionalData)
+	mac.Write(nonce)
+	mac.Write(ciphertext)
+	var al [8]byte
+	binary.BigEndian.PutUint64(al[:], uint64(len(additionalData))*8)
+	mac.Write(al[:])
+	return mac.Sum(nil)[:c.tagSize]
+}
@@ -0,0 +1,406 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/gmtls"
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// CFCA SM2双证书
+//
+// 国密体系下每个主体持有两张证书：签名证书的私钥由用户自己生成，加密证书的私钥由CA的密钥管理中心生成，
+// 用签名证书公钥加密后以GM/T 0009 SM2EnvelopedKey结构下发：
+//
+//	SM2EnvelopedKey ::= SEQUENCE {
+//	    symAlgID               AlgorithmIdentifier, -- SM4，通常为ECB
+//	    symEncryptedKey        SM2Cipher,           -- 用签名公钥加密的SM4密钥
+//	    sm2PublicKey           BIT STRING,          -- 加密公钥 04||X||Y
+//	    sm2EncryptedPrivateKey BIT STRING           -- SM4加密的加密私钥标量
+//	}
+//
+// CFCA工具包导出的签名证书与私钥为.sm2文件（Base64编码的DER）：
+//
+//	SEQUENCE { INTEGER 1,
+//	    SEQUENCE { data OID, SM4 OID, OCTET STRING 加密的私钥标量 },
+//	    SEQUENCE { data OID, OCTET STRING 证书DER } }
+//
+// .sm2文件的私钥以 SM3(口令) 的前16字节为SM4密钥、后16字节为IV，按CBC/PKCS#7加密
+
+// sm2EnvelopedKey GM/T 0009 SM2EnvelopedKey
+type sm2EnvelopedKey struct {
+	SymAlgID               pkix.AlgorithmIdentifier
+	SymEncryptedKey        asn1.RawValue
+	SM2PublicKey           asn1.BitString
+	SM2EncryptedPrivateKey asn1.BitString
+}
+
+// cfcaSM2File CFCA .sm2文件
+type cfcaSM2File struct {
+	Version int
+	Key     cfcaSM2Key
+	Cert    cfcaSM2Cert
+}
+
+// cfcaSM2Key .sm2文件中的加密私钥
+type cfcaSM2Key struct {
+	ContentType  asn1.ObjectIdentifier
+	Algorithm    asn1.ObjectIdentifier
+	EncryptedKey []byte
+}
+
+// cfcaSM2Cert .sm2文件中的证书
+type cfcaSM2Cert struct {
+	ContentType asn1.ObjectIdentifier
+	Certificate []byte
+}
+
+// SM2KeyPair SM2证书与私钥（PEM）
+type SM2KeyPair struct {
+	CertPEM       []byte
+	PrivateKeyPEM []byte
+	Certificate   *x509.Certificate
+}
+
+// SM2DoubleCert SM2双证书：签名证书与加密证书
+type SM2DoubleCert struct {
+	Sign    *SM2KeyPair
+	Encrypt *SM2KeyPair
+}
+
+// ParseCFCASM2File 解析CFCA导出的.sm2文件，返回签名证书与私钥，输入可以是Base64或DER
+func ParseCFCASM2File(data []byte, password string) (*SM2KeyPair, error) {
+	der, err := decodeDERorBase64(data)
+	if err != nil {
+		return nil, err
+	}
+	var file cfcaSM2File
+	if _, err := asn1.Unmarshal(der, &file); err != nil {
+		return nil, errors.Wrap(err, "解析.sm2文件失败")
+	}
+	if !file.Key.Algorithm.Equal(oidGMSM4) && !file.Key.Algorithm.Equal(oidGMSM4CBC) {
+		return nil, errors.Errorf("不支持的私钥加密算法: %s", file.Key.Algorithm)
+	}
+
+	cert, err := x509.ParseCertificate(file.Cert.Certificate)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析证书失败")
+	}
+
+	h := newSM3()
+	h.Write([]byte(password))
+	sum := h.Sum(nil)
+	defer zeroBytes(sum)
+	decryptor, err := SM4(sum[:16], WithMode(ModeCBC), WithPadding(PaddingPKCS7), WithIV(append([]byte(nil), sum[16:]...)), WithEncoding(EncodingNone))
+	if err != nil {
+		return nil, err
+	}
+	defer decryptor.Release()
+	scalar, err := decryptor.Decrypt(file.Key.EncryptedKey)
+	if err != nil {
+		return nil, errors.New("口令错误或私钥已损坏")
+	}
+	defer zeroBytes(scalar)
+
+	privateKey, err := sm2PrivateKeyFromScalar(scalar)
+	if err != nil {
+		return nil, errors.New("口令错误或私钥已损坏")
+	}
+	return newSM2KeyPair(cert, privateKey)
+}
+
+// MarshalCFCASM2File 将证书与私钥（PEM）导出为CFCA .sm2文件格式（Base64）
+func MarshalCFCASM2File(certPEM, privateKeyPEM []byte, password string) ([]byte, error) {
+	cert, privateKey, err := loadSM2KeyPair(certPEM, privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	h := newSM3()
+	h.Write([]byte(password))
+	sum := h.Sum(nil)
+	defer zeroBytes(sum)
+	encryptor, err := SM4(sum[:16], WithMode(ModeCBC), WithPadding(PaddingPKCS7), WithIV(append([]byte(nil), sum[16:]...)), WithEncoding(EncodingNone))
+	if err != nil {
+		return nil, err
+	}
+	defer encryptor.Release()
+	scalar := leftPad(privateKey.D.Bytes(), sm2CoordinateSize)
+	defer zeroBytes(scalar)
+	encryptedKey, err := encryptor.Encrypt(scalar)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密私钥失败")
+	}
+
+	der, err := asn1.Marshal(cfcaSM2File{
+		Version: 1,
+		Key:     cfcaSM2Key{ContentType: oidGMData, Algorithm: oidGMSM4, EncryptedKey: encryptedKey},
+		Cert:    cfcaSM2Cert{ContentType: oidGMData, Certificate: cert.Raw},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "编码.sm2文件失败")
+	}
+	out := make([]byte, base64.StdEncoding.EncodedLen(len(der)))
+	base64.StdEncoding.Encode(out, der)
+	return out, nil
+}
+
+// OpenSM2EnvelopedKey 使用签名私钥（PEM）解开CA下发的加密私钥，返回加密私钥PEM
+// 输入可以是Base64或DER，解出的私钥与结构中携带的加密公钥不一致时返回错误
+func OpenSM2EnvelopedKey(envelopedKey, signPrivateKeyPEM []byte) ([]byte, error) {
+	signKey, err := x509.ReadPrivateKeyFromPem(signPrivateKeyPEM, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析签名私钥失败")
+	}
+	privateKey, err := openSM2EnvelopedKey(envelopedKey, signKey)
+	if err != nil {
+		return nil, err
+	}
+	privatePEM, err := x509.WritePrivateKeyToPem(privateKey, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "编码加密私钥失败")
+	}
+	return privatePEM, nil
+}
+
+// openSM2EnvelopedKey 解开SM2EnvelopedKey
+func openSM2EnvelopedKey(envelopedKey []byte, signKey *sm2.PrivateKey) (*sm2.PrivateKey, error) {
+	der, err := decodeDERorBase64(envelopedKey)
+	if err != nil {
+		return nil, err
+	}
+	var env sm2EnvelopedKey
+	if _, err := asn1.Unmarshal(der, &env); err != nil {
+		return nil, errors.Wrap(err, "解析SM2EnvelopedKey失败")
+	}
+
+	symKey, err := decryptSM2Flexible(signKey, env.SymEncryptedKey.FullBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密对称密钥失败")
+	}
+	defer zeroBytes(symKey)
+	if len(symKey) != 16 {
+		return nil, errors.New("对称密钥长度不正确")
+	}
+
+	opts := []Option{WithPadding(PaddingNone), WithEncoding(EncodingNone)}
+	alg := env.SymAlgID
+	switch {
+	case alg.Algorithm.Equal(oidGMSM4CBC):
+		if alg.Parameters.Tag != asn1.TagOctetString || len(alg.Parameters.Bytes) != 16 {
+			return nil, errors.New("SM4-CBC缺少IV")
+		}
+		opts = append(opts, WithMode(ModeCBC), WithIV(append([]byte(nil), alg.Parameters.Bytes...)))
+	case alg.Algorithm.Equal(oidGMSM4ECB), alg.Algorithm.Equal(oidGMSM4):
+		opts = append(opts, WithMode(ModeECB))
+	default:
+		return nil, errors.Errorf("不支持的对称算法: %s", alg.Algorithm)
+	}
+	decryptor, err := SM4(symKey, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer decryptor.Release()
+	scalar, err := decryptor.Decrypt(env.SM2EncryptedPrivateKey.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密加密私钥失败")
+	}
+	defer zeroBytes(scalar)
+	// 部分实现将私钥标量补齐到64字节
+	if len(scalar) == 2*sm2CoordinateSize {
+		scalar = scalar[sm2CoordinateSize:]
+	}
+	privateKey, err := sm2PrivateKeyFromScalar(scalar)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(env.SM2PublicKey.Bytes) > 0 {
+		publicKey, err := parseSM2PublicKeyHex(hex.EncodeToString(env.SM2PublicKey.Bytes))
+		if err != nil {
+			return nil, err
+		}
+		if publicKey.X.Cmp(privateKey.X) != 0 || publicKey.Y.Cmp(privateKey.Y) != 0 {
+			return nil, errors.New("加密私钥与加密公钥不匹配")
+		}
+	}
+	return privateKey, nil
+}
+
+// SealSM2EnvelopedKey 用签名证书（PEM）的公钥封装加密私钥（PEM），生成SM2EnvelopedKey（DER）
+// 供密钥管理中心或测试环境使用，对称算法为SM4-ECB
+func SealSM2EnvelopedKey(encPrivateKeyPEM, signCertPEM []byte) ([]byte, error) {
+	encKey, err := x509.ReadPrivateKeyFromPem(encPrivateKeyPEM, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析加密私钥失败")
+	}
+	signCert, err := x509.ReadCertificateFromPem(signCertPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析签名证书失败")
+	}
+	signPub, err := sm2CertificatePublicKey(signCert)
+	if err != nil {
+		return nil, err
+	}
+
+	symKey, err := GenerateRandomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(symKey)
+	symEncryptedKey, err := signPub.EncryptAsn1(symKey, rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密对称密钥失败")
+	}
+	encryptor, err := SM4(symKey, WithMode(ModeECB), WithPadding(PaddingNone), WithEncoding(EncodingNone))
+	if err != nil {
+		return nil, err
+	}
+	defer encryptor.Release()
+	scalar := leftPad(encKey.D.Bytes(), sm2CoordinateSize)
+	defer zeroBytes(scalar)
+	encryptedScalar, err := encryptor.Encrypt(scalar)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密私钥失败")
+	}
+
+	point := append([]byte{0x04}, leftPad(encKey.X.Bytes(), sm2CoordinateSize)...)
+	point = append(point, leftPad(encKey.Y.Bytes(), sm2CoordinateSize)...)
+	return asn1.Marshal(sm2EnvelopedKey{
+		SymAlgID:               pkix.AlgorithmIdentifier{Algorithm: oidGMSM4ECB},
+		SymEncryptedKey:        asn1.RawValue{FullBytes: symEncryptedKey},
+		SM2PublicKey:           asn1.BitString{Bytes: point, BitLength: 8 * len(point)},
+		SM2EncryptedPrivateKey: asn1.BitString{Bytes: encryptedScalar, BitLength: 8 * len(encryptedScalar)},
+	})
+}
+
+// LoadSM2DoubleCert 加载双证书：签名证书与私钥、加密证书与CA下发的SM2EnvelopedKey
+// 会校验两张证书与各自私钥匹配
+func LoadSM2DoubleCert(signCertPEM, signPrivateKeyPEM, encCertPEM, envelopedKey []byte) (*SM2DoubleCert, error) {
+	signCert, signKey, err := loadSM2KeyPair(signCertPEM, signPrivateKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "加载签名证书失败")
+	}
+	encCert, err := x509.ReadCertificateFromPem(encCertPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析加密证书失败")
+	}
+	encKey, err := openSM2EnvelopedKey(envelopedKey, signKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSM2KeyMatchesCert(encCert, encKey); err != nil {
+		return nil, errors.Wrap(err, "加载加密证书失败")
+	}
+
+	sign, err := newSM2KeyPair(signCert, signKey)
+	if err != nil {
+		return nil, err
+	}
+	enc, err := newSM2KeyPair(encCert, encKey)
+	if err != nil {
+		return nil, err
+	}
+	return &SM2DoubleCert{Sign: sign, Encrypt: enc}, nil
+}
+
+// SignEncryptor 返回使用签名私钥的SM2加密器，用于签名与验签
+func (d *SM2DoubleCert) SignEncryptor() (IAsymmetric, error) {
+	return d.Sign.Encryptor()
+}
+
+// EncryptEncryptor 返回使用加密私钥的SM2加密器，用于加解密
+func (d *SM2DoubleCert) EncryptEncryptor() (IAsymmetric, error) {
+	return d.Encrypt.Encryptor()
+}
+
+// GMTLSServerConfig 使用双证书创建国密TLS服务端配置
+func (d *SM2DoubleCert) GMTLSServerConfig() (*gmtls.Config, error) {
+	return NewGMTLSServerConfig(d.Sign.CertPEM, d.Sign.PrivateKeyPEM, d.Encrypt.CertPEM, d.Encrypt.PrivateKeyPEM)
+}
+
+// Encryptor 返回设置了该私钥的SM2加密器
+func (p *SM2KeyPair) Encryptor() (IAsymmetric, error) {
+	encryptor, err := NewSM2()
+	if err != nil {
+		return nil, err
+	}
+	return encryptor.WithPrivateKey(p.PrivateKeyPEM), nil
+}
+
+// WithCFCAFile 使用CFCA .sm2文件设置私钥，同时设置对应的公钥
+func (s *SM2Encryptor) WithCFCAFile(data []byte, password string) IAsymmetric {
+	pair, err := ParseCFCASM2File(data, password)
+	if err != nil {
+		panic(fmt.Sprintf("解析.sm2文件失败: %s", err))
+	}
+	return s.WithPrivateKey(pair.PrivateKeyPEM)
+}
+
+// WithEnvelopedKey 使用签名私钥（PEM）解开CA下发的加密私钥并设置，同时设置对应的公钥
+func (s *SM2Encryptor) WithEnvelopedKey(envelopedKey, signPrivateKeyPEM []byte) IAsymmetric {
+	privatePEM, err := OpenSM2EnvelopedKey(envelopedKey, signPrivateKeyPEM)
+	if err != nil {
+		panic(fmt.Sprintf("解开加密私钥失败: %s", err))
+	}
+	return s.WithPrivateKey(privatePEM)
+}
+
+// loadSM2KeyPair 解析证书与私钥并校验匹配
+func loadSM2KeyPair(certPEM, privateKeyPEM []byte) (*x509.Certificate, *sm2.PrivateKey, error) {
+	cert, err := x509.ReadCertificateFromPem(certPEM)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "解析证书失败")
+	}
+	privateKey, err := x509.ReadPrivateKeyFromPem(privateKeyPEM, nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "解析SM2私钥失败")
+	}
+	if err := checkSM2KeyMatchesCert(cert, privateKey); err != nil {
+		return nil, nil, err
+	}
+	return cert, privateKey, nil
+}
+
+// checkSM2KeyMatchesCert 校验私钥与证书公钥匹配
+func checkSM2KeyMatchesCert(cert *x509.Certificate, privateKey *sm2.PrivateKey) error {
+	publicKey, err := sm2CertificatePublicKey(cert)
+	if err != nil {
+		return err
+	}
+	if publicKey.X.Cmp(privateKey.X) != 0 || publicKey.Y.Cmp(privateKey.Y) != 0 {
+		return errors.New("私钥与证书不匹配")
+	}
+	return nil
+}
+
+// newSM2KeyPair 构造PEM格式的证书与私钥
+func newSM2KeyPair(cert *x509.Certificate, privateKey *sm2.PrivateKey) (*SM2KeyPair, error) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	privatePEM, err := x509.WritePrivateKeyToPem(privateKey, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "编码SM2私钥失败")
+	}
+	return &SM2KeyPair{CertPEM: certPEM, PrivateKeyPEM: privatePEM, Certificate: cert}, nil
+}
+
+// decodeDERorBase64 输入以SEQUENCE开头时视为DER，否则按Base64解码
+func decodeDERorBase64(data []byte) ([]byte, error) {
+	if len(data) > 0 && data[0] == 0x30 {
+		return data, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(data), nil)))
+	if err != nil {
+		return nil, errors.New("数据既不是DER也不是Base64编码")
+	}
+	return der, nil
+}
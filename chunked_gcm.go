@@ -0,0 +1,472 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/binary"
+	"io"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 分块GCM格式（支持随机访问）
+//
+// 大文件按固定大小分块，每块独立GCM加密，可以只解密任意区间，用于对加密视频响应HTTP Range请求：
+//
+//	magic(4) | version(1) | algorithm(1) | chunkSize(4) | salt(16) | 块0 | 块1 | ... | 最后一块
+//	块 = 密文(chunkSize字节，最后一块可更短) | 标签(16)
+//
+// 每个文件由主密钥与随机盐经HKDF-SHA256派生独立的文件密钥，nonce由块序号确定：
+// 序号(8字节大端) | 0x000000 | 末块标志(1)。末块标志防止在块边界截断，头部作为每块的附加认证数据，
+// 防止修改分块大小或算法。明文长度由密文长度推算，无需预先知道。
+// ChunkedReader 实现io.ReadSeeker与io.ReaderAt，可直接交给http.ServeContent。
+// 随机访问只验证被读取的块，需要在处理任何明文之前确认整个文件未被篡改时，
+// 使用OpenChunkedReaderVerified或ChunkedDecryptVerified（两遍：先验证全部标签，再解密）
+
+// ChunkedDefaultSize 默认分块大小
+const ChunkedDefaultSize = 64 * 1024
+
+// 分块格式常量
+const (
+	chunkedVersion    = 1
+	chunkedHeaderSize = 4 + 1 + 1 + 4 + 16
+	chunkedTagSize    = 16
+	chunkedMaxSize    = 16 * 1024 * 1024
+	chunkedInfo       = "sylphbyte/encrypt chunked v1"
+)
+
+// chunkedMagic 分块格式魔数
+var chunkedMagic = []byte("SCHK")
+
+// ChunkedWriter 分块加密写入器，必须调用Close写出最后一块
+type ChunkedWriter struct {
+	w         io.Writer
+	aead      cipher.AEAD
+	header    []byte
+	chunkSize int
+	buf       []byte
+	index     uint64
+	closed    bool
+	err       error
+}
+
+// NewChunkedWriter 创建分块加密写入器，algorithm为AlgorithmAES或AlgorithmSM4，chunkSize为0时使用默认值
+func NewChunkedWriter(w io.Writer, algorithm Algorithm, key []byte, chunkSize int) (*ChunkedWriter, error) {
+	if chunkSize == 0 {
+		chunkSize = ChunkedDefaultSize
+	}
+	if chunkSize < 1 || chunkSize > chunkedMaxSize {
+		return nil, errors.New("分块大小必须在1字节到16MiB之间")
+	}
+
+	salt, err := GenerateRandomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	header := make([]byte, 0, chunkedHeaderSize)
+	header = append(header, chunkedMagic...)
+	header = append(header, chunkedVersion, byte(algorithm))
+	header = binary.BigEndian.AppendUint32(header, uint32(chunkSize))
+	header = append(header, salt...)
+
+	aead, err := chunkedAEAD(algorithm, key, salt)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header); err != nil {
+		return nil, errors.Wrap(err, "写入头部失败")
+	}
+
+	return &ChunkedWriter{
+		w:         w,
+		aead:      aead,
+		header:    header,
+		chunkSize: chunkSize,
+		buf:       make([]byte, 0, chunkSize),
+	}, nil
+}
+
+// Write 写入明文，满一块后加密输出（保留最后一块直到Close）
+func (c *ChunkedWriter) Write(p []byte) (int, error) {
+	if c.closed {
+		return 0, errors.New("写入器已关闭")
+	}
+	if c.err != nil {
+		return 0, c.err
+	}
+
+	n := 0
+	for len(p) > 0 {
+		// 缓冲区已满且还有后续数据，说明当前块不是最后一块
+		if len(c.buf) == c.chunkSize {
+			if err := c.flush(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(c.buf[len(c.buf):c.chunkSize], p)
+		c.buf = c.buf[:len(c.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+// Close 加密并写出最后一块，不关闭底层写入器
+func (c *ChunkedWriter) Close() error {
+	if c.closed {
+		return nil
+	}
+	if c.err != nil {
+		return c.err
+	}
+	err := c.flush(true)
+	c.closed = true
+	return err
+}
+
+// flush 加密并写出缓冲区中的一块
+func (c *ChunkedWriter) flush(last bool) error {
+	if c.index == 1<<64-1 {
+		c.err = errors.New("分块数量超出限制")
+		return c.err
+	}
+	out := c.aead.Seal(nil, chunkedNonce(c.index, last), c.buf, c.header)
+	if _, err := c.w.Write(out); err != nil {
+		c.err = errors.Wrap(err, "写入分块失败")
+		return c.err
+	}
+	zeroBytes(c.buf)
+	c.buf = c.buf[:0]
+	c.index++
+	return nil
+}
+
+// ChunkedEncrypt 一次性分块加密
+func ChunkedEncrypt(algorithm Algorithm, key, plaintext []byte, chunkSize int) ([]byte, error) {
+	var out bytes.Buffer
+	w, err := NewChunkedWriter(&out, algorithm, key, chunkSize)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(plaintext); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// ChunkedDecrypt 一次性解密分块密文
+func ChunkedDecrypt(key, ciphertext []byte) ([]byte, error) {
+	r, err := OpenChunkedReader(bytes.NewReader(ciphertext), int64(len(ciphertext)), key)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, r.Size())
+	if _, err := r.ReadAt(out, 0); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ChunkedReader 分块密文随机访问读取器
+type ChunkedReader struct {
+	r         io.ReaderAt
+	aead      cipher.AEAD
+	header    []byte
+	chunkSize int64
+	chunks    int64
+	size      int64
+
+	mu          sync.Mutex
+	offset      int64
+	cachedIndex int64
+	cached      []byte
+}
+
+// OpenChunkedReader 打开分块密文，size为密文总长度
+func OpenChunkedReader(r io.ReaderAt, size int64, key []byte) (*ChunkedReader, error) {
+	if size < chunkedHeaderSize+chunkedTagSize {
+		return nil, errors.New("分块密文长度不足")
+	}
+
+	header := make([]byte, chunkedHeaderSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		return nil, errors.Wrap(err, "读取头部失败")
+	}
+	if !bytes.Equal(header[:4], chunkedMagic) {
+		return nil, errors.New("不是分块密文格式")
+	}
+	if header[4] != chunkedVersion {
+		return nil, errors.Errorf("不支持的分块格式版本: %d", header[4])
+	}
+	chunkSize := int64(binary.BigEndian.Uint32(header[6:10]))
+	if chunkSize < 1 || chunkSize > chunkedMaxSize {
+		return nil, errors.New("分块大小不正确")
+	}
+
+	aead, err := chunkedAEAD(Algorithm(header[5]), key, header[10:])
+	if err != nil {
+		return nil, err
+	}
+
+	// 由密文长度推算块数与明文长度，最后一块至少包含标签
+	body := size - chunkedHeaderSize
+	sealedChunk := chunkSize + chunkedTagSize
+	chunks := (body + sealedChunk - 1) / sealedChunk
+	lastSealed := body - (chunks-1)*sealedChunk
+	if lastSealed < chunkedTagSize {
+		return nil, errors.New("分块密文长度不正确")
+	}
+
+	return &ChunkedReader{
+		r:           r,
+		aead:        aead,
+		header:      header,
+		chunkSize:   chunkSize,
+		chunks:      chunks,
+		size:        (chunks-1)*chunkSize + lastSealed - chunkedTagSize,
+		cachedIndex: -1,
+	}, nil
+}
+
+// Size 明文总长度
+func (c *ChunkedReader) Size() int64 {
+	return c.size
+}
+
+// ReadAt 解密任意区间，只读取并验证覆盖该区间的块
+func (c *ChunkedReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("偏移量不能为负数")
+	}
+	if off >= c.size {
+		return 0, io.EOF
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for n < len(p) && off < c.size {
+		index := off / c.chunkSize
+		chunk, err := c.chunk(index)
+		if err != nil {
+			return n, err
+		}
+		m := copy(p[n:], chunk[off-index*c.chunkSize:])
+		n += m
+		off += int64(m)
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// Read 从当前位置顺序读取
+func (c *ChunkedReader) Read(p []byte) (int, error) {
+	c.mu.Lock()
+	off := c.offset
+	c.mu.Unlock()
+
+	n, err := c.ReadAt(p, off)
+	c.mu.Lock()
+	c.offset = off + int64(n)
+	c.mu.Unlock()
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+// Seek 设置读取位置
+func (c *ChunkedReader) Seek(offset int64, whence int) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += c.offset
+	case io.SeekEnd:
+		offset += c.size
+	default:
+		return 0, errors.New("不支持的whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("偏移量不能为负数")
+	}
+	c.offset = offset
+	return offset, nil
+}
+
+// chunk 读取并解密指定块，缓存最近一块以加速顺序读取
+func (c *ChunkedReader) chunk(index int64) ([]byte, error) {
+	if index == c.cachedIndex {
+		return c.cached, nil
+	}
+
+	plaintext, err := c.openChunk(index, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	zeroBytes(c.cached)
+	c.cachedIndex = index
+	c.cached = plaintext
+	return plaintext, nil
+}
+
+// openChunk 读取并解密指定块，buf容量足够时复用
+func (c *ChunkedReader) openChunk(index int64, buf []byte) ([]byte, error) {
+	sealedChunk := c.chunkSize + chunkedTagSize
+	start := chunkedHeaderSize + index*sealedChunk
+	length := sealedChunk
+	last := index == c.chunks-1
+	if last {
+		length = c.size - index*c.chunkSize + chunkedTagSize
+	}
+
+	var sealed []byte
+	if int64(cap(buf)) >= length {
+		sealed = buf[:length]
+	} else {
+		sealed = make([]byte, length)
+	}
+	if _, err := c.r.ReadAt(sealed, start); err != nil && err != io.EOF {
+		return nil, errors.Wrap(err, "读取分块失败")
+	}
+	plaintext, err := c.aead.Open(sealed[:0], chunkedNonce(uint64(index), last), sealed, c.header)
+	if err != nil {
+		return nil, errors.Errorf("分块%d解密失败，数据被篡改或截断", index)
+	}
+	return plaintext, nil
+}
+
+// Verify 验证全部分块的认证标签，不输出任何明文
+// 调用方在处理明文前先Verify，可以避免对部分被篡改的数据采取行动（例如导入了前半个文件）
+func (c *ChunkedReader) Verify() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	buf := make([]byte, c.chunkSize+chunkedTagSize)
+	defer zeroBytes(buf)
+	for index := int64(0); index < c.chunks; index++ {
+		plaintext, err := c.openChunk(index, buf)
+		if err != nil {
+			return err
+		}
+		zeroBytes(plaintext)
+	}
+	return nil
+}
+
+// OpenChunkedReaderVerified 打开分块密文并先验证全部分块，验证通过后才返回读取器
+func OpenChunkedReaderVerified(r io.ReaderAt, size int64, key []byte) (*ChunkedReader, error) {
+	reader, err := OpenChunkedReader(r, size, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := reader.Verify(); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// ChunkedDecryptVerified 两遍解密：第一遍验证全部分块，全部通过后第二遍把明文写入w，
+// 被篡改的密文不会输出任何明文。两遍之间src被修改时，第二遍仍会逐块验证并返回错误，
+// 但此时已写出部分明文，因此src应当是调用方独占的文件
+func ChunkedDecryptVerified(w io.Writer, src io.ReadSeeker, key []byte) (int64, error) {
+	size, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return 0, errors.Wrap(err, "获取密文长度失败")
+	}
+	readerAt, ok := src.(io.ReaderAt)
+	if !ok {
+		readerAt = &seekReaderAt{rs: src}
+	}
+
+	reader, err := OpenChunkedReaderVerified(readerAt, size, key)
+	if err != nil {
+		return 0, err
+	}
+
+	var written int64
+	buf := make([]byte, reader.chunkSize+chunkedTagSize)
+	defer zeroBytes(buf)
+	for index := int64(0); index < reader.chunks; index++ {
+		plaintext, err := reader.openChunk(index, buf)
+		if err != nil {
+			return written, err
+		}
+		n, err := w.Write(plaintext)
+		written += int64(n)
+		zeroBytes(plaintext)
+		if err != nil {
+			return written, errors.Wrap(err, "写入明文失败")
+		}
+	}
+	return written, nil
+}
+
+// seekReaderAt 用Seek与Read为io.ReadSeeker实现io.ReaderAt
+type seekReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+// ReadAt 定位后读取
+func (s *seekReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(s.rs, p)
+}
+
+// chunkedNonce nonce = 序号(8) | 0x000000 | 末块标志(1)
+func chunkedNonce(index uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce, index)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// chunkedAEAD 由主密钥与盐派生文件密钥并创建GCM实例
+func chunkedAEAD(algorithm Algorithm, key, salt []byte) (cipher.AEAD, error) {
+	var keyLen int
+	switch algorithm {
+	case AlgorithmAES:
+		if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+			return nil, errors.New("AES密钥长度必须是16、24或32字节")
+		}
+		keyLen = len(key)
+	case AlgorithmSM4:
+		if len(key) != 16 {
+			return nil, errors.New("SM4密钥长度必须是16字节")
+		}
+		keyLen = 16
+	default:
+		return nil, errors.New("分块格式仅支持AES与SM4")
+	}
+
+	fileKey, err := hkdf.Key(sha256.New, key, salt, chunkedInfo, keyLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生文件密钥失败")
+	}
+	defer zeroBytes(fileKey)
+
+	if algorithm == AlgorithmSM4 {
+		return newSM4GCM(fileKey)
+	}
+	return newAESGCM(fileKey)
+}
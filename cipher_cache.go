@@ -0,0 +1,268 @@
+package encrypt
+
+import (
+	"container/list"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 密码实例缓存
+//
+// 多租户场景下频繁在上千把密钥之间切换，每次重新创建cipher.Block/GCM会重复密钥扩展。
+// CipherCache 以密钥指纹为键缓存已创建的实例，按LRU淘汰并支持TTL过期：
+//   - 指纹为 HMAC-SHA256(缓存随机密钥, 用途 || 算法 || 密钥)，缓存中不保存原始密钥，
+//     指纹也无法在缓存外被用来验证密钥猜测
+//   - 淘汰或过期时清零缓存持有的指纹并解除实例引用；标准库实例内部的轮密钥无法从外部清零，
+//     由GC回收
+//   - 缓存的实例均可并发使用
+
+// cipherCacheUsage 缓存条目用途
+type cipherCacheUsage byte
+
+// 缓存条目用途常量
+const (
+	cipherCacheBlock cipherCacheUsage = iota + 1
+	cipherCacheAEAD
+)
+
+// CipherCacheStats 缓存统计
+type CipherCacheStats struct {
+	Size      int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// cipherCacheEntry 缓存条目
+type cipherCacheEntry struct {
+	id      string
+	block   cipher.Block
+	aead    cipher.AEAD
+	expires time.Time
+}
+
+// CipherCache 按密钥指纹寻址的LRU密码实例缓存
+type CipherCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	clock    func() time.Time
+	macKey   []byte
+	entries  map[string]*list.Element
+	lru      *list.List
+	stats    CipherCacheStats
+}
+
+// NewCipherCache 创建缓存，capacity为最大条目数，ttl为0表示不过期
+func NewCipherCache(capacity int, ttl time.Duration) (*CipherCache, error) {
+	if capacity <= 0 {
+		return nil, errors.New("缓存容量必须大于0")
+	}
+	macKey, err := GenerateRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	return &CipherCache{
+		capacity: capacity,
+		ttl:      ttl,
+		clock:    time.Now,
+		macKey:   macKey,
+		entries:  make(map[string]*list.Element, capacity),
+		lru:      list.New(),
+	}, nil
+}
+
+// WithClock 设置时钟（主要用于测试）
+func (c *CipherCache) WithClock(clock func() time.Time) *CipherCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+	return c
+}
+
+// Block 获取分组密码实例，支持AES、DES、3DES与SM4
+func (c *CipherCache) Block(algorithm Algorithm, key []byte) (cipher.Block, error) {
+	entry, err := c.get(cipherCacheBlock, algorithm, key, func() (*cipherCacheEntry, error) {
+		block, err := newCachedBlock(algorithm, key)
+		if err != nil {
+			return nil, err
+		}
+		return &cipherCacheEntry{block: block}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.block, nil
+}
+
+// AEAD 获取GCM实例，支持AES与SM4
+func (c *CipherCache) AEAD(algorithm Algorithm, key []byte) (cipher.AEAD, error) {
+	entry, err := c.get(cipherCacheAEAD, algorithm, key, func() (*cipherCacheEntry, error) {
+		var aead cipher.AEAD
+		var err error
+		switch algorithm {
+		case AlgorithmAES:
+			aead, err = newAESGCM(key)
+		case AlgorithmSM4:
+			aead, err = newSM4GCM(key)
+		default:
+			return nil, errors.New("AEAD仅支持AES与SM4")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return &cipherCacheEntry{aead: aead}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry.aead, nil
+}
+
+// Seal 使用缓存的GCM实例加密，输出格式与AESGCMEncrypt/SM4GCMEncrypt一致
+func (c *CipherCache) Seal(algorithm Algorithm, key, plaintext, aad []byte) ([]byte, error) {
+	aead, err := c.AEAD(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(aead, plaintext, aad)
+}
+
+// Open 使用缓存的GCM实例解密
+func (c *CipherCache) Open(algorithm Algorithm, key, ciphertext, aad []byte) ([]byte, error) {
+	aead, err := c.AEAD(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(aead, ciphertext, aad)
+}
+
+// Remove 移除某把密钥的所有缓存实例，密钥轮换或撤销时调用
+func (c *CipherCache) Remove(algorithm Algorithm, key []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, usage := range []cipherCacheUsage{cipherCacheBlock, cipherCacheAEAD} {
+		if elem, ok := c.entries[c.fingerprint(usage, algorithm, key)]; ok {
+			c.evict(elem)
+		}
+	}
+}
+
+// Cleanup 清除所有已过期条目，可由定时任务调用
+func (c *CipherCache) Cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if c.expired(elem.Value.(*cipherCacheEntry), now) {
+			c.evict(elem)
+		}
+		elem = prev
+	}
+}
+
+// Purge 清空缓存
+func (c *CipherCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
+
+// Stats 获取缓存统计
+func (c *CipherCache) Stats() CipherCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// get 查找缓存，未命中时调用create创建并插入
+func (c *CipherCache) get(usage cipherCacheUsage, algorithm Algorithm, key []byte, create func() (*cipherCacheEntry, error)) (*cipherCacheEntry, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := c.fingerprint(usage, algorithm, key)
+	now := c.clock()
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*cipherCacheEntry)
+		if !c.expired(entry, now) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			return entry, nil
+		}
+		c.evict(elem)
+	}
+	c.stats.Misses++
+
+	entry, err := create()
+	if err != nil {
+		return nil, err
+	}
+	entry.id = id
+	if c.ttl > 0 {
+		entry.expires = now.Add(c.ttl)
+	}
+
+	for c.lru.Len() >= c.capacity {
+		c.evict(c.lru.Back())
+	}
+	c.entries[id] = c.lru.PushFront(entry)
+	return entry, nil
+}
+
+// expired 判断条目是否过期
+func (c *CipherCache) expired(entry *cipherCacheEntry, now time.Time) bool {
+	return !entry.expires.IsZero() && !now.Before(entry.expires)
+}
+
+// evict 移除条目并清理
+func (c *CipherCache) evict(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*cipherCacheEntry)
+	delete(c.entries, entry.id)
+	entry.block = nil
+	entry.aead = nil
+	entry.id = ""
+	c.stats.Evictions++
+}
+
+// fingerprint 计算缓存键
+func (c *CipherCache) fingerprint(usage cipherCacheUsage, algorithm Algorithm, key []byte) string {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write([]byte{byte(usage), byte(algorithm)})
+	mac.Write(key)
+	return string(mac.Sum(nil))
+}
+
+// newCachedBlock 创建分组密码实例
+func newCachedBlock(algorithm Algorithm, key []byte) (cipher.Block, error) {
+	var block cipher.Block
+	var err error
+	switch algorithm {
+	case AlgorithmAES:
+		block, err = aes.NewCipher(key)
+	case AlgorithmDES:
+		block, err = des.NewCipher(key)
+	case Algorithm3DES:
+		return newTripleDESBlock(key)
+	case AlgorithmSM4:
+		block, err = newSM4Cipher(key)
+	default:
+		return nil, errors.New("不支持的分组密码算法")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "创建密码块失败")
+	}
+	return block, nil
+}
@@ -0,0 +1,293 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 带超时的承诺-公开流程
+//
+// 多人游戏的开局随机数（发牌种子、掷骰结果）不能由任何一方单独决定。CommitReveal 把一轮分为两个阶段：
+//   - 承诺阶段（commitTTL内）：各参与方提交对自己随机值的承诺（Committer，域为"commit-reveal:"+轮次）
+//   - 公开阶段（随后的revealTTL内）：各参与方公开随机值与承诺随机数，与承诺不符的公开被拒绝
+//
+// 承诺阶段结束前不接受公开，避免后提交的一方参照他人的值；公开阶段结束或全部参与方公开后得到结果：
+//
+//	randomness = SHA-256("sylphbyte/encrypt commit-reveal v1" || len(round) || round ||
+//	                     按参与方排序的 len(id) || id || len(value) || value || len(beta) || beta)
+//
+// 超时未公开的参与方列入Forfeited，不参与计算，由业务决定判负或重开。仅靠参与方随机值时，
+// 最后一个公开的一方可以通过放弃公开影响结果；WithVRF让服务端用Ed25519密钥对全部承诺计算VRF输出beta，
+// 服务端在承诺阶段结束前无法预知beta，之后也无法更改，任何人可用公开结果与服务端公钥复核
+
+// commitRevealLabel 结果哈希的固定前缀
+const commitRevealLabel = "sylphbyte/encrypt commit-reveal v1"
+
+// 承诺-公开流程的错误，可通过errors.Is判断
+var (
+	ErrCommitRevealPhase    = errors.New("当前阶段不接受该操作")
+	ErrCommitRevealMismatch = errors.New("公开的值与承诺不符")
+)
+
+// CommitRevealPhase 流程阶段
+type CommitRevealPhase int
+
+// 流程阶段常量定义
+const (
+	PhaseCommit CommitRevealPhase = iota + 1
+	PhaseReveal
+	PhaseClosed
+)
+
+// String 阶段名称
+func (p CommitRevealPhase) String() string {
+	switch p {
+	case PhaseCommit:
+		return "commit"
+	case PhaseReveal:
+		return "reveal"
+	case PhaseClosed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// CommitRevealOpening 参与方公开的值与承诺随机数
+type CommitRevealOpening struct {
+	Value      []byte `json:"value"`
+	Randomness []byte `json:"randomness"`
+}
+
+// CommitRevealResult 一轮的结果，包含复核所需的全部数据
+type CommitRevealResult struct {
+	Round       string                         `json:"round"`
+	Randomness  []byte                         `json:"randomness"`
+	Commitments map[string][]byte              `json:"commitments"`
+	Openings    map[string]CommitRevealOpening `json:"openings"`
+	Forfeited   []string                       `json:"forfeited,omitempty"` // 已承诺但未按时公开，已排序
+	VRFProof    []byte                         `json:"vrf_proof,omitempty"`
+}
+
+// CommitReveal 一轮承诺-公开流程，并发安全
+type CommitReveal struct {
+	mu          sync.Mutex
+	round       string
+	committer   *Committer
+	commitTTL   time.Duration
+	revealTTL   time.Duration
+	start       time.Time
+	clock       func() time.Time
+	vrfKey      ed25519.PrivateKey
+	commitments map[string][]byte
+	openings    map[string]CommitRevealOpening
+}
+
+// NewCommitReveal 创建一轮流程，从创建时开始计时
+func NewCommitReveal(round string, commitTTL, revealTTL time.Duration) *CommitReveal {
+	if round == "" {
+		panic("轮次不能为空")
+	}
+	if commitTTL <= 0 || revealTTL <= 0 {
+		panic("承诺与公开阶段的时长必须大于0")
+	}
+	return &CommitReveal{
+		round:       round,
+		committer:   NewCommitter("commit-reveal:" + round),
+		commitTTL:   commitTTL,
+		revealTTL:   revealTTL,
+		start:       time.Now(),
+		clock:       time.Now,
+		commitments: make(map[string][]byte),
+		openings:    make(map[string]CommitRevealOpening),
+	}
+}
+
+// WithClock 设置时钟并以新时钟重新开始计时，主要用于测试
+func (c *CommitReveal) WithClock(now func() time.Time) *CommitReveal {
+	c.clock = now
+	c.start = now()
+	return c
+}
+
+// WithVRF 由服务端以Ed25519私钥对全部承诺计算VRF输出并计入结果
+func (c *CommitReveal) WithVRF(privateKey ed25519.PrivateKey) *CommitReveal {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		panic("Ed25519私钥长度不正确")
+	}
+	c.vrfKey = privateKey
+	return c
+}
+
+// Committer 本轮使用的承诺生成器，参与方用它生成承诺
+func (c *CommitReveal) Committer() *Committer {
+	return c.committer
+}
+
+// Phase 当前阶段
+func (c *CommitReveal) Phase() CommitRevealPhase {
+	return c.phaseAt(c.clock())
+}
+
+// CommitDeadline 承诺阶段截止时间
+func (c *CommitReveal) CommitDeadline() time.Time {
+	return c.start.Add(c.commitTTL)
+}
+
+// RevealDeadline 公开阶段截止时间
+func (c *CommitReveal) RevealDeadline() time.Time {
+	return c.CommitDeadline().Add(c.revealTTL)
+}
+
+// Commit 登记参与方的承诺，每个参与方只能承诺一次
+func (c *CommitReveal) Commit(participant string, commitment []byte) error {
+	if participant == "" {
+		return errors.New("参与方不能为空")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if phase := c.phaseAt(c.clock()); phase != PhaseCommit {
+		return errors.Wrapf(ErrCommitRevealPhase, "承诺阶段已结束，当前阶段: %s", phase)
+	}
+	if _, ok := c.commitments[participant]; ok {
+		return errors.Errorf("参与方%s已提交承诺", participant)
+	}
+	c.commitments[participant] = bytes.Clone(commitment)
+	return nil
+}
+
+// Reveal 公开参与方的值与承诺随机数，仅在公开阶段接受
+func (c *CommitReveal) Reveal(participant string, value, randomness []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if phase := c.phaseAt(c.clock()); phase != PhaseReveal {
+		return errors.Wrapf(ErrCommitRevealPhase, "不在公开阶段，当前阶段: %s", phase)
+	}
+	commitment, ok := c.commitments[participant]
+	if !ok {
+		return errors.Errorf("参与方%s未提交承诺", participant)
+	}
+	if _, ok := c.openings[participant]; ok {
+		return errors.Errorf("参与方%s已公开", participant)
+	}
+	if !c.committer.Verify(commitment, value, randomness) {
+		return errors.Wrapf(ErrCommitRevealMismatch, "参与方%s", participant)
+	}
+	c.openings[participant] = CommitRevealOpening{Value: bytes.Clone(value), Randomness: bytes.Clone(randomness)}
+	return nil
+}
+
+// Result 计算本轮结果，公开阶段结束或全部参与方已公开后可用
+func (c *CommitReveal) Result() (*CommitRevealResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	phase := c.phaseAt(c.clock())
+	allRevealed := phase == PhaseReveal && len(c.openings) == len(c.commitments)
+	if phase != PhaseClosed && !allRevealed {
+		return nil, errors.Wrapf(ErrCommitRevealPhase, "仍有参与方未公开，当前阶段: %s", phase)
+	}
+
+	result := &CommitRevealResult{
+		Round:       c.round,
+		Commitments: make(map[string][]byte, len(c.commitments)),
+		Openings:    make(map[string]CommitRevealOpening, len(c.openings)),
+	}
+	for _, participant := range sortedKeys(c.commitments) {
+		result.Commitments[participant] = bytes.Clone(c.commitments[participant])
+		opening, ok := c.openings[participant]
+		if !ok {
+			result.Forfeited = append(result.Forfeited, participant)
+			continue
+		}
+		result.Openings[participant] = opening
+	}
+
+	var beta []byte
+	if c.vrfKey != nil {
+		proof, output, err := VRFProve(c.vrfKey, commitRevealVRFInput(c.round, result.Commitments))
+		if err != nil {
+			return nil, err
+		}
+		result.VRFProof, beta = proof, output
+	}
+	if len(result.Openings) == 0 && beta == nil {
+		return nil, errors.New("没有参与方公开且未启用VRF，无法生成随机数")
+	}
+	result.Randomness = commitRevealRandomness(c.round, result.Openings, beta)
+	return result, nil
+}
+
+// phaseAt 根据时间判断阶段
+func (c *CommitReveal) phaseAt(now time.Time) CommitRevealPhase {
+	switch {
+	case now.Before(c.CommitDeadline()):
+		return PhaseCommit
+	case now.Before(c.RevealDeadline()):
+		return PhaseReveal
+	default:
+		return PhaseClosed
+	}
+}
+
+// VerifyCommitRevealResult 复核结果：各公开与承诺一致、VRF证明有效、随机数可以重新算出
+// vrfPublicKey为服务端VRF公钥，结果未使用VRF时传nil
+func VerifyCommitRevealResult(result *CommitRevealResult, vrfPublicKey ed25519.PublicKey) error {
+	committer := NewCommitter("commit-reveal:" + result.Round)
+	for participant, opening := range result.Openings {
+		commitment, ok := result.Commitments[participant]
+		if !ok {
+			return errors.Errorf("参与方%s未提交承诺", participant)
+		}
+		if !committer.Verify(commitment, opening.Value, opening.Randomness) {
+			return errors.Wrapf(ErrCommitRevealMismatch, "参与方%s", participant)
+		}
+	}
+
+	var beta []byte
+	switch {
+	case result.VRFProof != nil && vrfPublicKey != nil:
+		output, err := VRFVerify(vrfPublicKey, commitRevealVRFInput(result.Round, result.Commitments), result.VRFProof)
+		if err != nil {
+			return err
+		}
+		beta = output
+	case result.VRFProof != nil || vrfPublicKey != nil:
+		return errors.New("VRF证明与公钥必须同时提供")
+	}
+
+	if !bytes.Equal(commitRevealRandomness(result.Round, result.Openings, beta), result.Randomness) {
+		return errors.New("随机数与公开数据不符")
+	}
+	return nil
+}
+
+// commitRevealVRFInput VRF输入：轮次与按参与方排序的全部承诺
+func commitRevealVRFInput(round string, commitments map[string][]byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(commitRevealLabel))
+	writeLengthPrefixed(h, []byte(round))
+	for _, participant := range sortedKeys(commitments) {
+		writeLengthPrefixed(h, []byte(participant), commitments[participant])
+	}
+	return h.Sum(nil)
+}
+
+// commitRevealRandomness 由公开的值与VRF输出计算随机数
+func commitRevealRandomness(round string, openings map[string]CommitRevealOpening, beta []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte(commitRevealLabel))
+	writeLengthPrefixed(h, []byte(round))
+	for _, participant := range sortedKeys(openings) {
+		writeLengthPrefixed(h, []byte(participant), openings[participant].Value)
+	}
+	writeLengthPrefixed(h, beta)
+	return h.Sum(nil)
+}
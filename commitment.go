@@ -0,0 +1,102 @@
+package encrypt
+
+import (
+	"crypto/subtle"
+	"encoding/binary"
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+// 哈希承诺
+//
+// 用于密封拍卖等“先承诺、后公开”的场景：出价阶段只公开承诺值，开标时公开出价与随机数供所有人验证。
+//
+//	commitment = H("sylphbyte/encrypt commitment v1" || len(domain) || domain || len(r) || r || len(value) || value)
+//
+// 随机数r至少32字节且每次承诺必须重新生成，保证承诺不泄露出价（隐藏性）；哈希的抗碰撞性保证无法换一个出价
+// 打开同一个承诺（绑定性）。domain用于区分业务与场次（如"auction:2025-001"），
+// 一个场次的承诺不能在另一个场次中使用。验证使用常量时间比较
+
+// CommitmentRandomnessSize 承诺随机数的最小长度
+const CommitmentRandomnessSize = 32
+
+// commitmentLabel 承诺哈希的固定前缀
+const commitmentLabel = "sylphbyte/encrypt commitment v1"
+
+// Committer 带域分离的承诺生成器
+type Committer struct {
+	domain   string
+	hashAlgo HashAlgorithm
+}
+
+// NewCommitter 创建承诺生成器，domain为业务域，默认使用SHA-256
+func NewCommitter(domain string) *Committer {
+	return &Committer{domain: domain, hashAlgo: HashSHA256}
+}
+
+// SHA256 使用SHA-256
+func (c *Committer) SHA256() *Committer {
+	c.hashAlgo = HashSHA256
+	return c
+}
+
+// SM3 使用SM3
+func (c *Committer) SM3() *Committer {
+	c.hashAlgo = HashSM3
+	return c
+}
+
+// Commit 计算承诺，randomness至少32字节
+func (c *Committer) Commit(value, randomness []byte) ([]byte, error) {
+	if len(randomness) < CommitmentRandomnessSize {
+		return nil, errors.Errorf("承诺随机数至少需要%d字节", CommitmentRandomnessSize)
+	}
+
+	h := hashFunc(c.hashAlgo)()
+	h.Write([]byte(commitmentLabel))
+	writeLengthPrefixed(h, []byte(c.domain), randomness, value)
+	return h.Sum(nil), nil
+}
+
+// CommitRandom 生成随机数并计算承诺，随机数需要由承诺方保存到公开阶段
+func (c *Committer) CommitRandom(value []byte) (commitment, randomness []byte, err error) {
+	randomness, err = GenerateRandomBytes(CommitmentRandomnessSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	commitment, err = c.Commit(value, randomness)
+	if err != nil {
+		return nil, nil, err
+	}
+	return commitment, randomness, nil
+}
+
+// Verify 常量时间验证公开的值与随机数是否与承诺一致
+func (c *Committer) Verify(commitment, value, randomness []byte) bool {
+	expected, err := c.Commit(value, randomness)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected, commitment) == 1
+}
+
+// Commit 使用默认域计算SHA-256承诺
+func Commit(value, randomness []byte) ([]byte, error) {
+	return NewCommitter("").Commit(value, randomness)
+}
+
+// VerifyCommitment 验证Commit生成的承诺
+func VerifyCommitment(commitment, value, randomness []byte) bool {
+	return NewCommitter("").Verify(commitment, value, randomness)
+}
+
+// writeLengthPrefixed 依次写入各部分的8字节大端长度前缀与数据，避免拼接边界产生歧义
+func writeLengthPrefixed(h hash.Hash, parts ...[]byte) {
+	for _, part := range parts {
+		var length [8]byte
+		binary.BigEndian.PutUint64(length[:], uint64(len(part)))
+		h.Write(length[:])
+		h.Write(part)
+	}
+}
@@ -3,8 +3,6 @@ package encrypt
 import (
 	"crypto/aes"
 	"crypto/des"
-	"crypto/rand"
-	"io"
 	"sync"
 	
 	"github.com/pkg/errors"
@@ -210,7 +208,7 @@ func NewConcurrentAES(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != blockSize {
 		encryptor.iv = make([]byte, blockSize)
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -246,7 +244,7 @@ func NewConcurrentDES(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != blockSize {
 		encryptor.iv = make([]byte, blockSize)
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -255,10 +253,12 @@ func NewConcurrentDES(key []byte) (ISymmetric, error) {
 
 // NewConcurrent3DES 创建新的线程安全3DES加密器
 func NewConcurrent3DES(key []byte) (ISymmetric, error) {
-	// 验证密钥长度
-	if len(key) != 24 {
-		return nil, errors.New("3DES密钥长度必须是24字节")
+	// 验证密钥长度，16字节双倍长密钥按K1K2K1展开
+	key, err := NormalizeTripleDESKey(key)
+	if err != nil {
+		return nil, err
 	}
+	defer zeroBytes(key)
 	
 	// 确保对象池已初始化
 	InitConcurrentPools()
@@ -282,7 +282,7 @@ func NewConcurrent3DES(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != blockSize {
 		encryptor.iv = make([]byte, blockSize)
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -312,7 +312,7 @@ func NewConcurrentSM4(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != 16 {
 		encryptor.iv = make([]byte, 16) // SM4块大小为16字节
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -0,0 +1,484 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// 配置文件字段加密（类似Mozilla SOPS）
+//
+// 只加密YAML/JSON中的值，键名、结构与YAML注释保持可读，便于评审与diff：
+//
+//	database:
+//	  password: ENC[v1,str,base64(nonce || 密文 || 标签)]
+//	  port: ENC[v1,int,...]
+//	encrypt_metadata:
+//	  version: 1
+//	  key_id: config
+//	  data_key: base64(包装后的数据密钥)
+//	  key_pattern: ^(password|token)$
+//	  mac: hex(HMAC-SHA256)
+//
+// 每个文件一把数据密钥，由KeyProvider（密钥环、Vault或云KMS）包装后保存在元数据中，解密只需一次解包。
+// 值以AES-256-GCM加密，附加认证数据为值的路径（如/database/password），值无法被挪到其他字段；
+// MAC覆盖密钥ID、键匹配规则与全部标量值（加密值按明文计入，未加密的值按原样计入），
+// 防止删除、增加或回滚单个值，也防止修改未加密的值或放宽键匹配规则后经EditConfig以明文写回。
+// 加密后重命名键会导致该值无法解密，应通过EditConfig修改
+
+// ConfigFormat 配置文件格式
+type ConfigFormat int
+
+// 配置文件格式常量定义
+const (
+	ConfigFormatYAML ConfigFormat = iota + 1
+	ConfigFormatJSON
+)
+
+// 配置文件加密常量
+const (
+	configMetadataKey = "encrypt_metadata"
+	configVersion     = 1
+	configValuePrefix = "ENC[v1,"
+	configMACInfo     = "sylphbyte/encrypt config mac v1"
+
+	configMACEncrypted = "enc"
+	configMACPlain     = "plain"
+)
+
+// configMetadata 加密元数据
+type configMetadata struct {
+	Version    int    `yaml:"version"`
+	KeyID      string `yaml:"key_id"`
+	DataKey    string `yaml:"data_key"`
+	KeyPattern string `yaml:"key_pattern,omitempty"`
+	MAC        string `yaml:"mac"`
+}
+
+// ConfigFormatOf 根据扩展名判断配置文件格式
+func ConfigFormatOf(path string) (ConfigFormat, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return ConfigFormatYAML, nil
+	case ".json":
+		return ConfigFormatJSON, nil
+	default:
+		return 0, errors.Errorf("不支持的配置文件格式: %s", path)
+	}
+}
+
+// ConfigEncrypter 配置文件加密器
+type ConfigEncrypter struct {
+	provider KeyProvider
+	keyID    string
+	pattern  string
+}
+
+// NewConfigEncrypter 创建加密器，数据密钥由provider的keyID包装
+func NewConfigEncrypter(provider KeyProvider, keyID string) *ConfigEncrypter {
+	return &ConfigEncrypter{provider: provider, keyID: keyID}
+}
+
+// WithKeyPattern 只加密键名匹配正则的值（匹配的映射或列表下的所有值都会加密），默认加密所有值
+// 正则无效时由Encrypt返回错误
+func (c *ConfigEncrypter) WithKeyPattern(pattern string) *ConfigEncrypter {
+	c.pattern = pattern
+	return c
+}
+
+// Encrypt 加密配置内容，已加密的内容返回错误
+func (c *ConfigEncrypter) Encrypt(ctx context.Context, data []byte, format ConfigFormat) ([]byte, error) {
+	if c.keyID == "" {
+		return nil, errors.New("密钥ID不能为空")
+	}
+	root, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, index := configMetadataIndex(root); index >= 0 {
+		return nil, errors.New("配置已加密")
+	}
+
+	var pattern *regexp.Regexp
+	if c.pattern != "" {
+		if pattern, err = regexp.Compile(c.pattern); err != nil {
+			return nil, errors.Wrap(err, "键匹配规则不是有效的正则表达式")
+		}
+	}
+
+	dataKey, wrapped, err := newDataKey(ctx, c.provider, c.keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(dataKey)
+
+	mac, err := newConfigMAC(dataKey, c.keyID, c.pattern)
+	if err != nil {
+		return nil, err
+	}
+	err = walkConfigValues(root, "", pattern == nil, pattern, func(node *yaml.Node, path string, selected bool) error {
+		typ := strings.TrimPrefix(node.ShortTag(), "!!")
+		if !selected || typ == "null" {
+			writeConfigMAC(mac, configMACPlain, path, typ, node.Value)
+			return nil
+		}
+		ciphertext, err := AESGCMEncrypt(dataKey, []byte(node.Value), []byte(path))
+		if err != nil {
+			return err
+		}
+		writeConfigMAC(mac, configMACEncrypted, path, typ, node.Value)
+
+		node.Value = configValuePrefix + typ + "," + base64.StdEncoding.EncodeToString(ciphertext) + "]"
+		node.Tag = "!!str"
+		node.Style = 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	meta := configMetadata{
+		Version:    configVersion,
+		KeyID:      c.keyID,
+		DataKey:    base64.StdEncoding.EncodeToString(wrapped),
+		KeyPattern: c.pattern,
+		MAC:        hex.EncodeToString(mac.Sum(nil)),
+	}
+	var metaNode yaml.Node
+	if err := metaNode.Encode(&meta); err != nil {
+		return nil, errors.Wrap(err, "编码加密元数据失败")
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: configMetadataKey}, &metaNode)
+	return encodeConfig(root, format)
+}
+
+// EncryptFile 原地加密配置文件
+func (c *ConfigEncrypter) EncryptFile(ctx context.Context, path string) error {
+	format, err := ConfigFormatOf(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrap(err, "读取配置文件失败")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "读取配置文件失败")
+	}
+
+	out, err := c.Encrypt(ctx, data, format)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out, info.Mode().Perm())
+}
+
+// DecryptConfig 读取并解密配置文件，返回去掉加密元数据的明文内容，格式与原文件相同
+func DecryptConfig(ctx context.Context, path string, provider KeyProvider) ([]byte, error) {
+	format, err := ConfigFormatOf(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取配置文件失败")
+	}
+	return DecryptConfigData(ctx, provider, data, format)
+}
+
+// DecryptConfigData 解密配置内容
+func DecryptConfigData(ctx context.Context, provider KeyProvider, data []byte, format ConfigFormat) ([]byte, error) {
+	root, err := parseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decryptConfigRoot(ctx, provider, root); err != nil {
+		return nil, err
+	}
+	return encodeConfig(root, format)
+}
+
+// EditConfig 解密配置文件交给edit修改，再以原密钥ID与键匹配规则重新加密写回，内容未变化时不写文件
+func EditConfig(ctx context.Context, path string, provider KeyProvider, edit func(plaintext []byte) ([]byte, error)) error {
+	format, err := ConfigFormatOf(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return errors.Wrap(err, "读取配置文件失败")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return errors.Wrap(err, "读取配置文件失败")
+	}
+
+	root, err := parseConfig(data)
+	if err != nil {
+		return err
+	}
+	meta, err := decryptConfigRoot(ctx, provider, root)
+	if err != nil {
+		return err
+	}
+	plaintext, err := encodeConfig(root, format)
+	if err != nil {
+		return err
+	}
+
+	edited, err := edit(plaintext)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, plaintext) {
+		return nil
+	}
+
+	encrypter := NewConfigEncrypter(provider, meta.KeyID)
+	encrypter.pattern = meta.KeyPattern
+	out, err := encrypter.Encrypt(ctx, edited, format)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, out, info.Mode().Perm())
+}
+
+// decryptConfigRoot 移除元数据、解包数据密钥并原地解密所有值
+func decryptConfigRoot(ctx context.Context, provider KeyProvider, root *yaml.Node) (*configMetadata, error) {
+	metaNode, index := configMetadataIndex(root)
+	if index < 0 {
+		return nil, errors.New("配置未加密，缺少加密元数据")
+	}
+	var meta configMetadata
+	if err := metaNode.Decode(&meta); err != nil {
+		return nil, errors.Wrap(err, "解析加密元数据失败")
+	}
+	if meta.Version != configVersion {
+		return nil, errors.Errorf("不支持的配置加密版本: %d", meta.Version)
+	}
+	root.Content = append(root.Content[:index], root.Content[index+2:]...)
+
+	wrapped, err := base64.StdEncoding.DecodeString(meta.DataKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "数据密钥解码失败")
+	}
+	dataKey, err := provider.UnwrapKey(ctx, meta.KeyID, wrapped)
+	if err != nil {
+		return nil, errors.Wrap(err, "解包数据密钥失败")
+	}
+	defer zeroBytes(dataKey)
+
+	mac, err := newConfigMAC(dataKey, meta.KeyID, meta.KeyPattern)
+	if err != nil {
+		return nil, err
+	}
+	err = walkConfigValues(root, "", true, nil, func(node *yaml.Node, path string, _ bool) error {
+		if !strings.HasPrefix(node.Value, configValuePrefix) || !strings.HasSuffix(node.Value, "]") {
+			writeConfigMAC(mac, configMACPlain, path, strings.TrimPrefix(node.ShortTag(), "!!"), node.Value)
+			return nil
+		}
+		typ, encoded, ok := strings.Cut(node.Value[len(configValuePrefix):len(node.Value)-1], ",")
+		if !ok {
+			return errors.Errorf("%s 的加密值格式不正确", path)
+		}
+		ciphertext, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return errors.Wrapf(err, "%s 的加密值解码失败", path)
+		}
+		plaintext, err := AESGCMDecrypt(dataKey, ciphertext, []byte(path))
+		if err != nil {
+			return errors.Errorf("%s 解密失败，值被篡改或被移动到其他字段", path)
+		}
+		writeConfigMAC(mac, configMACEncrypted, path, typ, string(plaintext))
+
+		node.Value = string(plaintext)
+		node.Tag = "!!" + typ
+		node.Style = 0
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := hex.DecodeString(meta.MAC)
+	if err != nil || !hmac.Equal(expected, mac.Sum(nil)) {
+		return nil, errors.New("配置MAC校验失败，值或键匹配规则被删除、增加或替换")
+	}
+	return &meta, nil
+}
+
+// parseConfig 解析YAML或JSON（JSON是YAML的子集），顶层必须是映射
+func parseConfig(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, errors.Wrap(err, "解析配置失败")
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, errors.New("配置顶层必须是映射")
+	}
+	return doc.Content[0], nil
+}
+
+// configMetadataIndex 查找顶层加密元数据，返回值节点与键节点下标，不存在时下标为-1
+func configMetadataIndex(root *yaml.Node) (*yaml.Node, int) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == configMetadataKey {
+			return root.Content[i+1], i
+		}
+	}
+	return nil, -1
+}
+
+// walkConfigValues 按文档顺序遍历所有标量值，selected表示当前子树是否需要加密，路径按JSON Pointer转义
+func walkConfigValues(node *yaml.Node, path string, selected bool, pattern *regexp.Regexp, fn func(node *yaml.Node, path string, selected bool) error) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i].Value
+			if path == "" && key == configMetadataKey {
+				continue
+			}
+			child := selected || (pattern != nil && pattern.MatchString(key))
+			escaped := strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+			if err := walkConfigValues(node.Content[i+1], path+"/"+escaped, child, pattern, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		for i, item := range node.Content {
+			if err := walkConfigValues(item, path+"/"+strconv.Itoa(i), selected, pattern, fn); err != nil {
+				return err
+			}
+		}
+	case yaml.ScalarNode:
+		return fn(node, path, selected)
+	case yaml.AliasNode:
+		return errors.Errorf("%s 使用了YAML别名，加密配置不支持别名", path)
+	}
+	return nil
+}
+
+// newConfigMAC 由数据密钥派生MAC密钥，并先写入密钥ID与键匹配规则
+func newConfigMAC(dataKey []byte, keyID, pattern string) (hash.Hash, error) {
+	macKey, err := hkdf.Key(sha256.New, dataKey, nil, configMACInfo, 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生MAC密钥失败")
+	}
+	defer zeroBytes(macKey)
+	mac := hmac.New(sha256.New, macKey)
+	writeConfigMAC(mac, keyID, pattern)
+	return mac, nil
+}
+
+// writeConfigMAC 按 长度 || 内容 依次写入各部分
+// 标量值写入 类别 || 路径 || 类型 || 明文，类别区分加密值与明文值，避免加密值被替换为相同明文后MAC仍然一致
+func writeConfigMAC(mac hash.Hash, parts ...string) {
+	for _, part := range parts {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(part)))
+		mac.Write(length[:])
+		mac.Write([]byte(part))
+	}
+}
+
+// encodeConfig 按原格式输出
+func encodeConfig(root *yaml.Node, format ConfigFormat) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case ConfigFormatYAML:
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(root); err != nil {
+			return nil, errors.Wrap(err, "输出YAML失败")
+		}
+		if err := enc.Close(); err != nil {
+			return nil, errors.Wrap(err, "输出YAML失败")
+		}
+	case ConfigFormatJSON:
+		if err := writeConfigJSON(&buf, root, ""); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+	default:
+		return nil, errors.New("不支持的配置文件格式")
+	}
+	return buf.Bytes(), nil
+}
+
+// writeConfigJSON 按节点顺序输出JSON，保持原有键顺序
+func writeConfigJSON(buf *bytes.Buffer, node *yaml.Node, indent string) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteString("{\n")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			buf.WriteString(indent + "  ")
+			writeJSONString(buf, node.Content[i].Value)
+			buf.WriteString(": ")
+			if err := writeConfigJSON(buf, node.Content[i+1], indent+"  "); err != nil {
+				return err
+			}
+			if i+2 < len(node.Content) {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "}")
+	case yaml.SequenceNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteString("[\n")
+		for i, item := range node.Content {
+			buf.WriteString(indent + "  ")
+			if err := writeConfigJSON(buf, item, indent+"  "); err != nil {
+				return err
+			}
+			if i+1 < len(node.Content) {
+				buf.WriteByte(',')
+			}
+			buf.WriteByte('\n')
+		}
+		buf.WriteString(indent + "]")
+	case yaml.ScalarNode:
+		switch node.ShortTag() {
+		case "!!int", "!!float", "!!bool":
+			buf.WriteString(node.Value)
+		case "!!null":
+			buf.WriteString("null")
+		default:
+			writeJSONString(buf, node.Value)
+		}
+	default:
+		return errors.New("JSON配置不支持该节点类型")
+	}
+	return nil
+}
+
+// writeJSONString 输出JSON字符串，不转义HTML字符
+func writeJSONString(buf *bytes.Buffer, s string) {
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	buf.Truncate(buf.Len() - 1) // 去掉Encode追加的换行
+}
@@ -0,0 +1,110 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// 配置文件密文
+//
+// 用于保护写入配置文件的口令、令牌等，一次调用完成：口令经argon2id派生密钥，
+// XChaCha20-Poly1305加密，输出可直接写入YAML/JSON/环境变量的单行文本：
+//
+//	enc:v1:base64url( version(1) | time(4) | memory(4) | threads(1) | salt(16) | nonce(24) | 密文 | 标签(16) )
+//
+// argon2id参数记录在头部并作为附加认证数据，调整默认参数不影响已有密文解密
+
+// ConfigSecretPrefix 配置密文前缀
+const ConfigSecretPrefix = "enc:v1:"
+
+// configSecretVersion 配置密文格式版本
+const configSecretVersion = 1
+
+// configSecretHeaderSize 头部长度（版本、argon2id参数与盐）
+const configSecretHeaderSize = 1 + 4 + 4 + 1 + 16
+
+// configSecretMaxMemory 解密时允许的最大argon2id内存（KiB），防止篡改参数耗尽内存
+const configSecretMaxMemory = 1024 * 1024
+
+// SealConfigSecret 使用口令加密配置项，argon2id使用DefaultKeystoreKDFParams
+func SealConfigSecret(password, plaintext []byte) (string, error) {
+	return SealConfigSecretWithParams(password, plaintext, DefaultKeystoreKDFParams)
+}
+
+// SealConfigSecretWithParams 使用指定argon2id参数加密配置项
+func SealConfigSecretWithParams(password, plaintext []byte, params KeystoreKDFParams) (string, error) {
+	if len(password) == 0 {
+		return "", errors.New("口令不能为空")
+	}
+	if params.Time == 0 || params.Memory == 0 || params.Threads == 0 {
+		return "", errors.New("argon2id参数不能为0")
+	}
+	if params.Memory > configSecretMaxMemory {
+		return "", errors.New("argon2id内存参数过大")
+	}
+
+	salt, err := GenerateRandomBytes(16)
+	if err != nil {
+		return "", err
+	}
+
+	header := make([]byte, 0, configSecretHeaderSize)
+	header = append(header, configSecretVersion)
+	header = binary.BigEndian.AppendUint32(header, params.Time)
+	header = binary.BigEndian.AppendUint32(header, params.Memory)
+	header = append(header, params.Threads)
+	header = append(header, salt...)
+
+	key := argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, chacha20poly1305.KeySize)
+	defer zeroBytes(key)
+
+	sealed, err := xchachaSeal(header, key, plaintext)
+	if err != nil {
+		return "", err
+	}
+	return ConfigSecretPrefix + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// UnsealConfigSecret 使用口令解密配置项
+func UnsealConfigSecret(password []byte, sealed string) ([]byte, error) {
+	sealed = strings.TrimSpace(sealed)
+	if !IsSealedConfigSecret(sealed) {
+		return nil, errors.New("不是配置密文格式")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(sealed[len(ConfigSecretPrefix):])
+	if err != nil {
+		return nil, errors.Wrap(err, "配置密文解码失败")
+	}
+	if len(data) < configSecretHeaderSize+chacha20poly1305.NonceSizeX+chacha20poly1305.Overhead {
+		return nil, errors.New("配置密文长度不足")
+	}
+	if data[0] != configSecretVersion {
+		return nil, errors.Errorf("不支持的配置密文版本: %d", data[0])
+	}
+
+	passes := binary.BigEndian.Uint32(data[1:5])
+	memory := binary.BigEndian.Uint32(data[5:9])
+	threads := data[9]
+	if passes == 0 || memory == 0 || threads == 0 || memory > configSecretMaxMemory {
+		return nil, errors.New("配置密文的argon2id参数不正确")
+	}
+
+	key := argon2.IDKey(password, data[10:configSecretHeaderSize], passes, memory, threads, chacha20poly1305.KeySize)
+	defer zeroBytes(key)
+
+	plaintext, err := xchachaOpen(data[:configSecretHeaderSize], key, data[configSecretHeaderSize:])
+	if err != nil {
+		return nil, errors.New("口令错误或配置密文已被篡改")
+	}
+	return plaintext, nil
+}
+
+// IsSealedConfigSecret 判断配置值是否为SealConfigSecret的输出，便于配置加载时按需解密
+func IsSealedConfigSecret(value string) bool {
+	return strings.HasPrefix(strings.TrimSpace(value), ConfigSecretPrefix)
+}
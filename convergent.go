@@ -0,0 +1,128 @@
+package encrypt
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+// 收敛加密（可去重的内容寻址加密），需要显式选用
+//
+// 文件密钥由明文与域密钥派生，相同明文在同一域内总是得到相同的密文，备份存储因此可以对密文去重：
+//
+//	文件密钥 = HMAC-SHA256(域密钥, 明文)
+//	加密密钥 = HKDF(文件密钥, "sylphbyte/encrypt convergent")
+//	密文     = GCM(加密密钥, nonce=0, 明文)    每把密钥只加密一段固定明文，固定nonce不会复用
+//	内容ID   = hex(SHA-256(密文))             存储端无需密钥即可校验内容与ID一致
+//
+// 机密性上的取舍，使用前必须确认可以接受：
+//   - 相等性泄露：任何人都能看出两份密文是否对应同一明文，这正是去重的前提
+//   - 确认文件攻击：持有域密钥的一方可以猜测明文、加密后与存储的密文比对，
+//     以此确认某人是否存储了某个已知文件；对低熵内容（如只有几位数字不同的模板文件）
+//     可以逐一枚举，恢复出未知的部分。域密钥把这种能力限制在同一域（租户）内部，
+//     不同域之间的密文互不相同，也无法去重
+//   - 不提供语义安全，不能用于数据库字段、消息等可枚举的小数据，这类数据使用普通的随机化加密
+//
+// 每个文件的密钥需要由客户端保存在自己的（随机化加密的）清单中，存储端只接触内容ID与密文
+
+// convergentInfo HKDF信息
+const convergentInfo = "sylphbyte/encrypt convergent"
+
+// ConvergentBlob 收敛加密结果
+type ConvergentBlob struct {
+	ID         string // 内容ID，hex(SHA-256(密文))
+	Key        []byte // 文件密钥，解密时需要
+	Ciphertext []byte
+}
+
+// ConvergentCipher 收敛加密器
+type ConvergentCipher struct {
+	secret    []byte
+	algorithm Algorithm
+}
+
+// NewConvergentCipher 使用域密钥创建收敛加密器，默认AES-256-GCM
+func NewConvergentCipher(secret []byte) (*ConvergentCipher, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("收敛加密的域密钥长度至少32字节")
+	}
+	return &ConvergentCipher{secret: append([]byte(nil), secret...), algorithm: AlgorithmAES}, nil
+}
+
+// SM4 使用SM4-GCM加密
+func (c *ConvergentCipher) SM4() *ConvergentCipher {
+	c.algorithm = AlgorithmSM4
+	return c
+}
+
+// Seal 加密明文，相同明文总是得到相同的内容ID与密文
+func (c *ConvergentCipher) Seal(plaintext []byte) (*ConvergentBlob, error) {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(plaintext)
+	fileKey := mac.Sum(nil)
+
+	ciphertext, err := c.crypt(fileKey, plaintext, true)
+	if err != nil {
+		return nil, err
+	}
+	return &ConvergentBlob{ID: ConvergentID(ciphertext), Key: fileKey, Ciphertext: ciphertext}, nil
+}
+
+// Open 使用文件密钥解密，并确认明文确实派生出该密钥，防止存储端用其他密文冒充同一内容
+func (c *ConvergentCipher) Open(fileKey, ciphertext []byte) ([]byte, error) {
+	plaintext, err := c.crypt(fileKey, ciphertext, false)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(plaintext)
+	if subtle.ConstantTimeCompare(mac.Sum(nil), fileKey) != 1 {
+		zeroBytes(plaintext)
+		return nil, errors.New("明文与文件密钥不一致")
+	}
+	return plaintext, nil
+}
+
+// ConvergentID 计算密文的内容ID，存储端可据此校验上传的数据
+func ConvergentID(ciphertext []byte) string {
+	sum := sha256.Sum256(ciphertext)
+	return hex.EncodeToString(sum[:])
+}
+
+// crypt 以固定nonce执行GCM加解密
+func (c *ConvergentCipher) crypt(fileKey, data []byte, seal bool) ([]byte, error) {
+	if len(fileKey) != sha256.Size {
+		return nil, errors.New("文件密钥长度不正确")
+	}
+	keyLen := 32
+	if c.algorithm == AlgorithmSM4 {
+		keyLen = 16
+	}
+	key, err := hkdf.Key(sha256.New, fileKey, nil, convergentInfo, keyLen)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生加密密钥失败")
+	}
+	defer zeroBytes(key)
+
+	newGCM := newAESGCM
+	if c.algorithm == AlgorithmSM4 {
+		newGCM = newSM4GCM
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if seal {
+		return gcm.Seal(nil, nonce, data, nil), nil
+	}
+	plaintext, err := gcm.Open(nil, nonce, data, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密失败")
+	}
+	return plaintext, nil
+}
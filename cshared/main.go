@@ -0,0 +1,170 @@
+// cshared 将加密库的核心能力导出为C动态库，供遗留C/C++服务复用完全一致的加密行为与密文格式
+//
+// 编译：
+//
+//	go build -buildmode=c-shared -o libencrypt.so ./cshared
+//
+// 约定：
+//   - 所有函数返回0表示成功，非0表示失败
+//   - 输出缓冲区与错误信息均由本库通过malloc分配，调用方必须使用EncryptFree释放
+//   - 密文格式与Go侧AESGCMEncrypt/SM4GCMEncrypt一致：nonce(12字节) || 密文 || 认证标签(16字节)
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// 返回码定义
+const (
+	codeOK      = 0
+	codeError   = 1
+	codeInvalid = 2
+)
+
+func main() {}
+
+//export EncryptFree
+func EncryptFree(p unsafe.Pointer) {
+	C.free(p)
+}
+
+//export EncryptAESGCMSeal
+func EncryptAESGCMSeal(key *C.uchar, keyLen C.int, plaintext *C.uchar, plaintextLen C.int, aad *C.uchar, aadLen C.int,
+	out **C.uchar, outLen *C.int, errMsg **C.char) C.int {
+	return seal(encrypt.AESGCMEncrypt, key, keyLen, plaintext, plaintextLen, aad, aadLen, out, outLen, errMsg)
+}
+
+//export EncryptAESGCMOpen
+func EncryptAESGCMOpen(key *C.uchar, keyLen C.int, ciphertext *C.uchar, ciphertextLen C.int, aad *C.uchar, aadLen C.int,
+	out **C.uchar, outLen *C.int, errMsg **C.char) C.int {
+	return seal(encrypt.AESGCMDecrypt, key, keyLen, ciphertext, ciphertextLen, aad, aadLen, out, outLen, errMsg)
+}
+
+//export EncryptSM4GCMSeal
+func EncryptSM4GCMSeal(key *C.uchar, keyLen C.int, plaintext *C.uchar, plaintextLen C.int, aad *C.uchar, aadLen C.int,
+	out **C.uchar, outLen *C.int, errMsg **C.char) C.int {
+	return seal(encrypt.SM4GCMEncrypt, key, keyLen, plaintext, plaintextLen, aad, aadLen, out, outLen, errMsg)
+}
+
+//export EncryptSM4GCMOpen
+func EncryptSM4GCMOpen(key *C.uchar, keyLen C.int, ciphertext *C.uchar, ciphertextLen C.int, aad *C.uchar, aadLen C.int,
+	out **C.uchar, outLen *C.int, errMsg **C.char) C.int {
+	return seal(encrypt.SM4GCMDecrypt, key, keyLen, ciphertext, ciphertextLen, aad, aadLen, out, outLen, errMsg)
+}
+
+//export EncryptSM2Sign
+func EncryptSM2Sign(privateKeyPEM *C.char, data *C.uchar, dataLen C.int, uid *C.uchar, uidLen C.int,
+	out **C.uchar, outLen *C.int, errMsg **C.char) (code C.int) {
+	defer recoverInto(&code, errMsg)
+
+	if privateKeyPEM == nil || out == nil || outLen == nil {
+		return fail(errMsg, codeInvalid, "参数不能为空")
+	}
+
+	sm2Encryptor := encrypt.MustNewSM2()
+	defer sm2Encryptor.Release()
+
+	sm2Encryptor.NoEncoding().WithPrivateKey([]byte(C.GoString(privateKeyPEM)))
+	if uidLen > 0 {
+		sm2Encryptor.(encrypt.IUIDSetter).WithUID(goBytes(uid, uidLen))
+	}
+
+	signature, err := sm2Encryptor.Sign(goBytes(data, dataLen))
+	if err != nil {
+		return fail(errMsg, codeError, err.Error())
+	}
+
+	*out, *outLen = cBytes(signature)
+	return codeOK
+}
+
+//export EncryptSM2Verify
+func EncryptSM2Verify(publicKeyPEM *C.char, data *C.uchar, dataLen C.int, uid *C.uchar, uidLen C.int,
+	signature *C.uchar, signatureLen C.int, valid *C.int, errMsg **C.char) (code C.int) {
+	defer recoverInto(&code, errMsg)
+
+	if publicKeyPEM == nil || valid == nil {
+		return fail(errMsg, codeInvalid, "参数不能为空")
+	}
+
+	sm2Encryptor := encrypt.MustNewSM2()
+	defer sm2Encryptor.Release()
+
+	sm2Encryptor.NoEncoding().WithPublicKey([]byte(C.GoString(publicKeyPEM)))
+	if uidLen > 0 {
+		sm2Encryptor.(encrypt.IUIDSetter).WithUID(goBytes(uid, uidLen))
+	}
+
+	ok, err := sm2Encryptor.Verify(goBytes(data, dataLen), goBytes(signature, signatureLen))
+	if err != nil {
+		return fail(errMsg, codeError, err.Error())
+	}
+
+	*valid = 0
+	if ok {
+		*valid = 1
+	}
+	return codeOK
+}
+
+// seal 调用Go侧的AEAD函数并把结果复制到C内存
+func seal(fn func(key, input, aad []byte) ([]byte, error), key *C.uchar, keyLen C.int, input *C.uchar, inputLen C.int,
+	aad *C.uchar, aadLen C.int, out **C.uchar, outLen *C.int, errMsg **C.char) (code C.int) {
+	defer recoverInto(&code, errMsg)
+
+	if key == nil || out == nil || outLen == nil {
+		return fail(errMsg, codeInvalid, "参数不能为空")
+	}
+
+	var aadBytes []byte
+	if aadLen > 0 {
+		aadBytes = goBytes(aad, aadLen)
+	}
+
+	result, err := fn(goBytes(key, keyLen), goBytes(input, inputLen), aadBytes)
+	if err != nil {
+		return fail(errMsg, codeError, err.Error())
+	}
+
+	*out, *outLen = cBytes(result)
+	return codeOK
+}
+
+// goBytes 将C内存复制为Go字节切片
+func goBytes(p *C.uchar, n C.int) []byte {
+	if p == nil || n <= 0 {
+		return []byte{}
+	}
+	return C.GoBytes(unsafe.Pointer(p), n)
+}
+
+// cBytes 将Go字节切片复制到malloc分配的C内存
+func cBytes(data []byte) (*C.uchar, C.int) {
+	if len(data) == 0 {
+		return (*C.uchar)(C.malloc(1)), 0
+	}
+	return (*C.uchar)(C.CBytes(data)), C.int(len(data))
+}
+
+// fail 写入错误信息并返回错误码
+func fail(errMsg **C.char, code C.int, message string) C.int {
+	if errMsg != nil {
+		*errMsg = C.CString(message)
+	}
+	return code
+}
+
+// recoverInto 捕获panic（如PEM解析失败），避免跨越cgo边界导致进程崩溃
+func recoverInto(code *C.int, errMsg **C.char) {
+	if r := recover(); r != nil {
+		*code = fail(errMsg, codeError, fmt.Sprint(r))
+	}
+}
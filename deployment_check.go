@@ -0,0 +1,188 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 启动自检
+//
+// 密钥文件损坏、环境变量里的密钥被截断、公私钥配错，通常要等到第一个用户请求才暴露。
+// ValidateDeployment 在进程启动时用密钥环中的每把密钥对探针值做一次完整的加解密或签名验签，
+// 并检查主密钥当前是否可用；调用方还可以传入上一版本写入的探针密文，确认新部署加载的密钥
+// 与线上数据一致（同一ID下换了密钥材料时，往返自检能通过，但探针密文解不开）。
+//
+// 所有问题汇总为*DeploymentError返回，每一项为*ProbeError，记录密钥ID与失败的操作，
+// 可用errors.Is判断ErrKeyExpired、ErrProbeMismatch等具体原因。已吊销的密钥不参与自检
+
+// 自检操作名称
+const (
+	ProbeOpPrimary = "primary" // 检查主密钥
+	ProbeOpEncrypt = "encrypt" // 对称加密
+	ProbeOpDecrypt = "decrypt" // 对称解密
+	ProbeOpSign    = "sign"    // 签名
+	ProbeOpVerify  = "verify"  // 验签
+	ProbeOpProbe   = "probe"   // 解密调用方提供的探针密文
+)
+
+// defaultProbeValue 默认的探针明文
+var defaultProbeValue = []byte("sylphbyte/encrypt deployment probe")
+
+// ErrProbeMismatch 探针解密结果与预期明文不一致
+var ErrProbeMismatch = errors.New("探针解密结果与预期不一致")
+
+// DeploymentProbe 上一版本用KeyRing.Encrypt写入的探针密文
+type DeploymentProbe struct {
+	Name       string // 探针名称，出现在错误信息中
+	Ciphertext []byte // KeyRing.Encrypt的输出
+	AAD        []byte // 加密时使用的附加认证数据
+	Plaintext  []byte // 预期明文
+}
+
+// ProbeError 一项自检失败
+type ProbeError struct {
+	KeyID     string // 相关的密钥ID，主密钥未设置时为空
+	Probe     string // 探针名称，仅ProbeOpProbe时有值
+	Operation string // 失败的操作，ProbeOp*常量之一
+	Err       error
+}
+
+// Error 实现error接口
+func (e *ProbeError) Error() string {
+	target := "密钥" + e.KeyID
+	if e.Probe != "" {
+		target = "探针" + e.Probe
+	}
+	return target + "自检失败(" + e.Operation + "): " + e.Err.Error()
+}
+
+// Unwrap 返回底层错误
+func (e *ProbeError) Unwrap() error {
+	return e.Err
+}
+
+// DeploymentError 启动自检发现的全部问题
+type DeploymentError struct {
+	Failures []*ProbeError
+}
+
+// Error 实现error接口，每行一个问题
+func (e *DeploymentError) Error() string {
+	lines := make([]string, 0, len(e.Failures)+1)
+	lines = append(lines, "部署自检失败，共"+strconv.Itoa(len(e.Failures))+"项问题")
+	for _, failure := range e.Failures {
+		lines = append(lines, "  - "+failure.Error())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Unwrap 返回所有问题，errors.Is与errors.As会逐项匹配
+func (e *DeploymentError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+	return errs
+}
+
+// ValidateDeployment 启动时自检密钥环，全部通过返回nil，否则返回*DeploymentError
+func ValidateDeployment(ring *KeyRing, probes []DeploymentProbe) error {
+	var failures []*ProbeError
+	fail := func(keyID, probe, op string, err error) {
+		failures = append(failures, &ProbeError{KeyID: keyID, Probe: probe, Operation: op, Err: err})
+	}
+
+	primary := ring.Primary()
+	if primary == "" {
+		fail("", "", ProbeOpPrimary, errors.New("未设置主密钥，无法加密"))
+	} else if _, err := ring.Use(primary, KeyUsageEncrypt); err != nil {
+		fail(primary, "", ProbeOpPrimary, err)
+	}
+
+	for _, id := range ring.IDs() {
+		entry, err := ring.Get(id)
+		if err != nil || entry.Revoked {
+			continue
+		}
+		if entry.Usage&KeyUsageCipher != 0 {
+			if op, err := probeSymmetric(entry); err != nil {
+				fail(id, "", op, err)
+			}
+		}
+		if entry.Usage&KeyUsageSignature != 0 {
+			if op, err := probeSignature(entry); err != nil {
+				fail(id, "", op, err)
+			}
+		}
+	}
+
+	for _, probe := range probes {
+		keyID, _, _ := SplitKeyID(probe.Ciphertext)
+		plaintext, err := ring.Decrypt(probe.Ciphertext, probe.AAD)
+		if err == nil && !bytes.Equal(plaintext, probe.Plaintext) {
+			err = ErrProbeMismatch
+		}
+		if err != nil {
+			fail(keyID, probe.Name, ProbeOpProbe, err)
+		}
+	}
+
+	if len(failures) > 0 {
+		return &DeploymentError{Failures: failures}
+	}
+	return nil
+}
+
+// probeSymmetric 用密钥材料做一次加解密往返，不检查有效期（有效期由主密钥检查与业务调用负责）
+func probeSymmetric(entry KeyEntry) (string, error) {
+	ciphertext, err := keyRingSeal(entry, defaultProbeValue, []byte(entry.ID))
+	if err != nil {
+		return ProbeOpEncrypt, err
+	}
+	plaintext, err := keyRingOpen(entry, ciphertext, []byte(entry.ID))
+	if err != nil {
+		return ProbeOpDecrypt, err
+	}
+	if !bytes.Equal(plaintext, defaultProbeValue) {
+		return ProbeOpDecrypt, ErrProbeMismatch
+	}
+	return "", nil
+}
+
+// probeSignature 签名后用配置的公钥验签；仅有公钥时只检查公钥能否解析
+func probeSignature(entry KeyEntry) (string, error) {
+	var publicKey crypto.PublicKey
+	if len(entry.PublicKey) > 0 {
+		key, err := parsePublicKeyPEM(entry.PublicKey)
+		if err != nil {
+			return ProbeOpVerify, err
+		}
+		publicKey = key
+	}
+	if len(entry.Key) == 0 {
+		if publicKey == nil {
+			return ProbeOpVerify, errors.New("既没有私钥也没有公钥")
+		}
+		return "", nil
+	}
+
+	signer, err := ParseSigner(entry.Key)
+	if err != nil {
+		return ProbeOpSign, err
+	}
+	signature, err := signMessage(signer, defaultProbeValue)
+	if err != nil {
+		return ProbeOpSign, err
+	}
+	if publicKey == nil {
+		publicKey = signer.Public()
+	}
+	if err := verifyMessage(publicKey, defaultProbeValue, signature); err != nil {
+		return ProbeOpVerify, errors.Wrap(err, "公钥与私钥不匹配")
+	}
+	return "", nil
+}
@@ -0,0 +1,87 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+
+	"github.com/pkg/errors"
+)
+
+// DES/3DES密钥规范化
+//
+// HSM导出的3DES密钥常见两种形式：
+//   - 双倍长（16字节，keying option 2）：K1K2，按 K1K2K1 展开为24字节使用
+//   - 三倍长（24字节，keying option 1）：K1K2K3
+//
+// 子密钥重复（K1==K2 等）会使3DES退化为单DES，由 ValidateKey 检查。
+// DES密钥每个字节的最低位是奇校验位，加密时被忽略，但HSM与密钥组件校验时会检查，
+// FixDESParity 与 CheckDESParity 用于修正与校验校验位
+
+// NormalizeTripleDESKey 将16或24字节的3DES密钥规范化为24字节，返回新的切片
+func NormalizeTripleDESKey(key []byte) ([]byte, error) {
+	switch len(key) {
+	case 16:
+		expanded := make([]byte, 24)
+		copy(expanded, key)
+		copy(expanded[16:], key[:8])
+		return expanded, nil
+	case 24:
+		return append([]byte(nil), key...), nil
+	default:
+		return nil, errors.New("3DES密钥长度必须是16或24字节")
+	}
+}
+
+// FixDESParity 返回每个字节设置为奇校验后的密钥副本，适用于DES与3DES密钥
+func FixDESParity(key []byte) []byte {
+	fixed := append([]byte(nil), key...)
+	desOddParity(fixed)
+	return fixed
+}
+
+// CheckDESParity 校验DES/3DES密钥每个字节是否为奇校验
+func CheckDESParity(key []byte) error {
+	if len(key) == 0 || len(key)%8 != 0 {
+		return errors.New("DES密钥长度必须是8的整数倍")
+	}
+	for i, b := range key {
+		if desOnes(b)%2 != 1 {
+			return errors.Errorf("DES密钥第%d字节校验位不正确", i+1)
+		}
+	}
+	return nil
+}
+
+// newTripleDESBlock 创建3DES分组密码，支持16与24字节密钥
+func newTripleDESBlock(key []byte) (cipher.Block, error) {
+	expanded, err := NormalizeTripleDESKey(key)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(expanded)
+	block, err := des.NewTripleDESCipher(expanded)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建3DES失败")
+	}
+	return block, nil
+}
+
+// desOddParity 将DES密钥每个字节的最低位设置为奇校验
+func desOddParity(key []byte) {
+	for i, b := range key {
+		b &= 0xfe
+		if desOnes(b)%2 == 0 {
+			b |= 1
+		}
+		key[i] = b
+	}
+}
+
+// desOnes 统计字节中1的个数
+func desOnes(b byte) int {
+	ones := 0
+	for ; b != 0; b >>= 1 {
+		ones += int(b & 1)
+	}
+	return ones
+}
@@ -0,0 +1,316 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 分离式签名文件（.sig）
+//
+// 签名文件为JSON格式，与被签名文件分开存放，记录文件摘要、摘要算法以及每个签名者的
+// 公钥指纹、签名时间和签名值。签名覆盖的不是文件本身，而是由上述元数据拼接成的规范化文本，
+// 因此篡改摘要算法、签名时间或签名者指纹都会导致验签失败
+
+// DetachedSignatureVersion 签名文件格式版本
+const DetachedSignatureVersion = 1
+
+// DetachedSignatureExt 签名文件扩展名
+const DetachedSignatureExt = ".sig"
+
+// DetachedSignature 分离式签名文件
+type DetachedSignature struct {
+	Version    int               `json:"version"`
+	File       string            `json:"file,omitempty"`
+	Size       int64             `json:"size"`
+	Hash       string            `json:"hash"`
+	Digest     string            `json:"digest"`
+	Signatures []SignatureRecord `json:"signatures"`
+}
+
+// SignatureRecord 单个签名者的签名记录
+type SignatureRecord struct {
+	Algorithm      string    `json:"algorithm"`
+	KeyFingerprint string    `json:"key_fingerprint"`
+	SignedAt       time.Time `json:"signed_at"`
+	Signature      string    `json:"signature"`
+	Countersigns   string    `json:"countersigns,omitempty"` // 副署时为被副署签名者的公钥指纹
+}
+
+// SignFile 对文件生成分离式签名
+// signer可以是ParseSigner或各加密器Signer方法的返回值；SM2签名使用签名器自身的UID，验签固定使用默认UID
+func SignFile(signer crypto.Signer, path string, hashAlgo HashAlgorithm) (*DetachedSignature, error) {
+	digest, size, err := hashFile(path, hashAlgo)
+	if err != nil {
+		return nil, err
+	}
+
+	hashName, err := hashAlgorithmName(hashAlgo)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &DetachedSignature{
+		Version: DetachedSignatureVersion,
+		File:    filepath.Base(path),
+		Size:    size,
+		Hash:    hashName,
+		Digest:  hex.EncodeToString(digest),
+	}
+	if err := sig.AddSignature(signer); err != nil {
+		return nil, err
+	}
+	return sig, nil
+}
+
+// AddSignature 使用signer对同一文件摘要追加签名
+func (d *DetachedSignature) AddSignature(signer crypto.Signer) error {
+	fp, err := fingerprint(signer.Public(), HashSHA256)
+	if err != nil {
+		return err
+	}
+
+	record := SignatureRecord{
+		KeyFingerprint: hex.EncodeToString(fp),
+		SignedAt:       time.Now().UTC().Truncate(time.Second),
+	}
+	if record.Algorithm, err = signatureAlgorithmName(signer.Public()); err != nil {
+		return err
+	}
+
+	raw, err := signMessage(signer, d.signingInput(record))
+	if err != nil {
+		return err
+	}
+	record.Signature = base64.StdEncoding.EncodeToString(raw)
+
+	d.Signatures = append(d.Signatures, record)
+	return nil
+}
+
+// Bytes 序列化签名文件
+func (d *DetachedSignature) Bytes() ([]byte, error) {
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化签名文件失败")
+	}
+	return append(data, '\n'), nil
+}
+
+// WriteFile 将签名文件写入被签名文件旁，文件名为原文件名加.sig
+func (d *DetachedSignature) WriteFile(path string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+DetachedSignatureExt, data, 0644); err != nil {
+		return errors.Wrap(err, "写入签名文件失败")
+	}
+	return nil
+}
+
+// ParseDetachedSignature 解析签名文件
+func ParseDetachedSignature(data []byte) (*DetachedSignature, error) {
+	var sig DetachedSignature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, errors.Wrap(err, "解析签名文件失败")
+	}
+	if sig.Version != DetachedSignatureVersion {
+		return nil, errors.Errorf("不支持的签名文件版本: %d", sig.Version)
+	}
+	return &sig, nil
+}
+
+// VerifyFile 使用PEM公钥验证文件的分离式签名
+func VerifyFile(path string, sig *DetachedSignature, publicKeyPEM []byte) error {
+	if err := sig.verifyDigest(path); err != nil {
+		return err
+	}
+
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	return sig.verifySigner(publicKey)
+}
+
+// verifyDigest 重新计算文件摘要并与签名文件比对
+func (d *DetachedSignature) verifyDigest(path string) error {
+	hashAlgo, err := hashAlgorithmFromName(d.Hash)
+	if err != nil {
+		return err
+	}
+
+	digest, size, err := hashFile(path, hashAlgo)
+	if err != nil {
+		return err
+	}
+	if size != d.Size || hex.EncodeToString(digest) != strings.ToLower(d.Digest) {
+		return errors.New("文件内容与签名文件记录的摘要不一致")
+	}
+	return nil
+}
+
+// verifySigner 查找公钥对应的签名记录并验签
+func (d *DetachedSignature) verifySigner(publicKey crypto.PublicKey) error {
+	fp, err := fingerprint(publicKey, HashSHA256)
+	if err != nil {
+		return err
+	}
+	keyFingerprint := hex.EncodeToString(fp)
+
+	for _, record := range d.Signatures {
+		if record.KeyFingerprint != keyFingerprint || record.Countersigns != "" {
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(record.Signature)
+		if err != nil {
+			return errors.Wrap(err, "解码签名失败")
+		}
+		return verifyMessage(publicKey, d.signingInput(record), raw)
+	}
+	return errors.Errorf("签名文件中没有公钥%s的签名", ShortFingerprint(fp))
+}
+
+// signingInput 生成签名覆盖的规范化文本
+// 副署签名额外覆盖被副署的签名值，使其无法被挪用到其他签名上
+func (d *DetachedSignature) signingInput(record SignatureRecord) []byte {
+	input := fmt.Sprintf("encrypt-detached-signature/v%d\nfile:%s\nsize:%d\nhash:%s\ndigest:%s\nalgorithm:%s\nkey:%s\nsigned_at:%s\n",
+		d.Version, d.File, d.Size, d.Hash, strings.ToLower(d.Digest),
+		record.Algorithm, record.KeyFingerprint, record.SignedAt.UTC().Format(time.RFC3339))
+
+	if record.Countersigns != "" {
+		if target, ok := d.primarySignature(record.Countersigns); ok {
+			input += fmt.Sprintf("countersigns:%s\ncountersigned_signature:%s\n", target.KeyFingerprint, target.Signature)
+		}
+	}
+	return []byte(input)
+}
+
+// primarySignature 查找指定公钥指纹的主签名（非副署）
+func (d *DetachedSignature) primarySignature(keyFingerprint string) (SignatureRecord, bool) {
+	for _, record := range d.Signatures {
+		if record.KeyFingerprint == keyFingerprint && record.Countersigns == "" {
+			return record, true
+		}
+	}
+	return SignatureRecord{}, false
+}
+
+// signMessage 按公钥类型选择签名方式
+func signMessage(signer crypto.Signer, message []byte) ([]byte, error) {
+	switch signer.Public().(type) {
+	case *sm2.PublicKey:
+		// SM2签名器要求传入原始消息
+		return signer.Sign(rand.Reader, message, nil)
+	case ed25519.PublicKey:
+		return signer.Sign(rand.Reader, message, crypto.Hash(0))
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		digest := hashFunc(HashSHA256)()
+		digest.Write(message)
+		return signer.Sign(rand.Reader, digest.Sum(nil), crypto.SHA256)
+	default:
+		return nil, errors.New("不支持的签名密钥类型")
+	}
+}
+
+// verifyMessage 按公钥类型验证签名
+func verifyMessage(publicKey crypto.PublicKey, message, signature []byte) error {
+	var ok bool
+	switch pub := publicKey.(type) {
+	case *sm2.PublicKey:
+		ok = pub.Verify(message, signature)
+	case ed25519.PublicKey:
+		ok = ed25519.Verify(pub, message, signature)
+	case *rsa.PublicKey:
+		digest := hashFunc(HashSHA256)()
+		digest.Write(message)
+		ok = rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest.Sum(nil), signature) == nil
+	case *ecdsa.PublicKey:
+		digest := hashFunc(HashSHA256)()
+		digest.Write(message)
+		ok = ecdsa.VerifyASN1(pub, digest.Sum(nil), signature)
+	default:
+		return errors.New("不支持的公钥类型")
+	}
+
+	if !ok {
+		return errors.New("签名验证失败")
+	}
+	return nil
+}
+
+// signatureAlgorithmName 获取公钥对应的签名算法名称
+func signatureAlgorithmName(publicKey crypto.PublicKey) (string, error) {
+	switch publicKey.(type) {
+	case *sm2.PublicKey:
+		return "SM2", nil
+	case ed25519.PublicKey:
+		return "Ed25519", nil
+	case *rsa.PublicKey:
+		return "RSA", nil
+	case *ecdsa.PublicKey:
+		return "ECDSA", nil
+	default:
+		return "", errors.New("不支持的签名密钥类型")
+	}
+}
+
+// hashFile 流式计算文件摘要
+func hashFile(path string, hashAlgo HashAlgorithm) ([]byte, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "打开文件失败")
+	}
+	defer file.Close()
+
+	h := hashFunc(hashAlgo)()
+	size, err := io.Copy(h, file)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "读取文件失败")
+	}
+	return h.Sum(nil), size, nil
+}
+
+// hashAlgorithmName 获取摘要算法名称
+func hashAlgorithmName(algo HashAlgorithm) (string, error) {
+	switch algo {
+	case HashSHA256:
+		return "SHA256", nil
+	case HashSHA512:
+		return "SHA512", nil
+	case HashSM3:
+		return "SM3", nil
+	default:
+		return "", errors.New("签名文件仅支持SHA256、SHA512与SM3")
+	}
+}
+
+// hashAlgorithmFromName 根据名称获取摘要算法
+func hashAlgorithmFromName(name string) (HashAlgorithm, error) {
+	switch strings.ToUpper(name) {
+	case "SHA256":
+		return HashSHA256, nil
+	case "SHA512":
+		return HashSHA512, nil
+	case "SM3":
+		return HashSM3, nil
+	default:
+		return 0, errors.Errorf("不支持的摘要算法: %s", name)
+	}
+}
@@ -0,0 +1,20 @@
+module github.com/sylphbyte/encrypt/device/skf
+
+go 1.24.2
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/sylphbyte/encrypt v0.0.0
+	github.com/tjfoc/gmsm v1.4.1
+)
+
+require (
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1 // indirect
+	golang.org/x/crypto v0.37.0 // indirect
+	golang.org/x/sys v0.32.0 // indirect
+	golang.org/x/text v0.24.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/sylphbyte/encrypt => ../..
@@ -0,0 +1,340 @@
+//go:build linux || darwin
+
+// Package skf 基于GM/T 0016 SKF接口的SM2 UKey设备驱动
+//
+// 运行时通过dlopen加载厂商提供的SKF动态库，导入本包即注册"skf"设备驱动：
+//
+//	import _ "github.com/sylphbyte/encrypt/device/skf"
+//
+//	sm2 := encrypt.MustNewSM2().(*encrypt.SM2Encryptor).
+//		WithDevice("skf:///usr/lib/libskf.so?device=UKEY01&application=APP&container=sign", "123456")
+//
+// 设备路径的path部分为动态库路径，查询参数device、application、container省略时使用枚举到的第一个。
+// 签名使用容器中的签名密钥对（SKF_ECCSignData），摘要e = SM3(Z || M)在本地计算。
+// 同一设备会话内的调用由互斥锁串行化，多数UKey不支持并发访问。
+// 需要cgo，作为独立子模块发布，不使用UKey时主模块无需cgo
+package skf
+
+/*
+#cgo linux LDFLAGS: -ldl
+#include <dlfcn.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef unsigned int ULONG;
+typedef int BOOL;
+typedef unsigned char BYTE;
+typedef void *HANDLE;
+
+#define SAR_OK 0
+#define SKF_USER_TYPE 1
+
+typedef struct {
+	ULONG BitLen;
+	BYTE XCoordinate[64];
+	BYTE YCoordinate[64];
+} ECCPUBLICKEYBLOB;
+
+typedef struct {
+	BYTE r[64];
+	BYTE s[64];
+} ECCSIGNATUREBLOB;
+
+typedef ULONG (*fnEnumDev)(BOOL, char *, ULONG *);
+typedef ULONG (*fnConnectDev)(char *, HANDLE *);
+typedef ULONG (*fnDisConnectDev)(HANDLE);
+typedef ULONG (*fnEnumApplication)(HANDLE, char *, ULONG *);
+typedef ULONG (*fnOpenApplication)(HANDLE, char *, HANDLE *);
+typedef ULONG (*fnCloseApplication)(HANDLE);
+typedef ULONG (*fnVerifyPIN)(HANDLE, ULONG, char *, ULONG *);
+typedef ULONG (*fnEnumContainer)(HANDLE, char *, ULONG *);
+typedef ULONG (*fnOpenContainer)(HANDLE, char *, HANDLE *);
+typedef ULONG (*fnCloseContainer)(HANDLE);
+typedef ULONG (*fnExportPublicKey)(HANDLE, BOOL, BYTE *, ULONG *);
+typedef ULONG (*fnECCSignData)(HANDLE, BYTE *, ULONG, ECCSIGNATUREBLOB *);
+
+typedef struct {
+	void *lib;
+	fnEnumDev EnumDev;
+	fnConnectDev ConnectDev;
+	fnDisConnectDev DisConnectDev;
+	fnEnumApplication EnumApplication;
+	fnOpenApplication OpenApplication;
+	fnCloseApplication CloseApplication;
+	fnVerifyPIN VerifyPIN;
+	fnEnumContainer EnumContainer;
+	fnOpenContainer OpenContainer;
+	fnCloseContainer CloseContainer;
+	fnExportPublicKey ExportPublicKey;
+	fnECCSignData ECCSignData;
+} skf_api;
+
+static const char *skf_load(const char *path, skf_api *api) {
+	memset(api, 0, sizeof(*api));
+	api->lib = dlopen(path, RTLD_NOW | RTLD_LOCAL);
+	if (api->lib == NULL) {
+		return dlerror();
+	}
+#define SKF_SYM(name) \
+	if ((api->name = (fn##name)dlsym(api->lib, "SKF_" #name)) == NULL) { \
+		dlclose(api->lib); \
+		api->lib = NULL; \
+		return "SKF_" #name; \
+	}
+	SKF_SYM(EnumDev)
+	SKF_SYM(ConnectDev)
+	SKF_SYM(DisConnectDev)
+	SKF_SYM(EnumApplication)
+	SKF_SYM(OpenApplication)
+	SKF_SYM(CloseApplication)
+	SKF_SYM(VerifyPIN)
+	SKF_SYM(EnumContainer)
+	SKF_SYM(OpenContainer)
+	SKF_SYM(CloseContainer)
+	SKF_SYM(ExportPublicKey)
+	SKF_SYM(ECCSignData)
+#undef SKF_SYM
+	return NULL;
+}
+
+static void skf_unload(skf_api *api) {
+	if (api->lib != NULL) {
+		dlclose(api->lib);
+		api->lib = NULL;
+	}
+}
+
+static ULONG skf_enum_dev(skf_api *api, char *list, ULONG *size) { return api->EnumDev(1, list, size); }
+static ULONG skf_connect_dev(skf_api *api, char *name, HANDLE *dev) { return api->ConnectDev(name, dev); }
+static ULONG skf_disconnect_dev(skf_api *api, HANDLE dev) { return api->DisConnectDev(dev); }
+static ULONG skf_enum_application(skf_api *api, HANDLE dev, char *list, ULONG *size) { return api->EnumApplication(dev, list, size); }
+static ULONG skf_open_application(skf_api *api, HANDLE dev, char *name, HANDLE *app) { return api->OpenApplication(dev, name, app); }
+static ULONG skf_close_application(skf_api *api, HANDLE app) { return api->CloseApplication(app); }
+static ULONG skf_verify_pin(skf_api *api, HANDLE app, char *pin, ULONG *retry) { return api->VerifyPIN(app, SKF_USER_TYPE, pin, retry); }
+static ULONG skf_enum_container(skf_api *api, HANDLE app, char *list, ULONG *size) { return api->EnumContainer(app, list, size); }
+static ULONG skf_open_container(skf_api *api, HANDLE app, char *name, HANDLE *con) { return api->OpenContainer(app, name, con); }
+static ULONG skf_close_container(skf_api *api, HANDLE con) { return api->CloseContainer(con); }
+static ULONG skf_export_sign_public_key(skf_api *api, HANDLE con, ECCPUBLICKEYBLOB *blob) {
+	ULONG size = sizeof(*blob);
+	return api->ExportPublicKey(con, 1, (BYTE *)blob, &size);
+}
+static ULONG skf_ecc_sign(skf_api *api, HANDLE con, BYTE *digest, ULONG len, ECCSIGNATUREBLOB *sig) { return api->ECCSignData(con, digest, len, sig); }
+*/
+import "C"
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net/url"
+	"sync"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"github.com/sylphbyte/encrypt"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// Scheme 设备路径中的驱动名
+const Scheme = "skf"
+
+// coordinateOffset ECC数据块中坐标按512位右对齐，SM2只使用后32字节
+const coordinateOffset = 32
+
+func init() {
+	encrypt.RegisterSM2DeviceDriver(Scheme, Open)
+}
+
+// Error SKF接口返回的错误码
+type Error struct {
+	Func string
+	Code uint32
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s失败，错误码0x%08X", e.Func, e.Code)
+}
+
+// Device SKF设备会话，实现encrypt.SM2Device
+type Device struct {
+	mu        sync.Mutex
+	api       *C.skf_api
+	dev       C.HANDLE
+	app       C.HANDLE
+	container C.HANDLE
+}
+
+var _ encrypt.SM2Device = (*Device)(nil)
+
+// Open 按设备路径打开UKey并校验用户PIN
+// 路径格式 skf://<动态库路径>?device=<设备名>&application=<应用名>&container=<容器名>
+func Open(devicePath, pin string) (encrypt.SM2Device, error) {
+	u, err := url.Parse(devicePath)
+	if err != nil || u.Scheme != Scheme || u.Path == "" {
+		return nil, errors.Errorf("设备路径格式不正确: %s", devicePath)
+	}
+	query := u.Query()
+
+	d := &Device{api: (*C.skf_api)(C.calloc(1, C.size_t(unsafe.Sizeof(C.skf_api{}))))}
+	libPath := C.CString(u.Path)
+	defer C.free(unsafe.Pointer(libPath))
+	if msg := C.skf_load(libPath, d.api); msg != nil {
+		C.free(unsafe.Pointer(d.api))
+		return nil, errors.Errorf("加载SKF动态库失败: %s", C.GoString(msg))
+	}
+
+	if err := d.open(query.Get("device"), query.Get("application"), query.Get("container"), pin); err != nil {
+		d.Close()
+		return nil, err
+	}
+	return d, nil
+}
+
+// open 依次连接设备、打开应用、校验PIN、打开容器
+func (d *Device) open(devName, appName, containerName, pin string) error {
+	if devName == "" {
+		names, err := d.enum("SKF_EnumDev", func(list *C.char, size *C.ULONG) C.ULONG {
+			return C.skf_enum_dev(d.api, list, size)
+		})
+		if err != nil {
+			return err
+		}
+		devName = names[0]
+	}
+	name := C.CString(devName)
+	defer C.free(unsafe.Pointer(name))
+	if rv := C.skf_connect_dev(d.api, name, &d.dev); rv != C.SAR_OK {
+		return &Error{Func: "SKF_ConnectDev", Code: uint32(rv)}
+	}
+
+	if appName == "" {
+		names, err := d.enum("SKF_EnumApplication", func(list *C.char, size *C.ULONG) C.ULONG {
+			return C.skf_enum_application(d.api, d.dev, list, size)
+		})
+		if err != nil {
+			return err
+		}
+		appName = names[0]
+	}
+	app := C.CString(appName)
+	defer C.free(unsafe.Pointer(app))
+	if rv := C.skf_open_application(d.api, d.dev, app, &d.app); rv != C.SAR_OK {
+		return &Error{Func: "SKF_OpenApplication", Code: uint32(rv)}
+	}
+
+	cpin := C.CString(pin)
+	defer func() {
+		C.memset(unsafe.Pointer(cpin), 0, C.size_t(len(pin)))
+		C.free(unsafe.Pointer(cpin))
+	}()
+	var retry C.ULONG
+	if rv := C.skf_verify_pin(d.api, d.app, cpin, &retry); rv != C.SAR_OK {
+		return errors.Wrapf(&Error{Func: "SKF_VerifyPIN", Code: uint32(rv)}, "PIN校验失败，剩余重试次数%d", uint32(retry))
+	}
+
+	if containerName == "" {
+		names, err := d.enum("SKF_EnumContainer", func(list *C.char, size *C.ULONG) C.ULONG {
+			return C.skf_enum_container(d.api, d.app, list, size)
+		})
+		if err != nil {
+			return err
+		}
+		containerName = names[0]
+	}
+	container := C.CString(containerName)
+	defer C.free(unsafe.Pointer(container))
+	if rv := C.skf_open_container(d.api, d.app, container, &d.container); rv != C.SAR_OK {
+		return &Error{Func: "SKF_OpenContainer", Code: uint32(rv)}
+	}
+	return nil
+}
+
+// enum 调用SKF枚举接口，先取长度再取以\0分隔、以双\0结尾的名称列表
+func (d *Device) enum(fn string, call func(list *C.char, size *C.ULONG) C.ULONG) ([]string, error) {
+	var size C.ULONG
+	if rv := call(nil, &size); rv != C.SAR_OK {
+		return nil, &Error{Func: fn, Code: uint32(rv)}
+	}
+	if size == 0 {
+		return nil, errors.Errorf("%s没有枚举到任何结果", fn)
+	}
+	buf := (*C.char)(C.calloc(1, C.size_t(size)))
+	defer C.free(unsafe.Pointer(buf))
+	if rv := call(buf, &size); rv != C.SAR_OK {
+		return nil, &Error{Func: fn, Code: uint32(rv)}
+	}
+
+	var names []string
+	for _, name := range bytes.Split(C.GoBytes(unsafe.Pointer(buf), C.int(size)), []byte{0}) {
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	if len(names) == 0 {
+		return nil, errors.Errorf("%s没有枚举到任何结果", fn)
+	}
+	return names, nil
+}
+
+// PublicKey 导出容器的签名公钥
+func (d *Device) PublicKey() (*sm2.PublicKey, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	var blob C.ECCPUBLICKEYBLOB
+	if rv := C.skf_export_sign_public_key(d.api, d.container, &blob); rv != C.SAR_OK {
+		return nil, &Error{Func: "SKF_ExportPublicKey", Code: uint32(rv)}
+	}
+	x := C.GoBytes(unsafe.Pointer(&blob.XCoordinate[coordinateOffset]), 32)
+	y := C.GoBytes(unsafe.Pointer(&blob.YCoordinate[coordinateOffset]), 32)
+	pubKey := &sm2.PublicKey{Curve: sm2.P256Sm2(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !pubKey.Curve.IsOnCurve(pubKey.X, pubKey.Y) {
+		return nil, errors.New("设备返回的公钥不在SM2曲线上")
+	}
+	return pubKey, nil
+}
+
+// SignDigest 使用容器的签名私钥对32字节摘要签名
+func (d *Device) SignDigest(digest []byte) (*big.Int, *big.Int, error) {
+	if len(digest) != 32 {
+		return nil, nil, errors.New("摘要长度必须是32字节")
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	buf := C.CBytes(digest)
+	defer C.free(buf)
+	var sig C.ECCSIGNATUREBLOB
+	if rv := C.skf_ecc_sign(d.api, d.container, (*C.BYTE)(buf), C.ULONG(len(digest)), &sig); rv != C.SAR_OK {
+		return nil, nil, &Error{Func: "SKF_ECCSignData", Code: uint32(rv)}
+	}
+	r := C.GoBytes(unsafe.Pointer(&sig.r[coordinateOffset]), 32)
+	s := C.GoBytes(unsafe.Pointer(&sig.s[coordinateOffset]), 32)
+	return new(big.Int).SetBytes(r), new(big.Int).SetBytes(s), nil
+}
+
+// Close 关闭容器、应用并断开设备，卸载动态库
+func (d *Device) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.api == nil {
+		return nil
+	}
+	if d.container != nil {
+		C.skf_close_container(d.api, d.container)
+		d.container = nil
+	}
+	if d.app != nil {
+		C.skf_close_application(d.api, d.app)
+		d.app = nil
+	}
+	if d.dev != nil {
+		C.skf_disconnect_dev(d.api, d.dev)
+		d.dev = nil
+	}
+	C.skf_unload(d.api)
+	C.free(unsafe.Pointer(d.api))
+	d.api = nil
+	return nil
+}
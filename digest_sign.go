@@ -0,0 +1,165 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 预计算摘要的签名与验签
+// 适用于已经在流式管道或HSM中完成哈希计算的调用方，避免对原文重复计算摘要
+
+// SignDigest 对预先计算的SHA-256摘要进行RSA签名
+// 结果与对原文调用Sign完全一致，可以互相验证
+func (r *RSAEncryptor) SignDigest(digest []byte) ([]byte, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("未设置私钥")
+	}
+	if len(digest) != sha256.Size {
+		return nil, errors.Errorf("摘要长度必须是%d字节", sha256.Size)
+	}
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, r.privateKey, crypto.SHA256, digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "RSA签名失败")
+	}
+
+	// 编码处理
+	return r.encoding.Encode(signature)
+}
+
+// VerifyDigest 使用预先计算的SHA-256摘要验证RSA签名
+func (r *RSAEncryptor) VerifyDigest(digest []byte, signature []byte) (bool, error) {
+	if r.publicKey == nil {
+		return false, errors.New("未设置公钥")
+	}
+	if len(digest) != sha256.Size {
+		return false, errors.Errorf("摘要长度必须是%d字节", sha256.Size)
+	}
+
+	// 解码签名
+	decoded, err := r.encoding.Decode(signature)
+	if err != nil {
+		return false, errors.Wrap(err, "解码签名失败")
+	}
+
+	if err := rsa.VerifyPKCS1v15(r.publicKey, crypto.SHA256, digest, decoded); err != nil {
+		return false, nil // 签名验证失败，但不是错误
+	}
+	return true, nil
+}
+
+// SignDigest 对预先计算的SM2摘要e进行签名
+//
+// SM2的摘要不是原文的SM3值，而是 e = SM3(Z || M)，其中Z由公钥与UID计算得到（见GM/T 0003.2），
+// 因此调用方必须使用与验签方相同的UID计算Z值，否则签名无法通过验证。
+// 结果与对原文调用Sign一致，可以互相验证
+func (s *SM2Encryptor) SignDigest(digest []byte) ([]byte, error) {
+	if len(digest) != sm2CoordinateSize {
+		return nil, errors.Errorf("摘要长度必须是%d字节", sm2CoordinateSize)
+	}
+	if device, ok := s.privateKey.(SM2Device); ok {
+		signature, err := signWithDevice(device, digest)
+		if err != nil {
+			return nil, err
+		}
+		return s.encoding.Encode(signature)
+	}
+	privKey, ok := s.privateKey.(*sm2.PrivateKey)
+	if !ok {
+		return nil, errors.New("未设置私钥或私钥类型不正确")
+	}
+
+	r, s0, err := sm2SignDigest(privKey, digest, rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "SM2签名失败")
+	}
+
+	// 将r,s转换为签名数据
+	signature, err := sm2.SignDigitToSignData(r, s0)
+	if err != nil {
+		return nil, errors.Wrap(err, "转换签名数据失败")
+	}
+
+	// 编码处理
+	return s.encoding.Encode(signature)
+}
+
+// VerifyDigest 使用预先计算的SM2摘要e验证签名，e的计算方式见SignDigest
+func (s *SM2Encryptor) VerifyDigest(digest []byte, signature []byte) (bool, error) {
+	pubKey, ok := s.publicKey.(*sm2.PublicKey)
+	if !ok {
+		return false, errors.New("未设置公钥或公钥类型不正确")
+	}
+	if len(digest) != sm2CoordinateSize {
+		return false, errors.Errorf("摘要长度必须是%d字节", sm2CoordinateSize)
+	}
+
+	// 解码签名
+	decoded, err := s.encoding.Decode(signature)
+	if err != nil {
+		return false, errors.Wrap(err, "解码签名失败")
+	}
+
+	// 将签名数据转换为r,s
+	r, s0, err := sm2.SignDataToSignDigit(decoded)
+	if err != nil {
+		return false, errors.Wrap(err, "解析签名格式失败")
+	}
+
+	return sm2.Verify(pubKey, digest, r, s0), nil
+}
+
+// sm2SignDigest 对摘要e执行SM2签名运算（GM/T 0003.2 第6.1节 A3-A7步骤）
+func sm2SignDigest(privKey *sm2.PrivateKey, digest []byte, random io.Reader) (*big.Int, *big.Int, error) {
+	curve := privKey.Curve
+	n := curve.Params().N
+	e := new(big.Int).SetBytes(digest)
+	one := big.NewInt(1)
+
+	for {
+		k, err := randScalar(n, random)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		// r = (e + x1) mod n
+		x1, _ := curve.ScalarBaseMult(k.Bytes())
+		r := new(big.Int).Add(e, x1)
+		r.Mod(r, n)
+		if r.Sign() == 0 || new(big.Int).Add(r, k).Cmp(n) == 0 {
+			continue
+		}
+
+		// s = ((1 + d)^-1 * (k - r*d)) mod n
+		dInv := new(big.Int).ModInverse(new(big.Int).Add(privKey.D, one), n)
+		s := new(big.Int).Mul(r, privKey.D)
+		s.Sub(k, s)
+		s.Mul(s, dInv)
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+		return r, s, nil
+	}
+}
+
+// randScalar 生成[1, n-1]范围内的随机标量
+func randScalar(n *big.Int, random io.Reader) (*big.Int, error) {
+	buf := make([]byte, n.BitLen()/8+8)
+	if _, err := io.ReadFull(random, buf); err != nil {
+		return nil, errors.Wrap(err, "生成随机数失败")
+	}
+
+	k := new(big.Int).SetBytes(buf)
+	nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+	k.Mod(k, nMinus1)
+	k.Add(k, big.NewInt(1))
+	return k, nil
+}
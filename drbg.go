@@ -0,0 +1,360 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+
+	"github.com/pkg/errors"
+)
+
+// 确定性随机比特生成器（NIST SP 800-90A）
+//
+//   - HMACDRBG：HMAC_DRBG，默认HMAC-SHA256
+//   - CTRDRBG：CTR_DRBG，AES-256，使用派生函数（entropy/nonce/个性化串可为任意长度）
+//
+// 相同的熵输入、nonce与个性化串总是产生相同的输出序列，可作为测试中的可复现随机源
+// （SetRandomReader），也可在要求使用SP 800-90A DRBG的环境中替代crypto/rand：
+// 通过WithEntropySource设置熵源后，达到重播种间隔时自动从熵源重播种。
+// 单次请求最多生成65536字节，Read会自动分段
+
+// DRBG相关常量
+const (
+	drbgMaxRequestBytes       = 1 << 16 // 2^19比特
+	drbgDefaultReseedInterval = 1 << 48
+	drbgMinEntropyBytes       = 32
+	ctrDRBGKeyLen             = 32
+	ctrDRBGSeedLen            = ctrDRBGKeyLen + aes.BlockSize
+)
+
+// ErrDRBGReseedRequired 达到重播种间隔且未设置熵源
+var ErrDRBGReseedRequired = errors.New("DRBG需要重播种")
+
+// DRBG 确定性随机比特生成器
+type DRBG interface {
+	RandomReader
+	// Generate 生成len(p)字节，additional为可选附加输入
+	Generate(p, additional []byte) error
+	// Reseed 使用新的熵输入重播种
+	Reseed(entropy, additional []byte) error
+}
+
+// 编译期检查
+var (
+	_ DRBG = (*HMACDRBG)(nil)
+	_ DRBG = (*CTRDRBG)(nil)
+)
+
+// drbgReseeder 重播种计数与自动重播种
+type drbgReseeder struct {
+	counter  uint64
+	interval uint64
+	source   EntropySource
+}
+
+// check 检查是否需要重播种，设置了熵源时自动重播种
+func (r *drbgReseeder) check(reseed func(entropy, additional []byte) error) error {
+	if r.counter <= r.interval {
+		return nil
+	}
+	if r.source == nil {
+		return ErrDRBGReseedRequired
+	}
+	entropy := make([]byte, drbgMinEntropyBytes)
+	defer zeroBytes(entropy)
+	if _, err := r.source.Read(entropy); err != nil {
+		return errors.Wrap(err, "读取重播种熵失败")
+	}
+	return reseed(entropy, nil)
+}
+
+// checkEntropy 检查熵输入长度
+func checkDRBGEntropy(entropy []byte) error {
+	if len(entropy) < drbgMinEntropyBytes {
+		return errors.Errorf("熵输入至少需要%d字节", drbgMinEntropyBytes)
+	}
+	return nil
+}
+
+// HMACDRBG HMAC_DRBG（SP 800-90A 10.1.2）
+type HMACDRBG struct {
+	drbgReseeder
+	k []byte
+	v []byte
+	h func() hash.Hash
+}
+
+// NewHMACDRBG 创建HMAC-SHA256 DRBG，entropy至少32字节，nonce与personalization可为空
+func NewHMACDRBG(entropy, nonce, personalization []byte) (*HMACDRBG, error) {
+	return NewHMACDRBGWithHash(sha256.New, entropy, nonce, personalization)
+}
+
+// NewHMACDRBGWithHash 使用指定哈希创建HMAC_DRBG
+func NewHMACDRBGWithHash(h func() hash.Hash, entropy, nonce, personalization []byte) (*HMACDRBG, error) {
+	if err := checkDRBGEntropy(entropy); err != nil {
+		return nil, err
+	}
+	seed := make([]byte, 0, len(entropy)+len(nonce)+len(personalization))
+	seed = append(append(append(seed, entropy...), nonce...), personalization...)
+	defer zeroBytes(seed)
+	return newHMACDRBGWithHash(h, seed), nil
+}
+
+// newHMACDRBG 使用种子初始化HMAC-SHA256 DRBG，不检查种子长度
+func newHMACDRBG(seed []byte) *HMACDRBG {
+	return newHMACDRBGWithHash(sha256.New, seed)
+}
+
+// newHMACDRBGWithHash 实例化：K = 0x00..，V = 0x01..，Update(seed)
+func newHMACDRBGWithHash(h func() hash.Hash, seed []byte) *HMACDRBG {
+	size := h().Size()
+	d := &HMACDRBG{
+		drbgReseeder: drbgReseeder{counter: 1, interval: drbgDefaultReseedInterval},
+		h:            h,
+		k:            make([]byte, size),
+		v:            make([]byte, size),
+	}
+	for i := range d.v {
+		d.v[i] = 0x01
+	}
+	d.update(seed)
+	return d
+}
+
+// WithEntropySource 设置重播种熵源，达到重播种间隔时自动重播种
+func (d *HMACDRBG) WithEntropySource(source EntropySource) *HMACDRBG {
+	d.source = source
+	return d
+}
+
+// WithReseedInterval 设置重播种间隔（生成请求次数）
+func (d *HMACDRBG) WithReseedInterval(interval uint64) *HMACDRBG {
+	d.interval = interval
+	return d
+}
+
+// Reseed 重播种：Update(entropy || additional)
+func (d *HMACDRBG) Reseed(entropy, additional []byte) error {
+	if err := checkDRBGEntropy(entropy); err != nil {
+		return err
+	}
+	d.update(append(append([]byte(nil), entropy...), additional...))
+	d.counter = 1
+	return nil
+}
+
+// Generate 生成伪随机字节，单次最多65536字节
+func (d *HMACDRBG) Generate(p, additional []byte) error {
+	if len(p) > drbgMaxRequestBytes {
+		return errors.New("单次请求超过65536字节")
+	}
+	if err := d.check(d.Reseed); err != nil {
+		return err
+	}
+
+	if len(additional) > 0 {
+		d.update(additional)
+	}
+	for n := 0; n < len(p); {
+		d.v = d.mac(d.k, d.v)
+		n += copy(p[n:], d.v)
+	}
+	d.update(additional)
+	d.counter++
+	return nil
+}
+
+// Read 生成确定性伪随机字节，实现io.Reader
+func (d *HMACDRBG) Read(p []byte) (int, error) {
+	return drbgRead(d, p)
+}
+
+// update 更新内部状态K和V
+func (d *HMACDRBG) update(data []byte) {
+	d.k = d.mac(d.k, d.v, []byte{0x00}, data)
+	d.v = d.mac(d.k, d.v)
+	if len(data) == 0 {
+		return
+	}
+	d.k = d.mac(d.k, d.v, []byte{0x01}, data)
+	d.v = d.mac(d.k, d.v)
+}
+
+// mac 计算HMAC(key, data...)
+func (d *HMACDRBG) mac(key []byte, data ...[]byte) []byte {
+	m := hmac.New(d.h, key)
+	for _, part := range data {
+		m.Write(part)
+	}
+	return m.Sum(nil)
+}
+
+// CTRDRBG AES-256 CTR_DRBG，使用派生函数（SP 800-90A 10.2）
+type CTRDRBG struct {
+	drbgReseeder
+	block cipher.Block
+	v     []byte
+}
+
+// NewCTRDRBG 创建AES-256 CTR_DRBG，entropy至少32字节，nonce与personalization可为空
+func NewCTRDRBG(entropy, nonce, personalization []byte) (*CTRDRBG, error) {
+	if err := checkDRBGEntropy(entropy); err != nil {
+		return nil, err
+	}
+
+	seed := make([]byte, 0, len(entropy)+len(nonce)+len(personalization))
+	seed = append(append(append(seed, entropy...), nonce...), personalization...)
+	defer zeroBytes(seed)
+
+	d := &CTRDRBG{
+		drbgReseeder: drbgReseeder{counter: 1, interval: drbgDefaultReseedInterval},
+		v:            make([]byte, aes.BlockSize),
+	}
+	d.block, _ = aes.NewCipher(make([]byte, ctrDRBGKeyLen))
+	d.update(ctrDRBGDerive(seed))
+	return d, nil
+}
+
+// WithEntropySource 设置重播种熵源，达到重播种间隔时自动重播种
+func (d *CTRDRBG) WithEntropySource(source EntropySource) *CTRDRBG {
+	d.source = source
+	return d
+}
+
+// WithReseedInterval 设置重播种间隔（生成请求次数）
+func (d *CTRDRBG) WithReseedInterval(interval uint64) *CTRDRBG {
+	d.interval = interval
+	return d
+}
+
+// Reseed 重播种：Update(df(entropy || additional))
+func (d *CTRDRBG) Reseed(entropy, additional []byte) error {
+	if err := checkDRBGEntropy(entropy); err != nil {
+		return err
+	}
+	d.update(ctrDRBGDerive(append(append([]byte(nil), entropy...), additional...)))
+	d.counter = 1
+	return nil
+}
+
+// Generate 生成伪随机字节，单次最多65536字节
+func (d *CTRDRBG) Generate(p, additional []byte) error {
+	if len(p) > drbgMaxRequestBytes {
+		return errors.New("单次请求超过65536字节")
+	}
+	if err := d.check(d.Reseed); err != nil {
+		return err
+	}
+
+	provided := make([]byte, ctrDRBGSeedLen)
+	if len(additional) > 0 {
+		provided = ctrDRBGDerive(additional)
+		d.update(provided)
+	}
+
+	block := make([]byte, aes.BlockSize)
+	for n := 0; n < len(p); {
+		ctrDRBGIncrement(d.v)
+		d.block.Encrypt(block, d.v)
+		n += copy(p[n:], block)
+	}
+	zeroBytes(block)
+
+	d.update(provided)
+	d.counter++
+	return nil
+}
+
+// Read 生成确定性伪随机字节，实现io.Reader
+func (d *CTRDRBG) Read(p []byte) (int, error) {
+	return drbgRead(d, p)
+}
+
+// update CTR_DRBG_Update：生成seedlen字节与provided异或，前32字节为新Key，后16字节为新V
+func (d *CTRDRBG) update(provided []byte) {
+	temp := make([]byte, ctrDRBGSeedLen)
+	for i := 0; i < ctrDRBGSeedLen; i += aes.BlockSize {
+		ctrDRBGIncrement(d.v)
+		d.block.Encrypt(temp[i:], d.v)
+	}
+	for i := range temp {
+		temp[i] ^= provided[i]
+	}
+	d.block, _ = aes.NewCipher(temp[:ctrDRBGKeyLen])
+	copy(d.v, temp[ctrDRBGKeyLen:])
+	zeroBytes(temp)
+}
+
+// ctrDRBGDerive Block_Cipher_df，输出seedlen字节
+func ctrDRBGDerive(input []byte) []byte {
+	// S = L || N || input || 0x80，补零到分组长度整数倍
+	s := make([]byte, 8, 8+len(input)+aes.BlockSize)
+	binary.BigEndian.PutUint32(s[0:4], uint32(len(input)))
+	binary.BigEndian.PutUint32(s[4:8], ctrDRBGSeedLen)
+	s = append(append(s, input...), 0x80)
+	for len(s)%aes.BlockSize != 0 {
+		s = append(s, 0)
+	}
+
+	initialKey := make([]byte, ctrDRBGKeyLen)
+	for i := range initialKey {
+		initialKey[i] = byte(i)
+	}
+	block, _ := aes.NewCipher(initialKey)
+
+	temp := make([]byte, 0, ctrDRBGSeedLen)
+	iv := make([]byte, aes.BlockSize)
+	for i := uint32(0); len(temp) < ctrDRBGSeedLen; i++ {
+		binary.BigEndian.PutUint32(iv, i)
+		temp = append(temp, ctrDRBGBCC(block, iv, s)...)
+	}
+
+	block, _ = aes.NewCipher(temp[:ctrDRBGKeyLen])
+	x := temp[ctrDRBGKeyLen:ctrDRBGSeedLen]
+	out := make([]byte, 0, ctrDRBGSeedLen)
+	for len(out) < ctrDRBGSeedLen {
+		block.Encrypt(x, x)
+		out = append(out, x...)
+	}
+	zeroBytes(temp)
+	zeroBytes(s)
+	return out
+}
+
+// ctrDRBGBCC BCC(K, IV || S)：CBC-MAC
+func ctrDRBGBCC(block cipher.Block, iv, s []byte) []byte {
+	chain := make([]byte, aes.BlockSize)
+	block.Encrypt(chain, iv)
+	for i := 0; i < len(s); i += aes.BlockSize {
+		for j := 0; j < aes.BlockSize; j++ {
+			chain[j] ^= s[i+j]
+		}
+		block.Encrypt(chain, chain)
+	}
+	return chain
+}
+
+// ctrDRBGIncrement V = (V + 1) mod 2^128
+func ctrDRBGIncrement(v []byte) {
+	for i := len(v) - 1; i >= 0; i-- {
+		v[i]++
+		if v[i] != 0 {
+			return
+		}
+	}
+}
+
+// drbgRead 按单次请求上限分段生成
+func drbgRead(d DRBG, p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		end := min(n+drbgMaxRequestBytes, len(p))
+		if err := d.Generate(p[n:end], nil); err != nil {
+			return n, err
+		}
+		n = end
+	}
+	return len(p), nil
+}
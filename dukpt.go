@@ -0,0 +1,345 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/des"
+	"crypto/subtle"
+	"encoding/binary"
+	"math/bits"
+
+	"github.com/pkg/errors"
+)
+
+// DUKPT（ANSI X9.24）
+//
+// 每笔交易使用独立的密钥：终端只持有由基础派生密钥（BDK）与密钥序列号（KSN）派生的初始密钥，
+// 每笔交易递增KSN中的交易计数器并派生新的交易密钥，单笔交易密钥泄露不影响其他交易。
+// 这里实现主机侧：由BDK与报文中的KSN直接计算交易密钥，用于解密PIN块与验证MAC。
+//
+//   - TDES DUKPT（X9.24-1:2009）：BDK为16字节双倍长3DES密钥，KSN为10字节，低21位为交易计数器，
+//     交易密钥经变体（PIN、MAC、数据）得到工作密钥，PIN使用ISO格式0，MAC使用ANSI X9.19零售MAC
+//   - AES DUKPT（X9.24-3:2017）：BDK为AES-128/192/256，KSN为12字节（8字节初始密钥ID | 4字节计数器），
+//     工作密钥由AES派生函数按用途生成，PIN使用ISO格式4，MAC使用AES-CMAC
+
+// DUKPTUsage 工作密钥用途
+type DUKPTUsage int
+
+// 工作密钥用途常量定义
+const (
+	DUKPTPIN          DUKPTUsage = iota + 1 // PIN加密
+	DUKPTMACRequest                         // 请求报文MAC（AES：MAC生成）
+	DUKPTMACResponse                        // 响应报文MAC（AES：MAC验证）
+	DUKPTDataRequest                        // 请求数据加密
+	DUKPTDataResponse                       // 响应数据加密
+)
+
+// DUKPT KSN长度
+const (
+	TDESDUKPTKSNSize = 10
+	AESDUKPTKSNSize  = 12
+)
+
+// AES DUKPT 派生数据中的用途与算法标识
+const (
+	aesDUKPTUsageKeyDerivation = 0x8000
+	aesDUKPTUsageInitialKey    = 0x8001
+)
+
+// tdesDUKPTKeyMask 派生IPEK右半部分与不可逆密钥生成过程中使用的掩码
+var tdesDUKPTKeyMask = []byte{0xC0, 0xC0, 0xC0, 0xC0, 0x00, 0x00, 0x00, 0x00, 0xC0, 0xC0, 0xC0, 0xC0, 0x00, 0x00, 0x00, 0x00}
+
+// DUKPT 主机侧DUKPT派生器
+type DUKPT struct {
+	bdk         []byte
+	aes         bool
+	workingSize int
+}
+
+// NewTDESDUKPT 创建TDES DUKPT派生器，bdk为16字节双倍长密钥
+func NewTDESDUKPT(bdk []byte) (*DUKPT, error) {
+	if len(bdk) != 16 {
+		return nil, errors.New("TDES DUKPT的BDK长度必须是16字节")
+	}
+	return &DUKPT{bdk: bdk, workingSize: 16}, nil
+}
+
+// NewAESDUKPT 创建AES DUKPT派生器，bdk为16、24或32字节，工作密钥默认与BDK等长
+func NewAESDUKPT(bdk []byte) (*DUKPT, error) {
+	if len(bdk) != 16 && len(bdk) != 24 && len(bdk) != 32 {
+		return nil, errors.New("AES DUKPT的BDK长度必须是16、24或32字节")
+	}
+	return &DUKPT{bdk: bdk, aes: true, workingSize: len(bdk)}, nil
+}
+
+// WithWorkingKeySize 设置AES工作密钥长度，不能超过BDK长度
+func (d *DUKPT) WithWorkingKeySize(size int) *DUKPT {
+	if !d.aes || (size != 16 && size != 24 && size != 32) || size > len(d.bdk) {
+		panic("AES DUKPT工作密钥长度必须是16、24或32字节且不超过BDK长度")
+	}
+	d.workingSize = size
+	return d
+}
+
+// InitialKey 计算终端的初始密钥（TDES为IPEK），用于注入终端
+func (d *DUKPT) InitialKey(ksn []byte) ([]byte, error) {
+	if d.aes {
+		if len(ksn) != AESDUKPTKSNSize {
+			return nil, errors.Errorf("AES DUKPT的KSN长度必须是%d字节", AESDUKPTKSNSize)
+		}
+		return d.aesInitialKey(ksn[:8])
+	}
+	if len(ksn) != TDESDUKPTKSNSize {
+		return nil, errors.Errorf("TDES DUKPT的KSN长度必须是%d字节", TDESDUKPTKSNSize)
+	}
+	return tdesDUKPTIPEK(d.bdk, ksn)
+}
+
+// WorkingKey 计算KSN对应交易的工作密钥
+func (d *DUKPT) WorkingKey(ksn []byte, usage DUKPTUsage) ([]byte, error) {
+	if d.aes {
+		return d.aesWorkingKey(ksn, usage)
+	}
+	return d.tdesWorkingKey(ksn, usage)
+}
+
+// EncryptPIN 加密PIN块：TDES使用格式0，AES使用格式4
+func (d *DUKPT) EncryptPIN(ksn []byte, pin, pan string) ([]byte, error) {
+	key, err := d.WorkingKey(ksn, DUKPTPIN)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+
+	if d.aes {
+		return EncryptPINBlockFormat4(key, pin, pan)
+	}
+	block, err := PINBlockFormat0(pin, pan)
+	if err != nil {
+		return nil, err
+	}
+	cipher, err := newTripleDESBlock(key)
+	if err != nil {
+		return nil, err
+	}
+	cipher.Encrypt(block, block)
+	return block, nil
+}
+
+// DecryptPIN 解密PIN块
+func (d *DUKPT) DecryptPIN(ksn, encrypted []byte, pan string) (string, error) {
+	key, err := d.WorkingKey(ksn, DUKPTPIN)
+	if err != nil {
+		return "", err
+	}
+	defer zeroBytes(key)
+
+	if d.aes {
+		return DecryptPINBlockFormat4(key, encrypted, pan)
+	}
+	if len(encrypted) != 8 {
+		return "", errors.New("格式0 PIN块长度必须是8字节")
+	}
+	cipher, err := newTripleDESBlock(key)
+	if err != nil {
+		return "", err
+	}
+	block := make([]byte, 8)
+	defer zeroBytes(block)
+	cipher.Decrypt(block, encrypted)
+	return ParsePINBlockFormat0(block, pan)
+}
+
+// MAC 计算报文MAC，usage为DUKPTMACRequest或DUKPTMACResponse：TDES返回8字节零售MAC，AES返回16字节CMAC
+func (d *DUKPT) MAC(ksn []byte, usage DUKPTUsage, data []byte) ([]byte, error) {
+	if usage != DUKPTMACRequest && usage != DUKPTMACResponse {
+		return nil, errors.New("MAC用途必须是请求或响应")
+	}
+	key, err := d.WorkingKey(ksn, usage)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+
+	if d.aes {
+		return CMAC(AlgorithmAES, key, data)
+	}
+	return RetailMAC(key, data)
+}
+
+// VerifyMAC 常量时间验证MAC，mac可以是截断后的前缀（至少4字节）
+func (d *DUKPT) VerifyMAC(ksn []byte, usage DUKPTUsage, data, mac []byte) bool {
+	expected, err := d.MAC(ksn, usage, data)
+	if err != nil || len(mac) < 4 || len(mac) > len(expected) {
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected[:len(mac)], mac) == 1
+}
+
+// tdesWorkingKey 由IPEK经不可逆密钥生成过程得到交易密钥，再应用用途变体
+func (d *DUKPT) tdesWorkingKey(ksn []byte, usage DUKPTUsage) ([]byte, error) {
+	key, err := d.InitialKey(ksn)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := uint64(ksn[7]&0x1f)<<16 | uint64(ksn[8])<<8 | uint64(ksn[9])
+	if bits.OnesCount64(counter) > 10 {
+		return nil, errors.New("交易计数器无效：置位数超过10")
+	}
+	register := binary.BigEndian.Uint64(ksn[2:]) &^ 0x1fffff
+	for shift := uint64(1 << 20); shift > 0; shift >>= 1 {
+		if counter&shift != 0 {
+			register |= shift
+			next, err := tdesDUKPTNonReversible(key, register)
+			zeroBytes(key)
+			if err != nil {
+				return nil, err
+			}
+			key = next
+		}
+	}
+
+	// 变体掩码作用于两半密钥的同一字节
+	variants := map[DUKPTUsage]int{DUKPTPIN: 7, DUKPTMACRequest: 6, DUKPTMACResponse: 4, DUKPTDataRequest: 5, DUKPTDataResponse: 3}
+	pos, ok := variants[usage]
+	if !ok {
+		zeroBytes(key)
+		return nil, errors.New("未知的密钥用途")
+	}
+	key[pos] ^= 0xff
+	key[pos+8] ^= 0xff
+
+	// 数据加密密钥再以自身加密两半，变体密钥不可由数据密钥反推
+	if usage == DUKPTDataRequest || usage == DUKPTDataResponse {
+		cipher, err := newTripleDESBlock(key)
+		if err != nil {
+			return nil, err
+		}
+		cipher.Encrypt(key[:8], key[:8])
+		cipher.Encrypt(key[8:], key[8:])
+	}
+	return key, nil
+}
+
+// tdesDUKPTIPEK 由BDK与KSN派生IPEK
+func tdesDUKPTIPEK(bdk, ksn []byte) ([]byte, error) {
+	data := append([]byte(nil), ksn[:8]...)
+	data[7] &= 0xe0
+
+	ipek := make([]byte, 16)
+	left, err := newTripleDESBlock(bdk)
+	if err != nil {
+		return nil, err
+	}
+	left.Encrypt(ipek[:8], data)
+
+	masked := append([]byte(nil), bdk...)
+	defer zeroBytes(masked)
+	subtleXOR(masked, tdesDUKPTKeyMask)
+	right, err := newTripleDESBlock(masked)
+	if err != nil {
+		return nil, err
+	}
+	right.Encrypt(ipek[8:], data)
+	return ipek, nil
+}
+
+// tdesDUKPTNonReversible 不可逆密钥生成过程，输出新的16字节密钥
+func tdesDUKPTNonReversible(key []byte, register uint64) ([]byte, error) {
+	out := make([]byte, 16)
+	half := func(k []byte, dst []byte) error {
+		cipher, err := des.NewCipher(k[:8])
+		if err != nil {
+			return errors.Wrap(err, "创建DES失败")
+		}
+		binary.BigEndian.PutUint64(dst, register)
+		subtleXOR(dst, k[8:])
+		cipher.Encrypt(dst, dst)
+		subtleXOR(dst, k[8:])
+		return nil
+	}
+
+	if err := half(key, out[8:]); err != nil {
+		return nil, err
+	}
+	masked := append([]byte(nil), key...)
+	defer zeroBytes(masked)
+	subtleXOR(masked, tdesDUKPTKeyMask)
+	if err := half(masked, out[:8]); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// aesInitialKey 由BDK与8字节初始密钥ID派生初始密钥
+func (d *DUKPT) aesInitialKey(initialKeyID []byte) ([]byte, error) {
+	data := aesDUKPTDerivationData(aesDUKPTUsageInitialKey, len(d.bdk))
+	copy(data[8:], initialKeyID)
+	return aesDUKPTDerive(d.bdk, data, len(d.bdk))
+}
+
+// aesWorkingKey 按计数器逐位派生中间派生密钥，再按用途派生工作密钥
+func (d *DUKPT) aesWorkingKey(ksn []byte, usage DUKPTUsage) ([]byte, error) {
+	key, err := d.InitialKey(ksn)
+	if err != nil {
+		return nil, err
+	}
+
+	counter := binary.BigEndian.Uint32(ksn[8:])
+	if counter == 0 || bits.OnesCount32(counter) > 16 {
+		zeroBytes(key)
+		return nil, errors.New("交易计数器无效：不能为0且置位数不能超过16")
+	}
+
+	var working uint32
+	for mask := uint32(1 << 31); mask > 0; mask >>= 1 {
+		if counter&mask != 0 {
+			working |= mask
+			data := aesDUKPTDerivationData(aesDUKPTUsageKeyDerivation, len(d.bdk))
+			copy(data[8:12], ksn[4:8])
+			binary.BigEndian.PutUint32(data[12:], working)
+			next, err := aesDUKPTDerive(key, data, len(d.bdk))
+			zeroBytes(key)
+			if err != nil {
+				return nil, err
+			}
+			key = next
+		}
+	}
+	defer zeroBytes(key)
+
+	usages := map[DUKPTUsage]uint16{DUKPTPIN: 0x1000, DUKPTMACRequest: 0x2000, DUKPTMACResponse: 0x2001, DUKPTDataRequest: 0x3000, DUKPTDataResponse: 0x3001}
+	code, ok := usages[usage]
+	if !ok {
+		return nil, errors.New("未知的密钥用途")
+	}
+	data := aesDUKPTDerivationData(code, d.workingSize)
+	copy(data[8:12], ksn[4:8])
+	copy(data[12:], ksn[8:12])
+	return aesDUKPTDerive(key, data, d.workingSize)
+}
+
+// aesDUKPTDerivationData 派生数据：版本 | 分组序号 | 用途(2) | 算法(2) | 长度位数(2) | 8字节上下文
+func aesDUKPTDerivationData(usage uint16, keySize int) []byte {
+	data := make([]byte, 16)
+	data[0], data[1] = 0x01, 0x01
+	binary.BigEndian.PutUint16(data[2:], usage)
+	// 算法标识：AES-128为2，AES-192为3，AES-256为4
+	binary.BigEndian.PutUint16(data[4:], uint16(2+(keySize-16)/8))
+	binary.BigEndian.PutUint16(data[6:], uint16(keySize*8))
+	return data
+}
+
+// aesDUKPTDerive 以AES-ECB加密派生数据，按分组序号拼接到所需长度
+func aesDUKPTDerive(key, data []byte, size int) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建AES失败")
+	}
+	out := make([]byte, (size+15)/16*16)
+	for i := 0; i*16 < size; i++ {
+		data[1] = byte(i + 1)
+		block.Encrypt(out[i*16:], data)
+	}
+	return out[:size], nil
+}
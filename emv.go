@@ -0,0 +1,236 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EMV应用密文（ARQC/ARPC）
+//
+// 发卡行主机验证芯片卡的授权请求密文（ARQC）并返回授权响应密文（ARPC）：
+//   - 卡片主密钥：由发卡行主密钥（IMK-AC）按EMV选项A以卡号与卡序列号分散
+//   - 会话密钥：EMV通用会话密钥派生（EMV Book 2 A1.3），以应用交易计数器（ATC）分散卡片主密钥
+//   - ARQC：会话密钥对交易数据（按CDOL1顺序拼接的标签值）计算MAC，TDES使用ISO 9797-1 MAC算法3，
+//     AES使用CMAC，均按填充方法2（0x80后补0）填充并取8字节
+//   - ARPC方法1：TDES加密 ARQC ⊕ (ARC || 补0)；方法2：对 ARQC || CSU || 专有数据 计算MAC取前4字节
+//
+// ParseEMVTLV 与 EMVTagData 用于从报文55域中取出标签并按数据对象列表拼接MAC输入
+
+// DeriveEMVCardKey 按EMV选项A分散卡片主密钥，imk为16字节TDES发卡行主密钥，psn为两位卡序列号（没有时为"00"）
+func DeriveEMVCardKey(imk []byte, pan, psn string) ([]byte, error) {
+	if len(imk) != 16 {
+		return nil, errors.New("发卡行主密钥长度必须是16字节")
+	}
+	digits := pan + psn
+	if strings.Trim(digits, "0123456789") != "" || len(psn) != 2 {
+		return nil, errors.New("卡号与卡序列号必须是数字，卡序列号为2位")
+	}
+	// 取卡号||卡序列号最右16位，不足左补0
+	if len(digits) > 16 {
+		digits = digits[len(digits)-16:]
+	} else {
+		digits = strings.Repeat("0", 16-len(digits)) + digits
+	}
+	y, _ := hex.DecodeString(digits)
+
+	block, err := newTripleDESBlock(imk)
+	if err != nil {
+		return nil, err
+	}
+	key := make([]byte, 16)
+	block.Encrypt(key[:8], y)
+	for i := range y {
+		y[i] ^= 0xff
+	}
+	block.Encrypt(key[8:], y)
+	desOddParity(key)
+	return key, nil
+}
+
+// DeriveEMVSessionKey EMV通用会话密钥派生，algorithm为Algorithm3DES（16字节主密钥）或AlgorithmAES，atc为2字节交易计数器
+func DeriveEMVSessionKey(algorithm Algorithm, cardKey, atc []byte) ([]byte, error) {
+	if len(atc) != 2 {
+		return nil, errors.New("ATC长度必须是2字节")
+	}
+	block, err := emvBlock(algorithm, cardKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// SK = E(F1) || E(F2) 取与主密钥等长的左侧部分，F1 = ATC || F0 || 00...，F2 = ATC || 0F || 00...
+	size := block.BlockSize()
+	key := make([]byte, 2*size)
+	for i, b := range []byte{0xf0, 0x0f} {
+		diversifier := make([]byte, size)
+		copy(diversifier, atc)
+		diversifier[2] = b
+		block.Encrypt(key[i*size:], diversifier)
+	}
+	key = key[:len(cardKey)]
+	if algorithm == Algorithm3DES {
+		desOddParity(key)
+	}
+	return key, nil
+}
+
+// GenerateARQC 使用会话密钥计算8字节应用密文
+func GenerateARQC(algorithm Algorithm, sessionKey, data []byte) ([]byte, error) {
+	return emvMAC(algorithm, sessionKey, data, 8)
+}
+
+// VerifyARQC 常量时间验证应用密文
+func VerifyARQC(algorithm Algorithm, sessionKey, data, arqc []byte) bool {
+	expected, err := GenerateARQC(algorithm, sessionKey, data)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(expected, arqc) == 1
+}
+
+// GenerateARPCMethod1 ARPC方法1，arc为2字节授权响应码，仅用于TDES
+func GenerateARPCMethod1(sessionKey, arqc, arc []byte) ([]byte, error) {
+	if len(arqc) != 8 || len(arc) != 2 {
+		return nil, errors.New("ARQC长度必须是8字节，ARC长度必须是2字节")
+	}
+	block, err := emvBlock(Algorithm3DES, sessionKey)
+	if err != nil {
+		return nil, err
+	}
+	out := append([]byte(nil), arqc...)
+	subtleXOR(out, arc)
+	block.Encrypt(out, out)
+	return out, nil
+}
+
+// GenerateARPCMethod2 ARPC方法2，csu为4字节卡状态更新，返回4字节ARPC
+func GenerateARPCMethod2(algorithm Algorithm, sessionKey, arqc, csu, proprietary []byte) ([]byte, error) {
+	if len(arqc) != 8 || len(csu) != 4 {
+		return nil, errors.New("ARQC长度必须是8字节，CSU长度必须是4字节")
+	}
+	data := make([]byte, 0, 12+len(proprietary))
+	data = append(data, arqc...)
+	data = append(data, csu...)
+	data = append(data, proprietary...)
+	return emvMAC(algorithm, sessionKey, data, 4)
+}
+
+// ParseEMVTLV 解析BER-TLV（如报文55域），复合标签会展开其中的子标签，标签以大写十六进制表示
+func ParseEMVTLV(data []byte) (map[string][]byte, error) {
+	tags := make(map[string][]byte)
+	if err := parseEMVTLV(data, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// EMVTagData 按数据对象列表的顺序拼接标签值，作为ARQC的输入
+func EMVTagData(tags map[string][]byte, order ...string) ([]byte, error) {
+	var data []byte
+	for _, tag := range order {
+		value, ok := tags[strings.ToUpper(tag)]
+		if !ok {
+			return nil, errors.Errorf("缺少标签%s", tag)
+		}
+		data = append(data, value...)
+	}
+	return data, nil
+}
+
+// parseEMVTLV 递归解析TLV
+func parseEMVTLV(data []byte, tags map[string][]byte) error {
+	for len(data) > 0 {
+		// 00与FF为标签间的填充
+		if data[0] == 0x00 || data[0] == 0xff {
+			data = data[1:]
+			continue
+		}
+
+		// 标签：首字节低5位全1时后续字节最高位为1表示继续
+		tagLen := 1
+		if data[0]&0x1f == 0x1f {
+			for tagLen < len(data) && data[tagLen]&0x80 != 0 {
+				tagLen++
+			}
+			tagLen++
+		}
+		if tagLen > len(data) {
+			return errors.New("TLV标签不完整")
+		}
+		tag, constructed := data[:tagLen], data[0]&0x20 != 0
+		data = data[tagLen:]
+
+		// 长度：短格式或 0x81/0x82 长格式
+		if len(data) == 0 {
+			return errors.New("TLV长度不完整")
+		}
+		length := int(data[0])
+		data = data[1:]
+		if length&0x80 != 0 {
+			n := length & 0x7f
+			if n == 0 || n > 2 || n > len(data) {
+				return errors.New("TLV长度格式不正确")
+			}
+			length = 0
+			for _, b := range data[:n] {
+				length = length<<8 | int(b)
+			}
+			data = data[n:]
+		}
+		if length > len(data) {
+			return errors.New("TLV值不完整")
+		}
+
+		value := data[:length]
+		tags[strings.ToUpper(hex.EncodeToString(tag))] = value
+		if constructed {
+			if err := parseEMVTLV(value, tags); err != nil {
+				return err
+			}
+		}
+		data = data[length:]
+	}
+	return nil
+}
+
+// emvMAC 填充方法2后计算MAC并截取
+func emvMAC(algorithm Algorithm, key, data []byte, size int) ([]byte, error) {
+	switch algorithm {
+	case Algorithm3DES:
+		padded := append(append([]byte(nil), data...), 0x80)
+		for len(padded)%8 != 0 {
+			padded = append(padded, 0)
+		}
+		mac, err := retailMAC(key, padded)
+		if err != nil {
+			return nil, err
+		}
+		return mac[:size], nil
+	case AlgorithmAES:
+		mac, err := CMAC(AlgorithmAES, key, data)
+		if err != nil {
+			return nil, err
+		}
+		return mac[:size], nil
+	default:
+		return nil, errors.New("EMV密文仅支持3DES与AES")
+	}
+}
+
+// emvBlock 创建EMV使用的分组密码，3DES为16字节双倍长密钥
+func emvBlock(algorithm Algorithm, key []byte) (cipher.Block, error) {
+	switch algorithm {
+	case Algorithm3DES:
+		if len(key) != 16 {
+			return nil, errors.New("EMV 3DES密钥长度必须是16字节")
+		}
+		return newTripleDESBlock(key)
+	case AlgorithmAES:
+		return newCachedBlock(AlgorithmAES, key)
+	default:
+		return nil, errors.New("EMV密文仅支持3DES与AES")
+	}
+}
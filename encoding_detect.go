@@ -0,0 +1,187 @@
+package encrypt
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+)
+
+// 编码自动识别
+//
+// 上游系统传来的密文编码不统一时，解密前按字符集与长度判断编码：
+//   - 只含十六进制字符且长度为偶数：十六进制
+//   - 只含 A-Z a-z 0-9 + / 与末尾的=：标准Base64（允许省略填充）
+//   - 只含 A-Z a-z 0-9 - _ 与末尾的=：URL安全Base64（允许省略填充）
+//   - 其他：原始字节
+//
+// 字符间的空白（换行、空格）会被忽略。十六进制字符串同时也是合法的Base64，此时优先按十六进制解码；
+// 随机密文经Base64编码后只出现十六进制字符的概率可以忽略，但极短的输入可能误判，
+// 编码确定时应直接使用对应的编码设置
+
+// DetectEncoding 根据字符集与长度判断数据的编码
+func DetectEncoding(data []byte) EncodingMode {
+	text := stripEncodingSpace(data)
+	if len(text) == 0 {
+		return EncodingNone
+	}
+
+	hexOK, stdOK, urlOK := len(text)%2 == 0, true, true
+	padding := 0
+	for _, c := range text {
+		if padding > 0 && c != '=' {
+			// 填充之后不能再出现其他字符
+			return EncodingNone
+		}
+		switch {
+		case c >= '0' && c <= '9', c >= 'a' && c <= 'f', c >= 'A' && c <= 'F':
+		case c >= 'g' && c <= 'z', c >= 'G' && c <= 'Z':
+			hexOK = false
+		case c == '+' || c == '/':
+			hexOK, urlOK = false, false
+		case c == '-' || c == '_':
+			hexOK, stdOK = false, false
+		case c == '=':
+			hexOK = false
+			padding++
+		default:
+			return EncodingNone
+		}
+	}
+
+	switch {
+	case hexOK:
+		return EncodingHex
+	case padding > 2:
+		return EncodingNone
+	case padding > 0 && len(text)%4 != 0:
+		return EncodingNone
+	case padding == 0 && len(text)%4 == 1:
+		return EncodingNone
+	case stdOK:
+		return EncodingBase64
+	case urlOK:
+		return EncodingBase64Safe
+	}
+	return EncodingNone
+}
+
+// DecodeAuto 自动识别编码并解码，无法识别时原样返回
+func DecodeAuto(data []byte) ([]byte, error) {
+	switch DetectEncoding(data) {
+	case EncodingHex:
+		decoded, err := hex.DecodeString(string(stripEncodingSpace(data)))
+		if err != nil {
+			return nil, errors.Wrap(err, "十六进制解码失败")
+		}
+		return decoded, nil
+	case EncodingBase64:
+		return decodeBase64Loose(base64.StdEncoding, stripEncodingSpace(data))
+	case EncodingBase64Safe:
+		return decodeBase64Loose(base64.URLEncoding, stripEncodingSpace(data))
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// decodeBase64Loose Base64解码，允许省略填充
+func decodeBase64Loose(enc *base64.Encoding, text []byte) ([]byte, error) {
+	if len(text)%4 != 0 {
+		enc = enc.WithPadding(base64.NoPadding)
+	}
+	decoded, err := enc.DecodeString(string(text))
+	if err != nil {
+		return nil, errors.Wrap(err, "Base64解码失败")
+	}
+	return decoded, nil
+}
+
+// stripEncodingSpace 去除文本中的空白字符
+func stripEncodingSpace(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for _, c := range data {
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// autoDecoding 编码时沿用原有编码，解码时自动识别
+type autoDecoding struct {
+	encoder Encoding
+}
+
+// newAutoDecoding 包装当前编码，重复调用不会多次包装
+func newAutoDecoding(encoding Encoding) Encoding {
+	if auto, ok := encoding.(*autoDecoding); ok {
+		return auto
+	}
+	return &autoDecoding{encoder: encoding}
+}
+
+// Encode 使用原有编码
+func (a *autoDecoding) Encode(data []byte) ([]byte, error) {
+	return a.encoder.Encode(data)
+}
+
+// Decode 自动识别编码并解码
+func (a *autoDecoding) Decode(data []byte) ([]byte, error) {
+	return DecodeAuto(data)
+}
+
+// IAutoDecoder 支持解密时自动识别密文编码的对称算法
+type IAutoDecoder interface {
+	AutoDecode() ISymmetric
+}
+
+// IAsymmetricAutoDecoder 支持解密与验签时自动识别输入编码的非对称算法
+type IAsymmetricAutoDecoder interface {
+	AutoDecode() IAsymmetric
+}
+
+var (
+	_ IAutoDecoder           = (*AESEncryptor)(nil)
+	_ IAutoDecoder           = (*DESEncryptor)(nil)
+	_ IAutoDecoder           = (*TripleDESEncryptor)(nil)
+	_ IAutoDecoder           = (*SM4Encryptor)(nil)
+	_ IAsymmetricAutoDecoder = (*RSAEncryptor)(nil)
+	_ IAsymmetricAutoDecoder = (*SM2Encryptor)(nil)
+)
+
+// AutoDecode 解密时自动识别密文编码，加密输出仍使用当前编码，需在设置编码之后调用
+func (a *AESEncryptor) AutoDecode() ISymmetric {
+	a.encoding = newAutoDecoding(a.encoding)
+	return a
+}
+
+// AutoDecode 解密时自动识别密文编码，加密输出仍使用当前编码，需在设置编码之后调用
+func (d *DESEncryptor) AutoDecode() ISymmetric {
+	d.encoding = newAutoDecoding(d.encoding)
+	return d
+}
+
+// AutoDecode 解密时自动识别密文编码，加密输出仍使用当前编码，需在设置编码之后调用
+func (t *TripleDESEncryptor) AutoDecode() ISymmetric {
+	t.encoding = newAutoDecoding(t.encoding)
+	return t
+}
+
+// AutoDecode 解密时自动识别密文编码，加密输出仍使用当前编码，需在设置编码之后调用
+func (s *SM4Encryptor) AutoDecode() ISymmetric {
+	s.encoding = newAutoDecoding(s.encoding)
+	return s
+}
+
+// AutoDecode 解密与验签时自动识别输入编码，输出仍使用当前编码，需在设置编码之后调用
+func (r *RSAEncryptor) AutoDecode() IAsymmetric {
+	r.encoding = newAutoDecoding(r.encoding)
+	return r
+}
+
+// AutoDecode 解密与验签时自动识别输入编码，输出仍使用当前编码，需在设置编码之后调用
+func (s *SM2Encryptor) AutoDecode() IAsymmetric {
+	s.encoding = newAutoDecoding(s.encoding)
+	return s
+}
@@ -0,0 +1,167 @@
+package encrypt
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// 折行Base64
+//
+// 老系统的SOAP/XML报文与邮件正文要求Base64按固定宽度折行：
+// MIME（RFC 2045）每行76字符、以CRLF结尾，PEM（RFC 7468）每行64字符、以LF结尾。
+// 解码时忽略所有空白字符，折行方式、缩进与末尾换行都不影响结果
+
+// Base64WrappedImpl 折行Base64编码实现
+type Base64WrappedImpl struct {
+	LineLength int    // 每行字符数
+	LineEnding string // 行尾
+}
+
+// NewBase64Wrapped 创建按lineLength字符折行的Base64编码
+func NewBase64Wrapped(lineLength int, lineEnding string) *Base64WrappedImpl {
+	return &Base64WrappedImpl{LineLength: lineLength, LineEnding: lineEnding}
+}
+
+// 折行Base64编码器实例
+var (
+	Base64MIME = NewBase64Wrapped(76, "\r\n")
+	Base64PEM  = NewBase64Wrapped(64, "\n")
+)
+
+// Encode 标准Base64编码后折行，最后一行不追加行尾
+func (b *Base64WrappedImpl) Encode(data []byte) ([]byte, error) {
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(data)))
+	base64.StdEncoding.Encode(encoded, data)
+	if b.LineLength <= 0 || len(encoded) <= b.LineLength {
+		return encoded, nil
+	}
+
+	lines := (len(encoded) + b.LineLength - 1) / b.LineLength
+	result := make([]byte, 0, len(encoded)+(lines-1)*len(b.LineEnding))
+	for len(encoded) > b.LineLength {
+		result = append(result, encoded[:b.LineLength]...)
+		result = append(result, b.LineEnding...)
+		encoded = encoded[b.LineLength:]
+	}
+	return append(result, encoded...), nil
+}
+
+// Decode 忽略空白字符后按标准Base64解码
+func (b *Base64WrappedImpl) Decode(data []byte) ([]byte, error) {
+	text := stripEncodingSpace(data)
+	result := make([]byte, base64.StdEncoding.DecodedLen(len(text)))
+	n, err := base64.StdEncoding.Decode(result, text)
+	if err != nil {
+		return nil, errors.Wrap(err, "Base64解码失败")
+	}
+	return result[:n], nil
+}
+
+// EncodePEMBody 将数据编码为PEM正文：每行64字符，每行以LF结尾，可直接放在BEGIN/END行之间
+func EncodePEMBody(data []byte) []byte {
+	encoded, _ := Base64PEM.Encode(data)
+	if len(encoded) == 0 {
+		return encoded
+	}
+	return append(encoded, '\n')
+}
+
+// IWrappedEncoder 支持折行Base64编码的对称算法
+type IWrappedEncoder interface {
+	Base64MIME() ISymmetric
+	Base64PEM() ISymmetric
+}
+
+// IAsymmetricWrappedEncoder 支持折行Base64编码的非对称算法
+type IAsymmetricWrappedEncoder interface {
+	Base64MIME() IAsymmetric
+	Base64PEM() IAsymmetric
+}
+
+var (
+	_ IWrappedEncoder           = (*AESEncryptor)(nil)
+	_ IWrappedEncoder           = (*DESEncryptor)(nil)
+	_ IWrappedEncoder           = (*TripleDESEncryptor)(nil)
+	_ IWrappedEncoder           = (*SM4Encryptor)(nil)
+	_ IAsymmetricWrappedEncoder = (*RSAEncryptor)(nil)
+	_ IAsymmetricWrappedEncoder = (*SM2Encryptor)(nil)
+)
+
+// Base64MIME 设置MIME折行Base64编码（每76字符CRLF）
+func (a *AESEncryptor) Base64MIME() ISymmetric {
+	a.encoding = Base64MIME
+	return a
+}
+
+// Base64PEM 设置PEM折行Base64编码（每64字符LF）
+func (a *AESEncryptor) Base64PEM() ISymmetric {
+	a.encoding = Base64PEM
+	return a
+}
+
+// Base64MIME 设置MIME折行Base64编码（每76字符CRLF）
+func (d *DESEncryptor) Base64MIME() ISymmetric {
+	d.encoding = Base64MIME
+	return d
+}
+
+// Base64PEM 设置PEM折行Base64编码（每64字符LF）
+func (d *DESEncryptor) Base64PEM() ISymmetric {
+	d.encoding = Base64PEM
+	return d
+}
+
+// Base64MIME 设置MIME折行Base64编码（每76字符CRLF）
+func (t *TripleDESEncryptor) Base64MIME() ISymmetric {
+	t.encoding = Base64MIME
+	return t
+}
+
+// Base64PEM 设置PEM折行Base64编码（每64字符LF）
+func (t *TripleDESEncryptor) Base64PEM() ISymmetric {
+	t.encoding = Base64PEM
+	return t
+}
+
+// Base64MIME 设置MIME折行Base64编码（每76字符CRLF）
+func (s *SM4Encryptor) Base64MIME() ISymmetric {
+	s.encoding = Base64MIME
+	s.encodingMode = EncodingBase64MIME
+	return s
+}
+
+// Base64PEM 设置PEM折行Base64编码（每64字符LF）
+func (s *SM4Encryptor) Base64PEM() ISymmetric {
+	s.encoding = Base64PEM
+	s.encodingMode = EncodingBase64PEM
+	return s
+}
+
+// Base64MIME 设置MIME折行Base64编码（每76字符CRLF）
+func (r *RSAEncryptor) Base64MIME() IAsymmetric {
+	r.encoding = Base64MIME
+	r.encodingMode = EncodingBase64MIME
+	return r
+}
+
+// Base64PEM 设置PEM折行Base64编码（每64字符LF）
+func (r *RSAEncryptor) Base64PEM() IAsymmetric {
+	r.encoding = Base64PEM
+	r.encodingMode = EncodingBase64PEM
+	return r
+}
+
+// Base64MIME 设置MIME折行Base64编码（每76字符CRLF）
+func (s *SM2Encryptor) Base64MIME() IAsymmetric {
+	s.encoding = Base64MIME
+	s.encodingMode = EncodingBase64MIME
+	return s
+}
+
+// Base64PEM 设置PEM折行Base64编码（每64字符LF）
+func (s *SM2Encryptor) Base64PEM() IAsymmetric {
+	s.encoding = Base64PEM
+	s.encodingMode = EncodingBase64PEM
+	return s
+}
@@ -0,0 +1,295 @@
+// encryptctl 加密库的命令行工具
+//
+// 配置文件值加密（类似SOPS，只加密值，键名保持可读）：
+//
+//	encryptctl config encrypt [-key ID] [-pattern 正则] app.yaml   原地加密
+//	encryptctl config decrypt app.yaml                             解密输出到标准输出
+//	encryptctl config edit app.yaml                                解密后用$EDITOR编辑，保存时重新加密
+//
+// 主密钥仪式（PCI知识拆分，保管员逐个输入十六进制分量，输入不回显）：
+//
+//	encryptctl ceremony -id master -alg aes -size 32 -custodians 3   XOR分量
+//	encryptctl ceremony -id master -alg aes -size 32 -threshold 2    Shamir份额
+//	encryptctl ceremony -id master -suite SM4-GCM -custodians 2     按套件确定算法与长度
+//
+// 主密钥来自密钥库文件：-keystore 指定路径（默认环境变量ENCRYPT_KEYSTORE），
+// 主密码来自环境变量ENCRYPT_KEYSTORE_PASSWORD或 -password-file 指定的文件
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/sylphbyte/encrypt"
+	"golang.org/x/term"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "encryptctl:", err)
+		os.Exit(1)
+	}
+}
+
+// run 分发子命令
+func run(args []string) error {
+	if len(args) == 0 {
+		return usage()
+	}
+	switch args[0] {
+	case "config":
+		return runConfig(args[1:])
+	case "ceremony":
+		return runCeremony(args[1:])
+	default:
+		return usage()
+	}
+}
+
+// keystoreFlags 注册密钥库相关参数
+func keystoreFlags(fs *flag.FlagSet) (keystorePath, passwordFile *string) {
+	keystorePath = fs.String("keystore", os.Getenv("ENCRYPT_KEYSTORE"), "密钥库文件路径")
+	passwordFile = fs.String("password-file", "", "主密码文件，默认读取环境变量ENCRYPT_KEYSTORE_PASSWORD")
+	return keystorePath, passwordFile
+}
+
+// runConfig 配置文件加密子命令
+func runConfig(args []string) error {
+	if len(args) < 1 {
+		return usage()
+	}
+
+	fs := flag.NewFlagSet("config "+args[0], flag.ContinueOnError)
+	keystorePath, passwordFile := keystoreFlags(fs)
+	keyID := fs.String("key", "", "包装数据密钥的密钥ID，默认使用主密钥")
+	pattern := fs.String("pattern", "", "只加密键名匹配该正则的值，默认加密所有值")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return usage()
+	}
+	path := fs.Arg(0)
+
+	ks, err := openKeystore(*keystorePath, *passwordFile)
+	if err != nil {
+		return err
+	}
+	defer ks.Close()
+	ring := ks.KeyRing()
+	ctx := context.Background()
+
+	switch args[0] {
+	case "encrypt":
+		id := *keyID
+		if id == "" {
+			id = ring.Primary()
+		}
+		encrypter := encrypt.NewConfigEncrypter(ring, id)
+		if *pattern != "" {
+			encrypter.WithKeyPattern(*pattern)
+		}
+		return encrypter.EncryptFile(ctx, path)
+	case "decrypt":
+		plaintext, err := encrypt.DecryptConfig(ctx, path, ring)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(plaintext)
+		return err
+	case "edit":
+		return encrypt.EditConfig(ctx, path, ring, func(plaintext []byte) ([]byte, error) {
+			return editInEditor(path, plaintext)
+		})
+	default:
+		return usage()
+	}
+}
+
+// runCeremony 主密钥仪式子命令：逐个输入分量，打印分量校验值与KCV，确认后写入密钥库
+func runCeremony(args []string) error {
+	fs := flag.NewFlagSet("ceremony", flag.ContinueOnError)
+	keystorePath, passwordFile := keystoreFlags(fs)
+	id := fs.String("id", "", "写入密钥库的密钥ID")
+	algName := fs.String("alg", "aes", "算法：aes、sm4或3des")
+	size := fs.Int("size", 32, "密钥长度（字节）")
+	suiteName := fs.String("suite", "", "按套件确定算法与密钥长度，如AES-256-GCM，指定后忽略-alg与-size")
+	custodians := fs.Int("custodians", 0, "XOR方案的保管员人数")
+	threshold := fs.Int("threshold", 0, "Shamir方案的门限")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *id == "" || (*custodians == 0) == (*threshold == 0) {
+		return usage()
+	}
+
+	algorithms := map[string]encrypt.Algorithm{"aes": encrypt.AlgorithmAES, "sm4": encrypt.AlgorithmSM4, "3des": encrypt.Algorithm3DES}
+	algorithm, ok := algorithms[strings.ToLower(*algName)]
+	if *suiteName != "" {
+		suite, err := encrypt.ParseSuite(*suiteName)
+		if err != nil {
+			return err
+		}
+		algorithm, *size, ok = suite.Algorithm, suite.KeySize, true
+	}
+	if !ok {
+		return fmt.Errorf("不支持的算法: %s", *algName)
+	}
+
+	ks, err := openKeystore(*keystorePath, *passwordFile)
+	if err != nil {
+		return err
+	}
+	defer ks.Close()
+	if _, err := ks.KeyRing().Get(*id); err == nil {
+		return fmt.Errorf("密钥%s已存在", *id)
+	}
+
+	var ceremony *encrypt.KeyCeremony
+	if *custodians > 0 {
+		ceremony, err = encrypt.NewXORCeremony(algorithm, *size, *custodians)
+	} else {
+		ceremony, err = encrypt.NewShamirCeremony(algorithm, *size, *threshold)
+	}
+	if err != nil {
+		return err
+	}
+	defer ceremony.Destroy()
+
+	reader := bufio.NewReader(os.Stdin)
+	for ceremony.Received() < ceremony.Required() {
+		fmt.Printf("保管员%d/%d 请输入分量（十六进制）: ", ceremony.Received()+1, ceremony.Required())
+		line, err := readSecretLine(reader)
+		if err != nil {
+			return err
+		}
+		component, err := hex.DecodeString(strings.ReplaceAll(string(line), " ", ""))
+		zeroBytes(line)
+		if err != nil {
+			fmt.Println("分量格式不正确，请重新输入")
+			continue
+		}
+		checkValue, err := ceremony.AddComponent(component)
+		zeroBytes(component)
+		if err != nil {
+			fmt.Printf("分量无效: %v，请重新输入\n", err)
+			continue
+		}
+		fmt.Printf("分量校验值: %s\n", checkValue)
+	}
+
+	key, kcv, err := ceremony.Combine()
+	if err != nil {
+		return err
+	}
+	defer zeroBytes(key)
+	fmt.Printf("密钥校验值(KCV): %s\n请核对KCV，输入yes写入密钥库: ", kcv)
+	answer, err := reader.ReadString('\n')
+	if err != nil || strings.TrimSpace(answer) != "yes" {
+		return fmt.Errorf("已取消，密钥未写入")
+	}
+
+	if err := ks.AddKey(encrypt.KeyEntry{ID: *id, Algorithm: algorithm, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		return err
+	}
+	if err := ks.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("密钥%s已写入密钥库，KCV %s\n", *id, kcv)
+	return nil
+}
+
+// readSecretLine 从终端不回显读取一行，非终端时读取标准输入的一行
+func readSecretLine(reader *bufio.Reader) ([]byte, error) {
+	if term.IsTerminal(int(os.Stdin.Fd())) {
+		line, err := term.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		return line, err
+	}
+	line, err := reader.ReadBytes('\n')
+	if err != nil && len(line) == 0 {
+		return nil, err
+	}
+	return bytes.TrimSpace(line), nil
+}
+
+// zeroBytes 清零敏感数据
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// openKeystore 打开密钥库
+func openKeystore(path, passwordFile string) (*encrypt.Keystore, error) {
+	if path == "" {
+		return nil, fmt.Errorf("未指定密钥库，请使用-keystore或设置ENCRYPT_KEYSTORE")
+	}
+	password := []byte(os.Getenv("ENCRYPT_KEYSTORE_PASSWORD"))
+	if passwordFile != "" {
+		data, err := os.ReadFile(passwordFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取主密码文件失败: %w", err)
+		}
+		password = bytes.TrimRight(data, "\r\n")
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("未提供主密码")
+	}
+	return encrypt.LoadKeystore(path, password)
+}
+
+// editInEditor 将明文写入仅当前用户可读的临时文件并打开编辑器，返回编辑后的内容
+func editInEditor(path string, plaintext []byte) ([]byte, error) {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	// 保留扩展名，便于编辑器识别语法
+	tmp, err := os.CreateTemp("", "encryptctl-*"+filepath.Ext(path))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		// 删除前覆盖明文
+		if info, err := os.Stat(tmp.Name()); err == nil {
+			os.WriteFile(tmp.Name(), make([]byte, info.Size()), 0600)
+		}
+		os.Remove(tmp.Name())
+	}()
+	if err := tmp.Chmod(0600); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if _, err := tmp.Write(plaintext); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	// EDITOR可以带参数，如 "code --wait"
+	fields := strings.Fields(editor)
+	cmd := exec.Command(fields[0], append(fields[1:], tmp.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("编辑器退出异常: %w", err)
+	}
+	return os.ReadFile(tmp.Name())
+}
+
+// usage 输出用法
+func usage() error {
+	fmt.Fprintln(os.Stderr, "用法:\n  encryptctl config encrypt|decrypt|edit [-keystore 路径] [-password-file 文件] [-key ID] [-pattern 正则] 文件\n  encryptctl ceremony [-keystore 路径] [-password-file 文件] -id ID [-alg aes|sm4|3des] [-size 字节] [-suite 套件] -custodians N|-threshold K")
+	return fmt.Errorf("参数错误")
+}
@@ -0,0 +1,11 @@
+// Command encryptlint 检查encrypt包的常见误用，用法见encryptlint包文档
+package main
+
+import (
+	"github.com/sylphbyte/encrypt/encryptlint"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(encryptlint.Analyzer)
+}
@@ -0,0 +1,211 @@
+// Package encryptlint 检查encrypt包的常见误用
+//
+// 以go/analysis分析器的形式提供，可以单独运行，也可以作为go vet的vettool：
+//
+//	go install github.com/sylphbyte/encrypt/encryptlint/cmd/encryptlint@latest
+//	encryptlint ./...
+//	go vet -vettool=$(which encryptlint) ./...
+//
+// 检查项：
+//   - ECB：调用ECB()或使用ModeECB，相同明文分组得到相同密文
+//   - 固定IV：CTR/GCM配置下传给WithIV的IV是常量（字面量、[]byte("...")、make得到的全零切片），
+//     同一密钥下重复使用计数器或nonce会直接泄露明文
+//   - 硬编码密钥：名为key、xxxKey的[]byte参数传入常量，公钥与已包装、加密的密钥参数除外
+//   - PBKDF2迭代次数过低：iterations参数为小于 -min-iterations 的常量
+//
+// 作为独立子模块发布，主模块无需引入golang.org/x/tools
+package encryptlint
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+// encryptPath encrypt包的导入路径
+const encryptPath = "github.com/sylphbyte/encrypt"
+
+// Analyzer encrypt误用检查
+var Analyzer = &analysis.Analyzer{
+	Name:     "encryptlint",
+	Doc:      "检查encrypt包的误用：ECB模式、CTR/GCM固定IV、硬编码密钥与过低的PBKDF2迭代次数",
+	URL:      "https://pkg.go.dev/github.com/sylphbyte/encrypt/encryptlint",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// minIterations PBKDF2最低迭代次数
+var minIterations int
+
+func init() {
+	Analyzer.Flags.IntVar(&minIterations, "min-iterations", 10000, "PBKDF2最低迭代次数")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	// encrypt包自身（如KCV计算）需要使用ECB等底层构件
+	if pass.Pkg.Path() == encryptPath {
+		return nil, nil
+	}
+
+	ins := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	nodes := []ast.Node{(*ast.Ident)(nil), (*ast.CallExpr)(nil)}
+	ins.WithStack(nodes, func(n ast.Node, push bool, stack []ast.Node) bool {
+		if !push {
+			return true
+		}
+		switch n := n.(type) {
+		case *ast.Ident:
+			if c, ok := pass.TypesInfo.Uses[n].(*types.Const); ok && isEncrypt(c) && c.Name() == "ModeECB" {
+				pass.Reportf(n.Pos(), "使用了ECB模式：相同明文分组会得到相同密文，请改用GCM")
+			}
+		case *ast.CallExpr:
+			checkCall(pass, n, stack)
+		}
+		return true
+	})
+	return nil, nil
+}
+
+// checkCall 检查对encrypt包函数或方法的调用
+func checkCall(pass *analysis.Pass, call *ast.CallExpr, stack []ast.Node) {
+	fn, ok := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+	if !ok || !isEncrypt(fn) {
+		return
+	}
+
+	switch fn.Name() {
+	case "ECB":
+		if fn.Type().(*types.Signature).Recv() != nil {
+			pass.Reportf(call.Pos(), "使用了ECB模式：相同明文分组会得到相同密文，请改用GCM")
+		}
+	case "WithIV":
+		if len(call.Args) == 1 && isStaticBytes(pass, call.Args[0]) {
+			if mode := streamMode(pass, outermostCall(call, stack)); mode != "" {
+				pass.Reportf(call.Args[0].Pos(), "%s模式使用了固定IV：同一密钥下重复的计数器/nonce会泄露明文，请使用随机IV", mode)
+			}
+		}
+	}
+
+	// 按参数名检查硬编码密钥与迭代次数
+	params := fn.Type().(*types.Signature).Params()
+	for i := 0; i < params.Len() && i < len(call.Args); i++ {
+		param, arg := params.At(i), call.Args[i]
+		switch name := strings.ToLower(param.Name()); {
+		case isSecretKeyParam(name) && isByteSlice(param.Type()) && isStaticBytes(pass, arg):
+			pass.Reportf(arg.Pos(), "硬编码密钥：%s的参数%s是常量，请从密钥管理系统或配置中加载", fn.Name(), param.Name())
+		case name == "iterations":
+			if tv, ok := pass.TypesInfo.Types[arg]; ok && tv.Value != nil && tv.Value.Kind() == constant.Int {
+				if n, exact := constant.Int64Val(tv.Value); exact && n < int64(minIterations) {
+					pass.Reportf(arg.Pos(), "PBKDF2迭代次数%d过低，至少应为%d", n, minIterations)
+				}
+			}
+		}
+	}
+}
+
+// publicKeyParamWords 参数名中表示非秘密密钥的词：公钥可以硬编码，包装或加密后的密钥也不是明文密钥
+var publicKeyParamWords = []string{"pub", "wrapped", "encrypted", "enveloped"}
+
+// isSecretKeyParam 判断小写参数名是否表示秘密密钥：key或以key结尾，且不是公钥或已包装的密钥
+func isSecretKeyParam(name string) bool {
+	if !strings.HasSuffix(name, "key") {
+		return false
+	}
+	for _, word := range publicKeyParamWords {
+		if strings.Contains(name, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// outermostCall 找到包含该调用的最外层调用表达式，链式调用与选项列表都在其中
+func outermostCall(call *ast.CallExpr, stack []ast.Node) ast.Node {
+	var outer ast.Node = call
+	for i := len(stack) - 2; i >= 0; i-- {
+		switch stack[i].(type) {
+		case *ast.CallExpr, *ast.SelectorExpr:
+			outer = stack[i]
+		default:
+			return outer
+		}
+	}
+	return outer
+}
+
+// streamMode 在表达式中查找CTR或GCM模式的配置
+func streamMode(pass *analysis.Pass, root ast.Node) string {
+	mode := ""
+	ast.Inspect(root, func(n ast.Node) bool {
+		id, ok := n.(*ast.Ident)
+		if !ok || mode != "" {
+			return mode == ""
+		}
+		switch obj := pass.TypesInfo.Uses[id].(type) {
+		case *types.Const:
+			if isEncrypt(obj) && (obj.Name() == "ModeCTR" || obj.Name() == "ModeGCM") {
+				mode = strings.TrimPrefix(obj.Name(), "Mode")
+			}
+		case *types.Func:
+			if isEncrypt(obj) && (obj.Name() == "CTR" || obj.Name() == "GCM") {
+				mode = obj.Name()
+			}
+		}
+		return true
+	})
+	return mode
+}
+
+// isStaticBytes 判断表达式是否为编译期确定的字节内容
+func isStaticBytes(pass *analysis.Pass, expr ast.Expr) bool {
+	switch e := ast.Unparen(expr).(type) {
+	case *ast.CallExpr:
+		// []byte("...") 或 []byte(常量)
+		if tv, ok := pass.TypesInfo.Types[e.Fun]; ok && tv.IsType() && len(e.Args) == 1 {
+			arg, ok := pass.TypesInfo.Types[e.Args[0]]
+			return ok && arg.Value != nil
+		}
+		// make([]byte, n) 得到全零切片
+		if id, ok := ast.Unparen(e.Fun).(*ast.Ident); ok {
+			if _, builtin := pass.TypesInfo.Uses[id].(*types.Builtin); builtin && id.Name == "make" {
+				return isByteSlice(pass.TypesInfo.TypeOf(e))
+			}
+		}
+	case *ast.CompositeLit:
+		// []byte{0x01, 0x02, ...}
+		if !isByteSlice(pass.TypesInfo.TypeOf(e)) {
+			return false
+		}
+		for _, elt := range e.Elts {
+			if kv, ok := elt.(*ast.KeyValueExpr); ok {
+				elt = kv.Value
+			}
+			if tv, ok := pass.TypesInfo.Types[elt]; !ok || tv.Value == nil {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// isByteSlice 判断类型是否为[]byte
+func isByteSlice(t types.Type) bool {
+	slice, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		return false
+	}
+	basic, ok := slice.Elem().Underlying().(*types.Basic)
+	return ok && basic.Kind() == types.Byte
+}
+
+// isEncrypt 判断对象是否属于encrypt包
+func isEncrypt(obj types.Object) bool {
+	return obj.Pkg() != nil && obj.Pkg().Path() == encryptPath
+}
@@ -0,0 +1,12 @@
+package encryptlint
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// TestAnalyzer 使用testdata/src/a中的want注释校验各项检查及不应报告的情况
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "a")
+}
@@ -0,0 +1,44 @@
+package a
+
+import (
+	"os"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+const pem = "-----BEGIN PUBLIC KEY-----"
+
+func ecb(key []byte) {
+	encrypt.MustNewAES(key).ECB()                                    // want `使用了ECB模式`
+	encrypt.AES(key, encrypt.WithMode(encrypt.ModeECB))              // want `使用了ECB模式`
+	encrypt.AES(key, encrypt.WithMode(encrypt.ModeCBC))              // CBC不报告
+	encrypt.MustNewAES(key).CBC().WithIV([]byte("0123456789abcdef")) // CBC的固定IV不报告
+}
+
+func fixedIV(key, iv []byte) {
+	encrypt.MustNewAES(key).CTR().WithIV([]byte("0123456789abcdef"))                     // want `CTR模式使用了固定IV`
+	encrypt.MustNewAES(key).CTR().WithIV(make([]byte, 16))                               // want `CTR模式使用了固定IV`
+	encrypt.AES(key, encrypt.WithMode(encrypt.ModeGCM), encrypt.WithIV([]byte{1, 2, 3})) // want `GCM模式使用了固定IV`
+	encrypt.MustNewAES(key).(encrypt.IGCMSetter).GCM().WithIV([]byte{0})                 // want `GCM模式使用了固定IV`
+	encrypt.MustNewAES(key).CTR().WithIV(iv)                                             // 变量IV不报告
+}
+
+func hardcodedKey() {
+	encrypt.NewAES([]byte("0123456789abcdef"))    // want `硬编码密钥：NewAES的参数key是常量`
+	encrypt.MustNewAES([]byte{1, 2, 3, 4})        // want `硬编码密钥：MustNewAES的参数key是常量`
+	encrypt.NewAESCBCHMAC(make([]byte, 32))       // want `硬编码密钥：NewAESCBCHMAC的参数macKey是常量`
+	encrypt.NewAES([]byte(os.Getenv("APP_KEY")))  // 运行时加载不报告
+	encrypt.NewAES(loadKey())                     // 运行时加载不报告
+	encrypt.BoxSeal(nil, []byte(pem))             // 公钥不报告
+	encrypt.NewBoxRecipient([]byte(pem))          // 公钥不报告
+	encrypt.WithPublicKey([]byte(pem))            // 公钥不报告
+	encrypt.UnwrapKey(loadKey(), []byte{1, 2, 3}) // 已包装的密钥不报告
+}
+
+func iterations(password, salt []byte) {
+	d := &encrypt.PBKDF2Deriver{}
+	d.DeriveKey(password, salt, 1000, 32)   // want `PBKDF2迭代次数1000过低，至少应为10000`
+	d.DeriveKey(password, salt, 600000, 32) // 足够的迭代次数不报告
+}
+
+func loadKey() []byte { return nil }
@@ -0,0 +1,42 @@
+// Package encrypt 分析器测试使用的桩包，只保留被检查API的签名
+package encrypt
+
+type Mode int
+
+const (
+	ModeECB Mode = iota
+	ModeCBC
+	ModeCTR
+	ModeGCM
+)
+
+type ISymmetric interface {
+	ECB() ISymmetric
+	CBC() ISymmetric
+	CTR() ISymmetric
+	WithIV(iv []byte) ISymmetric
+	Encrypt(data []byte) ([]byte, error)
+}
+
+type IGCMSetter interface {
+	GCM() ISymmetric
+}
+
+type Option func()
+
+func NewAES(key []byte) (ISymmetric, error)                        { return nil, nil }
+func MustNewAES(key []byte) ISymmetric                             { return nil }
+func AES(key []byte, opts ...Option) (ISymmetric, error)           { return nil, nil }
+func WithMode(mode Mode) Option                                    { return nil }
+func WithIV(iv []byte) Option                                      { return nil }
+func NewAESCBCHMAC(macKey []byte) error                            { return nil }
+func BoxSeal(plaintext, recipientPublicKey []byte) ([]byte, error) { return nil, nil }
+func NewBoxRecipient(publicKey []byte) error                       { return nil }
+func UnwrapKey(kek, wrappedKey []byte) ([]byte, error)             { return nil, nil }
+func WithPublicKey(publicKeyData []byte) error                     { return nil }
+
+type PBKDF2Deriver struct{}
+
+func (p *PBKDF2Deriver) DeriveKey(password, salt []byte, iterations int, keyLength int) (string, error) {
+	return "", nil
+}
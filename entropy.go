@@ -0,0 +1,296 @@
+package encrypt
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 熵源健康测试（NIST SP 800-90B 4.4）
+//
+// HealthTestedSource 对熵源输出的每个样本持续执行两项测试：
+//   - 重复计数测试（RCT）：同一样本连续出现C次即报警，C = 1 + ⌈-log2(α) / H⌉
+//   - 自适应比例测试（APT）：512个样本的窗口内首个样本出现次数达到C即报警，
+//     C = 1 + CRITBINOM(512, 2^-H, 1-α)
+//
+// H为熵源声明的每样本最小熵，α为误报率（默认2^-30）。创建时先执行1024个样本的启动测试。
+// 报警后熵源进入故障状态，Read一律返回错误直到调用Reset，并回调OnFailure。
+// MixedEntropySource 以SHA-512调节多个熵源的输出，可将操作系统随机数与CPU抖动熵混合
+
+// ErrEntropyHealth 熵源未通过健康测试
+var ErrEntropyHealth = errors.New("熵源健康测试失败")
+
+// 健康测试参数
+const (
+	entropyAPTWindow       = 512
+	entropyStartupSamples  = 1024
+	defaultEntropyAlphaExp = 30
+)
+
+// EntropyHealthError 健康测试失败详情
+type EntropyHealthError struct {
+	Test   string // RCT或APT
+	Sample byte   // 触发报警的样本值
+	Count  int    // 触发报警时的计数
+	Cutoff int    // 阈值
+}
+
+// Error 实现error接口
+func (e *EntropyHealthError) Error() string {
+	return fmt.Sprintf("熵源健康测试失败: %s 样本0x%02x 计数%d 达到阈值%d", e.Test, e.Sample, e.Count, e.Cutoff)
+}
+
+// Unwrap 支持errors.Is(err, ErrEntropyHealth)
+func (e *EntropyHealthError) Unwrap() error {
+	return ErrEntropyHealth
+}
+
+// HealthTestedSource 带持续健康测试的熵源
+type HealthTestedSource struct {
+	mu        sync.Mutex
+	source    EntropySource
+	onFailure func(*EntropyHealthError)
+	failure   *EntropyHealthError
+
+	rctCutoff int
+	rctLast   byte
+	rctCount  int
+
+	aptCutoff int
+	aptFirst  byte
+	aptCount  int
+	aptIndex  int
+}
+
+// NewHealthTestedSource 包装熵源并执行启动测试，误报率α = 2^-30
+func NewHealthTestedSource(source EntropySource) (*HealthTestedSource, error) {
+	return NewHealthTestedSourceWithAlpha(source, defaultEntropyAlphaExp)
+}
+
+// NewHealthTestedSourceWithAlpha 指定误报率α = 2^-alphaExp（SP 800-90B 建议20~40）
+func NewHealthTestedSourceWithAlpha(source EntropySource, alphaExp int) (*HealthTestedSource, error) {
+	if source == nil {
+		return nil, errors.New("熵源不能为空")
+	}
+	if alphaExp < 20 || alphaExp > 40 {
+		return nil, errors.New("误报率指数必须在20到40之间")
+	}
+	h := source.MinEntropy()
+	if h <= 0 || h > 8 {
+		return nil, errors.New("熵源声明的最小熵必须在(0, 8]之间")
+	}
+
+	s := &HealthTestedSource{
+		source:    source,
+		rctCutoff: 1 + int(math.Ceil(float64(alphaExp)/h)),
+		aptCutoff: 1 + entropyCritBinom(entropyAPTWindow, math.Pow(2, -h), alphaExp),
+	}
+
+	// 启动测试
+	startup := make([]byte, entropyStartupSamples)
+	defer zeroBytes(startup)
+	if _, err := s.Read(startup); err != nil {
+		return nil, errors.Wrap(err, "熵源启动测试失败")
+	}
+	return s, nil
+}
+
+// OnFailure 设置健康测试失败回调，回调在持有内部锁时执行，不应再调用本熵源
+func (s *HealthTestedSource) OnFailure(fn func(*EntropyHealthError)) *HealthTestedSource {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onFailure = fn
+	return s
+}
+
+// Read 读取样本并执行健康测试，测试失败时返回的数据不可使用
+func (s *HealthTestedSource) Read(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failure != nil {
+		return 0, s.failure
+	}
+	n, err := s.source.Read(p)
+	if err != nil {
+		return n, errors.Wrap(err, "读取熵源失败")
+	}
+
+	for _, sample := range p[:n] {
+		if failure := s.test(sample); failure != nil {
+			s.failure = failure
+			zeroBytes(p[:n])
+			if s.onFailure != nil {
+				s.onFailure(failure)
+			}
+			return 0, failure
+		}
+	}
+	return n, nil
+}
+
+// MinEntropy 返回被测熵源声明的最小熵
+func (s *HealthTestedSource) MinEntropy() float64 {
+	return s.source.MinEntropy()
+}
+
+// Failure 返回导致故障的健康测试错误，未故障时返回nil
+func (s *HealthTestedSource) Failure() *EntropyHealthError {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.failure
+}
+
+// Reset 清除故障状态并重新执行启动测试
+func (s *HealthTestedSource) Reset() error {
+	s.mu.Lock()
+	s.failure = nil
+	s.rctCount, s.aptCount, s.aptIndex = 0, 0, 0
+	s.mu.Unlock()
+
+	startup := make([]byte, entropyStartupSamples)
+	defer zeroBytes(startup)
+	_, err := s.Read(startup)
+	return err
+}
+
+// test 对单个样本执行RCT与APT
+func (s *HealthTestedSource) test(sample byte) *EntropyHealthError {
+	if s.rctCount > 0 && sample == s.rctLast {
+		s.rctCount++
+		if s.rctCount >= s.rctCutoff {
+			return &EntropyHealthError{Test: "RCT", Sample: sample, Count: s.rctCount, Cutoff: s.rctCutoff}
+		}
+	} else {
+		s.rctLast = sample
+		s.rctCount = 1
+	}
+
+	if s.aptIndex == 0 {
+		s.aptFirst = sample
+		s.aptCount = 1
+	} else if sample == s.aptFirst {
+		s.aptCount++
+		if s.aptCount >= s.aptCutoff {
+			return &EntropyHealthError{Test: "APT", Sample: sample, Count: s.aptCount, Cutoff: s.aptCutoff}
+		}
+	}
+	s.aptIndex = (s.aptIndex + 1) % entropyAPTWindow
+	return nil
+}
+
+// entropyCritBinom 二项分布B(n, p)满足 P(X > k) <= 2^-alphaExp 的最小k
+func entropyCritBinom(n int, p float64, alphaExp int) int {
+	alpha := math.Pow(2, -float64(alphaExp))
+	lgN, _ := math.Lgamma(float64(n + 1))
+
+	tail := 0.0
+	for k := n; k > 0; k-- {
+		lgK, _ := math.Lgamma(float64(k + 1))
+		lgNK, _ := math.Lgamma(float64(n - k + 1))
+		tail += math.Exp(lgN - lgK - lgNK + float64(k)*math.Log(p) + float64(n-k)*math.Log1p(-p))
+		if tail > alpha {
+			return k
+		}
+	}
+	return 0
+}
+
+// JitterEntropySource CPU执行时间抖动熵源，不依赖操作系统随机数
+type JitterEntropySource struct {
+	mu         sync.Mutex
+	buf        []byte
+	acc        uint64
+	minEntropy float64
+}
+
+// NewJitterEntropySource 创建抖动熵源，默认声明每样本1比特最小熵
+func NewJitterEntropySource() *JitterEntropySource {
+	return &JitterEntropySource{buf: make([]byte, 4096), minEntropy: 1}
+}
+
+// WithMinEntropy 设置声明的每样本最小熵，应依据SP 800-90B熵评估结果设置
+func (j *JitterEntropySource) WithMinEntropy(bits float64) *JitterEntropySource {
+	j.minEntropy = bits
+	return j
+}
+
+// Read 每字节为一次计时样本
+func (j *JitterEntropySource) Read(p []byte) (int, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for i := range p {
+		p[i] = j.sample()
+	}
+	return len(p), nil
+}
+
+// MinEntropy 返回声明的每样本最小熵
+func (j *JitterEntropySource) MinEntropy() float64 {
+	return j.minEntropy
+}
+
+// sample 计时一段依赖前次结果的内存访问，折叠时间差作为样本
+func (j *JitterEntropySource) sample() byte {
+	start := time.Now()
+	for i := uint64(0); i < 64; i++ {
+		idx := (i*67 + j.acc) % uint64(len(j.buf))
+		j.buf[idx] += byte(i)
+		j.acc = j.acc*31 + uint64(j.buf[idx])
+	}
+	d := uint64(time.Since(start).Nanoseconds())
+	return byte(d ^ d>>8 ^ d>>16)
+}
+
+// MixedEntropySource 以SHA-512调节混合多个熵源
+type MixedEntropySource struct {
+	mu      sync.Mutex
+	sources []EntropySource
+	counter uint64
+}
+
+// NewMixedEntropySource 混合多个熵源，任一熵源出错时Read返回错误
+func NewMixedEntropySource(sources ...EntropySource) *MixedEntropySource {
+	return &MixedEntropySource{sources: sources}
+}
+
+// Read 每64字节输出为 SHA-512(计数器 || 各熵源输入)，输入量按声明的最小熵折算为至少512比特
+func (m *MixedEntropySource) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.sources) == 0 {
+		return 0, errors.New("没有可用的熵源")
+	}
+
+	n := 0
+	for n < len(p) {
+		h := sha512.New()
+		var counter [8]byte
+		binary.BigEndian.PutUint64(counter[:], m.counter)
+		m.counter++
+		h.Write(counter[:])
+
+		for _, source := range m.sources {
+			input := make([]byte, int(math.Ceil(512/source.MinEntropy())))
+			if _, err := source.Read(input); err != nil {
+				zeroBytes(p[:n])
+				return 0, err
+			}
+			h.Write(input)
+			zeroBytes(input)
+		}
+		n += copy(p[n:], h.Sum(nil))
+	}
+	return n, nil
+}
+
+// MinEntropy 调节后的输出按满熵计
+func (m *MixedEntropySource) MinEntropy() float64 {
+	return 8
+}
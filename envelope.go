@@ -0,0 +1,322 @@
+package encrypt
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+)
+
+// 版本化密文信封
+//
+// 链式API输出的密文不包含任何元数据：未设置IV时为 IV||密文，设置WithIV后只有密文（3DES仍为 IV||密文），IV需要调用方另行保存。
+// 一旦默认格式调整，已存储的数据就无法再解密。信封在密文前加上自描述头部，
+// 解密时按版本选择对应的解析方式，旧格式通过LegacyFormat描述后仍可解密，并可用UpgradeCiphertext迁移到当前版本。
+//
+// FormatV1 布局：
+//
+//	magic(2) | version(1) | algorithm(1) | mode(1) | padding(1) | ivLen(1) | iv | ciphertext
+
+// FormatVersion 密文格式版本
+type FormatVersion uint8
+
+// 密文格式版本常量定义
+const (
+	// FormatLegacyEmbedded 旧格式：IV||密文，无头部（链式API未设置IV时的输出）
+	FormatLegacyEmbedded FormatVersion = iota
+	// FormatLegacyDetached 旧格式：仅密文，IV单独保存（链式API设置WithIV时的输出）
+	FormatLegacyDetached
+	// FormatV1 带自描述头部的信封格式
+	FormatV1
+)
+
+// CurrentFormatVersion 当前写入使用的格式版本
+const CurrentFormatVersion = FormatV1
+
+// envelopeMagic 信封头部魔数
+var envelopeMagic = []byte{0x53, 0x45}
+
+// envelopeHeaderSize 信封固定头部长度（不含IV）
+const envelopeHeaderSize = 7
+
+// Envelope 版本化密文信封
+type Envelope struct {
+	Version    FormatVersion
+	Algorithm  Algorithm
+	Mode       Mode
+	Padding    PaddingMode
+	IV         []byte
+	Ciphertext []byte
+}
+
+// LegacyFormat 描述无头部的旧格式密文，解密时必须与加密时的配置一致
+type LegacyFormat struct {
+	Version   FormatVersion // FormatLegacyEmbedded 或 FormatLegacyDetached
+	Algorithm Algorithm
+	Mode      Mode
+	Padding   PaddingMode
+	IV        []byte // 仅FormatLegacyDetached使用
+}
+
+// Bytes 按当前格式版本序列化信封
+func (e *Envelope) Bytes() ([]byte, error) {
+	if e.Version != FormatV1 {
+		return nil, errors.Errorf("不支持序列化的格式版本: %d", e.Version)
+	}
+	if len(e.IV) > 0xff {
+		return nil, errors.New("IV长度超出限制")
+	}
+
+	out := make([]byte, 0, envelopeHeaderSize+len(e.IV)+len(e.Ciphertext))
+	out = append(out, envelopeMagic...)
+	out = append(out, byte(e.Version), byte(e.Algorithm), byte(e.Mode), byte(e.Padding), byte(len(e.IV)))
+	out = append(out, e.IV...)
+	out = append(out, e.Ciphertext...)
+	return out, nil
+}
+
+// IsEnvelope 判断数据是否带有信封头部
+func IsEnvelope(data []byte) bool {
+	return len(data) >= envelopeHeaderSize && bytes.Equal(data[:len(envelopeMagic)], envelopeMagic)
+}
+
+// ParseEnvelope 解析带头部的信封
+func ParseEnvelope(data []byte) (*Envelope, error) {
+	if !IsEnvelope(data) {
+		return nil, errors.New("数据不是信封格式")
+	}
+
+	version := FormatVersion(data[2])
+	switch version {
+	case FormatV1:
+		ivLen := int(data[6])
+		if len(data) < envelopeHeaderSize+ivLen {
+			return nil, errors.New("信封数据长度不足")
+		}
+		return &Envelope{
+			Version:    version,
+			Algorithm:  Algorithm(data[3]),
+			Mode:       Mode(data[4]),
+			Padding:    PaddingMode(data[5]),
+			IV:         data[envelopeHeaderSize : envelopeHeaderSize+ivLen],
+			Ciphertext: data[envelopeHeaderSize+ivLen:],
+		}, nil
+	default:
+		return nil, errors.Errorf("不支持的格式版本: %d", version)
+	}
+}
+
+// SealEnvelope 使用当前格式版本加密数据
+// ECB与GCM模式不使用独立IV（GCM的nonce包含在密文中），其余模式生成随机IV写入头部
+func SealEnvelope(algorithm Algorithm, mode Mode, key, plaintext []byte) ([]byte, error) {
+	return sealEnvelope(algorithm, mode, PaddingPKCS7, key, plaintext)
+}
+
+// SealEnvelopeSuite 按套件加密数据，套件的算法、模式与填充写入信封头部
+func SealEnvelopeSuite(suite Suite, key, plaintext []byte) ([]byte, error) {
+	if err := suite.Validate(); err != nil {
+		return nil, err
+	}
+	if len(key) != suite.KeySize {
+		return nil, errors.Errorf("密钥长度必须是%d字节", suite.KeySize)
+	}
+	padding := suite.Padding
+	if suite.Mode != ModeECB && suite.Mode != ModeCBC {
+		padding = PaddingPKCS7
+	}
+	return sealEnvelope(suite.Algorithm, suite.Mode, padding, key, plaintext)
+}
+
+// sealEnvelope 生成信封
+func sealEnvelope(algorithm Algorithm, mode Mode, padding PaddingMode, key, plaintext []byte) ([]byte, error) {
+	var iv []byte
+	if mode != ModeECB && mode != ModeGCM {
+		blockSize, err := symmetricBlockSize(algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if iv, err = GenerateRandomIV(blockSize); err != nil {
+			return nil, err
+		}
+	}
+
+	env := &Envelope{
+		Version:   CurrentFormatVersion,
+		Algorithm: algorithm,
+		Mode:      mode,
+		Padding:   padding,
+		IV:        iv,
+	}
+
+	encryptor, err := newEnvelopeEncryptor(algorithm, mode, env.Padding, key, iv)
+	if err != nil {
+		return nil, err
+	}
+	defer encryptor.Release()
+
+	ciphertext, err := encryptor.Encrypt(plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密失败")
+	}
+	if env.Ciphertext, err = stripEmbeddedIV(algorithm, mode, iv, ciphertext); err != nil {
+		return nil, err
+	}
+	return env.Bytes()
+}
+
+// OpenEnvelope 解密任意版本的密文
+// 带头部的数据按头部描述解密；无头部的旧数据需要提供legacy描述，否则返回错误
+func OpenEnvelope(key, data []byte, legacy *LegacyFormat) ([]byte, error) {
+	plaintext, _, err := openEnvelope(key, data, legacy)
+	return plaintext, err
+}
+
+// UpgradeCiphertext 将任意版本的密文迁移到当前格式版本，算法与模式保持不变
+// 已是当前版本且能够解密的数据原样返回
+func UpgradeCiphertext(key, data []byte, legacy *LegacyFormat) ([]byte, error) {
+	plaintext, env, err := openEnvelope(key, data, legacy)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(plaintext)
+	if env.Version == CurrentFormatVersion {
+		return data, nil
+	}
+	return SealEnvelope(env.Algorithm, env.Mode, key, plaintext)
+}
+
+// openEnvelope 解密数据并返回实际采用的Envelope
+// 旧格式密文约有1/65536的概率恰好以魔数开头，提供legacy时，头部解析或解密失败后按旧格式重试
+func openEnvelope(key, data []byte, legacy *LegacyFormat) ([]byte, *Envelope, error) {
+	if IsEnvelope(data) {
+		env, err := ParseEnvelope(data)
+		if err == nil {
+			plaintext, err := decryptEnvelope(key, env)
+			if err == nil {
+				return plaintext, env, nil
+			}
+		}
+		if legacy == nil {
+			return nil, nil, err
+		}
+	}
+	if legacy == nil {
+		return nil, nil, errors.New("数据不是信封格式，需要提供旧格式描述")
+	}
+
+	env, err := legacyEnvelope(data, legacy)
+	if err != nil {
+		return nil, nil, err
+	}
+	plaintext, err := decryptEnvelope(key, env)
+	if err != nil {
+		return nil, nil, err
+	}
+	return plaintext, env, nil
+}
+
+// decryptEnvelope 按信封描述解密
+func decryptEnvelope(key []byte, env *Envelope) ([]byte, error) {
+	encryptor, err := newEnvelopeEncryptor(env.Algorithm, env.Mode, env.Padding, key, env.IV)
+	if err != nil {
+		return nil, err
+	}
+	defer encryptor.Release()
+
+	ciphertext := env.Ciphertext
+	if embedsIV(env.Algorithm, env.Mode) {
+		ciphertext = append(append(make([]byte, 0, len(env.IV)+len(ciphertext)), env.IV...), ciphertext...)
+	}
+	plaintext, err := encryptor.Decrypt(ciphertext)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密失败")
+	}
+	return plaintext, nil
+}
+
+// embedsIV 判断加密器在设置WithIV后是否仍把IV置于密文之前
+// 3DES沿用 IV||密文 的历史格式，信封中IV只写入头部，加解密时需要去除或补回
+func embedsIV(algorithm Algorithm, mode Mode) bool {
+	return algorithm == Algorithm3DES && mode != ModeECB && mode != ModeGCM
+}
+
+// stripEmbeddedIV 去除密文前与头部一致的IV
+func stripEmbeddedIV(algorithm Algorithm, mode Mode, iv, ciphertext []byte) ([]byte, error) {
+	if !embedsIV(algorithm, mode) {
+		return ciphertext, nil
+	}
+	if len(ciphertext) < len(iv) || !bytes.Equal(ciphertext[:len(iv)], iv) {
+		return nil, errors.New("密文中的IV与信封头部不一致")
+	}
+	return ciphertext[len(iv):], nil
+}
+
+// legacyEnvelope 将旧格式数据按legacy描述转换为Envelope
+func legacyEnvelope(data []byte, legacy *LegacyFormat) (*Envelope, error) {
+	env := &Envelope{
+		Version:    legacy.Version,
+		Algorithm:  legacy.Algorithm,
+		Mode:       legacy.Mode,
+		Padding:    legacy.Padding,
+		Ciphertext: data,
+	}
+
+	switch legacy.Version {
+	case FormatLegacyEmbedded:
+		// ECB与GCM模式没有需要拆分的IV
+		if legacy.Mode == ModeECB || legacy.Mode == ModeGCM {
+			return env, nil
+		}
+		blockSize, err := symmetricBlockSize(legacy.Algorithm)
+		if err != nil {
+			return nil, err
+		}
+		if len(data) < blockSize {
+			return nil, errors.New("密文太短，无法提取IV")
+		}
+		env.IV = data[:blockSize]
+		env.Ciphertext = data[blockSize:]
+	case FormatLegacyDetached:
+		env.IV = legacy.IV
+	default:
+		return nil, errors.Errorf("不是旧格式版本: %d", legacy.Version)
+	}
+	return env, nil
+}
+
+// newEnvelopeEncryptor 创建IV独立保存、无编码的加密器
+func newEnvelopeEncryptor(algorithm Algorithm, mode Mode, padding PaddingMode, key, iv []byte) (ISymmetric, error) {
+	opts := []Option{WithMode(mode), WithPadding(padding), WithEncoding(EncodingNone)}
+	if len(iv) > 0 {
+		// Release会就地清零IV，复制一份避免破坏调用方的数据
+		opts = append(opts, WithIV(append([]byte(nil), iv...)))
+	}
+	return newSymmetric(algorithm, key, opts...)
+}
+
+// newSymmetric 按算法使用选项创建对称加密器
+func newSymmetric(algorithm Algorithm, key []byte, opts ...Option) (ISymmetric, error) {
+	switch algorithm {
+	case AlgorithmAES:
+		return AES(key, opts...)
+	case AlgorithmDES:
+		return DES(key, opts...)
+	case Algorithm3DES:
+		return TripleDES(key, opts...)
+	case AlgorithmSM4:
+		return SM4(key, opts...)
+	default:
+		return nil, errors.New("不支持的对称加密算法")
+	}
+}
+
+// symmetricBlockSize 获取对称算法的分组长度
+func symmetricBlockSize(algorithm Algorithm) (int, error) {
+	switch algorithm {
+	case AlgorithmAES, AlgorithmSM4:
+		return 16, nil
+	case AlgorithmDES, Algorithm3DES:
+		return 8, nil
+	default:
+		return 0, errors.New("不支持的对称加密算法")
+	}
+}
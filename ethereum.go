@@ -0,0 +1,177 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	secpecdsa "github.com/decred/dcrd/dcrec/secp256k1/v4/ecdsa"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/sha3"
+)
+
+// Keccak-256 与以太坊签名
+//
+// 以太坊使用的是原始Keccak-256（填充规则与FIPS 202的SHA3-256不同），两者输出不一致。
+// 签名为65字节 R || S || V，签名时S总是取低位值（EIP-2）。
+// personal_sign（EIP-191）对消息加前缀 "\x19Ethereum Signed Message:\n" + 十进制长度 后再做Keccak-256，
+// 其V为27或28；SignHash输出的V为0或1，与交易签名一致
+
+// EthereumSignatureSize 以太坊签名长度
+const EthereumSignatureSize = 65
+
+// ethereumMessagePrefix EIP-191 personal_sign前缀
+const ethereumMessagePrefix = "\x19Ethereum Signed Message:\n"
+
+// EthereumSigner secp256k1签名器
+type EthereumSigner struct {
+	key *secp256k1.PrivateKey
+}
+
+// Keccak256 计算Keccak-256摘要，多个参数按顺序拼接
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// EthereumMessageHash 计算personal_sign消息摘要
+func EthereumMessageHash(message []byte) []byte {
+	prefix := ethereumMessagePrefix + strconv.Itoa(len(message))
+	return Keccak256([]byte(prefix), message)
+}
+
+// GenerateEthereumSigner 生成随机secp256k1私钥
+func GenerateEthereumSigner() (*EthereumSigner, error) {
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成私钥失败")
+	}
+	return &EthereumSigner{key: key}, nil
+}
+
+// NewEthereumSigner 从32字节私钥创建签名器
+func NewEthereumSigner(privateKey []byte) (*EthereumSigner, error) {
+	if len(privateKey) != 32 {
+		return nil, errors.New("私钥长度必须是32字节")
+	}
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(privateKey); overflow || scalar.IsZero() {
+		return nil, errors.New("私钥超出secp256k1曲线阶范围")
+	}
+	return &EthereumSigner{key: secp256k1.NewPrivateKey(&scalar)}, nil
+}
+
+// NewEthereumSignerFromHex 从十六进制私钥创建签名器，可带0x前缀
+func NewEthereumSignerFromHex(privateKeyHex string) (*EthereumSigner, error) {
+	privateKey, err := hex.DecodeString(strings.TrimPrefix(privateKeyHex, "0x"))
+	if err != nil {
+		return nil, errors.Wrap(err, "私钥十六进制解码失败")
+	}
+	return NewEthereumSigner(privateKey)
+}
+
+// PrivateKey 获取32字节私钥
+func (s *EthereumSigner) PrivateKey() []byte {
+	return s.key.Serialize()
+}
+
+// PublicKey 获取65字节非压缩公钥
+func (s *EthereumSigner) PublicKey() []byte {
+	return s.key.PubKey().SerializeUncompressed()
+}
+
+// Address 获取EIP-55校验格式的以太坊地址
+func (s *EthereumSigner) Address() string {
+	return ethereumAddress(s.key.PubKey())
+}
+
+// SignHash 对32字节摘要签名，输出 R || S || V，V为0或1
+func (s *EthereumSigner) SignHash(hash []byte) ([]byte, error) {
+	if len(hash) != 32 {
+		return nil, errors.New("摘要长度必须是32字节")
+	}
+
+	// SignCompact输出 V || R || S，V = 27 + 恢复ID
+	compact := secpecdsa.SignCompact(s.key, hash, false)
+	sig := make([]byte, EthereumSignatureSize)
+	copy(sig, compact[1:])
+	sig[64] = compact[0] - 27
+	return sig, nil
+}
+
+// PersonalSign 按personal_sign对消息签名，V为27或28
+func (s *EthereumSigner) PersonalSign(message []byte) ([]byte, error) {
+	sig, err := s.SignHash(EthereumMessageHash(message))
+	if err != nil {
+		return nil, err
+	}
+	sig[64] += 27
+	return sig, nil
+}
+
+// RecoverEthereumAddress 从摘要与签名恢复签名者地址，V可以是0/1或27/28
+func RecoverEthereumAddress(hash, signature []byte) (string, error) {
+	if len(hash) != 32 {
+		return "", errors.New("摘要长度必须是32字节")
+	}
+	if len(signature) != EthereumSignatureSize {
+		return "", errors.New("签名长度必须是65字节")
+	}
+
+	v := signature[64]
+	if v >= 27 {
+		v -= 27
+	}
+	if v > 1 {
+		return "", errors.New("签名的V值不正确")
+	}
+
+	compact := make([]byte, EthereumSignatureSize)
+	compact[0] = 27 + v
+	copy(compact[1:], signature[:64])
+
+	pub, _, err := secpecdsa.RecoverCompact(compact, hash)
+	if err != nil {
+		return "", errors.Wrap(err, "恢复公钥失败")
+	}
+	return ethereumAddress(pub), nil
+}
+
+// RecoverPersonalSign 从personal_sign签名恢复签名者地址
+func RecoverPersonalSign(message, signature []byte) (string, error) {
+	return RecoverEthereumAddress(EthereumMessageHash(message), signature)
+}
+
+// VerifyPersonalSign 验证personal_sign签名是否由address签发，地址比较不区分大小写
+func VerifyPersonalSign(address string, message, signature []byte) bool {
+	recovered, err := RecoverPersonalSign(message, signature)
+	return err == nil && strings.EqualFold(recovered, address)
+}
+
+// ethereumAddress 计算公钥对应的地址：Keccak-256(X || Y)的后20字节
+func ethereumAddress(pub *secp256k1.PublicKey) string {
+	hash := Keccak256(pub.SerializeUncompressed()[1:])
+	return ethereumChecksumAddress(hash[12:])
+}
+
+// ethereumChecksumAddress 按EIP-55生成大小写校验地址
+func ethereumChecksumAddress(address []byte) string {
+	lower := hex.EncodeToString(address)
+	hash := Keccak256([]byte(lower))
+
+	out := []byte(lower)
+	for i, c := range out {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			out[i] = c - 32
+		}
+	}
+	return "0x" + string(out)
+}
@@ -3,8 +3,6 @@ package encrypt
 import (
 	"crypto/aes"
 	"crypto/des"
-	"crypto/rand"
-	"io"
 	
 	"github.com/pkg/errors"
 )
@@ -36,7 +34,7 @@ func NewAES(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != blockSize {
 		encryptor.iv = make([]byte, blockSize)
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -70,7 +68,7 @@ func NewDES(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != blockSize {
 		encryptor.iv = make([]byte, blockSize)
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -79,10 +77,12 @@ func NewDES(key []byte) (ISymmetric, error) {
 
 // New3DES 创建新的3DES加密器
 func New3DES(key []byte) (ISymmetric, error) {
-	// 验证密钥长度
-	if len(key) != 24 {
-		return nil, errors.New("3DES密钥长度必须是24字节")
+	// 验证密钥长度，16字节双倍长密钥按K1K2K1展开
+	key, err := NormalizeTripleDESKey(key)
+	if err != nil {
+		return nil, err
 	}
+	defer zeroBytes(key)
 	
 	// 从对象池获取实例
 	encryptor := EncryptorPools.TripleDES.Get().(*TripleDESEncryptor)
@@ -104,7 +104,7 @@ func New3DES(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != blockSize {
 		encryptor.iv = make([]byte, blockSize)
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -160,7 +160,7 @@ func NewSM4(key []byte) (ISymmetric, error) {
 	if encryptor.iv == nil || len(encryptor.iv) != 16 {
 		encryptor.iv = make([]byte, 16) // SM4块大小为16字节
 	}
-	if _, err := io.ReadFull(rand.Reader, encryptor.iv); err != nil {
+	if err := readNonce(encryptor.iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
@@ -0,0 +1,233 @@
+package encrypt
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// 按角色分字段加密的信封
+//
+// 一条订单记录中，银行卡号只给财务看，联系电话只给客服看，若为每个角色各存一份副本，
+// 更新时容易不一致。FieldEnvelope 把一条记录的各字段按角色分组，每组生成独立的数据密钥，
+// 数据密钥由该角色的主密钥（KeyProvider中的keyID）包装，全部字段保存在同一个JSON密文中：
+//
+//	{"version":1,"groups":[{"role":"finance","key_id":"kek-finance","wrapped_key":"...","fields":{"card_no":"..."}}]}
+//
+// 角色的访问控制落在主密钥上：客服服务的KeyProvider无法解包财务组的数据密钥，
+// 即使拿到完整密文也只能解密客服组的字段。每个字段的附加认证数据包含角色与字段名，
+// 密文不能被挪到其他字段或其他组；字段名与角色本身不加密
+
+// fieldEnvelopeVersion 字段信封格式版本
+const fieldEnvelopeVersion = 1
+
+// ErrFieldAccessDenied 无法解包字段所属角色的数据密钥，可通过errors.Is判断
+var ErrFieldAccessDenied = errors.New("无权解密该字段")
+
+// FieldEnvelope 分字段加密配置
+type FieldEnvelope struct {
+	provider KeyProvider
+	keyIDs   map[string]string // 角色 -> 主密钥ID
+	roles    map[string]string // 字段 -> 角色
+}
+
+// fieldEnvelopeFile 密文结构
+type fieldEnvelopeFile struct {
+	Version int                  `json:"version"`
+	Groups  []fieldEnvelopeGroup `json:"groups"`
+}
+
+// fieldEnvelopeGroup 一个角色的字段组
+type fieldEnvelopeGroup struct {
+	Role       string            `json:"role"`
+	KeyID      string            `json:"key_id"`
+	WrappedKey []byte            `json:"wrapped_key"`
+	Fields     map[string][]byte `json:"fields"`
+}
+
+// NewFieldEnvelope 创建分字段加密配置，数据密钥由provider包装
+func NewFieldEnvelope(provider KeyProvider) *FieldEnvelope {
+	return &FieldEnvelope{provider: provider, keyIDs: make(map[string]string), roles: make(map[string]string)}
+}
+
+// WithRole 声明角色及其主密钥，fields为该角色可见的字段，一个字段只能属于一个角色
+func (e *FieldEnvelope) WithRole(role, keyID string, fields ...string) *FieldEnvelope {
+	if role == "" || keyID == "" {
+		panic("角色与主密钥ID不能为空")
+	}
+	e.keyIDs[role] = keyID
+	for _, field := range fields {
+		if owner, ok := e.roles[field]; ok && owner != role {
+			panic("字段" + field + "已属于角色" + owner)
+		}
+		e.roles[field] = role
+	}
+	return e
+}
+
+// Seal 加密记录的各字段，未声明角色的字段报错，aad为调用方的附加认证数据（如记录ID）
+func (e *FieldEnvelope) Seal(ctx context.Context, values map[string][]byte, aad []byte) ([]byte, error) {
+	grouped := make(map[string][]string)
+	for field := range values {
+		role, ok := e.roles[field]
+		if !ok {
+			return nil, errors.Errorf("字段%s未声明所属角色", field)
+		}
+		grouped[role] = append(grouped[role], field)
+	}
+
+	file := fieldEnvelopeFile{Version: fieldEnvelopeVersion}
+	for _, role := range sortedKeys(grouped) {
+		keyID := e.keyIDs[role]
+		dataKey, wrapped, err := newDataKey(ctx, e.provider, keyID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "角色%s", role)
+		}
+
+		group := fieldEnvelopeGroup{Role: role, KeyID: keyID, WrappedKey: wrapped, Fields: make(map[string][]byte)}
+		for _, field := range grouped[role] {
+			ciphertext, err := AESGCMEncrypt(dataKey, values[field], fieldEnvelopeAAD(role, field, aad))
+			if err != nil {
+				zeroBytes(dataKey)
+				return nil, err
+			}
+			group.Fields[field] = ciphertext
+		}
+		zeroBytes(dataKey)
+		file.Groups = append(file.Groups, group)
+	}
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化字段信封失败")
+	}
+	return data, nil
+}
+
+// OpenFields 解密指定字段，fields为空时解密全部字段，任一字段无权解密时返回ErrFieldAccessDenied
+func OpenFields(ctx context.Context, provider KeyProvider, data, aad []byte, fields ...string) (map[string][]byte, error) {
+	file, err := parseFieldEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		wanted[field] = true
+	}
+	values := make(map[string][]byte)
+	for _, group := range file.Groups {
+		var selected []string
+		for field := range group.Fields {
+			if len(wanted) == 0 || wanted[field] {
+				selected = append(selected, field)
+				delete(wanted, field)
+			}
+		}
+		if len(selected) == 0 {
+			continue
+		}
+		if err := openFieldGroup(ctx, provider, group, selected, aad, values); err != nil {
+			return nil, err
+		}
+	}
+	for field := range wanted {
+		return nil, errors.Errorf("字段%s不存在", field)
+	}
+	return values, nil
+}
+
+// OpenAccessibleFields 解密provider有权解包的全部字段，denied为无权解密的字段（已排序）
+func OpenAccessibleFields(ctx context.Context, provider KeyProvider, data, aad []byte) (values map[string][]byte, denied []string, err error) {
+	file, err := parseFieldEnvelope(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values = make(map[string][]byte)
+	for _, group := range file.Groups {
+		fields := sortedKeys(group.Fields)
+		err := openFieldGroup(ctx, provider, group, fields, aad, values)
+		if errors.Is(err, ErrFieldAccessDenied) {
+			denied = append(denied, fields...)
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	sort.Strings(denied)
+	return values, denied, nil
+}
+
+// FieldEnvelopeRoles 读取各字段所属的角色，不解密
+func FieldEnvelopeRoles(data []byte) (map[string]string, error) {
+	file, err := parseFieldEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	roles := make(map[string]string)
+	for _, group := range file.Groups {
+		for field := range group.Fields {
+			roles[field] = group.Role
+		}
+	}
+	return roles, nil
+}
+
+// openFieldGroup 解包组数据密钥并解密选中的字段
+func openFieldGroup(ctx context.Context, provider KeyProvider, group fieldEnvelopeGroup, fields []string, aad []byte, values map[string][]byte) error {
+	dataKey, err := provider.UnwrapKey(ctx, group.KeyID, group.WrappedKey)
+	if err != nil {
+		return errors.Wrapf(ErrFieldAccessDenied, "角色%s: %v", group.Role, err)
+	}
+	defer zeroBytes(dataKey)
+
+	for _, field := range fields {
+		plaintext, err := AESGCMDecrypt(dataKey, group.Fields[field], fieldEnvelopeAAD(group.Role, field, aad))
+		if err != nil {
+			return errors.Wrapf(err, "解密字段%s失败", field)
+		}
+		values[field] = plaintext
+	}
+	return nil
+}
+
+// parseFieldEnvelope 解析并检查密文结构
+func parseFieldEnvelope(data []byte) (*fieldEnvelopeFile, error) {
+	var file fieldEnvelopeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrap(err, "解析字段信封失败")
+	}
+	if file.Version != fieldEnvelopeVersion {
+		return nil, errors.Errorf("不支持的字段信封版本: %d", file.Version)
+	}
+
+	seen := make(map[string]bool)
+	for _, group := range file.Groups {
+		for field := range group.Fields {
+			if seen[field] {
+				return nil, errors.Errorf("字段%s重复出现", field)
+			}
+			seen[field] = true
+		}
+	}
+	return &file, nil
+}
+
+// fieldEnvelopeAAD 字段附加认证数据：len(角色) || 角色 || 字段名，再与调用方的aad组合
+func fieldEnvelopeAAD(role, field string, aad []byte) []byte {
+	return storageAAD(storageAAD([]byte(role), []byte(field)), aad)
+}
+
+// sortedKeys 按字典序返回map的键
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
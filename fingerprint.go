@@ -0,0 +1,167 @@
+package encrypt
+
+import (
+	"crypto"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 公钥指纹工具
+// 指纹基于公钥的PKIX(SubjectPublicKeyInfo) DER编码计算，同一公钥的PKCS#1与PKIX PEM得到相同指纹，
+// 便于密钥交换时通过电话等带外渠道核对
+
+// PublicKeyFingerprint 计算PEM编码公钥的指纹
+// hashAlgo推荐使用HashSHA256或HashSM3
+func PublicKeyFingerprint(publicKeyPEM []byte, hashAlgo HashAlgorithm) ([]byte, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return fingerprint(publicKey, hashAlgo)
+}
+
+// Fingerprint 计算RSA公钥指纹
+func (r *RSAEncryptor) Fingerprint(hashAlgo HashAlgorithm) ([]byte, error) {
+	if r.publicKey == nil {
+		return nil, errors.New("未设置公钥")
+	}
+	return fingerprint(r.publicKey, hashAlgo)
+}
+
+// Fingerprint 计算SM2公钥指纹
+func (s *SM2Encryptor) Fingerprint(hashAlgo HashAlgorithm) ([]byte, error) {
+	publicKey := s.Public()
+	if publicKey == nil {
+		return nil, errors.New("未设置公钥")
+	}
+	return fingerprint(publicKey, hashAlgo)
+}
+
+// fingerprint 计算公钥DER编码的哈希值
+func fingerprint(publicKey crypto.PublicKey, hashAlgo HashAlgorithm) ([]byte, error) {
+	der, err := marshalPublicKeyDER(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hashFunc(hashAlgo)()
+	h.Write(der)
+	return h.Sum(nil), nil
+}
+
+// FormatFingerprint 将指纹格式化为冒号分隔的大写十六进制，如 "AB:CD:EF:..."
+func FormatFingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = strings.ToUpper(hex.EncodeToString([]byte{b}))
+	}
+	return strings.Join(parts, ":")
+}
+
+// ShortFingerprint 返回指纹前8字节的分组十六进制表示，如 "ABCD EF01 2345 6789"
+// 适合口头核对，碰撞概率对人工核验场景足够低
+func ShortFingerprint(sum []byte) string {
+	if len(sum) > 8 {
+		sum = sum[:8]
+	}
+
+	encoded := strings.ToUpper(hex.EncodeToString(sum))
+	groups := make([]string, 0, (len(encoded)+3)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		end := i + 4
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		groups = append(groups, encoded[i:end])
+	}
+	return strings.Join(groups, " ")
+}
+
+// 随机图尺寸，与OpenSSH保持一致
+const (
+	randomArtWidth  = 17
+	randomArtHeight = 9
+)
+
+// randomArtSymbols 按访问次数显示的字符，与OpenSSH保持一致
+const randomArtSymbols = " .o+=*BOX@%&#/^"
+
+// RandomArt 使用"醉酒主教"算法将指纹渲染为ASCII随机图（与ssh-keygen -lv风格一致）
+// title显示在边框顶部，通常为算法名称，如"SM2"或"RSA 2048"
+func RandomArt(sum []byte, title string) string {
+	var field [randomArtWidth][randomArtHeight]int
+	x, y := randomArtWidth/2, randomArtHeight/2
+	startX, startY := x, y
+
+	// 每个字节拆成4步，每步2比特决定移动方向
+	for _, b := range sum {
+		for i := 0; i < 4; i++ {
+			if b&0x1 != 0 {
+				x++
+			} else {
+				x--
+			}
+			if b&0x2 != 0 {
+				y++
+			} else {
+				y--
+			}
+
+			x = clampInt(x, 0, randomArtWidth-1)
+			y = clampInt(y, 0, randomArtHeight-1)
+
+			if field[x][y] < len(randomArtSymbols)-3 {
+				field[x][y]++
+			}
+			b >>= 2
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(randomArtBorder(title))
+	sb.WriteByte('\n')
+	for row := 0; row < randomArtHeight; row++ {
+		sb.WriteByte('|')
+		for col := 0; col < randomArtWidth; col++ {
+			switch {
+			case col == startX && row == startY:
+				sb.WriteByte('S')
+			case col == x && row == y:
+				sb.WriteByte('E')
+			default:
+				sb.WriteByte(randomArtSymbols[field[col][row]])
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	sb.WriteString("+" + strings.Repeat("-", randomArtWidth) + "+")
+	return sb.String()
+}
+
+// randomArtBorder 生成带标题的上边框
+func randomArtBorder(title string) string {
+	if title == "" {
+		return "+" + strings.Repeat("-", randomArtWidth) + "+"
+	}
+
+	label := "[" + title + "]"
+	if len(label) > randomArtWidth {
+		label = label[:randomArtWidth]
+	}
+	left := (randomArtWidth - len(label)) / 2
+	right := randomArtWidth - len(label) - left
+	return "+" + strings.Repeat("-", left) + label + strings.Repeat("-", right) + "+"
+}
+
+// clampInt 将数值限制在[min, max]范围内
+func clampInt(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,328 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/subtle"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// 固件镜像签名
+//
+// OTA构建步骤输出的镜像由签名头部与各分区数据依次拼接而成：
+//
+//	magic "SFWI"(4) | 格式版本(1) | 哈希算法(1) | 防回滚计数器(8) | 版本号长度(1) | 版本号 |
+//	分区数(2) | 每个分区：名称长度(1) | 名称 | 偏移(8) | 长度(8) | 分区哈希 |
+//	Merkle根 | 签名长度(2) | 签名 | 分区数据...
+//
+// 签名覆盖从magic到Merkle根的全部头部，Merkle树的叶子为各分区的 名称||哈希，
+// 设备端验证头部签名后可以边接收边校验单个分区（VerifySection），不必缓存整个镜像。
+// 防回滚计数器单调递增，设备保存已安装的计数器，拒绝计数器更小的镜像
+
+// ErrFirmwareRollback 固件计数器小于设备已安装的计数器
+var ErrFirmwareRollback = errors.New("固件版本回滚")
+
+// firmwareMagic 固件头部魔数
+var firmwareMagic = []byte("SFWI")
+
+// firmwareFormatVersion 固件头部格式版本
+const firmwareFormatVersion = 1
+
+// FirmwareSection 待签名的固件分区
+type FirmwareSection struct {
+	Name string
+	Data []byte
+}
+
+// FirmwareSectionInfo 头部记录的分区信息，Offset相对于分区数据起始位置
+type FirmwareSectionInfo struct {
+	Name   string
+	Offset uint64
+	Size   uint64
+	Hash   []byte
+}
+
+// FirmwareManifest 解析后的固件头部
+type FirmwareManifest struct {
+	HashAlgo   HashAlgorithm
+	Counter    uint64
+	Version    string
+	Sections   []FirmwareSectionInfo
+	Root       []byte
+	Signature  []byte
+	HeaderSize int // 头部总长度，分区数据从此处开始
+	signed     []byte
+}
+
+// Section 按名称查找分区
+func (m *FirmwareManifest) Section(name string) (*FirmwareSectionInfo, error) {
+	for i := range m.Sections {
+		if m.Sections[i].Name == name {
+			return &m.Sections[i], nil
+		}
+	}
+	return nil, errors.Errorf("固件分区不存在: %s", name)
+}
+
+// SignFirmware 签名固件并输出完整镜像，hashAlgo支持HashSHA256与HashSM3
+func SignFirmware(signer crypto.Signer, version string, counter uint64, sections []FirmwareSection, hashAlgo HashAlgorithm) ([]byte, error) {
+	if len(sections) == 0 || len(sections) > 0xffff {
+		return nil, errors.New("固件分区数量不正确")
+	}
+	if len(version) > 0xff {
+		return nil, errors.New("固件版本号过长")
+	}
+
+	manifest := &FirmwareManifest{HashAlgo: hashAlgo, Counter: counter, Version: version}
+	var offset uint64
+	names := make(map[string]bool, len(sections))
+	for _, section := range sections {
+		if section.Name == "" || len(section.Name) > 0xff || names[section.Name] {
+			return nil, errors.Errorf("固件分区名称不正确: %q", section.Name)
+		}
+		names[section.Name] = true
+		h := hashFunc(hashAlgo)()
+		h.Write(section.Data)
+		manifest.Sections = append(manifest.Sections, FirmwareSectionInfo{
+			Name:   section.Name,
+			Offset: offset,
+			Size:   uint64(len(section.Data)),
+			Hash:   h.Sum(nil),
+		})
+		offset += uint64(len(section.Data))
+	}
+
+	var err error
+	if manifest.Root, err = manifest.merkleRoot(); err != nil {
+		return nil, err
+	}
+	signed := manifest.marshalSigned()
+	if manifest.Signature, err = signMessage(signer, signed); err != nil {
+		return nil, errors.Wrap(err, "签名固件失败")
+	}
+	if len(manifest.Signature) > 0xffff {
+		return nil, errors.New("签名过长")
+	}
+
+	image := make([]byte, 0, len(signed)+2+len(manifest.Signature)+int(offset))
+	image = append(image, signed...)
+	image = binary.BigEndian.AppendUint16(image, uint16(len(manifest.Signature)))
+	image = append(image, manifest.Signature...)
+	for _, section := range sections {
+		image = append(image, section.Data...)
+	}
+	return image, nil
+}
+
+// FirmwareVerifier 固件验证器
+type FirmwareVerifier struct {
+	publicKey  crypto.PublicKey
+	minCounter uint64
+}
+
+// NewFirmwareVerifier 使用PEM公钥创建验证器
+func NewFirmwareVerifier(publicKeyPEM []byte) (*FirmwareVerifier, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return &FirmwareVerifier{publicKey: publicKey}, nil
+}
+
+// WithMinCounter 设置设备已安装固件的计数器，计数器更小的镜像被拒绝
+func (v *FirmwareVerifier) WithMinCounter(counter uint64) *FirmwareVerifier {
+	v.minCounter = counter
+	return v
+}
+
+// VerifyHeader 解析并验证头部签名与防回滚计数器，不校验分区数据
+func (v *FirmwareVerifier) VerifyHeader(image []byte) (*FirmwareManifest, error) {
+	manifest, err := ParseFirmwareHeader(image)
+	if err != nil {
+		return nil, err
+	}
+	root, err := manifest.merkleRoot()
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(root, manifest.Root) != 1 {
+		return nil, errors.New("固件分区哈希与Merkle根不一致")
+	}
+	if err := verifyMessage(v.publicKey, manifest.signed, manifest.Signature); err != nil {
+		return nil, errors.Wrap(err, "固件签名验证失败")
+	}
+	if manifest.Counter < v.minCounter {
+		return manifest, ErrFirmwareRollback
+	}
+	return manifest, nil
+}
+
+// Verify 验证完整镜像：头部签名、防回滚计数器以及每个分区的数据
+func (v *FirmwareVerifier) Verify(image []byte) (*FirmwareManifest, error) {
+	manifest, err := v.VerifyHeader(image)
+	if err != nil {
+		return manifest, err
+	}
+	payload := image[manifest.HeaderSize:]
+	var total uint64
+	for _, section := range manifest.Sections {
+		total += section.Size
+	}
+	if uint64(len(payload)) != total {
+		return nil, errors.New("固件数据长度与头部不一致")
+	}
+	for _, section := range manifest.Sections {
+		if err := manifest.VerifySection(section.Name, payload[section.Offset:section.Offset+section.Size]); err != nil {
+			return nil, err
+		}
+	}
+	return manifest, nil
+}
+
+// VerifySection 校验单个分区的数据，头部须已通过VerifyHeader验证
+func (m *FirmwareManifest) VerifySection(name string, data []byte) error {
+	section, err := m.Section(name)
+	if err != nil {
+		return err
+	}
+	if uint64(len(data)) != section.Size {
+		return errors.Errorf("固件分区%s长度不正确", name)
+	}
+	h := hashFunc(m.HashAlgo)()
+	h.Write(data)
+	if subtle.ConstantTimeCompare(h.Sum(nil), section.Hash) != 1 {
+		return errors.Errorf("固件分区%s哈希校验失败", name)
+	}
+	return nil
+}
+
+// ParseFirmwareHeader 解析固件头部，不做任何验证
+func ParseFirmwareHeader(image []byte) (*FirmwareManifest, error) {
+	r := bytes.NewReader(image)
+	fail := errors.New("固件头部格式不正确")
+
+	magic := make([]byte, len(firmwareMagic))
+	if _, err := io.ReadFull(r, magic); err != nil || !bytes.Equal(magic, firmwareMagic) {
+		return nil, errors.New("不是固件镜像")
+	}
+	var fixed struct {
+		Format   uint8
+		HashAlgo uint8
+		Counter  uint64
+	}
+	if err := binary.Read(r, binary.BigEndian, &fixed); err != nil {
+		return nil, fail
+	}
+	if fixed.Format != firmwareFormatVersion {
+		return nil, errors.Errorf("不支持的固件格式版本: %d", fixed.Format)
+	}
+	manifest := &FirmwareManifest{HashAlgo: HashAlgorithm(fixed.HashAlgo), Counter: fixed.Counter}
+	if manifest.HashAlgo != HashSHA256 && manifest.HashAlgo != HashSM3 {
+		return nil, errors.New("固件仅支持SHA-256与SM3")
+	}
+	hashSize := hashFunc(manifest.HashAlgo)().Size()
+
+	version, err := readFirmwareString(r)
+	if err != nil {
+		return nil, fail
+	}
+	manifest.Version = version
+
+	var count uint16
+	if err := binary.Read(r, binary.BigEndian, &count); err != nil || count == 0 {
+		return nil, fail
+	}
+	var expected uint64
+	for i := 0; i < int(count); i++ {
+		var info FirmwareSectionInfo
+		if info.Name, err = readFirmwareString(r); err != nil {
+			return nil, fail
+		}
+		if err := binary.Read(r, binary.BigEndian, &info.Offset); err != nil {
+			return nil, fail
+		}
+		if err := binary.Read(r, binary.BigEndian, &info.Size); err != nil {
+			return nil, fail
+		}
+		// 分区必须紧密排列，偏移不能指向其他分区，总长度不能溢出
+		if info.Offset != expected || info.Size > math.MaxUint64-expected {
+			return nil, fail
+		}
+		expected += info.Size
+		info.Hash = make([]byte, hashSize)
+		if _, err := io.ReadFull(r, info.Hash); err != nil {
+			return nil, fail
+		}
+		manifest.Sections = append(manifest.Sections, info)
+	}
+
+	manifest.Root = make([]byte, hashSize)
+	if _, err := io.ReadFull(r, manifest.Root); err != nil {
+		return nil, fail
+	}
+	signedSize := len(image) - r.Len()
+
+	var sigLen uint16
+	if err := binary.Read(r, binary.BigEndian, &sigLen); err != nil || int(sigLen) > r.Len() {
+		return nil, fail
+	}
+	manifest.Signature = make([]byte, sigLen)
+	if _, err := io.ReadFull(r, manifest.Signature); err != nil {
+		return nil, fail
+	}
+	manifest.HeaderSize = len(image) - r.Len()
+	manifest.signed = image[:signedSize]
+	return manifest, nil
+}
+
+// merkleRoot 以 名称||分区哈希 为叶子计算Merkle根
+func (m *FirmwareManifest) merkleRoot() ([]byte, error) {
+	leaves := make([][]byte, len(m.Sections))
+	for i, section := range m.Sections {
+		leaf := append([]byte{byte(len(section.Name))}, section.Name...)
+		leaves[i] = append(leaf, section.Hash...)
+	}
+	tree, err := BuildMerkleTree(leaves, m.HashAlgo)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Root(), nil
+}
+
+// marshalSigned 序列化签名覆盖的头部
+func (m *FirmwareManifest) marshalSigned() []byte {
+	out := append([]byte(nil), firmwareMagic...)
+	out = append(out, firmwareFormatVersion, byte(m.HashAlgo))
+	out = binary.BigEndian.AppendUint64(out, m.Counter)
+	out = append(out, byte(len(m.Version)))
+	out = append(out, m.Version...)
+	out = binary.BigEndian.AppendUint16(out, uint16(len(m.Sections)))
+	for _, section := range m.Sections {
+		out = append(out, byte(len(section.Name)))
+		out = append(out, section.Name...)
+		out = binary.BigEndian.AppendUint64(out, section.Offset)
+		out = binary.BigEndian.AppendUint64(out, section.Size)
+		out = append(out, section.Hash...)
+	}
+	return append(out, m.Root...)
+}
+
+// readFirmwareString 读取长度前缀的字符串
+func readFirmwareString(r *bytes.Reader) (string, error) {
+	n, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if int(n) > r.Len() {
+		return "", errors.New("长度超出范围")
+	}
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
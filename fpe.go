@@ -0,0 +1,270 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// 格式保留加密（NIST SP 800-38G FF1）
+//
+// 密文与明文使用相同的字符表与长度，例如11位手机号加密后仍是11位数字，
+// 可以直接写回原有的数据库列与校验规则。FF1是确定性的：相同的密钥、tweak与明文总是得到相同的密文，
+// 不同字段应使用不同的tweak。字符表大小为radix时，明文长度必须满足 radix^长度 ≥ 1000000，
+// 短值的取值空间太小，可以被穷举
+
+// FPE字符表常量定义
+const (
+	FPEDigits       = "0123456789"
+	FPEAlphanumeric = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+// FF1 相关限制
+const (
+	ff1Rounds     = 10
+	ff1MinDomain  = 1000000
+	ff1MaxRadix   = 1 << 16
+	ff1MaxLength  = 1 << 20
+	ff1MaxTweakSz = 1 << 16
+)
+
+// FPE FF1格式保留加密器
+type FPE struct {
+	block    cipher.Block
+	alphabet []rune
+	index    map[rune]int
+	minLen   int
+}
+
+// NewFPE 创建FF1加密器，algorithm为AlgorithmAES或AlgorithmSM4，alphabet为字符表（不能有重复字符）
+func NewFPE(algorithm Algorithm, key []byte, alphabet string) (*FPE, error) {
+	if algorithm != AlgorithmAES && algorithm != AlgorithmSM4 {
+		return nil, errors.New("FF1仅支持AES与SM4")
+	}
+	block, err := newCachedBlock(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(alphabet)
+	if len(runes) < 2 || len(runes) > ff1MaxRadix {
+		return nil, errors.Errorf("字符表大小必须在2到%d之间", ff1MaxRadix)
+	}
+	index := make(map[rune]int, len(runes))
+	for i, r := range runes {
+		if _, ok := index[r]; ok {
+			return nil, errors.Errorf("字符表中有重复字符: %q", r)
+		}
+		index[r] = i
+	}
+
+	// 最小长度：radix^minLen ≥ 1000000
+	minLen := 1
+	for domain := len(runes); domain < ff1MinDomain; domain *= len(runes) {
+		minLen++
+	}
+
+	return &FPE{block: block, alphabet: runes, index: index, minLen: minLen}, nil
+}
+
+// Radix 字符表大小
+func (f *FPE) Radix() int {
+	return len(f.alphabet)
+}
+
+// MinLength 可加密的最小长度
+func (f *FPE) MinLength() int {
+	return f.minLen
+}
+
+// Contains 字符是否在字符表中
+func (f *FPE) Contains(r rune) bool {
+	_, ok := f.index[r]
+	return ok
+}
+
+// Encrypt 加密字符串，所有字符必须在字符表中
+func (f *FPE) Encrypt(plaintext string, tweak []byte) (string, error) {
+	numerals, err := f.toNumerals(plaintext)
+	if err != nil {
+		return "", err
+	}
+	out, err := f.EncryptNumerals(numerals, tweak)
+	if err != nil {
+		return "", err
+	}
+	return f.fromNumerals(out), nil
+}
+
+// Decrypt 解密字符串
+func (f *FPE) Decrypt(ciphertext string, tweak []byte) (string, error) {
+	numerals, err := f.toNumerals(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	out, err := f.DecryptNumerals(numerals, tweak)
+	if err != nil {
+		return "", err
+	}
+	return f.fromNumerals(out), nil
+}
+
+// EncryptNumerals 加密数字串，每个元素为0到radix-1
+func (f *FPE) EncryptNumerals(numerals []uint16, tweak []byte) ([]uint16, error) {
+	return f.ff1(numerals, tweak, true)
+}
+
+// DecryptNumerals 解密数字串
+func (f *FPE) DecryptNumerals(numerals []uint16, tweak []byte) ([]uint16, error) {
+	return f.ff1(numerals, tweak, false)
+}
+
+// ff1 SP 800-38G 算法7与算法8
+func (f *FPE) ff1(x []uint16, tweak []byte, encrypt bool) ([]uint16, error) {
+	n := len(x)
+	radix := len(f.alphabet)
+	if n < f.minLen || n > ff1MaxLength {
+		return nil, errors.Errorf("FF1输入长度必须在%d到%d之间", f.minLen, ff1MaxLength)
+	}
+	if len(tweak) > ff1MaxTweakSz {
+		return nil, errors.New("FF1 tweak过长")
+	}
+	for _, numeral := range x {
+		if int(numeral) >= radix {
+			return nil, errors.New("数字超出字符表范围")
+		}
+	}
+
+	u := n / 2
+	v := n - u
+	bigRadix := big.NewInt(int64(radix))
+	modU := new(big.Int).Exp(bigRadix, big.NewInt(int64(u)), nil)
+	modV := new(big.Int).Exp(bigRadix, big.NewInt(int64(v)), nil)
+
+	// b = ⌈⌈v·log2(radix)⌉/8⌉，d = 4⌈b/4⌉ + 4
+	b := (new(big.Int).Sub(modV, big.NewInt(1)).BitLen() + 7) / 8
+	d := 4*((b+3)/4) + 4
+
+	t := len(tweak)
+	p := []byte{1, 2, 1, byte(radix >> 16), byte(radix >> 8), byte(radix), 10, byte(u),
+		byte(n >> 24), byte(n >> 16), byte(n >> 8), byte(n),
+		byte(t >> 24), byte(t >> 16), byte(t >> 8), byte(t)}
+
+	pad := (16 - (t+b+1)%16) % 16
+	q := make([]byte, t+pad+1+b)
+	copy(q, tweak)
+
+	a := ff1Num(x[:u], bigRadix)
+	bb := ff1Num(x[u:], bigRadix)
+	y := new(big.Int)
+	s := make([]byte, (d+15)/16*16)
+	r := make([]byte, 16)
+
+	for round := 0; round < ff1Rounds; round++ {
+		i := round
+		if !encrypt {
+			i = ff1Rounds - 1 - round
+		}
+
+		// 加密时对B计算轮函数，解密时对A计算
+		src := bb
+		if !encrypt {
+			src = a
+		}
+		q[t+pad] = byte(i)
+		numBytes := src.Bytes()
+		clear(q[t+pad+1:])
+		copy(q[len(q)-len(numBytes):], numBytes)
+
+		// R = PRF(P || Q)，CBC-MAC
+		clear(r)
+		f.cbcMAC(r, p)
+		f.cbcMAC(r, q)
+
+		// S = R || CIPH(R ⊕ [1]) || CIPH(R ⊕ [2]) ...
+		copy(s, r)
+		for j := 1; j*16 < d; j++ {
+			block := s[j*16 : j*16+16]
+			copy(block, r)
+			for k := 0; k < 4; k++ {
+				block[15-k] ^= byte(j >> (8 * k))
+			}
+			f.block.Encrypt(block, block)
+		}
+		y.SetBytes(s[:d])
+
+		mod := modU
+		if i%2 == 1 {
+			mod = modV
+		}
+		if encrypt {
+			c := new(big.Int).Add(a, y)
+			c.Mod(c, mod)
+			a, bb = bb, c
+		} else {
+			c := new(big.Int).Sub(bb, y)
+			c.Mod(c, mod)
+			bb, a = a, c
+		}
+	}
+
+	out := make([]uint16, n)
+	ff1Str(out[:u], a, bigRadix)
+	ff1Str(out[u:], bb, bigRadix)
+	return out, nil
+}
+
+// cbcMAC 以state为链接值继续对data做CBC-MAC，data长度必须是16的倍数
+func (f *FPE) cbcMAC(state, data []byte) {
+	for i := 0; i < len(data); i += 16 {
+		for j := 0; j < 16; j++ {
+			state[j] ^= data[i+j]
+		}
+		f.block.Encrypt(state, state)
+	}
+}
+
+// toNumerals 字符串转数字串
+func (f *FPE) toNumerals(s string) ([]uint16, error) {
+	numerals := make([]uint16, 0, utf8.RuneCountInString(s))
+	for _, r := range s {
+		i, ok := f.index[r]
+		if !ok {
+			return nil, errors.Errorf("字符%q不在字符表中", r)
+		}
+		numerals = append(numerals, uint16(i))
+	}
+	return numerals, nil
+}
+
+// fromNumerals 数字串转字符串
+func (f *FPE) fromNumerals(numerals []uint16) string {
+	out := make([]rune, len(numerals))
+	for i, numeral := range numerals {
+		out[i] = f.alphabet[numeral]
+	}
+	return string(out)
+}
+
+// ff1Num NUM_radix(X)，X[0]为最高位
+func ff1Num(x []uint16, radix *big.Int) *big.Int {
+	num := new(big.Int)
+	digit := new(big.Int)
+	for _, numeral := range x {
+		num.Mul(num, radix)
+		num.Add(num, digit.SetUint64(uint64(numeral)))
+	}
+	return num
+}
+
+// ff1Str STR_radix(num)，写满out（高位补0）
+func ff1Str(out []uint16, num, radix *big.Int) {
+	num = new(big.Int).Set(num)
+	rem := new(big.Int)
+	for i := len(out) - 1; i >= 0; i-- {
+		num.QuoRem(num, radix, rem)
+		out[i] = uint16(rem.Uint64())
+	}
+}
@@ -0,0 +1,111 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+// 无状态的GCM便捷函数，覆盖最常见的"用一个密钥加密一段数据"场景，无需了解链式API
+// 每次加密都生成随机nonce，输出格式为 nonce(12字节) || 密文 || 认证标签(16字节)，
+// 与SM4链式API中 GCM().NoEncoding() 的输出格式一致（aad为nil时可以互相解密）。
+// 注意AES链式API在GCM模式下仍会先做PKCS7填充，其密文解密后需要自行去除填充
+
+// AESGCMEncrypt 使用AES-GCM加密数据，aad为附加认证数据，可为nil
+func AESGCMEncrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(gcm, plaintext, aad)
+}
+
+// AESGCMDecrypt 解密AESGCMEncrypt的输出，aad必须与加密时一致
+func AESGCMDecrypt(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(gcm, ciphertext, aad)
+}
+
+// SM4GCMEncrypt 使用SM4-GCM加密数据，aad为附加认证数据，可为nil
+func SM4GCMEncrypt(key, plaintext, aad []byte) ([]byte, error) {
+	gcm, err := newSM4GCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmSeal(gcm, plaintext, aad)
+}
+
+// SM4GCMDecrypt 解密SM4GCMEncrypt的输出，aad必须与加密时一致
+func SM4GCMDecrypt(key, ciphertext, aad []byte) ([]byte, error) {
+	gcm, err := newSM4GCM(key)
+	if err != nil {
+		return nil, err
+	}
+	return gcmOpen(gcm, ciphertext, aad)
+}
+
+// newAESGCM 创建AES-GCM实例
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("AES密钥长度必须是16、24或32字节")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建密码块失败")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建GCM模式失败")
+	}
+	return gcm, nil
+}
+
+// newSM4GCM 创建SM4-GCM实例
+func newSM4GCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != sm4.BlockSize {
+		return nil, errors.New("SM4密钥长度必须是16字节")
+	}
+
+	block, err := newSM4Cipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建SM4块失败")
+	}
+
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建GCM模式失败")
+	}
+	return gcm, nil
+}
+
+// gcmSeal 生成随机nonce并加密，nonce附加在密文前
+func gcmSeal(gcm cipher.AEAD, plaintext, aad []byte) ([]byte, error) {
+	nonceSize := gcm.NonceSize()
+	result := make([]byte, nonceSize, nonceSize+len(plaintext)+gcm.Overhead())
+	if _, err := ReadRandom(result); err != nil {
+		return nil, errors.Wrap(err, "生成随机nonce失败")
+	}
+
+	return gcm.Seal(result, result[:nonceSize], plaintext, aad), nil
+}
+
+// gcmOpen 从密文中分离nonce并解密验证
+func gcmOpen(gcm cipher.AEAD, ciphertext, aad []byte) ([]byte, error) {
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize+gcm.Overhead() {
+		return nil, errors.New("密文太短，无法提取nonce")
+	}
+
+	plaintext, err := gcm.Open(nil, ciphertext[:nonceSize], ciphertext[nonceSize:], aad)
+	if err != nil {
+		return nil, errors.Wrap(err, "GCM解密失败，可能是数据被篡改")
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,47 @@
+// Package emmansun 基于emmansun/gmsm的国密后端
+//
+// emmansun/gmsm在amd64/arm64上使用汇编实现SM3/SM4，吞吐量明显高于默认的tjfoc/gmsm。
+// 导入本包即注册名为"emmansun"的后端并设为当前后端：
+//
+//	import _ "github.com/sylphbyte/encrypt/gm/emmansun"
+//
+// 之后仍可通过encrypt.UseGMBackend在"tjfoc"与"emmansun"之间切换。
+// 作为独立子模块发布，不使用该后端时主模块无需引入emmansun/gmsm
+package emmansun
+
+import (
+	"crypto/cipher"
+	"hash"
+
+	"github.com/emmansun/gmsm/sm3"
+	"github.com/emmansun/gmsm/sm4"
+	"github.com/sylphbyte/encrypt"
+)
+
+// Name 后端名称
+const Name = "emmansun"
+
+func init() {
+	encrypt.RegisterGMBackend(Backend{})
+	if err := encrypt.UseGMBackend(Name); err != nil {
+		panic(err)
+	}
+}
+
+// Backend 基于emmansun/gmsm的国密后端
+type Backend struct{}
+
+// Name 后端名称
+func (Backend) Name() string {
+	return Name
+}
+
+// NewSM3 创建SM3哈希实例
+func (Backend) NewSM3() hash.Hash {
+	return sm3.New()
+}
+
+// NewSM4Cipher 创建SM4分组密码实例
+func (Backend) NewSM4Cipher(key []byte) (cipher.Block, error) {
+	return sm4.NewCipher(key)
+}
@@ -0,0 +1,48 @@
+package emmansun
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestBackend 测试导入后自动启用，且结果与默认后端一致（GB/T 32905、GB/T 32907示例）
+func TestBackend(t *testing.T) {
+	if encrypt.CurrentGMBackend().Name() != Name {
+		t.Fatalf("导入后应启用%s后端，实际为%s", Name, encrypt.CurrentGMBackend().Name())
+	}
+
+	sum, err := encrypt.NewSM3().Hex().SumString("abc")
+	if err != nil || sum != "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0" {
+		t.Fatalf("SM3计算错误: %v, %s", err, sum)
+	}
+
+	key, _ := hex.DecodeString("0123456789abcdeffedcba9876543210")
+	want, _ := hex.DecodeString("681edf34d206965e86b3e94f536e4246")
+	block, err := Backend{}.NewSM4Cipher(key)
+	if err != nil {
+		t.Fatalf("创建SM4失败: %v", err)
+	}
+	out := make([]byte, 16)
+	block.Encrypt(out, key)
+	if !bytes.Equal(out, want) {
+		t.Fatalf("SM4计算错误: %x", out)
+	}
+
+	// 与tjfoc后端的SM4-GCM结果互通
+	plaintext := []byte("backend interop")
+	ciphertext, err := encrypt.MustNewSM4(key).(encrypt.IGCMSetter).GCM().NoEncoding().Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if err := encrypt.UseGMBackend("tjfoc"); err != nil {
+		t.Fatalf("切换后端失败: %v", err)
+	}
+	defer encrypt.UseGMBackend(Name)
+	decrypted, err := encrypt.MustNewSM4(key).(encrypt.IGCMSetter).GCM().NoEncoding().Decrypt(ciphertext)
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("跨后端解密失败: %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"hash"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm3"
+	"github.com/tjfoc/gmsm/sm4"
+)
+
+// 国密算法后端抽象
+// SM3/SM4是吞吐量敏感的热点路径，通过后端抽象可以在tjfoc/gmsm（默认）与
+// emmansun/gmsm（带汇编加速）之间切换：
+//   - 导入子模块：import _ "github.com/sylphbyte/encrypt/gm/emmansun"，该后端会注册并成为默认后端，
+//     emmansun/gmsm的依赖只出现在子模块的go.mod中，不使用它的项目不会引入
+//   - 运行期：UseGMBackend("tjfoc") / UseGMBackend("emmansun") 在已注册的后端之间切换
+// 当前后端保存在atomic.Pointer中，每次创建SM3/SM4实例只需一次原子读取，不加锁。
+// SM2的密钥类型与证书解析深度依赖tjfoc/gmsm，暂不纳入后端抽象
+
+// GMBackend 国密算法底层实现
+type GMBackend interface {
+	// Name 后端名称
+	Name() string
+	// NewSM3 创建SM3哈希实例
+	NewSM3() hash.Hash
+	// NewSM4Cipher 创建SM4分组密码实例
+	NewSM4Cipher(key []byte) (cipher.Block, error)
+}
+
+var (
+	// 已注册的国密后端
+	gmBackends = map[string]GMBackend{}
+
+	// 当前使用的国密后端，热点路径只做原子读取
+	currentGMBackend atomic.Pointer[GMBackend]
+
+	// 用于保护后端注册表的读写锁
+	gmBackendLock sync.RWMutex
+)
+
+func init() {
+	RegisterGMBackend(tjfocBackend{})
+	var backend GMBackend = tjfocBackend{}
+	currentGMBackend.Store(&backend)
+}
+
+// RegisterGMBackend 注册国密后端，同名后端会被覆盖
+func RegisterGMBackend(backend GMBackend) {
+	gmBackendLock.Lock()
+	defer gmBackendLock.Unlock()
+
+	gmBackends[backend.Name()] = backend
+}
+
+// UseGMBackend 切换当前使用的国密后端
+func UseGMBackend(name string) error {
+	gmBackendLock.Lock()
+	defer gmBackendLock.Unlock()
+
+	backend, ok := gmBackends[name]
+	if !ok {
+		return errors.Errorf("未注册的国密后端: %s", name)
+	}
+	currentGMBackend.Store(&backend)
+	return nil
+}
+
+// CurrentGMBackend 获取当前使用的国密后端
+func CurrentGMBackend() GMBackend {
+	return *currentGMBackend.Load()
+}
+
+// GMBackends 获取所有已注册的国密后端名称
+func GMBackends() []string {
+	gmBackendLock.RLock()
+	defer gmBackendLock.RUnlock()
+
+	names := make([]string, 0, len(gmBackends))
+	for name := range gmBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newSM3 使用当前后端创建SM3哈希实例
+func newSM3() hash.Hash {
+	return CurrentGMBackend().NewSM3()
+}
+
+// newSM4Cipher 使用当前后端创建SM4分组密码实例
+func newSM4Cipher(key []byte) (cipher.Block, error) {
+	return CurrentGMBackend().NewSM4Cipher(key)
+}
+
+// sm3Sum 使用当前后端计算SM3哈希值
+func sm3Sum(data []byte) []byte {
+	h := newSM3()
+	h.Write(data)
+	return h.Sum(nil)
+}
+
+// tjfocBackend 基于tjfoc/gmsm的默认后端
+type tjfocBackend struct{}
+
+// Name 后端名称
+func (tjfocBackend) Name() string {
+	return "tjfoc"
+}
+
+// NewSM3 创建SM3哈希实例
+func (tjfocBackend) NewSM3() hash.Hash {
+	return sm3.New()
+}
+
+// NewSM4Cipher 创建SM4分组密码实例
+func (tjfocBackend) NewSM4Cipher(key []byte) (cipher.Block, error) {
+	return sm4.NewCipher(key)
+}
@@ -0,0 +1,486 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// GM/T 0010 SM2密码消息（数字信封与签名数据）
+//
+// CFCA及各银行工具包输出的SM2数字信封、签名数据，结构与PKCS#7相同，使用国密OID：
+//
+//	ContentInfo ::= SEQUENCE { contentType OID, content [0] EXPLICIT ANY }
+//	EnvelopedData ::= SEQUENCE { version, recipientInfos SET OF RecipientInfo, encryptedContentInfo }
+//	RecipientInfo ::= SEQUENCE { version, issuerAndSerialNumber, keyEncryptionAlgorithm, encryptedKey OCTET STRING }
+//	SignedData ::= SEQUENCE { version, digestAlgorithms, contentInfo, certificates [0] IMPLICIT, signerInfos }
+//
+// 数字信封用随机SM4密钥以CBC模式加密内容，SM4密钥用每个接收者证书中的SM2公钥加密（ASN.1格式的SM2密文）。
+// 签名数据默认不带认证属性，直接对内容做SM2签名（含Z值，默认用户ID），与CFCA工具包的P7签名一致。
+// 解析时兼容PKCS#7标准OID、Base64文本、带认证属性的签名，以及C1C3C2/C1C2C3格式的SM2密文
+
+// GM/T 0010 对象标识符
+var (
+	oidGMData          = asn1.ObjectIdentifier{1, 2, 156, 10197, 6, 1, 4, 2, 1}
+	oidGMSignedData    = asn1.ObjectIdentifier{1, 2, 156, 10197, 6, 1, 4, 2, 2}
+	oidGMEnvelopedData = asn1.ObjectIdentifier{1, 2, 156, 10197, 6, 1, 4, 2, 3}
+	oidGMSM2Sign       = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 1}
+	oidGMSM2Encrypt    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 301, 3}
+	oidGMSM3WithSM2    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 501}
+	oidGMSM3           = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}
+	oidGMSM4           = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104}
+	oidGMSM4ECB        = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 1}
+	oidGMSM4CBC        = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 104, 2}
+
+	oidPKCS7SignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidPKCS7EnvelopedData     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 3}
+	oidAttributeMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+)
+
+// gmContentInfo ContentInfo
+type gmContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,optional,tag:0"`
+}
+
+// gmIssuerAndSerial IssuerAndSerialNumber
+type gmIssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+// gmEnvelopedData EnvelopedData
+type gmEnvelopedData struct {
+	Version              int
+	RecipientInfos       []gmRecipientInfo `asn1:"set"`
+	EncryptedContentInfo gmEncryptedContentInfo
+}
+
+// gmRecipientInfo RecipientInfo
+type gmRecipientInfo struct {
+	Version                int
+	IssuerAndSerial        gmIssuerAndSerial
+	KeyEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedKey           []byte
+}
+
+// gmEncryptedContentInfo EncryptedContentInfo
+type gmEncryptedContentInfo struct {
+	ContentType                asn1.ObjectIdentifier
+	ContentEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedContent           asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+// gmSignedData SignedData
+type gmSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	ContentInfo      gmContentInfo
+	Certificates     asn1.RawValue  `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue  `asn1:"optional,tag:1"`
+	SignerInfos      []gmSignerInfo `asn1:"set"`
+}
+
+// gmSignerInfo SignerInfo
+type gmSignerInfo struct {
+	Version                   int
+	IssuerAndSerial           gmIssuerAndSerial
+	DigestAlgorithm           pkix.AlgorithmIdentifier
+	AuthenticatedAttributes   asn1.RawValue `asn1:"optional,tag:0"`
+	DigestEncryptionAlgorithm pkix.AlgorithmIdentifier
+	EncryptedDigest           []byte
+	UnauthenticatedAttributes asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// gmAttribute Attribute
+type gmAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// SM2SignedData 解析后的签名数据
+type SM2SignedData struct {
+	Content      []byte              // 原文，分离式签名时为验证时传入的原文
+	Certificates []*x509.Certificate // 签名数据中携带的证书
+	Signers      []*x509.Certificate // 已验证的签名者证书
+}
+
+// SealSM2Envelope 使用接收者的SM2证书（PEM）生成GM/T 0010数字信封，输出DER编码
+func SealSM2Envelope(plaintext []byte, recipientCertsPEM ...[]byte) ([]byte, error) {
+	if len(recipientCertsPEM) == 0 {
+		return nil, errors.New("至少需要一个接收者证书")
+	}
+
+	key, err := GenerateRandomBytes(16)
+	if err != nil {
+		return nil, errors.Wrap(err, "生成内容加密密钥失败")
+	}
+	defer zeroBytes(key)
+	iv, err := GenerateRandomIV(16)
+	if err != nil {
+		return nil, err
+	}
+
+	env := gmEnvelopedData{}
+	for _, certPEM := range recipientCertsPEM {
+		cert, err := x509.ReadCertificateFromPem(certPEM)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析接收者证书失败")
+		}
+		publicKey, err := sm2CertificatePublicKey(cert)
+		if err != nil {
+			return nil, err
+		}
+		encryptedKey, err := publicKey.EncryptAsn1(key, rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "加密内容加密密钥失败")
+		}
+		env.RecipientInfos = append(env.RecipientInfos, gmRecipientInfo{
+			IssuerAndSerial:        gmIssuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			KeyEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidGMSM2Encrypt},
+			EncryptedKey:           encryptedKey,
+		})
+	}
+
+	encryptor, err := SM4(key, WithMode(ModeCBC), WithPadding(PaddingPKCS7), WithIV(append([]byte(nil), iv...)), WithEncoding(EncodingNone))
+	if err != nil {
+		return nil, err
+	}
+	defer encryptor.Release()
+	ciphertext, err := encryptor.Encrypt(plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密内容失败")
+	}
+
+	ivParam, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	env.EncryptedContentInfo = gmEncryptedContentInfo{
+		ContentType:                oidGMData,
+		ContentEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidGMSM4CBC, Parameters: asn1.RawValue{FullBytes: ivParam}},
+		EncryptedContent:           asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: ciphertext},
+	}
+	return marshalGMContentInfo(oidGMEnvelopedData, env)
+}
+
+// OpenSM2Envelope 使用接收者证书与SM2私钥（PEM）解开数字信封，输入可以是DER或Base64
+func OpenSM2Envelope(envelope, certPEM, privateKeyPEM []byte) ([]byte, error) {
+	cert, err := x509.ReadCertificateFromPem(certPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析接收者证书失败")
+	}
+	privateKey, err := x509.ReadPrivateKeyFromPem(privateKeyPEM, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析SM2私钥失败")
+	}
+	return openSM2Envelope(envelope, cert, privateKey)
+}
+
+// openSM2Envelope 解开数字信封
+func openSM2Envelope(envelope []byte, cert *x509.Certificate, privateKey *sm2.PrivateKey) ([]byte, error) {
+	contentType, content, err := parseGMContentInfo(envelope)
+	if err != nil {
+		return nil, err
+	}
+	if !contentType.Equal(oidGMEnvelopedData) && !contentType.Equal(oidPKCS7EnvelopedData) {
+		return nil, errors.Errorf("不是数字信封: %s", contentType)
+	}
+	var env gmEnvelopedData
+	if _, err := asn1.Unmarshal(content, &env); err != nil {
+		return nil, errors.Wrap(err, "解析数字信封失败")
+	}
+
+	var recipient *gmRecipientInfo
+	for i := range env.RecipientInfos {
+		ri := &env.RecipientInfos[i]
+		if ri.IssuerAndSerial.SerialNumber != nil && ri.IssuerAndSerial.SerialNumber.Cmp(cert.SerialNumber) == 0 &&
+			bytes.Equal(ri.IssuerAndSerial.Issuer.FullBytes, cert.RawIssuer) {
+			recipient = ri
+			break
+		}
+	}
+	if recipient == nil {
+		return nil, errors.New("数字信封中没有该证书对应的接收者")
+	}
+
+	key, err := decryptSM2Flexible(privateKey, recipient.EncryptedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密内容加密密钥失败")
+	}
+	defer zeroBytes(key)
+
+	eci := env.EncryptedContentInfo
+	ciphertext := eci.EncryptedContent.Bytes
+	if eci.EncryptedContent.IsCompound {
+		// BER分段编码的OCTET STRING
+		if ciphertext, err = joinOctetStrings(ciphertext); err != nil {
+			return nil, err
+		}
+	}
+
+	opts := []Option{WithPadding(PaddingPKCS7), WithEncoding(EncodingNone)}
+	alg := eci.ContentEncryptionAlgorithm
+	var iv []byte
+	if len(alg.Parameters.FullBytes) > 0 && alg.Parameters.Tag == asn1.TagOctetString {
+		iv = alg.Parameters.Bytes
+	}
+	switch {
+	case alg.Algorithm.Equal(oidGMSM4CBC), alg.Algorithm.Equal(oidGMSM4) && len(iv) == 16:
+		if len(iv) != 16 {
+			return nil, errors.New("SM4-CBC缺少IV")
+		}
+		opts = append(opts, WithMode(ModeCBC), WithIV(append([]byte(nil), iv...)))
+	case alg.Algorithm.Equal(oidGMSM4ECB), alg.Algorithm.Equal(oidGMSM4):
+		opts = append(opts, WithMode(ModeECB))
+	default:
+		return nil, errors.Errorf("不支持的内容加密算法: %s", alg.Algorithm)
+	}
+	decryptor, err := SM4(key, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer decryptor.Release()
+	plaintext, err := decryptor.Decrypt(ciphertext)
+	if err != nil {
+		return nil, errors.Wrap(err, "解密内容失败")
+	}
+	return plaintext, nil
+}
+
+// SignSM2Data 使用SM2签名证书与私钥（PEM）生成GM/T 0010签名数据，detached为true时不携带原文
+func SignSM2Data(content, certPEM, privateKeyPEM []byte, detached bool) ([]byte, error) {
+	cert, err := x509.ReadCertificateFromPem(certPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析签名证书失败")
+	}
+	privateKey, err := x509.ReadPrivateKeyFromPem(privateKeyPEM, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析SM2私钥失败")
+	}
+	return signSM2Data(content, cert, privateKey, detached)
+}
+
+// signSM2Data 生成签名数据
+func signSM2Data(content []byte, cert *x509.Certificate, privateKey *sm2.PrivateKey, detached bool) ([]byte, error) {
+	r, s, err := sm2.Sm2Sign(privateKey, content, nil, rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "SM2签名失败")
+	}
+	signature, err := sm2.SignDigitToSignData(r, s)
+	if err != nil {
+		return nil, errors.Wrap(err, "编码签名失败")
+	}
+
+	contentInfo := gmContentInfo{ContentType: oidGMData}
+	if !detached {
+		inner, err := asn1.Marshal(content)
+		if err != nil {
+			return nil, err
+		}
+		contentInfo.Content = asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner}
+	}
+
+	sd := gmSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidGMSM3}},
+		ContentInfo:      contentInfo,
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: cert.Raw},
+		SignerInfos: []gmSignerInfo{{
+			Version:                   1,
+			IssuerAndSerial:           gmIssuerAndSerial{Issuer: asn1.RawValue{FullBytes: cert.RawIssuer}, SerialNumber: cert.SerialNumber},
+			DigestAlgorithm:           pkix.AlgorithmIdentifier{Algorithm: oidGMSM3},
+			DigestEncryptionAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oidGMSM2Sign},
+			EncryptedDigest:           signature,
+		}},
+	}
+	return marshalGMContentInfo(oidGMSignedData, sd)
+}
+
+// VerifySM2SignedData 验证GM/T 0010签名数据，输入可以是DER或Base64
+// 分离式签名需要传入原文detachedContent，带原文的签名传nil
+// 只验证签名本身，签名证书的信任链需要调用方另行校验
+func VerifySM2SignedData(signed, detachedContent []byte) (*SM2SignedData, error) {
+	contentType, content, err := parseGMContentInfo(signed)
+	if err != nil {
+		return nil, err
+	}
+	if !contentType.Equal(oidGMSignedData) && !contentType.Equal(oidPKCS7SignedData) {
+		return nil, errors.Errorf("不是签名数据: %s", contentType)
+	}
+	var sd gmSignedData
+	if _, err := asn1.Unmarshal(content, &sd); err != nil {
+		return nil, errors.Wrap(err, "解析签名数据失败")
+	}
+
+	result := &SM2SignedData{Content: detachedContent}
+	if len(sd.ContentInfo.Content.Bytes) > 0 {
+		var attached []byte
+		if _, err := asn1.Unmarshal(sd.ContentInfo.Content.Bytes, &attached); err != nil {
+			return nil, errors.Wrap(err, "解析签名原文失败")
+		}
+		if detachedContent != nil && !bytes.Equal(attached, detachedContent) {
+			return nil, errors.New("签名数据中的原文与传入的原文不一致")
+		}
+		result.Content = attached
+	}
+	if result.Content == nil {
+		return nil, errors.New("分离式签名需要提供原文")
+	}
+
+	if len(sd.Certificates.Bytes) > 0 {
+		if result.Certificates, err = x509.ParseCertificates(sd.Certificates.Bytes); err != nil {
+			return nil, errors.Wrap(err, "解析签名证书失败")
+		}
+	}
+	if len(sd.SignerInfos) == 0 {
+		return nil, errors.New("签名数据中没有签名者")
+	}
+
+	for _, signer := range sd.SignerInfos {
+		cert := findGMCertificate(result.Certificates, signer.IssuerAndSerial)
+		if cert == nil {
+			return nil, errors.New("签名数据中缺少签名者证书")
+		}
+		if err := verifyGMSignerInfo(cert, signer, result.Content); err != nil {
+			return nil, err
+		}
+		result.Signers = append(result.Signers, cert)
+	}
+	return result, nil
+}
+
+// verifyGMSignerInfo 验证单个签名者
+func verifyGMSignerInfo(cert *x509.Certificate, signer gmSignerInfo, content []byte) error {
+	if !signer.DigestEncryptionAlgorithm.Algorithm.Equal(oidGMSM2Sign) &&
+		!signer.DigestEncryptionAlgorithm.Algorithm.Equal(oidGMSM3WithSM2) {
+		return errors.Errorf("不支持的签名算法: %s", signer.DigestEncryptionAlgorithm.Algorithm)
+	}
+	publicKey, err := sm2CertificatePublicKey(cert)
+	if err != nil {
+		return err
+	}
+
+	signedBytes := content
+	if len(signer.AuthenticatedAttributes.Bytes) > 0 {
+		// 带认证属性时，消息摘要属性必须等于原文的SM3摘要，签名覆盖按SET编码的属性
+		var attrs []gmAttribute
+		if _, err := asn1.UnmarshalWithParams(signer.AuthenticatedAttributes.FullBytes, &attrs, "set,tag:0"); err != nil {
+			return errors.Wrap(err, "解析认证属性失败")
+		}
+		var digest []byte
+		for _, attr := range attrs {
+			if attr.Type.Equal(oidAttributeMessageDigest) {
+				if _, err := asn1.Unmarshal(attr.Values.Bytes, &digest); err != nil {
+					return errors.Wrap(err, "解析消息摘要属性失败")
+				}
+			}
+		}
+		h := newSM3()
+		h.Write(content)
+		if digest == nil || subtle.ConstantTimeCompare(h.Sum(nil), digest) != 1 {
+			return errors.New("消息摘要属性与原文不一致")
+		}
+		signedBytes = append([]byte{0x31}, signer.AuthenticatedAttributes.FullBytes[1:]...)
+	}
+
+	r, s, err := sm2.SignDataToSignDigit(signer.EncryptedDigest)
+	if err != nil {
+		return errors.Wrap(err, "解析签名值失败")
+	}
+	if !sm2.Sm2Verify(publicKey, signedBytes, nil, r, s) {
+		return errors.New("签名验证失败")
+	}
+	return nil
+}
+
+// marshalGMContentInfo 编码ContentInfo
+func marshalGMContentInfo(contentType asn1.ObjectIdentifier, content interface{}) ([]byte, error) {
+	inner, err := asn1.Marshal(content)
+	if err != nil {
+		return nil, errors.Wrap(err, "编码内容失败")
+	}
+	return asn1.Marshal(gmContentInfo{
+		ContentType: contentType,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: inner},
+	})
+}
+
+// parseGMContentInfo 解析ContentInfo，返回内容类型与内容的DER编码，输入不是DER时按Base64解码
+func parseGMContentInfo(data []byte) (asn1.ObjectIdentifier, []byte, error) {
+	data, err := decodeDERorBase64(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	var info gmContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, nil, errors.Wrap(err, "解析ContentInfo失败")
+	}
+	return info.ContentType, info.Content.Bytes, nil
+}
+
+// decryptSM2Flexible 解密SM2密文，依次尝试ASN.1、C1C3C2与C1C2C3格式
+func decryptSM2Flexible(privateKey *sm2.PrivateKey, ciphertext []byte) ([]byte, error) {
+	if plaintext, err := privateKey.DecryptAsn1(ciphertext); err == nil {
+		return plaintext, nil
+	}
+	// 裸格式以0x04开头（C1为未压缩点），部分工具包省略该字节
+	raw := ciphertext
+	if len(raw) == 0 || raw[0] != 0x04 {
+		raw = append([]byte{0x04}, raw...)
+	}
+	if len(raw) < 97 {
+		return nil, errors.New("SM2密文长度不正确")
+	}
+	if plaintext, err := sm2.Decrypt(privateKey, raw, sm2.C1C3C2); err == nil {
+		return plaintext, nil
+	}
+	return sm2.Decrypt(privateKey, raw, sm2.C1C2C3)
+}
+
+// joinOctetStrings 拼接BER分段编码的OCTET STRING
+func joinOctetStrings(data []byte) ([]byte, error) {
+	var out []byte
+	for len(data) > 0 {
+		var part []byte
+		rest, err := asn1.Unmarshal(data, &part)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析分段密文失败")
+		}
+		out = append(out, part...)
+		data = rest
+	}
+	return out, nil
+}
+
+// findGMCertificate 按颁发者与序列号查找证书
+func findGMCertificate(certs []*x509.Certificate, ias gmIssuerAndSerial) *x509.Certificate {
+	for _, cert := range certs {
+		if ias.SerialNumber != nil && cert.SerialNumber.Cmp(ias.SerialNumber) == 0 &&
+			bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+			return cert
+		}
+	}
+	return nil
+}
+
+// sm2CertificatePublicKey 获取证书中的SM2公钥
+func sm2CertificatePublicKey(cert *x509.Certificate) (*sm2.PublicKey, error) {
+	switch pub := cert.PublicKey.(type) {
+	case *sm2.PublicKey:
+		return pub, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve == sm2.P256Sm2() {
+			return &sm2.PublicKey{Curve: pub.Curve, X: pub.X, Y: pub.Y}, nil
+		}
+	}
+	return nil, errors.New("证书不是SM2证书")
+}
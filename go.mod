@@ -3,15 +3,19 @@ module github.com/sylphbyte/encrypt
 go 1.24.2
 
 require (
+	filippo.io/edwards25519 v1.1.0
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1
 	github.com/pkg/errors v0.9.1
 	github.com/stretchr/testify v1.10.0
 	github.com/tjfoc/gmsm v1.4.1
+	golang.org/x/crypto v0.37.0
+	golang.org/x/term v0.31.0
+	golang.org/x/text v0.24.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
-	golang.org/x/crypto v0.37.0 // indirect
 	golang.org/x/sys v0.32.0 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,322 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// 密文诊断
+//
+// "解不开"的工单里，最常见的原因是格式或编码不对：把Base64当成原始字节、IV单独保存却按IV||密文解析、
+// 用错了密钥ID等。Inspect 识别本库输出的各种格式（版本化信封、KMS数据密钥信封、分块GCM、
+// 密钥环、配置密文、Vault密文等），报告算法、密钥ID、IV/nonce、是否有认证标签与各部分长度，
+// 外层为十六进制或Base64时先自动解码。Inspect 不需要密钥，也永远不会输出明文，
+// 结果可以直接贴进工单。无法识别时按长度给出可能的模式提示
+
+// Inspect识别出的格式
+const (
+	InspectFormatUnknown      = "unknown"
+	InspectFormatEnvelope     = "envelope-v1"
+	InspectFormatKMSEnvelope  = "kms-envelope"
+	InspectFormatChunked      = "chunked-gcm"
+	InspectFormatKeyRing      = "keyring"
+	InspectFormatConfigSecret = "config-secret"
+	InspectFormatConfigValue  = "config-value"
+	InspectFormatVault        = "vault-transit"
+)
+
+// inspectGCMNonceSize 与 inspectTagSize GCM的nonce与标签长度
+const (
+	inspectGCMNonceSize = 12
+	inspectTagSize      = 16
+)
+
+// CiphertextInfo 密文诊断结果，不包含任何明文
+type CiphertextInfo struct {
+	Format     string       // 识别出的格式，InspectFormat*常量之一
+	Encoding   EncodingMode // 外层文本编码，原始字节或带前缀的文本格式为EncodingNone
+	Algorithm  string       // 算法名称，如AES、SM4、XChaCha20-Poly1305，无法确定时为空
+	Mode       string       // 模式名称，如GCM、CBC
+	Padding    string       // 填充名称，仅ECB、CBC有值
+	KeyID      string       // 密钥ID或密钥版本
+	Nonce      []byte       // IV或nonce
+	HasTag     bool         // 是否带认证标签
+	TagSize    int          // 认证标签长度
+	TotalSize  int          // 解码后的总长度
+	HeaderSize int          // 头部长度（不含nonce）
+	BodySize   int          // 去掉头部、nonce与标签后的密文长度
+	Notes      []string     // 其他信息与排查提示
+}
+
+// Inspect 解析密文并报告其结构，data可以是原始字节、十六进制、Base64或带前缀的文本格式
+func Inspect(data []byte) *CiphertextInfo {
+	text := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(text, ConfigSecretPrefix):
+		return inspectConfigSecret(text)
+	case strings.HasPrefix(text, configValuePrefix) && strings.HasSuffix(text, "]"):
+		return inspectConfigValue(text)
+	case strings.HasPrefix(text, "vault:v"):
+		return inspectVault(text)
+	}
+
+	info := &CiphertextInfo{}
+	raw := data
+	if encoding := DetectEncoding(data); encoding != EncodingNone {
+		if decoded, err := DecodeAuto(data); err == nil {
+			raw, info.Encoding = decoded, encoding
+		}
+	}
+	info.TotalSize = len(raw)
+
+	switch {
+	case IsEnvelope(raw):
+		inspectEnvelope(info, raw)
+	case len(raw) >= 4 && bytes.Equal(raw[:2], kmsEnvelopeMagic):
+		inspectKMSEnvelope(info, raw)
+	case len(raw) >= chunkedHeaderSize && bytes.Equal(raw[:4], chunkedMagic):
+		inspectChunked(info, raw)
+	case inspectIsKeyRing(raw):
+		inspectKeyRing(info, raw)
+	default:
+		inspectUnknown(info, raw)
+	}
+	return info
+}
+
+// String 多行文本形式的诊断结果
+func (i *CiphertextInfo) String() string {
+	var b strings.Builder
+	line := func(name, value string) {
+		if value != "" {
+			b.WriteString(name + ": " + value + "\n")
+		}
+	}
+	line("格式", i.Format)
+	line("外层编码", inspectEncodingNames[i.Encoding])
+	line("算法", i.Algorithm)
+	line("模式", i.Mode)
+	line("填充", i.Padding)
+	line("密钥ID", i.KeyID)
+	if len(i.Nonce) > 0 {
+		line("IV/nonce", hex.EncodeToString(i.Nonce)+" ("+strconv.Itoa(len(i.Nonce))+"字节)")
+	}
+	if i.HasTag {
+		line("认证标签", strconv.Itoa(i.TagSize)+"字节")
+	} else if i.Format != InspectFormatUnknown {
+		line("认证标签", "无")
+	}
+	line("长度", "总计"+strconv.Itoa(i.TotalSize)+"，头部"+strconv.Itoa(i.HeaderSize)+"，密文"+strconv.Itoa(i.BodySize))
+	for _, note := range i.Notes {
+		line("提示", note)
+	}
+	return b.String()
+}
+
+// inspectEncodingNames 外层编码名称，EncodingNone不输出
+var inspectEncodingNames = map[EncodingMode]string{
+	EncodingHex:        "十六进制",
+	EncodingBase64:     "Base64",
+	EncodingBase64Safe: "URL安全Base64",
+}
+
+// note 追加提示
+func (i *CiphertextInfo) note(text string) {
+	i.Notes = append(i.Notes, text)
+}
+
+// setAEAD 按 nonce || 密文 || 标签 的布局填充长度信息
+func (i *CiphertextInfo) setAEAD(body []byte, nonceSize int) {
+	if len(body) < nonceSize+inspectTagSize {
+		i.BodySize = len(body)
+		i.note("数据长度不足nonce与认证标签，可能被截断")
+		return
+	}
+	i.Nonce = body[:nonceSize]
+	i.HasTag, i.TagSize = true, inspectTagSize
+	i.BodySize = len(body) - nonceSize - inspectTagSize
+}
+
+// inspectEnvelope 版本化信封
+func inspectEnvelope(info *CiphertextInfo, raw []byte) {
+	info.Format = InspectFormatEnvelope
+	envelope, err := ParseEnvelope(raw)
+	if err != nil {
+		info.note(err.Error())
+		return
+	}
+	info.Algorithm = suiteAlgorithmNames[envelope.Algorithm]
+	info.Mode = suiteModeNames[envelope.Mode]
+	info.HeaderSize = envelopeHeaderSize
+	if envelope.Mode == ModeECB || envelope.Mode == ModeCBC {
+		info.Padding = suitePaddingNames[envelope.Padding]
+	}
+	if envelope.Mode == ModeGCM {
+		info.setAEAD(envelope.Ciphertext, inspectGCMNonceSize)
+		return
+	}
+
+	info.Nonce = envelope.IV
+	info.BodySize = len(envelope.Ciphertext)
+	if blockSize, err := symmetricBlockSize(envelope.Algorithm); err == nil &&
+		(envelope.Mode == ModeECB || envelope.Mode == ModeCBC) && info.BodySize%blockSize != 0 {
+		info.note("密文长度不是分组长度" + strconv.Itoa(blockSize) + "的整数倍，数据可能被截断")
+	}
+}
+
+// inspectKMSEnvelope KMS数据密钥信封
+func inspectKMSEnvelope(info *CiphertextInfo, raw []byte) {
+	info.Format = InspectFormatKMSEnvelope
+	info.Algorithm, info.Mode = "AES", "GCM"
+	keyID, wrapped, headerLen, err := parseKMSEnvelope(raw)
+	if err != nil {
+		info.note(err.Error())
+		return
+	}
+	info.KeyID = keyID
+	info.HeaderSize = headerLen
+	info.note("包装后的数据密钥" + strconv.Itoa(len(wrapped)) + "字节，解密需要KMS密钥" + keyID)
+	info.setAEAD(raw[headerLen:], inspectGCMNonceSize)
+}
+
+// inspectChunked 分块GCM
+func inspectChunked(info *CiphertextInfo, raw []byte) {
+	info.Format = InspectFormatChunked
+	info.Algorithm = suiteAlgorithmNames[Algorithm(raw[5])]
+	info.Mode = "GCM"
+	info.HeaderSize = chunkedHeaderSize
+	info.HasTag, info.TagSize = true, chunkedTagSize
+
+	chunkSize := int(binary.BigEndian.Uint32(raw[6:10]))
+	body := len(raw) - chunkedHeaderSize
+	chunks := 0
+	if chunkSize > 0 {
+		chunks = (body + chunkSize + chunkedTagSize - 1) / (chunkSize + chunkedTagSize)
+	}
+	if chunks == 0 {
+		chunks = 1
+	}
+	info.BodySize = body - chunks*chunkedTagSize
+	if raw[4] != chunkedVersion {
+		info.note("不支持的分块格式版本" + strconv.Itoa(int(raw[4])))
+	}
+	info.note("分块大小" + strconv.Itoa(chunkSize) + "字节，共" + strconv.Itoa(chunks) + "块，每块一个认证标签")
+	if info.BodySize < 0 {
+		info.BodySize = 0
+		info.note("数据长度不足一个认证标签，可能被截断")
+	}
+}
+
+// inspectIsKeyRing 判断是否为 len(ID) || ID || nonce || 密文 || 标签，ID须为可打印ASCII
+func inspectIsKeyRing(raw []byte) bool {
+	if len(raw) < 1 || raw[0] == 0 || len(raw) < 1+int(raw[0])+inspectGCMNonceSize+inspectTagSize {
+		return false
+	}
+	for _, c := range raw[1 : 1+int(raw[0])] {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// inspectKeyRing 密钥环（及RolloutCipher）密文
+func inspectKeyRing(info *CiphertextInfo, raw []byte) {
+	info.Format = InspectFormatKeyRing
+	info.Mode = "GCM"
+	idLen := int(raw[0])
+	info.KeyID = string(raw[1 : 1+idLen])
+	info.HeaderSize = 1 + idLen
+	info.setAEAD(raw[info.HeaderSize:], inspectGCMNonceSize)
+	info.note("算法由密钥" + info.KeyID + "决定（AES或SM4），确认解密方密钥环中存在该ID")
+}
+
+// inspectUnknown 按长度推测可能的模式
+func inspectUnknown(info *CiphertextInfo, raw []byte) {
+	info.Format = InspectFormatUnknown
+	info.BodySize = len(raw)
+	switch {
+	case len(raw) == 0:
+		info.note("数据为空")
+	case len(raw)%16 == 0:
+		info.note("长度是16的整数倍：可能是AES/SM4的ECB、CBC密文，或 IV(16) || CBC密文")
+	case len(raw)%8 == 0:
+		info.note("长度是8的整数倍但不是16的整数倍：可能是DES/3DES的ECB、CBC密文")
+	default:
+		info.note("长度不是分组长度的整数倍：可能是CTR、CFB、OFB，或 nonce(12) || GCM密文 || 标签(16)；ECB、CBC密文不可能是此长度")
+	}
+	if info.Encoding == EncodingNone && len(raw) > 0 && inspectIsText(raw) {
+		info.note("数据全部为可打印字符但不是十六进制或Base64，可能不是密文，或编码被破坏")
+	}
+}
+
+// inspectIsText 是否全部为可打印ASCII
+func inspectIsText(raw []byte) bool {
+	for _, c := range raw {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// inspectConfigSecret enc:v1: 配置密文
+func inspectConfigSecret(text string) *CiphertextInfo {
+	info := &CiphertextInfo{Format: InspectFormatConfigSecret, Algorithm: "XChaCha20-Poly1305"}
+	raw, err := base64.RawURLEncoding.DecodeString(text[len(ConfigSecretPrefix):])
+	if err != nil {
+		info.note("前缀之后不是URL安全Base64（无填充）")
+		return info
+	}
+	info.TotalSize = len(raw)
+	if len(raw) < configSecretHeaderSize {
+		info.note("头部不完整，数据可能被截断")
+		return info
+	}
+	info.HeaderSize = configSecretHeaderSize
+	info.note("argon2id t=" + strconv.FormatUint(uint64(binary.BigEndian.Uint32(raw[1:5])), 10) +
+		" m=" + strconv.FormatUint(uint64(binary.BigEndian.Uint32(raw[5:9])), 10) +
+		" p=" + strconv.Itoa(int(raw[9])))
+	info.setAEAD(raw[configSecretHeaderSize:], 24)
+	return info
+}
+
+// inspectConfigValue ENC[v1,类型,...] 配置文件字段
+func inspectConfigValue(text string) *CiphertextInfo {
+	info := &CiphertextInfo{Format: InspectFormatConfigValue, Algorithm: "AES", Mode: "GCM"}
+	typ, encoded, ok := strings.Cut(text[len(configValuePrefix):len(text)-1], ",")
+	if !ok {
+		info.note("缺少值类型")
+		return info
+	}
+	info.note("值类型" + typ + "，附加认证数据为字段路径，字段被移动后无法解密")
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		info.note("密文不是标准Base64")
+		return info
+	}
+	info.TotalSize = len(raw)
+	info.setAEAD(raw, inspectGCMNonceSize)
+	return info
+}
+
+// inspectVault vault:vN: Vault Transit密文
+func inspectVault(text string) *CiphertextInfo {
+	info := &CiphertextInfo{Format: InspectFormatVault}
+	version, encoded, ok := strings.Cut(text[len("vault:"):], ":")
+	if !ok {
+		info.note("缺少密钥版本")
+		return info
+	}
+	info.KeyID = version
+	info.note("由Vault Transit主密钥" + version + "加密，只能通过Vault解密")
+	if raw, err := base64.StdEncoding.DecodeString(encoded); err == nil {
+		info.TotalSize = len(raw)
+		info.BodySize = len(raw)
+	}
+	return info
+}
@@ -1,5 +1,7 @@
 package encrypt
 
+import "crypto"
+
 // Algorithm 加密算法类型
 type Algorithm int
 
@@ -46,10 +48,55 @@ const (
 	EncodingBase64
 	EncodingBase64Safe
 	EncodingHex
+	EncodingBase64MIME // 每76字符以CRLF折行（RFC 2045）
+	EncodingBase64PEM  // 每64字符以LF折行（RFC 7468）
 )
 
-// ISymmetric 对称加密接口
-type ISymmetric interface {
+// 能力接口
+// 新算法只需实现与自身相关的能力接口（如ICipher、ISigner），无需为无关方法编写空实现；
+// 调用方可通过类型断言检测算法是否支持某项扩展能力，例如：
+//
+//	if sizer, ok := encryptor.(IKeySizer); ok {
+//		sizer.WithKeySize(4096)
+//	}
+
+// ICipher 加解密操作
+type ICipher interface {
+	Encrypt(plaintext []byte) ([]byte, error)
+	Decrypt(ciphertext []byte) ([]byte, error)
+}
+
+// IStringCipher 字符串加解密操作
+type IStringCipher interface {
+	EncryptString(plaintext string) (string, error)
+	DecryptString(ciphertext string) (string, error)
+}
+
+// ISigner 签名验签操作
+type ISigner interface {
+	Sign(data []byte) ([]byte, error)
+	Verify(data []byte, signature []byte) (bool, error)
+}
+
+// IStringSigner 字符串签名验签操作
+type IStringSigner interface {
+	SignString(data string) (string, error)
+	VerifyString(data string, signature string) (bool, error)
+}
+
+// IDigestSigner 预计算摘要的签名验签操作
+type IDigestSigner interface {
+	SignDigest(digest []byte) ([]byte, error)
+	VerifyDigest(digest []byte, signature []byte) (bool, error)
+}
+
+// IReleaser 可归还到对象池的资源
+type IReleaser interface {
+	Release()
+}
+
+// ISymmetricConfigurer 对称加密配置（模式、填充、编码、IV）
+type ISymmetricConfigurer interface {
 	// 访问器方法
 	Algorithm() Algorithm
 	GetKey() []byte
@@ -61,7 +108,6 @@ type ISymmetric interface {
 	CFB() ISymmetric
 	OFB() ISymmetric
 	CTR() ISymmetric
-	GCM() ISymmetric
 	
 	// 填充模式设置
 	NoPadding() ISymmetric
@@ -76,17 +122,10 @@ type ISymmetric interface {
 	
 	// 参数设置
 	WithIV(iv []byte) ISymmetric
-	
-	// 核心操作
-	Encrypt(plaintext []byte) ([]byte, error)
-	Decrypt(ciphertext []byte) ([]byte, error)
-	
-	// Release 释放加密器资源到对象池
-	Release()
 }
 
-// IAsymmetric 非对称加密接口
-type IAsymmetric interface {
+// IAsymmetricConfigurer 非对称加密配置（编码、密钥）
+type IAsymmetricConfigurer interface {
 	// 访问器方法
 	Algorithm() Algorithm
 	
@@ -97,20 +136,82 @@ type IAsymmetric interface {
 	Hex() IAsymmetric
 	
 	// 密钥管理
-	WithKeySize(size int) IAsymmetric // 只对RSA有效
 	WithPublicKey(publicKey []byte) IAsymmetric
 	WithPrivateKey(privateKey []byte) IAsymmetric
 	GenerateKeyPair() (public []byte, private []byte, err error)
-	
-	// SM2特有方法
-	WithUID(uid []byte) IAsymmetric // 只对SM2有效，设置签名用的用户ID
-	
-	// 核心操作
-	Encrypt(plaintext []byte) ([]byte, error)
-	Decrypt(ciphertext []byte) ([]byte, error)
-	Sign(data []byte) ([]byte, error)
-	Verify(data []byte, signature []byte) (bool, error)
-	
-	// Release 释放加密器资源到对象池
-	Release()
-}
\ No newline at end of file
+}
+
+// IGCMSetter 支持GCM模式的对称算法（AES、SM4），DES/3DES的64位分组不适用GCM
+type IGCMSetter interface {
+	GCM() ISymmetric
+}
+
+// IKeySizer 可设置密钥大小的算法（RSA）
+type IKeySizer interface {
+	WithKeySize(size int) IAsymmetric
+}
+
+// IUIDSetter 可设置签名用户ID的算法（SM2）
+type IUIDSetter interface {
+	WithUID(uid []byte) IAsymmetric
+}
+
+// ICryptoSigner 可导出标准库crypto.Signer的算法
+type ICryptoSigner interface {
+	Public() crypto.PublicKey
+	Signer() (crypto.Signer, error)
+}
+
+// ICryptoDecrypter 可导出标准库crypto.Decrypter的算法
+type ICryptoDecrypter interface {
+	Decrypter() (crypto.Decrypter, error)
+}
+
+// IFingerprinter 可计算公钥指纹的算法
+type IFingerprinter interface {
+	Fingerprint(hashAlgo HashAlgorithm) ([]byte, error)
+}
+
+// ISymmetric 对称加密接口
+// 由配置接口与操作接口组合而成；GCM等仅部分算法支持的设置通过IGCMSetter类型断言使用
+type ISymmetric interface {
+	ISymmetricConfigurer
+	ICipher
+	IStringCipher
+	IReleaser
+}
+
+// IAsymmetric 非对称加密接口
+// 由配置接口与操作接口组合而成；WithKeySize与WithUID仅对特定算法有效，通过IKeySizer/IUIDSetter类型断言使用
+type IAsymmetric interface {
+	IAsymmetricConfigurer
+	ICipher
+	ISigner
+	IStringCipher
+	IStringSigner
+	IReleaser
+}
+
+// 编译期检查各算法实现的接口
+var (
+	_ ISymmetric = (*AESEncryptor)(nil)
+	_ ISymmetric = (*DESEncryptor)(nil)
+	_ ISymmetric = (*TripleDESEncryptor)(nil)
+	_ ISymmetric = (*SM4Encryptor)(nil)
+	_ IGCMSetter = (*AESEncryptor)(nil)
+	_ IGCMSetter = (*SM4Encryptor)(nil)
+
+	_ IAsymmetric      = (*RSAEncryptor)(nil)
+	_ IKeySizer        = (*RSAEncryptor)(nil)
+	_ IDigestSigner    = (*RSAEncryptor)(nil)
+	_ ICryptoSigner    = (*RSAEncryptor)(nil)
+	_ ICryptoDecrypter = (*RSAEncryptor)(nil)
+	_ IFingerprinter   = (*RSAEncryptor)(nil)
+
+	_ IAsymmetric      = (*SM2Encryptor)(nil)
+	_ IUIDSetter       = (*SM2Encryptor)(nil)
+	_ IDigestSigner    = (*SM2Encryptor)(nil)
+	_ ICryptoSigner    = (*SM2Encryptor)(nil)
+	_ ICryptoDecrypter = (*SM2Encryptor)(nil)
+	_ IFingerprinter   = (*SM2Encryptor)(nil)
+)
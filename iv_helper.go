@@ -2,8 +2,6 @@ package encrypt
 
 import (
 	"crypto/cipher"
-	"crypto/rand"
-	"io"
 	
 	"github.com/pkg/errors"
 )
@@ -55,7 +53,7 @@ func generateIVForMode(blockMode BlockMode, block cipher.Block) (BlockMode, erro
 	iv := make([]byte, blockSize)
 	
 	// 生成随机IV
-	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+	if err := readNonce(iv); err != nil {
 		return nil, errors.Wrap(err, "生成随机IV失败")
 	}
 	
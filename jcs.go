@@ -0,0 +1,237 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// JSON规范化（RFC 8785 JSON Canonicalization Scheme）
+//
+// 同一个JSON对象经不同语言、不同序列化库输出后，字段顺序、空白、数字与字符串转义都可能不同，
+// 对序列化结果直接签名会导致验签方重新序列化后失败。JCS规定唯一的输出形式：
+//   - 对象成员按键的UTF-16编码单元排序，不允许重复的键
+//   - 不输出任何空白
+//   - 数字按IEEE 754双精度解析后以ECMAScript Number.prototype.toString的规则输出
+//   - 字符串只转义引号、反斜杠与控制字符，其余字符原样输出为UTF-8
+//
+// 超出双精度范围的整数会丢失精度，这是JCS的既定行为，需要精确大整数的字段应使用字符串
+
+// CanonicalizeJSON 将JSON文本转换为RFC 8785规范形式
+func CanonicalizeJSON(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, errors.New("JSON文本不是有效的UTF-8")
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var buf bytes.Buffer
+	if err := writeCanonicalJSON(&buf, decoder); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("JSON文本包含多余内容")
+	}
+	return buf.Bytes(), nil
+}
+
+// MarshalCanonicalJSON 序列化对象并转换为RFC 8785规范形式
+func MarshalCanonicalJSON(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化JSON失败")
+	}
+	return CanonicalizeJSON(data)
+}
+
+// writeCanonicalJSON 读取一个JSON值并输出规范形式
+func writeCanonicalJSON(buf *bytes.Buffer, decoder *json.Decoder) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return errors.Wrap(err, "解析JSON失败")
+	}
+
+	switch t := token.(type) {
+	case json.Delim:
+		switch t {
+		case '{':
+			return writeCanonicalJSONObject(buf, decoder)
+		case '[':
+			buf.WriteByte('[')
+			for i := 0; decoder.More(); i++ {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if err := writeCanonicalJSON(buf, decoder); err != nil {
+					return err
+				}
+			}
+			if _, err := decoder.Token(); err != nil {
+				return errors.Wrap(err, "解析JSON失败")
+			}
+			buf.WriteByte(']')
+			return nil
+		}
+		return errors.Errorf("JSON结构不正确: %v", t)
+	case string:
+		writeCanonicalJSONString(buf, t)
+	case json.Number:
+		number, err := canonicalJSONNumber(t.String())
+		if err != nil {
+			return err
+		}
+		buf.WriteString(number)
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case nil:
+		buf.WriteString("null")
+	default:
+		return errors.Errorf("不支持的JSON值: %v", t)
+	}
+	return nil
+}
+
+// writeCanonicalJSONObject 输出按键排序的对象，调用时已读取起始的 {
+func writeCanonicalJSONObject(buf *bytes.Buffer, decoder *json.Decoder) error {
+	type member struct {
+		key   string
+		order []uint16
+		value []byte
+	}
+
+	var members []member
+	seen := make(map[string]bool)
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return errors.Wrap(err, "解析JSON失败")
+		}
+		key, ok := token.(string)
+		if !ok {
+			return errors.New("JSON对象的键必须是字符串")
+		}
+		if seen[key] {
+			return errors.Errorf("JSON对象包含重复的键: %s", key)
+		}
+		seen[key] = true
+
+		var value bytes.Buffer
+		if err := writeCanonicalJSON(&value, decoder); err != nil {
+			return err
+		}
+		members = append(members, member{key: key, order: utf16.Encode([]rune(key)), value: value.Bytes()})
+	}
+	if _, err := decoder.Token(); err != nil {
+		return errors.Wrap(err, "解析JSON失败")
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		a, b := members[i].order, members[j].order
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeCanonicalJSONString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// writeCanonicalJSONString 按JCS规则输出字符串
+func writeCanonicalJSONString(buf *bytes.Buffer, s string) {
+	const hexDigits = "0123456789abcdef"
+
+	buf.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hexDigits[r>>4])
+				buf.WriteByte(hexDigits[r&0xf])
+			} else {
+				buf.WriteRune(r)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// canonicalJSONNumber 按ECMAScript Number.prototype.toString输出数字
+func canonicalJSONNumber(literal string) (string, error) {
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil || math.IsInf(value, 0) || math.IsNaN(value) {
+		return "", errors.Errorf("JSON数字超出双精度范围: %s", literal)
+	}
+	if value == 0 {
+		return "0", nil
+	}
+
+	sign := ""
+	if value < 0 {
+		sign = "-"
+		value = -value
+	}
+
+	// 最短往返表示：d.ddde±x，取出有效数字与十进制指数
+	formatted := strconv.FormatFloat(value, 'e', -1, 64)
+	mantissa, exponent := formatted, 0
+	if i := strings.IndexByte(formatted, 'e'); i >= 0 {
+		mantissa = formatted[:i]
+		exponent, _ = strconv.Atoi(formatted[i+1:])
+	}
+	digits := strings.Replace(mantissa, ".", "", 1)
+	k, n := len(digits), exponent+1
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k), nil
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:], nil
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits, nil
+	}
+
+	result := sign + digits[:1]
+	if k > 1 {
+		result += "." + digits[1:]
+	}
+	if n-1 >= 0 {
+		return result + "e+" + strconv.Itoa(n-1), nil
+	}
+	return result + "e-" + strconv.Itoa(1-n), nil
+}
@@ -0,0 +1,199 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// JSON文档的分离式JWS签名（RFC 7515 附录F）
+//
+// 签名覆盖的是文档的RFC 8785规范形式而不是原始字节，因此发送方与接收方使用不同的序列化库、
+// 字段顺序或缩进都不影响验签。签名结果为 header..signature，载荷部分为空，
+// 文档本身照常作为请求体传输。
+//
+// 算法：RS256、ES256（P-256）、EdDSA（Ed25519），以及非标准的SM2SM3
+// （SM3withSM2，默认UID，签名值与ES256一样为定长r||s）
+
+// JWS签名算法
+const (
+	JWSAlgorithmRS256 = "RS256"
+	JWSAlgorithmES256 = "ES256"
+	JWSAlgorithmEdDSA = "EdDSA"
+	JWSAlgorithmSM2   = "SM2SM3"
+)
+
+// JWSHeader JWS保护头
+type JWSHeader struct {
+	Algorithm string   `json:"alg"`
+	KeyID     string   `json:"kid,omitempty"`
+	Type      string   `json:"typ,omitempty"`
+	Critical  []string `json:"crit,omitempty"`
+}
+
+// SignJSONDetached 对JSON文档生成分离式JWS，keyID为空时不输出kid
+func SignJSONDetached(signer crypto.Signer, document []byte, keyID string) (string, error) {
+	algorithm, err := jwsAlgorithm(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	canonical, err := CanonicalizeJSON(document)
+	if err != nil {
+		return "", err
+	}
+	header, err := MarshalCanonicalJSON(JWSHeader{Algorithm: algorithm, KeyID: keyID})
+	if err != nil {
+		return "", err
+	}
+
+	encodedHeader := base64.RawURLEncoding.EncodeToString(header)
+	signature, err := signMessage(signer, jwsSigningInput(encodedHeader, canonical))
+	if err != nil {
+		return "", err
+	}
+	if algorithm == JWSAlgorithmES256 || algorithm == JWSAlgorithmSM2 {
+		if signature, err = derToFixedSignature(signature, 32); err != nil {
+			return "", err
+		}
+	}
+	return encodedHeader + ".." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// VerifyJSONDetached 使用PEM公钥验证JSON文档的分离式JWS
+func VerifyJSONDetached(signature string, document []byte, publicKeyPEM []byte) error {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	return VerifyJSONDetachedWithKey(signature, document, publicKey)
+}
+
+// VerifyJSONDetachedWithKey 使用公钥验证JSON文档的分离式JWS
+func VerifyJSONDetachedWithKey(signature string, document []byte, publicKey crypto.PublicKey) error {
+	parts := strings.Split(signature, ".")
+	if len(parts) != 3 {
+		return errors.New("JWS格式不正确")
+	}
+	if parts[1] != "" {
+		return errors.New("只支持载荷分离的JWS")
+	}
+
+	header, err := ParseJWSHeader(signature)
+	if err != nil {
+		return err
+	}
+	if len(header.Critical) > 0 {
+		return errors.Errorf("不支持的JWS扩展: %s", strings.Join(header.Critical, ","))
+	}
+	expected, err := jwsAlgorithm(publicKey)
+	if err != nil {
+		return err
+	}
+	if header.Algorithm != expected {
+		return errors.Errorf("JWS算法%s与公钥类型不一致", header.Algorithm)
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errors.Wrap(err, "解码JWS签名失败")
+	}
+	if expected == JWSAlgorithmES256 || expected == JWSAlgorithmSM2 {
+		if raw, err = fixedToDERSignature(raw, 32); err != nil {
+			return err
+		}
+	}
+
+	canonical, err := CanonicalizeJSON(document)
+	if err != nil {
+		return err
+	}
+	return verifyMessage(publicKey, jwsSigningInput(parts[0], canonical), raw)
+}
+
+// ParseJWSHeader 解析JWS保护头，不验证签名，可用于按kid选择公钥
+func ParseJWSHeader(signature string) (*JWSHeader, error) {
+	encoded := signature
+	if i := strings.IndexByte(signature, '.'); i >= 0 {
+		encoded = signature[:i]
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, errors.Wrap(err, "解码JWS头失败")
+	}
+
+	// 载荷编码扩展（b64）会改变签名输入，不接受
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, errors.Wrap(err, "解析JWS头失败")
+	}
+	if _, ok := fields["b64"]; ok {
+		return nil, errors.New("不支持b64载荷编码扩展")
+	}
+
+	var header JWSHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, errors.Wrap(err, "解析JWS头失败")
+	}
+	if header.Algorithm == "" || strings.EqualFold(header.Algorithm, "none") {
+		return nil, errors.New("JWS头缺少签名算法")
+	}
+	return &header, nil
+}
+
+// jwsSigningInput 生成签名输入 BASE64URL(header).BASE64URL(payload)
+func jwsSigningInput(encodedHeader string, payload []byte) []byte {
+	return []byte(encodedHeader + "." + base64.RawURLEncoding.EncodeToString(payload))
+}
+
+// jwsAlgorithm 获取公钥对应的JWS算法
+func jwsAlgorithm(publicKey crypto.PublicKey) (string, error) {
+	switch pub := publicKey.(type) {
+	case *sm2.PublicKey:
+		return JWSAlgorithmSM2, nil
+	case *rsa.PublicKey:
+		return JWSAlgorithmRS256, nil
+	case ed25519.PublicKey:
+		return JWSAlgorithmEdDSA, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return "", errors.New("ES256只支持P-256曲线")
+		}
+		return JWSAlgorithmES256, nil
+	default:
+		return "", errors.New("不支持的签名密钥类型")
+	}
+}
+
+// derToFixedSignature 将DER编码的(r, s)转换为定长r||s
+func derToFixedSignature(der []byte, size int) ([]byte, error) {
+	var sig struct{ R, S *big.Int }
+	if rest, err := asn1.Unmarshal(der, &sig); err != nil || len(rest) != 0 {
+		return nil, errors.New("签名不是有效的DER编码")
+	}
+	return append(leftPad(sig.R.Bytes(), size), leftPad(sig.S.Bytes(), size)...), nil
+}
+
+// fixedToDERSignature 将定长r||s转换为DER编码
+func fixedToDERSignature(raw []byte, size int) ([]byte, error) {
+	if len(raw) != 2*size {
+		return nil, errors.Errorf("签名长度应为%d字节", 2*size)
+	}
+	der, err := asn1.Marshal(struct{ R, S *big.Int }{
+		new(big.Int).SetBytes(raw[:size]),
+		new(big.Int).SetBytes(raw[size:]),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "编码签名失败")
+	}
+	return der, nil
+}
@@ -0,0 +1,224 @@
+package encrypt
+
+import (
+	"container/list"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+// 密钥派生缓存
+//
+// 登录、解锁密钥库等路径每次请求都用同一密码与盐重新派生KEK，argon2id、PBKDF2单次耗时可达数十毫秒。
+// KDFCache 缓存派生结果，按LRU淘汰，条目必须设置TTL：
+//   - 缓存键为 HMAC-SHA256(缓存随机密钥, 算法 || 参数 || 盐 || 密码)，缓存中不保存密码，
+//     键无法在缓存外被用来离线猜测密码
+//   - 返回派生密钥的副本，调用方可以放心清零；淘汰或过期时清零缓存持有的副本
+//   - 同一组输入并发未命中时只派生一次，其余调用等待结果，派生期间不持有缓存锁
+//
+// 错误密码同样会被派生并缓存，容量应按活跃用户数设置；修改密码后应更换盐或调用Purge
+
+// KDFCacheStats 派生缓存统计
+type KDFCacheStats struct {
+	Size      int
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// kdfCacheKind 派生算法
+type kdfCacheKind byte
+
+// 派生算法常量
+const (
+	kdfCachePBKDF2 kdfCacheKind = iota + 1
+	kdfCacheArgon2id
+)
+
+// kdfCacheEntry 缓存条目
+type kdfCacheEntry struct {
+	id      string
+	key     []byte
+	expires time.Time
+}
+
+// kdfCacheCall 进行中的派生
+type kdfCacheCall struct {
+	done chan struct{}
+	key  []byte
+}
+
+// KDFCache 密钥派生结果缓存
+type KDFCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	clock    func() time.Time
+	macKey   []byte
+	entries  map[string]*list.Element
+	inflight map[string]*kdfCacheCall
+	lru      *list.List
+	stats    KDFCacheStats
+}
+
+// NewKDFCache 创建缓存，capacity为最大条目数，ttl为条目有效期
+func NewKDFCache(capacity int, ttl time.Duration) (*KDFCache, error) {
+	if capacity <= 0 {
+		return nil, errors.New("缓存容量必须大于0")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("派生密钥缓存必须设置有效期")
+	}
+	macKey, err := GenerateRandomBytes(32)
+	if err != nil {
+		return nil, err
+	}
+	return &KDFCache{
+		capacity: capacity,
+		ttl:      ttl,
+		clock:    time.Now,
+		macKey:   macKey,
+		entries:  make(map[string]*list.Element, capacity),
+		inflight: make(map[string]*kdfCacheCall),
+		lru:      list.New(),
+	}, nil
+}
+
+// WithClock 设置时钟（主要用于测试）
+func (c *KDFCache) WithClock(clock func() time.Time) *KDFCache {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.clock = clock
+	return c
+}
+
+// PBKDF2 派生密钥，结果与pbkdf2包一致
+func (c *KDFCache) PBKDF2(password, salt []byte, hashAlgo HashAlgorithm, iterations, keyLen int) ([]byte, error) {
+	if iterations < 1 || keyLen < 1 {
+		return nil, errors.New("迭代次数与密钥长度必须大于0")
+	}
+	h := hashFunc(hashAlgo)
+	params := binary.BigEndian.AppendUint32([]byte{byte(hashAlgo)}, uint32(iterations))
+	return c.get(kdfCachePBKDF2, params, password, salt, keyLen, func() []byte {
+		return pbkdf2(password, salt, iterations, keyLen, h)
+	})
+}
+
+// Argon2id 派生密钥，参数与密钥库的KeystoreKDFParams一致
+func (c *KDFCache) Argon2id(password, salt []byte, params KeystoreKDFParams, keyLen int) ([]byte, error) {
+	if params.Time < 1 || params.Threads < 1 || params.Memory < 8*uint32(params.Threads) {
+		return nil, errors.New("argon2参数不正确")
+	}
+	if keyLen < 4 {
+		return nil, errors.New("argon2输出长度至少4字节")
+	}
+	encoded := binary.BigEndian.AppendUint32(nil, params.Time)
+	encoded = binary.BigEndian.AppendUint32(encoded, params.Memory)
+	encoded = append(encoded, params.Threads)
+	return c.get(kdfCacheArgon2id, encoded, password, salt, keyLen, func() []byte {
+		return argon2.IDKey(password, salt, params.Time, params.Memory, params.Threads, uint32(keyLen))
+	})
+}
+
+// Cleanup 清除所有已过期条目，可由定时任务调用
+func (c *KDFCache) Cleanup() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if !now.Before(elem.Value.(*kdfCacheEntry).expires) {
+			c.evict(elem)
+		}
+		elem = prev
+	}
+}
+
+// Purge 清空缓存
+func (c *KDFCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.lru.Len() > 0 {
+		c.evict(c.lru.Back())
+	}
+}
+
+// Stats 获取缓存统计
+func (c *KDFCache) Stats() KDFCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Size = c.lru.Len()
+	return stats
+}
+
+// get 查找缓存，未命中时调用derive派生并插入，返回副本
+func (c *KDFCache) get(kind kdfCacheKind, params, password, salt []byte, keyLen int, derive func() []byte) ([]byte, error) {
+	id := c.fingerprint(kind, params, password, salt, keyLen)
+
+	c.mu.Lock()
+	if elem, ok := c.entries[id]; ok {
+		entry := elem.Value.(*kdfCacheEntry)
+		if c.clock().Before(entry.expires) {
+			c.lru.MoveToFront(elem)
+			c.stats.Hits++
+			key := append([]byte(nil), entry.key...)
+			c.mu.Unlock()
+			return key, nil
+		}
+		c.evict(elem)
+	}
+	if call, ok := c.inflight[id]; ok {
+		c.stats.Hits++
+		c.mu.Unlock()
+		<-call.done
+		return append([]byte(nil), call.key...), nil
+	}
+	c.stats.Misses++
+	call := &kdfCacheCall{done: make(chan struct{})}
+	c.inflight[id] = call
+	c.mu.Unlock()
+
+	key := derive()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.inflight, id)
+	call.key = key
+	close(call.done)
+
+	for c.lru.Len() >= c.capacity {
+		c.evict(c.lru.Back())
+	}
+	entry := &kdfCacheEntry{id: id, key: append([]byte(nil), key...), expires: c.clock().Add(c.ttl)}
+	c.entries[id] = c.lru.PushFront(entry)
+	return append([]byte(nil), key...), nil
+}
+
+// evict 移除条目并清零派生密钥
+func (c *KDFCache) evict(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*kdfCacheEntry)
+	delete(c.entries, entry.id)
+	zeroBytes(entry.key)
+	entry.key = nil
+	entry.id = ""
+	c.stats.Evictions++
+}
+
+// fingerprint 计算缓存键，变长字段带长度前缀
+func (c *KDFCache) fingerprint(kind kdfCacheKind, params, password, salt []byte, keyLen int) string {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write([]byte{byte(kind)})
+	for _, field := range [][]byte{params, salt, password} {
+		mac.Write(binary.BigEndian.AppendUint32(nil, uint32(len(field))))
+		mac.Write(field)
+	}
+	mac.Write(binary.BigEndian.AppendUint32(nil, uint32(keyLen)))
+	return string(mac.Sum(nil))
+}
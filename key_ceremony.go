@@ -0,0 +1,190 @@
+package encrypt
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 主密钥仪式（知识拆分）
+//
+// PCI PIN/DSS要求主密钥不能由任何一个人单独掌握：密钥由N名保管员各自持有一个分量，
+// 仪式现场逐个输入，在内存中合成后写入密钥库，任何分量都不落盘：
+//   - XOR方案：N个与密钥等长的随机分量异或得到密钥，需要全部N个分量
+//   - Shamir方案：任意threshold个份额即可恢复，容忍保管员缺席
+//
+// 每输入一个分量打印其校验值，保管员与交接单核对确认输入无误；合成后打印密钥校验值（KCV）
+// 记录在仪式记录中，日后可以在不暴露密钥的情况下确认两处密钥是否一致。
+// KCV按传统方法计算：用密钥加密全零分组，取前3字节
+
+// CeremonyScheme 密钥分量方案
+type CeremonyScheme int
+
+// 密钥分量方案常量定义
+const (
+	CeremonyXOR CeremonyScheme = iota + 1
+	CeremonyShamir
+)
+
+// KeyCeremony 主密钥仪式
+type KeyCeremony struct {
+	algorithm  Algorithm
+	keySize    int
+	scheme     CeremonyScheme
+	required   int
+	components [][]byte
+}
+
+// NewXORCeremony 创建XOR方案的仪式，需要custodians个分量
+func NewXORCeremony(algorithm Algorithm, keySize, custodians int) (*KeyCeremony, error) {
+	if custodians < 2 {
+		return nil, errors.New("至少需要2名保管员")
+	}
+	return newKeyCeremony(algorithm, keySize, CeremonyXOR, custodians)
+}
+
+// NewShamirCeremony 创建Shamir方案的仪式，需要threshold个份额
+func NewShamirCeremony(algorithm Algorithm, keySize, threshold int) (*KeyCeremony, error) {
+	if threshold < 2 || threshold > 255 {
+		return nil, errors.New("门限必须在2到255之间")
+	}
+	return newKeyCeremony(algorithm, keySize, CeremonyShamir, threshold)
+}
+
+// newKeyCeremony 校验算法与密钥长度
+func newKeyCeremony(algorithm Algorithm, keySize int, scheme CeremonyScheme, required int) (*KeyCeremony, error) {
+	if _, err := newCachedBlock(algorithm, make([]byte, keySize)); err != nil {
+		return nil, errors.Wrap(err, "算法与密钥长度不匹配")
+	}
+	return &KeyCeremony{algorithm: algorithm, keySize: keySize, scheme: scheme, required: required}, nil
+}
+
+// Required 需要的分量数量
+func (c *KeyCeremony) Required() int {
+	return c.required
+}
+
+// Received 已输入的分量数量
+func (c *KeyCeremony) Received() int {
+	return len(c.components)
+}
+
+// AddComponent 输入一个分量，返回分量校验值供保管员核对
+// XOR分量的校验值即分量作为密钥的KCV；Shamir份额的校验值为SHA-256的前3字节
+func (c *KeyCeremony) AddComponent(component []byte) (string, error) {
+	if len(c.components) >= c.required {
+		return "", errors.New("分量已足够，请合成密钥")
+	}
+
+	expected := c.keySize
+	if c.scheme == CeremonyShamir {
+		expected++
+	}
+	if len(component) != expected {
+		return "", errors.Errorf("分量长度必须是%d字节", expected)
+	}
+	for _, existing := range c.components {
+		if subtle.ConstantTimeCompare(existing, component) == 1 {
+			return "", errors.New("分量重复")
+		}
+	}
+
+	var checkValue string
+	if c.scheme == CeremonyXOR {
+		var err error
+		if checkValue, err = KeyCheckValue(c.algorithm, component); err != nil {
+			return "", err
+		}
+	} else {
+		sum := sha256.Sum256(component)
+		checkValue = strings.ToUpper(hex.EncodeToString(sum[:3]))
+	}
+
+	c.components = append(c.components, append([]byte(nil), component...))
+	return checkValue, nil
+}
+
+// Combine 合成密钥并返回KCV，合成后清零所有分量，调用方用完密钥后应清零
+func (c *KeyCeremony) Combine() (key []byte, kcv string, err error) {
+	if len(c.components) < c.required {
+		return nil, "", errors.Errorf("还需要%d个分量", c.required-len(c.components))
+	}
+	defer c.Destroy()
+
+	if c.scheme == CeremonyXOR {
+		key = make([]byte, c.keySize)
+		for _, component := range c.components {
+			subtle.XORBytes(key, key, component)
+		}
+	} else if key, err = CombineShares(c.components); err != nil {
+		return nil, "", err
+	}
+
+	if subtle.ConstantTimeCompare(key, make([]byte, len(key))) == 1 {
+		zeroBytes(key)
+		return nil, "", errors.New("合成的密钥全为0，分量有误")
+	}
+	if kcv, err = KeyCheckValue(c.algorithm, key); err != nil {
+		zeroBytes(key)
+		return nil, "", err
+	}
+	return key, kcv, nil
+}
+
+// StoreInKeystore 合成密钥并以id写入密钥库后保存，返回KCV
+func (c *KeyCeremony) StoreInKeystore(ks *Keystore, id string, usage KeyUsage) (string, error) {
+	key, kcv, err := c.Combine()
+	if err != nil {
+		return "", err
+	}
+	defer zeroBytes(key)
+
+	if err := ks.AddKey(KeyEntry{ID: id, Algorithm: c.algorithm, Key: key, Usage: usage}); err != nil {
+		return "", err
+	}
+	if err := ks.Save(); err != nil {
+		return "", err
+	}
+	return kcv, nil
+}
+
+// Destroy 清零所有已输入的分量，中止仪式时调用
+func (c *KeyCeremony) Destroy() {
+	for _, component := range c.components {
+		zeroBytes(component)
+	}
+	c.components = nil
+}
+
+// SplitKeyComponents 将已有密钥拆分为n个XOR分量，用于向保管员分发
+func SplitKeyComponents(key []byte, n int) ([][]byte, error) {
+	if n < 2 {
+		return nil, errors.New("至少需要2个分量")
+	}
+	components := make([][]byte, n)
+	last := append([]byte(nil), key...)
+	for i := 0; i < n-1; i++ {
+		component, err := GenerateRandomBytes(len(key))
+		if err != nil {
+			return nil, err
+		}
+		subtle.XORBytes(last, last, component)
+		components[i] = component
+	}
+	components[n-1] = last
+	return components, nil
+}
+
+// KeyCheckValue 计算密钥校验值：加密全零分组取前3字节，大写十六进制
+func KeyCheckValue(algorithm Algorithm, key []byte) (string, error) {
+	block, err := newCachedBlock(algorithm, key)
+	if err != nil {
+		return "", err
+	}
+	out := make([]byte, block.BlockSize())
+	block.Encrypt(out, out)
+	return strings.ToUpper(hex.EncodeToString(out[:3])), nil
+}
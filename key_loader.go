@@ -0,0 +1,92 @@
+package encrypt
+
+import (
+	"bytes"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// 从环境变量与文件加载密钥
+//
+// 密钥以文本形式保存时需要指定编码（HexEncoding、Base64Encoding等），首尾空白会被去除。
+// 通过WithKeyFileWarning设置处理函数后，密钥文件对组或其他用户可读时发出警告，不会拒绝加载，
+// 便于在容器挂载卷等权限不可控的环境中先发现问题再收紧权限。
+// 默认不发出警告，库不会自行写日志；警告按次调用配置，不依赖可变的全局状态
+
+// KeyFileOption 从文件加载密钥的配置选项
+type KeyFileOption func(*keyFileOptions)
+
+// keyFileOptions 从文件加载密钥的配置
+type keyFileOptions struct {
+	warn func(path string, perm os.FileMode)
+}
+
+// WithKeyFileWarning 设置密钥文件权限过宽时的警告处理函数，默认与nil均不发出警告。
+// 需要写入日志时传入如 func(path string, perm os.FileMode) { log.Printf("密钥文件%s权限为%04o", path, perm) }
+func WithKeyFileWarning(warn func(path string, perm os.FileMode)) KeyFileOption {
+	return func(o *keyFileOptions) {
+		o.warn = warn
+	}
+}
+
+// LoadKeyFromEnv 从环境变量读取密钥并按encoding解码
+func LoadKeyFromEnv(name string, encoding Encoding) ([]byte, error) {
+	value, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, errors.Errorf("环境变量%s未设置", name)
+	}
+
+	key, err := decodeKeyText([]byte(value), encoding)
+	if err != nil {
+		return nil, errors.Wrapf(err, "环境变量%s中的密钥格式不正确", name)
+	}
+	return key, nil
+}
+
+// LoadKeyFromFile 从文件读取密钥并按encoding解码，encoding为NoEncoding时按原始字节读取
+// 文件对组或其他用户可读时调用WithKeyFileWarning设置的处理函数，未设置时不发出警告
+func LoadKeyFromFile(path string, encoding Encoding, opts ...KeyFileOption) ([]byte, error) {
+	o := &keyFileOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取密钥文件失败")
+	}
+	if !info.Mode().IsRegular() {
+		return nil, errors.Errorf("密钥文件%s不是普通文件", path)
+	}
+	if perm := info.Mode().Perm(); perm&0044 != 0 && o.warn != nil {
+		o.warn(path, perm)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取密钥文件失败")
+	}
+	if encoding == nil || encoding == NoEncoding {
+		return data, nil
+	}
+
+	key, err := decodeKeyText(data, encoding)
+	zeroBytes(data)
+	if err != nil {
+		return nil, errors.Wrapf(err, "密钥文件%s格式不正确", path)
+	}
+	return key, nil
+}
+
+// decodeKeyText 去除首尾空白后解码密钥文本
+func decodeKeyText(text []byte, encoding Encoding) ([]byte, error) {
+	text = bytes.TrimSpace(text)
+	if len(text) == 0 {
+		return nil, errors.New("密钥为空")
+	}
+	if encoding == nil {
+		encoding = NoEncoding
+	}
+	return encoding.Decode(text)
+}
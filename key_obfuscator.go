@@ -0,0 +1,278 @@
+package encrypt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 内存密钥混淆
+//
+// KeyObfuscator 将密钥拆分为若干随机份额分别存放，所有份额异或才得到原密钥，内存中任何时刻
+// 都不存在完整的明文密钥。份额可以定期刷新（各份额同时异或同一随机数），刷新不需要还原密钥，
+// 刷新前抓取到的份额与刷新后的份额无法拼合。仅在Use回调期间临时还原密钥，回调返回后立即清零。
+//
+// 这只能提高内存抓取的门槛，无法抵御能单步调试进程或在加密瞬间读取内存的攻击者。
+// Cipher返回的加密器实现ICipher与IStringCipher，每次调用临时创建加密器，可直接替换原有加密器
+
+// defaultKeyShares 默认份额数量
+const defaultKeyShares = 3
+
+// KeyObfuscator 份额化存储的内存密钥
+type KeyObfuscator struct {
+	mu     sync.RWMutex
+	shares [][]byte
+	closed bool
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewKeyObfuscator 拆分密钥，调用方应在之后自行清零传入的key
+func NewKeyObfuscator(key []byte) (*KeyObfuscator, error) {
+	if len(key) == 0 {
+		return nil, errors.New("密钥不能为空")
+	}
+	shares, err := splitKeyShares(key, defaultKeyShares)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyObfuscator{shares: shares, stop: make(chan struct{})}, nil
+}
+
+// WithShares 设置份额数量（至少2份），重新拆分现有密钥
+func (o *KeyObfuscator) WithShares(n int) *KeyObfuscator {
+	if n < 2 {
+		panic("份额数量至少为2")
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return o
+	}
+
+	key := combineKeyShares(o.shares)
+	defer zeroBytes(key)
+	shares, err := splitKeyShares(key, n)
+	if err != nil {
+		panic(err)
+	}
+	wipeKeyShares(o.shares)
+	o.shares = shares
+	return o
+}
+
+// WithRefreshInterval 启动后台定期刷新份额，Close时停止
+func (o *KeyObfuscator) WithRefreshInterval(interval time.Duration) *KeyObfuscator {
+	if interval <= 0 {
+		panic("刷新间隔必须大于0")
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = o.Refresh()
+			case <-o.stop:
+				return
+			}
+		}
+	}()
+	return o
+}
+
+// Refresh 刷新份额，刷新过程中不还原密钥
+func (o *KeyObfuscator) Refresh() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.closed {
+		return errors.New("密钥已销毁")
+	}
+
+	last := o.shares[len(o.shares)-1]
+	for i, share := range o.shares[:len(o.shares)-1] {
+		mask, err := GenerateRandomBytes(len(share))
+		if err != nil {
+			return err
+		}
+
+		// 份额重新分配到新内存，旧份额清零
+		fresh := make([]byte, len(share))
+		for j := range share {
+			fresh[j] = share[j] ^ mask[j]
+			last[j] ^= mask[j]
+		}
+		zeroBytes(mask)
+		zeroBytes(share)
+		o.shares[i] = fresh
+	}
+	return nil
+}
+
+// Use 临时还原密钥并调用fn，fn返回后密钥立即清零，fn不得保留key的引用
+func (o *KeyObfuscator) Use(fn func(key []byte) error) error {
+	o.mu.RLock()
+	if o.closed {
+		o.mu.RUnlock()
+		return errors.New("密钥已销毁")
+	}
+	key := combineKeyShares(o.shares)
+	o.mu.RUnlock()
+
+	defer zeroBytes(key)
+	return fn(key)
+}
+
+// Reveal 还原密钥副本，调用方负责用完后清零
+func (o *KeyObfuscator) Reveal() ([]byte, error) {
+	var out []byte
+	err := o.Use(func(key []byte) error {
+		out = append([]byte(nil), key...)
+		return nil
+	})
+	return out, err
+}
+
+// Cipher 创建使用该密钥的对称加密器，opts与AES、SM4等构造方法相同。
+// 每次加解密都使用新的加密器，IV不随加密器保存的模式（如SM4的CBC）需通过WithIV指定，推荐使用GCM
+func (o *KeyObfuscator) Cipher(algorithm Algorithm, opts ...Option) (*ObfuscatedCipher, error) {
+	var factory func([]byte) (ISymmetric, error)
+	switch algorithm {
+	case AlgorithmAES:
+		factory = NewAES
+	case AlgorithmDES:
+		factory = NewDES
+	case Algorithm3DES:
+		factory = New3DES
+	case AlgorithmSM4:
+		factory = NewSM4
+	default:
+		return nil, errors.New("不支持的对称加密算法")
+	}
+
+	c := &ObfuscatedCipher{obfuscator: o, factory: factory, opts: opts}
+	// 提前检查密钥长度与选项
+	if err := c.with(func(ISymmetric) error { return nil }); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Close 停止刷新并清零所有份额
+func (o *KeyObfuscator) Close() {
+	o.stopOnce.Do(func() { close(o.stop) })
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	wipeKeyShares(o.shares)
+	o.shares = nil
+	o.closed = true
+}
+
+// ObfuscatedCipher 使用混淆密钥的对称加密器
+type ObfuscatedCipher struct {
+	obfuscator *KeyObfuscator
+	factory    func([]byte) (ISymmetric, error)
+	opts       []Option
+}
+
+// Encrypt 加密数据
+func (c *ObfuscatedCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	var out []byte
+	err := c.with(func(encryptor ISymmetric) (err error) {
+		out, err = encryptor.Encrypt(plaintext)
+		return err
+	})
+	return out, err
+}
+
+// Decrypt 解密数据
+func (c *ObfuscatedCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	var out []byte
+	err := c.with(func(encryptor ISymmetric) (err error) {
+		out, err = encryptor.Decrypt(ciphertext)
+		return err
+	})
+	return out, err
+}
+
+// EncryptString 加密字符串
+func (c *ObfuscatedCipher) EncryptString(plaintext string) (string, error) {
+	return applyString(c.Encrypt, plaintext)
+}
+
+// DecryptString 解密字符串
+func (c *ObfuscatedCipher) DecryptString(ciphertext string) (string, error) {
+	return applyString(c.Decrypt, ciphertext)
+}
+
+// with 临时创建加密器执行fn，结束后清除加密器中的密钥再归还对象池
+func (c *ObfuscatedCipher) with(fn func(ISymmetric) error) error {
+	return c.obfuscator.Use(func(key []byte) error {
+		encryptor, err := newWithOptions(c.factory, key, c.opts)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			wipeEncryptorKey(encryptor)
+			encryptor.Release()
+		}()
+		return fn(encryptor)
+	})
+}
+
+// wipeEncryptorKey 清零池化加密器保留的密钥（Release不会清除密钥）
+func wipeEncryptorKey(encryptor ISymmetric) {
+	switch e := encryptor.(type) {
+	case *AESEncryptor:
+		zeroBytes(e.key)
+	case *DESEncryptor:
+		zeroBytes(e.key)
+	case *TripleDESEncryptor:
+		zeroBytes(e.key)
+	case *SM4Encryptor:
+		zeroBytes(e.key)
+	}
+}
+
+// splitKeyShares 拆分为n个份额：前n-1份随机，最后一份为密钥与随机份额的异或
+func splitKeyShares(key []byte, n int) ([][]byte, error) {
+	shares := make([][]byte, n)
+	last := append([]byte(nil), key...)
+	for i := 0; i < n-1; i++ {
+		share, err := GenerateRandomBytes(len(key))
+		if err != nil {
+			wipeKeyShares(shares)
+			zeroBytes(last)
+			return nil, err
+		}
+		for j := range last {
+			last[j] ^= share[j]
+		}
+		shares[i] = share
+	}
+	shares[n-1] = last
+	return shares, nil
+}
+
+// combineKeyShares 异或所有份额还原密钥
+func combineKeyShares(shares [][]byte) []byte {
+	key := make([]byte, len(shares[0]))
+	for _, share := range shares {
+		for i := range key {
+			key[i] ^= share[i]
+		}
+	}
+	return key
+}
+
+// wipeKeyShares 清零所有份额
+func wipeKeyShares(shares [][]byte) {
+	for _, share := range shares {
+		zeroBytes(share)
+	}
+}
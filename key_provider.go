@@ -0,0 +1,33 @@
+package encrypt
+
+import (
+	"context"
+)
+
+// KeyProvider 主密钥提供者
+// 主密钥保存在提供者内部（本地密钥环、Vault、云KMS等），调用方只接触被包装的数据密钥，
+// 用于信封加密：本地用随机数据密钥加密数据，再由提供者包装数据密钥后与密文一起保存
+type KeyProvider interface {
+	// WrapKey 使用keyID指定的主密钥包装数据密钥
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+	// UnwrapKey 使用keyID指定的主密钥解包数据密钥
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// WrapKey 使用密钥环中的密钥包装数据密钥，密钥ID作为附加认证数据
+func (k *KeyRing) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	entry, err := k.Use(keyID, KeyUsageEncrypt)
+	if err != nil {
+		return nil, err
+	}
+	return keyRingSeal(entry, dataKey, []byte(keyID))
+}
+
+// UnwrapKey 使用密钥环中的密钥解包数据密钥
+func (k *KeyRing) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	entry, err := k.Use(keyID, KeyUsageDecrypt)
+	if err != nil {
+		return nil, err
+	}
+	return keyRingOpen(entry, wrapped, []byte(keyID))
+}
@@ -0,0 +1,336 @@
+package encrypt
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 密钥环
+//
+// 按ID管理多把密钥，每把密钥带有用途限制与有效期。所有操作在使用密钥前都会检查：
+// 密钥已吊销、尚未生效、已过期或用途不符时直接拒绝，并返回可用errors.Is判断的错误，
+// 避免误用已退役的密钥。对称加密输出中记录密钥ID，解密时自动选择对应密钥
+
+// KeyUsage 密钥用途，可组合
+type KeyUsage int
+
+// 密钥用途常量定义
+const (
+	KeyUsageEncrypt KeyUsage = 1 << iota
+	KeyUsageDecrypt
+	KeyUsageSign
+	KeyUsageVerify
+
+	// KeyUsageCipher 加解密
+	KeyUsageCipher = KeyUsageEncrypt | KeyUsageDecrypt
+	// KeyUsageSignature 签名验签
+	KeyUsageSignature = KeyUsageSign | KeyUsageVerify
+)
+
+// 密钥环错误，可通过errors.Is判断，errors.As可获取*KeyError以得到密钥ID
+var (
+	ErrKeyNotFound    = errors.New("密钥不存在")
+	ErrKeyRevoked     = errors.New("密钥已吊销")
+	ErrKeyNotYetValid = errors.New("密钥尚未生效")
+	ErrKeyExpired     = errors.New("密钥已过期")
+	ErrKeyUsage       = errors.New("密钥用途不允许该操作")
+)
+
+// KeyError 与具体密钥相关的错误
+type KeyError struct {
+	KeyID string
+	Err   error
+}
+
+// Error 实现error接口
+func (e *KeyError) Error() string {
+	return "密钥" + e.KeyID + ": " + e.Err.Error()
+}
+
+// Unwrap 返回底层错误
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// KeyEntry 密钥环中的一把密钥
+// 对称密钥的Key为原始密钥；非对称密钥的Key为PEM私钥（可为空），PublicKey为PEM公钥
+type KeyEntry struct {
+	ID        string
+	Algorithm Algorithm
+	Key       []byte
+	PublicKey []byte
+	Usage     KeyUsage
+	NotBefore time.Time // 零值表示不限制
+	NotAfter  time.Time // 零值表示不限制
+	Revoked   bool
+	CreatedAt time.Time
+}
+
+// Allows 判断密钥用途是否包含指定操作
+func (e *KeyEntry) Allows(usage KeyUsage) bool {
+	return e.Usage&usage == usage
+}
+
+// Check 检查密钥在指定时间能否用于指定操作
+func (e *KeyEntry) Check(usage KeyUsage, now time.Time) error {
+	var err error
+	switch {
+	case e.Revoked:
+		err = ErrKeyRevoked
+	case !e.NotBefore.IsZero() && now.Before(e.NotBefore):
+		err = ErrKeyNotYetValid
+	case !e.NotAfter.IsZero() && now.After(e.NotAfter):
+		err = ErrKeyExpired
+	case !e.Allows(usage):
+		err = ErrKeyUsage
+	default:
+		return nil
+	}
+	return &KeyError{KeyID: e.ID, Err: err}
+}
+
+// KeyRing 密钥环，并发安全
+type KeyRing struct {
+	mu      sync.RWMutex
+	entries map[string]*KeyEntry
+	primary string
+	now     func() time.Time
+}
+
+// NewKeyRing 创建空密钥环
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		entries: make(map[string]*KeyEntry),
+		now:     time.Now,
+	}
+}
+
+// WithClock 设置时钟，主要用于测试
+func (k *KeyRing) WithClock(now func() time.Time) *KeyRing {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.now = now
+	return k
+}
+
+// Add 添加密钥，ID不能重复；第一把可用于加密的密钥自动成为主密钥
+func (k *KeyRing) Add(entry KeyEntry) error {
+	if entry.ID == "" {
+		return errors.New("密钥ID不能为空")
+	}
+	if len(entry.ID) > 0xff {
+		return errors.New("密钥ID过长")
+	}
+	if entry.Usage == 0 {
+		return errors.New("密钥用途不能为空")
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, ok := k.entries[entry.ID]; ok {
+		return errors.Errorf("密钥%s已存在", entry.ID)
+	}
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = k.now()
+	}
+	entry.Key = append([]byte(nil), entry.Key...)
+	entry.PublicKey = append([]byte(nil), entry.PublicKey...)
+	k.entries[entry.ID] = &entry
+
+	if k.primary == "" && entry.Allows(KeyUsageEncrypt) {
+		k.primary = entry.ID
+	}
+	return nil
+}
+
+// Get 获取密钥副本，不做有效性检查
+func (k *KeyRing) Get(id string) (KeyEntry, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	entry, ok := k.entries[id]
+	if !ok {
+		return KeyEntry{}, &KeyError{KeyID: id, Err: ErrKeyNotFound}
+	}
+	return *entry, nil
+}
+
+// Use 获取可用于指定操作的密钥副本，不可用时返回*KeyError
+func (k *KeyRing) Use(id string, usage KeyUsage) (KeyEntry, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.use(id, usage)
+}
+
+// IDs 获取所有密钥ID，按字典序排列
+func (k *KeyRing) IDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	ids := make([]string, 0, len(k.entries))
+	for id := range k.entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// SetPrimary 设置用于加密的主密钥
+func (k *KeyRing) SetPrimary(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, err := k.use(id, KeyUsageEncrypt); err != nil {
+		return err
+	}
+	k.primary = id
+	return nil
+}
+
+// Primary 获取主密钥ID
+func (k *KeyRing) Primary() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.primary
+}
+
+// Revoke 吊销密钥，吊销后任何操作都会被拒绝
+func (k *KeyRing) Revoke(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry, ok := k.entries[id]
+	if !ok {
+		return &KeyError{KeyID: id, Err: ErrKeyNotFound}
+	}
+	entry.Revoked = true
+	return nil
+}
+
+// Remove 从密钥环移除密钥并清零密钥数据
+func (k *KeyRing) Remove(id string) error {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	entry, ok := k.entries[id]
+	if !ok {
+		return &KeyError{KeyID: id, Err: ErrKeyNotFound}
+	}
+	zeroBytes(entry.Key)
+	delete(k.entries, id)
+	if k.primary == id {
+		k.primary = ""
+	}
+	return nil
+}
+
+// Encrypt 使用主密钥加密，输出格式为 idLen(1) | id | GCM密文
+func (k *KeyRing) Encrypt(plaintext, aad []byte) ([]byte, error) {
+	k.mu.RLock()
+	entry, err := k.use(k.primary, KeyUsageEncrypt)
+	k.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	ciphertext, err := keyRingSeal(entry, plaintext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, 1+len(entry.ID)+len(ciphertext))
+	out = append(out, byte(len(entry.ID)))
+	out = append(out, entry.ID...)
+	return append(out, ciphertext...), nil
+}
+
+// Decrypt 根据密文中记录的密钥ID选择密钥解密
+func (k *KeyRing) Decrypt(data, aad []byte) ([]byte, error) {
+	id, ciphertext, err := SplitKeyID(data)
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := k.Use(id, KeyUsageDecrypt)
+	if err != nil {
+		return nil, err
+	}
+	return keyRingOpen(entry, ciphertext, aad)
+}
+
+// Sign 使用指定的非对称密钥签名，签名方式与SignFile一致
+func (k *KeyRing) Sign(id string, data []byte) ([]byte, error) {
+	entry, err := k.Use(id, KeyUsageSign)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := ParseSigner(entry.Key)
+	if err != nil {
+		return nil, err
+	}
+	return signMessage(signer, data)
+}
+
+// Verify 使用指定的非对称密钥验签
+func (k *KeyRing) Verify(id string, data, signature []byte) error {
+	entry, err := k.Use(id, KeyUsageVerify)
+	if err != nil {
+		return err
+	}
+
+	publicKey, err := parsePublicKeyPEM(entry.PublicKey)
+	if err != nil {
+		return err
+	}
+	return verifyMessage(publicKey, data, signature)
+}
+
+// SplitKeyID 拆分密钥环密文中的密钥ID与GCM密文
+func SplitKeyID(data []byte) (string, []byte, error) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) {
+		return "", nil, errors.New("密文格式不正确")
+	}
+	idLen := int(data[0])
+	return string(data[1 : 1+idLen]), data[1+idLen:], nil
+}
+
+// use 获取并检查密钥，调用方需持有锁
+func (k *KeyRing) use(id string, usage KeyUsage) (KeyEntry, error) {
+	entry, ok := k.entries[id]
+	if !ok {
+		return KeyEntry{}, &KeyError{KeyID: id, Err: ErrKeyNotFound}
+	}
+	if err := entry.Check(usage, k.now()); err != nil {
+		return KeyEntry{}, err
+	}
+	return *entry, nil
+}
+
+// keyRingSeal 按密钥算法执行GCM加密
+func keyRingSeal(entry KeyEntry, plaintext, aad []byte) ([]byte, error) {
+	switch entry.Algorithm {
+	case AlgorithmAES:
+		return AESGCMEncrypt(entry.Key, plaintext, aad)
+	case AlgorithmSM4:
+		return SM4GCMEncrypt(entry.Key, plaintext, aad)
+	default:
+		return nil, &KeyError{KeyID: entry.ID, Err: errors.New("密钥环加密仅支持AES与SM4")}
+	}
+}
+
+// keyRingOpen 按密钥算法执行GCM解密
+func keyRingOpen(entry KeyEntry, ciphertext, aad []byte) ([]byte, error) {
+	switch entry.Algorithm {
+	case AlgorithmAES:
+		return AESGCMDecrypt(entry.Key, ciphertext, aad)
+	case AlgorithmSM4:
+		return SM4GCMDecrypt(entry.Key, ciphertext, aad)
+	default:
+		return nil, &KeyError{KeyID: entry.ID, Err: errors.New("密钥环解密仅支持AES与SM4")}
+	}
+}
@@ -0,0 +1,333 @@
+package encrypt
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 密钥环快照（导出/导入）
+//
+// 用于灾难恢复与环境迁移（如预发布环境提升到生产）：把整个密钥环连同主密钥、吊销状态与有效期
+// 导出为一个加密、带版本号的快照，代替直接复制密钥文件。快照为JSON：
+//
+//	{"format":"encrypt-keyring-snapshot","version":1,"created_at":...,"compression":"gzip",
+//	 "kdf":{"name":"argon2id",...},"key_count":3,"payload":"..."}
+//
+// payload = AES-256-GCM(argon2id(密码), 压缩(密钥环JSON))，除payload外的所有字段作为附加认证数据，
+// 修改压缩算法、密钥数量等元数据都会导致导入失败。压缩算法可插拔，通过RegisterSnapshotCompressor注册，
+// 内置none与gzip（默认）
+
+// KeyRingSnapshotVersion 快照格式版本
+const KeyRingSnapshotVersion = 1
+
+// keyRingSnapshotFormat 快照格式标识
+const keyRingSnapshotFormat = "encrypt-keyring-snapshot"
+
+// 内置压缩算法
+const (
+	SnapshotCompressionNone = "none"
+	SnapshotCompressionGzip = "gzip"
+)
+
+// SnapshotCompressor 快照压缩算法
+type SnapshotCompressor interface {
+	// Name 算法名称，记录在快照中
+	Name() string
+	// Compress 压缩数据
+	Compress(data []byte) ([]byte, error)
+	// Decompress 解压数据
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	// 已注册的压缩算法
+	snapshotCompressors = map[string]SnapshotCompressor{}
+
+	// 用于保护压缩算法注册表的读写锁
+	snapshotCompressorLock sync.RWMutex
+)
+
+func init() {
+	RegisterSnapshotCompressor(noneCompressor{})
+	RegisterSnapshotCompressor(gzipCompressor{})
+}
+
+// RegisterSnapshotCompressor 注册快照压缩算法，同名算法会被覆盖
+func RegisterSnapshotCompressor(compressor SnapshotCompressor) {
+	snapshotCompressorLock.Lock()
+	defer snapshotCompressorLock.Unlock()
+
+	snapshotCompressors[compressor.Name()] = compressor
+}
+
+// snapshotCompressor 按名称获取压缩算法
+func snapshotCompressor(name string) (SnapshotCompressor, error) {
+	snapshotCompressorLock.RLock()
+	defer snapshotCompressorLock.RUnlock()
+
+	compressor, ok := snapshotCompressors[name]
+	if !ok {
+		return nil, errors.Errorf("未注册的快照压缩算法: %s", name)
+	}
+	return compressor, nil
+}
+
+// KeyRingExportOptions 导出选项
+type KeyRingExportOptions struct {
+	Compression string            // 压缩算法，为空时使用gzip
+	KDF         KeystoreKDFParams // argon2id参数，零值时使用DefaultKeystoreKDFParams
+}
+
+// keyRingSnapshot 快照文件结构
+type keyRingSnapshot struct {
+	Format      string      `json:"format"`
+	Version     int         `json:"version"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Compression string      `json:"compression"`
+	KDF         keystoreKDF `json:"kdf"`
+	KeyCount    int         `json:"key_count"`
+	Payload     []byte      `json:"payload,omitempty"`
+}
+
+// keyRingSnapshotBody 快照加密部分，条目的Secret为原始密钥
+type keyRingSnapshotBody struct {
+	Primary string          `json:"primary,omitempty"`
+	Keys    []keystoreEntry `json:"keys"`
+}
+
+// ExportKeyRing 使用默认选项（gzip压缩、默认argon2id参数）导出密钥环快照
+func ExportKeyRing(ring *KeyRing, password []byte) ([]byte, error) {
+	return ExportKeyRingWithOptions(ring, password, KeyRingExportOptions{})
+}
+
+// ExportKeyRingWithOptions 导出密钥环快照
+func ExportKeyRingWithOptions(ring *KeyRing, password []byte, opts KeyRingExportOptions) ([]byte, error) {
+	if len(password) == 0 {
+		return nil, errors.New("快照密码不能为空")
+	}
+	if opts.Compression == "" {
+		opts.Compression = SnapshotCompressionGzip
+	}
+	if opts.KDF == (KeystoreKDFParams{}) {
+		opts.KDF = DefaultKeystoreKDFParams
+	}
+	if err := opts.KDF.validate(); err != nil {
+		return nil, err
+	}
+	compressor, err := snapshotCompressor(opts.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	body := keyRingSnapshotBody{Primary: ring.Primary(), Keys: []keystoreEntry{}}
+	for _, id := range ring.IDs() {
+		entry, err := ring.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		body.Keys = append(body.Keys, keystoreEntry{
+			ID:        entry.ID,
+			Algorithm: entry.Algorithm,
+			Usage:     entry.Usage,
+			NotBefore: entry.NotBefore,
+			NotAfter:  entry.NotAfter,
+			Revoked:   entry.Revoked,
+			CreatedAt: entry.CreatedAt,
+			PublicKey: string(entry.PublicKey),
+			Secret:    append([]byte(nil), entry.Key...),
+		})
+	}
+	defer func() {
+		for _, item := range body.Keys {
+			zeroBytes(item.Secret)
+		}
+	}()
+
+	plaintext, err := json.Marshal(body)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化密钥环失败")
+	}
+	defer zeroBytes(plaintext)
+	compressed, err := compressor.Compress(plaintext)
+	if err != nil {
+		return nil, errors.Wrap(err, "压缩快照失败")
+	}
+	defer zeroBytes(compressed)
+
+	salt, err := GenerateRandomBytes(16)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := keyRingSnapshot{
+		Format:      keyRingSnapshotFormat,
+		Version:     KeyRingSnapshotVersion,
+		CreatedAt:   time.Now().UTC().Truncate(time.Second),
+		Compression: compressor.Name(),
+		KDF:         keystoreKDF{Name: "argon2id", Salt: salt, KeystoreKDFParams: opts.KDF},
+		KeyCount:    len(body.Keys),
+	}
+	aad, err := snapshot.aad()
+	if err != nil {
+		return nil, err
+	}
+
+	kek := snapshot.KDF.derive(password)
+	defer zeroBytes(kek)
+	if snapshot.Payload, err = AESGCMEncrypt(kek, compressed, aad); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化快照失败")
+	}
+	return append(data, '\n'), nil
+}
+
+// ImportKeyRing 导入密钥环快照，返回新的密钥环
+func ImportKeyRing(data, password []byte) (*KeyRing, error) {
+	var snapshot keyRingSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, errors.Wrap(err, "解析快照失败")
+	}
+	if snapshot.Format != keyRingSnapshotFormat {
+		return nil, errors.New("不是密钥环快照")
+	}
+	if snapshot.Version != KeyRingSnapshotVersion {
+		return nil, errors.Errorf("不支持的快照版本: %d", snapshot.Version)
+	}
+	if snapshot.KDF.Name != "argon2id" {
+		return nil, errors.Errorf("不支持的密钥派生算法: %s", snapshot.KDF.Name)
+	}
+	if err := snapshot.KDF.validate(); err != nil {
+		return nil, err
+	}
+	compressor, err := snapshotCompressor(snapshot.Compression)
+	if err != nil {
+		return nil, err
+	}
+
+	aad, err := snapshot.aad()
+	if err != nil {
+		return nil, err
+	}
+	kek := snapshot.KDF.derive(password)
+	defer zeroBytes(kek)
+	compressed, err := AESGCMDecrypt(kek, snapshot.Payload, aad)
+	if err != nil {
+		return nil, errors.New("快照密码错误或快照已损坏")
+	}
+	defer zeroBytes(compressed)
+	plaintext, err := compressor.Decompress(compressed)
+	if err != nil {
+		return nil, errors.Wrap(err, "解压快照失败")
+	}
+	defer zeroBytes(plaintext)
+
+	var body keyRingSnapshotBody
+	if err := json.Unmarshal(plaintext, &body); err != nil {
+		return nil, errors.Wrap(err, "解析密钥环失败")
+	}
+	defer func() {
+		for _, item := range body.Keys {
+			zeroBytes(item.Secret)
+		}
+	}()
+	if len(body.Keys) != snapshot.KeyCount {
+		return nil, errors.New("快照中的密钥数量不一致")
+	}
+
+	// 按创建时间添加，保证没有记录主密钥时自动选择的主密钥与导出前一致
+	sort.SliceStable(body.Keys, func(i, j int) bool {
+		return body.Keys[i].CreatedAt.Before(body.Keys[j].CreatedAt)
+	})
+	ring := NewKeyRing()
+	for _, item := range body.Keys {
+		entry := KeyEntry{
+			ID:        item.ID,
+			Algorithm: item.Algorithm,
+			Key:       item.Secret,
+			PublicKey: []byte(item.PublicKey),
+			Usage:     item.Usage,
+			NotBefore: item.NotBefore,
+			NotAfter:  item.NotAfter,
+			Revoked:   item.Revoked,
+			CreatedAt: item.CreatedAt,
+		}
+		if err := ring.Add(entry); err != nil {
+			return nil, err
+		}
+	}
+	if body.Primary != "" {
+		if err := ring.SetPrimary(body.Primary); err != nil {
+			return nil, err
+		}
+	}
+	return ring, nil
+}
+
+// aad 快照元数据的附加认证数据（不含payload）
+func (s keyRingSnapshot) aad() ([]byte, error) {
+	s.Payload = nil
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化快照元数据失败")
+	}
+	return data, nil
+}
+
+// noneCompressor 不压缩
+type noneCompressor struct{}
+
+// Name 算法名称
+func (noneCompressor) Name() string {
+	return SnapshotCompressionNone
+}
+
+// Compress 原样返回副本
+func (noneCompressor) Compress(data []byte) ([]byte, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// Decompress 原样返回副本
+func (noneCompressor) Decompress(data []byte) ([]byte, error) {
+	return append([]byte(nil), data...), nil
+}
+
+// gzipCompressor gzip压缩
+type gzipCompressor struct{}
+
+// Name 算法名称
+func (gzipCompressor) Name() string {
+	return SnapshotCompressionGzip
+}
+
+// Compress gzip压缩
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decompress gzip解压
+func (gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
@@ -0,0 +1,362 @@
+package encrypt
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+)
+
+// 密钥库文件
+//
+// 将密钥环持久化为JSON文件。文件内有一把随机主密钥，由主密码经argon2id派生的密钥
+// 以AES-256-GCM包装；每个条目的密钥数据再由主密钥加密，并以密钥ID作为附加认证数据，
+// 防止条目之间互换密文。修改主密码只需重新包装主密钥，不必重新加密所有条目。
+// 公钥、用途与有效期等元数据以明文保存，便于在不输入密码的情况下审计；
+// 元数据（主密钥ID、吊销状态、有效期等）由主密钥派生的HMAC认证，修改文件不能撤销吊销或更换主密钥。
+// 文件中的argon2id参数在派生前检查上限，篡改参数不能耗尽内存或CPU
+
+// KeystoreVersion 密钥库文件格式版本，版本2起元数据由MAC认证
+const KeystoreVersion = 2
+
+// keystoreMasterKeySize 主密钥长度
+const keystoreMasterKeySize = 32
+
+// keystoreWrapAAD 包装主密钥时使用的附加认证数据
+var keystoreWrapAAD = []byte("encrypt-keystore/v1")
+
+// keystoreMACInfo 由主密钥派生元数据MAC密钥的HKDF info
+const keystoreMACInfo = "encrypt-keystore/v2 metadata"
+
+// argon2id参数上限，防止篡改文件中的参数耗尽内存或CPU
+const (
+	keystoreMaxKDFTime   = 64
+	keystoreMaxKDFMemory = 1024 * 1024 // KiB
+)
+
+// KeystoreKDFParams argon2id参数
+type KeystoreKDFParams struct {
+	Time    uint32 `json:"time"`
+	Memory  uint32 `json:"memory"` // 单位KiB
+	Threads uint8  `json:"threads"`
+}
+
+// DefaultKeystoreKDFParams 默认argon2id参数（RFC 9106 推荐的低内存配置）
+var DefaultKeystoreKDFParams = KeystoreKDFParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// validate 检查参数不为0且不超过上限，参数来自文件时必须在派生前调用
+func (p KeystoreKDFParams) validate() error {
+	if p.Time == 0 || p.Memory == 0 || p.Threads == 0 {
+		return errors.New("argon2id参数不能为0")
+	}
+	if p.Time > keystoreMaxKDFTime || p.Memory > keystoreMaxKDFMemory {
+		return errors.Errorf("argon2id参数超出上限（迭代次数不超过%d，内存不超过%dKiB）", keystoreMaxKDFTime, keystoreMaxKDFMemory)
+	}
+	return nil
+}
+
+// Keystore 受主密码保护的密钥库
+type Keystore struct {
+	path      string
+	kdf       keystoreKDF
+	wrapped   []byte
+	masterKey []byte
+	ring      *KeyRing
+}
+
+// keystoreFile 密钥库文件结构
+type keystoreFile struct {
+	Version    int             `json:"version"`
+	KDF        keystoreKDF     `json:"kdf"`
+	WrappedKey []byte          `json:"wrapped_key"`
+	Primary    string          `json:"primary,omitempty"`
+	Keys       []keystoreEntry `json:"keys"`
+	MAC        []byte          `json:"mac"`
+}
+
+// keystoreMetadata 元数据MAC的输入
+type keystoreMetadata struct {
+	Version int             `json:"version"`
+	Primary string          `json:"primary,omitempty"`
+	Keys    []keystoreEntry `json:"keys"`
+}
+
+// keystoreKDF 主密码派生参数
+type keystoreKDF struct {
+	Name string `json:"name"`
+	Salt []byte `json:"salt"`
+	KeystoreKDFParams
+}
+
+// keystoreEntry 密钥库中的一个条目，Secret为主密钥加密后的密钥数据
+type keystoreEntry struct {
+	ID        string    `json:"id"`
+	Algorithm Algorithm `json:"algorithm"`
+	Usage     KeyUsage  `json:"usage"`
+	NotBefore time.Time `json:"not_before,omitempty"`
+	NotAfter  time.Time `json:"not_after,omitempty"`
+	Revoked   bool      `json:"revoked,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	PublicKey string    `json:"public_key,omitempty"`
+	Secret    []byte    `json:"secret,omitempty"`
+}
+
+// CreateKeystore 创建空密钥库，调用Save后才写入文件
+func CreateKeystore(path string, password []byte) (*Keystore, error) {
+	return CreateKeystoreWithParams(path, password, DefaultKeystoreKDFParams)
+}
+
+// CreateKeystoreWithParams 使用指定argon2id参数创建空密钥库
+func CreateKeystoreWithParams(path string, password []byte, params KeystoreKDFParams) (*Keystore, error) {
+	if _, err := os.Stat(path); err == nil {
+		return nil, errors.Errorf("密钥库文件已存在: %s", path)
+	}
+
+	masterKey, err := GenerateRandomKey(keystoreMasterKeySize)
+	if err != nil {
+		return nil, err
+	}
+
+	ks := &Keystore{
+		path:      path,
+		masterKey: masterKey,
+		ring:      NewKeyRing(),
+	}
+	if err := ks.wrapMasterKey(password, params); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// LoadKeystore 使用主密码打开密钥库文件
+func LoadKeystore(path string, password []byte) (*Keystore, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "读取密钥库文件失败")
+	}
+
+	var file keystoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, errors.Wrap(err, "解析密钥库文件失败")
+	}
+	if file.Version != KeystoreVersion {
+		return nil, errors.Errorf("不支持的密钥库版本: %d", file.Version)
+	}
+	if file.KDF.Name != "argon2id" {
+		return nil, errors.Errorf("不支持的密钥派生算法: %s", file.KDF.Name)
+	}
+	if err := file.KDF.validate(); err != nil {
+		return nil, err
+	}
+
+	kek := file.KDF.derive(password)
+	defer zeroBytes(kek)
+	masterKey, err := AESGCMDecrypt(kek, file.WrappedKey, keystoreWrapAAD)
+	if err != nil {
+		return nil, errors.New("主密码错误或密钥库已损坏")
+	}
+	expected, err := keystoreMAC(masterKey, file.Primary, file.Keys)
+	if err != nil {
+		zeroBytes(masterKey)
+		return nil, err
+	}
+	if !hmac.Equal(expected, file.MAC) {
+		zeroBytes(masterKey)
+		return nil, errors.New("密钥库元数据校验失败，文件可能被篡改")
+	}
+
+	ks := &Keystore{
+		path:      path,
+		kdf:       file.KDF,
+		wrapped:   file.WrappedKey,
+		masterKey: masterKey,
+		ring:      NewKeyRing(),
+	}
+	for _, item := range file.Keys {
+		entry := KeyEntry{
+			ID:        item.ID,
+			Algorithm: item.Algorithm,
+			Usage:     item.Usage,
+			NotBefore: item.NotBefore,
+			NotAfter:  item.NotAfter,
+			Revoked:   item.Revoked,
+			CreatedAt: item.CreatedAt,
+			PublicKey: []byte(item.PublicKey),
+		}
+		if len(item.Secret) > 0 {
+			if entry.Key, err = AESGCMDecrypt(masterKey, item.Secret, []byte(item.ID)); err != nil {
+				return nil, errors.Errorf("解密密钥%s失败", item.ID)
+			}
+		}
+		if err := ks.ring.Add(entry); err != nil {
+			return nil, err
+		}
+		zeroBytes(entry.Key)
+	}
+	if file.Primary != "" {
+		if err := ks.ring.SetPrimary(file.Primary); err != nil {
+			return nil, err
+		}
+	}
+	return ks, nil
+}
+
+// KeyRing 获取密钥库中的密钥环，对其吊销、设置主密钥等修改会在Save时写回
+func (k *Keystore) KeyRing() *KeyRing {
+	return k.ring
+}
+
+// AddKey 添加密钥
+func (k *Keystore) AddKey(entry KeyEntry) error {
+	return k.ring.Add(entry)
+}
+
+// RemoveKey 移除密钥
+func (k *Keystore) RemoveKey(id string) error {
+	return k.ring.Remove(id)
+}
+
+// ChangePassword 修改主密码，需调用Save写入文件
+func (k *Keystore) ChangePassword(password []byte) error {
+	return k.wrapMasterKey(password, k.kdf.KeystoreKDFParams)
+}
+
+// Save 将密钥库写入文件，先写临时文件再替换，文件权限为0600
+func (k *Keystore) Save() error {
+	file := keystoreFile{
+		Version:    KeystoreVersion,
+		KDF:        k.kdf,
+		WrappedKey: k.wrapped,
+		Primary:    k.ring.Primary(),
+		Keys:       []keystoreEntry{},
+	}
+
+	for _, id := range k.ring.IDs() {
+		entry, err := k.ring.Get(id)
+		if err != nil {
+			return err
+		}
+
+		item := keystoreEntry{
+			ID:        entry.ID,
+			Algorithm: entry.Algorithm,
+			Usage:     entry.Usage,
+			NotBefore: entry.NotBefore,
+			NotAfter:  entry.NotAfter,
+			Revoked:   entry.Revoked,
+			CreatedAt: entry.CreatedAt,
+			PublicKey: string(entry.PublicKey),
+		}
+		if len(entry.Key) > 0 {
+			if item.Secret, err = AESGCMEncrypt(k.masterKey, entry.Key, []byte(entry.ID)); err != nil {
+				return err
+			}
+		}
+		file.Keys = append(file.Keys, item)
+	}
+	mac, err := keystoreMAC(k.masterKey, file.Primary, file.Keys)
+	if err != nil {
+		return err
+	}
+	file.MAC = mac
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "序列化密钥库失败")
+	}
+
+	return writeFileAtomic(k.path, append(data, '\n'), 0600)
+}
+
+// writeFileAtomic 先写同目录下的临时文件再替换，避免写入中断留下不完整的文件
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return errors.Wrap(err, "创建临时文件失败")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "写入文件失败")
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "设置文件权限失败")
+	}
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "写入文件失败")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return errors.Wrap(err, "替换文件失败")
+	}
+	return nil
+}
+
+// Close 清零内存中的主密钥
+func (k *Keystore) Close() {
+	zeroBytes(k.masterKey)
+}
+
+// wrapMasterKey 使用新的盐和主密码包装主密钥
+func (k *Keystore) wrapMasterKey(password []byte, params KeystoreKDFParams) error {
+	if len(password) == 0 {
+		return errors.New("主密码不能为空")
+	}
+	if err := params.validate(); err != nil {
+		return err
+	}
+
+	salt, err := GenerateRandomBytes(16)
+	if err != nil {
+		return err
+	}
+	kdf := keystoreKDF{Name: "argon2id", Salt: salt, KeystoreKDFParams: params}
+
+	kek := kdf.derive(password)
+	defer zeroBytes(kek)
+	wrapped, err := AESGCMEncrypt(kek, k.masterKey, keystoreWrapAAD)
+	if err != nil {
+		return err
+	}
+
+	k.kdf = kdf
+	k.wrapped = wrapped
+	return nil
+}
+
+// keystoreMAC 以主密钥派生的密钥计算元数据的HMAC-SHA256
+func keystoreMAC(masterKey []byte, primary string, keys []keystoreEntry) ([]byte, error) {
+	macKey, err := hkdf.Key(sha256.New, masterKey, nil, keystoreMACInfo, sha256.Size)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生元数据MAC密钥失败")
+	}
+	defer zeroBytes(macKey)
+
+	// 文件中的JSON经解析后重新序列化，与保存时的序列化结果一致
+	data, err := json.Marshal(keystoreMetadata{Version: KeystoreVersion, Primary: primary, Keys: keys})
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化密钥库元数据失败")
+	}
+	mac := hmac.New(sha256.New, macKey)
+	mac.Write(data)
+	return mac.Sum(nil), nil
+}
+
+// derive 由主密码派生包装密钥
+func (p keystoreKDF) derive(password []byte) []byte {
+	return argon2.IDKey(password, p.Salt, p.Time, p.Memory, p.Threads, 32)
+}
+
+// zeroBytes 清零字节切片
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
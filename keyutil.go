@@ -0,0 +1,101 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	stdx509 "crypto/x509"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// parsePublicKeyPEM 解析PEM编码的公钥
+// 支持RSA(PKCS#1/PKIX)、ECDSA、Ed25519以及SM2公钥
+func parsePublicKeyPEM(publicKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("无法解析PEM编码的公钥")
+	}
+
+	switch block.Type {
+	case "RSA PUBLIC KEY":
+		key, err := stdx509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析PKCS1公钥失败")
+		}
+		return key, nil
+	case "PUBLIC KEY":
+		// 先尝试标准库，再尝试SM2
+		if key, err := stdx509.ParsePKIXPublicKey(block.Bytes); err == nil {
+			return key, nil
+		}
+		key, err := x509.ParseSm2PublicKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析公钥失败")
+		}
+		return key, nil
+	default:
+		return nil, errors.Errorf("不支持的密钥类型: %s", block.Type)
+	}
+}
+
+// parsePrivateKeyPEM 解析PEM编码的私钥
+// 支持RSA(PKCS#1/PKCS#8)、ECDSA(SEC1/PKCS#8)、Ed25519(PKCS#8)以及SM2私钥
+func parsePrivateKeyPEM(privateKeyPEM []byte) (crypto.PrivateKey, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("无法解析PEM编码的私钥")
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err := stdx509.ParsePKCS1PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析PKCS1私钥失败")
+		}
+		return key, nil
+	case "EC PRIVATE KEY":
+		key, err := stdx509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "解析EC私钥失败")
+		}
+		return key, nil
+	}
+
+	// PKCS#8格式，先尝试标准库，再尝试SM2
+	if key, err := stdx509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+			return k, nil
+		default:
+			return nil, errors.New("不支持的私钥类型")
+		}
+	}
+
+	key, err := x509.ParsePKCS8UnecryptedPrivateKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析私钥失败")
+	}
+	return key, nil
+}
+
+// marshalPublicKeyDER 将公钥编码为PKIX(SubjectPublicKeyInfo) DER格式
+func marshalPublicKeyDER(publicKey crypto.PublicKey) ([]byte, error) {
+	if pubKey, ok := publicKey.(*sm2.PublicKey); ok {
+		der, err := x509.MarshalSm2PublicKey(pubKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "编码SM2公钥失败")
+		}
+		return der, nil
+	}
+
+	der, err := stdx509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "编码公钥失败")
+	}
+	return der, nil
+}
@@ -0,0 +1,87 @@
+package aliyunkms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/aliyun/alibaba-cloud-sdk-go/services/kms"
+	"github.com/sylphbyte/encrypt"
+)
+
+// fakeKMS 以"密钥ID:上下文:Base64明文"模拟包装，与阿里云KMS一样明文参数为Base64字符串
+type fakeKMS struct {
+	keyID string
+}
+
+func (f *fakeKMS) wrap(context, plaintext string) string {
+	return f.keyID + ":" + context + ":" + plaintext
+}
+
+func (f *fakeKMS) GenerateDataKey(request *kms.GenerateDataKeyRequest) (*kms.GenerateDataKeyResponse, error) {
+	if request.KeyId != f.keyID {
+		return nil, errors.New("Forbidden.KeyNotFound")
+	}
+	size, err := request.NumberOfBytes.GetValue()
+	if err != nil {
+		return nil, err
+	}
+	plaintext := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{0x42}, size))
+	return &kms.GenerateDataKeyResponse{
+		KeyId:          f.keyID,
+		Plaintext:      plaintext,
+		CiphertextBlob: f.wrap(request.EncryptionContext, plaintext),
+	}, nil
+}
+
+func (f *fakeKMS) Encrypt(request *kms.EncryptRequest) (*kms.EncryptResponse, error) {
+	if request.KeyId != f.keyID {
+		return nil, errors.New("Forbidden.KeyNotFound")
+	}
+	return &kms.EncryptResponse{KeyId: f.keyID, CiphertextBlob: f.wrap(request.EncryptionContext, request.Plaintext)}, nil
+}
+
+func (f *fakeKMS) Decrypt(request *kms.DecryptRequest) (*kms.DecryptResponse, error) {
+	prefix := f.wrap(request.EncryptionContext, "")
+	if len(request.CiphertextBlob) < len(prefix) || request.CiphertextBlob[:len(prefix)] != prefix {
+		return nil, errors.New("Rejected.InvalidCiphertext")
+	}
+	return &kms.DecryptResponse{KeyId: f.keyID, Plaintext: request.CiphertextBlob[len(prefix):]}, nil
+}
+
+// TestProvider 测试数据密钥生成、包装解包与信封加解密
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	encryptionContext := map[string]string{"tenant": "t-1"}
+	fake := &fakeKMS{keyID: "alias/app"}
+	provider := New(fake).WithEncryptionContext(encryptionContext)
+
+	plaintext, wrapped, err := provider.GenerateDataKey(ctx, "alias/app", 32)
+	if err != nil || len(plaintext) != 32 {
+		t.Fatalf("生成数据密钥失败: %v", err)
+	}
+	unwrapped, err := provider.UnwrapKey(ctx, "alias/app", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, plaintext) {
+		t.Fatalf("解包数据密钥失败: %v", err)
+	}
+
+	ciphertext, err := encrypt.EnvelopeEncrypt(ctx, provider, "alias/app", []byte("hello kms"), nil)
+	if err != nil {
+		t.Fatalf("信封加密失败: %v", err)
+	}
+	decrypted, err := encrypt.EnvelopeDecrypt(ctx, provider, ciphertext, nil)
+	if err != nil || string(decrypted) != "hello kms" {
+		t.Fatalf("信封解密失败: %v", err)
+	}
+
+	// 加密上下文不符时解包失败
+	other := New(fake).WithEncryptionContext(map[string]string{"tenant": "t-2"})
+	if _, err := other.UnwrapKey(ctx, "alias/app", wrapped); err == nil {
+		t.Fatal("加密上下文不符时应解包失败")
+	}
+	if _, err := provider.WrapKey(ctx, "alias/other", plaintext); err == nil {
+		t.Fatal("密钥不存在时应包装失败")
+	}
+}
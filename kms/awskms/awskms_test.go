@@ -0,0 +1,88 @@
+package awskms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/sylphbyte/encrypt"
+)
+
+// fakeKMS 以"密钥ID||数据"模拟包装，并校验加密上下文与密钥ID
+type fakeKMS struct {
+	keyID   string
+	context map[string]string
+}
+
+func (f *fakeKMS) check(keyID *string, encryptionContext map[string]string) error {
+	if aws.ToString(keyID) != f.keyID {
+		return errors.New("NotFoundException")
+	}
+	if !maps.Equal(encryptionContext, f.context) {
+		return errors.New("InvalidCiphertextException")
+	}
+	return nil
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, params *kms.GenerateDataKeyInput, optFns ...func(*kms.Options)) (*kms.GenerateDataKeyOutput, error) {
+	if err := f.check(params.KeyId, params.EncryptionContext); err != nil {
+		return nil, err
+	}
+	plaintext := bytes.Repeat([]byte{0x42}, int(aws.ToInt32(params.NumberOfBytes)))
+	return &kms.GenerateDataKeyOutput{Plaintext: plaintext, CiphertextBlob: append([]byte(f.keyID), plaintext...)}, nil
+}
+
+func (f *fakeKMS) Encrypt(ctx context.Context, params *kms.EncryptInput, optFns ...func(*kms.Options)) (*kms.EncryptOutput, error) {
+	if err := f.check(params.KeyId, params.EncryptionContext); err != nil {
+		return nil, err
+	}
+	return &kms.EncryptOutput{CiphertextBlob: append([]byte(f.keyID), params.Plaintext...)}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, params *kms.DecryptInput, optFns ...func(*kms.Options)) (*kms.DecryptOutput, error) {
+	if err := f.check(params.KeyId, params.EncryptionContext); err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(params.CiphertextBlob, []byte(f.keyID)) {
+		return nil, errors.New("IncorrectKeyException")
+	}
+	return &kms.DecryptOutput{Plaintext: bytes.Clone(params.CiphertextBlob[len(f.keyID):])}, nil
+}
+
+// TestProvider 测试数据密钥生成、包装解包与信封加解密
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	encryptionContext := map[string]string{"tenant": "t-1"}
+	fake := &fakeKMS{keyID: "alias/app", context: encryptionContext}
+	provider := New(fake).WithEncryptionContext(encryptionContext)
+
+	plaintext, wrapped, err := provider.GenerateDataKey(ctx, "alias/app", 32)
+	if err != nil || len(plaintext) != 32 {
+		t.Fatalf("生成数据密钥失败: %v", err)
+	}
+	unwrapped, err := provider.UnwrapKey(ctx, "alias/app", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, plaintext) {
+		t.Fatalf("解包数据密钥失败: %v", err)
+	}
+
+	ciphertext, err := encrypt.EnvelopeEncrypt(ctx, provider, "alias/app", []byte("hello kms"), nil)
+	if err != nil {
+		t.Fatalf("信封加密失败: %v", err)
+	}
+	decrypted, err := encrypt.EnvelopeDecrypt(ctx, provider, ciphertext, nil)
+	if err != nil || string(decrypted) != "hello kms" {
+		t.Fatalf("信封解密失败: %v", err)
+	}
+
+	// 加密上下文或密钥不符时解包失败
+	if _, err := New(fake).UnwrapKey(ctx, "alias/app", wrapped); err == nil {
+		t.Fatal("缺少加密上下文时应解包失败")
+	}
+	if _, err := provider.UnwrapKey(ctx, "alias/other", wrapped); err == nil {
+		t.Fatal("密钥ID不符时应解包失败")
+	}
+}
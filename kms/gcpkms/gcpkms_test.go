@@ -0,0 +1,85 @@
+package gcpkms
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"cloud.google.com/go/kms/apiv1/kmspb"
+	"github.com/googleapis/gax-go/v2"
+	"github.com/sylphbyte/encrypt"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// fakeKMS 以"资源名||AAD||数据"模拟包装，按Cloud KMS的方式校验与返回CRC32C
+type fakeKMS struct {
+	name    string
+	corrupt bool // 返回校验和不一致的响应
+}
+
+func (f *fakeKMS) Encrypt(ctx context.Context, req *kmspb.EncryptRequest, opts ...gax.CallOption) (*kmspb.EncryptResponse, error) {
+	if req.GetName() != f.name {
+		return nil, errors.New("NotFound")
+	}
+	if req.GetPlaintextCrc32C().GetValue() != crc32c(req.GetPlaintext()) {
+		return nil, errors.New("InvalidArgument: plaintext_crc32c")
+	}
+	ciphertext := append(append([]byte(f.name), req.GetAdditionalAuthenticatedData()...), req.GetPlaintext()...)
+	checksum := crc32c(ciphertext)
+	if f.corrupt {
+		checksum++
+	}
+	return &kmspb.EncryptResponse{
+		Name:                    f.name,
+		Ciphertext:              ciphertext,
+		CiphertextCrc32C:        wrapperspb.Int64(checksum),
+		VerifiedPlaintextCrc32C: true,
+		VerifiedAdditionalAuthenticatedDataCrc32C: req.AdditionalAuthenticatedDataCrc32C != nil,
+	}, nil
+}
+
+func (f *fakeKMS) Decrypt(ctx context.Context, req *kmspb.DecryptRequest, opts ...gax.CallOption) (*kmspb.DecryptResponse, error) {
+	if req.GetName() != f.name {
+		return nil, errors.New("NotFound")
+	}
+	if req.GetCiphertextCrc32C().GetValue() != crc32c(req.GetCiphertext()) {
+		return nil, errors.New("InvalidArgument: ciphertext_crc32c")
+	}
+	prefix := append([]byte(f.name), req.GetAdditionalAuthenticatedData()...)
+	if !bytes.HasPrefix(req.GetCiphertext(), prefix) {
+		return nil, errors.New("InvalidArgument: decryption failed")
+	}
+	plaintext := bytes.Clone(req.GetCiphertext()[len(prefix):])
+	return &kmspb.DecryptResponse{Plaintext: plaintext, PlaintextCrc32C: wrapperspb.Int64(crc32c(plaintext))}, nil
+}
+
+// TestProvider 测试包装解包、附加认证数据与响应校验和检查
+func TestProvider(t *testing.T) {
+	ctx := context.Background()
+	name := "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	provider := New(&fakeKMS{name: name}).WithAdditionalData([]byte("tenant-1"))
+
+	ciphertext, err := encrypt.EnvelopeEncrypt(ctx, provider, name, []byte("hello kms"), nil)
+	if err != nil {
+		t.Fatalf("信封加密失败: %v", err)
+	}
+	decrypted, err := encrypt.EnvelopeDecrypt(ctx, provider, ciphertext, nil)
+	if err != nil || string(decrypted) != "hello kms" {
+		t.Fatalf("信封解密失败: %v", err)
+	}
+
+	wrapped, err := provider.WrapKey(ctx, name, []byte("data-key"))
+	if err != nil {
+		t.Fatalf("包装数据密钥失败: %v", err)
+	}
+	other := New(&fakeKMS{name: name}).WithAdditionalData([]byte("tenant-2"))
+	if _, err := other.UnwrapKey(ctx, name, wrapped); err == nil {
+		t.Fatal("附加认证数据不符时应解包失败")
+	}
+
+	corrupted := New(&fakeKMS{name: name, corrupt: true})
+	if _, err := corrupted.WrapKey(ctx, name, []byte("data-key")); err == nil {
+		t.Fatal("响应校验和不一致时应包装失败")
+	}
+}
@@ -0,0 +1,29 @@
+module github.com/sylphbyte/encrypt/kms/gcpkms
+
+go 1.24.2
+
+require (
+	cloud.google.com/go/kms v1.23.2
+	github.com/googleapis/gax-go/v2 v2.15.0
+	github.com/pkg/errors v0.9.1
+	github.com/sylphbyte/encrypt v0.0.0
+	google.golang.org/protobuf v1.36.7
+)
+
+require (
+	cloud.google.com/go/longrunning v0.6.7 // indirect
+	filippo.io/edwards25519 v1.1.0 // indirect
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.1 // indirect
+	github.com/tjfoc/gmsm v1.4.1 // indirect
+	golang.org/x/crypto v0.41.0 // indirect
+	golang.org/x/net v0.43.0 // indirect
+	golang.org/x/sys v0.35.0 // indirect
+	golang.org/x/text v0.28.0 // indirect
+	google.golang.org/api v0.247.0 // indirect
+	google.golang.org/genproto/googleapis/api v0.0.0-20250818200422-3122310a409c // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20250811230008-5f3141c8851a // indirect
+	google.golang.org/grpc v1.74.2 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace github.com/sylphbyte/encrypt => ../..
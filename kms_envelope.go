@@ -0,0 +1,139 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// KMS数据密钥信封
+//
+// 与AWS Encryption SDK相同的数据密钥模式：每条消息使用一把新的256位数据密钥在本地做AES-GCM加密，
+// 数据密钥由KeyProvider（本地密钥环、Vault或云KMS）包装后写入消息头部。
+// 解密时只需一次KMS调用解包数据密钥，数据本身不会发送到KMS。
+// 各云厂商的实现位于kms目录下的独立子模块，避免主模块引入云SDK依赖。
+//
+// 布局：
+//
+//	magic(2) | version(1) | idLen(1) | keyID | wrappedLen(2) | wrappedKey | nonce || 密文 || 标签
+//
+// 头部整体作为GCM附加认证数据，篡改密钥ID或包装后的数据密钥都会导致解密失败
+
+// DataKeyGenerator 可由KMS直接生成数据密钥的提供者，例如AWS KMS与阿里云KMS的GenerateDataKey
+type DataKeyGenerator interface {
+	// GenerateDataKey 生成size字节的数据密钥，返回明文与包装后的密钥
+	GenerateDataKey(ctx context.Context, keyID string, size int) (plaintext, wrapped []byte, err error)
+}
+
+// kmsEnvelopeMagic 数据密钥信封魔数
+var kmsEnvelopeMagic = []byte{0x53, 0x4b}
+
+// kmsEnvelopeVersion 数据密钥信封版本
+const kmsEnvelopeVersion = 1
+
+// kmsDataKeySize 数据密钥长度（AES-256）
+const kmsDataKeySize = 32
+
+// EnvelopeEncrypt 生成数据密钥并加密数据，provider实现DataKeyGenerator时由KMS生成数据密钥
+func EnvelopeEncrypt(ctx context.Context, provider KeyProvider, keyID string, plaintext, aad []byte) ([]byte, error) {
+	if len(keyID) == 0 || len(keyID) > 0xff {
+		return nil, errors.New("密钥ID长度必须在1到255字节之间")
+	}
+
+	dataKey, wrapped, err := newDataKey(ctx, provider, keyID)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(dataKey)
+
+	if len(wrapped) > 0xffff {
+		return nil, errors.New("包装后的数据密钥过长")
+	}
+
+	header := make([]byte, 0, 6+len(keyID)+len(wrapped))
+	header = append(header, kmsEnvelopeMagic...)
+	header = append(header, kmsEnvelopeVersion, byte(len(keyID)))
+	header = append(header, keyID...)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+	header = append(header, wrapped...)
+
+	ciphertext, err := AESGCMEncrypt(dataKey, plaintext, kmsEnvelopeAAD(header, aad))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, ciphertext...), nil
+}
+
+// newDataKey 生成256位数据密钥并包装，provider实现DataKeyGenerator时由KMS生成
+func newDataKey(ctx context.Context, provider KeyProvider, keyID string) (dataKey, wrapped []byte, err error) {
+	if generator, ok := provider.(DataKeyGenerator); ok {
+		dataKey, wrapped, err = generator.GenerateDataKey(ctx, keyID, kmsDataKeySize)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "生成数据密钥失败")
+		}
+		return dataKey, wrapped, nil
+	}
+
+	if dataKey, err = GenerateRandomKey(kmsDataKeySize); err != nil {
+		return nil, nil, err
+	}
+	if wrapped, err = provider.WrapKey(ctx, keyID, dataKey); err != nil {
+		zeroBytes(dataKey)
+		return nil, nil, errors.Wrap(err, "包装数据密钥失败")
+	}
+	return dataKey, wrapped, nil
+}
+
+// EnvelopeDecrypt 解包数据密钥并解密数据，aad必须与加密时一致
+func EnvelopeDecrypt(ctx context.Context, provider KeyProvider, data, aad []byte) ([]byte, error) {
+	keyID, wrapped, headerLen, err := parseKMSEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := provider.UnwrapKey(ctx, keyID, wrapped)
+	if err != nil {
+		return nil, errors.Wrap(err, "解包数据密钥失败")
+	}
+	defer zeroBytes(dataKey)
+
+	return AESGCMDecrypt(dataKey, data[headerLen:], kmsEnvelopeAAD(data[:headerLen], aad))
+}
+
+// EnvelopeKeyID 读取数据密钥信封中的主密钥ID，不解密
+func EnvelopeKeyID(data []byte) (string, error) {
+	keyID, _, _, err := parseKMSEnvelope(data)
+	return keyID, err
+}
+
+// parseKMSEnvelope 解析信封头部，返回密钥ID、包装后的数据密钥与头部长度
+func parseKMSEnvelope(data []byte) (string, []byte, int, error) {
+	if len(data) < 4 || !bytes.Equal(data[:2], kmsEnvelopeMagic) {
+		return "", nil, 0, errors.New("不是数据密钥信封")
+	}
+	if data[2] != kmsEnvelopeVersion {
+		return "", nil, 0, errors.Errorf("不支持的数据密钥信封版本: %d", data[2])
+	}
+
+	pos := 4 + int(data[3])
+	if len(data) < pos+2 {
+		return "", nil, 0, errors.New("数据密钥信封头部不完整")
+	}
+	keyID := string(data[4:pos])
+
+	wrappedLen := int(binary.BigEndian.Uint16(data[pos:]))
+	pos += 2
+	if len(data) < pos+wrappedLen {
+		return "", nil, 0, errors.New("数据密钥信封头部不完整")
+	}
+	return keyID, data[pos : pos+wrappedLen], pos + wrappedLen, nil
+}
+
+// kmsEnvelopeAAD 拼接头部与调用方的附加认证数据
+func kmsEnvelopeAAD(header, aad []byte) []byte {
+	out := make([]byte, 0, len(header)+len(aad))
+	out = append(out, header...)
+	return append(out, aad...)
+}
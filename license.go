@@ -0,0 +1,216 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 许可证签发与离线验证
+//
+// 授权声明（功能、有效期、机器码等）序列化为JSON后用Ed25519或SM2私钥签名，
+// 编码为不区分大小写、按5个字符分组的Base32字符串，便于复制粘贴与电话口述：
+//
+//	base32( 版本(1) | 签名算法(1) | 声明长度(2) | 声明JSON | 签名 )
+//
+// 客户端只需内置公钥即可离线验证，验证时检查签名、有效期以及可选的机器码绑定
+
+// 许可证错误，可通过errors.Is判断
+var (
+	ErrLicenseInvalid = errors.New("许可证无效")
+	ErrLicenseExpired = errors.New("许可证已过期")
+	ErrLicenseMachine = errors.New("许可证与本机不匹配")
+)
+
+// licenseVersion 许可证格式版本
+const licenseVersion = 1
+
+// 许可证签名算法
+const (
+	licenseAlgEd25519 = 1
+	licenseAlgSM2     = 2
+)
+
+// licenseEncoding Base32编码，无填充
+var licenseEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// licenseGroupSize 输出分组长度
+const licenseGroupSize = 5
+
+// LicenseClaims 许可证声明，时间以Unix秒保存
+type LicenseClaims struct {
+	ID        string            `json:"id,omitempty"`
+	Licensee  string            `json:"sub"`
+	Product   string            `json:"prd,omitempty"`
+	Features  []string          `json:"ftr,omitempty"`
+	MachineID string            `json:"mid,omitempty"` // 为空表示不绑定机器
+	IssuedAt  int64             `json:"iat"`
+	ExpiresAt int64             `json:"exp,omitempty"` // 为0表示永久有效
+	Extra     map[string]string `json:"ext,omitempty"`
+}
+
+// HasFeature 判断是否授权了指定功能
+func (c *LicenseClaims) HasFeature(feature string) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// IssueLicense 使用PEM私钥（Ed25519或SM2）签发许可证，IssuedAt为0时使用当前时间
+func IssueLicense(privateKeyPEM []byte, claims LicenseClaims) (string, error) {
+	signer, err := ParseSigner(privateKeyPEM)
+	if err != nil {
+		return "", err
+	}
+	alg, err := licenseAlgorithm(signer.Public())
+	if err != nil {
+		return "", err
+	}
+	if claims.Licensee == "" {
+		return "", errors.New("许可证必须指定被授权方")
+	}
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = time.Now().Unix()
+	}
+
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", errors.Wrap(err, "序列化许可证声明失败")
+	}
+	if len(body) > 0xffff {
+		return "", errors.New("许可证声明过长")
+	}
+
+	data := []byte{licenseVersion, alg}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(body)))
+	data = append(data, body...)
+	signature, err := signMessage(signer, data)
+	if err != nil {
+		return "", errors.Wrap(err, "签名许可证失败")
+	}
+	return formatLicense(licenseEncoding.EncodeToString(append(data, signature...))), nil
+}
+
+// LicenseVerifier 许可证验证器
+type LicenseVerifier struct {
+	publicKey crypto.PublicKey
+	machineID string
+	product   string
+	now       func() time.Time
+}
+
+// NewLicenseVerifier 使用PEM公钥创建验证器
+func NewLicenseVerifier(publicKeyPEM []byte) (*LicenseVerifier, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := licenseAlgorithm(publicKey); err != nil {
+		return nil, err
+	}
+	return &LicenseVerifier{publicKey: publicKey, now: time.Now}, nil
+}
+
+// WithMachineID 设置本机机器码，绑定了机器的许可证必须与之一致
+func (v *LicenseVerifier) WithMachineID(machineID string) *LicenseVerifier {
+	v.machineID = machineID
+	return v
+}
+
+// WithProduct 设置产品标识，许可证声明了产品时必须与之一致
+func (v *LicenseVerifier) WithProduct(product string) *LicenseVerifier {
+	v.product = product
+	return v
+}
+
+// WithClock 设置时钟，主要用于测试
+func (v *LicenseVerifier) WithClock(now func() time.Time) *LicenseVerifier {
+	v.now = now
+	return v
+}
+
+// Verify 离线验证许可证，返回其中的声明
+func (v *LicenseVerifier) Verify(license string) (*LicenseClaims, error) {
+	claims, err := v.parse(license)
+	if err != nil {
+		return nil, err
+	}
+	if claims.ExpiresAt != 0 && v.now().Unix() > claims.ExpiresAt {
+		return claims, ErrLicenseExpired
+	}
+	if claims.MachineID != "" && claims.MachineID != v.machineID {
+		return claims, ErrLicenseMachine
+	}
+	if v.product != "" && claims.Product != "" && claims.Product != v.product {
+		return claims, errors.Wrap(ErrLicenseInvalid, "产品不符")
+	}
+	return claims, nil
+}
+
+// parse 解码并验证签名
+func (v *LicenseVerifier) parse(license string) (*LicenseClaims, error) {
+	normalized := strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' || r == '\n' || r == '\r' || r == '\t' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(license))
+	data, err := licenseEncoding.DecodeString(normalized)
+	if err != nil || len(data) < 4 || data[0] != licenseVersion {
+		return nil, ErrLicenseInvalid
+	}
+	if alg, _ := licenseAlgorithm(v.publicKey); data[1] != alg {
+		return nil, ErrLicenseInvalid
+	}
+	bodyEnd := 4 + int(binary.BigEndian.Uint16(data[2:4]))
+	if len(data) <= bodyEnd {
+		return nil, ErrLicenseInvalid
+	}
+	if err := verifyMessage(v.publicKey, data[:bodyEnd], data[bodyEnd:]); err != nil {
+		return nil, ErrLicenseInvalid
+	}
+
+	claims := &LicenseClaims{}
+	if err := json.Unmarshal(data[4:bodyEnd], claims); err != nil {
+		return nil, errors.Wrap(ErrLicenseInvalid, "声明格式不正确")
+	}
+	return claims, nil
+}
+
+// licenseAlgorithm 获取公钥对应的许可证签名算法
+func licenseAlgorithm(publicKey crypto.PublicKey) (byte, error) {
+	switch publicKey.(type) {
+	case ed25519.PublicKey:
+		return licenseAlgEd25519, nil
+	case *sm2.PublicKey:
+		return licenseAlgSM2, nil
+	default:
+		return 0, errors.New("许可证仅支持Ed25519与SM2密钥")
+	}
+}
+
+// formatLicense 按固定长度分组
+func formatLicense(encoded string) string {
+	var b strings.Builder
+	for i := 0; i < len(encoded); i += licenseGroupSize {
+		if i > 0 {
+			b.WriteByte('-')
+		}
+		end := i + licenseGroupSize
+		if end > len(encoded) {
+			end = len(encoded)
+		}
+		b.WriteString(encoded[i:end])
+	}
+	return b.String()
+}
@@ -0,0 +1,197 @@
+package encrypt
+
+import (
+	"crypto/hkdf"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 日志字段脱敏
+//
+// LogRedactor 在日志输出前处理配置的敏感字段（手机号、身份证号等），满足个人信息保护要求：
+//   - 哈希：HMAC截断为96位，不可逆，相同的值得到相同的结果，可以在日志中关联同一用户
+//   - 加密：确定性加密（与StorageCodec键加密相同的SIV结构），同样可以关联，
+//     必要时持有密钥的人员可以用Reveal还原
+//
+// 字段名不区分大小写，RedactValue按同样的规则处理嵌套在map、切片与结构体中的字段。zap与logrus的适配器在 logging/ 下的独立子模块中
+
+// RedactMode 字段脱敏方式
+type RedactMode int
+
+// 脱敏方式常量定义
+const (
+	RedactHash RedactMode = iota + 1
+	RedactEncrypt
+)
+
+// 脱敏输出前缀
+const (
+	redactHashPrefix    = "hmac:"
+	redactEncryptPrefix = "enc:"
+	redactInfo          = "sylphbyte/encrypt log redact v1"
+)
+
+// redactNamespace 确定性加密的命名空间，所有字段共用，不同字段中的相同值可以关联
+var redactNamespace = []byte("log")
+
+// LogRedactor 日志字段脱敏器
+type LogRedactor struct {
+	fields map[string]RedactMode
+	hasher *BlindIndexer
+	codec  *StorageCodec
+}
+
+// NewLogRedactor 创建脱敏器，key为32字节专用密钥，哈希与加密子密钥由其派生
+func NewLogRedactor(key []byte) (*LogRedactor, error) {
+	if len(key) != 32 {
+		return nil, errors.New("日志脱敏密钥长度必须是32字节")
+	}
+	derived, err := hkdf.Key(sha256.New, key, nil, redactInfo, 64)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生日志脱敏密钥失败")
+	}
+	return &LogRedactor{
+		fields: make(map[string]RedactMode),
+		hasher: NewBlindIndex(derived[:32], redactNamespace).Bits(96),
+		codec:  NewStorageCodec(nil).WithDeterministicKeys(derived[32:]),
+	}, nil
+}
+
+// Hash 对字段做不可逆哈希
+func (r *LogRedactor) Hash(fields ...string) *LogRedactor {
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = RedactHash
+	}
+	return r
+}
+
+// Encrypt 对字段做确定性加密
+func (r *LogRedactor) Encrypt(fields ...string) *LogRedactor {
+	for _, field := range fields {
+		r.fields[strings.ToLower(field)] = RedactEncrypt
+	}
+	return r
+}
+
+// Mode 获取字段的脱敏方式，未配置的字段返回0
+func (r *LogRedactor) Mode(field string) RedactMode {
+	return r.fields[strings.ToLower(field)]
+}
+
+// Redact 脱敏字段值，第二个返回值表示该字段是否需要脱敏
+func (r *LogRedactor) Redact(field, value string) (string, bool) {
+	switch r.Mode(field) {
+	case RedactHash:
+		index, err := r.hasher.IndexString(value)
+		if err != nil {
+			return "[REDACTED]", true
+		}
+		return redactHashPrefix + index, true
+	case RedactEncrypt:
+		sealed, err := r.codec.EncodeKey(redactNamespace, []byte(value))
+		if err != nil {
+			return "[REDACTED]", true
+		}
+		return redactEncryptPrefix + base64.RawURLEncoding.EncodeToString(sealed), true
+	default:
+		return value, false
+	}
+}
+
+// RedactValue 递归脱敏复合值中配置的字段：map按键名匹配，切片逐项处理，结构体等其他复合类型按JSON展开后处理。
+// 配置字段的值为复合类型时按fmt.Sprint的结果整体脱敏；没有字段被替换时原样返回value，第二个返回值为false
+func (r *LogRedactor) RedactValue(value interface{}) (interface{}, bool) {
+	switch v := value.(type) {
+	case nil, string, []byte, error, json.Number:
+		return value, false
+	case map[string]interface{}:
+		var out map[string]interface{}
+		for key, item := range v {
+			redacted, changed := r.redactEntry(key, item)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = make(map[string]interface{}, len(v))
+				for k, i := range v {
+					out[k] = i
+				}
+			}
+			out[key] = redacted
+		}
+		if out == nil {
+			return value, false
+		}
+		return out, true
+	case []interface{}:
+		var out []interface{}
+		for i, item := range v {
+			redacted, changed := r.RedactValue(item)
+			if !changed {
+				continue
+			}
+			if out == nil {
+				out = append([]interface{}(nil), v...)
+			}
+			out[i] = redacted
+		}
+		if out == nil {
+			return value, false
+		}
+		return out, true
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array, reflect.Pointer:
+	default:
+		return value, false
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value, false
+	}
+	decoder := json.NewDecoder(strings.NewReader(string(data)))
+	decoder.UseNumber()
+	var generic interface{}
+	if err := decoder.Decode(&generic); err != nil {
+		return value, false
+	}
+	if redacted, changed := r.RedactValue(generic); changed {
+		return redacted, true
+	}
+	return value, false
+}
+
+// redactEntry 脱敏map中的一项，未配置的键递归处理其值
+func (r *LogRedactor) redactEntry(key string, value interface{}) (interface{}, bool) {
+	if r.Mode(key) == 0 {
+		return r.RedactValue(value)
+	}
+	if s, ok := value.(string); ok {
+		return r.Redact(key, s)
+	}
+	return r.Redact(key, fmt.Sprint(value))
+}
+
+// Reveal 还原加密方式脱敏的值，哈希方式不可还原
+func (r *LogRedactor) Reveal(redacted string) (string, error) {
+	encoded, ok := strings.CutPrefix(redacted, redactEncryptPrefix)
+	if !ok {
+		return "", errors.New("不是加密方式脱敏的值")
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(err, "脱敏值解码失败")
+	}
+	value, err := r.codec.DecodeKey(redactNamespace, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
@@ -0,0 +1,272 @@
+package encrypt
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// 审计日志哈希链
+//
+// LogSealer 把每条记录与上一条记录的哈希串成链，写成JSON Lines：
+//
+//	hash = H(seq(8字节大端) || 时间(UnixNano，8字节大端) || prev || 记录)
+//
+// 第一条记录的prev为全零。删除、插入、修改或重排任意一条记录都会让之后的链断开。
+// 仅有哈希链时，攻击者可以改完后重算整条链，也可以直接截掉末尾的记录；
+// 设置WithCheckpoints后每N条记录用私钥对 (seq, hash) 签名一次，重算的链无法通过签名校验，
+// 最后一个检查点之后的记录仍可能被截掉，VerifyLog返回最后一个检查点的序号供调用方判断。
+//
+// 记录按文本存放，必须是合法的UTF-8，不能包含换行
+
+// logCheckpointContext 检查点签名的上下文前缀
+const logCheckpointContext = "sylphbyte/encrypt log checkpoint v1"
+
+// ErrLogTampered 日志哈希链或检查点校验失败，可通过errors.Is判断
+var ErrLogTampered = errors.New("审计日志已被篡改")
+
+// LogEntry 日志中的一行
+type LogEntry struct {
+	Seq        uint64         `json:"seq"`
+	Time       time.Time      `json:"time"`
+	Record     string         `json:"record"`
+	Prev       string         `json:"prev"`
+	Hash       string         `json:"hash"`
+	Checkpoint *LogCheckpoint `json:"checkpoint,omitempty"`
+}
+
+// LogCheckpoint 检查点签名
+type LogCheckpoint struct {
+	KeyFingerprint string `json:"key_fingerprint"`
+	Signature      string `json:"signature"`
+}
+
+// LogSealer 日志哈希链生成器，可并发使用
+type LogSealer struct {
+	mu       sync.Mutex
+	hashAlgo HashAlgorithm
+	seq      uint64
+	prev     []byte
+	signer   crypto.Signer
+	keyID    string
+	every    int
+	now      func() time.Time
+}
+
+// LogVerifyOptions 日志校验选项
+type LogVerifyOptions struct {
+	HashAlgorithm   HashAlgorithm      // 与LogSealer一致，零值使用SHA-256
+	PublicKeys      []crypto.PublicKey // 检查点验签公钥，按指纹匹配，支持轮换
+	CheckpointEvery int                // 大于0时要求每N条记录必须有检查点
+}
+
+// LogVerifyResult 日志校验结果
+type LogVerifyResult struct {
+	Records        uint64    // 校验通过的记录数
+	Last           *LogEntry // 最后一条记录，用于LogSealer.Resume
+	LastCheckpoint uint64    // 最后一个检查点的序号，之后的记录未受签名保护
+}
+
+// NewLogSealer 创建哈希链生成器，hashAlgo为零值时使用SHA-256
+func NewLogSealer(hashAlgo HashAlgorithm) *LogSealer {
+	if hashAlgo == 0 {
+		hashAlgo = HashSHA256
+	}
+	return &LogSealer{
+		hashAlgo: hashAlgo,
+		prev:     make([]byte, hashFunc(hashAlgo)().Size()),
+		now:      time.Now,
+	}
+}
+
+// WithCheckpoints 每every条记录使用signer对链头签名
+func (s *LogSealer) WithCheckpoints(signer crypto.Signer, every int) *LogSealer {
+	if every < 1 {
+		panic("检查点间隔必须大于0")
+	}
+	fp, err := fingerprint(signer.Public(), HashSHA256)
+	if err != nil {
+		panic(err)
+	}
+	s.signer, s.keyID, s.every = signer, hex.EncodeToString(fp), every
+	return s
+}
+
+// WithClock 设置时钟，主要用于测试
+func (s *LogSealer) WithClock(now func() time.Time) *LogSealer {
+	s.now = now
+	return s
+}
+
+// Resume 从已有日志的最后一条记录继续，last通常取自VerifyLog的结果
+func (s *LogSealer) Resume(last *LogEntry) error {
+	if last == nil {
+		return nil
+	}
+	hash, err := hex.DecodeString(last.Hash)
+	if err != nil || len(hash) != len(s.prev) {
+		return errors.New("记录哈希格式不正确")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq, s.prev = last.Seq, hash
+	return nil
+}
+
+// Seal 把记录追加到链上，返回待写入的日志行
+func (s *LogSealer) Seal(record []byte) (*LogEntry, error) {
+	if !utf8.Valid(record) {
+		return nil, errors.New("日志记录必须是合法的UTF-8")
+	}
+	if bytes.ContainsAny(record, "\r\n") {
+		return nil, errors.New("日志记录不能包含换行")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := &LogEntry{
+		Seq:    s.seq + 1,
+		Time:   s.now().UTC(),
+		Record: string(record),
+		Prev:   hex.EncodeToString(s.prev),
+	}
+	hash := logEntryHash(s.hashAlgo, entry.Seq, entry.Time, s.prev, record)
+	entry.Hash = hex.EncodeToString(hash)
+
+	if s.signer != nil && entry.Seq%uint64(s.every) == 0 {
+		signature, err := signMessage(s.signer, logCheckpointMessage(entry.Seq, hash))
+		if err != nil {
+			return nil, errors.Wrap(err, "检查点签名失败")
+		}
+		entry.Checkpoint = &LogCheckpoint{
+			KeyFingerprint: s.keyID,
+			Signature:      base64.StdEncoding.EncodeToString(signature),
+		}
+	}
+
+	s.seq, s.prev = entry.Seq, hash
+	return entry, nil
+}
+
+// Append 封装记录并作为一行写入w
+// 写入失败时链头已经前移，调用方应停止写入并用VerifyLog与Resume恢复
+func (s *LogSealer) Append(w io.Writer, record []byte) error {
+	entry, err := s.Seal(record)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return errors.Wrap(err, "序列化日志记录失败")
+	}
+	if _, err := w.Write(append(line, '\n')); err != nil {
+		return errors.Wrap(err, "写入日志失败")
+	}
+	return nil
+}
+
+// VerifyLog 逐行校验哈希链与检查点签名
+func VerifyLog(r io.Reader, opts LogVerifyOptions) (*LogVerifyResult, error) {
+	hashAlgo := opts.HashAlgorithm
+	if hashAlgo == 0 {
+		hashAlgo = HashSHA256
+	}
+	keys := make(map[string]crypto.PublicKey, len(opts.PublicKeys))
+	for _, pub := range opts.PublicKeys {
+		fp, err := fingerprint(pub, HashSHA256)
+		if err != nil {
+			return nil, err
+		}
+		keys[hex.EncodeToString(fp)] = pub
+	}
+
+	result := &LogVerifyResult{}
+	prev := make([]byte, hashFunc(hashAlgo)().Size())
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		seq := result.Records + 1
+
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return result, errors.Wrapf(ErrLogTampered, "第%d条记录无法解析", seq)
+		}
+		if entry.Seq != seq {
+			return result, errors.Wrapf(ErrLogTampered, "第%d条记录的序号为%d", seq, entry.Seq)
+		}
+		if entry.Prev != hex.EncodeToString(prev) {
+			return result, errors.Wrapf(ErrLogTampered, "第%d条记录与上一条记录不衔接", seq)
+		}
+		hash := logEntryHash(hashAlgo, entry.Seq, entry.Time, prev, []byte(entry.Record))
+		expected, err := hex.DecodeString(entry.Hash)
+		if err != nil || subtle.ConstantTimeCompare(hash, expected) != 1 {
+			return result, errors.Wrapf(ErrLogTampered, "第%d条记录的哈希不匹配", seq)
+		}
+
+		if entry.Checkpoint != nil {
+			if err := verifyLogCheckpoint(keys, &entry, hash); err != nil {
+				return result, errors.Wrapf(ErrLogTampered, "第%d条记录的检查点%v", seq, err)
+			}
+			result.LastCheckpoint = seq
+		} else if opts.CheckpointEvery > 0 && seq%uint64(opts.CheckpointEvery) == 0 {
+			return result, errors.Wrapf(ErrLogTampered, "第%d条记录缺少检查点", seq)
+		}
+
+		prev = hash
+		result.Records = seq
+		result.Last = &entry
+	}
+	if err := scanner.Err(); err != nil {
+		return result, errors.Wrap(err, "读取日志失败")
+	}
+	return result, nil
+}
+
+// verifyLogCheckpoint 校验检查点签名
+func verifyLogCheckpoint(keys map[string]crypto.PublicKey, entry *LogEntry, hash []byte) error {
+	pub, ok := keys[entry.Checkpoint.KeyFingerprint]
+	if !ok {
+		return errors.New("使用了未知的签名密钥")
+	}
+	signature, err := base64.StdEncoding.DecodeString(entry.Checkpoint.Signature)
+	if err != nil {
+		return errors.New("签名格式不正确")
+	}
+	return verifyMessage(pub, logCheckpointMessage(entry.Seq, hash), signature)
+}
+
+// logEntryHash 计算记录哈希
+func logEntryHash(hashAlgo HashAlgorithm, seq uint64, at time.Time, prev, record []byte) []byte {
+	h := hashFunc(hashAlgo)()
+	var header [16]byte
+	binary.BigEndian.PutUint64(header[:8], seq)
+	binary.BigEndian.PutUint64(header[8:], uint64(at.UnixNano()))
+	h.Write(header[:])
+	h.Write(prev)
+	h.Write(record)
+	return h.Sum(nil)
+}
+
+// logCheckpointMessage 检查点签名内容：上下文 || seq || hash
+func logCheckpointMessage(seq uint64, hash []byte) []byte {
+	message := make([]byte, 0, len(logCheckpointContext)+8+len(hash))
+	message = append(message, logCheckpointContext...)
+	message = binary.BigEndian.AppendUint64(message, seq)
+	return append(message, hash...)
+}
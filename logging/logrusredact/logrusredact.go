@@ -0,0 +1,73 @@
+// Package logrusredact logrus日志的敏感字段脱敏Hook
+//
+// Hook在日志格式化前对encrypt.LogRedactor中配置的字段做哈希或确定性加密：
+//
+//	redactor, _ := encrypt.NewLogRedactor(key)
+//	redactor.Encrypt("phone").Hash("id_card")
+//	logger.AddHook(logrusredact.NewHook(redactor))
+//
+// 按entry.Data的字段名匹配，非字符串的值按fmt.Sprint的结果脱敏；
+// 未配置的字段值为map、切片或结构体时用encrypt.LogRedactor.RedactValue处理其中嵌套的字段。
+// 作为独立子模块发布，不使用logrus时主模块无需引入logrus
+package logrusredact
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sylphbyte/encrypt"
+)
+
+// Hook 脱敏Hook
+type Hook struct {
+	redactor *encrypt.LogRedactor
+	levels   []logrus.Level
+}
+
+var _ logrus.Hook = (*Hook)(nil)
+
+// NewHook 创建Hook，默认处理所有级别
+func NewHook(redactor *encrypt.LogRedactor) *Hook {
+	return &Hook{redactor: redactor, levels: logrus.AllLevels}
+}
+
+// WithLevels 只处理指定级别
+func (h *Hook) WithLevels(levels ...logrus.Level) *Hook {
+	h.levels = levels
+	return h
+}
+
+// Levels 实现logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	return h.levels
+}
+
+// Fire 替换需要脱敏的字段，logrus在触发Hook前已复制entry.Data，不会影响调用方的Fields
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	for key, value := range entry.Data {
+		if h.redactor.Mode(key) == 0 {
+			if redacted, changed := h.redactor.RedactValue(value); changed {
+				entry.Data[key] = redacted
+			}
+			continue
+		}
+		entry.Data[key], _ = h.redactor.Redact(key, valueString(value))
+	}
+	return nil
+}
+
+// valueString 获取字段值的字符串形式
+func valueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
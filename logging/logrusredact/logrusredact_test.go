@@ -0,0 +1,126 @@
+package logrusredact
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sylphbyte/encrypt"
+)
+
+const (
+	testPhone  = "13800138000"
+	testIDCard = "110101199003074514"
+)
+
+// profile 按JSON展开处理的测试类型
+type profile struct {
+	Name   string `json:"name"`
+	IDCard string `json:"id_card"`
+}
+
+// newTestLogger 创建输出到缓冲区的脱敏logger
+func newTestLogger(t *testing.T, formatter logrus.Formatter) (*logrus.Logger, *encrypt.LogRedactor, *bytes.Buffer) {
+	t.Helper()
+	key, err := encrypt.GenerateRandomBytes(32)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	redactor, err := encrypt.NewLogRedactor(key)
+	if err != nil {
+		t.Fatalf("创建脱敏器失败: %v", err)
+	}
+	redactor.Encrypt("phone").Hash("id_card")
+
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(formatter)
+	logger.AddHook(NewHook(redactor))
+	return logger, redactor, buf
+}
+
+// TestRedactFields 测试顶层与嵌套字段在输出中不出现明文
+func TestRedactFields(t *testing.T) {
+	for _, formatter := range []logrus.Formatter{&logrus.JSONFormatter{}, &logrus.TextFormatter{DisableColors: true}} {
+		logger, _, buf := newTestLogger(t, formatter)
+		for _, c := range []struct {
+			name   string
+			fields logrus.Fields
+		}{
+			{"顶层字符串", logrus.Fields{"phone": testPhone}},
+			{"顶层非字符串", logrus.Fields{"ID_Card": int64(110101199003074514)}},
+			{"配置的复合字段", logrus.Fields{"phone": map[string]string{"mobile": testPhone}}},
+			{"嵌套map", logrus.Fields{"user": map[string]interface{}{"name": "alice", "phone": testPhone}}},
+			{"多层嵌套", logrus.Fields{"req": map[string]interface{}{"users": []interface{}{map[string]interface{}{"phone": testPhone}}}}},
+			{"结构体", logrus.Fields{"profile": profile{Name: "alice", IDCard: testIDCard}}},
+			{"结构体指针", logrus.Fields{"profile": &profile{Name: "alice", IDCard: testIDCard}}},
+			{"字符串map", logrus.Fields{"meta": map[string]string{"id_card": testIDCard}}},
+		} {
+			buf.Reset()
+			logger.WithFields(c.fields).Info("hello")
+			out := buf.String()
+			if out == "" {
+				t.Fatalf("%T %s: 没有输出", formatter, c.name)
+			}
+			if strings.Contains(out, testPhone) || strings.Contains(out, testIDCard) {
+				t.Errorf("%T %s: 输出包含明文: %s", formatter, c.name, out)
+			}
+			if !strings.Contains(out, "enc:") && !strings.Contains(out, "hmac:") {
+				t.Errorf("%T %s: 输出中没有脱敏值: %s", formatter, c.name, out)
+			}
+		}
+	}
+}
+
+// TestRedactValues 测试未配置的字段保持不变，加密的值可以还原，调用方的Fields不受影响
+func TestRedactValues(t *testing.T) {
+	logger, redactor, buf := newTestLogger(t, &logrus.JSONFormatter{})
+	user := map[string]interface{}{"name": "alice", "phone": testPhone}
+	fields := logrus.Fields{"phone": testPhone, "city": "beijing", "user": user, "tags": []string{"a"}}
+	logger.WithFields(fields).Info("hello")
+
+	var entry map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("解析日志失败: %v\n%s", err, buf.String())
+	}
+	if entry["city"] != "beijing" {
+		t.Errorf("未配置的字段不应改变: %v", entry["city"])
+	}
+	if tags, _ := entry["tags"].([]interface{}); len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("无需脱敏的切片不应改变: %v", entry["tags"])
+	}
+	if revealed, err := redactor.Reveal(entry["phone"].(string)); err != nil || revealed != testPhone {
+		t.Errorf("顶层字段还原失败: %v %q", err, revealed)
+	}
+	nested, _ := entry["user"].(map[string]interface{})
+	if nested["name"] != "alice" {
+		t.Errorf("嵌套的其他字段不应改变: %v", entry["user"])
+	}
+	if revealed, err := redactor.Reveal(nested["phone"].(string)); err != nil || revealed != testPhone {
+		t.Errorf("嵌套字段还原失败: %v %q", err, revealed)
+	}
+
+	if fields["phone"] != testPhone || user["phone"] != testPhone {
+		t.Error("脱敏不应修改调用方的Fields")
+	}
+}
+
+// TestRedactLevels 测试只处理指定级别
+func TestRedactLevels(t *testing.T) {
+	logger, redactor, buf := newTestLogger(t, &logrus.JSONFormatter{})
+	logger.ReplaceHooks(make(logrus.LevelHooks))
+	logger.AddHook(NewHook(redactor).WithLevels(logrus.WarnLevel))
+
+	logger.WithField("phone", testPhone).Warn("hello")
+	if strings.Contains(buf.String(), testPhone) {
+		t.Errorf("指定级别的字段应脱敏: %s", buf.String())
+	}
+	buf.Reset()
+	logger.WithField("phone", testPhone).Info("hello")
+	if !strings.Contains(buf.String(), testPhone) {
+		t.Errorf("未指定的级别不应处理: %s", buf.String())
+	}
+}
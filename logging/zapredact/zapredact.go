@@ -0,0 +1,138 @@
+// Package zapredact zap日志的敏感字段脱敏
+//
+// 包装zapcore.Core，在日志写出前对encrypt.LogRedactor中配置的字段做哈希或确定性加密：
+//
+//	redactor, _ := encrypt.NewLogRedactor(key)
+//	redactor.Encrypt("phone").Hash("id_card")
+//	logger := zap.New(core, zapredact.Wrap(redactor))
+//
+// 按字段名匹配，logger.With添加的字段同样处理；配置的字段为zap.Object等复合字段时整体按字符串形式脱敏，
+// 未配置的复合字段展开后用encrypt.LogRedactor.RedactValue处理其中嵌套的字段。
+// 作为独立子模块发布，不使用zap时主模块无需引入zap
+package zapredact
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sylphbyte/encrypt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// core 脱敏Core
+type core struct {
+	zapcore.Core
+	redactor *encrypt.LogRedactor
+}
+
+// NewCore 包装Core
+func NewCore(next zapcore.Core, redactor *encrypt.LogRedactor) zapcore.Core {
+	return &core{Core: next, redactor: redactor}
+}
+
+// Wrap 返回zap.Option，用于zap.New或logger.WithOptions
+func Wrap(redactor *encrypt.LogRedactor) zap.Option {
+	return zap.WrapCore(func(next zapcore.Core) zapcore.Core {
+		return NewCore(next, redactor)
+	})
+}
+
+// With 添加上下文字段
+func (c *core) With(fields []zapcore.Field) zapcore.Core {
+	return &core{Core: c.Core.With(c.redact(fields)), redactor: c.redactor}
+}
+
+// Check 必须把自身加入CheckedEntry，否则Write会绕过脱敏直接调用内层Core
+func (c *core) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return checked.AddCore(entry, c)
+	}
+	return checked
+}
+
+// Write 脱敏后写出
+func (c *core) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(entry, c.redact(fields))
+}
+
+// redact 替换需要脱敏的字段，没有需要处理的字段时不复制
+func (c *core) redact(fields []zapcore.Field) []zapcore.Field {
+	out, copied := fields, false
+	for i, field := range fields {
+		redacted, ok := c.redactField(field)
+		if !ok {
+			continue
+		}
+		if !copied {
+			out, copied = append([]zapcore.Field(nil), fields...), true
+		}
+		out[i] = redacted
+	}
+	return out
+}
+
+// redactField 脱敏单个字段，配置的字段整体替换，未配置的复合字段处理其中嵌套的字段
+func (c *core) redactField(field zapcore.Field) (zapcore.Field, bool) {
+	if c.redactor.Mode(field.Key) != 0 {
+		value, ok := fieldString(field)
+		if !ok {
+			return field, false
+		}
+		redacted, _ := c.redactor.Redact(field.Key, value)
+		return zap.String(field.Key, redacted), true
+	}
+
+	switch field.Type {
+	case zapcore.ObjectMarshalerType, zapcore.ArrayMarshalerType, zapcore.ReflectType:
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		value, changed := c.redactor.RedactValue(enc.Fields[field.Key])
+		if !changed {
+			return field, false
+		}
+		return zap.Any(field.Key, value), true
+	case zapcore.InlineMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		field.AddTo(enc)
+		value, changed := c.redactor.RedactValue(enc.Fields)
+		if !changed {
+			return field, false
+		}
+		return zap.Inline(fieldMap(value.(map[string]interface{}))), true
+	}
+	return field, false
+}
+
+// fieldMap 按键名顺序输出的对象，用于替换脱敏后的内联字段
+type fieldMap map[string]interface{}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler
+func (m fieldMap) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		zap.Any(key, m[key]).AddTo(enc)
+	}
+	return nil
+}
+
+// fieldString 获取字段值的字符串形式
+func fieldString(field zapcore.Field) (string, bool) {
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String, true
+	case zapcore.SkipType, zapcore.NamespaceType:
+		return "", false
+	}
+	enc := zapcore.NewMapObjectEncoder()
+	field.AddTo(enc)
+	value, ok := enc.Fields[field.Key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(value), true
+}
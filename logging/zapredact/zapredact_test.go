@@ -0,0 +1,173 @@
+package zapredact
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	testPhone  = "13800138000"
+	testIDCard = "110101199003074514"
+)
+
+// user 实现zapcore.ObjectMarshaler的测试类型
+type user struct {
+	Name  string `json:"name"`
+	Phone string `json:"phone"`
+}
+
+func (u user) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", u.Name)
+	enc.AddString("phone", u.Phone)
+	return nil
+}
+
+// users 实现zapcore.ArrayMarshaler的测试类型
+type users []user
+
+func (us users) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, u := range us {
+		if err := enc.AppendObject(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reflected 通过zap.Any按反射输出的测试类型
+type reflected struct {
+	Profile struct {
+		IDCard string `json:"id_card"`
+	} `json:"profile"`
+}
+
+// newTestLogger 创建输出JSON到缓冲区的脱敏logger
+func newTestLogger(t *testing.T) (*zap.Logger, *encrypt.LogRedactor, *bytes.Buffer) {
+	t.Helper()
+	key, err := encrypt.GenerateRandomBytes(32)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	redactor, err := encrypt.NewLogRedactor(key)
+	if err != nil {
+		t.Fatalf("创建脱敏器失败: %v", err)
+	}
+	redactor.Encrypt("phone").Hash("id_card")
+
+	buf := &bytes.Buffer{}
+	encoder := zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig())
+	core := zapcore.NewCore(encoder, zapcore.AddSync(buf), zapcore.DebugLevel)
+	return zap.New(core, Wrap(redactor)), redactor, buf
+}
+
+// decodeLine 解析一行JSON日志
+func decodeLine(t *testing.T, line string) map[string]interface{} {
+	t.Helper()
+	var entry map[string]interface{}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		t.Fatalf("解析日志失败: %v\n%s", err, line)
+	}
+	return entry
+}
+
+// TestRedactFields 测试顶层与嵌套字段在输出中不出现明文
+func TestRedactFields(t *testing.T) {
+	logger, redactor, buf := newTestLogger(t)
+	u := user{Name: "alice", Phone: testPhone}
+	var r reflected
+	r.Profile.IDCard = testIDCard
+
+	for _, c := range []struct {
+		name   string
+		fields []zap.Field
+	}{
+		{"顶层字符串", []zap.Field{zap.String("phone", testPhone)}},
+		{"顶层非字符串", []zap.Field{zap.Int64("ID_Card", 110101199003074514)}},
+		{"配置的复合字段", []zap.Field{zap.Object("phone", u)}},
+		{"嵌套对象", []zap.Field{zap.Object("user", u)}},
+		{"对象数组", []zap.Field{zap.Array("users", users{u, u})}},
+		{"内联对象", []zap.Field{zap.Inline(u)}},
+		{"反射结构体", []zap.Field{zap.Any("meta", r)}},
+		{"反射map", []zap.Field{zap.Any("meta", map[string]string{"id_card": testIDCard})}},
+		{"命名空间", []zap.Field{zap.Namespace("user"), zap.String("phone", testPhone)}},
+	} {
+		buf.Reset()
+		logger.Info("hello", c.fields...)
+		out := buf.String()
+		if out == "" {
+			t.Fatalf("%s: 没有输出", c.name)
+		}
+		if strings.Contains(out, testPhone) || strings.Contains(out, testIDCard) {
+			t.Errorf("%s: 输出包含明文: %s", c.name, out)
+		}
+		if !strings.Contains(out, "enc:") && !strings.Contains(out, "hmac:") {
+			t.Errorf("%s: 输出中没有脱敏值: %s", c.name, out)
+		}
+	}
+
+	// 未配置的字段与嵌套的其他字段保持不变，加密的值可以还原
+	buf.Reset()
+	logger.Info("hello", zap.String("city", "beijing"), zap.Object("user", u))
+	entry := decodeLine(t, buf.String())
+	if entry["city"] != "beijing" {
+		t.Errorf("未配置的字段不应改变: %v", entry["city"])
+	}
+	nested, _ := entry["user"].(map[string]interface{})
+	if nested["name"] != "alice" {
+		t.Errorf("嵌套的其他字段不应改变: %v", entry["user"])
+	}
+	sealed, _ := nested["phone"].(string)
+	if revealed, err := redactor.Reveal(sealed); err != nil || revealed != testPhone {
+		t.Errorf("嵌套字段还原失败: %v %q", err, revealed)
+	}
+
+	// 没有需要脱敏的嵌套字段时原样输出
+	buf.Reset()
+	logger.Info("hello", zap.Any("meta", map[string]string{"city": "beijing"}), zap.Strings("tags", []string{"a"}))
+	entry = decodeLine(t, buf.String())
+	if nested, _ := entry["meta"].(map[string]interface{}); nested["city"] != "beijing" {
+		t.Errorf("无需脱敏的对象输出不正确: %v", entry["meta"])
+	}
+	if tags, _ := entry["tags"].([]interface{}); len(tags) != 1 || tags[0] != "a" {
+		t.Errorf("无需脱敏的数组输出不正确: %v", entry["tags"])
+	}
+}
+
+// TestRedactWith 测试logger.With添加的字段
+func TestRedactWith(t *testing.T) {
+	logger, redactor, buf := newTestLogger(t)
+	logger.With(zap.String("phone", testPhone), zap.Object("user", user{Phone: testPhone})).Info("hello")
+
+	out := buf.String()
+	if strings.Contains(out, testPhone) {
+		t.Fatalf("With添加的字段包含明文: %s", out)
+	}
+	entry := decodeLine(t, out)
+	if revealed, err := redactor.Reveal(entry["phone"].(string)); err != nil || revealed != testPhone {
+		t.Errorf("顶层字段还原失败: %v %q", err, revealed)
+	}
+	nested, _ := entry["user"].(map[string]interface{})
+	if revealed, err := redactor.Reveal(nested["phone"].(string)); err != nil || revealed != testPhone {
+		t.Errorf("嵌套字段还原失败: %v %q", err, revealed)
+	}
+}
+
+// TestRedactLevel 测试未启用的级别不输出
+func TestRedactLevel(t *testing.T) {
+	logger, _, buf := newTestLogger(t)
+	logger = logger.WithOptions(zap.IncreaseLevel(zapcore.WarnLevel))
+	logger.Info("hello", zap.String("phone", testPhone))
+	if buf.Len() != 0 {
+		t.Errorf("未启用的级别不应输出: %s", buf.String())
+	}
+	logger.Warn("hello", zap.String("phone", testPhone))
+	if buf.Len() == 0 || strings.Contains(buf.String(), testPhone) {
+		t.Errorf("输出不正确: %s", buf.String())
+	}
+}
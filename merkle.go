@@ -0,0 +1,128 @@
+package encrypt
+
+import (
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+)
+
+// Merkle树（审计日志防篡改）
+//
+// 叶子与内部节点使用不同前缀区分（RFC 6962）：
+//
+//	叶子哈希 = H(0x00 || 数据)
+//	节点哈希 = H(0x01 || 左 || 右)
+//
+// 某层节点数为奇数时，最后一个节点直接提升到上一层，不与自身拼接，
+// 避免重复叶子得到相同根哈希的问题
+
+// 节点前缀
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// MerkleTree Merkle树
+type MerkleTree struct {
+	hashAlgo HashAlgorithm
+	levels   [][][]byte // levels[0]为叶子哈希，最后一层为根
+}
+
+// MerkleProofNode 证明路径上的兄弟节点
+type MerkleProofNode struct {
+	Hash []byte
+	Left bool // 兄弟节点是否位于左侧
+}
+
+// MerkleProof 叶子存在性证明
+type MerkleProof struct {
+	Index int
+	Path  []MerkleProofNode
+}
+
+// BuildMerkleTree 构建Merkle树，hashAlgo支持HashSHA256与HashSM3
+func BuildMerkleTree(leaves [][]byte, hashAlgo HashAlgorithm) (*MerkleTree, error) {
+	if len(leaves) == 0 {
+		return nil, errors.New("叶子不能为空")
+	}
+	if hashAlgo != HashSHA256 && hashAlgo != HashSM3 {
+		return nil, errors.New("Merkle树仅支持SHA-256与SM3")
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = merkleHash(hashAlgo, merkleLeafPrefix, leaf)
+	}
+
+	tree := &MerkleTree{hashAlgo: hashAlgo, levels: [][][]byte{level}}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				// 奇数个节点时提升最后一个节点
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, merkleHash(hashAlgo, merkleNodePrefix, level[i], level[i+1]))
+		}
+		tree.levels = append(tree.levels, next)
+		level = next
+	}
+	return tree, nil
+}
+
+// Root 获取根哈希
+func (t *MerkleTree) Root() []byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// LeafCount 获取叶子数量
+func (t *MerkleTree) LeafCount() int {
+	return len(t.levels[0])
+}
+
+// Proof 生成指定叶子的存在性证明
+func (t *MerkleTree) Proof(index int) (*MerkleProof, error) {
+	if index < 0 || index >= t.LeafCount() {
+		return nil, errors.New("叶子索引超出范围")
+	}
+
+	proof := &MerkleProof{Index: index}
+	pos := index
+	for _, level := range t.levels[:len(t.levels)-1] {
+		if pos%2 == 1 {
+			proof.Path = append(proof.Path, MerkleProofNode{Hash: level[pos-1], Left: true})
+		} else if pos+1 < len(level) {
+			proof.Path = append(proof.Path, MerkleProofNode{Hash: level[pos+1], Left: false})
+		}
+		pos /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof 使用根哈希验证叶子数据的存在性证明
+func VerifyMerkleProof(root, leaf []byte, proof *MerkleProof, hashAlgo HashAlgorithm) bool {
+	if proof == nil {
+		return false
+	}
+
+	current := merkleHash(hashAlgo, merkleLeafPrefix, leaf)
+	for _, node := range proof.Path {
+		if node.Left {
+			current = merkleHash(hashAlgo, merkleNodePrefix, node.Hash, current)
+		} else {
+			current = merkleHash(hashAlgo, merkleNodePrefix, current, node.Hash)
+		}
+	}
+	return subtle.ConstantTimeCompare(current, root) == 1
+}
+
+// merkleHash 计算带前缀的哈希
+func merkleHash(hashAlgo HashAlgorithm, prefix byte, parts ...[]byte) []byte {
+	h := hashFunc(hashAlgo)()
+	h.Write([]byte{prefix})
+	for _, part := range parts {
+		h.Write(part)
+	}
+	return h.Sum(nil)
+}
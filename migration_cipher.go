@@ -0,0 +1,126 @@
+package encrypt
+
+import (
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+)
+
+// 算法迁移（双写）
+//
+// MigrationCipher 包装新旧两个加密器，用于不停机地更换算法或密钥：
+//   - 写入：总是使用新加密器；启用WithDualWrite后同时用旧加密器加密一份交给回调，
+//     供迁移期间仍在读取旧字段的旧版本服务使用
+//   - 读取：先用新加密器解密，失败后再用旧加密器，命中旧格式时计数并调用OnOldFormat回调，
+//     可据此触发回写或观察旧数据的存量
+//
+// 新加密器应使用带认证的模式（GCM等），否则旧格式密文可能被新加密器"成功"解密出错误的明文。
+// 旧格式命中数降为0且保持一段时间后，即可移除旧加密器
+
+// MigrationCipher 新旧双加密器
+type MigrationCipher struct {
+	current ICipher
+	legacy  ICipher
+
+	dualWrite   func(legacy []byte) error
+	onOldFormat func(ciphertext []byte)
+
+	newHits    int64 // 新格式解密次数
+	oldHits    int64 // 旧格式解密次数
+	failures   int64 // 新旧均解密失败的次数
+	dualWrites int64 // 双写次数
+}
+
+// NewMigrationCipher 创建迁移加密器，current为新加密器，legacy为旧加密器
+func NewMigrationCipher(current, legacy ICipher) *MigrationCipher {
+	if current == nil || legacy == nil {
+		panic("新旧加密器都不能为空")
+	}
+	return &MigrationCipher{current: current, legacy: legacy}
+}
+
+// WithDualWrite 启用双写，每次Encrypt都会把旧格式密文交给fn，fn返回错误时Encrypt失败
+func (m *MigrationCipher) WithDualWrite(fn func(legacy []byte) error) *MigrationCipher {
+	m.dualWrite = fn
+	return m
+}
+
+// OnOldFormat 设置命中旧格式时的回调，回调在解密的调用协程中同步执行
+func (m *MigrationCipher) OnOldFormat(fn func(ciphertext []byte)) *MigrationCipher {
+	m.onOldFormat = fn
+	return m
+}
+
+// Encrypt 使用新加密器加密，启用双写时同时输出旧格式密文
+func (m *MigrationCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	if m.dualWrite == nil {
+		return m.current.Encrypt(plaintext)
+	}
+
+	current, legacy, err := m.EncryptBoth(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.dualWrite(legacy); err != nil {
+		return nil, errors.Wrap(err, "双写旧格式失败")
+	}
+	return current, nil
+}
+
+// EncryptBoth 同时输出新旧两种格式的密文
+func (m *MigrationCipher) EncryptBoth(plaintext []byte) (current, legacy []byte, err error) {
+	if current, err = m.current.Encrypt(plaintext); err != nil {
+		return nil, nil, err
+	}
+	if legacy, err = m.legacy.Encrypt(plaintext); err != nil {
+		return nil, nil, errors.Wrap(err, "旧加密器加密失败")
+	}
+	atomic.AddInt64(&m.dualWrites, 1)
+	return current, legacy, nil
+}
+
+// Decrypt 先用新加密器解密，失败后再尝试旧加密器
+func (m *MigrationCipher) Decrypt(ciphertext []byte) ([]byte, error) {
+	plaintext, _, err := m.DecryptWithFormat(ciphertext)
+	return plaintext, err
+}
+
+// DecryptWithFormat 解密并返回密文是否为旧格式，调用方可据此用Encrypt重新加密回写
+func (m *MigrationCipher) DecryptWithFormat(ciphertext []byte) ([]byte, bool, error) {
+	plaintext, currentErr := m.current.Decrypt(ciphertext)
+	if currentErr == nil {
+		atomic.AddInt64(&m.newHits, 1)
+		return plaintext, false, nil
+	}
+
+	plaintext, err := m.legacy.Decrypt(ciphertext)
+	if err != nil {
+		atomic.AddInt64(&m.failures, 1)
+		return nil, false, errors.Wrapf(currentErr, "新旧格式均解密失败（旧格式: %v）", err)
+	}
+	atomic.AddInt64(&m.oldHits, 1)
+	if m.onOldFormat != nil {
+		m.onOldFormat(ciphertext)
+	}
+	return plaintext, true, nil
+}
+
+// EncryptString 加密字符串，返回新格式密文字符串
+func (m *MigrationCipher) EncryptString(plaintext string) (string, error) {
+	return applyString(m.Encrypt, plaintext)
+}
+
+// DecryptString 解密新格式或旧格式的密文字符串
+func (m *MigrationCipher) DecryptString(ciphertext string) (string, error) {
+	return applyString(m.Decrypt, ciphertext)
+}
+
+// GetMetrics 获取迁移指标：new_hits、old_hits、failures、dual_writes
+func (m *MigrationCipher) GetMetrics() map[string]int64 {
+	return map[string]int64{
+		"new_hits":    atomic.LoadInt64(&m.newHits),
+		"old_hits":    atomic.LoadInt64(&m.oldHits),
+		"failures":    atomic.LoadInt64(&m.failures),
+		"dual_writes": atomic.LoadInt64(&m.dualWrites),
+	}
+}
@@ -2,8 +2,6 @@ package encrypt
 
 import (
 	"crypto/cipher"
-	"crypto/rand"
-	"io"
 
 	"github.com/pkg/errors"
 )
@@ -500,7 +498,7 @@ func (g *GCMMode) Encrypt(block cipher.Block, data []byte) ([]byte, error) {
 	// 从对象池获取nonce缓冲区
 	nonceSize := gcm.NonceSize()
 	nonceBuf := GetBuffer(nonceSize)
-	if _, err := io.ReadFull(rand.Reader, nonceBuf); err != nil {
+	if err := readNonce(nonceBuf); err != nil {
 		PutBuffer(nonceBuf) // 出错时释放缓冲区
 		return nil, errors.Wrap(err, "生成随机nonce失败")
 	}
@@ -0,0 +1,187 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"encoding/base64"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 支持轮换的HMAC
+//
+// 令牌、回调签名等场景下，密钥轮换后仍有大量用旧密钥签发、尚未过期的数据在途。
+// MultiKeyHMAC始终用当前密钥签名，验证时按输出中的密钥ID选择当前或保留的旧密钥，
+// 旧密钥超出保留数量后自动淘汰并清零。输出格式：
+//
+//	keyID:base64url(HMAC)
+
+// ErrInvalidMAC MAC校验失败
+var ErrInvalidMAC = errors.New("MAC校验失败")
+
+// multiKeyHMACSeparator 密钥ID与MAC的分隔符
+const multiKeyHMACSeparator = ":"
+
+// DefaultHMACPreviousKeys 默认保留的旧密钥数量
+const DefaultHMACPreviousKeys = 2
+
+// hmacKey 带ID的HMAC密钥
+type hmacKey struct {
+	id  string
+	key []byte
+}
+
+// MultiKeyHMAC 支持密钥轮换的HMAC，并发安全
+type MultiKeyHMAC struct {
+	mu       sync.RWMutex
+	hashAlgo HashAlgorithm
+	keys     []hmacKey // keys[0]为当前密钥，其后按从新到旧排列
+	previous int
+}
+
+// NewMultiKeyHMAC 使用当前密钥创建，默认HMAC-SHA256，保留2把旧密钥
+func NewMultiKeyHMAC(id string, key []byte) (*MultiKeyHMAC, error) {
+	if err := checkHMACKey(id, key); err != nil {
+		return nil, err
+	}
+	return &MultiKeyHMAC{
+		hashAlgo: HashSHA256,
+		keys:     []hmacKey{{id: id, key: append([]byte(nil), key...)}},
+		previous: DefaultHMACPreviousKeys,
+	}, nil
+}
+
+// WithHash 设置哈希算法，对所有密钥生效
+func (m *MultiKeyHMAC) WithHash(hashAlgo HashAlgorithm) *MultiKeyHMAC {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hashAlgo = hashAlgo
+	return m
+}
+
+// KeepPrevious 设置保留的旧密钥数量，0表示轮换后旧签名立即失效
+func (m *MultiKeyHMAC) KeepPrevious(n int) *MultiKeyHMAC {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	m.previous = n
+	m.trim()
+	return m
+}
+
+// Rotate 切换到新的当前密钥，原当前密钥转为旧密钥继续用于验证
+func (m *MultiKeyHMAC) Rotate(id string, key []byte) error {
+	if err := checkHMACKey(id, key); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, k := range m.keys {
+		if k.id == id {
+			return errors.Errorf("密钥ID已存在: %s", id)
+		}
+	}
+	m.keys = append([]hmacKey{{id: id, key: append([]byte(nil), key...)}}, m.keys...)
+	m.trim()
+	return nil
+}
+
+// Retire 提前淘汰旧密钥，如密钥泄露时。当前密钥不能淘汰
+func (m *MultiKeyHMAC) Retire(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := 1; i < len(m.keys); i++ {
+		if m.keys[i].id == id {
+			zeroBytes(m.keys[i].key)
+			m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			return nil
+		}
+	}
+	if m.keys[0].id == id {
+		return errors.New("不能淘汰当前密钥，请先轮换")
+	}
+	return &KeyError{KeyID: id, Err: ErrKeyNotFound}
+}
+
+// CurrentID 当前密钥ID
+func (m *MultiKeyHMAC) CurrentID() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[0].id
+}
+
+// IDs 所有可用于验证的密钥ID，当前密钥在前
+func (m *MultiKeyHMAC) IDs() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	ids := make([]string, len(m.keys))
+	for i, k := range m.keys {
+		ids[i] = k.id
+	}
+	return ids
+}
+
+// Sign 使用当前密钥签名，输出带密钥ID前缀
+func (m *MultiKeyHMAC) Sign(data []byte) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	current := m.keys[0]
+	return current.id + multiKeyHMACSeparator + base64.RawURLEncoding.EncodeToString(m.sum(current.key, data))
+}
+
+// Verify 按签名中的密钥ID验证，返回签名所用的密钥ID
+// 返回的ID不是当前密钥时，调用方可以借机重新签发
+func (m *MultiKeyHMAC) Verify(data []byte, signature string) (string, error) {
+	id, encoded, ok := strings.Cut(signature, multiKeyHMACSeparator)
+	if !ok {
+		return "", errors.New("签名格式不正确，缺少密钥ID")
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", errors.Wrap(err, "签名编码不正确")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, k := range m.keys {
+		if k.id != id {
+			continue
+		}
+		if !hmac.Equal(m.sum(k.key, data), mac) {
+			return id, ErrInvalidMAC
+		}
+		return id, nil
+	}
+	return id, &KeyError{KeyID: id, Err: ErrKeyNotFound}
+}
+
+// sum 计算HMAC，调用方需持有锁
+func (m *MultiKeyHMAC) sum(key, data []byte) []byte {
+	mac := hmac.New(hashFunc(m.hashAlgo), key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// trim 淘汰超出保留数量的旧密钥，调用方需持有锁
+func (m *MultiKeyHMAC) trim() {
+	for len(m.keys) > m.previous+1 {
+		last := len(m.keys) - 1
+		zeroBytes(m.keys[last].key)
+		m.keys = m.keys[:last]
+	}
+}
+
+// checkHMACKey 检查密钥ID与密钥
+func checkHMACKey(id string, key []byte) error {
+	if id == "" || strings.Contains(id, multiKeyHMACSeparator) {
+		return errors.New("密钥ID不能为空且不能包含冒号")
+	}
+	if len(key) < 16 {
+		return errors.New("HMAC密钥长度至少16字节")
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	stdx509 "crypto/x509"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 多接收方信封
+//
+// 同一份数据需要发给多个成员（不同部门的RSA证书、国密SM2证书、X25519设备密钥）时，
+// 不必为每个成员各加密一份：数据只用随机数据密钥加密一次，数据密钥分别用每个接收方的公钥包装：
+//
+//	magic(2) | version(1) | count(2) | 接收方... | AES-256-GCM(数据密钥, 明文)
+//	接收方 = 类型(1) | 公钥指纹(32) | len(2) | 包装后的数据密钥
+//
+// 包装方式按公钥类型选择：
+//   - RSA：RSA-OAEP-SHA256，标签为格式上下文
+//   - SM2：SM2公钥加密（ASN.1）
+//   - X25519：临时密钥协商，经HKDF-SHA256派生包装密钥，输出 临时公钥(32) | AES-GCM(数据密钥)
+//
+// 指纹为公钥DER的SHA-256，解密时按私钥对应的指纹找到自己的条目。整个头部参与数据密文的认证，
+// 外部无法增删接收方；但任一接收方都持有数据密钥，可以重新构造密文，需要确认发送方时应另行签名
+
+// 多接收方信封格式常量
+const (
+	recipientsVersion       = 1
+	recipientsMaxCount      = 0xffff
+	recipientsDataKeySize   = 32
+	recipientFingerprintLen = sha256.Size
+	recipientsInfo          = "sylphbyte/encrypt recipients v1"
+)
+
+// recipientsMagic 多接收方信封魔数
+var recipientsMagic = []byte{0x53, 0x52}
+
+// 接收方公钥类型
+const (
+	recipientRSA    = 1
+	recipientSM2    = 2
+	recipientX25519 = 3
+)
+
+// Recipient 接收方
+type Recipient struct {
+	PublicKey crypto.PublicKey // *rsa.PublicKey、*sm2.PublicKey或X25519的*ecdh.PublicKey
+}
+
+// RecipientInfo 信封中的接收方信息
+type RecipientInfo struct {
+	Algorithm   string // RSA、SM2或X25519
+	Fingerprint string // 公钥DER的SHA-256，十六进制
+}
+
+// NewRecipientPEM 从PEM编码的公钥创建接收方，支持RSA、SM2与X25519（PKIX）
+func NewRecipientPEM(publicKeyPEM []byte) (Recipient, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return Recipient{}, err
+	}
+	return Recipient{PublicKey: publicKey}, nil
+}
+
+// NewBoxRecipient 从GenerateBoxKeyPair生成的32字节X25519公钥创建接收方
+func NewBoxRecipient(publicKey []byte) (Recipient, error) {
+	pub, err := ecdh.X25519().NewPublicKey(publicKey)
+	if err != nil {
+		return Recipient{}, errors.Wrap(err, "X25519公钥格式不正确")
+	}
+	return Recipient{PublicKey: pub}, nil
+}
+
+// SealForRecipients 加密一次，任一接收方都可以用自己的私钥解密，aad为调用方的附加认证数据
+func SealForRecipients(plaintext, aad []byte, recipients ...Recipient) ([]byte, error) {
+	if len(recipients) == 0 || len(recipients) > recipientsMaxCount {
+		return nil, errors.New("接收方数量必须在1到65535之间")
+	}
+
+	dataKey, err := GenerateRandomKey(recipientsDataKeySize)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(dataKey)
+
+	header := append([]byte(nil), recipientsMagic...)
+	header = append(header, recipientsVersion)
+	header = binary.BigEndian.AppendUint16(header, uint16(len(recipients)))
+	seen := make(map[string]bool, len(recipients))
+	for i, recipient := range recipients {
+		typ, fp, err := recipientIdentity(recipient.PublicKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "第%d个接收方", i+1)
+		}
+		if seen[string(fp)] {
+			return nil, errors.Errorf("第%d个接收方重复", i+1)
+		}
+		seen[string(fp)] = true
+
+		wrapped, err := wrapForRecipient(typ, recipient.PublicKey, fp, dataKey)
+		if err != nil {
+			return nil, errors.Wrapf(err, "第%d个接收方", i+1)
+		}
+		if len(wrapped) > 0xffff {
+			return nil, errors.New("包装后的数据密钥过长")
+		}
+		header = append(header, typ)
+		header = append(header, fp...)
+		header = binary.BigEndian.AppendUint16(header, uint16(len(wrapped)))
+		header = append(header, wrapped...)
+	}
+
+	ciphertext, err := AESGCMEncrypt(dataKey, plaintext, recipientsAAD(header, aad))
+	if err != nil {
+		return nil, err
+	}
+	return append(header, ciphertext...), nil
+}
+
+// OpenForRecipient 使用接收方私钥解密，privateKey为*rsa.PrivateKey、*sm2.PrivateKey或*ecdh.PrivateKey
+func OpenForRecipient(data, aad []byte, privateKey crypto.PrivateKey) ([]byte, error) {
+	holder, ok := privateKey.(interface{ Public() crypto.PublicKey })
+	if !ok {
+		return nil, errors.New("不支持的私钥类型")
+	}
+	typ, fp, err := recipientIdentity(holder.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	entries, headerLen, err := parseRecipients(data)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.typ != typ || !bytes.Equal(entry.fingerprint, fp) {
+			continue
+		}
+		dataKey, err := unwrapForRecipient(typ, privateKey, fp, entry.wrapped)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroBytes(dataKey)
+		return AESGCMDecrypt(dataKey, data[headerLen:], recipientsAAD(data[:headerLen], aad))
+	}
+	return nil, errors.New("私钥不属于任何接收方")
+}
+
+// OpenForRecipientPEM 使用PEM编码的私钥解密
+func OpenForRecipientPEM(data, aad, privateKeyPEM []byte) ([]byte, error) {
+	// X25519私钥（PKCS#8）不在通用私钥解析的支持范围内，单独处理
+	if block, _ := pem.Decode(privateKeyPEM); block != nil && block.Type == "PRIVATE KEY" {
+		if key, err := stdx509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			if priv, ok := key.(*ecdh.PrivateKey); ok {
+				return OpenForRecipient(data, aad, priv)
+			}
+		}
+	}
+	privateKey, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return OpenForRecipient(data, aad, privateKey)
+}
+
+// ListRecipients 列出信封中的接收方，不解密
+func ListRecipients(data []byte) ([]RecipientInfo, error) {
+	entries, _, err := parseRecipients(data)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]RecipientInfo, len(entries))
+	for i, entry := range entries {
+		infos[i] = RecipientInfo{Algorithm: recipientTypeName(entry.typ), Fingerprint: hex.EncodeToString(entry.fingerprint)}
+	}
+	return infos, nil
+}
+
+// recipientEntry 解析后的接收方条目
+type recipientEntry struct {
+	typ         byte
+	fingerprint []byte
+	wrapped     []byte
+}
+
+// parseRecipients 解析头部，返回接收方条目与头部长度
+func parseRecipients(data []byte) ([]recipientEntry, int, error) {
+	if len(data) < 5 || !bytes.Equal(data[:2], recipientsMagic) {
+		return nil, 0, errors.New("不是多接收方信封")
+	}
+	if data[2] != recipientsVersion {
+		return nil, 0, errors.Errorf("不支持的多接收方信封版本: %d", data[2])
+	}
+
+	count := int(binary.BigEndian.Uint16(data[3:]))
+	entries := make([]recipientEntry, 0, count)
+	pos := 5
+	for i := 0; i < count; i++ {
+		if len(data) < pos+1+recipientFingerprintLen+2 {
+			return nil, 0, errors.New("多接收方信封头部不完整")
+		}
+		entry := recipientEntry{typ: data[pos], fingerprint: data[pos+1 : pos+1+recipientFingerprintLen]}
+		pos += 1 + recipientFingerprintLen
+		length := int(binary.BigEndian.Uint16(data[pos:]))
+		pos += 2
+		if len(data) < pos+length {
+			return nil, 0, errors.New("多接收方信封头部不完整")
+		}
+		entry.wrapped = data[pos : pos+length]
+		pos += length
+		entries = append(entries, entry)
+	}
+	return entries, pos, nil
+}
+
+// recipientIdentity 公钥类型与指纹
+func recipientIdentity(publicKey crypto.PublicKey) (byte, []byte, error) {
+	var typ byte
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		typ = recipientRSA
+	case *sm2.PublicKey:
+		typ = recipientSM2
+	case *ecdh.PublicKey:
+		if pub.Curve() != ecdh.X25519() {
+			return 0, nil, errors.New("ECDH接收方仅支持X25519")
+		}
+		typ = recipientX25519
+	default:
+		return 0, nil, errors.New("不支持的接收方公钥类型")
+	}
+	fp, err := fingerprint(publicKey, HashSHA256)
+	if err != nil {
+		return 0, nil, err
+	}
+	return typ, fp, nil
+}
+
+// wrapForRecipient 用接收方公钥包装数据密钥
+func wrapForRecipient(typ byte, publicKey crypto.PublicKey, fp, dataKey []byte) ([]byte, error) {
+	switch typ {
+	case recipientRSA:
+		wrapped, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, publicKey.(*rsa.PublicKey), dataKey, []byte(recipientsInfo))
+		if err != nil {
+			return nil, errors.Wrap(err, "RSA包装数据密钥失败")
+		}
+		return wrapped, nil
+	case recipientSM2:
+		wrapped, err := publicKey.(*sm2.PublicKey).EncryptAsn1(dataKey, rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "SM2包装数据密钥失败")
+		}
+		return wrapped, nil
+	default:
+		ephemeral, err := ecdh.X25519().GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, errors.Wrap(err, "生成临时密钥失败")
+		}
+		kek, err := recipientX25519Key(ephemeral, publicKey.(*ecdh.PublicKey), ephemeral.PublicKey().Bytes(), fp)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroBytes(kek)
+		wrapped, err := AESGCMEncrypt(kek, dataKey, nil)
+		if err != nil {
+			return nil, err
+		}
+		return append(ephemeral.PublicKey().Bytes(), wrapped...), nil
+	}
+}
+
+// unwrapForRecipient 用接收方私钥解包数据密钥
+func unwrapForRecipient(typ byte, privateKey crypto.PrivateKey, fp, wrapped []byte) ([]byte, error) {
+	switch typ {
+	case recipientRSA:
+		dataKey, err := rsa.DecryptOAEP(sha256.New(), nil, privateKey.(*rsa.PrivateKey), wrapped, []byte(recipientsInfo))
+		if err != nil {
+			return nil, errors.New("解包数据密钥失败")
+		}
+		return dataKey, nil
+	case recipientSM2:
+		dataKey, err := privateKey.(*sm2.PrivateKey).DecryptAsn1(wrapped)
+		if err != nil {
+			return nil, errors.New("解包数据密钥失败")
+		}
+		return dataKey, nil
+	default:
+		if len(wrapped) < BoxKeySize {
+			return nil, errors.New("解包数据密钥失败")
+		}
+		ephemeral, err := ecdh.X25519().NewPublicKey(wrapped[:BoxKeySize])
+		if err != nil {
+			return nil, errors.Wrap(err, "临时公钥格式不正确")
+		}
+		kek, err := recipientX25519Key(privateKey.(*ecdh.PrivateKey), ephemeral, wrapped[:BoxKeySize], fp)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroBytes(kek)
+		return AESGCMDecrypt(kek, wrapped[BoxKeySize:], nil)
+	}
+}
+
+// recipientX25519Key 协商共享密钥并派生包装密钥，临时公钥与接收方指纹参与派生
+func recipientX25519Key(priv *ecdh.PrivateKey, peer *ecdh.PublicKey, ephemeralPublicKey, fp []byte) ([]byte, error) {
+	shared, err := priv.ECDH(peer)
+	if err != nil {
+		return nil, errors.Wrap(err, "密钥协商失败")
+	}
+	defer zeroBytes(shared)
+
+	info := append([]byte(recipientsInfo), ephemeralPublicKey...)
+	info = append(info, fp...)
+	key, err := hkdf.Key(sha256.New, shared, nil, string(info), recipientsDataKeySize)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生密钥失败")
+	}
+	return key, nil
+}
+
+// recipientsAAD 头部与调用方的附加认证数据
+func recipientsAAD(header, aad []byte) []byte {
+	out := make([]byte, 0, len(header)+len(aad))
+	out = append(out, header...)
+	return append(out, aad...)
+}
+
+// recipientTypeName 接收方类型名称
+func recipientTypeName(typ byte) string {
+	switch typ {
+	case recipientRSA:
+		return "RSA"
+	case recipientSM2:
+		return "SM2"
+	case recipientX25519:
+		return "X25519"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,151 @@
+package encrypt
+
+import (
+	"crypto"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 多签名与副署
+//
+// 同一签名文件可以由多个签名者分别对同一摘要签名（AddSignature），
+// 验证时按策略要求全部、任意一个或至少N个指定公钥签名有效，用于双人复核等发布审批流程。
+// 副署（Countersign）是对另一个签名者签名的签名，证明副署者认可了该签名本身，
+// 例如审批人在构建系统签名后再副署
+
+// Countersign 对targetPublicKeyPEM对应签名者的签名进行副署
+func (d *DetachedSignature) Countersign(signer crypto.Signer, targetPublicKeyPEM []byte) error {
+	targetFingerprint, err := pemKeyFingerprint(targetPublicKeyPEM)
+	if err != nil {
+		return err
+	}
+	if _, ok := d.primarySignature(targetFingerprint); !ok {
+		return errors.New("签名文件中没有被副署者的签名")
+	}
+
+	fp, err := fingerprint(signer.Public(), HashSHA256)
+	if err != nil {
+		return err
+	}
+
+	record := SignatureRecord{
+		KeyFingerprint: hex.EncodeToString(fp),
+		SignedAt:       time.Now().UTC().Truncate(time.Second),
+		Countersigns:   targetFingerprint,
+	}
+	if record.Algorithm, err = signatureAlgorithmName(signer.Public()); err != nil {
+		return err
+	}
+
+	raw, err := signMessage(signer, d.signingInput(record))
+	if err != nil {
+		return err
+	}
+	record.Signature = base64.StdEncoding.EncodeToString(raw)
+
+	d.Signatures = append(d.Signatures, record)
+	return nil
+}
+
+// VerifyCountersignature 验证副署签名，同时验证被副署的签名
+func (d *DetachedSignature) VerifyCountersignature(counterPublicKeyPEM, targetPublicKeyPEM []byte) error {
+	target, err := parsePublicKeyPEM(targetPublicKeyPEM)
+	if err != nil {
+		return err
+	}
+	if err := d.verifySigner(target); err != nil {
+		return errors.Wrap(err, "被副署的签名无效")
+	}
+
+	counter, err := parsePublicKeyPEM(counterPublicKeyPEM)
+	if err != nil {
+		return err
+	}
+	counterFingerprint, err := fingerprint(counter, HashSHA256)
+	if err != nil {
+		return err
+	}
+	targetFingerprint, err := fingerprint(target, HashSHA256)
+	if err != nil {
+		return err
+	}
+
+	for _, record := range d.Signatures {
+		if record.KeyFingerprint != hex.EncodeToString(counterFingerprint) ||
+			record.Countersigns != hex.EncodeToString(targetFingerprint) {
+			continue
+		}
+
+		raw, err := base64.StdEncoding.DecodeString(record.Signature)
+		if err != nil {
+			return errors.Wrap(err, "解码副署签名失败")
+		}
+		return verifyMessage(counter, d.signingInput(record), raw)
+	}
+	return errors.New("签名文件中没有对应的副署签名")
+}
+
+// VerifyFileAll 要求所有给定公钥都对文件有有效签名
+func VerifyFileAll(path string, sig *DetachedSignature, publicKeyPEMs [][]byte) error {
+	return VerifyFileThreshold(path, sig, publicKeyPEMs, len(publicKeyPEMs))
+}
+
+// VerifyFileAny 要求给定公钥中至少一个对文件有有效签名
+func VerifyFileAny(path string, sig *DetachedSignature, publicKeyPEMs [][]byte) error {
+	return VerifyFileThreshold(path, sig, publicKeyPEMs, 1)
+}
+
+// VerifyFileThreshold 要求给定公钥中至少threshold个对文件有有效签名
+// 同一公钥重复出现只计一次
+func VerifyFileThreshold(path string, sig *DetachedSignature, publicKeyPEMs [][]byte, threshold int) error {
+	if threshold < 1 || threshold > len(publicKeyPEMs) {
+		return errors.New("签名数量要求超出公钥数量范围")
+	}
+	if err := sig.verifyDigest(path); err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(publicKeyPEMs))
+	valid := 0
+	var lastErr error
+	for _, publicKeyPEM := range publicKeyPEMs {
+		publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+		if err != nil {
+			return err
+		}
+		fp, err := fingerprint(publicKey, HashSHA256)
+		if err != nil {
+			return err
+		}
+		if seen[string(fp)] {
+			continue
+		}
+		seen[string(fp)] = true
+
+		if err := sig.verifySigner(publicKey); err != nil {
+			lastErr = err
+			continue
+		}
+		valid++
+	}
+
+	if valid < threshold {
+		if lastErr == nil {
+			lastErr = errors.New("公钥重复")
+		}
+		return errors.Wrapf(lastErr, "有效签名%d个，要求至少%d个", valid, threshold)
+	}
+	return nil
+}
+
+// pemKeyFingerprint 计算PEM公钥的十六进制SHA-256指纹
+func pemKeyFingerprint(publicKeyPEM []byte) (string, error) {
+	fp, err := PublicKeyFingerprint(publicKeyPEM, HashSHA256)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(fp), nil
+}
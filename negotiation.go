@@ -0,0 +1,223 @@
+package encrypt
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// 握手阶段的算法协商
+//
+// 双方各自声明支持的对称加密套件，Negotiator在交集中按策略选出一个，
+// 结果可以直接通过Suite.NewCipher创建加密器。默认策略按安全强度排序：
+//
+//	认证加密（GCM）优先 > 密钥长度更长 > 工作模式（CTR > CBC > CFB/OFB）> 本地列表顺序
+//
+// ECB模式与DES/3DES默认视为弱套件，不会被选中，确需兼容旧系统时使用AllowWeak
+
+// ErrNoCommonSuite 双方没有共同支持的套件
+var ErrNoCommonSuite = errors.New("没有双方共同支持的加密套件")
+
+// Suite 对称加密套件
+type Suite struct {
+	Algorithm Algorithm
+	KeySize   int // 密钥字节数
+	Mode      Mode
+	Padding   PaddingMode // 仅ECB、CBC使用，其余模式为PaddingNone
+}
+
+// NegotiationPolicy 协商策略
+type NegotiationPolicy int
+
+// 协商策略常量定义
+const (
+	// NegotiateStrongest 按安全强度选择，强度相同时按本地顺序
+	NegotiateStrongest NegotiationPolicy = iota
+	// NegotiateLocalOrder 按本地列表顺序选择（服务端偏好）
+	NegotiateLocalOrder
+	// NegotiatePeerOrder 按对端列表顺序选择（客户端偏好）
+	NegotiatePeerOrder
+)
+
+// Validate 检查套件参数是否有效
+func (s Suite) Validate() error {
+	switch s.Algorithm {
+	case AlgorithmAES:
+		if s.KeySize != 16 && s.KeySize != 24 && s.KeySize != 32 {
+			return errors.New("AES密钥长度必须是16、24或32字节")
+		}
+	case AlgorithmSM4:
+		if s.KeySize != 16 {
+			return errors.New("SM4密钥长度必须是16字节")
+		}
+	case Algorithm3DES:
+		if s.KeySize != 16 && s.KeySize != 24 {
+			return errors.New("3DES密钥长度必须是16或24字节")
+		}
+	case AlgorithmDES:
+		if s.KeySize != 8 {
+			return errors.New("DES密钥长度必须是8字节")
+		}
+	default:
+		return errors.New("不支持的对称加密算法")
+	}
+
+	if s.Mode < ModeECB || s.Mode > ModeGCM {
+		return errors.New("不支持的加密模式")
+	}
+	if s.Mode == ModeGCM && s.Algorithm != AlgorithmAES && s.Algorithm != AlgorithmSM4 {
+		return errors.New("GCM模式仅支持AES与SM4")
+	}
+	if s.Mode == ModeECB || s.Mode == ModeCBC {
+		if s.Padding != PaddingPKCS7 && s.Padding != PaddingZero && s.Padding != PaddingNone {
+			return errors.New("不支持的填充模式")
+		}
+	} else if s.Padding != PaddingNone {
+		return errors.New("流模式与GCM不使用填充")
+	}
+	return nil
+}
+
+// Weak 判断是否为弱套件（ECB模式或DES/3DES）
+func (s Suite) Weak() bool {
+	return s.Mode == ModeECB || s.Algorithm == AlgorithmDES || s.Algorithm == Algorithm3DES
+}
+
+// Options 套件对应的构造选项，填充只对ECB、CBC设置，其余模式保持加密器默认行为
+func (s Suite) Options() []Option {
+	if s.Mode == ModeECB || s.Mode == ModeCBC {
+		return []Option{WithMode(s.Mode), WithPadding(s.Padding)}
+	}
+	return []Option{WithMode(s.Mode)}
+}
+
+// NewCipher 使用协商得到的套件创建加密器，opts追加在套件选项之后（如WithEncoding）
+func (s Suite) NewCipher(key []byte, opts ...Option) (ISymmetric, error) {
+	if err := s.Validate(); err != nil {
+		return nil, err
+	}
+	if len(key) != s.KeySize {
+		return nil, errors.Errorf("密钥长度必须是%d字节", s.KeySize)
+	}
+	return newSymmetric(s.Algorithm, key, append(s.Options(), opts...)...)
+}
+
+// Negotiator 加密套件协商器
+type Negotiator struct {
+	local     []Suite
+	policy    NegotiationPolicy
+	allowWeak bool
+}
+
+// NewNegotiator 创建协商器，local为本地支持的套件，按偏好从高到低排列
+func NewNegotiator(local ...Suite) *Negotiator {
+	return &Negotiator{local: append([]Suite(nil), local...)}
+}
+
+// WithPolicy 设置协商策略，默认NegotiateStrongest
+func (n *Negotiator) WithPolicy(policy NegotiationPolicy) *Negotiator {
+	n.policy = policy
+	return n
+}
+
+// AllowWeak 允许选择弱套件，仅用于兼容旧系统
+func (n *Negotiator) AllowWeak() *Negotiator {
+	n.allowWeak = true
+	return n
+}
+
+// Supported 本地支持且允许使用的套件
+func (n *Negotiator) Supported() []Suite {
+	out := make([]Suite, 0, len(n.local))
+	for _, s := range n.local {
+		if s.Validate() == nil && (n.allowWeak || !s.Weak()) {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Negotiate 根据对端声明的套件列表选出双方都支持的套件
+func (n *Negotiator) Negotiate(peer []Suite) (Suite, error) {
+	supported := n.Supported()
+	localIndex := make(map[Suite]int, len(supported))
+	for i, s := range supported {
+		if _, ok := localIndex[s]; !ok {
+			localIndex[s] = i
+		}
+	}
+
+	type candidate struct {
+		suite Suite
+		local int
+		peer  int
+	}
+	var candidates []candidate
+	seen := make(map[Suite]bool)
+	for i, s := range peer {
+		if idx, ok := localIndex[s]; ok && !seen[s] {
+			seen[s] = true
+			candidates = append(candidates, candidate{suite: s, local: idx, peer: i})
+		}
+	}
+	if len(candidates) == 0 {
+		return Suite{}, ErrNoCommonSuite
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		switch n.policy {
+		case NegotiateLocalOrder:
+			return a.local < b.local
+		case NegotiatePeerOrder:
+			return a.peer < b.peer
+		default:
+			if sa, sb := suiteStrength(a.suite), suiteStrength(b.suite); sa != sb {
+				return sa > sb
+			}
+			return a.local < b.local
+		}
+	})
+	return candidates[0].suite, nil
+}
+
+// NegotiateNames 根据对端声明的套件名称选出双方都支持的套件，无法识别的名称会被忽略，便于对端先行支持新套件
+func (n *Negotiator) NegotiateNames(peer []string) (Suite, error) {
+	suites := make([]Suite, 0, len(peer))
+	for _, name := range peer {
+		if suite, err := ParseSuite(name); err == nil {
+			suites = append(suites, suite)
+		}
+	}
+	return n.Negotiate(suites)
+}
+
+// SupportedNames 本地支持且允许使用的套件名称，用于向对端声明
+func (n *Negotiator) SupportedNames() []string {
+	supported := n.Supported()
+	names := make([]string, len(supported))
+	for i, s := range supported {
+		names[i] = s.String()
+	}
+	return names
+}
+
+// suiteStrength 套件强度评分：认证加密 > 密钥长度 > 工作模式，弱套件最低
+func suiteStrength(s Suite) int {
+	if s.Weak() {
+		return 0
+	}
+	score := s.KeySize * 10
+	if s.Mode == ModeGCM {
+		score += 1000
+	}
+	switch s.Mode {
+	case ModeCTR:
+		score += 3
+	case ModeCBC:
+		score += 2
+	case ModeCFB, ModeOFB:
+		score++
+	}
+	return score
+}
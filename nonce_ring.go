@@ -0,0 +1,170 @@
+package encrypt
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 随机数预生成
+//
+// 每条消息加密都要为IV/nonce读取一次crypto/rand，每次读取都是一次系统调用，
+// 在每秒十万条消息的场景下这部分开销在profile中很明显。NonceRing由后台goroutine
+// 按批（默认4KiB）读取随机数并缓存若干批，加密时只从内存中切出所需字节：
+//
+//	ring := encrypt.EnableNoncePregeneration(0, 0)
+//	defer encrypt.DisableNoncePregeneration()
+//
+// 启用后，对称加密器每次加密生成的IV与GCM nonce都从环中读取；密钥生成等其他用途不受影响。
+// 缓存耗尽时直接同步读取，不会阻塞加密。已取出的字节会从缓存中清零，
+// 批次来源为ReadRandom，因此同样遵循SetRandomReader与SetEntropySource的设置
+
+// 预生成默认参数
+const (
+	DefaultNonceBatchSize = 4096
+	DefaultNonceRingDepth = 4
+)
+
+// activeNonceRing 当前启用的预生成环
+var activeNonceRing atomic.Pointer[NonceRing]
+
+// NonceRing 后台批量预生成的随机数缓存
+type NonceRing struct {
+	batchSize int
+	batches   chan []byte
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+
+	mu      sync.Mutex
+	current []byte // 当前批次中尚未使用的部分
+
+	fallbacks atomic.Uint64
+}
+
+// NewNonceRing 创建并启动预生成环，batchSize为每批字节数，depth为缓存的批数，小于等于0时使用默认值
+func NewNonceRing(batchSize, depth int) *NonceRing {
+	if batchSize <= 0 {
+		batchSize = DefaultNonceBatchSize
+	}
+	if depth <= 0 {
+		depth = DefaultNonceRingDepth
+	}
+
+	r := &NonceRing{
+		batchSize: batchSize,
+		batches:   make(chan []byte, depth),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	go r.fill()
+	return r
+}
+
+// fill 后台补充随机数批次
+func (r *NonceRing) fill() {
+	defer close(r.done)
+	for {
+		batch := make([]byte, r.batchSize)
+		if _, err := ReadRandom(batch); err != nil {
+			// 随机数源故障时稍后重试，期间Read同步读取并向调用方返回错误
+			select {
+			case <-r.stop:
+				return
+			case <-time.After(10 * time.Millisecond):
+				continue
+			}
+		}
+
+		select {
+		case r.batches <- batch:
+		case <-r.stop:
+			zeroBytes(batch)
+			return
+		}
+	}
+}
+
+// Read 读取预生成的随机数，实现RandomReader
+func (r *NonceRing) Read(p []byte) (int, error) {
+	r.mu.Lock()
+	n := 0
+	for n < len(p) {
+		if len(r.current) == 0 {
+			select {
+			case batch := <-r.batches:
+				r.current = batch
+			default:
+			}
+		}
+		if len(r.current) == 0 {
+			break
+		}
+		c := copy(p[n:], r.current)
+		zeroBytes(r.current[:c])
+		r.current = r.current[c:]
+		n += c
+	}
+	r.mu.Unlock()
+
+	if n == len(p) {
+		return n, nil
+	}
+	// 缓存耗尽，同步读取剩余部分
+	r.fallbacks.Add(1)
+	m, err := ReadRandom(p[n:])
+	return n + m, err
+}
+
+// Fallbacks 因缓存耗尽而同步读取的次数，持续增长时应增大批大小或缓存批数
+func (r *NonceRing) Fallbacks() uint64 {
+	return r.fallbacks.Load()
+}
+
+// Close 停止后台goroutine并清零缓存，之后的Read全部同步读取
+func (r *NonceRing) Close() {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+		<-r.done
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		zeroBytes(r.current)
+		r.current = nil
+		for {
+			select {
+			case batch := <-r.batches:
+				zeroBytes(batch)
+			default:
+				return
+			}
+		}
+	})
+}
+
+// EnableNoncePregeneration 为对称加密器启用随机数预生成，参数含义同NewNonceRing。
+// 重复调用会替换并关闭之前的环
+func EnableNoncePregeneration(batchSize, depth int) *NonceRing {
+	ring := NewNonceRing(batchSize, depth)
+	if previous := activeNonceRing.Swap(ring); previous != nil {
+		previous.Close()
+	}
+	return ring
+}
+
+// DisableNoncePregeneration 停用随机数预生成，恢复每次通过ReadRandom同步读取
+func DisableNoncePregeneration() {
+	if previous := activeNonceRing.Swap(nil); previous != nil {
+		previous.Close()
+	}
+}
+
+// readNonce 生成IV/nonce，启用预生成时从环中读取，否则通过ReadRandom读取，遵循SetRandomReader与SetEntropySource的设置
+func readNonce(p []byte) error {
+	if ring := activeNonceRing.Load(); ring != nil {
+		_, err := ring.Read(p)
+		return err
+	}
+	_, err := ReadRandom(p)
+	return err
+}
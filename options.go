@@ -0,0 +1,205 @@
+package encrypt
+
+import (
+	"github.com/pkg/errors"
+)
+
+// 函数式选项风格的构造方法，作为可变链式调用之外的另一种写法：
+//
+//	aes, err := encrypt.AES(key, encrypt.WithMode(encrypt.ModeGCM), encrypt.WithEncoding(encrypt.EncodingHex))
+//
+// 配置在构造时一次性完成，便于组合、复用和在测试中注入
+
+// Option 对称加密器配置选项
+type Option func(*symmetricOptions)
+
+// symmetricOptions 对称加密器配置
+type symmetricOptions struct {
+	mode     Mode
+	padding  PaddingMode
+	encoding EncodingMode
+	iv       []byte
+	macKey   []byte
+
+	modeSet     bool
+	paddingSet  bool
+	encodingSet bool
+	hardenCBC   bool
+	autoDecode  bool
+}
+
+// WithMode 设置加密模式
+func WithMode(mode Mode) Option {
+	return func(o *symmetricOptions) {
+		o.mode = mode
+		o.modeSet = true
+	}
+}
+
+// WithPadding 设置填充模式
+func WithPadding(padding PaddingMode) Option {
+	return func(o *symmetricOptions) {
+		o.padding = padding
+		o.paddingSet = true
+	}
+}
+
+// WithEncoding 设置编码模式
+func WithEncoding(encoding EncodingMode) Option {
+	return func(o *symmetricOptions) {
+		o.encoding = encoding
+		o.encodingSet = true
+	}
+}
+
+// WithIV 设置初始化向量，设置后IV不再附加到密文中
+func WithIV(iv []byte) Option {
+	return func(o *symmetricOptions) {
+		o.iv = iv
+	}
+}
+
+// WithAutoDecode 解密时自动识别密文编码，见IAutoDecoder
+func WithAutoDecode() Option {
+	return func(o *symmetricOptions) {
+		o.autoDecode = true
+	}
+}
+
+// WithCBCHardening 开启CBC填充预言机加固，macKey非nil时启用Encrypt-then-MAC，见HardenCBC
+func WithCBCHardening(macKey []byte) Option {
+	return func(o *symmetricOptions) {
+		o.macKey = macKey
+		o.hardenCBC = true
+	}
+}
+
+// AES 使用选项创建AES加密器
+func AES(key []byte, opts ...Option) (ISymmetric, error) {
+	return newWithOptions(NewAES, key, opts)
+}
+
+// DES 使用选项创建DES加密器
+func DES(key []byte, opts ...Option) (ISymmetric, error) {
+	return newWithOptions(NewDES, key, opts)
+}
+
+// TripleDES 使用选项创建3DES加密器
+func TripleDES(key []byte, opts ...Option) (ISymmetric, error) {
+	return newWithOptions(New3DES, key, opts)
+}
+
+// SM4 使用选项创建SM4加密器
+func SM4(key []byte, opts ...Option) (ISymmetric, error) {
+	return newWithOptions(NewSM4, key, opts)
+}
+
+// newWithOptions 创建加密器并依次应用选项
+func newWithOptions(factory func([]byte) (ISymmetric, error), key []byte, opts []Option) (ISymmetric, error) {
+	o := &symmetricOptions{
+		mode: ModeCBC, // 默认使用CBC模式
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	encryptor, err := factory(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := applySymmetricOptions(encryptor, o); err != nil {
+		encryptor.Release()
+		return nil, err
+	}
+	return encryptor, nil
+}
+
+// applySymmetricOptions 通过链式方法应用配置，保证与链式调用行为一致
+func applySymmetricOptions(encryptor ISymmetric, o *symmetricOptions) error {
+	// IV需要在设置模式前写入，模式会使用已设置的IV
+	if o.iv != nil && o.mode != ModeECB && o.mode != ModeGCM {
+		encryptor.WithIV(o.iv)
+	}
+
+	switch o.mode {
+	case ModeECB:
+		encryptor.ECB()
+	case ModeCBC:
+		encryptor.CBC()
+	case ModeCFB:
+		encryptor.CFB()
+	case ModeOFB:
+		encryptor.OFB()
+	case ModeCTR:
+		encryptor.CTR()
+	case ModeGCM:
+		gcm, ok := encryptor.(IGCMSetter)
+		if !ok {
+			return errors.New("该算法不支持GCM模式")
+		}
+		gcm.GCM()
+	default:
+		return errors.New("不支持的加密模式")
+	}
+
+	// 模式创建后再次写入IV，标记IV独立于密文
+	if o.iv != nil && o.mode != ModeECB && o.mode != ModeGCM {
+		encryptor.WithIV(o.iv)
+	}
+
+	if o.paddingSet {
+		switch o.padding {
+		case PaddingNone:
+			encryptor.NoPadding()
+		case PaddingPKCS7:
+			encryptor.PKCS7()
+		case PaddingZero:
+			encryptor.ZeroPadding()
+		default:
+			return errors.New("不支持的填充模式")
+		}
+	}
+
+	if o.encodingSet {
+		switch o.encoding {
+		case EncodingNone:
+			encryptor.NoEncoding()
+		case EncodingBase64:
+			encryptor.Base64()
+		case EncodingBase64Safe:
+			encryptor.Base64Safe()
+		case EncodingHex:
+			encryptor.Hex()
+		case EncodingBase64MIME, EncodingBase64PEM:
+			wrapped, ok := encryptor.(IWrappedEncoder)
+			if !ok {
+				return errors.New("该算法不支持折行Base64编码")
+			}
+			if o.encoding == EncodingBase64MIME {
+				wrapped.Base64MIME()
+			} else {
+				wrapped.Base64PEM()
+			}
+		default:
+			return errors.New("不支持的编码模式")
+		}
+	}
+
+	if o.autoDecode {
+		decoder, ok := encryptor.(IAutoDecoder)
+		if !ok {
+			return errors.New("该算法不支持自动识别编码")
+		}
+		decoder.AutoDecode()
+	}
+
+	if o.hardenCBC {
+		hardener, ok := encryptor.(ICBCHardener)
+		if !ok {
+			return errors.New("该算法不支持CBC加固")
+		}
+		hardener.HardenCBC(o.macKey)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package encrypt
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// 密码哈希审计报告
+//
+// 年度凭据检查需要回答：库里还有多少MD5时代迁移过来的PBKDF2-SHA1、低代价bcrypt，
+// 升级到新参数的进度如何。AuditPasswordHashes 通过调用方提供的迭代函数逐条读取存储的哈希
+// （通常是分页查询用户表），按算法与参数归类，并按PasswordHashPolicy判断每一类是否低于最低要求。
+// 报告不保存哈希本身，每类只保留少量ID样本便于抽查。
+//
+// 低于策略的哈希只能在用户下次登录时通过PasswordHasher.VerifyAndUpgrade升级，
+// 长期未登录的账户应考虑强制重置密码
+
+// PasswordHashPolicy 密码哈希最低要求
+type PasswordHashPolicy struct {
+	MinArgon2Time   uint32                // argon2id最少迭代次数
+	MinArgon2Memory uint32                // argon2id最少内存，单位KiB
+	MinBcryptCost   int                   // bcrypt最小代价因子
+	MinPBKDF2       map[HashAlgorithm]int // 各哈希算法的PBKDF2最少迭代次数，未列出的算法视为不合规
+	MinSaltSize     int                   // 最短盐值字节数
+	MinKeySize      int                   // 最短输出字节数
+}
+
+// DefaultPasswordHashPolicy 默认策略（OWASP密码存储建议）
+var DefaultPasswordHashPolicy = PasswordHashPolicy{
+	MinArgon2Time:   DefaultPasswordArgon2Time,
+	MinArgon2Memory: DefaultPasswordArgon2Memory,
+	MinBcryptCost:   10,
+	MinPBKDF2: map[HashAlgorithm]int{
+		HashSHA1:   1300000,
+		HashSHA256: DefaultPasswordPBKDF2Iterations,
+		HashSHA512: 210000,
+	},
+	MinSaltSize: passwordSaltSize,
+	MinKeySize:  passwordKeySize,
+}
+
+// defaultPasswordAuditSamples 每类保留的ID样本数
+const defaultPasswordAuditSamples = 5
+
+// passwordAuditUnrecognized 无法识别的哈希所属类别
+const passwordAuditUnrecognized = "unrecognized"
+
+// PasswordAuditOptions 审计选项
+type PasswordAuditOptions struct {
+	Hasher     *PasswordHasher // 当前使用的哈希器，设置后统计与当前配置不一致、需要升级的数量
+	MaxSamples int             // 每类保留的ID样本数，默认5，小于0表示不保留
+}
+
+// PasswordAuditClass 一类算法与参数相同的哈希
+type PasswordAuditClass struct {
+	Class       string                `json:"class"`
+	Algorithm   PasswordHashAlgorithm `json:"algorithm"`
+	Count       int                   `json:"count"`
+	BelowPolicy bool                  `json:"below_policy"`
+	Reasons     []string              `json:"reasons,omitempty"`
+	SampleIDs   []string              `json:"sample_ids,omitempty"`
+}
+
+// PasswordAuditReport 审计报告
+type PasswordAuditReport struct {
+	Total        int                   `json:"total"`
+	Compliant    int                   `json:"compliant"`
+	BelowPolicy  int                   `json:"below_policy"`
+	Unrecognized int                   `json:"unrecognized"`
+	NeedsRehash  int                   `json:"needs_rehash"` // 与当前哈希器配置不一致，仅设置Hasher时统计
+	Classes      []*PasswordAuditClass `json:"classes"`      // 按数量从多到少排列
+}
+
+// AuditPasswordHashes 遍历存储的哈希并生成报告，iterate对每条记录调用yield，yield返回的错误应原样返回
+func AuditPasswordHashes(iterate func(yield func(id, encoded string) error) error, policy PasswordHashPolicy, opts PasswordAuditOptions) (*PasswordAuditReport, error) {
+	maxSamples := opts.MaxSamples
+	if maxSamples == 0 {
+		maxSamples = defaultPasswordAuditSamples
+	}
+
+	report := &PasswordAuditReport{}
+	classes := make(map[string]*PasswordAuditClass)
+	err := iterate(func(id, encoded string) error {
+		report.Total++
+
+		var class *PasswordAuditClass
+		info, err := ParsePasswordHash(encoded)
+		if err != nil {
+			report.Unrecognized++
+			report.BelowPolicy++
+			class = classes[passwordAuditUnrecognized]
+			if class == nil {
+				class = &PasswordAuditClass{Class: passwordAuditUnrecognized, BelowPolicy: true, Reasons: []string{"无法识别的哈希格式"}}
+				classes[passwordAuditUnrecognized] = class
+			}
+		} else {
+			name := passwordAuditClassName(info, policy)
+			class = classes[name]
+			if class == nil {
+				reasons := policy.Check(info)
+				class = &PasswordAuditClass{Class: name, Algorithm: info.Algorithm, BelowPolicy: len(reasons) > 0, Reasons: reasons}
+				classes[name] = class
+			}
+			if class.BelowPolicy {
+				report.BelowPolicy++
+			} else {
+				report.Compliant++
+			}
+		}
+		if opts.Hasher != nil && (info == nil || opts.Hasher.needsRehash(info)) {
+			report.NeedsRehash++
+		}
+
+		class.Count++
+		if len(class.SampleIDs) < maxSamples {
+			class.SampleIDs = append(class.SampleIDs, id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "读取密码哈希失败")
+	}
+
+	for _, class := range classes {
+		report.Classes = append(report.Classes, class)
+	}
+	sort.Slice(report.Classes, func(i, j int) bool {
+		a, b := report.Classes[i], report.Classes[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Class < b.Class
+	})
+	return report, nil
+}
+
+// Check 返回哈希低于策略的原因，满足策略时返回nil
+func (p PasswordHashPolicy) Check(info *PasswordHashInfo) []string {
+	var reasons []string
+	switch info.Algorithm {
+	case PasswordArgon2id:
+		if info.Time < p.MinArgon2Time {
+			reasons = append(reasons, fmt.Sprintf("迭代次数%d低于%d", info.Time, p.MinArgon2Time))
+		}
+		if info.Memory < p.MinArgon2Memory {
+			reasons = append(reasons, fmt.Sprintf("内存%dKiB低于%dKiB", info.Memory, p.MinArgon2Memory))
+		}
+	case PasswordBcrypt:
+		if info.Cost < p.MinBcryptCost {
+			reasons = append(reasons, fmt.Sprintf("代价因子%d低于%d", info.Cost, p.MinBcryptCost))
+		}
+		// bcrypt的盐与输出长度固定
+		return reasons
+	case PasswordPBKDF2:
+		minimum, ok := p.MinPBKDF2[info.Hash]
+		switch {
+		case !ok:
+			reasons = append(reasons, fmt.Sprintf("策略不允许PBKDF2-%s", strings.ToUpper(pbkdf2PasswordHashNames[info.Hash])))
+		case info.Iterations < minimum:
+			reasons = append(reasons, fmt.Sprintf("迭代次数%d低于%d", info.Iterations, minimum))
+		}
+	case PasswordArgon2i:
+		reasons = append(reasons, "argon2i仅用于校验旧哈希，应升级到argon2id")
+	default:
+		return []string{"不支持的密码哈希算法"}
+	}
+
+	if len(info.Salt) < p.MinSaltSize {
+		reasons = append(reasons, fmt.Sprintf("盐值%d字节少于%d字节", len(info.Salt), p.MinSaltSize))
+	}
+	if len(info.Key) < p.MinKeySize {
+		reasons = append(reasons, fmt.Sprintf("输出%d字节少于%d字节", len(info.Key), p.MinKeySize))
+	}
+	return reasons
+}
+
+// passwordAuditClassName 按算法与参数命名类别，盐值或输出过短时单独归类
+func passwordAuditClassName(info *PasswordHashInfo, policy PasswordHashPolicy) string {
+	var name string
+	switch info.Algorithm {
+	case PasswordArgon2id, PasswordArgon2i:
+		name = fmt.Sprintf("%s m=%d,t=%d,p=%d", info.Algorithm, info.Memory, info.Time, info.Threads)
+	case PasswordBcrypt:
+		return fmt.Sprintf("bcrypt cost=%d", info.Cost)
+	case PasswordPBKDF2:
+		name = fmt.Sprintf("pbkdf2-%s i=%d", pbkdf2PasswordHashNames[info.Hash], info.Iterations)
+	default:
+		name = info.Algorithm.String()
+	}
+	if len(info.Salt) < policy.MinSaltSize {
+		name += fmt.Sprintf(" salt=%d", len(info.Salt))
+	}
+	if len(info.Key) < policy.MinKeySize {
+		name += fmt.Sprintf(" len=%d", len(info.Key))
+	}
+	return name
+}
+
+// BelowPolicyRatio 低于策略的比例
+func (r *PasswordAuditReport) BelowPolicyRatio() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.BelowPolicy) / float64(r.Total)
+}
+
+// WriteText 以表格形式输出报告
+func (r *PasswordAuditReport) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "# 共%d条，合规%d条，低于策略%d条（%.1f%%），无法识别%d条\n",
+		r.Total, r.Compliant, r.BelowPolicy, 100*r.BelowPolicyRatio(), r.Unrecognized)
+	fmt.Fprintln(tw, "class\tcount\tstatus\treasons\t")
+	for _, class := range r.Classes {
+		status := "ok"
+		if class.BelowPolicy {
+			status = "weak"
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t\n", class.Class, class.Count, status, strings.Join(class.Reasons, "; "))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,387 @@
+package encrypt
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// 密码哈希与升级
+//
+// PasswordHasher按存储哈希的前缀选择校验算法，支持同时存在多代哈希：
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<hash>    argon2id（PHC格式，Base64无填充）
+//	$argon2i$v=19$m=...,t=...,p=...$<salt>$<hash>   argon2i（仅校验）
+//	$2a$10$...、$2b$...、$2y$...                     bcrypt
+//	$pbkdf2-sha256$i=600000,l=32$<salt>$<hash>      PBKDF2（sha1/sha256/sha512/sm3）
+//
+// 校验通过后，如果哈希的算法或参数与当前配置不一致，Verify返回needsRehash=true，
+// 调用方此时持有明文密码，可以用Hash重新生成并覆盖存储，旧哈希在用户登录时逐步迁移
+
+// PasswordHashAlgorithm 密码哈希算法
+type PasswordHashAlgorithm int
+
+// 密码哈希算法常量定义
+const (
+	PasswordArgon2id PasswordHashAlgorithm = iota + 1
+	PasswordBcrypt
+	PasswordPBKDF2
+	PasswordArgon2i // 仅用于校验旧哈希
+)
+
+// String 算法名称
+func (a PasswordHashAlgorithm) String() string {
+	switch a {
+	case PasswordArgon2id:
+		return "argon2id"
+	case PasswordBcrypt:
+		return "bcrypt"
+	case PasswordPBKDF2:
+		return "pbkdf2"
+	case PasswordArgon2i:
+		return "argon2i"
+	default:
+		return "unknown"
+	}
+}
+
+// 密码哈希默认参数
+const (
+	// DefaultPasswordArgon2Time argon2id迭代次数（OWASP推荐配置）
+	DefaultPasswordArgon2Time = 2
+	// DefaultPasswordArgon2Memory argon2id内存，单位KiB
+	DefaultPasswordArgon2Memory = 19 * 1024
+	// DefaultPasswordArgon2Threads argon2id并行度
+	DefaultPasswordArgon2Threads = 1
+	// DefaultPasswordBcryptCost bcrypt代价因子
+	DefaultPasswordBcryptCost = 12
+	// DefaultPasswordPBKDF2Iterations PBKDF2-SHA256迭代次数
+	DefaultPasswordPBKDF2Iterations = 600000
+)
+
+// passwordSaltSize 盐值长度
+const passwordSaltSize = 16
+
+// passwordKeySize 输出哈希长度
+const passwordKeySize = 32
+
+// pbkdf2PasswordHashNames PBKDF2哈希名称
+var pbkdf2PasswordHashNames = map[HashAlgorithm]string{
+	HashSHA1:   "sha1",
+	HashSHA256: "sha256",
+	HashSHA512: "sha512",
+	HashSM3:    "sm3",
+}
+
+// PasswordHashInfo 解析后的密码哈希参数
+type PasswordHashInfo struct {
+	Algorithm  PasswordHashAlgorithm
+	Time       uint32        // argon2
+	Memory     uint32        // argon2，单位KiB
+	Threads    uint8         // argon2
+	Cost       int           // bcrypt
+	Hash       HashAlgorithm // PBKDF2
+	Iterations int           // PBKDF2
+	Salt       []byte        // argon2、PBKDF2
+	Key        []byte        // argon2、PBKDF2
+}
+
+// PasswordHasher 密码哈希器
+type PasswordHasher struct {
+	algorithm  PasswordHashAlgorithm
+	time       uint32
+	memory     uint32
+	threads    uint8
+	cost       int
+	hashAlgo   HashAlgorithm
+	iterations int
+}
+
+// NewPasswordHasher 创建密码哈希器，默认使用argon2id
+func NewPasswordHasher() *PasswordHasher {
+	return &PasswordHasher{
+		algorithm:  PasswordArgon2id,
+		time:       DefaultPasswordArgon2Time,
+		memory:     DefaultPasswordArgon2Memory,
+		threads:    DefaultPasswordArgon2Threads,
+		cost:       DefaultPasswordBcryptCost,
+		hashAlgo:   HashSHA256,
+		iterations: DefaultPasswordPBKDF2Iterations,
+	}
+}
+
+// Argon2id 使用argon2id生成新哈希
+func (h *PasswordHasher) Argon2id(time, memory uint32, threads uint8) *PasswordHasher {
+	h.algorithm = PasswordArgon2id
+	h.time, h.memory, h.threads = time, memory, threads
+	return h
+}
+
+// Bcrypt 使用bcrypt生成新哈希
+func (h *PasswordHasher) Bcrypt(cost int) *PasswordHasher {
+	h.algorithm = PasswordBcrypt
+	h.cost = cost
+	return h
+}
+
+// PBKDF2 使用PBKDF2生成新哈希
+func (h *PasswordHasher) PBKDF2(hashAlgo HashAlgorithm, iterations int) *PasswordHasher {
+	h.algorithm = PasswordPBKDF2
+	h.hashAlgo, h.iterations = hashAlgo, iterations
+	return h
+}
+
+// Hash 使用当前配置生成密码哈希
+func (h *PasswordHasher) Hash(password []byte) (string, error) {
+	if len(password) == 0 {
+		return "", errors.New("密码不能为空")
+	}
+
+	switch h.algorithm {
+	case PasswordBcrypt:
+		if h.cost < bcrypt.MinCost || h.cost > bcrypt.MaxCost {
+			return "", errors.Errorf("bcrypt代价因子必须在%d到%d之间", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		hashed, err := bcrypt.GenerateFromPassword(password, h.cost)
+		if err != nil {
+			return "", errors.Wrap(err, "生成bcrypt哈希失败")
+		}
+		return string(hashed), nil
+	case PasswordArgon2id, PasswordPBKDF2:
+		salt, err := GenerateRandomBytes(passwordSaltSize)
+		if err != nil {
+			return "", errors.Wrap(err, "生成盐值失败")
+		}
+		info := &PasswordHashInfo{
+			Algorithm:  h.algorithm,
+			Time:       h.time,
+			Memory:     h.memory,
+			Threads:    h.threads,
+			Hash:       h.hashAlgo,
+			Iterations: h.iterations,
+			Salt:       salt,
+		}
+		if err := info.validate(); err != nil {
+			return "", err
+		}
+		info.Key = info.derive(password, passwordKeySize)
+		return info.String(), nil
+	default:
+		return "", errors.New("不支持的密码哈希算法")
+	}
+}
+
+// Verify 校验密码，valid表示密码正确，needsRehash表示应使用当前配置重新生成哈希
+// 密码错误时返回false且不报错，只有哈希格式无法识别时返回错误
+func (h *PasswordHasher) Verify(password []byte, encoded string) (valid bool, needsRehash bool, err error) {
+	info, err := ParsePasswordHash(encoded)
+	if err != nil {
+		return false, false, err
+	}
+
+	if info.Algorithm == PasswordBcrypt {
+		err := bcrypt.CompareHashAndPassword([]byte(encoded), password)
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, errors.Wrap(err, "校验bcrypt哈希失败")
+		}
+	} else {
+		key := info.derive(password, len(info.Key))
+		defer zeroBytes(key)
+		if subtle.ConstantTimeCompare(key, info.Key) != 1 {
+			return false, false, nil
+		}
+	}
+	return true, h.needsRehash(info), nil
+}
+
+// VerifyAndUpgrade 校验密码，需要升级时同时返回使用当前配置生成的新哈希，无需升级时upgraded为空
+func (h *PasswordHasher) VerifyAndUpgrade(password []byte, encoded string) (valid bool, upgraded string, err error) {
+	valid, needsRehash, err := h.Verify(password, encoded)
+	if err != nil || !valid || !needsRehash {
+		return valid, "", err
+	}
+	upgraded, err = h.Hash(password)
+	if err != nil {
+		return true, "", errors.Wrap(err, "重新生成密码哈希失败")
+	}
+	return true, upgraded, nil
+}
+
+// NeedsRehash 判断哈希是否与当前配置不一致，无法识别的哈希视为需要重新生成
+func (h *PasswordHasher) NeedsRehash(encoded string) bool {
+	info, err := ParsePasswordHash(encoded)
+	if err != nil {
+		return true
+	}
+	return h.needsRehash(info)
+}
+
+// needsRehash 比较哈希参数与当前配置
+func (h *PasswordHasher) needsRehash(info *PasswordHashInfo) bool {
+	if info.Algorithm != h.algorithm {
+		return true
+	}
+	switch info.Algorithm {
+	case PasswordArgon2id:
+		return info.Time != h.time || info.Memory != h.memory || info.Threads != h.threads ||
+			len(info.Salt) < passwordSaltSize || len(info.Key) < passwordKeySize
+	case PasswordBcrypt:
+		return info.Cost != h.cost
+	case PasswordPBKDF2:
+		return info.Hash != h.hashAlgo || info.Iterations != h.iterations ||
+			len(info.Salt) < passwordSaltSize || len(info.Key) < passwordKeySize
+	default:
+		return true
+	}
+}
+
+// ParsePasswordHash 按前缀解析密码哈希
+func ParsePasswordHash(encoded string) (*PasswordHashInfo, error) {
+	switch {
+	case strings.HasPrefix(encoded, "$2a$"), strings.HasPrefix(encoded, "$2b$"), strings.HasPrefix(encoded, "$2y$"):
+		cost, err := bcrypt.Cost([]byte(encoded))
+		if err != nil {
+			return nil, errors.Wrap(err, "bcrypt哈希格式不正确")
+		}
+		return &PasswordHashInfo{Algorithm: PasswordBcrypt, Cost: cost}, nil
+	case strings.HasPrefix(encoded, "$argon2id$"), strings.HasPrefix(encoded, "$argon2i$"):
+		return parseArgon2Hash(encoded)
+	case strings.HasPrefix(encoded, "$pbkdf2-"):
+		return parsePBKDF2Hash(encoded)
+	default:
+		return nil, errors.New("无法识别的密码哈希格式")
+	}
+}
+
+// String 按PHC格式输出哈希，bcrypt哈希不保存盐值与输出，返回空字符串
+func (i *PasswordHashInfo) String() string {
+	salt := base64.RawStdEncoding.EncodeToString(i.Salt)
+	key := base64.RawStdEncoding.EncodeToString(i.Key)
+	switch i.Algorithm {
+	case PasswordArgon2id, PasswordArgon2i:
+		return fmt.Sprintf("$%s$v=%d$m=%d,t=%d,p=%d$%s$%s", i.Algorithm, argon2.Version, i.Memory, i.Time, i.Threads, salt, key)
+	case PasswordPBKDF2:
+		return fmt.Sprintf("$pbkdf2-%s$i=%d,l=%d$%s$%s", pbkdf2PasswordHashNames[i.Hash], i.Iterations, len(i.Key), salt, key)
+	default:
+		return ""
+	}
+}
+
+// validate 检查参数范围
+func (i *PasswordHashInfo) validate() error {
+	switch i.Algorithm {
+	case PasswordArgon2id, PasswordArgon2i:
+		if i.Time < 1 || i.Threads < 1 || i.Memory < 8*uint32(i.Threads) {
+			return errors.New("argon2参数不正确")
+		}
+	case PasswordPBKDF2:
+		if _, ok := pbkdf2PasswordHashNames[i.Hash]; !ok {
+			return errors.New("不支持的PBKDF2哈希算法")
+		}
+		if i.Iterations < 1000 {
+			return errors.New("迭代次数太少，安全性不足，建议至少10000次")
+		}
+	}
+	return nil
+}
+
+// derive 计算密码的派生值
+func (i *PasswordHashInfo) derive(password []byte, keyLen int) []byte {
+	switch i.Algorithm {
+	case PasswordArgon2id:
+		return argon2.IDKey(password, i.Salt, i.Time, i.Memory, i.Threads, uint32(keyLen))
+	case PasswordArgon2i:
+		return argon2.Key(password, i.Salt, i.Time, i.Memory, i.Threads, uint32(keyLen))
+	default:
+		return pbkdf2(password, i.Salt, i.Iterations, keyLen, hashFunc(i.Hash))
+	}
+}
+
+// parseArgon2Hash 解析 $argon2id$v=19$m=..,t=..,p=..$salt$hash
+func parseArgon2Hash(encoded string) (*PasswordHashInfo, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 {
+		return nil, errors.New("argon2哈希格式不正确")
+	}
+	info := &PasswordHashInfo{Algorithm: PasswordArgon2id}
+	if parts[1] == "argon2i" {
+		info.Algorithm = PasswordArgon2i
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("不支持的argon2版本")
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &info.Memory, &info.Time, &info.Threads); err != nil {
+		return nil, errors.Wrap(err, "argon2参数格式不正确")
+	}
+	if err := info.decodeSaltAndKey(parts[4], parts[5]); err != nil {
+		return nil, err
+	}
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// parsePBKDF2Hash 解析 $pbkdf2-sha256$i=..,l=..$salt$hash
+func parsePBKDF2Hash(encoded string) (*PasswordHashInfo, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 5 {
+		return nil, errors.New("PBKDF2哈希格式不正确")
+	}
+	info := &PasswordHashInfo{Algorithm: PasswordPBKDF2}
+	name := strings.TrimPrefix(parts[1], "pbkdf2-")
+	for algo, algoName := range pbkdf2PasswordHashNames {
+		if name == algoName {
+			info.Hash = algo
+		}
+	}
+	if info.Hash == 0 {
+		return nil, errors.Errorf("不支持的PBKDF2哈希算法: %s", name)
+	}
+
+	// l参数可选，以实际输出长度为准
+	for _, param := range strings.Split(parts[2], ",") {
+		key, value, _ := strings.Cut(param, "=")
+		if key != "i" {
+			continue
+		}
+		iterations, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, errors.Wrap(err, "PBKDF2迭代次数格式不正确")
+		}
+		info.Iterations = iterations
+	}
+	if err := info.decodeSaltAndKey(parts[3], parts[4]); err != nil {
+		return nil, err
+	}
+	if err := info.validate(); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// decodeSaltAndKey 解码PHC格式中的盐值与哈希
+func (i *PasswordHashInfo) decodeSaltAndKey(salt, key string) error {
+	var err error
+	if i.Salt, err = base64.RawStdEncoding.DecodeString(salt); err != nil {
+		return errors.Wrap(err, "盐值格式不正确")
+	}
+	if i.Key, err = base64.RawStdEncoding.DecodeString(key); err != nil {
+		return errors.Wrap(err, "哈希值格式不正确")
+	}
+	if len(i.Salt) == 0 || len(i.Key) < 16 {
+		return errors.New("盐值或哈希值长度不足")
+	}
+	return nil
+}
@@ -0,0 +1,318 @@
+package encrypt
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// 加密载荷的protobuf序列化
+//
+// gRPC服务之间传递密文时常把整段二进制转成base64放进string字段，接收方只能拿到不透明的字符串，
+// 无法在不解析私有格式的情况下看到算法、密钥ID等信息。EncryptedPayload 把信封、密钥环密文、
+// KMS数据密钥信封拆成结构化字段，schema见 proto/sylphbyte/encrypt/v1/payload.proto，
+// 其他语言可用protoc生成对应类型。
+//
+// Marshal输出与protoc生成代码的线路格式一致（零值字段省略，metadata按键排序以保证输出确定），
+// 本包不依赖protobuf运行时。元数据不受认证保护，不要在其中放置需要防篡改的信息，
+// 需要绑定的上下文应作为附加认证数据参与加密
+
+// PayloadFormat 加密载荷的来源格式，取值与proto枚举一致
+type PayloadFormat int
+
+// 载荷格式常量定义
+const (
+	PayloadFormatUnspecified PayloadFormat = iota
+	// PayloadFormatEnvelope SealEnvelope输出的版本化信封
+	PayloadFormatEnvelope
+	// PayloadFormatKeyRing KeyRing.Encrypt的输出
+	PayloadFormatKeyRing
+	// PayloadFormatKMSEnvelope EnvelopeEncrypt输出的数据密钥信封
+	PayloadFormatKMSEnvelope
+)
+
+// EncryptedPayload 加密载荷与元数据，对应proto消息sylphbyte.encrypt.v1.EncryptedPayload
+type EncryptedPayload struct {
+	Format     PayloadFormat     `json:"format"`
+	Version    uint32            `json:"version,omitempty"`
+	Algorithm  Algorithm         `json:"algorithm,omitempty"`
+	Mode       Mode              `json:"mode,omitempty"`
+	Padding    PaddingMode       `json:"padding,omitempty"`
+	IV         []byte            `json:"iv,omitempty"`
+	KeyID      string            `json:"key_id,omitempty"`
+	WrappedKey []byte            `json:"wrapped_key,omitempty"`
+	Ciphertext []byte            `json:"ciphertext"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+}
+
+// EncryptedPayload 字段编号
+const (
+	payloadFieldFormat     = 1
+	payloadFieldVersion    = 2
+	payloadFieldAlgorithm  = 3
+	payloadFieldMode       = 4
+	payloadFieldPadding    = 5
+	payloadFieldIV         = 6
+	payloadFieldKeyID      = 7
+	payloadFieldWrappedKey = 8
+	payloadFieldCiphertext = 9
+	payloadFieldMetadata   = 10
+)
+
+// PayloadCodec 加密载荷编解码器
+type PayloadCodec interface {
+	Marshal(payload *EncryptedPayload) ([]byte, error)
+	Unmarshal(data []byte) (*EncryptedPayload, error)
+}
+
+// 内置编解码器
+var (
+	// PayloadCodecProto protobuf二进制，用于gRPC消息
+	PayloadCodecProto PayloadCodec = protoPayloadCodec{}
+	// PayloadCodecJSON JSON，字节字段为标准base64，用于HTTP接口与日志
+	PayloadCodecJSON PayloadCodec = jsonPayloadCodec{}
+)
+
+// PayloadFromEnvelope 把SealEnvelope输出的信封拆为载荷
+func PayloadFromEnvelope(data []byte) (*EncryptedPayload, error) {
+	envelope, err := ParseEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedPayload{
+		Format:     PayloadFormatEnvelope,
+		Version:    uint32(envelope.Version),
+		Algorithm:  envelope.Algorithm,
+		Mode:       envelope.Mode,
+		Padding:    envelope.Padding,
+		IV:         append([]byte(nil), envelope.IV...),
+		Ciphertext: append([]byte(nil), envelope.Ciphertext...),
+	}, nil
+}
+
+// PayloadFromKeyRing 把KeyRing.Encrypt的输出拆为载荷
+func PayloadFromKeyRing(data []byte) (*EncryptedPayload, error) {
+	keyID, ciphertext, err := SplitKeyID(data)
+	if err != nil {
+		return nil, err
+	}
+	if keyID == "" {
+		return nil, errors.New("密钥ID不能为空")
+	}
+	return &EncryptedPayload{
+		Format:     PayloadFormatKeyRing,
+		KeyID:      keyID,
+		Ciphertext: append([]byte(nil), ciphertext...),
+	}, nil
+}
+
+// PayloadFromKMSEnvelope 把EnvelopeEncrypt输出的数据密钥信封拆为载荷
+func PayloadFromKMSEnvelope(data []byte) (*EncryptedPayload, error) {
+	keyID, wrapped, headerLen, err := parseKMSEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedPayload{
+		Format:     PayloadFormatKMSEnvelope,
+		Version:    kmsEnvelopeVersion,
+		KeyID:      keyID,
+		WrappedKey: append([]byte(nil), wrapped...),
+		Ciphertext: append([]byte(nil), data[headerLen:]...),
+	}, nil
+}
+
+// WithMetadata 设置一项元数据
+func (p *EncryptedPayload) WithMetadata(key, value string) *EncryptedPayload {
+	if p.Metadata == nil {
+		p.Metadata = make(map[string]string)
+	}
+	p.Metadata[key] = value
+	return p
+}
+
+// Bytes 还原为来源格式的密文，可直接交给OpenEnvelope、KeyRing.Decrypt或EnvelopeDecrypt
+// KMS数据密钥信封的头部参与认证，还原后的头部与原始输出逐字节一致
+func (p *EncryptedPayload) Bytes() ([]byte, error) {
+	switch p.Format {
+	case PayloadFormatEnvelope:
+		if p.Version > 0xff {
+			return nil, errors.Errorf("不支持的格式版本: %d", p.Version)
+		}
+		envelope := &Envelope{
+			Version:    FormatVersion(p.Version),
+			Algorithm:  p.Algorithm,
+			Mode:       p.Mode,
+			Padding:    p.Padding,
+			IV:         p.IV,
+			Ciphertext: p.Ciphertext,
+		}
+		return envelope.Bytes()
+	case PayloadFormatKeyRing:
+		if len(p.KeyID) == 0 || len(p.KeyID) > 0xff {
+			return nil, errors.New("密钥ID长度必须在1到255字节之间")
+		}
+		out := make([]byte, 0, 1+len(p.KeyID)+len(p.Ciphertext))
+		out = append(out, byte(len(p.KeyID)))
+		out = append(out, p.KeyID...)
+		return append(out, p.Ciphertext...), nil
+	case PayloadFormatKMSEnvelope:
+		if p.Version != kmsEnvelopeVersion {
+			return nil, errors.Errorf("不支持的数据密钥信封版本: %d", p.Version)
+		}
+		if len(p.KeyID) == 0 || len(p.KeyID) > 0xff {
+			return nil, errors.New("密钥ID长度必须在1到255字节之间")
+		}
+		if len(p.WrappedKey) > 0xffff {
+			return nil, errors.New("包装后的数据密钥过长")
+		}
+		out := make([]byte, 0, 6+len(p.KeyID)+len(p.WrappedKey)+len(p.Ciphertext))
+		out = append(out, kmsEnvelopeMagic...)
+		out = append(out, kmsEnvelopeVersion, byte(len(p.KeyID)))
+		out = append(out, p.KeyID...)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(p.WrappedKey)))
+		out = append(out, p.WrappedKey...)
+		return append(out, p.Ciphertext...), nil
+	default:
+		return nil, errors.Errorf("不支持的载荷格式: %d", p.Format)
+	}
+}
+
+// Marshal 序列化为protobuf二进制
+func (p *EncryptedPayload) Marshal() ([]byte, error) {
+	return PayloadCodecProto.Marshal(p)
+}
+
+// UnmarshalEncryptedPayload 解析protobuf二进制，未知字段被忽略
+func UnmarshalEncryptedPayload(data []byte) (*EncryptedPayload, error) {
+	return PayloadCodecProto.Unmarshal(data)
+}
+
+// protoPayloadCodec protobuf编解码器
+type protoPayloadCodec struct{}
+
+// Marshal 按字段编号顺序输出，与protoc生成代码的确定性序列化一致
+func (protoPayloadCodec) Marshal(p *EncryptedPayload) ([]byte, error) {
+	if p == nil {
+		return nil, errors.New("载荷不能为空")
+	}
+	var out []byte
+	appendVarint := func(num int, v uint64) {
+		if v != 0 {
+			out = protoAppendTag(out, num, protoVarint)
+			out = protoAppendVarint(out, v)
+		}
+	}
+	appendBytes := func(num int, value []byte) {
+		if len(value) > 0 {
+			out = protoAppendTag(out, num, protoBytes)
+			out = protoAppendBytes(out, value)
+		}
+	}
+
+	appendVarint(payloadFieldFormat, uint64(p.Format))
+	appendVarint(payloadFieldVersion, uint64(p.Version))
+	appendVarint(payloadFieldAlgorithm, uint64(p.Algorithm))
+	appendVarint(payloadFieldMode, uint64(p.Mode))
+	appendVarint(payloadFieldPadding, uint64(p.Padding))
+	appendBytes(payloadFieldIV, p.IV)
+	appendBytes(payloadFieldKeyID, []byte(p.KeyID))
+	appendBytes(payloadFieldWrappedKey, p.WrappedKey)
+	appendBytes(payloadFieldCiphertext, p.Ciphertext)
+
+	// map字段编码为重复的 entry{key=1, value=2}
+	keys := make([]string, 0, len(p.Metadata))
+	for key := range p.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		var entry []byte
+		if key != "" {
+			entry = protoAppendTag(entry, 1, protoBytes)
+			entry = protoAppendBytes(entry, []byte(key))
+		}
+		if value := p.Metadata[key]; value != "" {
+			entry = protoAppendTag(entry, 2, protoBytes)
+			entry = protoAppendBytes(entry, []byte(value))
+		}
+		out = protoAppendTag(out, payloadFieldMetadata, protoBytes)
+		out = protoAppendBytes(out, entry)
+	}
+	return out, nil
+}
+
+// Unmarshal 解析protobuf二进制，字节字段会被复制
+func (protoPayloadCodec) Unmarshal(data []byte) (*EncryptedPayload, error) {
+	p := &EncryptedPayload{}
+	err := walkProto(data, func(num, typ int, value []byte, v uint64) error {
+		switch {
+		case num == payloadFieldFormat && typ == protoVarint:
+			p.Format = PayloadFormat(int32(v))
+		case num == payloadFieldVersion && typ == protoVarint:
+			p.Version = uint32(v)
+		case num == payloadFieldAlgorithm && typ == protoVarint:
+			p.Algorithm = Algorithm(int32(v))
+		case num == payloadFieldMode && typ == protoVarint:
+			p.Mode = Mode(int32(v))
+		case num == payloadFieldPadding && typ == protoVarint:
+			p.Padding = PaddingMode(int32(v))
+		case num == payloadFieldIV && typ == protoBytes:
+			p.IV = append([]byte(nil), value...)
+		case num == payloadFieldKeyID && typ == protoBytes:
+			p.KeyID = string(value)
+		case num == payloadFieldWrappedKey && typ == protoBytes:
+			p.WrappedKey = append([]byte(nil), value...)
+		case num == payloadFieldCiphertext && typ == protoBytes:
+			p.Ciphertext = append([]byte(nil), value...)
+		case num == payloadFieldMetadata && typ == protoBytes:
+			var key, val string
+			err := walkProto(value, func(num, typ int, value []byte, _ uint64) error {
+				switch {
+				case num == 1 && typ == protoBytes:
+					key = string(value)
+				case num == 2 && typ == protoBytes:
+					val = string(value)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if p.Metadata == nil {
+				p.Metadata = make(map[string]string)
+			}
+			p.Metadata[key] = val
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "解析加密载荷失败")
+	}
+	return p, nil
+}
+
+// jsonPayloadCodec JSON编解码器
+type jsonPayloadCodec struct{}
+
+// Marshal 序列化为JSON
+func (jsonPayloadCodec) Marshal(p *EncryptedPayload) ([]byte, error) {
+	if p == nil {
+		return nil, errors.New("载荷不能为空")
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化加密载荷失败")
+	}
+	return data, nil
+}
+
+// Unmarshal 解析JSON
+func (jsonPayloadCodec) Unmarshal(data []byte) (*EncryptedPayload, error) {
+	p := &EncryptedPayload{}
+	if err := json.Unmarshal(data, p); err != nil {
+		return nil, errors.Wrap(err, "解析加密载荷失败")
+	}
+	return p, nil
+}
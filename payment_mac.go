@@ -0,0 +1,116 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"crypto/des"
+
+	"github.com/pkg/errors"
+)
+
+// 支付行业MAC
+//
+//   - RetailMAC：ANSI X9.19 / ISO 9797-1 MAC算法3（零填充），双倍长3DES密钥，
+//     前面的分组用单DES做CBC-MAC，最后一个分组做完整的3DES，常用于POS与DUKPT的TDES变体
+//   - CMAC：NIST SP 800-38B，支持AES、SM4与3DES，用于AES DUKPT与EMV的AES会话密钥
+
+// RetailMAC 计算ANSI X9.19零售MAC，key为16字节双倍长密钥，返回8字节MAC，调用方通常截取前4字节
+func RetailMAC(key, data []byte) ([]byte, error) {
+	// 零填充到8字节整数倍，空数据填充一个全零分组
+	padded := make([]byte, (len(data)+7)/8*8)
+	if len(padded) == 0 {
+		padded = make([]byte, 8)
+	}
+	copy(padded, data)
+	return retailMAC(key, padded)
+}
+
+// retailMAC 对已填充的数据计算ISO 9797-1 MAC算法3
+func retailMAC(key, padded []byte) ([]byte, error) {
+	if len(key) != 16 {
+		return nil, errors.New("零售MAC密钥长度必须是16字节")
+	}
+	left, err := des.NewCipher(key[:8])
+	if err != nil {
+		return nil, errors.Wrap(err, "创建DES失败")
+	}
+	right, err := des.NewCipher(key[8:])
+	if err != nil {
+		return nil, errors.Wrap(err, "创建DES失败")
+	}
+
+	mac := make([]byte, 8)
+	for i := 0; i < len(padded); i += 8 {
+		subtleXOR(mac, padded[i:i+8])
+		left.Encrypt(mac, mac)
+	}
+	right.Decrypt(mac, mac)
+	left.Encrypt(mac, mac)
+	return mac, nil
+}
+
+// CMAC 计算CMAC，algorithm为AlgorithmAES、AlgorithmSM4或Algorithm3DES（16或24字节密钥），返回完整分组长度的MAC
+func CMAC(algorithm Algorithm, key, data []byte) ([]byte, error) {
+	if algorithm != AlgorithmAES && algorithm != AlgorithmSM4 && algorithm != Algorithm3DES {
+		return nil, errors.New("CMAC仅支持AES、SM4与3DES")
+	}
+	block, err := newCachedBlock(algorithm, key)
+	if err != nil {
+		return nil, err
+	}
+	return cmac(block, data), nil
+}
+
+// cmac SP 800-38B CMAC
+func cmac(block cipher.Block, data []byte) []byte {
+	size := block.BlockSize()
+	k1 := make([]byte, size)
+	block.Encrypt(k1, k1)
+	cmacDouble(k1)
+	k2 := append([]byte(nil), k1...)
+	cmacDouble(k2)
+
+	// 最后一个分组：完整时异或K1，否则按10*填充后异或K2
+	n := (len(data) + size - 1) / size
+	last := make([]byte, size)
+	if n > 0 && len(data)%size == 0 {
+		copy(last, data[(n-1)*size:])
+		subtleXOR(last, k1)
+	} else {
+		if n == 0 {
+			n = 1
+		}
+		rest := data[(n-1)*size:]
+		copy(last, rest)
+		last[len(rest)] = 0x80
+		subtleXOR(last, k2)
+	}
+
+	mac := make([]byte, size)
+	for i := 0; i < n-1; i++ {
+		subtleXOR(mac, data[i*size:(i+1)*size])
+		block.Encrypt(mac, mac)
+	}
+	subtleXOR(mac, last)
+	block.Encrypt(mac, mac)
+	return mac
+}
+
+// cmacDouble 子密钥生成中的左移一位，溢出时异或Rb（128位分组0x87，64位分组0x1b）
+func cmacDouble(b []byte) {
+	rb := byte(0x87)
+	if len(b) == 8 {
+		rb = 0x1b
+	}
+	carry := b[0] >> 7
+	for i := 0; i < len(b)-1; i++ {
+		b[i] = b[i]<<1 | b[i+1]>>7
+	}
+	b[len(b)-1] = b[len(b)-1]<<1 ^ rb*carry
+}
+
+// subtleXOR dst ^= src
+func subtleXOR(dst, src []byte) {
+	for i := range src {
+		dst[i] ^= src[i]
+	}
+}
@@ -8,7 +8,6 @@ import (
 	"hash"
 	
 	"github.com/pkg/errors"
-	"github.com/tjfoc/gmsm/sm3"
 )
 
 // HashAlgorithm 哈希算法类型
@@ -92,7 +91,12 @@ func (p *PBKDF2Deriver) Hex() *PBKDF2Deriver {
 
 // getHashFunc 获取对应的哈希函数
 func (p *PBKDF2Deriver) getHashFunc() func() hash.Hash {
-	switch p.hashAlgo {
+	return hashFunc(p.hashAlgo)
+}
+
+// hashFunc 根据哈希算法类型获取对应的哈希函数
+func hashFunc(algo HashAlgorithm) func() hash.Hash {
+	switch algo {
 	case HashSHA1:
 		return sha1.New
 	case HashSHA256:
@@ -100,7 +104,7 @@ func (p *PBKDF2Deriver) getHashFunc() func() hash.Hash {
 	case HashSHA512:
 		return sha512.New
 	case HashSM3:
-		return sm3.New
+		return newSM3
 	default:
 		return sha256.New // 默认使用SHA-256
 	}
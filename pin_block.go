@@ -0,0 +1,158 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"encoding/hex"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// PIN块（ISO 9564-1）
+//
+//   - 格式0：8字节，PIN字段 0 | PIN长度 | PIN | F填充，与 0000 | 卡号右起12位（不含校验位）异或，
+//     用3DES加密，TDES DUKPT使用该格式
+//   - 格式4：16字节，用于AES，PIN字段 4 | PIN长度 | PIN | A填充 | 8字节随机数，
+//     卡号字段 卡号长度-12 | 卡号 | 0填充；加密为 E(K, E(K, PIN字段) ⊕ 卡号字段)，
+//     随机填充使相同的PIN每次加密结果不同
+
+// PIN块长度限制
+const (
+	pinMinLength = 4
+	pinMaxLength = 12
+)
+
+// PINBlockFormat0 生成格式0明文PIN块
+func PINBlockFormat0(pin, pan string) ([]byte, error) {
+	if err := checkPIN(pin); err != nil {
+		return nil, err
+	}
+	panField, err := panFieldFormat0(pan)
+	if err != nil {
+		return nil, err
+	}
+
+	field := "0" + string("0123456789ABC"[len(pin)]) + pin + strings.Repeat("F", 14-len(pin))
+	block, _ := hex.DecodeString(field)
+	subtleXOR(block, panField)
+	return block, nil
+}
+
+// ParsePINBlockFormat0 解析格式0明文PIN块
+func ParsePINBlockFormat0(block []byte, pan string) (string, error) {
+	if len(block) != 8 {
+		return "", errors.New("格式0 PIN块长度必须是8字节")
+	}
+	panField, err := panFieldFormat0(pan)
+	if err != nil {
+		return "", err
+	}
+	field := append([]byte(nil), block...)
+	subtleXOR(field, panField)
+	defer zeroBytes(field)
+
+	digits := strings.ToUpper(hex.EncodeToString(field))
+	length := int(field[0] & 0x0f)
+	if field[0]>>4 != 0 || length < pinMinLength || length > pinMaxLength {
+		return "", errors.New("PIN块格式不正确")
+	}
+	pin := digits[2 : 2+length]
+	if strings.Trim(pin, "0123456789") != "" || strings.Trim(digits[2+length:], "F") != "" {
+		return "", errors.New("PIN块格式不正确")
+	}
+	return pin, nil
+}
+
+// EncryptPINBlockFormat4 使用AES密钥生成并加密格式4 PIN块
+func EncryptPINBlockFormat4(key []byte, pin, pan string) ([]byte, error) {
+	if err := checkPIN(pin); err != nil {
+		return nil, err
+	}
+	panField, err := panFieldFormat4(pan)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建AES失败")
+	}
+
+	field, _ := hex.DecodeString("4" + string("0123456789ABC"[len(pin)]) + pin + strings.Repeat("A", 14-len(pin)))
+	random, err := GenerateRandomBytes(8)
+	if err != nil {
+		return nil, err
+	}
+	field = append(field, random...)
+	defer zeroBytes(field)
+
+	out := make([]byte, 16)
+	block.Encrypt(out, field)
+	subtleXOR(out, panField)
+	block.Encrypt(out, out)
+	return out, nil
+}
+
+// DecryptPINBlockFormat4 解密格式4 PIN块
+func DecryptPINBlockFormat4(key, encrypted []byte, pan string) (string, error) {
+	if len(encrypted) != 16 {
+		return "", errors.New("格式4 PIN块长度必须是16字节")
+	}
+	panField, err := panFieldFormat4(pan)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", errors.Wrap(err, "创建AES失败")
+	}
+
+	field := make([]byte, 16)
+	defer zeroBytes(field)
+	block.Decrypt(field, encrypted)
+	subtleXOR(field, panField)
+	block.Decrypt(field, field)
+
+	digits := strings.ToUpper(hex.EncodeToString(field[:8]))
+	length := int(field[0] & 0x0f)
+	if field[0]>>4 != 4 || length < pinMinLength || length > pinMaxLength {
+		return "", errors.New("PIN块格式不正确")
+	}
+	pin := digits[2 : 2+length]
+	if strings.Trim(pin, "0123456789") != "" || strings.Trim(digits[2+length:], "A") != "" {
+		return "", errors.New("PIN块格式不正确")
+	}
+	return pin, nil
+}
+
+// checkPIN 校验PIN为4到12位数字
+func checkPIN(pin string) error {
+	if len(pin) < pinMinLength || len(pin) > pinMaxLength || strings.Trim(pin, "0123456789") != "" {
+		return errors.New("PIN必须是4到12位数字")
+	}
+	return nil
+}
+
+// panFieldFormat0 0000 | 卡号右起12位（不含校验位）
+func panFieldFormat0(pan string) ([]byte, error) {
+	if len(pan) < 13 || len(pan) > 19 || strings.Trim(pan, "0123456789") != "" {
+		return nil, errors.New("卡号必须是13到19位数字")
+	}
+	field, _ := hex.DecodeString("0000" + pan[len(pan)-13:len(pan)-1])
+	return field, nil
+}
+
+// panFieldFormat4 卡号长度-12 | 卡号 | 0填充，不足12位的卡号左补0
+func panFieldFormat4(pan string) ([]byte, error) {
+	if len(pan) < 1 || len(pan) > 19 || strings.Trim(pan, "0123456789") != "" {
+		return nil, errors.New("卡号必须是1到19位数字")
+	}
+	m := 0
+	if len(pan) > 12 {
+		m = len(pan) - 12
+	} else {
+		pan = strings.Repeat("0", 12-len(pan)) + pan
+	}
+	digits := string("01234567"[m]) + pan
+	field, _ := hex.DecodeString(digits + strings.Repeat("0", 32-len(digits)))
+	return field, nil
+}
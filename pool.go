@@ -208,6 +208,7 @@ func (s *AESEncryptor) Reset() {
 	s.blockMode = NewCBCMode(nil)
 	s.padding = DefaultPKCS7Padding
 	s.encoding = Base64Encoding
+	s.hardening.reset()
 }
 
 // Release 释放AES加密器到对象池
@@ -240,6 +241,7 @@ func (s *DESEncryptor) Reset() {
 	s.blockMode = NewCBCMode(nil)
 	s.padding = DefaultPKCS7Padding
 	s.encoding = Base64Encoding
+	s.hardening.reset()
 }
 
 // Release 释放DES加密器到对象池
@@ -272,6 +274,7 @@ func (s *TripleDESEncryptor) Reset() {
 	s.blockMode = NewCBCMode(nil)
 	s.padding = DefaultPKCS7Padding
 	s.encoding = Base64Encoding
+	s.hardening.reset()
 }
 
 // Release 释放3DES加密器到对象池
@@ -304,6 +307,7 @@ func (s *SM4Encryptor) Reset() {
 	s.blockMode = ModeCBC
 	s.padding = DefaultPKCS7Padding
 	s.encoding = Base64Encoding
+	s.hardening.reset()
 	s.encodingMode = EncodingBase64
 }
 
@@ -0,0 +1,374 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/sha256"
+
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/pkg/errors"
+)
+
+// 代理重加密（实验性）
+//
+// 文档分享场景中，Alice把文档加密给自己，授权Bob阅读时生成一把重加密密钥交给半可信的代理（存储服务），
+// 代理把密文转换为Bob可以解密的形式，转换过程中看不到明文与数据密钥，Alice也不必在线或重新上传。
+// AFGH方案依赖双线性配对，本库没有配对实现，这里采用不需要配对的Umbral方案（单代理，阈值为1），
+// 基于secp256k1，重加密是单向、非交互的：生成重加密密钥只需要Bob的公钥。
+//
+//	加密：     E=r·G, V=u·G, s=u+r·H(E,V)，K=KDF((r+u)·pkA)
+//	            密文 = 0x01 | E(33) | V(33) | s(32) | AES-256-GCM(K, 明文)
+//	重加密密钥：X=x·G, d=H(X, pkB, x·pkB)，rk = a/d，输出 rk(32) | X(33)
+//	重加密：   E'=rk·E, V'=rk·V，密文 = 0x02 | E | V | s | E'(33) | V'(33) | X(33) | AES-GCM密文
+//	Bob解密：  d=H(X, pkB, b·X)，K=KDF(d·(E'+V'))
+//
+// 代理与Bob合谋可以算出Alice的私钥（a = rk·d），只应把重加密密钥交给不会与被授权方合谋的代理；
+// 撤销授权只能靠代理删除重加密密钥。secp256k1库只提供非常量时间的标量乘法，
+// 不应在可被精确计时的共享主机上处理私钥
+
+// 代理重加密格式常量
+const (
+	preVersionOriginal    = 0x01
+	preVersionReEncrypted = 0x02
+	prePointSize          = 33
+	preScalarSize         = 32
+	preCapsuleSize        = 1 + 2*prePointSize + preScalarSize
+	preReKeySize          = preScalarSize + prePointSize
+	preInfo               = "sylphbyte/encrypt pre v1"
+)
+
+// PREPrivateKey 代理重加密私钥
+type PREPrivateKey struct {
+	key *secp256k1.PrivateKey
+}
+
+// GeneratePREKey 生成随机私钥
+func GeneratePREKey() (*PREPrivateKey, error) {
+	key, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成私钥失败")
+	}
+	return &PREPrivateKey{key: key}, nil
+}
+
+// NewPREPrivateKey 从32字节私钥创建
+func NewPREPrivateKey(privateKey []byte) (*PREPrivateKey, error) {
+	scalar, err := preParseScalar(privateKey)
+	if err != nil || scalar.IsZero() {
+		return nil, errors.New("私钥超出secp256k1曲线阶范围")
+	}
+	return &PREPrivateKey{key: secp256k1.NewPrivateKey(scalar)}, nil
+}
+
+// Bytes 32字节私钥
+func (k *PREPrivateKey) Bytes() []byte {
+	return k.key.Serialize()
+}
+
+// PublicKey 33字节压缩公钥
+func (k *PREPrivateKey) PublicKey() []byte {
+	return k.key.PubKey().SerializeCompressed()
+}
+
+// PREEncrypt 加密给公钥持有者，aad为调用方的附加认证数据
+func PREEncrypt(publicKey, plaintext, aad []byte) ([]byte, error) {
+	pk, err := secp256k1.ParsePubKey(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "公钥格式不正确")
+	}
+
+	r, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成随机数失败")
+	}
+	u, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成随机数失败")
+	}
+	defer r.Zero()
+	defer u.Zero()
+
+	e, v := r.PubKey(), u.PubKey()
+	s := preCapsuleHash(e, v)
+	s.Mul(&r.Key).Add(&u.Key)
+
+	var sum secp256k1.ModNScalar
+	sum.Set(&r.Key).Add(&u.Key)
+	shared, err := preMul(&sum, pk)
+	if err != nil {
+		return nil, err
+	}
+	sum.Zero()
+
+	capsule := make([]byte, 0, preCapsuleSize)
+	capsule = append(capsule, preVersionOriginal)
+	capsule = append(capsule, e.SerializeCompressed()...)
+	capsule = append(capsule, v.SerializeCompressed()...)
+	capsule = append(capsule, preScalarBytes(s)...)
+	return preSeal(capsule, shared, plaintext, aad)
+}
+
+// PREDecrypt 私钥持有者直接解密未经重加密的密文
+func PREDecrypt(privateKey *PREPrivateKey, ciphertext, aad []byte) ([]byte, error) {
+	e, v, _, err := preParseCapsule(ciphertext, preVersionOriginal)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := preMul(&privateKey.key.Key, preAdd(e, v))
+	if err != nil {
+		return nil, err
+	}
+	return preOpen(ciphertext[:preCapsuleSize], shared, ciphertext[preCapsuleSize:], aad)
+}
+
+// PREReKey 生成把delegator的密文转换给delegatee公钥的重加密密钥，交给代理保存
+func PREReKey(delegator *PREPrivateKey, delegateePublicKey []byte) ([]byte, error) {
+	pkB, err := secp256k1.ParsePubKey(delegateePublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "被授权方公钥格式不正确")
+	}
+	x, err := secp256k1.GeneratePrivateKey()
+	if err != nil {
+		return nil, errors.Wrap(err, "生成随机数失败")
+	}
+	defer x.Zero()
+
+	xPub := x.PubKey()
+	shared, err := preMul(&x.Key, pkB)
+	if err != nil {
+		return nil, err
+	}
+	d := preDelegationHash(xPub, pkB, shared)
+	if d.IsZero() {
+		return nil, errors.New("生成重加密密钥失败，请重试")
+	}
+
+	rk := new(secp256k1.ModNScalar).InverseValNonConst(d).Mul(&delegator.key.Key)
+	defer rk.Zero()
+	return append(preScalarBytes(rk), xPub.SerializeCompressed()...), nil
+}
+
+// PREReEncrypt 代理使用重加密密钥转换密文，过程中无法得到明文
+func PREReEncrypt(reKey, ciphertext []byte) ([]byte, error) {
+	if len(reKey) != preReKeySize {
+		return nil, errors.New("重加密密钥长度不正确")
+	}
+	rk, err := preParseScalar(reKey[:preScalarSize])
+	if err != nil || rk.IsZero() {
+		return nil, errors.New("重加密密钥格式不正确")
+	}
+	if _, err := secp256k1.ParsePubKey(reKey[preScalarSize:]); err != nil {
+		return nil, errors.Wrap(err, "重加密密钥格式不正确")
+	}
+
+	e, v, s, err := preParseCapsule(ciphertext, preVersionOriginal)
+	if err != nil {
+		return nil, err
+	}
+	// 拒绝被篡改的胶囊，避免代理成为解密预言机
+	if !preCapsuleValid(e, v, s) {
+		return nil, errors.New("密文胶囊校验失败")
+	}
+
+	e1, err := preMul(rk, e)
+	if err != nil {
+		return nil, err
+	}
+	v1, err := preMul(rk, v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, 0, len(ciphertext)+3*prePointSize)
+	out = append(out, preVersionReEncrypted)
+	out = append(out, ciphertext[1:preCapsuleSize]...)
+	out = append(out, e1.SerializeCompressed()...)
+	out = append(out, v1.SerializeCompressed()...)
+	out = append(out, reKey[preScalarSize:]...)
+	return append(out, ciphertext[preCapsuleSize:]...), nil
+}
+
+// PREDecryptReEncrypted 被授权方解密重加密后的密文，delegatorPublicKey为授权方公钥
+func PREDecryptReEncrypted(delegatee *PREPrivateKey, delegatorPublicKey, ciphertext, aad []byte) ([]byte, error) {
+	pkA, err := secp256k1.ParsePubKey(delegatorPublicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "授权方公钥格式不正确")
+	}
+	e, v, s, err := preParseCapsule(ciphertext, preVersionReEncrypted)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < preCapsuleSize+3*prePointSize {
+		return nil, errors.New("密文长度不足")
+	}
+	points := make([]*secp256k1.PublicKey, 3)
+	for i := range points {
+		offset := preCapsuleSize + i*prePointSize
+		if points[i], err = secp256k1.ParsePubKey(ciphertext[offset : offset+prePointSize]); err != nil {
+			return nil, errors.Wrap(err, "重加密密文格式不正确")
+		}
+	}
+	e1, v1, xPub := points[0], points[1], points[2]
+
+	shared, err := preMul(&delegatee.key.Key, xPub)
+	if err != nil {
+		return nil, err
+	}
+	d := preDelegationHash(xPub, delegatee.key.PubKey(), shared)
+
+	// (s/d)·pkA 应等于 V' + H(E,V)·E'，确认转换结果属于该胶囊与授权方
+	check := new(secp256k1.ModNScalar).InverseValNonConst(d).Mul(s)
+	left, err := preMul(check, pkA)
+	if err != nil {
+		return nil, err
+	}
+	right, err := preMul(preCapsuleHash(e, v), e1)
+	if err != nil {
+		return nil, err
+	}
+	if !left.IsEqual(preAdd(v1, right)) {
+		return nil, errors.New("重加密结果校验失败")
+	}
+
+	key, err := preMul(d, preAdd(e1, v1))
+	if err != nil {
+		return nil, err
+	}
+	capsule := append([]byte{preVersionOriginal}, ciphertext[1:preCapsuleSize]...)
+	return preOpen(capsule, key, ciphertext[preCapsuleSize+3*prePointSize:], aad)
+}
+
+// preSeal 由共享点派生数据密钥并加密，胶囊作为附加认证数据的一部分
+func preSeal(capsule []byte, shared *secp256k1.PublicKey, plaintext, aad []byte) ([]byte, error) {
+	key, err := preDataKey(shared, capsule)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+	ciphertext, err := AESGCMEncrypt(key, plaintext, append(append([]byte(nil), capsule...), aad...))
+	if err != nil {
+		return nil, err
+	}
+	return append(capsule, ciphertext...), nil
+}
+
+// preOpen 由共享点派生数据密钥并解密
+func preOpen(capsule []byte, shared *secp256k1.PublicKey, ciphertext, aad []byte) ([]byte, error) {
+	key, err := preDataKey(shared, capsule)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(key)
+	return AESGCMDecrypt(key, ciphertext, append(append([]byte(nil), capsule...), aad...))
+}
+
+// preDataKey HKDF-SHA256(共享点, info=上下文 || 胶囊)
+func preDataKey(shared *secp256k1.PublicKey, capsule []byte) ([]byte, error) {
+	key, err := hkdf.Key(sha256.New, shared.SerializeCompressed(), nil, preInfo+string(capsule), 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生数据密钥失败")
+	}
+	return key, nil
+}
+
+// preParseCapsule 解析胶囊 E | V | s
+func preParseCapsule(data []byte, version byte) (e, v *secp256k1.PublicKey, s *secp256k1.ModNScalar, err error) {
+	if len(data) < preCapsuleSize {
+		return nil, nil, nil, errors.New("密文长度不足")
+	}
+	if data[0] != version {
+		if data[0] == preVersionOriginal || data[0] == preVersionReEncrypted {
+			return nil, nil, nil, errors.New("密文类型与解密方式不符")
+		}
+		return nil, nil, nil, errors.Errorf("不支持的代理重加密密文版本: %d", data[0])
+	}
+	if e, err = secp256k1.ParsePubKey(data[1 : 1+prePointSize]); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "密文胶囊格式不正确")
+	}
+	if v, err = secp256k1.ParsePubKey(data[1+prePointSize : 1+2*prePointSize]); err != nil {
+		return nil, nil, nil, errors.Wrap(err, "密文胶囊格式不正确")
+	}
+	if s, err = preParseScalar(data[1+2*prePointSize : preCapsuleSize]); err != nil {
+		return nil, nil, nil, err
+	}
+	return e, v, s, nil
+}
+
+// preCapsuleValid 校验 s·G == V + H(E,V)·E
+func preCapsuleValid(e, v *secp256k1.PublicKey, s *secp256k1.ModNScalar) bool {
+	var left secp256k1.JacobianPoint
+	secp256k1.ScalarBaseMultNonConst(s, &left)
+	if (left.X.IsZero() && left.Y.IsZero()) || left.Z.IsZero() {
+		return false
+	}
+	left.ToAffine()
+	right, err := preMul(preCapsuleHash(e, v), e)
+	if err != nil {
+		return false
+	}
+	return secp256k1.NewPublicKey(&left.X, &left.Y).IsEqual(preAdd(v, right))
+}
+
+// preCapsuleHash H(E, V)
+func preCapsuleHash(e, v *secp256k1.PublicKey) *secp256k1.ModNScalar {
+	return preHashToScalar("capsule", e.SerializeCompressed(), v.SerializeCompressed())
+}
+
+// preDelegationHash H(X, pkB, 共享点)
+func preDelegationHash(x, pkB, shared *secp256k1.PublicKey) *secp256k1.ModNScalar {
+	return preHashToScalar("delegation", x.SerializeCompressed(), pkB.SerializeCompressed(), shared.SerializeCompressed())
+}
+
+// preHashToScalar 带域标签的SHA-256映射到标量，曲线阶接近2^256，取模的偏差可以忽略
+func preHashToScalar(tag string, parts ...[]byte) *secp256k1.ModNScalar {
+	h := sha256.New()
+	h.Write([]byte(preInfo))
+	h.Write([]byte{byte(len(tag))})
+	h.Write([]byte(tag))
+	for _, part := range parts {
+		h.Write(part)
+	}
+	var scalar secp256k1.ModNScalar
+	scalar.SetByteSlice(h.Sum(nil))
+	return &scalar
+}
+
+// preMul 标量乘法，结果为无穷远点时报错
+func preMul(k *secp256k1.ModNScalar, point *secp256k1.PublicKey) (*secp256k1.PublicKey, error) {
+	var p, result secp256k1.JacobianPoint
+	point.AsJacobian(&p)
+	secp256k1.ScalarMultNonConst(k, &p, &result)
+	if result.Z.IsZero() || (result.X.IsZero() && result.Y.IsZero()) {
+		return nil, errors.New("标量乘法结果无效")
+	}
+	result.ToAffine()
+	return secp256k1.NewPublicKey(&result.X, &result.Y), nil
+}
+
+// preAdd 点加法
+func preAdd(a, b *secp256k1.PublicKey) *secp256k1.PublicKey {
+	var p, q, result secp256k1.JacobianPoint
+	a.AsJacobian(&p)
+	b.AsJacobian(&q)
+	secp256k1.AddNonConst(&p, &q, &result)
+	result.ToAffine()
+	return secp256k1.NewPublicKey(&result.X, &result.Y)
+}
+
+// preParseScalar 解析32字节标量，不接受大于等于曲线阶的值
+func preParseScalar(data []byte) (*secp256k1.ModNScalar, error) {
+	if len(data) != preScalarSize {
+		return nil, errors.New("标量长度必须是32字节")
+	}
+	var scalar secp256k1.ModNScalar
+	if overflow := scalar.SetByteSlice(data); overflow {
+		return nil, errors.New("标量超出曲线阶范围")
+	}
+	return &scalar, nil
+}
+
+// preScalarBytes 32字节大端编码
+func preScalarBytes(s *secp256k1.ModNScalar) []byte {
+	b := s.Bytes()
+	return bytes.Clone(b[:])
+}
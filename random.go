@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"sync"
+
+	"github.com/pkg/errors"
 )
 
 // 定义一个随机读取器接口，便于在测试中进行模拟
@@ -20,6 +22,29 @@ var (
 	randomLock sync.Mutex
 )
 
+// EntropySource 熵源，Read输出原始熵样本（每字节一个样本）
+// MinEntropy返回每个样本声明的最小熵（比特，0~8），健康测试据此计算阈值
+type EntropySource interface {
+	RandomReader
+	MinEntropy() float64
+}
+
+// osEntropySource 操作系统随机数源
+type osEntropySource struct{}
+
+// Read 读取操作系统随机数
+func (osEntropySource) Read(p []byte) (int, error) {
+	return io.ReadFull(rand.Reader, p)
+}
+
+// MinEntropy 操作系统随机数已经过调节，按满熵计
+func (osEntropySource) MinEntropy() float64 {
+	return 8
+}
+
+// OSEntropySource 操作系统随机数熵源（crypto/rand）
+var OSEntropySource EntropySource = osEntropySource{}
+
 // SetRandomReader 设置自定义随机数生成器（主要用于测试）
 func SetRandomReader(reader RandomReader) {
 	if reader == nil {
@@ -32,6 +57,34 @@ func SetRandomReader(reader RandomReader) {
 	defaultRandomReader = reader
 }
 
+// SetEntropySource 使用经健康测试的熵源作为默认随机数生成器，多个熵源时混合输出，熵源故障时随机数生成返回错误。
+// 需要故障回调时先用NewHealthTestedSource创建并设置OnFailure再传入
+func SetEntropySource(sources ...EntropySource) error {
+	if len(sources) == 0 {
+		return errors.New("至少需要一个熵源")
+	}
+
+	tested := make([]EntropySource, len(sources))
+	for i, source := range sources {
+		if health, ok := source.(*HealthTestedSource); ok {
+			tested[i] = health
+			continue
+		}
+		health, err := NewHealthTestedSource(source)
+		if err != nil {
+			return err
+		}
+		tested[i] = health
+	}
+
+	if len(tested) == 1 {
+		SetRandomReader(tested[0])
+		return nil
+	}
+	SetRandomReader(NewMixedEntropySource(tested...))
+	return nil
+}
+
 // ReadRandom 安全地生成随机字节
 // 该函数是线程安全的，适合高并发场景
 func ReadRandom(p []byte) (int, error) {
@@ -0,0 +1,94 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 顺序揭示加密（ORE，Chenette-Lewi-Weis-Wu 2016）
+//
+// 警告：这是一个有意弱化安全性的实验性方案，请在充分理解以下代价后再使用：
+//   - 任意两个密文可以比较大小，攻击者可据此恢复数据的完整排序；
+//   - 比较时还会泄露两个明文第一个不同比特的位置，即二者的大致差距；
+//   - 对于时间戳这类分布集中、可预测的数据，结合已知样本足以推断出大量明文。
+//
+// 仅当必须在不可信环境中执行范围查询，且数据本来以明文保存时才考虑使用，
+// 原值仍应使用常规的认证加密单独保存。密文需要用CompareRangeIndex比较，不能按字节序比较
+
+// rangeIndexBits 明文位数
+const rangeIndexBits = 64
+
+// ExperimentalInsecureRangeIndex 实验性的可比较范围索引，安全性弱，见文件头部的说明
+type ExperimentalInsecureRangeIndex struct {
+	key []byte
+}
+
+// NewExperimentalInsecureRangeIndex 创建范围索引，密钥至少16字节
+func NewExperimentalInsecureRangeIndex(key []byte) (*ExperimentalInsecureRangeIndex, error) {
+	if len(key) < 16 {
+		return nil, errors.New("范围索引密钥长度至少需要16字节")
+	}
+	return &ExperimentalInsecureRangeIndex{key: append([]byte(nil), key...)}, nil
+}
+
+// Encrypt 加密无符号整数，输出64字节，每字节对应一个比特位
+// u_i = (PRF(k, i, x的前i位) + x_i) mod 3
+func (r *ExperimentalInsecureRangeIndex) Encrypt(v uint64) []byte {
+	out := make([]byte, rangeIndexBits)
+	mac := hmac.New(sha256.New, r.key)
+	var input [9]byte
+
+	for i := 0; i < rangeIndexBits; i++ {
+		// 保留最高的i位作为前缀
+		var prefix uint64
+		if i > 0 {
+			prefix = v &^ (^uint64(0) >> uint(i))
+		}
+		input[0] = byte(i)
+		binary.BigEndian.PutUint64(input[1:], prefix)
+
+		mac.Reset()
+		mac.Write(input[:])
+		sum := mac.Sum(nil)
+
+		bit := byte(v>>uint(rangeIndexBits-1-i)) & 1
+		out[i] = byte((uint16(binary.BigEndian.Uint16(sum))%3 + uint16(bit)) % 3)
+	}
+	return out
+}
+
+// EncryptInt64 加密有符号整数，翻转符号位使负数排在正数之前
+func (r *ExperimentalInsecureRangeIndex) EncryptInt64(v int64) []byte {
+	return r.Encrypt(uint64(v) ^ (1 << 63))
+}
+
+// EncryptTime 按纳秒精度的Unix时间加密时间戳
+func (r *ExperimentalInsecureRangeIndex) EncryptTime(t time.Time) []byte {
+	return r.EncryptInt64(t.UnixNano())
+}
+
+// CompareRangeIndex 比较两个由同一密钥生成的范围索引
+// a对应的明文小于、等于、大于b时分别返回-1、0、1
+func CompareRangeIndex(a, b []byte) (int, error) {
+	if len(a) != rangeIndexBits || len(b) != rangeIndexBits {
+		return 0, errors.New("范围索引长度不正确")
+	}
+
+	for i := 0; i < rangeIndexBits; i++ {
+		if a[i] > 2 || b[i] > 2 {
+			return 0, errors.New("范围索引格式不正确")
+		}
+		if a[i] == b[i] {
+			continue
+		}
+		if a[i] == (b[i]+1)%3 {
+			return 1, nil
+		}
+		return -1, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,130 @@
+package encrypt
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 滑动窗口防重放（RFC 4303 第3.4.3节、RFC 6479）
+//
+// 记录已接收的最大序号与其之前size个序号的接收情况：
+// 比窗口更旧的序号与已接收过的序号都会被拒绝，窗口内乱序到达的消息仍可接受。
+// 应先Check再解密，认证通过后再Update，避免伪造的消息推动窗口
+
+// DefaultReplayWindowSize 默认窗口大小
+const DefaultReplayWindowSize = 1024
+
+// maxReplayWindowSize 窗口大小上限
+const maxReplayWindowSize = 1 << 16
+
+// ReplayWindow 序号防重放窗口，并发安全
+type ReplayWindow struct {
+	mu     sync.Mutex
+	bitmap []uint64
+	size   uint64
+	top    uint64
+	seen   bool
+}
+
+// NewReplayWindow 创建防重放窗口，size会向上取整到64的倍数
+func NewReplayWindow(size int) (*ReplayWindow, error) {
+	if size <= 0 || size > maxReplayWindowSize {
+		return nil, errors.Errorf("窗口大小必须在1到%d之间", maxReplayWindowSize)
+	}
+
+	words := (size + 63) / 64
+	return &ReplayWindow{
+		bitmap: make([]uint64, words),
+		size:   uint64(words * 64),
+	}, nil
+}
+
+// Check 检查序号是否可以接受，不修改窗口状态
+func (w *ReplayWindow) Check(seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.check(seq)
+}
+
+// Update 标记序号已接收并在需要时向前滑动窗口，应在消息认证通过后调用
+func (w *ReplayWindow) Update(seq uint64) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.check(seq); err != nil {
+		return err
+	}
+
+	if !w.seen || seq > w.top {
+		w.advance(seq)
+	}
+	w.bitmap[(seq%w.size)/64] |= 1 << (seq % 64)
+	return nil
+}
+
+// Highest 获取已接收的最大序号，尚未接收任何消息时ok为false
+func (w *ReplayWindow) Highest() (seq uint64, ok bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.top, w.seen
+}
+
+// Reset 清空窗口，通常在更换密钥后调用
+func (w *ReplayWindow) Reset() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for i := range w.bitmap {
+		w.bitmap[i] = 0
+	}
+	w.top = 0
+	w.seen = false
+}
+
+// check 检查序号，调用方需持有锁
+func (w *ReplayWindow) check(seq uint64) error {
+	if !w.seen || seq > w.top {
+		return nil
+	}
+	if w.top-seq >= w.size {
+		return errors.New("序号超出防重放窗口")
+	}
+	if w.bitmap[(seq%w.size)/64]&(1<<(seq%64)) != 0 {
+		return errors.New("检测到重放消息")
+	}
+	return nil
+}
+
+// advance 将窗口顶端移动到seq，并清除滑出窗口的位，调用方需持有锁
+func (w *ReplayWindow) advance(seq uint64) {
+	if !w.seen || seq-w.top >= w.size {
+		for i := range w.bitmap {
+			w.bitmap[i] = 0
+		}
+	} else {
+		for s := w.top + 1; s <= seq; s++ {
+			w.bitmap[(s%w.size)/64] &^= 1 << (s % 64)
+		}
+	}
+	w.top = seq
+	w.seen = true
+}
+
+// OpenWindow 使用显式序号解密数据报，并通过防重放窗口拒绝重放与过旧的消息
+// 只有解密认证成功的消息才会更新窗口
+func (c *SessionCipher) OpenWindow(window *ReplayWindow, seq uint64, ciphertext, aad []byte) ([]byte, error) {
+	if err := window.Check(seq); err != nil {
+		return nil, err
+	}
+
+	plaintext, err := c.OpenSequence(seq, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := window.Update(seq); err != nil {
+		return nil, err
+	}
+	return plaintext, nil
+}
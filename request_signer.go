@@ -0,0 +1,437 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 请求规范化与签名
+//
+// 参照AWS SigV4的通用请求签名，内部API统一使用，避免各团队各自实现。签名覆盖规范请求：
+//
+//	方法\n
+//	规范路径（逐段URI编码）\n
+//	规范查询串（按键、值排序，RFC 3986编码）\n
+//	规范头部（小写名称:去除首尾空白的值\n，按名称排序）\n
+//	签名头部列表（分号分隔）\n
+//	hex(H(请求体))
+//
+// 待签名字符串为：
+//
+//	HMAC-<哈希>\n时间戳\n凭证范围\nhex(H(规范请求))
+//
+// 签名写入Authorization头：
+//
+//	HMAC-SHA256 Credential=<密钥ID>/<日期>/<范围>, SignedHeaders=host;x-sign-date, Signature=<hex>
+//
+// 签名密钥默认直接使用共享密钥，可通过WithKeyDerivation改为按日期与范围逐级派生（SigV4KeyDerivation），
+// 使泄露的派生密钥只在当天、当前范围内有效
+
+// 请求签名相关头部
+const (
+	// RequestSignDateHeader 签名时间头部，格式20060102T150405Z
+	RequestSignDateHeader = "X-Sign-Date"
+	// requestSignDateFormat 签名时间格式
+	requestSignDateFormat = "20060102T150405Z"
+	// requestSignDayFormat 凭证范围中的日期格式
+	requestSignDayFormat = "20060102"
+)
+
+// RequestKeyDerivation 签名密钥派生函数，date为yyyymmdd，scope为凭证范围
+type RequestKeyDerivation func(secret []byte, date, scope string, hashAlgo HashAlgorithm) []byte
+
+// SigV4KeyDerivation SigV4方式的逐级派生：k = HMAC(prefix+secret, date)，再依次对范围中的每一段及terminator做HMAC
+func SigV4KeyDerivation(prefix, terminator string) RequestKeyDerivation {
+	return func(secret []byte, date, scope string, hashAlgo HashAlgorithm) []byte {
+		key := requestHMAC(hashAlgo, append([]byte(prefix), secret...), []byte(date))
+		parts := strings.Split(scope, "/")
+		if scope == "" {
+			parts = nil
+		}
+		if terminator != "" {
+			parts = append(parts, terminator)
+		}
+		for _, part := range parts {
+			key = requestHMAC(hashAlgo, key, []byte(part))
+		}
+		return key
+	}
+}
+
+// RequestSigner HTTP请求签名器，同时用于签名与验证
+type RequestSigner struct {
+	keyID         string
+	secret        []byte
+	lookup        func(keyID string) ([]byte, error)
+	hashAlgo      HashAlgorithm
+	scope         string
+	signedHeaders []string
+	derive        RequestKeyDerivation
+	tolerance     time.Duration
+	now           func() time.Time
+}
+
+// NewRequestSigner 创建请求签名器，默认HMAC-SHA256，签名host、content-type与签名时间
+func NewRequestSigner(keyID string, secret []byte) *RequestSigner {
+	return &RequestSigner{
+		keyID:         keyID,
+		secret:        append([]byte(nil), secret...),
+		hashAlgo:      HashSHA256,
+		signedHeaders: []string{"host", "content-type", strings.ToLower(RequestSignDateHeader)},
+		tolerance:     5 * time.Minute,
+		now:           time.Now,
+	}
+}
+
+// WithHash 设置哈希算法，支持SHA256、SHA512与SM3
+func (s *RequestSigner) WithHash(hashAlgo HashAlgorithm) *RequestSigner {
+	s.hashAlgo = hashAlgo
+	return s
+}
+
+// WithScope 设置凭证范围，如"cn-north-1/orders"，验证方要求范围一致
+func (s *RequestSigner) WithScope(scope string) *RequestSigner {
+	s.scope = scope
+	return s
+}
+
+// WithSignedHeaders 追加必须签名的头部，签名时间头部始终签名
+func (s *RequestSigner) WithSignedHeaders(headers ...string) *RequestSigner {
+	for _, h := range headers {
+		h = strings.ToLower(strings.TrimSpace(h))
+		if h != "" && !containsString(s.signedHeaders, h) {
+			s.signedHeaders = append(s.signedHeaders, h)
+		}
+	}
+	return s
+}
+
+// WithKeyDerivation 设置签名密钥派生方式，默认直接使用共享密钥
+func (s *RequestSigner) WithKeyDerivation(derive RequestKeyDerivation) *RequestSigner {
+	s.derive = derive
+	return s
+}
+
+// WithKeyLookup 设置验证时按密钥ID查找共享密钥，用于服务端同时接受多个调用方
+func (s *RequestSigner) WithKeyLookup(lookup func(keyID string) ([]byte, error)) *RequestSigner {
+	s.lookup = lookup
+	return s
+}
+
+// WithTolerance 设置验证时签名时间的容忍范围，小于等于0时不检查
+func (s *RequestSigner) WithTolerance(tolerance time.Duration) *RequestSigner {
+	s.tolerance = tolerance
+	return s
+}
+
+// WithClock 设置时钟，主要用于测试
+func (s *RequestSigner) WithClock(now func() time.Time) *RequestSigner {
+	s.now = now
+	return s
+}
+
+// Sign 为请求签名，设置签名时间与Authorization头，请求体会被读取后重新放回
+func (s *RequestSigner) Sign(req *http.Request) error {
+	name, err := hashAlgorithmName(s.hashAlgo)
+	if err != nil {
+		return err
+	}
+	body, err := readRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	req.Header.Set(RequestSignDateHeader, now.Format(requestSignDateFormat))
+
+	// 只签名请求中实际存在的头部，如GET请求没有content-type
+	var headers []string
+	for _, h := range s.signedHeaders {
+		if requestHeaderValue(req, h) != "" {
+			headers = append(headers, h)
+		}
+	}
+	sort.Strings(headers)
+
+	credentialScope := now.Format(requestSignDayFormat)
+	if s.scope != "" {
+		credentialScope += "/" + s.scope
+	}
+	signature, err := s.signature(s.secret, req, headers, body, now, credentialScope)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "HMAC-"+name+" Credential="+s.keyID+"/"+credentialScope+
+		", SignedHeaders="+strings.Join(headers, ";")+", Signature="+hex.EncodeToString(signature))
+	return nil
+}
+
+// Verify 验证请求签名，返回调用方的密钥ID
+func (s *RequestSigner) Verify(req *http.Request) (string, error) {
+	auth, err := parseRequestAuthorization(req.Header.Get("Authorization"))
+	if err != nil {
+		return "", err
+	}
+	name, err := hashAlgorithmName(s.hashAlgo)
+	if err != nil {
+		return "", err
+	}
+	if auth.algorithm != "HMAC-"+name {
+		return auth.keyID, errors.Errorf("签名算法不符: %s", auth.algorithm)
+	}
+
+	// 范围与签名头部必须满足本地要求，不能由请求方削减
+	if auth.scope != s.scope {
+		return auth.keyID, errors.New("凭证范围不符")
+	}
+	for _, h := range s.signedHeaders {
+		if requestHeaderValue(req, h) != "" && !containsString(auth.headers, h) {
+			return auth.keyID, errors.Errorf("头部%s未签名", h)
+		}
+	}
+
+	signedAt, err := time.Parse(requestSignDateFormat, req.Header.Get(RequestSignDateHeader))
+	if err != nil {
+		return auth.keyID, errors.Wrap(err, "签名时间格式不正确")
+	}
+	if auth.date != signedAt.Format(requestSignDayFormat) {
+		return auth.keyID, errors.New("凭证日期与签名时间不一致")
+	}
+	if s.tolerance > 0 {
+		diff := s.now().Sub(signedAt)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > s.tolerance {
+			return auth.keyID, errors.New("签名时间超出容忍范围")
+		}
+	}
+
+	secret, err := s.lookupSecret(auth.keyID)
+	if err != nil {
+		return auth.keyID, err
+	}
+	body, err := readRequestBody(req)
+	if err != nil {
+		return auth.keyID, err
+	}
+
+	credentialScope := auth.date
+	if auth.scope != "" {
+		credentialScope += "/" + auth.scope
+	}
+	expected, err := s.signature(secret, req, auth.headers, body, signedAt, credentialScope)
+	if err != nil {
+		return auth.keyID, err
+	}
+	if !hmac.Equal(expected, auth.signature) {
+		return auth.keyID, ErrInvalidMAC
+	}
+	return auth.keyID, nil
+}
+
+// CanonicalRequest 生成请求的规范形式，便于调用方排查签名不一致
+func (s *RequestSigner) CanonicalRequest(req *http.Request, signedHeaders []string, body []byte) string {
+	var b strings.Builder
+	b.WriteString(strings.ToUpper(req.Method))
+	b.WriteByte('\n')
+	b.WriteString(canonicalRequestPath(req.URL.EscapedPath()))
+	b.WriteByte('\n')
+	b.WriteString(canonicalRequestQuery(req.URL.Query()))
+	b.WriteByte('\n')
+	for _, h := range signedHeaders {
+		b.WriteString(h)
+		b.WriteByte(':')
+		b.WriteString(requestHeaderValue(req, h))
+		b.WriteByte('\n')
+	}
+	b.WriteByte('\n')
+	b.WriteString(strings.Join(signedHeaders, ";"))
+	b.WriteByte('\n')
+	h := hashFunc(s.hashAlgo)()
+	h.Write(body)
+	b.WriteString(hex.EncodeToString(h.Sum(nil)))
+	return b.String()
+}
+
+// signature 计算签名
+func (s *RequestSigner) signature(secret []byte, req *http.Request, headers []string, body []byte, at time.Time, credentialScope string) ([]byte, error) {
+	name, err := hashAlgorithmName(s.hashAlgo)
+	if err != nil {
+		return nil, err
+	}
+	h := hashFunc(s.hashAlgo)()
+	h.Write([]byte(s.CanonicalRequest(req, headers, body)))
+	stringToSign := "HMAC-" + name + "\n" + at.Format(requestSignDateFormat) + "\n" + credentialScope + "\n" + hex.EncodeToString(h.Sum(nil))
+
+	key := secret
+	if s.derive != nil {
+		key = s.derive(secret, at.Format(requestSignDayFormat), s.scope, s.hashAlgo)
+		defer zeroBytes(key)
+	}
+	return requestHMAC(s.hashAlgo, key, []byte(stringToSign)), nil
+}
+
+// lookupSecret 查找密钥ID对应的共享密钥
+func (s *RequestSigner) lookupSecret(keyID string) ([]byte, error) {
+	if s.lookup != nil {
+		return s.lookup(keyID)
+	}
+	if keyID != s.keyID {
+		return nil, &KeyError{KeyID: keyID, Err: ErrKeyNotFound}
+	}
+	return s.secret, nil
+}
+
+// requestAuthorization 解析后的Authorization头
+type requestAuthorization struct {
+	algorithm string
+	keyID     string
+	date      string
+	scope     string
+	headers   []string
+	signature []byte
+}
+
+// parseRequestAuthorization 解析Authorization头
+func parseRequestAuthorization(header string) (*requestAuthorization, error) {
+	algorithm, params, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.HasPrefix(algorithm, "HMAC-") {
+		return nil, errors.New("Authorization头格式不正确")
+	}
+	auth := &requestAuthorization{algorithm: algorithm}
+	var credential, signature string
+	hasHeaders := false
+	for _, item := range strings.Split(params, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(item), "=")
+		switch key {
+		case "Credential":
+			credential = value
+		case "SignedHeaders":
+			hasHeaders = true
+			if value != "" {
+				auth.headers = strings.Split(value, ";")
+			}
+		case "Signature":
+			signature = value
+		}
+	}
+	if credential == "" || signature == "" || !hasHeaders {
+		return nil, errors.New("Authorization头缺少必要字段")
+	}
+	if !sort.StringsAreSorted(auth.headers) || !containsString(auth.headers, strings.ToLower(RequestSignDateHeader)) {
+		return nil, errors.New("签名头部列表不正确")
+	}
+
+	// Credential=<密钥ID>/<日期>[/<范围>]
+	parts := strings.SplitN(credential, "/", 3)
+	if len(parts) < 2 || parts[0] == "" {
+		return nil, errors.New("凭证格式不正确")
+	}
+	auth.keyID, auth.date = parts[0], parts[1]
+	if len(parts) == 3 {
+		auth.scope = parts[2]
+	}
+
+	var err error
+	if auth.signature, err = hex.DecodeString(signature); err != nil {
+		return nil, errors.Wrap(err, "签名格式不正确")
+	}
+	return auth, nil
+}
+
+// canonicalRequestPath 规范路径：逐段解码后按RFC 3986重新编码
+func canonicalRequestPath(escaped string) string {
+	if escaped == "" {
+		return "/"
+	}
+	segments := strings.Split(escaped, "/")
+	for i, segment := range segments {
+		if unescaped, err := url.PathUnescape(segment); err == nil {
+			segment = unescaped
+		}
+		segments[i] = rfc3986Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalRequestQuery 规范查询串：按键排序，同名参数按值排序
+func canonicalRequestQuery(query url.Values) string {
+	pairs := make([]string, 0, len(query))
+	for key, values := range query {
+		for _, value := range values {
+			pairs = append(pairs, rfc3986Escape(key)+"="+rfc3986Escape(value))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// rfc3986Escape 只保留非保留字符A-Z a-z 0-9 - _ . ~，其余按%XX编码
+func rfc3986Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+// requestHeaderValue 规范头部值：多个值以逗号连接，连续空白压缩为一个空格
+func requestHeaderValue(req *http.Request, name string) string {
+	if name == "host" {
+		if req.Host != "" {
+			return req.Host
+		}
+		return req.URL.Host
+	}
+	values := req.Header.Values(name)
+	for i, v := range values {
+		values[i] = strings.Join(strings.Fields(v), " ")
+	}
+	return strings.Join(values, ",")
+}
+
+// readRequestBody 读取请求体并重新放回，以便后续处理
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "读取请求体失败")
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// requestHMAC 计算HMAC
+func requestHMAC(hashAlgo HashAlgorithm, key, data []byte) []byte {
+	mac := hmac.New(hashFunc(hashAlgo), key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// containsString 判断切片是否包含字符串
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
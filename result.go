@@ -0,0 +1,57 @@
+package encrypt
+
+// 基于泛型的结果包装，便于把返回(值, 错误)的调用组合起来，
+// 例如 encrypt.Must(encrypt.AES(key)) 或 encrypt.Then(encrypt.Try(aes.Encrypt(data)), decode)
+
+// Result 携带值或错误的结果
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// Try 将(值, 错误)包装为Result
+func Try[T any](value T, err error) Result[T] {
+	return Result[T]{Value: value, Err: err}
+}
+
+// Ok 判断结果是否成功
+func (r Result[T]) Ok() bool {
+	return r.Err == nil
+}
+
+// Unwrap 拆解为(值, 错误)
+func (r Result[T]) Unwrap() (T, error) {
+	return r.Value, r.Err
+}
+
+// Must 获取值，出错时直接panic
+func (r Result[T]) Must() T {
+	if r.Err != nil {
+		panic(r.Err)
+	}
+	return r.Value
+}
+
+// OrElse 获取值，出错时返回默认值
+func (r Result[T]) OrElse(fallback T) T {
+	if r.Err != nil {
+		return fallback
+	}
+	return r.Value
+}
+
+// Then 在结果成功时继续执行下一步，出错时直接传递错误
+func Then[T, U any](r Result[T], fn func(T) (U, error)) Result[U] {
+	if r.Err != nil {
+		return Result[U]{Err: r.Err}
+	}
+	return Try(fn(r.Value))
+}
+
+// Must 获取值，出错时直接panic，适合在初始化阶段使用
+func Must[T any](value T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
@@ -0,0 +1,235 @@
+package encrypt
+
+import (
+	"math/rand/v2"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 灰度发布新的加密配置
+//
+// RolloutCipher 按比例把加密请求路由到新配置（金丝雀），其余仍使用稳定配置。
+// 密文格式与KeyRing.Encrypt相同：len(ID)(1) || ID || 密文，解密时按ID选择配置，
+// 因此比例调整、回滚都不影响已写入数据的解密。
+//
+// 启用WithAutoFallback后统计金丝雀的加解密错误率，样本数达到minSamples且错误率超过阈值时
+// 自动把比例降为0（已写入的金丝雀密文仍按ID解密），并调用OnFallback回调，排查后调用Resume恢复
+
+// defaultRolloutWindow 错误率统计窗口，样本数达到窗口大小后计数减半，使统计偏向最近的请求
+const defaultRolloutWindow = 1000
+
+// rolloutTarget 一组加密配置
+type rolloutTarget struct {
+	id     string
+	cipher ICipher
+}
+
+// RolloutCipher 按比例灰度的加密器，可并发使用（前提是包装的加密器可并发使用）
+type RolloutCipher struct {
+	mu      sync.Mutex
+	stable  rolloutTarget
+	canary  rolloutTarget
+	percent float64
+
+	threshold  float64
+	minSamples int64
+	onFallback func(errorRate float64)
+	fellBack   bool
+
+	canaryOps    int64 // 窗口内金丝雀操作数
+	canaryErrors int64 // 窗口内金丝雀错误数
+	canaryWrites int64 // 累计路由到金丝雀的加密数
+	stableWrites int64 // 累计路由到稳定配置的加密数
+}
+
+// NewRolloutCipher 创建灰度加密器，初始比例为0，ID长度不能超过255字节且不能相同
+func NewRolloutCipher(stableID string, stable ICipher, canaryID string, canary ICipher) (*RolloutCipher, error) {
+	if stable == nil || canary == nil {
+		return nil, errors.New("加密器不能为空")
+	}
+	for _, id := range []string{stableID, canaryID} {
+		if id == "" || len(id) > 0xff {
+			return nil, errors.New("配置ID长度必须在1到255字节之间")
+		}
+	}
+	if stableID == canaryID {
+		return nil, errors.New("稳定配置与金丝雀配置的ID不能相同")
+	}
+	return &RolloutCipher{
+		stable: rolloutTarget{id: stableID, cipher: stable},
+		canary: rolloutTarget{id: canaryID, cipher: canary},
+	}, nil
+}
+
+// WithPercent 设置路由到金丝雀的加密比例（0~100）
+func (r *RolloutCipher) WithPercent(percent float64) *RolloutCipher {
+	if percent < 0 || percent > 100 {
+		panic("灰度比例必须在0到100之间")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.percent = percent
+	return r
+}
+
+// WithAutoFallback 启用自动回退：金丝雀样本数达到minSamples且错误率超过threshold（0~1）时停止路由
+func (r *RolloutCipher) WithAutoFallback(threshold float64, minSamples int) *RolloutCipher {
+	if threshold <= 0 || threshold >= 1 {
+		panic("错误率阈值必须在0到1之间")
+	}
+	if minSamples < 1 || minSamples > defaultRolloutWindow {
+		panic("最小样本数必须在1到1000之间")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.threshold = threshold
+	r.minSamples = int64(minSamples)
+	return r
+}
+
+// OnFallback 设置自动回退时的回调，参数为触发回退时的错误率
+func (r *RolloutCipher) OnFallback(fn func(errorRate float64)) *RolloutCipher {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onFallback = fn
+	return r
+}
+
+// Percent 当前生效的金丝雀比例，已回退时为0
+func (r *RolloutCipher) Percent() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.fellBack {
+		return 0
+	}
+	return r.percent
+}
+
+// FellBack 是否已自动回退
+func (r *RolloutCipher) FellBack() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.fellBack
+}
+
+// Resume 清除回退状态与错误统计，恢复按设置的比例路由
+func (r *RolloutCipher) Resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fellBack = false
+	r.canaryOps, r.canaryErrors = 0, 0
+}
+
+// Encrypt 按比例选择配置加密，输出带配置ID的密文；金丝雀加密失败时改用稳定配置
+func (r *RolloutCipher) Encrypt(plaintext []byte) ([]byte, error) {
+	r.mu.Lock()
+	useCanary := !r.fellBack && r.percent > 0 && rand.Float64()*100 < r.percent
+	r.mu.Unlock()
+
+	if useCanary {
+		ciphertext, err := r.canary.cipher.Encrypt(plaintext)
+		r.record(err)
+		if err == nil {
+			r.mu.Lock()
+			r.canaryWrites++
+			r.mu.Unlock()
+			return rolloutSeal(r.canary.id, ciphertext), nil
+		}
+	}
+
+	ciphertext, err := r.stable.cipher.Encrypt(plaintext)
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.stableWrites++
+	r.mu.Unlock()
+	return rolloutSeal(r.stable.id, ciphertext), nil
+}
+
+// Decrypt 按密文中记录的配置ID解密
+func (r *RolloutCipher) Decrypt(data []byte) ([]byte, error) {
+	id, ciphertext, err := SplitKeyID(data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch id {
+	case r.stable.id:
+		return r.stable.cipher.Decrypt(ciphertext)
+	case r.canary.id:
+		plaintext, err := r.canary.cipher.Decrypt(ciphertext)
+		r.record(err)
+		return plaintext, err
+	default:
+		return nil, errors.Errorf("未知的配置ID: %s", id)
+	}
+}
+
+// EncryptString 加密字符串，返回带配置ID的密文字符串
+func (r *RolloutCipher) EncryptString(plaintext string) (string, error) {
+	return applyString(r.Encrypt, plaintext)
+}
+
+// DecryptString 解密带配置ID的密文字符串
+func (r *RolloutCipher) DecryptString(ciphertext string) (string, error) {
+	return applyString(r.Decrypt, ciphertext)
+}
+
+// GetMetrics 获取灰度指标：canary_writes、stable_writes、canary_ops、canary_errors、fell_back
+func (r *RolloutCipher) GetMetrics() map[string]int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	fellBack := int64(0)
+	if r.fellBack {
+		fellBack = 1
+	}
+	return map[string]int64{
+		"canary_writes": r.canaryWrites,
+		"stable_writes": r.stableWrites,
+		"canary_ops":    r.canaryOps,
+		"canary_errors": r.canaryErrors,
+		"fell_back":     fellBack,
+	}
+}
+
+// record 记录一次金丝雀操作结果，必要时触发回退
+func (r *RolloutCipher) record(err error) {
+	r.mu.Lock()
+	r.canaryOps++
+	if err != nil {
+		r.canaryErrors++
+	}
+	if r.canaryOps >= defaultRolloutWindow {
+		r.canaryOps /= 2
+		r.canaryErrors /= 2
+	}
+
+	var callback func(float64)
+	var rate float64
+	if r.threshold > 0 && !r.fellBack && r.canaryOps >= r.minSamples {
+		rate = float64(r.canaryErrors) / float64(r.canaryOps)
+		if rate > r.threshold {
+			r.fellBack = true
+			callback = r.onFallback
+		}
+	}
+	r.mu.Unlock()
+
+	// 回调在锁外执行，允许在回调中调用Percent等方法
+	if callback != nil {
+		callback(rate)
+	}
+}
+
+// rolloutSeal 在密文前加上配置ID
+func rolloutSeal(id string, ciphertext []byte) []byte {
+	out := make([]byte, 0, 1+len(id)+len(ciphertext))
+	out = append(out, byte(len(id)))
+	out = append(out, id...)
+	return append(out, ciphertext...)
+}
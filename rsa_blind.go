@@ -0,0 +1,317 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// RSA盲签名（RFC 9474 RSABSSA）
+//
+// 匿名兑换券发放：用户把券号盲化后交给签发方签名，签发方看不到券号，兑换时也无法把券与签发请求关联。
+//
+//	用户：    blinded, state := Blind(variant, msg)          // 只需要公钥
+//	签发方：  blindSig := BlindSign(blinded)                 // 需要私钥，不知道msg
+//	用户：    sig := Unblind(state, blindSig)                 // 得到普通的RSASSA-PSS签名
+//	兑换方：  VerifyBlindSignature(variant, state.Message(), sig)
+//
+// 签名是SHA-384的RSASSA-PSS签名，可以用任意标准PSS实现验证。Randomized变体在消息前加32字节随机前缀，
+// 验签与兑换时必须使用带前缀的消息（BlindingState.Message）；Deterministic变体只应用于本身有足够熵的消息。
+// BlindSign会对任意输入做私钥运算，签发方必须为盲签名单独使用一把密钥，不能与解密或其他签名共用
+//
+// 盲化后的消息、盲签名与最终签名按编码器配置编码，与Sign/Verify一致
+
+// BlindRSAVariant RFC 9474定义的盲签名变体
+type BlindRSAVariant int
+
+// 盲签名变体常量定义
+const (
+	BlindRSASHA384PSSRandomized        BlindRSAVariant = iota + 1 // RSABSSA-SHA384-PSS-Randomized（推荐）
+	BlindRSASHA384PSSZeroRandomized                               // RSABSSA-SHA384-PSSZERO-Randomized
+	BlindRSASHA384PSSDeterministic                                // RSABSSA-SHA384-PSS-Deterministic
+	BlindRSASHA384PSSZeroDeterministic                            // RSABSSA-SHA384-PSSZERO-Deterministic
+)
+
+// blindRSAPrefixSize Randomized变体的消息前缀长度
+const blindRSAPrefixSize = 32
+
+// String 返回RFC 9474中的变体名称
+func (v BlindRSAVariant) String() string {
+	switch v {
+	case BlindRSASHA384PSSRandomized:
+		return "RSABSSA-SHA384-PSS-Randomized"
+	case BlindRSASHA384PSSZeroRandomized:
+		return "RSABSSA-SHA384-PSSZERO-Randomized"
+	case BlindRSASHA384PSSDeterministic:
+		return "RSABSSA-SHA384-PSS-Deterministic"
+	case BlindRSASHA384PSSZeroDeterministic:
+		return "RSABSSA-SHA384-PSSZERO-Deterministic"
+	default:
+		return "Unknown"
+	}
+}
+
+// saltLength PSS盐值长度
+func (v BlindRSAVariant) saltLength() (int, error) {
+	switch v {
+	case BlindRSASHA384PSSRandomized, BlindRSASHA384PSSDeterministic:
+		return sha512.Size384, nil
+	case BlindRSASHA384PSSZeroRandomized, BlindRSASHA384PSSZeroDeterministic:
+		return 0, nil
+	default:
+		return 0, errors.New("不支持的盲签名变体")
+	}
+}
+
+// randomized 是否添加随机消息前缀
+func (v BlindRSAVariant) randomized() bool {
+	return v == BlindRSASHA384PSSRandomized || v == BlindRSASHA384PSSZeroRandomized
+}
+
+// BlindingState 盲化时产生的秘密状态，用户保存到Unblind为止，不能发给签发方
+type BlindingState struct {
+	variant BlindRSAVariant
+	message []byte
+	inverse *big.Int
+}
+
+// Message 实际被签名的消息，Randomized变体包含32字节随机前缀，验签时使用该值
+func (s *BlindingState) Message() []byte {
+	return append([]byte(nil), s.message...)
+}
+
+// Blind 盲化消息，返回发给签发方的盲化消息与本地保存的状态，只需要公钥
+func (r *RSAEncryptor) Blind(variant BlindRSAVariant, msg []byte) ([]byte, *BlindingState, error) {
+	if r.publicKey == nil {
+		return nil, nil, errors.New("未设置公钥")
+	}
+	saltLen, err := variant.saltLength()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	prepared := append([]byte(nil), msg...)
+	if variant.randomized() {
+		prefix, err := GenerateRandomBytes(blindRSAPrefixSize)
+		if err != nil {
+			return nil, nil, err
+		}
+		prepared = append(prefix, msg...)
+	}
+
+	n := r.publicKey.N
+	encoded, err := emsaPSSEncode(prepared, n.BitLen()-1, saltLen)
+	if err != nil {
+		return nil, nil, err
+	}
+	m := new(big.Int).SetBytes(encoded)
+	if new(big.Int).GCD(nil, nil, m, n).Cmp(big.NewInt(1)) != 0 {
+		return nil, nil, errors.New("盲化失败，消息与模数不互素")
+	}
+
+	blind, inverse, err := blindRSAFactor(r.publicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	z := blind.Mul(blind, m)
+	z.Mod(z, n)
+
+	blinded, err := r.encoding.Encode(z.FillBytes(make([]byte, r.publicKey.Size())))
+	if err != nil {
+		return nil, nil, err
+	}
+	return blinded, &BlindingState{variant: variant, message: prepared, inverse: inverse}, nil
+}
+
+// BlindSign 签发方对盲化消息签名，看不到原始消息
+func (r *RSAEncryptor) BlindSign(blindedMsg []byte) ([]byte, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("未设置私钥")
+	}
+	decoded, err := r.encoding.Decode(blindedMsg)
+	if err != nil {
+		return nil, errors.Wrap(err, "解码盲化消息失败")
+	}
+
+	pub := &r.privateKey.PublicKey
+	if len(decoded) != pub.Size() {
+		return nil, errors.Errorf("盲化消息长度必须是%d字节", pub.Size())
+	}
+	m := new(big.Int).SetBytes(decoded)
+	if m.Cmp(pub.N) >= 0 {
+		return nil, errors.New("盲化消息超出模数范围")
+	}
+
+	// 私钥运算前再做一次盲化，避免big.Int模幂的耗时与输入相关
+	blind, inverse, err := blindRSAFactor(pub)
+	if err != nil {
+		return nil, err
+	}
+	s := blind.Mul(blind, m)
+	s.Mod(s, pub.N)
+	s.Exp(s, r.privateKey.D, pub.N)
+	s.Mul(s, inverse)
+	s.Mod(s, pub.N)
+
+	// 校验签名结果，防止计算故障泄露私钥
+	check := new(big.Int).Exp(s, big.NewInt(int64(pub.E)), pub.N)
+	if check.Cmp(m) != 0 {
+		return nil, errors.New("盲签名计算校验失败")
+	}
+	return r.encoding.Encode(s.FillBytes(make([]byte, pub.Size())))
+}
+
+// Unblind 去盲化得到消息的签名，签名无效时报错
+func (r *RSAEncryptor) Unblind(state *BlindingState, blindSig []byte) ([]byte, error) {
+	if r.publicKey == nil {
+		return nil, errors.New("未设置公钥")
+	}
+	decoded, err := r.encoding.Decode(blindSig)
+	if err != nil {
+		return nil, errors.Wrap(err, "解码盲签名失败")
+	}
+	if len(decoded) != r.publicKey.Size() {
+		return nil, errors.Errorf("盲签名长度必须是%d字节", r.publicKey.Size())
+	}
+
+	s := new(big.Int).SetBytes(decoded)
+	s.Mul(s, state.inverse)
+	s.Mod(s, r.publicKey.N)
+	signature := s.FillBytes(make([]byte, r.publicKey.Size()))
+	if err := verifyBlindRSA(r.publicKey, state.variant, state.message, signature); err != nil {
+		return nil, errors.Wrap(err, "去盲化后的签名无效")
+	}
+	return r.encoding.Encode(signature)
+}
+
+// VerifyBlindSignature 验证去盲化后的签名，msg为BlindingState.Message返回的消息
+func (r *RSAEncryptor) VerifyBlindSignature(variant BlindRSAVariant, msg []byte, signature []byte) (bool, error) {
+	if r.publicKey == nil {
+		return false, errors.New("未设置公钥")
+	}
+	if _, err := variant.saltLength(); err != nil {
+		return false, err
+	}
+	decoded, err := r.encoding.Decode(signature)
+	if err != nil {
+		return false, errors.Wrap(err, "解码签名失败")
+	}
+	if err := verifyBlindRSA(r.publicKey, variant, msg, decoded); err != nil {
+		return false, nil // 签名验证失败，但不是错误
+	}
+	return true, nil
+}
+
+// verifyBlindRSA RSASSA-PSS-VERIFY，SHA-384与MGF1-SHA-384
+func verifyBlindRSA(pub *rsa.PublicKey, variant BlindRSAVariant, msg, signature []byte) error {
+	saltLen, err := variant.saltLength()
+	if err != nil {
+		return err
+	}
+	if saltLen == 0 {
+		// rsa.PSSOptions中盐值长度0表示自动识别，这里改为自行解码后比较
+		return verifyPSSZero(pub, msg, signature)
+	}
+	digest := sha512.Sum384(msg)
+	return rsa.VerifyPSS(pub, crypto.SHA384, digest[:], signature, &rsa.PSSOptions{SaltLength: saltLen, Hash: crypto.SHA384})
+}
+
+// verifyPSSZero 盐值为空时PSS编码是确定的，直接比较编码结果
+func verifyPSSZero(pub *rsa.PublicKey, msg, signature []byte) error {
+	if len(signature) != pub.Size() {
+		return errors.New("签名长度不正确")
+	}
+	s := new(big.Int).SetBytes(signature)
+	if s.Cmp(pub.N) >= 0 {
+		return errors.New("签名超出模数范围")
+	}
+	emBits := pub.N.BitLen() - 1
+	expected, err := emsaPSSEncode(msg, emBits, 0)
+	if err != nil {
+		return err
+	}
+	// 模数位数是8的倍数时EM比模长短一个字节，按模长补齐高位0后比较
+	padded := make([]byte, pub.Size())
+	copy(padded[len(padded)-len(expected):], expected)
+	em := s.Exp(s, big.NewInt(int64(pub.E)), pub.N).FillBytes(make([]byte, pub.Size()))
+	if !bytes.Equal(em, padded) {
+		return errors.New("签名不匹配")
+	}
+	return nil
+}
+
+// blindRSAFactor 生成随机盲化因子r，返回r^e mod n与r^-1 mod n
+func blindRSAFactor(pub *rsa.PublicKey) (*big.Int, *big.Int, error) {
+	for {
+		r, err := rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "生成盲化因子失败")
+		}
+		inverse := new(big.Int).ModInverse(r, pub.N)
+		if r.Sign() == 0 || inverse == nil {
+			continue
+		}
+		return r.Exp(r, big.NewInt(int64(pub.E)), pub.N), inverse, nil
+	}
+}
+
+// emsaPSSEncode EMSA-PSS-ENCODE（RFC 8017 9.1.1），哈希与MGF1均为SHA-384
+func emsaPSSEncode(msg []byte, emBits, saltLen int) ([]byte, error) {
+	hLen := sha512.Size384
+	emLen := (emBits + 7) / 8
+	if emLen < hLen+saltLen+2 {
+		return nil, errors.New("RSA模数过短")
+	}
+
+	salt := make([]byte, saltLen)
+	if saltLen > 0 {
+		if _, err := rand.Read(salt); err != nil {
+			return nil, errors.Wrap(err, "生成盐值失败")
+		}
+	}
+	mHash := sha512.Sum384(msg)
+	h := sha512.New384()
+	h.Write(make([]byte, 8))
+	h.Write(mHash[:])
+	h.Write(salt)
+	hash := h.Sum(nil)
+
+	// DB = PS || 0x01 || salt，与MGF1(H)异或
+	em := make([]byte, emLen)
+	db := em[:emLen-hLen-1]
+	db[emLen-saltLen-hLen-2] = 0x01
+	copy(db[emLen-saltLen-hLen-1:], salt)
+	mgf1XOR(db, hash)
+	db[0] &= 0xff >> (8*emLen - emBits)
+
+	copy(em[emLen-hLen-1:], hash)
+	em[emLen-1] = 0xbc
+	return em, nil
+}
+
+// mgf1XOR 将MGF1-SHA-384(seed)异或到out
+func mgf1XOR(out, seed []byte) {
+	var counter [4]byte
+	for done := 0; done < len(out); {
+		h := sha512.New384()
+		h.Write(seed)
+		h.Write(counter[:])
+		for _, b := range h.Sum(nil) {
+			if done == len(out) {
+				break
+			}
+			out[done] ^= b
+			done++
+		}
+		for i := 3; i >= 0; i-- {
+			if counter[i]++; counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
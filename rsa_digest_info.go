@@ -0,0 +1,107 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+
+	"github.com/pkg/errors"
+)
+
+// PKCS#1 v1.5 DigestInfo与NONEwithRSA
+//
+// PKCS#1 v1.5签名的填充内容是DER编码的DigestInfo：
+//
+//	SEQUENCE { SEQUENCE { OID, NULL }, OCTET STRING digest }
+//
+// 智能卡中间件（PKCS#11 CKM_RSA_PKCS、CSP等）通常由调用方预先拼好DigestInfo，
+// 卡内只做填充与模幂运算，即Java中的"NONEwithRSA"。SignRaw/VerifyRaw 对应这种用法，
+// SignDigestWith/VerifyDigestWith 则按指定的摘要算法自动构造DigestInfo，
+// 结果与对原文使用对应摘要算法的PKCS#1 v1.5签名一致
+
+// digestInfoPrefixes 各摘要算法DigestInfo中摘要值之前的DER前缀
+var digestInfoPrefixes = map[HashAlgorithm][]byte{
+	HashSHA1:   {0x30, 0x21, 0x30, 0x09, 0x06, 0x05, 0x2b, 0x0e, 0x03, 0x02, 0x1a, 0x05, 0x00, 0x04, 0x14},
+	HashSHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	HashSHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+	// SM3 OID 1.2.156.10197.1.401（GM/T 0006）
+	HashSM3: {0x30, 0x30, 0x30, 0x0c, 0x06, 0x08, 0x2a, 0x81, 0x1c, 0xcf, 0x55, 0x01, 0x83, 0x11, 0x05, 0x00, 0x04, 0x20},
+}
+
+// EncodeDigestInfo 将摘要编码为DER格式的DigestInfo，支持SHA1、SHA256、SHA512与SM3
+func EncodeDigestInfo(hashAlgo HashAlgorithm, digest []byte) ([]byte, error) {
+	prefix, ok := digestInfoPrefixes[hashAlgo]
+	if !ok {
+		return nil, errors.New("DigestInfo仅支持SHA1、SHA256、SHA512与SM3")
+	}
+	if size := hashFunc(hashAlgo)().Size(); len(digest) != size {
+		return nil, errors.Errorf("摘要长度必须是%d字节", size)
+	}
+	info := make([]byte, 0, len(prefix)+len(digest))
+	info = append(info, prefix...)
+	return append(info, digest...), nil
+}
+
+// ParseDigestInfo 解析DER格式的DigestInfo，返回摘要算法与摘要值
+func ParseDigestInfo(info []byte) (HashAlgorithm, []byte, error) {
+	for hashAlgo, prefix := range digestInfoPrefixes {
+		size := hashFunc(hashAlgo)().Size()
+		if len(info) == len(prefix)+size && bytes.HasPrefix(info, prefix) {
+			return hashAlgo, append([]byte(nil), info[len(prefix):]...), nil
+		}
+	}
+	return 0, nil, errors.New("无法识别的DigestInfo")
+}
+
+// SignRaw NONEwithRSA签名，对调用方提供的数据（通常是预先构造的DigestInfo）直接做PKCS#1 v1.5填充后签名
+// 数据长度不能超过模长减11字节
+func (r *RSAEncryptor) SignRaw(data []byte) ([]byte, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("未设置私钥")
+	}
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, r.privateKey, crypto.Hash(0), data)
+	if err != nil {
+		return nil, errors.Wrap(err, "RSA签名失败")
+	}
+
+	// 编码处理
+	return r.encoding.Encode(signature)
+}
+
+// VerifyRaw 验证NONEwithRSA签名
+func (r *RSAEncryptor) VerifyRaw(data []byte, signature []byte) (bool, error) {
+	if r.publicKey == nil {
+		return false, errors.New("未设置公钥")
+	}
+
+	// 解码签名
+	decoded, err := r.encoding.Decode(signature)
+	if err != nil {
+		return false, errors.Wrap(err, "解码签名失败")
+	}
+
+	if err := rsa.VerifyPKCS1v15(r.publicKey, crypto.Hash(0), data, decoded); err != nil {
+		return false, nil // 签名验证失败，但不是错误
+	}
+	return true, nil
+}
+
+// SignDigestWith 对指定算法预先计算的摘要进行PKCS#1 v1.5签名
+func (r *RSAEncryptor) SignDigestWith(hashAlgo HashAlgorithm, digest []byte) ([]byte, error) {
+	info, err := EncodeDigestInfo(hashAlgo, digest)
+	if err != nil {
+		return nil, err
+	}
+	return r.SignRaw(info)
+}
+
+// VerifyDigestWith 使用指定算法预先计算的摘要验证PKCS#1 v1.5签名
+func (r *RSAEncryptor) VerifyDigestWith(hashAlgo HashAlgorithm, digest []byte, signature []byte) (bool, error) {
+	info, err := EncodeDigestInfo(hashAlgo, digest)
+	if err != nil {
+		return false, err
+	}
+	return r.VerifyRaw(info, signature)
+}
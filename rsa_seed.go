@@ -0,0 +1,122 @@
+package encrypt
+
+import (
+	"crypto/rsa"
+	"io"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// seedMinLength 确定性密钥生成所需的最小种子长度
+const seedMinLength = 32
+
+// GenerateKeyPairFromSeed 从种子确定性地生成RSA密钥对
+//
+// 警告：仅用于测试夹具、可复现构建和分层派生等场景，密钥强度完全取决于种子的熵，
+// 切勿在生产环境中使用可预测的种子。相同的种子与密钥大小总是生成相同的密钥对。
+//
+// 标准库rsa.GenerateKey会有意引入随机性，无法保证确定性，因此这里基于HMAC-DRBG自行搜索素数
+func (r *RSAEncryptor) GenerateKeyPairFromSeed(seed []byte) ([]byte, []byte, error) {
+	if len(seed) < seedMinLength {
+		return nil, nil, errors.Errorf("种子长度至少需要%d字节", seedMinLength)
+	}
+
+	// 如果未设置密钥大小，使用默认值
+	if r.keySize == 0 {
+		r.keySize = 2048
+	}
+
+	privateKey, err := generateRSAKeyFromReader(newHMACDRBG(seed), r.keySize)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "生成RSA密钥对失败")
+	}
+
+	// 保存密钥用于后续操作
+	r.privateKey = privateKey
+	r.publicKey = &privateKey.PublicKey
+
+	publicKeyPEM, privateKeyPEM := encodeRSAKeyPairPEM(privateKey)
+	return publicKeyPEM, privateKeyPEM, nil
+}
+
+// generateRSAKeyFromReader 使用给定的随机源确定性地生成双素数RSA密钥
+func generateRSAKeyFromReader(random io.Reader, bits int) (*rsa.PrivateKey, error) {
+	e := big.NewInt(rsaMinExponent)
+	one := big.NewInt(1)
+
+	for {
+		p, err := deterministicPrime(random, bits-bits/2, e)
+		if err != nil {
+			return nil, err
+		}
+		q, err := deterministicPrime(random, bits/2, e)
+		if err != nil {
+			return nil, err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+
+		pMinus1 := new(big.Int).Sub(p, one)
+		qMinus1 := new(big.Int).Sub(q, one)
+		phi := new(big.Int).Mul(pMinus1, qMinus1)
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue
+		}
+
+		privateKey := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: rsaMinExponent},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		privateKey.Precompute()
+		if err := privateKey.Validate(); err != nil {
+			return nil, err
+		}
+		return privateKey, nil
+	}
+}
+
+// deterministicPrime 从随机源读取候选值并搜索指定位数的素数
+// 候选值最高两位与最低位置1，保证两素数乘积达到目标位数且为奇数，同时要求gcd(p-1, e)=1
+func deterministicPrime(random io.Reader, bits int, e *big.Int) (*big.Int, error) {
+	if bits < 2 {
+		return nil, errors.New("素数位数过小")
+	}
+
+	buf := make([]byte, (bits+7)/8)
+	excess := uint(len(buf)*8 - bits)
+	one := big.NewInt(1)
+	gcd := new(big.Int)
+
+	for {
+		if _, err := io.ReadFull(random, buf); err != nil {
+			return nil, err
+		}
+
+		buf[0] &= byte(0xff >> excess)
+		if excess <= 6 {
+			buf[0] |= 0xc0 >> excess
+		} else {
+			buf[0] |= 0x01
+			buf[1] |= 0x80
+		}
+		buf[len(buf)-1] |= 0x01
+
+		p := new(big.Int).SetBytes(buf)
+		if !p.ProbablyPrime(20) {
+			continue
+		}
+		if gcd.GCD(nil, nil, new(big.Int).Sub(p, one), e).Cmp(one) != 0 {
+			continue
+		}
+		return p, nil
+	}
+}
@@ -0,0 +1,49 @@
+package encrypt
+
+import (
+	"crypto/rsa"
+
+	"github.com/pkg/errors"
+)
+
+// 抗Bleichenbacher攻击的会话密钥解密
+//
+// PKCS#1 v1.5解密时，如果"填充错误"与"后续使用失败"在返回值、错误信息或耗时上有差异，
+// 攻击者可以把服务端当作填充预言机，通过大量构造的密文逐步解出会话密钥。
+// DecryptSessionKey 先生成随机密钥，填充校验与长度校验都以常量时间进行，
+// 校验失败时不报错而是返回该随机密钥，调用方随后用它解密消息时自然以认证失败告终，
+// 与密文被篡改的情况无法区分。
+//
+// 调用方必须保证：会话密钥长度固定且事先约定；随后的消息使用AEAD（如AES-GCM）认证；
+// 不在日志或响应中区分"会话密钥解密失败"与"消息认证失败"
+
+// DecryptSessionKey 以常量行为解密keySize字节的会话密钥，填充或长度不正确时返回随机密钥而不是错误
+// 只有密文长度与模长不符、keySize超出范围等与密钥内容无关的情况才返回错误
+func (r *RSAEncryptor) DecryptSessionKey(ciphertext []byte, keySize int) ([]byte, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("未设置私钥")
+	}
+	if keySize <= 0 || keySize > r.privateKey.Size()-11 {
+		return nil, errors.Errorf("会话密钥长度必须在1到%d字节之间", r.privateKey.Size()-11)
+	}
+
+	// 解码处理
+	decoded, err := r.encoding.Decode(ciphertext)
+	if err != nil {
+		return nil, errors.Wrap(err, "解码失败")
+	}
+	if len(decoded) != r.privateKey.Size() {
+		return nil, errors.New("密文长度与RSA模长不符")
+	}
+
+	// 先生成随机密钥，解密成功时才会被覆盖
+	key, err := GenerateRandomBytes(keySize)
+	if err != nil {
+		return nil, err
+	}
+	if err := rsa.DecryptPKCS1v15SessionKey(nil, r.privateKey, decoded, key); err != nil {
+		zeroBytes(key)
+		return nil, errors.Wrap(err, "RSA解密失败")
+	}
+	return key, nil
+}
@@ -0,0 +1,201 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 加密Cookie编解码
+//
+// 语义与gorilla/securecookie一致：Encode(name, value)输出可直接写入Cookie的字符串，
+// Decode时校验名称、有效期与长度，Cookie名称作为附加认证数据，不能把一个Cookie的值挪用到另一个名称下。
+// 加密使用密钥环主密钥（AES-GCM或SM4-GCM），输出中记录密钥ID，
+// 轮换时设置新的主密钥即可，旧密钥保留解密权限期间已签发的Cookie仍然有效。
+//
+//	base64url( idLen(1) | keyID | GCM( timestamp(8) | 序列化的值 ) )
+
+// Cookie编解码错误，可通过errors.Is判断
+var (
+	ErrCookieExpired  = errors.New("Cookie已过期")
+	ErrCookieTooLong  = errors.New("Cookie长度超出限制")
+	ErrCookieInvalid  = errors.New("Cookie无效")
+	ErrCookieTimeSkew = errors.New("Cookie时间戳晚于当前时间")
+)
+
+// 默认参数，与gorilla/securecookie相同
+const (
+	// DefaultCookieMaxAge 默认有效期30天
+	DefaultCookieMaxAge = 30 * 24 * time.Hour
+	// DefaultCookieMaxLength 默认最大长度，浏览器通常限制单个Cookie为4096字节
+	DefaultCookieMaxLength = 4096
+)
+
+// cookieTimeSkew 允许的时钟偏差
+const cookieTimeSkew = time.Minute
+
+// CookieSerializer Cookie值的序列化方式
+type CookieSerializer interface {
+	Serialize(src interface{}) ([]byte, error)
+	Deserialize(data []byte, dst interface{}) error
+}
+
+// JSONCookieSerializer JSON序列化（默认）
+type JSONCookieSerializer struct{}
+
+// Serialize 实现CookieSerializer
+func (JSONCookieSerializer) Serialize(src interface{}) ([]byte, error) {
+	return json.Marshal(src)
+}
+
+// Deserialize 实现CookieSerializer
+func (JSONCookieSerializer) Deserialize(data []byte, dst interface{}) error {
+	return json.Unmarshal(data, dst)
+}
+
+// GobCookieSerializer gob序列化，与gorilla/securecookie的默认方式相同
+type GobCookieSerializer struct{}
+
+// Serialize 实现CookieSerializer
+func (GobCookieSerializer) Serialize(src interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Deserialize 实现CookieSerializer
+func (GobCookieSerializer) Deserialize(data []byte, dst interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dst)
+}
+
+// NopCookieSerializer 不做序列化，值必须是[]byte，解码目标必须是*[]byte
+type NopCookieSerializer struct{}
+
+// Serialize 实现CookieSerializer
+func (NopCookieSerializer) Serialize(src interface{}) ([]byte, error) {
+	data, ok := src.([]byte)
+	if !ok {
+		return nil, errors.New("NopCookieSerializer只支持[]byte")
+	}
+	return data, nil
+}
+
+// Deserialize 实现CookieSerializer
+func (NopCookieSerializer) Deserialize(data []byte, dst interface{}) error {
+	out, ok := dst.(*[]byte)
+	if !ok {
+		return errors.New("NopCookieSerializer只支持*[]byte")
+	}
+	*out = append((*out)[:0], data...)
+	return nil
+}
+
+// CookieCodec 加密Cookie编解码器
+type CookieCodec struct {
+	ring       *KeyRing
+	maxAge     time.Duration
+	maxLength  int
+	serializer CookieSerializer
+	now        func() time.Time
+}
+
+// NewCookieCodec 基于密钥环创建编解码器
+func NewCookieCodec(ring *KeyRing) *CookieCodec {
+	return &CookieCodec{
+		ring:       ring,
+		maxAge:     DefaultCookieMaxAge,
+		maxLength:  DefaultCookieMaxLength,
+		serializer: JSONCookieSerializer{},
+		now:        time.Now,
+	}
+}
+
+// MaxAge 设置有效期，0表示不检查
+func (c *CookieCodec) MaxAge(maxAge time.Duration) *CookieCodec {
+	c.maxAge = maxAge
+	return c
+}
+
+// MaxLength 设置编码后的最大长度，0表示不限制
+func (c *CookieCodec) MaxLength(maxLength int) *CookieCodec {
+	c.maxLength = maxLength
+	return c
+}
+
+// WithSerializer 设置序列化方式
+func (c *CookieCodec) WithSerializer(serializer CookieSerializer) *CookieCodec {
+	c.serializer = serializer
+	return c
+}
+
+// WithClock 设置时钟，主要用于测试
+func (c *CookieCodec) WithClock(now func() time.Time) *CookieCodec {
+	c.now = now
+	return c
+}
+
+// Encode 序列化并加密Cookie值
+func (c *CookieCodec) Encode(name string, value interface{}) (string, error) {
+	data, err := c.serializer.Serialize(value)
+	if err != nil {
+		return "", errors.Wrap(err, "序列化Cookie失败")
+	}
+
+	payload := make([]byte, 8, 8+len(data))
+	binary.BigEndian.PutUint64(payload, uint64(c.now().Unix()))
+	payload = append(payload, data...)
+
+	sealed, err := c.ring.Encrypt(payload, []byte(name))
+	if err != nil {
+		return "", errors.Wrap(err, "加密Cookie失败")
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(sealed)
+	if c.maxLength > 0 && len(encoded) > c.maxLength {
+		return "", ErrCookieTooLong
+	}
+	return encoded, nil
+}
+
+// Decode 解密并反序列化Cookie值到dst
+func (c *CookieCodec) Decode(name, value string, dst interface{}) error {
+	if c.maxLength > 0 && len(value) > c.maxLength {
+		return ErrCookieTooLong
+	}
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return ErrCookieInvalid
+	}
+	payload, err := c.ring.Decrypt(sealed, []byte(name))
+	if err != nil {
+		// 密钥不可用（吊销、过期）时保留原因，其余一律视为无效Cookie
+		var keyErr *KeyError
+		if errors.As(err, &keyErr) {
+			return err
+		}
+		return ErrCookieInvalid
+	}
+	if len(payload) < 8 {
+		return ErrCookieInvalid
+	}
+
+	issued := time.Unix(int64(binary.BigEndian.Uint64(payload)), 0)
+	now := c.now()
+	if issued.After(now.Add(cookieTimeSkew)) {
+		return ErrCookieTimeSkew
+	}
+	if c.maxAge > 0 && now.Sub(issued) > c.maxAge {
+		return ErrCookieExpired
+	}
+
+	if err := c.serializer.Deserialize(payload[8:], dst); err != nil {
+		return errors.Wrap(err, "反序列化Cookie失败")
+	}
+	return nil
+}
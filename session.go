@@ -0,0 +1,216 @@
+package encrypt
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"math"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 长连接会话加密
+//
+// 与每条消息生成随机nonce不同，SessionCipher为每个方向维护单调递增的序号，
+// nonce = 方向标识(4字节) || 序号(8字节)，与TLS记录层的做法一致：
+// 同一密钥下两个方向的nonce互不重叠，序号也无需随消息传输。
+// 发起方与响应方使用同一密钥，通过initiator参数区分方向。
+//
+// 序列化状态中保存的不是当前发送序号，而是预留的上限（当前序号 + SessionSendReserve）。
+// 保存后发送序号不能越过该上限，恢复时从上限继续，即使进程在两次保存之间崩溃也不会重复使用nonce。
+
+// sessionStateVersion 会话状态序列化格式版本，版本2起保存发送序号的预留上限
+const sessionStateVersion = 2
+
+// sessionStateSize 序列化状态长度：version(1) | algorithm(1) | initiator(1) | sendReserved(8) | recv(8)
+const sessionStateSize = 19
+
+// SessionSendReserve 每次保存状态时为发送方向预留的序号数量
+const SessionSendReserve = 1 << 20
+
+// 方向标识
+var (
+	sessionInitiatorLabel = []byte{0x00, 0x00, 0x00, 0x01}
+	sessionResponderLabel = []byte{0x00, 0x00, 0x00, 0x02}
+)
+
+// SessionCipher 基于计数器nonce的会话加密器，并发安全
+type SessionCipher struct {
+	mu        sync.Mutex
+	aead      cipher.AEAD
+	algorithm Algorithm
+	initiator bool
+	sendSeq   uint64
+	recvSeq   uint64
+	// sendLimit 最近一次保存的发送序号上限，保存过状态后发送序号不能达到该值
+	sendLimit uint64
+	limited   bool
+}
+
+// NewSessionCipher 创建会话加密器，支持AES-GCM与SM4-GCM
+// 连接两端必须一端initiator为true，另一端为false
+func NewSessionCipher(algorithm Algorithm, key []byte, initiator bool) (*SessionCipher, error) {
+	var aead cipher.AEAD
+	var err error
+	switch algorithm {
+	case AlgorithmAES:
+		aead, err = newAESGCM(key)
+	case AlgorithmSM4:
+		aead, err = newSM4GCM(key)
+	default:
+		return nil, errors.New("会话加密仅支持AES与SM4")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &SessionCipher{
+		aead:      aead,
+		algorithm: algorithm,
+		initiator: initiator,
+	}, nil
+}
+
+// Seal 使用下一个发送序号加密消息，输出不包含nonce
+func (c *SessionCipher) Seal(plaintext, aad []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.sendSeq == math.MaxUint64 {
+		return nil, errors.New("发送序号已耗尽，需要更换密钥")
+	}
+	if c.limited && c.sendSeq >= c.sendLimit {
+		return nil, errors.New("发送序号已达到已保存状态的预留上限，需要重新保存会话状态")
+	}
+
+	nonce := c.nonce(c.sendLabel(), c.sendSeq)
+	ciphertext := c.aead.Seal(nil, nonce, plaintext, aad)
+	c.sendSeq++
+	return ciphertext, nil
+}
+
+// Open 使用下一个接收序号解密消息，适用于TCP等保序传输
+// 解密失败时接收序号不变
+func (c *SessionCipher) Open(ciphertext, aad []byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.recvSeq == math.MaxUint64 {
+		return nil, errors.New("接收序号已耗尽，需要更换密钥")
+	}
+
+	plaintext, err := c.open(c.recvSeq, ciphertext, aad)
+	if err != nil {
+		return nil, err
+	}
+	c.recvSeq++
+	return plaintext, nil
+}
+
+// OpenSequence 使用显式序号解密消息，不改变接收序号
+// 适用于UDP等可能乱序或丢包的传输，调用方需自行防范重放
+func (c *SessionCipher) OpenSequence(seq uint64, ciphertext, aad []byte) ([]byte, error) {
+	return c.open(seq, ciphertext, aad)
+}
+
+// SendSequence 获取下一条发送消息的序号
+func (c *SessionCipher) SendSequence() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.sendSeq
+}
+
+// ReceiveSequence 获取下一条接收消息的序号
+func (c *SessionCipher) ReceiveSequence() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recvSeq
+}
+
+// MarshalBinary 序列化会话状态（不包含密钥），用于进程重启后恢复序号
+// 每次调用预留SessionSendReserve个发送序号并以其上限作为保存值，之后发送序号不能越过该上限，
+// 因此结果必须持久化后才能继续依赖它恢复；恢复时绝不能使用比最近一次保存更早的状态
+func (c *SessionCipher) MarshalBinary() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.sendLimit = c.sendSeq + SessionSendReserve
+	if c.sendLimit < c.sendSeq {
+		c.sendLimit = math.MaxUint64
+	}
+	c.limited = true
+
+	state := make([]byte, sessionStateSize)
+	state[0] = sessionStateVersion
+	state[1] = byte(c.algorithm)
+	if c.initiator {
+		state[2] = 1
+	}
+	binary.BigEndian.PutUint64(state[3:11], c.sendLimit)
+	binary.BigEndian.PutUint64(state[11:19], c.recvSeq)
+	return state, nil
+}
+
+// UnmarshalBinary 恢复会话状态，算法与方向必须与当前加密器一致
+// 发送序号从保存时的预留上限继续，跳过的序号可能已在崩溃前使用过，接收方需通过OpenSequence或OpenWindow按序号接收；
+// 恢复后需再次调用MarshalBinary并保存结果才能发送。版本1的状态没有预留上限，拒绝恢复，需要更换密钥
+func (c *SessionCipher) UnmarshalBinary(state []byte) error {
+	if len(state) != sessionStateSize {
+		return errors.New("会话状态长度不正确")
+	}
+	if state[0] == 1 {
+		return errors.New("版本1的会话状态无法保证nonce不重复，需要更换密钥")
+	}
+	if state[0] != sessionStateVersion {
+		return errors.Errorf("不支持的会话状态版本: %d", state[0])
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if Algorithm(state[1]) != c.algorithm {
+		return errors.New("会话状态的算法不匹配")
+	}
+	if (state[2] == 1) != c.initiator {
+		return errors.New("会话状态的方向不匹配")
+	}
+
+	c.sendSeq = binary.BigEndian.Uint64(state[3:11])
+	c.recvSeq = binary.BigEndian.Uint64(state[11:19])
+	c.sendLimit, c.limited = c.sendSeq, true
+	return nil
+}
+
+// open 使用指定序号解密
+func (c *SessionCipher) open(seq uint64, ciphertext, aad []byte) ([]byte, error) {
+	nonce := c.nonce(c.recvLabel(), seq)
+	plaintext, err := c.aead.Open(nil, nonce, ciphertext, aad)
+	if err != nil {
+		return nil, errors.Wrap(err, "会话解密失败，可能是数据被篡改或序号不一致")
+	}
+	return plaintext, nil
+}
+
+// nonce 由方向标识与序号拼接生成nonce
+func (c *SessionCipher) nonce(label []byte, seq uint64) []byte {
+	nonce := make([]byte, c.aead.NonceSize())
+	copy(nonce, label)
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], seq)
+	return nonce
+}
+
+// sendLabel 获取发送方向标识
+func (c *SessionCipher) sendLabel() []byte {
+	if c.initiator {
+		return sessionInitiatorLabel
+	}
+	return sessionResponderLabel
+}
+
+// recvLabel 获取接收方向标识
+func (c *SessionCipher) recvLabel() []byte {
+	if c.initiator {
+		return sessionResponderLabel
+	}
+	return sessionInitiatorLabel
+}
@@ -0,0 +1,118 @@
+package encrypt
+
+import (
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+)
+
+// Shamir秘密共享
+//
+// 在GF(2^8)（AES的不可约多项式 x^8+x^4+x^3+x+1）上对秘密逐字节构造 threshold-1 次随机多项式，
+// 常数项为秘密字节，份额为多项式在不同x处的取值。任意threshold份可以用拉格朗日插值恢复秘密，
+// 少于threshold份得不到关于秘密的任何信息。
+//
+//	份额 = y(len(secret)字节) | x(1)
+//
+// 域运算使用不查表的常量时间实现
+
+// SplitSecret 将秘密拆分为shares份，任意threshold份可恢复，2 ≤ threshold ≤ shares ≤ 255
+func SplitSecret(secret []byte, shares, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("秘密不能为空")
+	}
+	if threshold < 2 || threshold > shares || shares > 255 {
+		return nil, errors.New("份额参数必须满足 2 ≤ 门限 ≤ 份数 ≤ 255")
+	}
+
+	out := make([][]byte, shares)
+	for i := range out {
+		out[i] = make([]byte, len(secret)+1)
+		out[i][len(secret)] = byte(i + 1)
+	}
+
+	coefficients := make([]byte, threshold)
+	defer zeroBytes(coefficients)
+	for pos, b := range secret {
+		coefficients[0] = b
+		if _, err := ReadRandom(coefficients[1:]); err != nil {
+			return nil, err
+		}
+		for _, share := range out {
+			share[pos] = gfEval(coefficients, share[len(secret)])
+		}
+	}
+	return out, nil
+}
+
+// CombineShares 由至少门限数量的份额恢复秘密，份额不足门限时得到的是错误的值而不会报错
+func CombineShares(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, errors.New("至少需要2份份额")
+	}
+	size := len(shares[0])
+	if size < 2 {
+		return nil, errors.New("份额长度不足")
+	}
+	xs := make([]byte, len(shares))
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, errors.New("份额长度不一致")
+		}
+		xs[i] = share[size-1]
+		if xs[i] == 0 {
+			return nil, errors.New("份额格式不正确")
+		}
+		for j := 0; j < i; j++ {
+			if xs[j] == xs[i] {
+				return nil, errors.New("份额重复")
+			}
+		}
+	}
+
+	// 拉格朗日插值求 f(0) = Σ y_i · Π_{j≠i} x_j / (x_j - x_i)
+	secret := make([]byte, size-1)
+	for i, share := range shares {
+		basis := byte(1)
+		for j := range shares {
+			if i != j {
+				basis = gfMul(basis, gfMul(xs[j], gfInverse(xs[j]^xs[i])))
+			}
+		}
+		for pos := range secret {
+			secret[pos] ^= gfMul(share[pos], basis)
+		}
+	}
+	return secret, nil
+}
+
+// gfEval 霍纳法则计算多项式在x处的取值
+func gfEval(coefficients []byte, x byte) byte {
+	y := coefficients[len(coefficients)-1]
+	for i := len(coefficients) - 2; i >= 0; i-- {
+		y = gfMul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// gfMul GF(2^8)乘法，常量时间
+func gfMul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= byte(subtle.ConstantTimeByteEq(b&1, 1)*0xff) & a
+		carry := byte(subtle.ConstantTimeByteEq(a>>7, 1) * 0xff)
+		a = a<<1 ^ 0x1b&carry
+		b >>= 1
+	}
+	return p
+}
+
+// gfInverse GF(2^8)乘法逆元 a^254，常量时间
+func gfInverse(a byte) byte {
+	result := byte(1)
+	for i := 0; i < 7; i++ {
+		a = gfMul(a, a)
+		result = gfMul(result, a)
+	}
+	return result
+}
@@ -0,0 +1,97 @@
+package encrypt
+
+import (
+	"crypto/ecdh"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// 签密（先签名后加密）
+//
+// 签名与加密分开调用时顺序容易出错：先加密后签名会让任何人都能剥掉签名换上自己的；
+// 只做先签名后加密，接收方又可以把签名后的明文重新加密转发给第三方，冒充发送方直接发给了对方。
+// SignAndEncrypt 固定为规范的分层顺序：
+//
+//	签名 = Sign(发送方私钥, "sylphbyte/encrypt signcryption v1" || 接收方公钥 || 数据)
+//	载荷 = version(1) | sigLen(2) | 签名 | 数据
+//	输出 = BoxSeal(载荷, 接收方公钥)
+//
+// 签名覆盖接收方公钥，转发给其他人时验证失败。签名密钥支持Ed25519、ECDSA、RSA与SM2（PEM），
+// 加密层使用Box（X25519 + XChaCha20-Poly1305）。DecryptAndVerify只在签名验证通过后返回数据
+
+// signcryptionVersion 签密载荷版本
+const signcryptionVersion = 1
+
+// signcryptionLabel 签名消息前缀
+const signcryptionLabel = "sylphbyte/encrypt signcryption v1"
+
+// SignAndEncrypt 使用发送方私钥签名后加密给接收方，recipientPublicKey为Box公钥，senderPrivateKeyPEM为PEM签名私钥
+func SignAndEncrypt(recipientPublicKey, senderPrivateKeyPEM, data []byte) ([]byte, error) {
+	if _, err := ecdh.X25519().NewPublicKey(recipientPublicKey); err != nil {
+		return nil, errors.Wrap(err, "接收方公钥格式不正确")
+	}
+	signer, err := ParseSigner(senderPrivateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signMessage(signer, signcryptionMessage(recipientPublicKey, data))
+	if err != nil {
+		return nil, errors.Wrap(err, "签名失败")
+	}
+	if len(signature) > 0xffff {
+		return nil, errors.New("签名过长")
+	}
+
+	payload := make([]byte, 0, 3+len(signature)+len(data))
+	payload = append(payload, signcryptionVersion)
+	payload = binary.BigEndian.AppendUint16(payload, uint16(len(signature)))
+	payload = append(payload, signature...)
+	payload = append(payload, data...)
+	defer zeroBytes(payload)
+
+	return BoxSeal(payload, recipientPublicKey)
+}
+
+// DecryptAndVerify 使用接收方Box私钥解密并验证发送方签名，senderPublicKeyPEM为发送方PEM公钥
+func DecryptAndVerify(ciphertext, recipientPrivateKey, senderPublicKeyPEM []byte) ([]byte, error) {
+	senderPublicKey, err := parsePublicKeyPEM(senderPublicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	priv, err := ecdh.X25519().NewPrivateKey(recipientPrivateKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "私钥格式不正确")
+	}
+
+	payload, err := BoxOpen(ciphertext, recipientPrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(payload) < 3 {
+		return nil, errors.New("签密载荷长度不足")
+	}
+	if payload[0] != signcryptionVersion {
+		return nil, errors.Errorf("不支持的签密版本: %d", payload[0])
+	}
+	sigLen := int(binary.BigEndian.Uint16(payload[1:3]))
+	if len(payload) < 3+sigLen {
+		return nil, errors.New("签密载荷长度不足")
+	}
+	signature, data := payload[3:3+sigLen], payload[3+sigLen:]
+
+	if err := verifyMessage(senderPublicKey, signcryptionMessage(priv.PublicKey().Bytes(), data), signature); err != nil {
+		zeroBytes(payload)
+		return nil, err
+	}
+	return data, nil
+}
+
+// signcryptionMessage 待签名消息
+func signcryptionMessage(recipientPublicKey, data []byte) []byte {
+	message := make([]byte, 0, len(signcryptionLabel)+len(recipientPublicKey)+len(data))
+	message = append(message, signcryptionLabel...)
+	message = append(message, recipientPublicKey...)
+	return append(message, data...)
+}
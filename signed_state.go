@@ -0,0 +1,169 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 无状态的签名/加密状态参数
+//
+// OAuth的state参数、CSRF双重提交令牌等场景需要一段服务端不落库、回传时可校验的数据。
+// StateCodec把载荷与过期时间、用途绑定在一起：
+//
+//	签名：base64url( 版本(1) | 过期时间(8) | 随机数(16) | 载荷 | HMAC-SHA256(32) )
+//	加密：base64url( 版本(1) | 过期时间(8) | AES-GCM(载荷) )
+//
+// 用途（purpose）参与MAC或作为附加认证数据，不同用途的令牌不能互换使用；
+// 签名模式的载荷可被客户端读取，包含敏感信息时使用加密模式
+
+// 状态参数错误，可通过errors.Is判断
+var (
+	ErrStateInvalid = errors.New("状态参数无效")
+	ErrStateExpired = errors.New("状态参数已过期")
+)
+
+// DefaultStateTTL 默认有效期
+const DefaultStateTTL = 10 * time.Minute
+
+// 状态参数格式版本
+const (
+	stateVersionSigned    = 1
+	stateVersionEncrypted = 2
+)
+
+// stateNonceSize 签名模式的随机数长度，保证相同载荷每次生成的令牌不同
+const stateNonceSize = 16
+
+// stateHeaderSize 版本与过期时间长度
+const stateHeaderSize = 9
+
+// StateCodec 状态参数编解码器
+type StateCodec struct {
+	key       []byte
+	encrypted bool
+	ttl       time.Duration
+	now       func() time.Time
+}
+
+// NewSignedState 创建签名模式的编解码器，密钥至少32字节
+func NewSignedState(key []byte) (*StateCodec, error) {
+	if len(key) < 32 {
+		return nil, errors.New("签名密钥长度至少32字节")
+	}
+	return &StateCodec{key: append([]byte(nil), key...), ttl: DefaultStateTTL, now: time.Now}, nil
+}
+
+// NewEncryptedState 创建加密模式的编解码器，密钥为16、24或32字节的AES密钥
+func NewEncryptedState(key []byte) (*StateCodec, error) {
+	if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+		return nil, errors.New("AES密钥长度必须是16、24或32字节")
+	}
+	return &StateCodec{key: append([]byte(nil), key...), encrypted: true, ttl: DefaultStateTTL, now: time.Now}, nil
+}
+
+// WithTTL 设置有效期
+func (s *StateCodec) WithTTL(ttl time.Duration) *StateCodec {
+	s.ttl = ttl
+	return s
+}
+
+// WithClock 设置时钟，主要用于测试
+func (s *StateCodec) WithClock(now func() time.Time) *StateCodec {
+	s.now = now
+	return s
+}
+
+// Issue 生成状态参数，purpose为用途（如"oauth:github"），payload可为空
+func (s *StateCodec) Issue(purpose string, payload []byte) (string, error) {
+	header := make([]byte, stateHeaderSize)
+	binary.BigEndian.PutUint64(header[1:], uint64(s.now().Add(s.ttl).Unix()))
+
+	if s.encrypted {
+		header[0] = stateVersionEncrypted
+		sealed, err := AESGCMEncrypt(s.key, payload, stateAAD(header, purpose))
+		if err != nil {
+			return "", errors.Wrap(err, "加密状态参数失败")
+		}
+		return base64.RawURLEncoding.EncodeToString(append(header, sealed...)), nil
+	}
+
+	header[0] = stateVersionSigned
+	nonce, err := GenerateRandomBytes(stateNonceSize)
+	if err != nil {
+		return "", errors.Wrap(err, "生成随机数失败")
+	}
+	token := append(append(header, nonce...), payload...)
+	token = append(token, s.mac(purpose, token)...)
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// Open 校验状态参数并返回载荷，用途不符、被篡改或格式错误时返回ErrStateInvalid
+func (s *StateCodec) Open(purpose, token string) ([]byte, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(data) < stateHeaderSize {
+		return nil, ErrStateInvalid
+	}
+	header := data[:stateHeaderSize]
+
+	var payload []byte
+	switch {
+	case s.encrypted && header[0] == stateVersionEncrypted:
+		if payload, err = AESGCMDecrypt(s.key, data[stateHeaderSize:], stateAAD(header, purpose)); err != nil {
+			return nil, ErrStateInvalid
+		}
+	case !s.encrypted && header[0] == stateVersionSigned:
+		if len(data) < stateHeaderSize+stateNonceSize+sha256.Size {
+			return nil, ErrStateInvalid
+		}
+		body, tag := data[:len(data)-sha256.Size], data[len(data)-sha256.Size:]
+		if !hmac.Equal(s.mac(purpose, body), tag) {
+			return nil, ErrStateInvalid
+		}
+		payload = body[stateHeaderSize+stateNonceSize:]
+	default:
+		return nil, ErrStateInvalid
+	}
+
+	// 先校验完整性再检查过期，避免未认证的时间戳影响判断
+	expires := time.Unix(int64(binary.BigEndian.Uint64(header[1:])), 0)
+	if s.now().After(expires) {
+		return nil, ErrStateExpired
+	}
+	return payload, nil
+}
+
+// mac 计算签名模式的MAC，用途以长度前缀参与计算
+func (s *StateCodec) mac(purpose string, data []byte) []byte {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write(stateAAD(nil, purpose))
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// stateAAD 附加认证数据：header | len(purpose)(4) | purpose
+func stateAAD(header []byte, purpose string) []byte {
+	aad := make([]byte, 0, len(header)+4+len(purpose))
+	aad = append(aad, header...)
+	aad = binary.BigEndian.AppendUint32(aad, uint32(len(purpose)))
+	return append(aad, purpose...)
+}
+
+// CSRFToken 生成与会话绑定的CSRF令牌，同时写入Cookie与表单/请求头（双重提交）
+func (s *StateCodec) CSRFToken(sessionID string) (string, error) {
+	return s.Issue("csrf:"+sessionID, nil)
+}
+
+// VerifyCSRF 校验双重提交的CSRF令牌：Cookie与请求中的值必须一致，且令牌属于当前会话、未过期
+func (s *StateCodec) VerifyCSRF(sessionID, cookieToken, requestToken string) error {
+	if cookieToken == "" || subtle.ConstantTimeCompare([]byte(cookieToken), []byte(requestToken)) != 1 {
+		return ErrStateInvalid
+	}
+	_, err := s.Open("csrf:"+sessionID, requestToken)
+	return err
+}
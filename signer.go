@@ -0,0 +1,135 @@
+package encrypt
+
+import (
+	"crypto"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 本文件提供crypto.Signer / crypto.Decrypter适配器
+// 加密器自身的Sign/Decrypt方法签名与标准库接口冲突，因此通过Signer()/Decrypter()导出适配对象，
+// 可直接用于tls.Certificate、x509.CreateCertificate等标准库调用方
+
+// Public 获取RSA公钥
+func (r *RSAEncryptor) Public() crypto.PublicKey {
+	if r.publicKey == nil {
+		return nil
+	}
+	return r.publicKey
+}
+
+// Signer 返回实现crypto.Signer的RSA私钥
+func (r *RSAEncryptor) Signer() (crypto.Signer, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("未设置私钥")
+	}
+	return r.privateKey, nil
+}
+
+// Decrypter 返回实现crypto.Decrypter的RSA私钥
+// 默认使用PKCS#1 v1.5解密，传入*rsa.OAEPOptions时使用OAEP
+func (r *RSAEncryptor) Decrypter() (crypto.Decrypter, error) {
+	if r.privateKey == nil {
+		return nil, errors.New("未设置私钥")
+	}
+	return r.privateKey, nil
+}
+
+// sm2UID 获取签名用的用户ID，未设置时返回默认值
+func (s *SM2Encryptor) sm2UID() []byte {
+	if s.uid == nil {
+		return []byte(DefaultSM2UID)
+	}
+	return s.uid
+}
+
+// Public 获取SM2公钥
+func (s *SM2Encryptor) Public() crypto.PublicKey {
+	pubKey, ok := s.publicKey.(*sm2.PublicKey)
+	if !ok {
+		return nil
+	}
+	return pubKey
+}
+
+// Signer 返回实现crypto.Signer的SM2签名适配器
+// 适配器使用当前设置的UID，签名结果为ASN.1 DER编码，与Sign方法的原始输出一致
+func (s *SM2Encryptor) Signer() (crypto.Signer, error) {
+	uid := make([]byte, len(s.sm2UID()))
+	copy(uid, s.sm2UID())
+
+	if device, ok := s.privateKey.(SM2Device); ok {
+		return &sm2DeviceSigner{device: device, publicKey: s.publicKey.(*sm2.PublicKey), uid: uid}, nil
+	}
+	privKey, ok := s.privateKey.(*sm2.PrivateKey)
+	if !ok {
+		return nil, errors.New("未设置私钥或私钥类型不正确")
+	}
+	return &sm2Signer{privateKey: privKey, uid: uid}, nil
+}
+
+// Decrypter 返回实现crypto.Decrypter的SM2解密适配器
+// 适配器按ASN.1格式解密，与Encrypt方法的原始输出一致
+func (s *SM2Encryptor) Decrypter() (crypto.Decrypter, error) {
+	privKey, ok := s.privateKey.(*sm2.PrivateKey)
+	if !ok {
+		return nil, errors.New("未设置私钥或私钥类型不正确")
+	}
+	return &sm2Decrypter{privateKey: privKey}, nil
+}
+
+// sm2Signer SM2 crypto.Signer适配器
+type sm2Signer struct {
+	privateKey *sm2.PrivateKey
+	uid        []byte
+}
+
+// Public 获取SM2公钥
+func (s *sm2Signer) Public() crypto.PublicKey {
+	return &s.privateKey.PublicKey
+}
+
+// Sign 签名数据
+// SM2签名需要在原文前拼接Z值后再做SM3摘要，因此digest参数应传入原始消息（与gmsm/x509的调用约定一致），opts被忽略
+func (s *sm2Signer) Sign(random io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	r, s0, err := sm2.Sm2Sign(s.privateKey, digest, s.uid, random)
+	if err != nil {
+		return nil, errors.Wrap(err, "SM2签名失败")
+	}
+	return sm2.SignDigitToSignData(r, s0)
+}
+
+// sm2Decrypter SM2 crypto.Decrypter适配器
+type sm2Decrypter struct {
+	privateKey *sm2.PrivateKey
+}
+
+// Public 获取SM2公钥
+func (d *sm2Decrypter) Public() crypto.PublicKey {
+	return &d.privateKey.PublicKey
+}
+
+// Decrypt 解密ASN.1格式的SM2密文
+func (d *sm2Decrypter) Decrypt(_ io.Reader, msg []byte, _ crypto.DecrypterOpts) ([]byte, error) {
+	plaintext, err := d.privateKey.DecryptAsn1(msg)
+	if err != nil {
+		return nil, errors.Wrap(err, "SM2解密失败")
+	}
+	return plaintext, nil
+}
+
+// ParseSigner 从PEM编码的私钥创建crypto.Signer
+// 支持RSA(PKCS#1/PKCS#8)、ECDSA(SEC1/PKCS#8)、Ed25519(PKCS#8)以及SM2私钥
+func ParseSigner(privateKeyPEM []byte) (crypto.Signer, error) {
+	key, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	if privKey, ok := key.(*sm2.PrivateKey); ok {
+		return &sm2Signer{privateKey: privKey, uid: []byte(DefaultSM2UID)}, nil
+	}
+	return key.(crypto.Signer), nil
+}
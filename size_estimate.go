@@ -0,0 +1,235 @@
+package encrypt
+
+import (
+	"encoding/base64"
+
+	"github.com/pkg/errors"
+)
+
+// 密文长度估算
+//
+// 设计数据库列宽、校验RSA载荷上限时不必先试加密一次。长度按本库实际的输出格式计算：
+//   - AES、DES、3DES加密器所有模式都会填充（流模式与GCM使用默认PKCS7），未设置WithIV时密文前附带IV，
+//     GCM输出 nonce(12) || 密文 || 标签(16)
+//   - SM4加密器只在ECB、CBC填充，IV不附带在密文中
+//   - 信封在加密器输出（不含IV）之外加上7字节头部与IV；密钥环输出 len(ID) || ID || GCM密文
+//   - RSA为PKCS#1 v1.5，密文长度等于模数长度；SM2为ASN.1编码，长度随坐标取值浮动，按上限计算
+//
+// 最后按Encoding计算编码后的长度
+
+// CiphertextLayout 密文布局
+type CiphertextLayout int
+
+// 密文布局常量定义
+const (
+	// LayoutEncryptor 工厂函数与链式API的输出（未设置WithIV）
+	LayoutEncryptor CiphertextLayout = iota
+	// LayoutEnvelope SealEnvelope、SealEnvelopeSuite的输出
+	LayoutEnvelope
+	// LayoutKeyRing KeyRing.Encrypt的输出
+	LayoutKeyRing
+)
+
+// sm2MaxFixedOverhead SM2 ASN.1密文除密文OCTET STRING外的最大长度：两个INTEGER坐标各35字节、SM3哈希34字节
+const sm2MaxFixedOverhead = 35 + 35 + 34
+
+// SizeConfig 长度估算配置
+type SizeConfig struct {
+	Suite      Suite            // 对称加密套件；RSA、SM2只需设置Suite.Algorithm
+	Layout     CiphertextLayout // 密文布局，RSA、SM2忽略
+	Encoding   EncodingMode     // 输出编码
+	KeyIDLen   int              // LayoutKeyRing的密钥ID长度
+	RSAKeyBits int              // RSA模数位数
+}
+
+// CiphertextLen 计算plaintextLen字节明文加密并编码后的长度，SM2为上限
+func CiphertextLen(config SizeConfig, plaintextLen int) (int, error) {
+	if plaintextLen < 0 {
+		return 0, errors.New("明文长度不能为负数")
+	}
+	n, err := rawCiphertextLen(config, plaintextLen)
+	if err != nil {
+		return 0, err
+	}
+	return encodedLen(config.Encoding, n)
+}
+
+// CiphertextOverhead 编码前密文比明文多出的最大字节数
+// 分组填充的模式按填充一整个分组计算；RSA密文长度固定，按空明文计算；SM2按64KiB以内的明文计算
+func CiphertextOverhead(config SizeConfig) (int, error) {
+	switch config.Suite.Algorithm {
+	case AlgorithmRSA:
+		return rawCiphertextLen(config, 0)
+	case AlgorithmSM2:
+		return sm2CiphertextLen(0xffff) - 0xffff, nil
+	}
+
+	// 填充开销在明文长度为分组整数倍时最大，PaddingNone要求明文对齐，同样取整数倍
+	blockSize, err := symmetricBlockSize(config.Suite.Algorithm)
+	if err != nil {
+		return 0, err
+	}
+	n, err := rawCiphertextLen(config, blockSize)
+	if err != nil {
+		return 0, err
+	}
+	return n - blockSize, nil
+}
+
+// MaxPlaintextLen 计算加密并编码后不超过limit字节的最大明文长度，RSA同时受模数限制
+func MaxPlaintextLen(config SizeConfig, limit int) (int, error) {
+	fits := func(n int) bool {
+		size, err := CiphertextLen(config, n)
+		return err == nil && size <= limit
+	}
+
+	// 密文长度随明文单调不减；PaddingNone只接受分组整数倍，按分组步进查找
+	step := 1
+	if (config.Suite.Mode == ModeECB || config.Suite.Mode == ModeCBC) && config.Suite.Padding == PaddingNone {
+		size, err := symmetricBlockSize(config.Suite.Algorithm)
+		if err != nil {
+			return 0, err
+		}
+		step = size
+	}
+	if _, err := CiphertextLen(config, 0); err != nil {
+		return 0, err
+	}
+	if !fits(0) {
+		return 0, errors.Errorf("长度上限%d字节不足以容纳空明文的密文", limit)
+	}
+
+	// 二分查找最大的k，使k*step满足上限
+	lo, hi := 0, limit/step+1
+	for lo+1 < hi {
+		mid := (lo + hi) / 2
+		if fits(mid * step) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo * step, nil
+}
+
+// rawCiphertextLen 编码前的密文长度
+func rawCiphertextLen(config SizeConfig, n int) (int, error) {
+	suite := config.Suite
+	switch suite.Algorithm {
+	case AlgorithmRSA:
+		return rsaCiphertextLen(config.RSAKeyBits, n)
+	case AlgorithmSM2:
+		return sm2CiphertextLen(n), nil
+	}
+
+	if config.Layout == LayoutKeyRing {
+		if suite.Algorithm != AlgorithmAES && suite.Algorithm != AlgorithmSM4 {
+			return 0, errors.New("密钥环加密仅支持AES与SM4")
+		}
+		if config.KeyIDLen < 1 || config.KeyIDLen > 0xff {
+			return 0, errors.New("密钥ID长度必须在1到255字节之间")
+		}
+		return 1 + config.KeyIDLen + inspectGCMNonceSize + n + inspectTagSize, nil
+	}
+
+	if err := suite.Validate(); err != nil {
+		return 0, err
+	}
+	blockSize, err := symmetricBlockSize(suite.Algorithm)
+	if err != nil {
+		return 0, err
+	}
+
+	// 加密器输出（不含IV）
+	body := n
+	padded := suite.Mode == ModeECB || suite.Mode == ModeCBC || suite.Algorithm != AlgorithmSM4
+	if padded {
+		padding := suite.Padding
+		if suite.Mode != ModeECB && suite.Mode != ModeCBC {
+			padding = PaddingPKCS7
+		}
+		switch padding {
+		case PaddingNone:
+			if n%blockSize != 0 {
+				return 0, errors.Errorf("不填充时明文长度必须是%d的整数倍", blockSize)
+			}
+		default:
+			body = (n/blockSize + 1) * blockSize
+		}
+	}
+	if suite.Mode == ModeGCM {
+		body += inspectGCMNonceSize + inspectTagSize
+	}
+
+	hasIV := suite.Mode != ModeECB && suite.Mode != ModeGCM
+	switch config.Layout {
+	case LayoutEncryptor:
+		if hasIV && suite.Algorithm != AlgorithmSM4 {
+			body += blockSize
+		}
+	case LayoutEnvelope:
+		body += envelopeHeaderSize
+		if hasIV {
+			body += blockSize
+		}
+	default:
+		return 0, errors.New("不支持的密文布局")
+	}
+	return body, nil
+}
+
+// rsaCiphertextLen RSA PKCS#1 v1.5密文长度
+func rsaCiphertextLen(bits, n int) (int, error) {
+	if bits < rsaMinKeyBits {
+		return 0, errors.Errorf("RSA模数位数至少为%d", rsaMinKeyBits)
+	}
+	k := (bits + 7) / 8
+	if n > k-11 {
+		return 0, errors.Errorf("%d位RSA最多加密%d字节", bits, k-11)
+	}
+	return k, nil
+}
+
+// sm2CiphertextLen SM2 ASN.1密文长度上限
+func sm2CiphertextLen(n int) int {
+	body := sm2MaxFixedOverhead + 1 + derLengthSize(n) + n
+	return 1 + derLengthSize(body) + body
+}
+
+// derLengthSize DER长度字段的字节数
+func derLengthSize(n int) int {
+	size := 1
+	if n >= 0x80 {
+		for ; n > 0; n >>= 8 {
+			size++
+		}
+	}
+	return size
+}
+
+// encodedLen 编码后的长度
+func encodedLen(encoding EncodingMode, n int) (int, error) {
+	switch encoding {
+	case EncodingNone:
+		return n, nil
+	case EncodingHex:
+		return 2 * n, nil
+	case EncodingBase64, EncodingBase64Safe:
+		return base64.StdEncoding.EncodedLen(n), nil
+	case EncodingBase64MIME:
+		return wrappedLen(base64.StdEncoding.EncodedLen(n), Base64MIME), nil
+	case EncodingBase64PEM:
+		return wrappedLen(base64.StdEncoding.EncodedLen(n), Base64PEM), nil
+	default:
+		return 0, errors.New("不支持的编码方式")
+	}
+}
+
+// wrappedLen 折行后的长度，最后一行不追加行尾
+func wrappedLen(n int, wrapped *Base64WrappedImpl) int {
+	if n <= wrapped.LineLength {
+		return n
+	}
+	lines := (n + wrapped.LineLength - 1) / wrapped.LineLength
+	return n + (lines-1)*len(wrapped.LineEnding)
+}
@@ -16,12 +16,6 @@ func (s *SM2Encryptor) Algorithm() Algorithm {
 	return s.algorithm
 }
 
-// WithKeySize SM2不需要设置密钥大小，保留此方法是为了符合接口
-func (s *SM2Encryptor) WithKeySize(size int) IAsymmetric {
-	// SM2使用固定的密钥大小，不需要特别设置
-	return s
-}
-
 // WithUID 设置SM2签名用的用户ID，默认为1234567812345678
 func (s *SM2Encryptor) WithUID(uid []byte) IAsymmetric {
 	s.uid = uid
@@ -164,6 +158,19 @@ func (s *SM2Encryptor) Sign(data []byte) ([]byte, error) {
 	if s.privateKey == nil {
 		return nil, errors.New("未设置私钥")
 	}
+
+	// 私钥在硬件设备中时委托设备签名
+	if device, ok := s.privateKey.(SM2Device); ok {
+		digest, err := computeSM2Digest(s.publicKey.(*sm2.PublicKey), s.sm2UID(), data)
+		if err != nil {
+			return nil, err
+		}
+		signature, err := signWithDevice(device, digest)
+		if err != nil {
+			return nil, err
+		}
+		return s.encoding.Encode(signature)
+	}
 	
 	// 类型断言
 	privKey, ok := s.privateKey.(*sm2.PrivateKey)
@@ -172,10 +179,7 @@ func (s *SM2Encryptor) Sign(data []byte) ([]byte, error) {
 	}
 	
 	// 使用默认用户ID或自定义用户ID
-	uid := s.uid
-	if uid == nil {
-		uid = []byte("1234567812345678") // 默认UID
-	}
+	uid := s.sm2UID()
 	
 	// 计算摘要
 	r, s0, err := sm2.Sm2Sign(privKey, data, uid, rand.Reader)
@@ -218,10 +222,7 @@ func (s *SM2Encryptor) Verify(data []byte, signature []byte) (bool, error) {
 	}
 	
 	// 使用默认用户ID或自定义用户ID
-	uid := s.uid
-	if uid == nil {
-		uid = []byte("1234567812345678") // 默认UID
-	}
+	uid := s.sm2UID()
 	
 	// 验证签名
 	valid := sm2.Sm2Verify(pubKey, data, uid, r, s0)
@@ -0,0 +1,125 @@
+package encrypt
+
+import (
+	"crypto"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SM2硬件设备（UKey、密码卡）
+//
+// 私钥不出设备，SM2签名委托给设备完成：本地计算 e = SM3(Z || M)，设备对e签名后返回r、s。
+// 设备驱动按设备路径的scheme注册，例如GM/T 0016 SKF驱动（子模块device/skf）注册为"skf"：
+//
+//	import _ "github.com/sylphbyte/encrypt/device/skf"
+//
+//	sm2 := encrypt.MustNewSM2().(*encrypt.SM2Encryptor).
+//		WithDevice("skf:///usr/lib/libskf.so?container=sign", "123456")
+//	defer sm2.(*encrypt.SM2Encryptor).CloseDevice()
+//
+// 设备只用于签名，Encrypt与Verify使用从设备导出的公钥在本地完成
+
+// SM2Device 持有SM2私钥的硬件设备
+type SM2Device interface {
+	// PublicKey 导出签名公钥
+	PublicKey() (*sm2.PublicKey, error)
+	// SignDigest 对32字节摘要e签名
+	SignDigest(digest []byte) (r, s *big.Int, err error)
+	// Close 关闭设备会话
+	Close() error
+}
+
+// SM2DeviceDriver 根据设备路径与PIN打开设备
+type SM2DeviceDriver func(devicePath, pin string) (SM2Device, error)
+
+var (
+	sm2DeviceDrivers    = map[string]SM2DeviceDriver{}
+	sm2DeviceDriverLock sync.RWMutex
+)
+
+// RegisterSM2DeviceDriver 注册设备驱动，同名驱动会被覆盖
+func RegisterSM2DeviceDriver(scheme string, driver SM2DeviceDriver) {
+	sm2DeviceDriverLock.Lock()
+	defer sm2DeviceDriverLock.Unlock()
+
+	sm2DeviceDrivers[scheme] = driver
+}
+
+// OpenSM2Device 按设备路径的scheme选择驱动并打开设备
+func OpenSM2Device(devicePath, pin string) (SM2Device, error) {
+	scheme, _, ok := strings.Cut(devicePath, ":")
+	if !ok {
+		return nil, errors.Errorf("设备路径缺少驱动名: %s", devicePath)
+	}
+
+	sm2DeviceDriverLock.RLock()
+	driver, ok := sm2DeviceDrivers[scheme]
+	sm2DeviceDriverLock.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("未注册的设备驱动: %s", scheme)
+	}
+	return driver(devicePath, pin)
+}
+
+// WithDevice 使用硬件设备中的私钥签名，同时设置从设备导出的公钥
+func (s *SM2Encryptor) WithDevice(devicePath, pin string) IAsymmetric {
+	device, err := OpenSM2Device(devicePath, pin)
+	if err != nil {
+		panic(fmt.Sprintf("打开SM2设备失败: %s", err))
+	}
+	pubKey, err := device.PublicKey()
+	if err != nil {
+		device.Close()
+		panic(fmt.Sprintf("导出设备公钥失败: %s", err))
+	}
+
+	s.privateKey = device
+	s.publicKey = pubKey
+	return s
+}
+
+// CloseDevice 关闭WithDevice打开的设备，未使用设备时不做任何操作
+func (s *SM2Encryptor) CloseDevice() error {
+	device, ok := s.privateKey.(SM2Device)
+	if !ok {
+		return nil
+	}
+	s.privateKey = nil
+	return device.Close()
+}
+
+// signWithDevice 使用设备对摘要签名，返回DER编码的签名
+func signWithDevice(device SM2Device, digest []byte) ([]byte, error) {
+	r, s, err := device.SignDigest(digest)
+	if err != nil {
+		return nil, errors.Wrap(err, "设备签名失败")
+	}
+	return sm2.SignDigitToSignData(r, s)
+}
+
+// sm2DeviceSigner 设备的crypto.Signer适配器
+type sm2DeviceSigner struct {
+	device    SM2Device
+	publicKey *sm2.PublicKey
+	uid       []byte
+}
+
+// Public 获取SM2公钥
+func (s *sm2DeviceSigner) Public() crypto.PublicKey {
+	return s.publicKey
+}
+
+// Sign 签名数据，digest参数为原始消息，与sm2Signer的调用约定一致
+func (s *sm2DeviceSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	e, err := computeSM2Digest(s.publicKey, s.uid, digest)
+	if err != nil {
+		return nil, err
+	}
+	return signWithDevice(s.device, e)
+}
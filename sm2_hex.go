@@ -0,0 +1,163 @@
+package encrypt
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// SM2裸密钥格式（GM/T 0009）
+// 私钥为32字节标量D，公钥为非压缩点 04||X||Y（64字节X||Y亦可），均以十六进制字符串交换，
+// 这是银行等机构接口文档中最常见的SM2密钥交换形式
+
+// sm2CoordinateSize SM2坐标与私钥标量的字节长度
+const sm2CoordinateSize = 32
+
+// WithPrivateKeyHex 使用十六进制私钥标量D设置私钥，同时设置对应的公钥
+func (s *SM2Encryptor) WithPrivateKeyHex(d string) IAsymmetric {
+	privKey, err := parseSM2PrivateKeyHex(d)
+	if err != nil {
+		panic(fmt.Sprintf("解析SM2私钥失败: %s", err))
+	}
+
+	s.privateKey = privKey
+	// 同时设置对应的公钥
+	s.publicKey = &privKey.PublicKey
+
+	return s
+}
+
+// WithPublicKeyHex 使用十六进制公钥设置公钥
+// 支持 04||X||Y 非压缩格式、X||Y 裸坐标格式以及 02/03 压缩格式
+func (s *SM2Encryptor) WithPublicKeyHex(q string) IAsymmetric {
+	pubKey, err := parseSM2PublicKeyHex(q)
+	if err != nil {
+		panic(fmt.Sprintf("解析SM2公钥失败: %s", err))
+	}
+
+	s.publicKey = pubKey
+	return s
+}
+
+// ExportPrivateKeyHex 导出私钥标量D的十六进制表示，固定64个字符
+func (s *SM2Encryptor) ExportPrivateKeyHex() (string, error) {
+	privKey, ok := s.privateKey.(*sm2.PrivateKey)
+	if !ok {
+		return "", errors.New("未设置私钥或私钥类型不正确")
+	}
+	return hex.EncodeToString(leftPad(privKey.D.Bytes(), sm2CoordinateSize)), nil
+}
+
+// ExportPublicKeyHex 导出 04||X||Y 非压缩格式的十六进制公钥，固定130个字符
+func (s *SM2Encryptor) ExportPublicKeyHex() (string, error) {
+	pubKey, ok := s.publicKey.(*sm2.PublicKey)
+	if !ok {
+		return "", errors.New("未设置公钥或公钥类型不正确")
+	}
+
+	point := make([]byte, 0, 1+2*sm2CoordinateSize)
+	point = append(point, 0x04)
+	point = append(point, leftPad(pubKey.X.Bytes(), sm2CoordinateSize)...)
+	point = append(point, leftPad(pubKey.Y.Bytes(), sm2CoordinateSize)...)
+	return hex.EncodeToString(point), nil
+}
+
+// parseSM2PrivateKeyHex 解析十六进制私钥标量
+func parseSM2PrivateKeyHex(d string) (*sm2.PrivateKey, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(d))
+	if err != nil {
+		return nil, errors.Wrap(err, "十六进制解码失败")
+	}
+	return sm2PrivateKeyFromScalar(raw)
+}
+
+// sm2PrivateKeyFromScalar 由32字节私钥标量构造私钥
+func sm2PrivateKeyFromScalar(raw []byte) (*sm2.PrivateKey, error) {
+	if len(raw) != sm2CoordinateSize {
+		return nil, errors.Errorf("私钥长度必须是%d字节", sm2CoordinateSize)
+	}
+
+	curve := sm2.P256Sm2()
+	k := new(big.Int).SetBytes(raw)
+	// SM2要求 1 <= d <= n-2
+	limit := new(big.Int).Sub(curve.Params().N, big.NewInt(1))
+	if k.Sign() <= 0 || k.Cmp(limit) >= 0 {
+		return nil, errors.New("私钥标量超出有效范围")
+	}
+
+	privKey := new(sm2.PrivateKey)
+	privKey.PublicKey.Curve = curve
+	privKey.D = k
+	privKey.PublicKey.X, privKey.PublicKey.Y = curve.ScalarBaseMult(raw)
+	return privKey, nil
+}
+
+// parseSM2PublicKeyHex 解析十六进制公钥点
+func parseSM2PublicKeyHex(q string) (*sm2.PublicKey, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(q))
+	if err != nil {
+		return nil, errors.Wrap(err, "十六进制解码失败")
+	}
+
+	var pubKey *sm2.PublicKey
+	switch {
+	case len(raw) == 1+2*sm2CoordinateSize && raw[0] == 0x04:
+		raw = raw[1:]
+		fallthrough
+	case len(raw) == 2*sm2CoordinateSize:
+		pubKey = &sm2.PublicKey{
+			Curve: sm2.P256Sm2(),
+			X:     new(big.Int).SetBytes(raw[:sm2CoordinateSize]),
+			Y:     new(big.Int).SetBytes(raw[sm2CoordinateSize:]),
+		}
+	case len(raw) == 1+sm2CoordinateSize && (raw[0] == 0x02 || raw[0] == 0x03):
+		pubKey, err = decompressSM2PublicKey(raw)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.New("不支持的公钥格式")
+	}
+
+	if !pubKey.Curve.IsOnCurve(pubKey.X, pubKey.Y) {
+		return nil, errors.New("公钥点不在曲线上")
+	}
+	return pubKey, nil
+}
+
+// decompressSM2PublicKey 解压 02/03||X 格式的公钥
+func decompressSM2PublicKey(raw []byte) (*sm2.PublicKey, error) {
+	params := sm2.P256Sm2().Params()
+	x := new(big.Int).SetBytes(raw[1:])
+
+	// y² = x³ - 3x + b (mod p)
+	y2 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+	threeX := new(big.Int).Mul(x, big.NewInt(3))
+	y2.Sub(y2, threeX)
+	y2.Add(y2, params.B)
+	y2.Mod(y2, params.P)
+
+	y := new(big.Int).ModSqrt(y2, params.P)
+	if y == nil {
+		return nil, errors.New("公钥点不在曲线上")
+	}
+	if y.Bit(0) != uint(raw[0]&0x01) {
+		y.Sub(params.P, y)
+	}
+
+	return &sm2.PublicKey{Curve: sm2.P256Sm2(), X: x, Y: y}, nil
+}
+
+// leftPad 在数据左侧补零至指定长度
+func leftPad(data []byte, size int) []byte {
+	if len(data) >= size {
+		return data
+	}
+	padded := make([]byte, size)
+	copy(padded[size-len(data):], data)
+	return padded
+}
@@ -0,0 +1,92 @@
+package encrypt
+
+import (
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// DefaultSM2UID GM/T 0009推荐的SM2默认用户标识
+const DefaultSM2UID = "1234567812345678"
+
+// SM2签名摘要流程（GM/T 0003.2）：
+//   Z = SM3(ENTL || ID || a || b || xG || yG || xA || yA)
+//   e = SM3(Z || M)
+// 与合作方互通时，签名失败最常见的原因是双方UID或Z值计算不一致，
+// 可以使用以下函数分别比对Z值和e值定位问题
+
+// ComputeZA 计算PEM编码SM2公钥在指定UID下的Z值，uid为空时使用DefaultSM2UID
+func ComputeZA(publicKeyPEM []byte, uid []byte) ([]byte, error) {
+	pubKey, err := parseSM2PublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return computeZA(pubKey, uid)
+}
+
+// ComputeSM2Digest 计算SM2签名使用的摘要e = SM3(Z || M)，uid为空时使用DefaultSM2UID
+// 结果可直接传给SignDigest/VerifyDigest
+func ComputeSM2Digest(publicKeyPEM []byte, uid []byte, data []byte) ([]byte, error) {
+	pubKey, err := parseSM2PublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	return computeSM2Digest(pubKey, uid, data)
+}
+
+// ComputeZA 使用当前公钥和UID计算Z值
+func (s *SM2Encryptor) ComputeZA() ([]byte, error) {
+	pubKey, ok := s.publicKey.(*sm2.PublicKey)
+	if !ok {
+		return nil, errors.New("未设置公钥或公钥类型不正确")
+	}
+	return computeZA(pubKey, s.sm2UID())
+}
+
+// ComputeDigest 使用当前公钥和UID计算签名摘要e = SM3(Z || M)
+func (s *SM2Encryptor) ComputeDigest(data []byte) ([]byte, error) {
+	pubKey, ok := s.publicKey.(*sm2.PublicKey)
+	if !ok {
+		return nil, errors.New("未设置公钥或公钥类型不正确")
+	}
+	return computeSM2Digest(pubKey, s.sm2UID(), data)
+}
+
+// computeZA 计算Z值
+func computeZA(pubKey *sm2.PublicKey, uid []byte) ([]byte, error) {
+	if len(uid) == 0 {
+		uid = []byte(DefaultSM2UID)
+	}
+
+	za, err := sm2.ZA(pubKey, uid)
+	if err != nil {
+		return nil, errors.Wrap(err, "计算SM2 Z值失败")
+	}
+	return za, nil
+}
+
+// computeSM2Digest 计算e = SM3(Z || M)
+func computeSM2Digest(pubKey *sm2.PublicKey, uid []byte, data []byte) ([]byte, error) {
+	za, err := computeZA(pubKey, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	h := newSM3()
+	h.Write(za)
+	h.Write(data)
+	return h.Sum(nil), nil
+}
+
+// parseSM2PublicKeyPEM 解析PEM编码的SM2公钥
+func parseSM2PublicKeyPEM(publicKeyPEM []byte) (*sm2.PublicKey, error) {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	pubKey, ok := publicKey.(*sm2.PublicKey)
+	if !ok {
+		return nil, errors.New("提供的不是SM2公钥")
+	}
+	return pubKey, nil
+}
@@ -4,7 +4,6 @@ import (
 	"os"
 
 	"github.com/pkg/errors"
-	"github.com/tjfoc/gmsm/sm3"
 )
 
 // SM3Hasher SM3哈希算法实现
@@ -52,7 +51,7 @@ func (s *SM3Hasher) Hex() *SM3Hasher {
 // Sum 计算数据的SM3哈希值
 func (s *SM3Hasher) Sum(data []byte) (string, error) {
 	// 计算SM3哈希值
-	hash := sm3.Sm3Sum(data)
+	hash := sm3Sum(data)
 	
 	// 编码结果
 	encodedBytes, err := s.encoding.Encode(hash)
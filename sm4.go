@@ -2,8 +2,6 @@ package encrypt
 
 import (
 	"crypto/cipher"
-	"crypto/rand"
-	"io"
 
 	"github.com/pkg/errors"
 	"github.com/tjfoc/gmsm/sm4"
@@ -19,6 +17,7 @@ type SM4Encryptor struct {
 
 	encoding     Encoding
 	encodingMode EncodingMode
+	hardening    cbcHardening
 }
 
 // Algorithm 获取算法类型
@@ -137,7 +136,7 @@ func (s *SM4Encryptor) needsPadding() bool {
 // Encrypt SM4加密
 func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 	// 创建SM4块
-	block, err := sm4.NewCipher(s.key)
+	block, err := newSM4Cipher(s.key)
 	if err != nil {
 		return nil, errors.Wrap(err, "创建SM4块失败")
 	}
@@ -198,7 +197,7 @@ func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		if s.iv == nil {
 			// 从对象池获取IV缓冲区
 			ivBuf := GetBuffer(blockSize)
-			if _, err := io.ReadFull(rand.Reader, ivBuf); err != nil {
+			if err := readNonce(ivBuf); err != nil {
 				PutBuffer(ivBuf) // 出错时归还缓冲区
 				return nil, errors.Wrap(err, "生成随机IV失败")
 			}
@@ -230,7 +229,7 @@ func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		if s.iv == nil {
 			// 从对象池获取IV缓冲区
 			ivBuf := GetBuffer(blockSize)
-			if _, err := io.ReadFull(rand.Reader, ivBuf); err != nil {
+			if err := readNonce(ivBuf); err != nil {
 				PutBuffer(ivBuf) // 出错时归还缓冲区
 				return nil, errors.Wrap(err, "生成随机IV失败")
 			}
@@ -262,7 +261,7 @@ func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		if s.iv == nil {
 			// 从对象池获取IV缓冲区
 			ivBuf := GetBuffer(blockSize)
-			if _, err := io.ReadFull(rand.Reader, ivBuf); err != nil {
+			if err := readNonce(ivBuf); err != nil {
 				PutBuffer(ivBuf) // 出错时归还缓冲区
 				return nil, errors.Wrap(err, "生成随机IV失败")
 			}
@@ -294,7 +293,7 @@ func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		if s.iv == nil {
 			// 从对象池获取IV缓冲区
 			ivBuf := GetBuffer(blockSize)
-			if _, err := io.ReadFull(rand.Reader, ivBuf); err != nil {
+			if err := readNonce(ivBuf); err != nil {
 				PutBuffer(ivBuf) // 出错时归还缓冲区
 				return nil, errors.Wrap(err, "生成随机IV失败")
 			}
@@ -331,7 +330,7 @@ func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		// 从对象池获取nonce缓冲区
 		nonceSize := gcm.NonceSize()
 		nonceBuf := GetBuffer(nonceSize)
-		if _, err := io.ReadFull(rand.Reader, nonceBuf); err != nil {
+		if err := readNonce(nonceBuf); err != nil {
 			PutBuffer(nonceBuf) // 出错时归还缓冲区
 			return nil, errors.Wrap(err, "生成GCM nonce失败")
 		}
@@ -363,6 +362,12 @@ func (s *SM4Encryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		return nil, errors.New("不支持的工作模式")
 	}
 
+	if s.blockMode == ModeCBC && s.hardening.enabled {
+		if encrypted, err = s.hardening.seal(encrypted); err != nil {
+			return nil, err
+		}
+	}
+
 	// 对加密结果进行编码
 	return s.encoding.Encode(encrypted)
 }
@@ -376,7 +381,7 @@ func (s *SM4Encryptor) Decrypt(ciphertext []byte) ([]byte, error) {
 	}
 
 	// 创建SM4块
-	block, err := sm4.NewCipher(s.key)
+	block, err := newSM4Cipher(s.key)
 	if err != nil {
 		return nil, errors.Wrap(err, "创建SM4块失败")
 	}
@@ -418,6 +423,18 @@ func (s *SM4Encryptor) Decrypt(ciphertext []byte) ([]byte, error) {
 			return nil, errors.New("CBC模式需要正确的IV")
 		}
 
+		// 加固模式：统一错误并常量时间去填充
+		if s.hardening.enabled {
+			return s.hardening.open(decoded, blockSize, s.padding, func(data []byte) ([]byte, error) {
+				if len(data) == 0 || len(data)%blockSize != 0 {
+					return nil, errors.New("密文长度不是块大小的整数倍")
+				}
+				out := make([]byte, len(data))
+				cipher.NewCBCDecrypter(block, s.iv).CryptBlocks(out, data)
+				return out, nil
+			})
+		}
+
 		// 从对象池获取解密结果缓冲区
 		resultBuf := GetBuffer(len(decoded))
 		
@@ -0,0 +1,173 @@
+package encrypt
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+
+	"github.com/pkg/errors"
+)
+
+// 短消息快速路径
+//
+// 令牌、用户ID等载荷通常不超过一个分组，常规加密器每次调用都要重新创建分组密码、
+// 经过对象池与多次中间切片复制，开销远大于一两次分组运算本身。SmallCipher只处理
+// 不超过16字节的载荷：分组密码在构造时创建一次，填充、加密与解密都原地写入调用方的dst，
+// dst容量足够时不产生任何内存分配。
+//
+// 输出格式：
+//   - Encrypt：CBC + PKCS7，每条消息随机IV，输出 IV(16) || 密文，共32或48字节，
+//     与AES加密器（CBC、EncodingNone）的输出互通；SM4加密器的IV单独保存，不能直接互通
+//   - EncryptDeterministic：ECB + PKCS7，相同明文得到相同密文，输出16或32字节，
+//     与AES、SM4加密器（ECB、EncodingNone）的输出互通。只适合需要按密文查找的ID类字段，会泄露明文是否相同
+
+// SmallMessageMaxSize 快速路径支持的最大明文长度
+const SmallMessageMaxSize = 16
+
+// smallBlockSize 快速路径的分组长度（AES与SM4）
+const smallBlockSize = 16
+
+// ErrSmallMessageTooLarge 明文超出快速路径支持的长度
+var ErrSmallMessageTooLarge = errors.New("明文超过16字节，请使用常规加密器")
+
+// SmallCipher 不超过一个分组的短消息加密器，可并发使用
+type SmallCipher struct {
+	block cipher.Block
+}
+
+// NewSmallCipher 创建短消息加密器，algorithm支持AlgorithmAES与AlgorithmSM4
+func NewSmallCipher(algorithm Algorithm, key []byte) (*SmallCipher, error) {
+	var block cipher.Block
+	var err error
+	switch algorithm {
+	case AlgorithmAES:
+		block, err = aes.NewCipher(key)
+	case AlgorithmSM4:
+		block, err = newSM4Cipher(key)
+	default:
+		return nil, errors.New("短消息快速路径只支持AES与SM4")
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "创建密码块失败")
+	}
+	return &SmallCipher{block: block}, nil
+}
+
+// Encrypt 以CBC模式加密并追加到dst，返回追加后的切片
+func (c *SmallCipher) Encrypt(dst, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > SmallMessageMaxSize {
+		return nil, ErrSmallMessageTooLarge
+	}
+
+	n := smallPaddedSize(len(plaintext))
+	dst, out := smallAppendSpace(dst, smallBlockSize+n)
+	iv, body := out[:smallBlockSize], out[smallBlockSize:]
+	if err := readNonce(iv); err != nil {
+		return nil, errors.Wrap(err, "生成随机IV失败")
+	}
+	smallPad(body, plaintext)
+
+	prev := iv
+	for i := 0; i < n; i += smallBlockSize {
+		chunk := body[i : i+smallBlockSize]
+		subtle.XORBytes(chunk, chunk, prev)
+		c.block.Encrypt(chunk, chunk)
+		prev = chunk
+	}
+	return dst, nil
+}
+
+// Decrypt 解密Encrypt的输出（或AES加密器CBC-PKCS7的单/双分组密文）并追加到dst
+func (c *SmallCipher) Decrypt(dst, ciphertext []byte) ([]byte, error) {
+	n := len(ciphertext) - smallBlockSize
+	if n != smallBlockSize && n != 2*smallBlockSize {
+		return nil, errors.New("短消息密文长度不正确")
+	}
+
+	base := len(dst)
+	dst, out := smallAppendSpace(dst, n)
+	prev := ciphertext[:smallBlockSize]
+	for i := 0; i < n; i += smallBlockSize {
+		chunk := ciphertext[smallBlockSize+i : smallBlockSize+i+smallBlockSize]
+		c.block.Decrypt(out[i:i+smallBlockSize], chunk)
+		subtle.XORBytes(out[i:i+smallBlockSize], out[i:i+smallBlockSize], prev)
+		prev = chunk
+	}
+	return smallUnpad(dst, base, n)
+}
+
+// EncryptDeterministic 以ECB模式加密并追加到dst，相同明文总是得到相同密文
+func (c *SmallCipher) EncryptDeterministic(dst, plaintext []byte) ([]byte, error) {
+	if len(plaintext) > SmallMessageMaxSize {
+		return nil, ErrSmallMessageTooLarge
+	}
+
+	n := smallPaddedSize(len(plaintext))
+	dst, out := smallAppendSpace(dst, n)
+	smallPad(out, plaintext)
+	for i := 0; i < n; i += smallBlockSize {
+		c.block.Encrypt(out[i:i+smallBlockSize], out[i:i+smallBlockSize])
+	}
+	return dst, nil
+}
+
+// DecryptDeterministic 解密EncryptDeterministic的输出并追加到dst
+func (c *SmallCipher) DecryptDeterministic(dst, ciphertext []byte) ([]byte, error) {
+	n := len(ciphertext)
+	if n != smallBlockSize && n != 2*smallBlockSize {
+		return nil, errors.New("短消息密文长度不正确")
+	}
+
+	base := len(dst)
+	dst, out := smallAppendSpace(dst, n)
+	for i := 0; i < n; i += smallBlockSize {
+		c.block.Decrypt(out[i:i+smallBlockSize], ciphertext[i:i+smallBlockSize])
+	}
+	return smallUnpad(dst, base, n)
+}
+
+// smallPaddedSize PKCS7填充后的长度：不足16字节为一个分组，恰好16字节为两个分组
+func smallPaddedSize(length int) int {
+	return (length/smallBlockSize + 1) * smallBlockSize
+}
+
+// smallPad 将明文与PKCS7填充写入out，out长度为smallPaddedSize
+func smallPad(out, plaintext []byte) {
+	copy(out, plaintext)
+	padding := byte(len(out) - len(plaintext))
+	for i := len(plaintext); i < len(out); i++ {
+		out[i] = padding
+	}
+}
+
+// smallUnpad 常量时间校验dst[base:base+n]的PKCS7填充并截掉填充
+func smallUnpad(dst []byte, base, n int) ([]byte, error) {
+	out := dst[base : base+n]
+	padding := int(out[n-1])
+
+	// 填充长度必须在1~16之间，最后padding个字节都等于padding
+	good := subtle.ConstantTimeLessOrEq(1, padding) & subtle.ConstantTimeLessOrEq(padding, smallBlockSize)
+	for i := 0; i < smallBlockSize; i++ {
+		inPadding := subtle.ConstantTimeLessOrEq(i+1, padding)
+		match := subtle.ConstantTimeByteEq(out[n-1-i], byte(padding))
+		good &= subtle.ConstantTimeSelect(inPadding, match, 1)
+	}
+	if good != 1 {
+		zeroBytes(out)
+		return nil, errors.New("解密失败")
+	}
+	zeroBytes(out[n-padding:])
+	return dst[:base+n-padding], nil
+}
+
+// smallAppendSpace 在dst之后预留n字节，返回扩展后的切片与预留部分
+func smallAppendSpace(dst []byte, n int) ([]byte, []byte) {
+	total := len(dst) + n
+	if cap(dst) < total {
+		grown := make([]byte, len(dst), total)
+		copy(grown, dst)
+		dst = grown
+	}
+	dst = dst[:total]
+	return dst, dst[total-n:]
+}
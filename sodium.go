@@ -0,0 +1,139 @@
+package encrypt
+
+import (
+	"crypto/rand"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/nacl/box"
+	"golang.org/x/crypto/nacl/secretbox"
+)
+
+// libsodium 兼容
+//
+// SodiumSeal 与 crypto_box_seal 一致（X25519 + XSalsa20-Poly1305，临时公钥在前），
+// SecretBox 系列与 crypto_secretbox_easy 一致（Poly1305标签在前）。
+// PHP等服务通常把nonce拼在secretbox密文之前，SecretBoxSeal/SecretBoxOpen 采用同样的布局：
+//
+//	nonce(24) | 标签(16) | 密文
+//
+// nonce单独保存时使用 SecretBoxSealWithNonce/SecretBoxOpenWithNonce
+
+// libsodium 常量
+const (
+	SodiumPublicKeySize  = 32
+	SodiumPrivateKeySize = 32
+	SodiumSealOverhead   = box.AnonymousOverhead
+	SecretBoxKeySize     = 32
+	SecretBoxNonceSize   = 24
+	SecretBoxOverhead    = secretbox.Overhead
+)
+
+// GenerateSodiumKeyPair 生成X25519密钥对，对应 crypto_box_keypair
+func GenerateSodiumKeyPair() (publicKey, privateKey []byte, err error) {
+	pub, priv, err := box.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "生成密钥对失败")
+	}
+	return pub[:], priv[:], nil
+}
+
+// SodiumSeal 匿名加密给接收方公钥，对应 crypto_box_seal
+func SodiumSeal(message, recipientPublicKey []byte) ([]byte, error) {
+	pub, err := sodiumKey(recipientPublicKey, "公钥")
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := box.SealAnonymous(nil, message, pub, rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "加密失败")
+	}
+	return sealed, nil
+}
+
+// SodiumSealOpen 使用接收方密钥对解密，对应 crypto_box_seal_open
+func SodiumSealOpen(sealed, publicKey, privateKey []byte) ([]byte, error) {
+	pub, err := sodiumKey(publicKey, "公钥")
+	if err != nil {
+		return nil, err
+	}
+	priv, err := sodiumKey(privateKey, "私钥")
+	if err != nil {
+		return nil, err
+	}
+
+	message, ok := box.OpenAnonymous(nil, sealed, pub, priv)
+	if !ok {
+		return nil, errors.New("解密失败")
+	}
+	return message, nil
+}
+
+// SecretBoxSeal 使用随机nonce加密，输出 nonce || crypto_secretbox_easy 密文
+func SecretBoxSeal(message, key []byte) ([]byte, error) {
+	nonce, err := GenerateRandomBytes(SecretBoxNonceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := SecretBoxSealWithNonce(message, nonce, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(nonce, sealed...), nil
+}
+
+// SecretBoxOpen 解密 nonce || crypto_secretbox_easy 密文
+func SecretBoxOpen(data, key []byte) ([]byte, error) {
+	if len(data) < SecretBoxNonceSize+SecretBoxOverhead {
+		return nil, errors.New("密文长度不足")
+	}
+	return SecretBoxOpenWithNonce(data[SecretBoxNonceSize:], data[:SecretBoxNonceSize], key)
+}
+
+// SecretBoxSealWithNonce 使用指定nonce加密，对应 crypto_secretbox_easy，同一密钥下nonce不能重复
+func SecretBoxSealWithNonce(message, nonce, key []byte) ([]byte, error) {
+	k, n, err := secretBoxParams(nonce, key)
+	if err != nil {
+		return nil, err
+	}
+	return secretbox.Seal(nil, message, n, k), nil
+}
+
+// SecretBoxOpenWithNonce 使用指定nonce解密，对应 crypto_secretbox_open_easy
+func SecretBoxOpenWithNonce(sealed, nonce, key []byte) ([]byte, error) {
+	k, n, err := secretBoxParams(nonce, key)
+	if err != nil {
+		return nil, err
+	}
+
+	message, ok := secretbox.Open(nil, sealed, n, k)
+	if !ok {
+		return nil, errors.New("解密失败")
+	}
+	return message, nil
+}
+
+// sodiumKey 校验并转换32字节密钥
+func sodiumKey(key []byte, name string) (*[32]byte, error) {
+	if len(key) != 32 {
+		return nil, errors.Errorf("%s长度必须是32字节", name)
+	}
+	var out [32]byte
+	copy(out[:], key)
+	return &out, nil
+}
+
+// secretBoxParams 校验并转换secretbox密钥与nonce
+func secretBoxParams(nonce, key []byte) (*[32]byte, *[24]byte, error) {
+	k, err := sodiumKey(key, "密钥")
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nonce) != SecretBoxNonceSize {
+		return nil, nil, errors.New("nonce长度必须是24字节")
+	}
+	var n [24]byte
+	copy(n[:], nonce)
+	return k, &n, nil
+}
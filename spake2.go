@@ -0,0 +1,220 @@
+package encrypt
+
+import (
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// SPAKE2口令认证密钥交换（RFC 9382，P256-SHA256-HKDF-HMAC套件）
+//
+// 双方仅共享一个口令即可协商出高强度的会话密钥，离线窃听者无法据此暴力破解口令，
+// 主动攻击者每次交互只能猜测一个口令。流程：
+//
+//	A: msgA := a.Message()          -> B
+//	B: msgB := b.Message()          -> A
+//	A: confirmA, _ := a.Finish(msgB) -> B
+//	B: confirmB, _ := b.Finish(msgA) -> A
+//	双方: Verify(对方的确认值)，成功后通过SharedKey获取会话密钥
+
+// SPAKE2Role 协议角色
+type SPAKE2Role int
+
+// 协议角色常量定义
+const (
+	SPAKE2RoleA SPAKE2Role = iota + 1
+	SPAKE2RoleB
+)
+
+// spake2PasswordIterations 口令派生w时的PBKDF2迭代次数
+const spake2PasswordIterations = 100000
+
+// RFC 9382 第6节 P-256 的M、N点（压缩格式）
+const (
+	spake2MHex = "02886e2f97ace46e55ba9dd7242579f2993b64e16ef3dcab95afd497333d8fa12f"
+	spake2NHex = "03d8bbd6c639c62937b04d997f38c3770719c629d7014d49a24b4f98baa1292b49"
+)
+
+// SPAKE2 单次SPAKE2协议会话，不可复用
+type SPAKE2 struct {
+	role     SPAKE2Role
+	curve    elliptic.Curve
+	idA, idB []byte
+	w, x     *big.Int
+	message  []byte
+
+	ke, kcPeer []byte
+	transcript []byte
+	verified   bool
+}
+
+// NewSPAKE2 创建SPAKE2会话，idA与idB为双方身份标识，双方必须使用相同的值
+func NewSPAKE2(role SPAKE2Role, password, idA, idB []byte) (*SPAKE2, error) {
+	if role != SPAKE2RoleA && role != SPAKE2RoleB {
+		return nil, errors.New("不支持的SPAKE2角色")
+	}
+	if len(password) == 0 {
+		return nil, errors.New("口令不能为空")
+	}
+
+	curve := elliptic.P256()
+	n := curve.Params().N
+
+	// w = MHF(pw) mod n，盐值绑定双方身份
+	salt := append(spake2LengthPrefixed(nil, idA), spake2LengthPrefixed(nil, idB)...)
+	w := new(big.Int).SetBytes(pbkdf2(password, salt, spake2PasswordIterations, 64, sha256.New))
+	w.Mod(w, n)
+
+	x, err := randScalar(n, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	// pA = x*G + w*M，pB = y*G + w*N
+	blindX, blindY, err := spake2Blind(curve, role)
+	if err != nil {
+		return nil, err
+	}
+	xGx, xGy := curve.ScalarBaseMult(x.Bytes())
+	wMx, wMy := curve.ScalarMult(blindX, blindY, w.Bytes())
+	px, py := curve.Add(xGx, xGy, wMx, wMy)
+
+	return &SPAKE2{
+		role:    role,
+		curve:   curve,
+		idA:     append([]byte(nil), idA...),
+		idB:     append([]byte(nil), idB...),
+		w:       w,
+		x:       x,
+		message: elliptic.Marshal(curve, px, py),
+	}, nil
+}
+
+// Message 获取发送给对方的公开消息
+func (s *SPAKE2) Message() []byte {
+	return s.message
+}
+
+// Finish 处理对方的消息，返回发送给对方的密钥确认值
+func (s *SPAKE2) Finish(peerMessage []byte) ([]byte, error) {
+	if s.transcript != nil {
+		return nil, errors.New("SPAKE2会话已完成，不可复用")
+	}
+
+	px, py := elliptic.Unmarshal(s.curve, peerMessage)
+	if px == nil {
+		return nil, errors.New("对方消息不是有效的曲线点")
+	}
+
+	// 去除对方的口令盲化：K = x * (pPeer - w*N)
+	peerRole := SPAKE2RoleB
+	if s.role == SPAKE2RoleB {
+		peerRole = SPAKE2RoleA
+	}
+	blindX, blindY, err := spake2Blind(s.curve, peerRole)
+	if err != nil {
+		return nil, err
+	}
+	wNx, wNy := s.curve.ScalarMult(blindX, blindY, s.w.Bytes())
+	wNy.Sub(s.curve.Params().P, wNy)
+	qx, qy := s.curve.Add(px, py, wNx, wNy)
+	kx, ky := s.curve.ScalarMult(qx, qy, s.x.Bytes())
+	if kx.Sign() == 0 && ky.Sign() == 0 {
+		return nil, errors.New("协商结果为无穷远点")
+	}
+
+	pA, pB := s.message, peerMessage
+	if s.role == SPAKE2RoleB {
+		pA, pB = peerMessage, s.message
+	}
+
+	// TT = len(A)||A||len(B)||B||len(pA)||pA||len(pB)||pB||len(K)||K||len(w)||w
+	var tt []byte
+	tt = spake2LengthPrefixed(tt, s.idA)
+	tt = spake2LengthPrefixed(tt, s.idB)
+	tt = spake2LengthPrefixed(tt, pA)
+	tt = spake2LengthPrefixed(tt, pB)
+	tt = spake2LengthPrefixed(tt, elliptic.Marshal(s.curve, kx, ky))
+	tt = spake2LengthPrefixed(tt, leftPad(s.w.Bytes(), 32))
+
+	hashTT := sha256.Sum256(tt)
+	ke, ka := hashTT[:16], hashTT[16:]
+
+	kc, err := hkdf.Key(sha256.New, ka, nil, "ConfirmationKeys", 32)
+	if err != nil {
+		return nil, errors.Wrap(err, "派生确认密钥失败")
+	}
+	kcA, kcB := kc[:16], kc[16:]
+
+	kcSelf, kcPeer := kcA, kcB
+	if s.role == SPAKE2RoleB {
+		kcSelf, kcPeer = kcB, kcA
+	}
+
+	s.ke = append([]byte(nil), ke...)
+	s.kcPeer = kcPeer
+	s.transcript = tt
+	s.x = nil
+
+	return spake2MAC(kcSelf, tt), nil
+}
+
+// Verify 校验对方的密钥确认值，失败说明双方口令不一致或遭到篡改
+func (s *SPAKE2) Verify(peerConfirmation []byte) error {
+	if s.transcript == nil {
+		return errors.New("需要先调用Finish")
+	}
+	if !hmac.Equal(spake2MAC(s.kcPeer, s.transcript), peerConfirmation) {
+		return errors.New("密钥确认失败，口令可能不一致")
+	}
+	s.verified = true
+	return nil
+}
+
+// SharedKey 获取协商出的16字节会话密钥，仅在Verify成功后可用
+func (s *SPAKE2) SharedKey() ([]byte, error) {
+	if !s.verified {
+		return nil, errors.New("密钥确认尚未完成")
+	}
+	return append([]byte(nil), s.ke...), nil
+}
+
+// spake2Blind 获取角色对应的盲化点，A使用M，B使用N
+func spake2Blind(curve elliptic.Curve, role SPAKE2Role) (*big.Int, *big.Int, error) {
+	pointHex := spake2MHex
+	if role == SPAKE2RoleB {
+		pointHex = spake2NHex
+	}
+
+	raw, err := hex.DecodeString(pointHex)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "解析SPAKE2常量失败")
+	}
+	x, y := elliptic.UnmarshalCompressed(curve, raw)
+	if x == nil {
+		return nil, nil, errors.New("SPAKE2常量不是有效的曲线点")
+	}
+	return x, y, nil
+}
+
+// spake2LengthPrefixed 追加8字节小端长度前缀与数据
+func spake2LengthPrefixed(dst, data []byte) []byte {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(data)))
+	dst = append(dst, length[:]...)
+	return append(dst, data...)
+}
+
+// spake2MAC 计算HMAC-SHA256确认值
+func spake2MAC(key, transcript []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(transcript)
+	return mac.Sum(nil)
+}
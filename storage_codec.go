@@ -0,0 +1,114 @@
+package encrypt
+
+import (
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// 本地存储编解码
+//
+// StorageCodec 为嵌入式KV存储（bbolt、badger等）提供统一的值加密：值使用KeyRing主密钥加密，
+// 附加认证数据为 命名空间 || 键，密文无法被挪到其他键下使用。
+// 键默认保持明文；设置WithDeterministicKeys后键使用确定性加密（SIV结构：nonce = HMAC(键)），
+// 相同的键总是得到相同的密文，可以直接按键查找，但键的顺序与前缀关系不再保留，无法做范围或前缀扫描。
+// 各存储的适配器在 store/ 下的独立子模块中
+
+// storageKeyInfo 派生确定性键加密子密钥的上下文
+const storageKeyInfo = "sylphbyte/encrypt storage key v1"
+
+// StorageCodec 存储值与键的编解码器
+type StorageCodec struct {
+	ring   *KeyRing
+	encKey []byte
+	macKey []byte
+}
+
+// NewStorageCodec 创建编解码器，值使用密钥环的主密钥加密
+func NewStorageCodec(ring *KeyRing) *StorageCodec {
+	return &StorageCodec{ring: ring}
+}
+
+// WithDeterministicKeys 启用键的确定性加密，key为32字节专用密钥，不要与密钥环中的密钥复用
+func (c *StorageCodec) WithDeterministicKeys(key []byte) *StorageCodec {
+	if len(key) != 32 {
+		panic("确定性键加密密钥长度必须是32字节")
+	}
+	derived, err := hkdf.Key(sha256.New, key, nil, storageKeyInfo, 64)
+	if err != nil {
+		panic(err)
+	}
+	c.encKey, c.macKey = derived[:32], derived[32:]
+	return c
+}
+
+// EncryptsKeys 是否启用了键加密
+func (c *StorageCodec) EncryptsKeys() bool {
+	return c.encKey != nil
+}
+
+// EncodeValue 加密值，namespace通常为bucket名或键前缀
+func (c *StorageCodec) EncodeValue(namespace, key, value []byte) ([]byte, error) {
+	return c.ring.Encrypt(value, storageAAD(namespace, key))
+}
+
+// DecodeValue 解密值，namespace与key必须与写入时一致（均为明文键）
+func (c *StorageCodec) DecodeValue(namespace, key, data []byte) ([]byte, error) {
+	return c.ring.Decrypt(data, storageAAD(namespace, key))
+}
+
+// EncodeKey 确定性加密键，未启用键加密时原样返回
+func (c *StorageCodec) EncodeKey(namespace, key []byte) ([]byte, error) {
+	if c.encKey == nil {
+		return key, nil
+	}
+
+	gcm, err := newAESGCM(c.encKey)
+	if err != nil {
+		return nil, err
+	}
+	nonce := c.keyNonce(namespace, key, gcm.NonceSize())
+	return gcm.Seal(nonce, nonce, key, storageAAD(namespace, nil)), nil
+}
+
+// DecodeKey 解密EncodeKey的输出，未启用键加密时原样返回
+func (c *StorageCodec) DecodeKey(namespace, encoded []byte) ([]byte, error) {
+	if c.encKey == nil {
+		return encoded, nil
+	}
+
+	gcm, err := newAESGCM(c.encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(encoded) < gcm.NonceSize()+gcm.Overhead() {
+		return nil, errors.New("加密键长度不足")
+	}
+	nonce := encoded[:gcm.NonceSize()]
+	key, err := gcm.Open(nil, nonce, encoded[gcm.NonceSize():], storageAAD(namespace, nil))
+	if err != nil {
+		return nil, errors.New("加密键解密失败")
+	}
+	if !hmac.Equal(nonce, c.keyNonce(namespace, key, gcm.NonceSize())) {
+		return nil, errors.New("加密键校验失败")
+	}
+	return key, nil
+}
+
+// keyNonce 合成nonce：HMAC-SHA256(macKey, 命名空间 || 键) 截断
+func (c *StorageCodec) keyNonce(namespace, key []byte, size int) []byte {
+	mac := hmac.New(sha256.New, c.macKey)
+	mac.Write(storageAAD(namespace, key))
+	return mac.Sum(nil)[:size]
+}
+
+// storageAAD 命名空间长度(4) || 命名空间 || 键
+func storageAAD(namespace, key []byte) []byte {
+	aad := make([]byte, 4, 4+len(namespace)+len(key))
+	binary.BigEndian.PutUint32(aad, uint32(len(namespace)))
+	aad = append(aad, namespace...)
+	return append(aad, key...)
+}
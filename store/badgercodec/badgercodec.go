@@ -0,0 +1,124 @@
+// Package badgercodec badger的透明加密适配器
+//
+// badger没有bucket，适配器以明文命名空间作为键前缀：磁盘上的键为 命名空间 || 键（启用确定性键加密时为加密后的键），
+// 同一命名空间内可以用Iterate遍历。值通过encrypt.StorageCodec加密，附加认证数据包含命名空间与明文键。
+// 作为独立子模块发布，不使用badger时主模块无需引入badger
+package badgercodec
+
+import (
+	"bytes"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/sylphbyte/encrypt"
+)
+
+// Codec badger加密适配器
+type Codec struct {
+	codec     *encrypt.StorageCodec
+	namespace []byte
+}
+
+// New 创建适配器，namespace作为键前缀，不同用途的数据应使用不同命名空间
+func New(codec *encrypt.StorageCodec, namespace []byte) *Codec {
+	return &Codec{codec: codec, namespace: append([]byte(nil), namespace...)}
+}
+
+// Wrap 包装事务
+func (c *Codec) Wrap(txn *badger.Txn) *Txn {
+	return &Txn{txn: txn, codec: c}
+}
+
+// Txn 加密事务
+type Txn struct {
+	txn   *badger.Txn
+	codec *Codec
+}
+
+// Get 读取并解密值，键不存在时返回badger.ErrKeyNotFound
+func (t *Txn) Get(key []byte) ([]byte, error) {
+	stored, err := t.codec.storedKey(key)
+	if err != nil {
+		return nil, err
+	}
+	item, err := t.txn.Get(stored)
+	if err != nil {
+		return nil, err
+	}
+	data, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return t.codec.codec.DecodeValue(t.codec.namespace, key, data)
+}
+
+// Set 加密并写入值
+func (t *Txn) Set(key, value []byte) error {
+	return t.SetEntry(badger.NewEntry(key, value))
+}
+
+// SetEntry 加密并写入条目，保留TTL、元数据等设置
+func (t *Txn) SetEntry(entry *badger.Entry) error {
+	stored, err := t.codec.storedKey(entry.Key)
+	if err != nil {
+		return err
+	}
+	data, err := t.codec.codec.EncodeValue(t.codec.namespace, entry.Key, entry.Value)
+	if err != nil {
+		return err
+	}
+	encrypted := *entry
+	encrypted.Key = stored
+	encrypted.Value = data
+	return t.txn.SetEntry(&encrypted)
+}
+
+// Delete 删除键
+func (t *Txn) Delete(key []byte) error {
+	stored, err := t.codec.storedKey(key)
+	if err != nil {
+		return err
+	}
+	return t.txn.Delete(stored)
+}
+
+// Iterate 遍历命名空间内的所有键值并解密
+func (t *Txn) Iterate(fn func(key, value []byte) error) error {
+	opts := badger.DefaultIteratorOptions
+	opts.Prefix = t.codec.namespace
+	it := t.txn.NewIterator(opts)
+	defer it.Close()
+
+	for it.Rewind(); it.Valid(); it.Next() {
+		item := it.Item()
+		key, err := t.codec.codec.DecodeKey(t.codec.namespace, bytes.TrimPrefix(item.KeyCopy(nil), t.codec.namespace))
+		if err != nil {
+			return err
+		}
+		data, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		value, err := t.codec.codec.DecodeValue(t.codec.namespace, key, data)
+		if err != nil {
+			return err
+		}
+		if err := fn(key, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Raw 获取底层事务
+func (t *Txn) Raw() *badger.Txn {
+	return t.txn
+}
+
+// storedKey 磁盘上的键：命名空间 || 编码后的键
+func (c *Codec) storedKey(key []byte) ([]byte, error) {
+	encoded, err := c.codec.EncodeKey(c.namespace, key)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte(nil), c.namespace...), encoded...), nil
+}
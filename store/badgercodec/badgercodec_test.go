@@ -0,0 +1,208 @@
+package badgercodec
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/sylphbyte/encrypt"
+)
+
+var testNamespace = []byte("secrets/")
+
+// newTestCodec 创建使用随机主密钥的编解码器，deterministic为true时同时启用键加密
+func newTestCodec(t *testing.T, deterministic bool) *encrypt.StorageCodec {
+	t.Helper()
+	key, err := encrypt.GenerateRandomBytes(32)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	ring := encrypt.NewKeyRing()
+	if err := ring.Add(encrypt.KeyEntry{ID: "db", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	codec := encrypt.NewStorageCodec(ring)
+	if deterministic {
+		keyKey, err := encrypt.GenerateRandomBytes(32)
+		if err != nil {
+			t.Fatalf("生成键加密密钥失败: %v", err)
+		}
+		codec.WithDeterministicKeys(keyKey)
+	}
+	return codec
+}
+
+// openTestDB 打开内存中的badger实例
+func openTestDB(t *testing.T) *badger.DB {
+	t.Helper()
+	db, err := badger.Open(badger.DefaultOptions("").WithInMemory(true).WithLogger(nil))
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// set 在可写事务中写入键值
+func set(t *testing.T, db *badger.DB, c *Codec, key, value []byte) {
+	t.Helper()
+	if err := db.Update(func(txn *badger.Txn) error {
+		return c.Wrap(txn).Set(key, value)
+	}); err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+}
+
+// get 在只读事务中读取键值
+func get(db *badger.DB, c *Codec, key []byte) ([]byte, error) {
+	var value []byte
+	err := db.View(func(txn *badger.Txn) error {
+		var err error
+		value, err = c.Wrap(txn).Get(key)
+		return err
+	})
+	return value, err
+}
+
+// TestRoundTrip 测试读写、遍历与删除
+func TestRoundTrip(t *testing.T) {
+	for _, deterministic := range []bool{false, true} {
+		db := openTestDB(t)
+		c := New(newTestCodec(t, deterministic), testNamespace)
+
+		// 其他命名空间的数据不应出现在遍历结果中
+		set(t, db, New(newTestCodec(t, deterministic), []byte("other/")), []byte("alice"), []byte("other"))
+
+		data := map[string]string{"alice": "13800000000", "bob": "13900000000", "empty": ""}
+		for k, v := range data {
+			set(t, db, c, []byte(k), []byte(v))
+		}
+
+		for k, v := range data {
+			got, err := get(db, c, []byte(k))
+			if err != nil {
+				t.Fatalf("键加密=%v 读取%s失败: %v", deterministic, k, err)
+			}
+			if string(got) != v {
+				t.Errorf("键加密=%v 读取%s得到%q，期望%q", deterministic, k, got, v)
+			}
+		}
+
+		if _, err := get(db, c, []byte("missing")); err != badger.ErrKeyNotFound {
+			t.Errorf("键加密=%v 不存在的键应返回ErrKeyNotFound，得到%v", deterministic, err)
+		}
+
+		err := db.View(func(txn *badger.Txn) error {
+			// 磁盘上不应出现明文键或明文值
+			it := txn.NewIterator(badger.IteratorOptions{Prefix: testNamespace})
+			for it.Rewind(); it.Valid(); it.Next() {
+				k := bytes.TrimPrefix(it.Item().KeyCopy(nil), testNamespace)
+				v, err := it.Item().ValueCopy(nil)
+				if err != nil {
+					it.Close()
+					return err
+				}
+				if bytes.Contains(v, []byte("1380000")) || bytes.Contains(v, []byte("1390000")) {
+					t.Errorf("键加密=%v 存储的值包含明文", deterministic)
+				}
+				if _, plain := data[string(k)]; deterministic && plain {
+					t.Errorf("键加密=%v 存储的键为明文%q", deterministic, k)
+				}
+			}
+			it.Close()
+
+			seen := map[string]string{}
+			if err := c.Wrap(txn).Iterate(func(k, v []byte) error {
+				seen[string(k)] = string(v)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if len(seen) != len(data) {
+				t.Errorf("键加密=%v 遍历得到%d项，期望%d项", deterministic, len(seen), len(data))
+			}
+			for k, v := range data {
+				if seen[k] != v {
+					t.Errorf("键加密=%v 遍历%s得到%q，期望%q", deterministic, k, seen[k], v)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("键加密=%v 遍历失败: %v", deterministic, err)
+		}
+
+		if err := db.Update(func(txn *badger.Txn) error {
+			return c.Wrap(txn).Delete([]byte("alice"))
+		}); err != nil {
+			t.Fatalf("键加密=%v 删除失败: %v", deterministic, err)
+		}
+		if _, err := get(db, c, []byte("alice")); err != badger.ErrKeyNotFound {
+			t.Errorf("键加密=%v 删除后应返回ErrKeyNotFound，得到%v", deterministic, err)
+		}
+	}
+}
+
+// TestWrongKey 测试使用其他密钥环无法解密
+func TestWrongKey(t *testing.T) {
+	db := openTestDB(t)
+	set(t, db, New(newTestCodec(t, false), testNamespace), []byte("alice"), []byte("secret"))
+
+	if _, err := get(db, New(newTestCodec(t, false), testNamespace), []byte("alice")); err == nil {
+		t.Error("使用其他密钥环读取应当失败")
+	}
+
+	// 启用键加密时，其他键加密密钥无法遍历出原始键
+	db = openTestDB(t)
+	set(t, db, New(newTestCodec(t, true), testNamespace), []byte("alice"), []byte("secret"))
+	err := db.View(func(txn *badger.Txn) error {
+		return New(newTestCodec(t, true), testNamespace).Wrap(txn).Iterate(func(k, v []byte) error { return nil })
+	})
+	if err == nil {
+		t.Error("使用其他键加密密钥遍历应当失败")
+	}
+}
+
+// TestTampered 测试篡改或移动存储的值后解密失败
+func TestTampered(t *testing.T) {
+	db := openTestDB(t)
+	codec := newTestCodec(t, false)
+	c := New(codec, testNamespace)
+	set(t, db, c, []byte("alice"), []byte("secret"))
+
+	err := db.Update(func(txn *badger.Txn) error {
+		item, err := txn.Get(append(append([]byte(nil), testNamespace...), "alice"...))
+		if err != nil {
+			return err
+		}
+		stored, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+
+		// 值被复制到其他键和其他命名空间下，附加认证数据不匹配
+		if err := txn.Set(append(append([]byte(nil), testNamespace...), "bob"...), stored); err != nil {
+			return err
+		}
+		if err := txn.Set([]byte("other/alice"), stored); err != nil {
+			return err
+		}
+		// 修改最后一个字节
+		tampered := append([]byte(nil), stored...)
+		tampered[len(tampered)-1] ^= 0x01
+		return txn.Set(append(append([]byte(nil), testNamespace...), "alice"...), tampered)
+	})
+	if err != nil {
+		t.Fatalf("修改底层数据失败: %v", err)
+	}
+
+	if _, err := get(db, c, []byte("alice")); err == nil {
+		t.Error("读取被篡改的值应当失败")
+	}
+	if _, err := get(db, c, []byte("bob")); err == nil {
+		t.Error("读取被移动到其他键的值应当失败")
+	}
+	if _, err := get(db, New(codec, []byte("other/")), []byte("alice")); err == nil {
+		t.Error("读取被移动到其他命名空间的值应当失败")
+	}
+}
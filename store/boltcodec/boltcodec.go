@@ -0,0 +1,110 @@
+// Package boltcodec bbolt的透明加密适配器
+//
+// 通过encrypt.StorageCodec对bucket中的值加密，启用确定性键加密时键也一并加密。
+// bucket名保持明文；键加密后ForEach的遍历顺序不再是键的字典序，Cursor的Seek等范围操作不可用。
+// 作为独立子模块发布，不使用bbolt时主模块无需引入bbolt
+package boltcodec
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sylphbyte/encrypt"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Codec bbolt加密适配器
+type Codec struct {
+	codec *encrypt.StorageCodec
+}
+
+// New 创建适配器
+func New(codec *encrypt.StorageCodec) *Codec {
+	return &Codec{codec: codec}
+}
+
+// Bucket 获取加密bucket，不存在时返回nil
+func (c *Codec) Bucket(tx *bolt.Tx, name []byte) *Bucket {
+	b := tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	return c.Wrap(b, name)
+}
+
+// CreateBucketIfNotExists 创建或获取加密bucket，需要在可写事务中调用
+func (c *Codec) CreateBucketIfNotExists(tx *bolt.Tx, name []byte) (*Bucket, error) {
+	b, err := tx.CreateBucketIfNotExists(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "创建bucket失败")
+	}
+	return c.Wrap(b, name), nil
+}
+
+// Wrap 包装已有bucket，name作为值加密的附加认证数据，嵌套bucket应传入能唯一标识它的路径
+func (c *Codec) Wrap(b *bolt.Bucket, name []byte) *Bucket {
+	return &Bucket{bucket: b, name: append([]byte(nil), name...), codec: c.codec}
+}
+
+// Bucket 加密bucket
+type Bucket struct {
+	bucket *bolt.Bucket
+	name   []byte
+	codec  *encrypt.StorageCodec
+}
+
+// Get 读取并解密值，键不存在时返回nil, nil
+func (b *Bucket) Get(key []byte) ([]byte, error) {
+	stored, err := b.codec.EncodeKey(b.name, key)
+	if err != nil {
+		return nil, err
+	}
+	data := b.bucket.Get(stored)
+	if data == nil {
+		return nil, nil
+	}
+	return b.codec.DecodeValue(b.name, key, data)
+}
+
+// Put 加密并写入值
+func (b *Bucket) Put(key, value []byte) error {
+	stored, err := b.codec.EncodeKey(b.name, key)
+	if err != nil {
+		return err
+	}
+	data, err := b.codec.EncodeValue(b.name, key, value)
+	if err != nil {
+		return err
+	}
+	return b.bucket.Put(stored, data)
+}
+
+// Delete 删除键
+func (b *Bucket) Delete(key []byte) error {
+	stored, err := b.codec.EncodeKey(b.name, key)
+	if err != nil {
+		return err
+	}
+	return b.bucket.Delete(stored)
+}
+
+// ForEach 遍历并解密所有键值，跳过嵌套bucket
+func (b *Bucket) ForEach(fn func(key, value []byte) error) error {
+	return b.bucket.ForEach(func(stored, data []byte) error {
+		if data == nil {
+			return nil
+		}
+		key, err := b.codec.DecodeKey(b.name, stored)
+		if err != nil {
+			return err
+		}
+		value, err := b.codec.DecodeValue(b.name, key, data)
+		if err != nil {
+			return err
+		}
+		return fn(key, value)
+	})
+}
+
+// Raw 获取底层bucket
+func (b *Bucket) Raw() *bolt.Bucket {
+	return b.bucket
+}
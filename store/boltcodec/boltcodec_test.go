@@ -0,0 +1,216 @@
+package boltcodec
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+	bolt "go.etcd.io/bbolt"
+)
+
+var testBucket = []byte("secrets")
+
+// newTestCodec 创建使用随机主密钥的编解码器，deterministic为true时同时启用键加密
+func newTestCodec(t *testing.T, deterministic bool) *encrypt.StorageCodec {
+	t.Helper()
+	key, err := encrypt.GenerateRandomBytes(32)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	ring := encrypt.NewKeyRing()
+	if err := ring.Add(encrypt.KeyEntry{ID: "db", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	codec := encrypt.NewStorageCodec(ring)
+	if deterministic {
+		keyKey, err := encrypt.GenerateRandomBytes(32)
+		if err != nil {
+			t.Fatalf("生成键加密密钥失败: %v", err)
+		}
+		codec.WithDeterministicKeys(keyKey)
+	}
+	return codec
+}
+
+// openTestDB 在临时目录中打开bolt数据库
+func openTestDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	db, err := bolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, nil)
+	if err != nil {
+		t.Fatalf("打开数据库失败: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// put 在可写事务中写入键值
+func put(t *testing.T, db *bolt.DB, c *Codec, key, value []byte) {
+	t.Helper()
+	err := db.Update(func(tx *bolt.Tx) error {
+		b, err := c.CreateBucketIfNotExists(tx, testBucket)
+		if err != nil {
+			return err
+		}
+		return b.Put(key, value)
+	})
+	if err != nil {
+		t.Fatalf("写入失败: %v", err)
+	}
+}
+
+// get 在只读事务中读取键值
+func get(db *bolt.DB, c *Codec, key []byte) ([]byte, error) {
+	var value []byte
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+		value, err = c.Bucket(tx, testBucket).Get(key)
+		return err
+	})
+	return value, err
+}
+
+// TestRoundTrip 测试读写、遍历与删除
+func TestRoundTrip(t *testing.T) {
+	for _, deterministic := range []bool{false, true} {
+		db := openTestDB(t)
+		c := New(newTestCodec(t, deterministic))
+
+		data := map[string]string{"alice": "13800000000", "bob": "13900000000", "empty": ""}
+		for k, v := range data {
+			put(t, db, c, []byte(k), []byte(v))
+		}
+
+		for k, v := range data {
+			got, err := get(db, c, []byte(k))
+			if err != nil {
+				t.Fatalf("键加密=%v 读取%s失败: %v", deterministic, k, err)
+			}
+			if string(got) != v {
+				t.Errorf("键加密=%v 读取%s得到%q，期望%q", deterministic, k, got, v)
+			}
+		}
+
+		got, err := get(db, c, []byte("missing"))
+		if err != nil || got != nil {
+			t.Errorf("键加密=%v 不存在的键应返回nil, nil，得到%q, %v", deterministic, got, err)
+		}
+
+		err = db.View(func(tx *bolt.Tx) error {
+			// 磁盘上不应出现明文键或明文值
+			raw := c.Bucket(tx, testBucket).Raw()
+			if err := raw.ForEach(func(k, v []byte) error {
+				if bytes.Contains(v, []byte("1380000")) || bytes.Contains(v, []byte("1390000")) {
+					t.Errorf("键加密=%v 存储的值包含明文", deterministic)
+				}
+				if _, plain := data[string(k)]; deterministic && plain {
+					t.Errorf("键加密=%v 存储的键为明文%q", deterministic, k)
+				}
+				return nil
+			}); err != nil {
+				return err
+			}
+
+			seen := map[string]string{}
+			if err := c.Bucket(tx, testBucket).ForEach(func(k, v []byte) error {
+				seen[string(k)] = string(v)
+				return nil
+			}); err != nil {
+				return err
+			}
+			if len(seen) != len(data) {
+				t.Errorf("键加密=%v 遍历得到%d项，期望%d项", deterministic, len(seen), len(data))
+			}
+			for k, v := range data {
+				if seen[k] != v {
+					t.Errorf("键加密=%v 遍历%s得到%q，期望%q", deterministic, k, seen[k], v)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("键加密=%v 遍历失败: %v", deterministic, err)
+		}
+
+		if err := db.Update(func(tx *bolt.Tx) error {
+			return c.Bucket(tx, testBucket).Delete([]byte("alice"))
+		}); err != nil {
+			t.Fatalf("键加密=%v 删除失败: %v", deterministic, err)
+		}
+		if got, err := get(db, c, []byte("alice")); err != nil || got != nil {
+			t.Errorf("键加密=%v 删除后应读取不到，得到%q, %v", deterministic, got, err)
+		}
+	}
+}
+
+// TestWrongKey 测试使用其他密钥环无法解密
+func TestWrongKey(t *testing.T) {
+	db := openTestDB(t)
+	put(t, db, New(newTestCodec(t, false)), []byte("alice"), []byte("secret"))
+
+	if _, err := get(db, New(newTestCodec(t, false)), []byte("alice")); err == nil {
+		t.Error("使用其他密钥环读取应当失败")
+	}
+
+	// 启用键加密时，其他键加密密钥无法遍历出原始键
+	db = openTestDB(t)
+	put(t, db, New(newTestCodec(t, true)), []byte("alice"), []byte("secret"))
+	err := db.View(func(tx *bolt.Tx) error {
+		return New(newTestCodec(t, true)).Bucket(tx, testBucket).ForEach(func(k, v []byte) error { return nil })
+	})
+	if err == nil {
+		t.Error("使用其他键加密密钥遍历应当失败")
+	}
+}
+
+// TestTampered 测试篡改或移动存储的值后解密失败
+func TestTampered(t *testing.T) {
+	db := openTestDB(t)
+	c := New(newTestCodec(t, false))
+	put(t, db, c, []byte("alice"), []byte("secret"))
+
+	err := db.Update(func(tx *bolt.Tx) error {
+		raw := c.Bucket(tx, testBucket).Raw()
+		stored := append([]byte(nil), raw.Get([]byte("alice"))...)
+
+		// 值被复制到其他键下，附加认证数据不匹配
+		if err := raw.Put([]byte("bob"), stored); err != nil {
+			return err
+		}
+		// 修改最后一个字节
+		stored[len(stored)-1] ^= 0x01
+		return raw.Put([]byte("alice"), stored)
+	})
+	if err != nil {
+		t.Fatalf("修改底层数据失败: %v", err)
+	}
+
+	if _, err := get(db, c, []byte("alice")); err == nil {
+		t.Error("读取被篡改的值应当失败")
+	}
+	if _, err := get(db, c, []byte("bob")); err == nil {
+		t.Error("读取被移动到其他键的值应当失败")
+	}
+
+	// 值被复制到其他bucket
+	err = db.Update(func(tx *bolt.Tx) error {
+		other, err := c.CreateBucketIfNotExists(tx, []byte("other"))
+		if err != nil {
+			return err
+		}
+		if err := c.Bucket(tx, testBucket).Put([]byte("carol"), []byte("secret")); err != nil {
+			return err
+		}
+		return other.Raw().Put([]byte("carol"), c.Bucket(tx, testBucket).Raw().Get([]byte("carol")))
+	})
+	if err != nil {
+		t.Fatalf("复制数据失败: %v", err)
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		_, err := c.Bucket(tx, []byte("other")).Get([]byte("carol"))
+		return err
+	})
+	if err == nil {
+		t.Error("读取被移动到其他bucket的值应当失败")
+	}
+}
@@ -0,0 +1,339 @@
+// Package rediscodec go-redis的透明加密Hook
+//
+// Hook在SET类命令发出前加密值，在GET类命令返回后解密结果，调用方代码无需改动：
+//
+//	rdb.AddHook(rediscodec.NewHook(encrypt.NewStorageCodec(ring)))
+//
+// 值由encrypt.StorageCodec使用密钥环主密钥以AES-GCM加密，密文内带有密钥ID，轮换主密钥后旧值仍可读取；
+// 附加认证数据包含Redis键，缓存值无法被挪到其他键下使用。
+// 支持SET、SETNX、SETEX、PSETEX、GETSET、MSET、MSETNX、GET、GETDEL、GETEX、MGET，
+// 其他命令（含INCR、APPEND等直接操作值的命令）原样透传，不要对加密的键使用它们。
+// 作为独立子模块发布，不使用Redis时主模块无需引入go-redis
+package rediscodec
+
+import (
+	"context"
+	"encoding"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/sylphbyte/encrypt"
+)
+
+// defaultNamespace 默认命名空间，作为附加认证数据的一部分
+const defaultNamespace = "redis"
+
+// Hook 透明加密Hook
+type Hook struct {
+	codec     *encrypt.StorageCodec
+	namespace []byte
+}
+
+var _ redis.Hook = (*Hook)(nil)
+
+// NewHook 创建Hook
+func NewHook(codec *encrypt.StorageCodec) *Hook {
+	return &Hook{codec: codec, namespace: []byte(defaultNamespace)}
+}
+
+// WithNamespace 设置命名空间，多个应用共用同一密钥环写同一Redis时用于隔离
+func (h *Hook) WithNamespace(namespace string) *Hook {
+	h.namespace = []byte(namespace)
+	return h
+}
+
+// Encrypt 加密单个值，可用于不经过Hook的场景（如Lua脚本参数）
+func (h *Hook) Encrypt(key string, value []byte) ([]byte, error) {
+	return h.codec.EncodeValue(h.namespace, []byte(key), value)
+}
+
+// Decrypt 解密单个值
+func (h *Hook) Decrypt(key string, data []byte) ([]byte, error) {
+	return h.codec.DecodeValue(h.namespace, []byte(key), data)
+}
+
+// DialHook 不处理连接
+func (h *Hook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook 处理单条命令
+func (h *Hook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.encryptArgs(cmd); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		if err := next(ctx, cmd); err != nil {
+			return err
+		}
+		return h.decryptResult(cmd)
+	}
+}
+
+// ProcessPipelineHook 处理管道与事务中的命令
+func (h *Hook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		for _, cmd := range cmds {
+			if err := h.encryptArgs(cmd); err != nil {
+				cmd.SetErr(err)
+				return err
+			}
+		}
+		pipeErr := next(ctx, cmds)
+		for _, cmd := range cmds {
+			if cmd.Err() != nil {
+				continue
+			}
+			if err := h.decryptResult(cmd); err != nil && pipeErr == nil {
+				pipeErr = err
+			}
+		}
+		return pipeErr
+	}
+}
+
+// encryptArgs 加密写命令中的值参数
+func (h *Hook) encryptArgs(cmd redis.Cmder) error {
+	args := cmd.Args()
+	switch strings.ToLower(cmd.Name()) {
+	case "set", "setnx", "getset":
+		return h.encryptArg(args, 1, 2)
+	case "setex", "psetex":
+		return h.encryptArg(args, 1, 3)
+	case "mset", "msetnx":
+		for i := 1; i+1 < len(args); i += 2 {
+			if err := h.encryptArg(args, i, i+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encryptArg 加密args[valueIndex]，args[keyIndex]为对应的键
+func (h *Hook) encryptArg(args []interface{}, keyIndex, valueIndex int) error {
+	if valueIndex >= len(args) {
+		return nil
+	}
+	value, err := argBytes(args[valueIndex])
+	if err != nil {
+		return err
+	}
+	key, err := argBytes(args[keyIndex])
+	if err != nil {
+		return err
+	}
+	data, err := h.codec.EncodeValue(h.namespace, key, value)
+	if err != nil {
+		return err
+	}
+	args[valueIndex] = data
+	return nil
+}
+
+// decryptResult 解密读命令的结果
+func (h *Hook) decryptResult(cmd redis.Cmder) error {
+	args := cmd.Args()
+	switch strings.ToLower(cmd.Name()) {
+	case "get", "getdel", "getex", "getset":
+		c, ok := cmd.(*redis.StringCmd)
+		if !ok || c.Err() != nil || len(args) < 2 {
+			return nil
+		}
+		value, err := h.decryptArg(args[1], c.Val())
+		if err != nil {
+			c.SetVal("")
+			c.SetErr(err)
+			return err
+		}
+		c.SetVal(value)
+	case "mget":
+		c, ok := cmd.(*redis.SliceCmd)
+		if !ok || c.Err() != nil {
+			return nil
+		}
+		values := c.Val()
+		for i, v := range values {
+			s, ok := v.(string)
+			if !ok || i+1 >= len(args) {
+				continue
+			}
+			value, err := h.decryptArg(args[i+1], s)
+			if err != nil {
+				values[i] = nil
+				c.SetErr(err)
+				return err
+			}
+			values[i] = value
+		}
+	}
+	return nil
+}
+
+// decryptArg 按键解密字符串结果
+func (h *Hook) decryptArg(keyArg interface{}, data string) (string, error) {
+	key, err := argBytes(keyArg)
+	if err != nil {
+		return "", err
+	}
+	value, err := h.codec.DecodeValue(h.namespace, key, []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// argBytes 按go-redis写入参数时的转换规则（proto.Writer.WriteArg）转换为字节，
+// 保证经Hook加密的值与直接写入go-redis的值解密后字节一致；不支持的类型与go-redis一样返回错误
+func argBytes(arg interface{}) ([]byte, error) {
+	switch v := arg.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []byte(v), nil
+	case *string:
+		if v == nil {
+			return nil, nil
+		}
+		return []byte(*v), nil
+	case []byte:
+		return v, nil
+	case int:
+		return formatInt(int64(v)), nil
+	case *int:
+		if v == nil {
+			return formatInt(0), nil
+		}
+		return formatInt(int64(*v)), nil
+	case int8:
+		return formatInt(int64(v)), nil
+	case *int8:
+		if v == nil {
+			return formatInt(0), nil
+		}
+		return formatInt(int64(*v)), nil
+	case int16:
+		return formatInt(int64(v)), nil
+	case *int16:
+		if v == nil {
+			return formatInt(0), nil
+		}
+		return formatInt(int64(*v)), nil
+	case int32:
+		return formatInt(int64(v)), nil
+	case *int32:
+		if v == nil {
+			return formatInt(0), nil
+		}
+		return formatInt(int64(*v)), nil
+	case int64:
+		return formatInt(v), nil
+	case *int64:
+		if v == nil {
+			return formatInt(0), nil
+		}
+		return formatInt(*v), nil
+	case uint:
+		return formatUint(uint64(v)), nil
+	case *uint:
+		if v == nil {
+			return formatUint(0), nil
+		}
+		return formatUint(uint64(*v)), nil
+	case uint8:
+		return formatUint(uint64(v)), nil
+	case *uint8:
+		// go-redis对nil的*uint8写入空字符串
+		if v == nil {
+			return nil, nil
+		}
+		return formatUint(uint64(*v)), nil
+	case uint16:
+		return formatUint(uint64(v)), nil
+	case *uint16:
+		if v == nil {
+			return formatUint(0), nil
+		}
+		return formatUint(uint64(*v)), nil
+	case uint32:
+		return formatUint(uint64(v)), nil
+	case *uint32:
+		if v == nil {
+			return formatUint(0), nil
+		}
+		return formatUint(uint64(*v)), nil
+	case uint64:
+		return formatUint(v), nil
+	case *uint64:
+		if v == nil {
+			return formatUint(0), nil
+		}
+		return formatUint(*v), nil
+	case float32:
+		return formatFloat(float64(v)), nil
+	case *float32:
+		if v == nil {
+			return formatFloat(0), nil
+		}
+		return formatFloat(float64(*v)), nil
+	case float64:
+		return formatFloat(v), nil
+	case *float64:
+		if v == nil {
+			return formatFloat(0), nil
+		}
+		return formatFloat(*v), nil
+	case bool:
+		return formatBool(v), nil
+	case *bool:
+		return formatBool(v != nil && *v), nil
+	case time.Time:
+		return v.AppendFormat(nil, time.RFC3339Nano), nil
+	case *time.Time:
+		if v == nil {
+			v = &time.Time{}
+		}
+		return v.AppendFormat(nil, time.RFC3339Nano), nil
+	case time.Duration:
+		return formatInt(v.Nanoseconds()), nil
+	case *time.Duration:
+		if v == nil {
+			return formatInt(0), nil
+		}
+		return formatInt(v.Nanoseconds()), nil
+	case encoding.BinaryMarshaler:
+		return v.MarshalBinary()
+	case net.IP:
+		return v, nil
+	default:
+		return nil, errors.Errorf("无法编码%T类型的参数，需要实现encoding.BinaryMarshaler", v)
+	}
+}
+
+// formatInt 十进制整数
+func formatInt(n int64) []byte {
+	return strconv.AppendInt(nil, n, 10)
+}
+
+// formatUint 十进制无符号整数
+func formatUint(n uint64) []byte {
+	return strconv.AppendUint(nil, n, 10)
+}
+
+// formatFloat 与go-redis一致的浮点数格式（'f'、最短精度）
+func formatFloat(f float64) []byte {
+	return strconv.AppendFloat(nil, f, 'f', -1, 64)
+}
+
+// formatBool go-redis将布尔值写为1或0
+func formatBool(b bool) []byte {
+	if b {
+		return formatInt(1)
+	}
+	return formatInt(0)
+}
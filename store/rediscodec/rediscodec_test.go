@@ -0,0 +1,168 @@
+package rediscodec
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sylphbyte/encrypt"
+)
+
+// binaryValue 实现encoding.BinaryMarshaler的测试类型
+type binaryValue struct{}
+
+func (binaryValue) MarshalBinary() ([]byte, error) { return []byte("binary"), nil }
+
+// newTestHook 创建使用随机主密钥的Hook
+func newTestHook(t *testing.T) *Hook {
+	t.Helper()
+	key, err := encrypt.GenerateRandomBytes(32)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	ring := encrypt.NewKeyRing()
+	if err := ring.Add(encrypt.KeyEntry{ID: "cache", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	return NewHook(encrypt.NewStorageCodec(ring))
+}
+
+// TestArgBytes 测试参数转换与go-redis写入规则一致
+func TestArgBytes(t *testing.T) {
+	var nilString *string
+	var nilInt *int
+	var nilUint8 *uint8
+	var nilBool *bool
+	var nilTime *time.Time
+	s, i, b := "v", -42, true
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.UTC)
+
+	for _, c := range []struct {
+		name string
+		arg  interface{}
+		want string
+	}{
+		{"nil", nil, ""},
+		{"string", "hello", "hello"},
+		{"*string", &s, "v"},
+		{"nil *string", nilString, ""},
+		{"[]byte", []byte{0x00, 0xff}, "\x00\xff"},
+		{"int", 42, "42"},
+		{"*int", &i, "-42"},
+		{"nil *int", nilInt, "0"},
+		{"int8", int8(-8), "-8"},
+		{"int64", int64(-1 << 62), "-4611686018427387904"},
+		{"uint8", uint8(255), "255"},
+		{"nil *uint8", nilUint8, ""},
+		{"uint64", uint64(1 << 63), "9223372036854775808"},
+		{"float32", float32(1.5), "1.5"},
+		{"float64", 0.1, "0.1"},
+		{"float64大数", 1e21, "1000000000000000000000"},
+		{"bool true", true, "1"},
+		{"bool false", false, "0"},
+		{"*bool", &b, "1"},
+		{"nil *bool", nilBool, "0"},
+		{"time.Time", ts, "2024-05-06T07:08:09.123Z"},
+		{"nil *time.Time", nilTime, "0001-01-01T00:00:00Z"},
+		{"time.Duration", 1500 * time.Millisecond, "1500000000"},
+		{"BinaryMarshaler", binaryValue{}, "binary"},
+		{"net.IP", net.IPv4(10, 0, 0, 1).To4(), "\x0a\x00\x00\x01"},
+	} {
+		got, err := argBytes(c.arg)
+		if err != nil {
+			t.Fatalf("%s: 转换失败: %v", c.name, err)
+		}
+		if string(got) != c.want {
+			t.Fatalf("%s: 期望%q，实际%q", c.name, c.want, got)
+		}
+	}
+
+	if _, err := argBytes(struct{}{}); err == nil {
+		t.Fatal("不支持的类型应返回错误")
+	}
+}
+
+// TestHook 测试经Hook写入的值与直接写入go-redis的值字节一致，且Redis中保存的是密文
+func TestHook(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	hook := newTestHook(t)
+
+	plain := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer plain.Close()
+	encrypted := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer encrypted.Close()
+	encrypted.AddHook(hook)
+
+	ts := time.Date(2024, 5, 6, 7, 8, 9, 0, time.FixedZone("CST", 8*3600))
+	for i, value := range []interface{}{"secret", []byte{0x00, 0x01}, 42, 1.25, true, ts, 3 * time.Second, binaryValue{}} {
+		key := "k" + string(rune('a'+i))
+		if err := plain.Set(ctx, "plain:"+key, value, 0).Err(); err != nil {
+			t.Fatalf("直接写入失败: %v", err)
+		}
+		if err := encrypted.Set(ctx, key, value, 0).Err(); err != nil {
+			t.Fatalf("加密写入失败: %v", err)
+		}
+
+		want, err := plain.Get(ctx, "plain:"+key).Result()
+		if err != nil {
+			t.Fatalf("直接读取失败: %v", err)
+		}
+		got, err := encrypted.Get(ctx, key).Result()
+		if err != nil {
+			t.Fatalf("解密读取失败: %v", err)
+		}
+		if got != want {
+			t.Fatalf("%T: 解密结果%q与直接写入的%q不一致", value, got, want)
+		}
+
+		stored, _ := server.Get(key)
+		if len(want) >= 4 && bytes.Contains([]byte(stored), []byte(want)) {
+			t.Fatalf("%T: Redis中保存了明文", value)
+		}
+	}
+
+	// MSET/MGET与管道
+	if err := encrypted.MSet(ctx, "m1", "one", "m2", 2).Err(); err != nil {
+		t.Fatalf("MSET失败: %v", err)
+	}
+	values, err := encrypted.MGet(ctx, "m1", "m2", "missing").Result()
+	if err != nil || values[0] != "one" || values[1] != "2" || values[2] != nil {
+		t.Fatalf("MGET结果不正确: %v %v", values, err)
+	}
+	pipe := encrypted.Pipeline()
+	pipe.Set(ctx, "p1", "piped", 0)
+	get := pipe.Get(ctx, "p1")
+	if _, err := pipe.Exec(ctx); err != nil || get.Val() != "piped" {
+		t.Fatalf("管道读写失败: %v %q", err, get.Val())
+	}
+
+	// 密文被挪到其他键下或被篡改时解密失败
+	stored, _ := server.Get("m1")
+	server.Set("moved", stored)
+	if err := encrypted.Get(ctx, "moved").Err(); err == nil {
+		t.Fatal("挪到其他键下的密文应解密失败")
+	}
+	tampered := []byte(stored)
+	tampered[len(tampered)-1] ^= 0x01
+	server.Set("m1", string(tampered))
+	if err := encrypted.Get(ctx, "m1").Err(); err == nil {
+		t.Fatal("篡改的密文应解密失败")
+	}
+
+	// 其他密钥环无法解密
+	other := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	defer other.Close()
+	other.AddHook(newTestHook(t))
+	if err := other.Get(ctx, "m2").Err(); err == nil {
+		t.Fatal("其他密钥环不应能解密")
+	}
+
+	if err := encrypted.Set(ctx, "bad", struct{}{}, 0).Err(); err == nil {
+		t.Fatal("不支持的值类型应返回错误")
+	}
+}
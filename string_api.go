@@ -0,0 +1,78 @@
+package encrypt
+
+// 字符串版本的加解密方法，省去Web处理函数中大量的[]byte(...)/string(...)转换
+// 输出字符串使用加密器当前设置的编码；设置NoEncoding时结果为原始二进制，不适合直接放入文本协议
+
+// EncryptString 加密字符串，返回编码后的密文字符串
+func (s *SymmetricEncryptor) EncryptString(plaintext string) (string, error) {
+	return applyString(s.Encrypt, plaintext)
+}
+
+// DecryptString 解密编码后的密文字符串
+func (s *SymmetricEncryptor) DecryptString(ciphertext string) (string, error) {
+	return applyString(s.Decrypt, ciphertext)
+}
+
+// EncryptString 加密字符串，返回编码后的密文字符串
+func (s *SM4Encryptor) EncryptString(plaintext string) (string, error) {
+	return applyString(s.Encrypt, plaintext)
+}
+
+// DecryptString 解密编码后的密文字符串
+func (s *SM4Encryptor) DecryptString(ciphertext string) (string, error) {
+	return applyString(s.Decrypt, ciphertext)
+}
+
+// EncryptString 加密字符串，返回编码后的密文字符串
+func (r *RSAEncryptor) EncryptString(plaintext string) (string, error) {
+	return applyString(r.Encrypt, plaintext)
+}
+
+// DecryptString 解密编码后的密文字符串
+func (r *RSAEncryptor) DecryptString(ciphertext string) (string, error) {
+	return applyString(r.Decrypt, ciphertext)
+}
+
+// SignString 对字符串签名，返回编码后的签名字符串
+func (r *RSAEncryptor) SignString(data string) (string, error) {
+	return applyString(r.Sign, data)
+}
+
+// VerifyString 验证字符串的签名
+func (r *RSAEncryptor) VerifyString(data string, signature string) (bool, error) {
+	return r.Verify([]byte(data), []byte(signature))
+}
+
+// EncryptString 加密字符串，返回编码后的密文字符串
+func (s *SM2Encryptor) EncryptString(plaintext string) (string, error) {
+	return applyString(s.Encrypt, plaintext)
+}
+
+// DecryptString 解密编码后的密文字符串
+func (s *SM2Encryptor) DecryptString(ciphertext string) (string, error) {
+	return applyString(s.Decrypt, ciphertext)
+}
+
+// SignString 对字符串签名，返回编码后的签名字符串
+func (s *SM2Encryptor) SignString(data string) (string, error) {
+	return applyString(s.Sign, data)
+}
+
+// VerifyString 验证字符串的签名
+func (s *SM2Encryptor) VerifyString(data string, signature string) (bool, error) {
+	return s.Verify([]byte(data), []byte(signature))
+}
+
+// SumString 计算字符串的SM3哈希值
+func (s *SM3Hasher) SumString(data string) (string, error) {
+	return s.Sum([]byte(data))
+}
+
+// applyString 以字符串形式调用字节切片处理函数
+func applyString(fn func([]byte) ([]byte, error), input string) (string, error) {
+	output, err := fn([]byte(input))
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
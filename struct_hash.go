@@ -0,0 +1,106 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 结构体确定性哈希
+//
+// 用fmt.Sprintf("%v")拼缓存键或幂等令牌时，map遍历顺序、指针地址、浮点格式都会让同一对象得到不同结果。
+// HashStruct 先按json标签序列化，再按RFC 8785（见CanonicalizeJSON）规范化：键排序、无空白、
+// 数字按ECMAScript规则输出，最后用选定的哈希算法计算摘要，不同语言实现JCS后可以得到相同结果。
+//
+// JCS把数字当作双精度处理，绝对值超过2^53的整数会被舍入，两个不同的ID可能得到相同摘要，
+// 因此HashStruct遇到这样的整数直接报错，这类字段应加上`json:",string"`标签。
+// 设置Domain时摘要输入为 len(Domain)(4字节大端) || Domain || 规范JSON，用于区分缓存键与幂等令牌等不同用途；
+// 设置Key时改用HMAC，摘要无法通过枚举对象离线猜出
+
+// maxExactJSONInteger 双精度可以精确表示的最大整数
+const maxExactJSONInteger = 1 << 53
+
+// HashStructOptions 结构体哈希选项
+type HashStructOptions struct {
+	Algorithm HashAlgorithm // 哈希算法，零值使用SHA-256
+	Domain    string        // 用途标签，为空时直接对规范JSON计算摘要
+	Key       []byte        // HMAC密钥，为空时计算普通哈希
+}
+
+// HashStruct 对任意可JSON序列化的值计算确定性摘要
+func HashStruct(v interface{}, opts HashStructOptions) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, errors.Wrap(err, "序列化JSON失败")
+	}
+	// 规范化后整数已被舍入，必须在规范化之前检查
+	if err := checkExactJSONNumbers(data); err != nil {
+		return nil, err
+	}
+	canonical, err := CanonicalizeJSON(data)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm := opts.Algorithm
+	if algorithm == 0 {
+		algorithm = HashSHA256
+	}
+	var h hash.Hash
+	if len(opts.Key) > 0 {
+		h = hmac.New(hashFunc(algorithm), opts.Key)
+	} else {
+		h = hashFunc(algorithm)()
+	}
+
+	if opts.Domain != "" {
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(opts.Domain)))
+		h.Write(length[:])
+		h.Write([]byte(opts.Domain))
+	}
+	h.Write(canonical)
+	return h.Sum(nil), nil
+}
+
+// HashStructHex 计算确定性摘要并以十六进制返回，便于直接用作缓存键
+func HashStructHex(v interface{}, opts HashStructOptions) (string, error) {
+	digest, err := HashStruct(v, opts)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest), nil
+}
+
+// checkExactJSONNumbers 检查JSON中的整数都在双精度精确范围内
+func checkExactJSONNumbers(data []byte) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	limit := big.NewInt(maxExactJSONInteger)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "解析JSON失败")
+		}
+
+		number, ok := token.(json.Number)
+		if !ok || strings.ContainsAny(string(number), ".eE") {
+			continue
+		}
+		value, ok := new(big.Int).SetString(string(number), 10)
+		if ok && value.CmpAbs(limit) > 0 {
+			return errors.Errorf("整数%s超出双精度精确范围，请改用字符串字段（json:\",string\"）", number)
+		}
+	}
+}
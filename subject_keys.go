@@ -0,0 +1,224 @@
+package encrypt
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// 主体密钥与加密粉碎（GDPR被遗忘权）
+//
+// 每个数据主体（用户ID）使用一把独立的256位数据密钥，数据密钥由KeyProvider包装后保存在SubjectKeyStore中。
+// 用户申请删除时调用DeleteSubjectKey删除其数据密钥，散落在数据库、备份与日志中的密文随之永久无法解密，
+// 无需逐条查找删除。
+//   - 密文的附加认证数据包含主体ID，一个用户的密文无法用另一个用户的密钥解密
+//   - 管理器不在内存中缓存明文数据密钥，删除立即生效；存储实现也不应保留已删除记录的副本
+//   - SubjectKeyStore.Create必须是原子的“不存在才写入”，避免并发为同一主体生成两把密钥
+
+// ErrSubjectKeyNotFound 主体密钥不存在或已被删除
+var ErrSubjectKeyNotFound = errors.New("主体密钥不存在或已删除")
+
+// ErrSubjectKeyExists 主体密钥已存在
+var ErrSubjectKeyExists = errors.New("主体密钥已存在")
+
+// subjectKeyVersion 主体密文格式版本
+const subjectKeyVersion = 1
+
+// SubjectKeyRecord 主体密钥记录
+type SubjectKeyRecord struct {
+	Subject    string    // 主体ID
+	KeyID      string    // 包装数据密钥的主密钥ID
+	WrappedKey []byte    // 包装后的数据密钥
+	CreatedAt  time.Time // 创建时间
+}
+
+// SubjectKeyStore 主体密钥存储，通常由数据库实现
+type SubjectKeyStore interface {
+	// Get 读取记录，不存在时返回ErrSubjectKeyNotFound
+	Get(ctx context.Context, subject string) (SubjectKeyRecord, error)
+	// Create 原子写入新记录，已存在时返回ErrSubjectKeyExists
+	Create(ctx context.Context, record SubjectKeyRecord) error
+	// Delete 删除记录，不存在时返回ErrSubjectKeyNotFound
+	Delete(ctx context.Context, subject string) error
+	// List 列出所有记录
+	List(ctx context.Context) ([]SubjectKeyRecord, error)
+}
+
+// SubjectKeyInfo 主体密钥信息（不含密钥材料）
+type SubjectKeyInfo struct {
+	Subject   string
+	KeyID     string
+	CreatedAt time.Time
+}
+
+// SubjectKeyManager 主体密钥管理器
+type SubjectKeyManager struct {
+	provider KeyProvider
+	keyID    string
+	store    SubjectKeyStore
+	now      func() time.Time
+}
+
+// NewSubjectKeyManager 创建管理器，新的主体数据密钥由provider的keyID包装
+func NewSubjectKeyManager(provider KeyProvider, keyID string, store SubjectKeyStore) *SubjectKeyManager {
+	return &SubjectKeyManager{provider: provider, keyID: keyID, store: store, now: time.Now}
+}
+
+// WithClock 设置时钟（主要用于测试）
+func (m *SubjectKeyManager) WithClock(now func() time.Time) *SubjectKeyManager {
+	m.now = now
+	return m
+}
+
+// Encrypt 使用主体的数据密钥加密，主体首次使用时自动生成密钥
+func (m *SubjectKeyManager) Encrypt(ctx context.Context, subject string, plaintext, aad []byte) ([]byte, error) {
+	dataKey, err := m.subjectKey(ctx, subject, true)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(dataKey)
+
+	ciphertext, err := AESGCMEncrypt(dataKey, plaintext, storageAAD([]byte(subject), aad))
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{subjectKeyVersion}, ciphertext...), nil
+}
+
+// Decrypt 解密主体的密文，主体密钥已删除时返回ErrSubjectKeyNotFound
+func (m *SubjectKeyManager) Decrypt(ctx context.Context, subject string, ciphertext, aad []byte) ([]byte, error) {
+	if len(ciphertext) < 1 || ciphertext[0] != subjectKeyVersion {
+		return nil, errors.New("不支持的主体密文格式")
+	}
+	dataKey, err := m.subjectKey(ctx, subject, false)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(dataKey)
+	return AESGCMDecrypt(dataKey, ciphertext[1:], storageAAD([]byte(subject), aad))
+}
+
+// DeleteSubjectKey 删除主体密钥，该主体的所有密文从此无法解密，操作不可逆
+func (m *SubjectKeyManager) DeleteSubjectKey(ctx context.Context, subject string) error {
+	return m.store.Delete(ctx, subject)
+}
+
+// HasSubjectKey 主体是否有密钥
+func (m *SubjectKeyManager) HasSubjectKey(ctx context.Context, subject string) (bool, error) {
+	_, err := m.store.Get(ctx, subject)
+	if errors.Is(err, ErrSubjectKeyNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Subjects 列出所有拥有密钥的主体，按主体ID排序
+func (m *SubjectKeyManager) Subjects(ctx context.Context) ([]SubjectKeyInfo, error) {
+	records, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]SubjectKeyInfo, 0, len(records))
+	for _, record := range records {
+		infos = append(infos, SubjectKeyInfo{Subject: record.Subject, KeyID: record.KeyID, CreatedAt: record.CreatedAt})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Subject < infos[j].Subject })
+	return infos, nil
+}
+
+// subjectKey 读取并解包主体数据密钥，create为true时不存在则生成
+func (m *SubjectKeyManager) subjectKey(ctx context.Context, subject string, create bool) ([]byte, error) {
+	if subject == "" {
+		return nil, errors.New("主体ID不能为空")
+	}
+
+	record, err := m.store.Get(ctx, subject)
+	if errors.Is(err, ErrSubjectKeyNotFound) && create {
+		dataKey, wrapped, err := newDataKey(ctx, m.provider, m.keyID)
+		if err != nil {
+			return nil, err
+		}
+		err = m.store.Create(ctx, SubjectKeyRecord{Subject: subject, KeyID: m.keyID, WrappedKey: wrapped, CreatedAt: m.now()})
+		if err == nil {
+			return dataKey, nil
+		}
+		zeroBytes(dataKey)
+		if !errors.Is(err, ErrSubjectKeyExists) {
+			return nil, errors.Wrap(err, "保存主体密钥失败")
+		}
+		// 并发创建时以先写入的密钥为准
+		record, err = m.store.Get(ctx, subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dataKey, err := m.provider.UnwrapKey(ctx, record.KeyID, record.WrappedKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "解包主体密钥失败")
+	}
+	return dataKey, nil
+}
+
+// MemorySubjectKeyStore 内存主体密钥存储，用于测试与单机场景
+type MemorySubjectKeyStore struct {
+	mu      sync.RWMutex
+	records map[string]SubjectKeyRecord
+}
+
+var _ SubjectKeyStore = (*MemorySubjectKeyStore)(nil)
+
+// NewMemorySubjectKeyStore 创建内存存储
+func NewMemorySubjectKeyStore() *MemorySubjectKeyStore {
+	return &MemorySubjectKeyStore{records: make(map[string]SubjectKeyRecord)}
+}
+
+// Get 读取记录
+func (s *MemorySubjectKeyStore) Get(ctx context.Context, subject string) (SubjectKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[subject]
+	if !ok {
+		return SubjectKeyRecord{}, ErrSubjectKeyNotFound
+	}
+	return record, nil
+}
+
+// Create 写入新记录
+func (s *MemorySubjectKeyStore) Create(ctx context.Context, record SubjectKeyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[record.Subject]; ok {
+		return ErrSubjectKeyExists
+	}
+	record.WrappedKey = append([]byte(nil), record.WrappedKey...)
+	s.records[record.Subject] = record
+	return nil
+}
+
+// Delete 删除记录并清零包装后的密钥
+func (s *MemorySubjectKeyStore) Delete(ctx context.Context, subject string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.records[subject]
+	if !ok {
+		return ErrSubjectKeyNotFound
+	}
+	zeroBytes(record.WrappedKey)
+	delete(s.records, subject)
+	return nil
+}
+
+// List 列出所有记录
+func (s *MemorySubjectKeyStore) List(ctx context.Context) ([]SubjectKeyRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]SubjectKeyRecord, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records, nil
+}
@@ -0,0 +1,189 @@
+package encrypt
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// 套件标识
+//
+// 套件的规范字符串在信封头部描述、配置文件、算法协商与命令行中统一使用：
+//
+//	算法[-密钥位数]-模式[-填充]
+//
+//	AES-256-GCM、AES-128-CBC-PKCS7、SM4-GCM、SM4-CBC-PKCS7、3DES-192-CBC-PKCS7
+//
+// 只有AES与3DES带密钥位数（SM4、DES密钥长度固定）；只有ECB、CBC带填充（PKCS7、ZERO、NOPADDING）。
+// 解析时不区分大小写，SM4/DES允许带位数，ECB、CBC省略填充时按PKCS7处理
+
+// suiteAlgorithmNames 算法名称
+var suiteAlgorithmNames = map[Algorithm]string{
+	AlgorithmAES:  "AES",
+	AlgorithmSM4:  "SM4",
+	AlgorithmDES:  "DES",
+	Algorithm3DES: "3DES",
+}
+
+// suiteModeNames 模式名称
+var suiteModeNames = map[Mode]string{
+	ModeECB: "ECB",
+	ModeCBC: "CBC",
+	ModeCFB: "CFB",
+	ModeOFB: "OFB",
+	ModeCTR: "CTR",
+	ModeGCM: "GCM",
+}
+
+// suitePaddingNames 填充名称
+var suitePaddingNames = map[PaddingMode]string{
+	PaddingPKCS7: "PKCS7",
+	PaddingZero:  "ZERO",
+	PaddingNone:  "NOPADDING",
+}
+
+// String 套件的规范字符串，无效套件返回带参数的描述
+func (s Suite) String() string {
+	name, err := s.format()
+	if err != nil {
+		return "INVALID(" + strconv.Itoa(int(s.Algorithm)) + "," + strconv.Itoa(s.KeySize) + "," +
+			strconv.Itoa(int(s.Mode)) + "," + strconv.Itoa(int(s.Padding)) + ")"
+	}
+	return name
+}
+
+// MarshalText 实现encoding.TextMarshaler，配置文件中以规范字符串保存
+func (s Suite) MarshalText() ([]byte, error) {
+	name, err := s.format()
+	if err != nil {
+		return nil, err
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText 实现encoding.TextUnmarshaler
+func (s *Suite) UnmarshalText(text []byte) error {
+	suite, err := ParseSuite(string(text))
+	if err != nil {
+		return err
+	}
+	*s = suite
+	return nil
+}
+
+// format 生成规范字符串
+func (s Suite) format() (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	parts := []string{suiteAlgorithmNames[s.Algorithm]}
+	if s.Algorithm == AlgorithmAES || s.Algorithm == Algorithm3DES {
+		parts = append(parts, strconv.Itoa(s.KeySize*8))
+	}
+	parts = append(parts, suiteModeNames[s.Mode])
+	if s.Mode == ModeECB || s.Mode == ModeCBC {
+		parts = append(parts, suitePaddingNames[s.Padding])
+	}
+	return strings.Join(parts, "-"), nil
+}
+
+// ParseSuite 解析套件字符串
+func ParseSuite(name string) (Suite, error) {
+	parts := strings.Split(strings.ToUpper(strings.TrimSpace(name)), "-")
+	var s Suite
+
+	// 算法与默认密钥长度
+	found := false
+	for algorithm, algName := range suiteAlgorithmNames {
+		if parts[0] == algName {
+			s.Algorithm, found = algorithm, true
+		}
+	}
+	if !found {
+		return Suite{}, errors.Errorf("不支持的套件算法: %s", name)
+	}
+	parts = parts[1:]
+	switch s.Algorithm {
+	case AlgorithmSM4:
+		s.KeySize = 16
+	case AlgorithmDES:
+		s.KeySize = 8
+	}
+
+	// 可选的密钥位数，AES与3DES必须提供
+	if len(parts) > 0 {
+		if bits, err := strconv.Atoi(parts[0]); err == nil {
+			if bits <= 0 || bits%8 != 0 {
+				return Suite{}, errors.Errorf("套件密钥位数不正确: %s", name)
+			}
+			s.KeySize = bits / 8
+			parts = parts[1:]
+		}
+	}
+
+	// 模式
+	if len(parts) == 0 {
+		return Suite{}, errors.Errorf("套件缺少模式: %s", name)
+	}
+	found = false
+	for mode, modeName := range suiteModeNames {
+		if parts[0] == modeName {
+			s.Mode, found = mode, true
+		}
+	}
+	if !found {
+		return Suite{}, errors.Errorf("不支持的套件模式: %s", name)
+	}
+	parts = parts[1:]
+
+	// 填充
+	if s.Mode == ModeECB || s.Mode == ModeCBC {
+		s.Padding = PaddingPKCS7
+		if len(parts) > 0 {
+			found = false
+			for padding, paddingName := range suitePaddingNames {
+				if parts[0] == paddingName {
+					s.Padding, found = padding, true
+				}
+			}
+			if !found {
+				return Suite{}, errors.Errorf("不支持的套件填充: %s", name)
+			}
+			parts = parts[1:]
+		}
+	}
+	if len(parts) > 0 {
+		return Suite{}, errors.Errorf("套件格式不正确: %s", name)
+	}
+
+	if err := s.Validate(); err != nil {
+		return Suite{}, errors.Wrapf(err, "套件%s无效", name)
+	}
+	return s, nil
+}
+
+// ParseSuiteList 解析逗号分隔的套件列表，如握手报文或配置中的"AES-256-GCM, SM4-GCM"
+func ParseSuiteList(list string) ([]Suite, error) {
+	var suites []Suite
+	for _, name := range strings.Split(list, ",") {
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		suite, err := ParseSuite(name)
+		if err != nil {
+			return nil, err
+		}
+		suites = append(suites, suite)
+	}
+	return suites, nil
+}
+
+// FormatSuiteList 将套件列表格式化为逗号分隔的规范字符串
+func FormatSuiteList(suites []Suite) string {
+	names := make([]string, len(suites))
+	for i, s := range suites {
+		names[i] = s.String()
+	}
+	return strings.Join(names, ",")
+}
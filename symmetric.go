@@ -4,8 +4,6 @@ import (
 	"crypto/aes"
 	"crypto/cipher"
 	"crypto/des"
-	"crypto/rand"
-	"io"
 	
 	"github.com/pkg/errors"
 )
@@ -30,6 +28,7 @@ type SymmetricEncryptor struct {
 	padding      Padding
 	encoding     Encoding
 	iv           []byte
+	hardening    cbcHardening
 }
 
 // Encrypt 加密数据
@@ -59,7 +58,7 @@ func (s *SymmetricEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
 		if s.iv == nil {
 			// 生成随机IV
 			s.iv = make([]byte, blockSize)
-			if _, err := io.ReadFull(rand.Reader, s.iv); err != nil {
+			if err := readNonce(s.iv); err != nil {
 				return nil, errors.Wrap(err, "生成随机IV失败")
 			}
 		} else if len(s.iv) != blockSize {
@@ -78,6 +77,11 @@ func (s *SymmetricEncryptor) Encrypt(plaintext []byte) ([]byte, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "加密数据失败")
 	}
+	if _, ok := s.blockMode.(*CBCMode); ok && s.hardening.enabled {
+		if encrypted, err = s.hardening.seal(encrypted); err != nil {
+			return nil, err
+		}
+	}
 	
 	// 5. 编码数据
 	return s.encoding.Encode(encrypted)
@@ -109,6 +113,13 @@ func (s *SymmetricEncryptor) Decrypt(ciphertext []byte) ([]byte, error) {
 		return nil, errors.Wrap(err, "创建密码块失败")
 	}
 	
+	// CBC加固模式：统一错误并常量时间去填充
+	if _, ok := s.blockMode.(*CBCMode); ok && s.hardening.enabled {
+		return s.hardening.open(decoded, block.BlockSize(), s.padding, func(data []byte) ([]byte, error) {
+			return s.blockMode.Decrypt(block, data)
+		})
+	}
+	
 	// 3. 解密数据
 	decrypted, err := s.blockMode.Decrypt(block, decoded)
 	if err != nil {
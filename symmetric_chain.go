@@ -227,12 +227,6 @@ func (d *DESEncryptor) CTR() ISymmetric {
 	return d
 }
 
-// GCM 设置GCM模式
-func (d *DESEncryptor) GCM() ISymmetric {
-	d.blockMode = NewGCMMode()
-	return d
-}
-
 // NoPadding 设置无填充
 func (d *DESEncryptor) NoPadding() ISymmetric {
 	d.padding = DefaultNoPadding
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestRunBenchmarks 测试基准报告与回归阈值
+func TestRunBenchmarks(t *testing.T) {
+	suites, _ := encrypt.ParseSuiteList("AES-256-GCM,SM4-CBC-NOPADDING")
+	report, err := encrypt.RunBenchmarks(encrypt.BenchmarkConfig{
+		Suites:       suites,
+		PayloadSizes: []int{100, 4096},
+		Duration:     5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("运行基准失败: %v", err)
+	}
+	if len(report.Results) != 8 {
+		t.Fatalf("结果数量不正确: %d", len(report.Results))
+	}
+	for _, res := range report.Results {
+		if res.Iterations == 0 || res.MBPerSecond <= 0 {
+			t.Fatalf("测量结果无效: %+v", res)
+		}
+	}
+	// 无填充CBC的载荷按分组对齐
+	if res := report.Results[4]; res.Suite != "SM4-CBC-NOPADDING" || res.PayloadSize != 112 {
+		t.Fatalf("载荷未按分组对齐: %+v", res)
+	}
+
+	var table bytes.Buffer
+	if err := report.WriteText(&table); err != nil || !strings.Contains(table.String(), "AES-256-GCM") {
+		t.Fatalf("表格输出不正确: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bench.json")
+	if err := report.Thresholds(0.5).WriteFile(path); err != nil {
+		t.Fatalf("写入阈值文件失败: %v", err)
+	}
+	thresholds, err := encrypt.LoadBenchmarkThresholds(path)
+	if err != nil {
+		t.Fatalf("读取阈值文件失败: %v", err)
+	}
+	if err := report.Check(thresholds); err != nil {
+		t.Fatalf("基线结果应满足阈值: %v", err)
+	}
+
+	thresholds.Thresholds[0].MinMBPerSecond = report.Results[0].MBPerSecond * 10
+	regressions := report.Regressions(thresholds)
+	if len(regressions) != 1 || regressions[0].Result.Suite != "AES-256-GCM" {
+		t.Fatalf("回归检测不正确: %+v", regressions)
+	}
+	if report.Check(thresholds) == nil {
+		t.Fatal("存在回归时应返回错误")
+	}
+
+	if _, err := encrypt.RunBenchmarks(encrypt.BenchmarkConfig{Operations: []string{"sign"}}); err == nil {
+		t.Fatal("不支持的操作应返回错误")
+	}
+}
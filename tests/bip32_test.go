@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestMnemonicVectors 使用Trezor官方向量测试助记词与种子
+func TestMnemonicVectors(t *testing.T) {
+	entropy := make([]byte, 16)
+	mnemonic, err := encrypt.NewMnemonic(entropy, encrypt.MnemonicEnglish)
+	if err != nil {
+		t.Fatalf("生成助记词失败: %v", err)
+	}
+	if mnemonic != strings.Repeat("abandon ", 11)+"about" {
+		t.Fatalf("助记词不正确: %s", mnemonic)
+	}
+
+	seed := encrypt.MnemonicToSeed(mnemonic, "TREZOR")
+	expected := "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04"
+	if hex.EncodeToString(seed) != expected {
+		t.Fatalf("种子不正确: %x", seed)
+	}
+
+	restored, err := encrypt.MnemonicToEntropy(mnemonic, encrypt.MnemonicEnglish)
+	if err != nil || !bytes.Equal(restored, entropy) {
+		t.Fatalf("还原熵失败: %v", err)
+	}
+	if err := encrypt.ValidateMnemonic(strings.Repeat("abandon ", 12), encrypt.MnemonicEnglish); err == nil {
+		t.Fatalf("校验和错误的助记词不应通过")
+	}
+}
+
+// TestGenerateMnemonic 测试随机助记词生成与中文词表
+func TestGenerateMnemonic(t *testing.T) {
+	for _, lang := range []encrypt.MnemonicLanguage{encrypt.MnemonicEnglish, encrypt.MnemonicChineseSimplified} {
+		mnemonic, err := encrypt.GenerateMnemonic(256, lang)
+		if err != nil {
+			t.Fatalf("生成助记词失败: %v", err)
+		}
+		if len(strings.Fields(mnemonic)) != 24 {
+			t.Fatalf("256位熵应生成24个词: %s", mnemonic)
+		}
+		if err := encrypt.ValidateMnemonic(mnemonic, lang); err != nil {
+			t.Fatalf("校验助记词失败: %v", err)
+		}
+	}
+}
+
+// TestHDKeyVectors 使用BIP32测试向量1验证派生与序列化
+func TestHDKeyVectors(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := encrypt.NewHDMasterKey(seed)
+	if err != nil {
+		t.Fatalf("生成主密钥失败: %v", err)
+	}
+	if master.String() != "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi" {
+		t.Fatalf("主扩展私钥不正确: %s", master)
+	}
+	if master.PublicString() != "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8" {
+		t.Fatalf("主扩展公钥不正确: %s", master.PublicString())
+	}
+
+	child, err := master.DerivePath("m/0'/1/2'/2/1000000000")
+	if err != nil {
+		t.Fatalf("派生失败: %v", err)
+	}
+	if child.String() != "xprvA41z7zogVVwxVSgdKUHDy1SKmdb533PjDz7J6N6mV6uS3ze1ai8FHa8kmHScGpWmj4WggLyQjgPie1rFSruoUihUZREPSL39UNdE3BBDu76" {
+		t.Fatalf("子扩展私钥不正确: %s", child)
+	}
+	if child.Depth() != 5 {
+		t.Fatalf("派生层级不正确: %d", child.Depth())
+	}
+
+	parsed, err := encrypt.ParseHDKey(child.String())
+	if err != nil || parsed.String() != child.String() {
+		t.Fatalf("解析扩展私钥失败: %v", err)
+	}
+	if _, err := master.DerivePath("0/1"); err == nil {
+		t.Fatalf("不以m开头的路径应失败")
+	}
+}
+
+// TestHDKeyUsage 测试由派生节点得到签名密钥与对称密钥
+func TestHDKeyUsage(t *testing.T) {
+	seed := encrypt.MnemonicToSeed(strings.Repeat("abandon ", 11)+"about", "")
+	master, err := encrypt.NewHDMasterKey(seed)
+	if err != nil {
+		t.Fatalf("生成主密钥失败: %v", err)
+	}
+
+	node, err := master.DerivePath("m/44'/60'/0'/0/0")
+	if err != nil {
+		t.Fatalf("派生失败: %v", err)
+	}
+	signer, err := node.EthereumSigner()
+	if err != nil {
+		t.Fatalf("创建签名器失败: %v", err)
+	}
+	// 与MetaMask等钱包由该助记词导出的第一个地址一致
+	if signer.Address() != "0x9858EfFD232B4033E47d90003D41EC34EcaEda94" {
+		t.Fatalf("以太坊地址不正确: %s", signer.Address())
+	}
+
+	key1, err := node.SymmetricKey("database", 32)
+	if err != nil {
+		t.Fatalf("派生对称密钥失败: %v", err)
+	}
+	key2, _ := node.SymmetricKey("cookie", 32)
+	again, _ := node.SymmetricKey("database", 32)
+	if len(key1) != 32 || bytes.Equal(key1, key2) || !bytes.Equal(key1, again) {
+		t.Fatalf("对称密钥派生结果不正确")
+	}
+}
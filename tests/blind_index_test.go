@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestBlindIndex 测试盲索引
+func TestBlindIndex(t *testing.T) {
+	key := []byte("0123456789ABCDEF0123456789ABCDEF")
+
+	phone := encrypt.NewBlindIndex(key, []byte("users.phone"))
+	email := encrypt.NewBlindIndex(key, []byte("users.email"))
+
+	a, err := phone.IndexString("13800138000")
+	if err != nil {
+		t.Fatalf("计算索引失败: %v", err)
+	}
+	b, _ := phone.IndexString("13800138000")
+	if a != b {
+		t.Fatalf("相同值的索引应该一致")
+	}
+	if len(a) != encrypt.BlindIndexDefaultBits/4 {
+		t.Fatalf("索引长度不正确: %s", a)
+	}
+
+	c, _ := email.IndexString("13800138000")
+	if a == c {
+		t.Fatalf("不同字段的相同值不应产生相同索引")
+	}
+	d, _ := phone.IndexString("13800138001")
+	if a == d {
+		t.Fatalf("不同值不应产生相同索引")
+	}
+
+	// 截断位数
+	short, err := encrypt.NewBlindIndex(key, []byte("users.phone")).SM3().Bits(20).NoEncoding().Index([]byte("13800138000"))
+	if err != nil {
+		t.Fatalf("计算索引失败: %v", err)
+	}
+	if len(short) != 3 || short[2]&0x0f != 0 {
+		t.Fatalf("截断结果不正确: %x", short)
+	}
+
+	if _, err := encrypt.NewBlindIndex(key, nil).Bits(8).Index([]byte("x")); err == nil {
+		t.Fatalf("截断位数过小时应该失败")
+	}
+	if _, err := encrypt.BlindIndex([]byte("short"), []byte("x")); err == nil {
+		t.Fatalf("密钥过短时应该失败")
+	}
+
+	if n := encrypt.NewBlindIndex(key, nil).Bits(16).ExpectedCollisions(1000); n < 7 || n > 8 {
+		t.Fatalf("碰撞估算不正确: %f", n)
+	}
+}
@@ -0,0 +1,64 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestBlobNamer 测试加密对象命名
+func TestBlobNamer(t *testing.T) {
+	namingKey := bytes.Repeat([]byte{9}, 32)
+	dataKey := bytes.Repeat([]byte{4}, 32)
+	plaintext := []byte("contract.pdf contents")
+
+	namer, err := encrypt.NewBlobNamer(namingKey, "files-2024")
+	if err != nil {
+		t.Fatalf("创建命名器失败: %v", err)
+	}
+	namer.WithFanout(2).WithSuffix(".enc")
+
+	name, ciphertext, err := encrypt.EncryptBlob(namer, encrypt.AlgorithmAES, dataKey, plaintext, 0)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	parts := strings.Split(name, "/")
+	if len(parts) != 3 || !strings.HasPrefix(parts[2], parts[0]+parts[1]) || len(parts[2]) != 64+len(".enc") {
+		t.Fatalf("名称格式不正确: %s", name)
+	}
+	if name != namer.Name(ciphertext) {
+		t.Fatal("写入时计算的名称应与按密文计算的一致")
+	}
+	if decrypted, err := encrypt.ChunkedDecrypt(dataKey, ciphertext); err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	// 相同明文每次得到不同的名称
+	other, _, _ := encrypt.EncryptBlob(namer, encrypt.AlgorithmAES, dataKey, plaintext, 0)
+	if other == name {
+		t.Fatal("相同明文的名称不应相同")
+	}
+
+	if err := namer.Verify(name, ciphertext); err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-1] ^= 1
+	if err := namer.Verify(name, tampered); !errors.Is(err, encrypt.ErrBlobNameMismatch) {
+		t.Fatalf("替换的对象应被发现: %v", err)
+	}
+
+	// 名称绑定密钥ID
+	rotated, _ := encrypt.NewBlobNamer(namingKey, "files-2025")
+	rotated.WithFanout(2).WithSuffix(".enc")
+	if rotated.Name(ciphertext) == name || rotated.Verify(name, ciphertext) == nil {
+		t.Fatal("不同密钥ID的名称不应相同")
+	}
+
+	if _, err := encrypt.NewBlobNamer(namingKey[:16], "k"); err == nil {
+		t.Fatal("过短的命名密钥应被拒绝")
+	}
+}
@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestBox 测试公钥Box与SecretBox
+func TestBox(t *testing.T) {
+	pub, priv, err := encrypt.GenerateBoxKeyPair()
+	if err != nil {
+		t.Fatalf("生成密钥对失败: %v", err)
+	}
+
+	sealed, err := encrypt.BoxSeal([]byte("to this public key"), pub)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	plaintext, err := encrypt.BoxOpen(sealed, priv)
+	if err != nil || string(plaintext) != "to this public key" {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	_, otherPriv, _ := encrypt.GenerateBoxKeyPair()
+	if _, err := encrypt.BoxOpen(sealed, otherPriv); err == nil {
+		t.Fatalf("其他私钥不应解密成功")
+	}
+	sealed[5] ^= 1
+	if _, err := encrypt.BoxOpen(sealed, priv); err == nil {
+		t.Fatalf("篡改临时公钥后不应解密成功")
+	}
+
+	key, _ := encrypt.GenerateRandomKey(encrypt.BoxKeySize)
+	sealed, err = encrypt.SecretSeal([]byte("secret"), key)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if plaintext, err := encrypt.SecretOpen(sealed, key); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("解密失败: %v", err)
+	}
+	sealed[0] = 2
+	if _, err := encrypt.SecretOpen(sealed, key); err == nil {
+		t.Fatalf("未知版本不应解密成功")
+	}
+}
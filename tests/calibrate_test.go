@@ -0,0 +1,67 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCalibrate 测试按目标耗时校准工作因子
+func TestCalibrate(t *testing.T) {
+	target := 20 * time.Millisecond
+	opts := encrypt.CalibrationOptions{Memory: 1024, Samples: 1}
+
+	for _, algorithm := range []encrypt.KDFAlgorithm{encrypt.KDFPBKDF2, encrypt.KDFArgon2id, encrypt.KDFScrypt} {
+		params, err := encrypt.Calibrate(algorithm, target, opts)
+		if err != nil {
+			t.Fatalf("算法%d校准失败: %v", algorithm, err)
+		}
+		// 调度抖动下允许较大的误差，只检查数量级
+		if params.Duration <= 0 || params.Duration > 4*target {
+			t.Fatalf("算法%d校准耗时偏离目标: %+v", algorithm, params)
+		}
+
+		salt := bytes.Repeat([]byte{1}, 16)
+		first, err := params.Derive([]byte("pw"), salt, 32)
+		if err != nil {
+			t.Fatalf("算法%d派生失败: %v", algorithm, err)
+		}
+		second, _ := params.Derive([]byte("pw"), salt, 32)
+		if !bytes.Equal(first, second) {
+			t.Fatalf("算法%d派生结果不确定", algorithm)
+		}
+	}
+
+	params, _ := encrypt.Calibrate(encrypt.KDFPBKDF2, time.Nanosecond, opts)
+	if params.Iterations != 10000 {
+		t.Fatalf("目标过小时应返回下限，实际为%d", params.Iterations)
+	}
+	hasher, err := params.PasswordHasher()
+	if err != nil {
+		t.Fatalf("创建密码哈希器失败: %v", err)
+	}
+	encoded, _ := hasher.Hash([]byte("pw"))
+	if info, _ := encrypt.ParsePasswordHash(encoded); info.Iterations != 10000 {
+		t.Fatalf("密码哈希未使用校准参数: %s", encoded)
+	}
+}
+
+// TestRecalibrator 测试定期校准只采用更强的参数
+func TestRecalibrator(t *testing.T) {
+	strong := &encrypt.KDFParams{Algorithm: encrypt.KDFArgon2id, Time: 1000, Memory: 1024, Threads: 1}
+
+	var changes int
+	r := encrypt.NewRecalibrator(encrypt.KDFArgon2id, 5*time.Millisecond, encrypt.CalibrationOptions{Memory: 1024, Samples: 1}).
+		WithInitial(strong).
+		OnChange(func(*encrypt.KDFParams) { changes++ })
+	if current, err := r.Recalibrate(); err != nil || current != strong || changes != 0 {
+		t.Fatalf("较弱的参数不应被采用: %+v %v", current, err)
+	}
+
+	r.AllowDecrease()
+	if current, err := r.Recalibrate(); err != nil || current.Time >= strong.Time || changes != 1 {
+		t.Fatalf("允许降低后应采用新参数: %+v %v", current, err)
+	}
+}
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCBCHardening 测试CBC加固模式的统一错误
+func TestCBCHardening(t *testing.T) {
+	key := bytes.Repeat([]byte{0x11}, 16)
+	macKey := bytes.Repeat([]byte{0x22}, 32)
+
+	aes, err := encrypt.AES(key, encrypt.WithCBCHardening(macKey), encrypt.WithEncoding(encrypt.EncodingNone))
+	if err != nil {
+		t.Fatalf("创建加密器失败: %v", err)
+	}
+	ciphertext, err := aes.Encrypt([]byte("legacy cbc payload"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	plaintext, err := aes.Decrypt(ciphertext)
+	if err != nil || string(plaintext) != "legacy cbc payload" {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	// 篡改密文、篡改标签、截断都返回同一个错误
+	for name, mutate := range map[string]func([]byte){
+		"密文": func(b []byte) { b[len(b)-40] ^= 1 },
+		"标签": func(b []byte) { b[len(b)-1] ^= 1 },
+	} {
+		tampered := append([]byte(nil), ciphertext...)
+		mutate(tampered)
+		if _, err := aes.Decrypt(tampered); !errors.Is(err, encrypt.ErrDecryptionFailed) {
+			t.Fatalf("篡改%s应返回统一错误: %v", name, err)
+		}
+	}
+	if _, err := aes.Decrypt(ciphertext[:20]); !errors.Is(err, encrypt.ErrDecryptionFailed) {
+		t.Fatalf("截断密文应返回统一错误: %v", err)
+	}
+
+	// 未启用Encrypt-then-MAC时填充错误同样返回统一错误
+	sm4, _ := encrypt.SM4(key, encrypt.WithIV(make([]byte, 16)), encrypt.WithCBCHardening(nil))
+	ciphertext, _ = sm4.NoEncoding().Encrypt([]byte("sm4 payload"))
+	ciphertext[len(ciphertext)-1] ^= 0x40
+	if _, err := sm4.Decrypt(ciphertext); !errors.Is(err, encrypt.ErrDecryptionFailed) {
+		t.Fatalf("填充错误应返回统一错误: %v", err)
+	}
+
+	weak, _ := encrypt.AES(key, encrypt.WithCBCHardening([]byte("short")))
+	if _, err := weak.Encrypt([]byte("x")); err == nil {
+		t.Fatal("过短的MAC密钥应当失败")
+	}
+}
+
+// TestCBCHardeningTiming 比较MAC错误与填充错误的解密耗时
+func TestCBCHardeningTiming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("跳过耗时测试")
+	}
+	key := bytes.Repeat([]byte{0x33}, 16)
+	macKey := bytes.Repeat([]byte{0x44}, 32)
+	iv := make([]byte, 16)
+
+	// MAC正确但填充错误：无填充加密最后一个字节非法的明文
+	raw, _ := encrypt.AES(key, encrypt.WithIV(iv), encrypt.WithPadding(encrypt.PaddingNone),
+		encrypt.WithEncoding(encrypt.EncodingNone), encrypt.WithCBCHardening(macKey))
+	badPadding, _ := raw.Encrypt(bytes.Repeat([]byte{0xee}, 4096))
+
+	// 填充正确但MAC错误
+	aes, _ := encrypt.AES(key, encrypt.WithIV(iv), encrypt.WithEncoding(encrypt.EncodingNone), encrypt.WithCBCHardening(macKey))
+	badMAC, _ := aes.Encrypt(bytes.Repeat([]byte{0xee}, 4095))
+	badMAC[len(badMAC)-1] ^= 1
+	if len(badMAC) != len(badPadding) {
+		t.Fatalf("两种密文长度应当一致: %d %d", len(badMAC), len(badPadding))
+	}
+
+	measure := func(ciphertext []byte) time.Duration {
+		start := time.Now()
+		for i := 0; i < 20; i++ {
+			if _, err := aes.Decrypt(ciphertext); !errors.Is(err, encrypt.ErrDecryptionFailed) {
+				t.Fatalf("应返回统一错误: %v", err)
+			}
+		}
+		return time.Since(start)
+	}
+
+	// 交替测量取中位数，减少调度噪声
+	const rounds = 200
+	padTimes := make([]time.Duration, rounds)
+	macTimes := make([]time.Duration, rounds)
+	for i := 0; i < rounds; i++ {
+		padTimes[i] = measure(badPadding)
+		macTimes[i] = measure(badMAC)
+	}
+	median := func(d []time.Duration) time.Duration {
+		sort.Slice(d, func(i, j int) bool { return d[i] < d[j] })
+		return d[len(d)/2]
+	}
+	padMedian, macMedian := median(padTimes), median(macTimes)
+	ratio := float64(padMedian) / float64(macMedian)
+	t.Logf("填充错误: %v, MAC错误: %v, 比值: %.3f", padMedian, macMedian, ratio)
+	if ratio < 0.67 || ratio > 1.5 {
+		t.Fatalf("两种失败的耗时差异过大: %.3f", ratio)
+	}
+}
@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestAESCBCHMAC 测试JOSE组合AEAD（RFC 7518 附录B.1测试向量）
+func TestAESCBCHMAC(t *testing.T) {
+	key, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	iv, _ := hex.DecodeString("1af38c2dc2b96ffdd86694092341bc04")
+	plaintext := []byte("A cipher system must not be required to be secret, and it must be able to fall into the hands of the enemy without inconvenience")
+	aad := []byte("The second principle of Auguste Kerckhoffs")
+
+	aead, err := encrypt.NewJOSEContentCipher(encrypt.JOSEA128CBCHS256, key)
+	if err != nil {
+		t.Fatalf("创建AEAD失败: %v", err)
+	}
+	sealed := aead.Seal(nil, iv, plaintext, aad)
+	if tag := hex.EncodeToString(sealed[len(sealed)-16:]); tag != "652c3fa36b0a7c5b3219fab3a30bc1c4" {
+		t.Fatalf("认证标签与RFC 7518不一致: %s", tag)
+	}
+	if !bytes.HasPrefix(sealed, []byte{0xc8, 0x0e, 0xdf, 0xa3}) {
+		t.Fatalf("密文与RFC 7518不一致: %x", sealed[:4])
+	}
+	opened, err := aead.Open(nil, iv, sealed, aad)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	sealed[0] ^= 1
+	if _, err := aead.Open(nil, iv, sealed, aad); err == nil {
+		t.Fatal("篡改密文后应解密失败")
+	}
+	sealed[0] ^= 1
+	if _, err := aead.Open(nil, iv, sealed, []byte("other")); err == nil {
+		t.Fatal("AAD不一致时应解密失败")
+	}
+
+	for _, size := range []int{32, 48, 64} {
+		k := bytes.Repeat([]byte{byte(size)}, size)
+		ciphertext, err := encrypt.AESCBCHMACEncrypt(k, []byte("legacy payload"), nil)
+		if err != nil {
+			t.Fatalf("%d字节密钥加密失败: %v", size, err)
+		}
+		if len(ciphertext) != 16+16+size/2 {
+			t.Fatalf("%d字节密钥的密文长度不正确: %d", size, len(ciphertext))
+		}
+		if decrypted, err := encrypt.AESCBCHMACDecrypt(k, ciphertext, nil); err != nil || string(decrypted) != "legacy payload" {
+			t.Fatalf("%d字节密钥解密失败: %v", size, err)
+		}
+	}
+	if _, err := encrypt.NewAESCBCHMAC(make([]byte, 16)); err == nil {
+		t.Fatal("密钥长度不正确时应返回错误")
+	}
+
+	// 自定义组合：独立的加密密钥与MAC密钥，完整HMAC-SHA256标签
+	block, _ := aes.NewCipher(key[:16])
+	custom, err := encrypt.NewCBCHMAC(block, key[16:], sha256.New, 32)
+	if err != nil {
+		t.Fatalf("创建自定义组合失败: %v", err)
+	}
+	sealed = custom.Seal(nil, iv, plaintext, nil)
+	if opened, err := custom.Open(nil, iv, sealed, nil); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("自定义组合解密失败: %v", err)
+	}
+	if _, err := encrypt.NewCBCHMAC(block, key, sha256.New, 8); err == nil {
+		t.Fatal("标签过短时应返回错误")
+	}
+
+	gcm, err := encrypt.NewJOSEContentCipher(encrypt.JOSEA256GCM, key)
+	if err != nil || gcm.NonceSize() != 12 {
+		t.Fatalf("A256GCM创建失败: %v", err)
+	}
+}
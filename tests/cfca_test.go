@@ -0,0 +1,99 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSM2DoubleCert 测试CFCA双证书与加密私钥下发
+func TestSM2DoubleCert(t *testing.T) {
+	signCert, signKey := newSM2CertPEM(t, 11)
+	encCert, encKey := newSM2CertPEM(t, 12)
+
+	// 密钥管理中心用签名证书公钥封装加密私钥
+	envelopedKey, err := encrypt.SealSM2EnvelopedKey(encKey, signCert)
+	if err != nil {
+		t.Fatalf("封装加密私钥失败: %v", err)
+	}
+	opened, err := encrypt.OpenSM2EnvelopedKey(envelopedKey, signKey)
+	if err != nil {
+		t.Fatalf("解开加密私钥失败: %v", err)
+	}
+	if _, err := encrypt.OpenSM2EnvelopedKey(envelopedKey, encKey); err == nil {
+		t.Fatal("使用错误的签名私钥应当失败")
+	}
+
+	double, err := encrypt.LoadSM2DoubleCert(signCert, signKey, encCert, envelopedKey)
+	if err != nil {
+		t.Fatalf("加载双证书失败: %v", err)
+	}
+	if !bytes.Equal(double.Encrypt.PrivateKeyPEM, opened) {
+		t.Fatal("加密私钥不一致")
+	}
+	if _, err := encrypt.LoadSM2DoubleCert(signCert, signKey, signCert, envelopedKey); err == nil {
+		t.Fatal("加密证书与加密私钥不匹配应当失败")
+	}
+
+	// 加密私钥可以解开发给加密证书的数字信封
+	envelope, _ := encrypt.SealSM2Envelope([]byte("密文报文"), encCert)
+	if plaintext, err := encrypt.OpenSM2Envelope(envelope, double.Encrypt.CertPEM, double.Encrypt.PrivateKeyPEM); err != nil || string(plaintext) != "密文报文" {
+		t.Fatalf("使用加密私钥解开数字信封失败: %v", err)
+	}
+
+	signer, err := double.SignEncryptor()
+	if err != nil {
+		t.Fatalf("获取签名加密器失败: %v", err)
+	}
+	signature, _ := signer.Sign([]byte("data"))
+	verifier := encrypt.MustNewSM2().(*encrypt.SM2Encryptor).WithCFCAFile(mustCFCAFile(t, signCert, signKey), "123456")
+	if ok, err := verifier.Verify([]byte("data"), signature); err != nil || !ok {
+		t.Fatalf("签名验证失败: %v", err)
+	}
+
+	decryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor).WithEnvelopedKey(envelopedKey, signKey)
+	encryptor, _ := double.EncryptEncryptor()
+	ciphertext, _ := encryptor.Encrypt([]byte("hello"))
+	if plaintext, err := decryptor.Decrypt(ciphertext); err != nil || string(plaintext) != "hello" {
+		t.Fatalf("加密私钥解密失败: %v", err)
+	}
+
+	if _, err := double.GMTLSServerConfig(); err != nil {
+		t.Fatalf("创建GMTLS配置失败: %v", err)
+	}
+}
+
+// TestCFCASM2File 测试CFCA .sm2文件
+func TestCFCASM2File(t *testing.T) {
+	cert, key := newSM2CertPEM(t, 21)
+	file := mustCFCAFile(t, cert, key)
+
+	pair, err := encrypt.ParseCFCASM2File(file, "123456")
+	if err != nil {
+		t.Fatalf("解析.sm2文件失败: %v", err)
+	}
+	if pair.Certificate.SerialNumber.Int64() != 21 {
+		t.Fatal("证书不正确")
+	}
+	if _, err := encrypt.MarshalCFCASM2File(cert, pair.PrivateKeyPEM, "x"); err != nil {
+		t.Fatalf("解析出的私钥应与证书匹配: %v", err)
+	}
+	if _, err := encrypt.ParseCFCASM2File(file, "wrong"); err == nil {
+		t.Fatal("口令错误应当失败")
+	}
+	_, otherKey := newSM2CertPEM(t, 22)
+	if _, err := encrypt.MarshalCFCASM2File(cert, otherKey, "123456"); err == nil {
+		t.Fatal("私钥与证书不匹配应当失败")
+	}
+}
+
+// mustCFCAFile 生成.sm2文件
+func mustCFCAFile(t *testing.T, certPEM, keyPEM []byte) []byte {
+	t.Helper()
+	file, err := encrypt.MarshalCFCASM2File(certPEM, keyPEM, "123456")
+	if err != nil {
+		t.Fatalf("导出.sm2文件失败: %v", err)
+	}
+	return file
+}
@@ -0,0 +1,135 @@
+package tests
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestChunkedRoundTrip 测试不同长度（含空数据与整块倍数）的分块加解密
+func TestChunkedRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 32)
+	for _, size := range []int{0, 1, 99, 100, 101, 1000} {
+		plaintext := bytes.Repeat([]byte("0123456789"), size/10+1)[:size]
+		ciphertext, err := encrypt.ChunkedEncrypt(encrypt.AlgorithmAES, key, plaintext, 100)
+		if err != nil {
+			t.Fatalf("加密失败: %v", err)
+		}
+		decrypted, err := encrypt.ChunkedDecrypt(key, ciphertext)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("长度%d解密失败: %v", size, err)
+		}
+	}
+}
+
+// TestChunkedRandomAccess 测试随机区间解密与截断检测
+func TestChunkedRandomAccess(t *testing.T) {
+	key := bytes.Repeat([]byte{6}, 16)
+	plaintext := make([]byte, 10000)
+	for i := range plaintext {
+		plaintext[i] = byte(i * 31)
+	}
+	ciphertext, err := encrypt.ChunkedEncrypt(encrypt.AlgorithmSM4, key, plaintext, 256)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	r, err := encrypt.OpenChunkedReader(bytes.NewReader(ciphertext), int64(len(ciphertext)), key)
+	if err != nil {
+		t.Fatalf("打开失败: %v", err)
+	}
+	if r.Size() != int64(len(plaintext)) {
+		t.Fatalf("明文长度不正确: %d", r.Size())
+	}
+	buf := make([]byte, 700)
+	if _, err := r.ReadAt(buf, 5000); err != nil || !bytes.Equal(buf, plaintext[5000:5700]) {
+		t.Fatalf("随机读取失败: %v", err)
+	}
+	if n, err := r.ReadAt(buf, 9800); err != io.EOF || n != 200 {
+		t.Fatalf("读取到末尾应返回io.EOF: %d %v", n, err)
+	}
+
+	// 在块边界截断
+	truncated := ciphertext[:26+(256+16)*10]
+	tr, err := encrypt.OpenChunkedReader(bytes.NewReader(truncated), int64(len(truncated)), key)
+	if err != nil {
+		t.Fatalf("打开截断密文失败: %v", err)
+	}
+	if _, err := tr.ReadAt(make([]byte, 10), tr.Size()-10); err == nil {
+		t.Fatalf("截断的密文不应解密成功")
+	}
+}
+
+// TestChunkedServeContent 测试配合http.ServeContent响应Range请求
+func TestChunkedServeContent(t *testing.T) {
+	key := bytes.Repeat([]byte{8}, 32)
+	plaintext := bytes.Repeat([]byte("video-frame-"), 2000)
+	ciphertext, _ := encrypt.ChunkedEncrypt(encrypt.AlgorithmAES, key, plaintext, 1024)
+
+	r, err := encrypt.OpenChunkedReader(bytes.NewReader(ciphertext), int64(len(ciphertext)), key)
+	if err != nil {
+		t.Fatalf("打开失败: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/video.mp4", nil)
+	req.Header.Set("Range", "bytes=10000-10099")
+	rec := httptest.NewRecorder()
+	http.ServeContent(rec, req, "video.mp4", time.Time{}, r)
+
+	if rec.Code != http.StatusPartialContent {
+		t.Fatalf("应返回206: %d", rec.Code)
+	}
+	if !bytes.Equal(rec.Body.Bytes(), plaintext[10000:10100]) {
+		t.Fatalf("Range响应内容不正确")
+	}
+}
+
+// onlyReadSeeker 只实现io.ReadSeeker，用于测试非ReaderAt输入
+type onlyReadSeeker struct {
+	io.ReadSeeker
+}
+
+// TestChunkedVerifyThenDecrypt 测试先验证全部分块再输出明文
+func TestChunkedVerifyThenDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{8}, 32)
+	plaintext := bytes.Repeat([]byte("ledger line\n"), 500)
+	ciphertext, err := encrypt.ChunkedEncrypt(encrypt.AlgorithmAES, key, plaintext, 512)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	var out bytes.Buffer
+	n, err := encrypt.ChunkedDecryptVerified(&out, onlyReadSeeker{bytes.NewReader(ciphertext)}, key)
+	if err != nil || n != int64(len(plaintext)) || !bytes.Equal(out.Bytes(), plaintext) {
+		t.Fatalf("两遍解密失败: %d %v", n, err)
+	}
+
+	// 篡改最后一块：不输出任何明文
+	tampered := append([]byte(nil), ciphertext...)
+	tampered[len(tampered)-20] ^= 1
+	out.Reset()
+	if _, err := encrypt.ChunkedDecryptVerified(&out, bytes.NewReader(tampered), key); err == nil || out.Len() != 0 {
+		t.Fatalf("篡改后不应输出明文: %d字节 %v", out.Len(), err)
+	}
+	if _, err := encrypt.OpenChunkedReaderVerified(bytes.NewReader(tampered), int64(len(tampered)), key); err == nil {
+		t.Fatal("篡改后打开应失败")
+	}
+
+	// 普通读取器可以读取未被篡改的前部，Verify能发现后部的篡改
+	reader, err := encrypt.OpenChunkedReader(bytes.NewReader(tampered), int64(len(tampered)), key)
+	if err != nil {
+		t.Fatalf("打开失败: %v", err)
+	}
+	head := make([]byte, 100)
+	if _, err := reader.ReadAt(head, 0); err != nil || !bytes.Equal(head, plaintext[:100]) {
+		t.Fatalf("读取前部失败: %v", err)
+	}
+	if reader.Verify() == nil {
+		t.Fatal("Verify应发现篡改")
+	}
+}
@@ -0,0 +1,71 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCipherCacheLRU 测试命中、LRU淘汰与TTL过期
+func TestCipherCacheLRU(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache, err := encrypt.NewCipherCache(2, time.Minute)
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+	cache.WithClock(func() time.Time { return now })
+
+	key1 := bytes.Repeat([]byte{1}, 32)
+	key2 := bytes.Repeat([]byte{2}, 32)
+	key3 := bytes.Repeat([]byte{3}, 16)
+
+	a, _ := cache.AEAD(encrypt.AlgorithmAES, key1)
+	b, _ := cache.AEAD(encrypt.AlgorithmAES, key1)
+	if a != b {
+		t.Fatalf("相同密钥应命中缓存")
+	}
+	cache.AEAD(encrypt.AlgorithmAES, key2)
+	cache.AEAD(encrypt.AlgorithmAES, key1) // key1变为最近使用
+	cache.AEAD(encrypt.AlgorithmSM4, key3) // 淘汰key2
+
+	stats := cache.Stats()
+	if stats.Size != 2 || stats.Hits != 2 || stats.Misses != 3 || stats.Evictions != 1 {
+		t.Fatalf("缓存统计不正确: %+v", stats)
+	}
+
+	now = now.Add(2 * time.Minute)
+	cache.Cleanup()
+	if cache.Stats().Size != 0 {
+		t.Fatalf("过期条目应被清除")
+	}
+}
+
+// TestCipherCacheSeal 测试缓存加密与无状态GCM函数互通
+func TestCipherCacheSeal(t *testing.T) {
+	cache, _ := encrypt.NewCipherCache(16, 0)
+	key := bytes.Repeat([]byte{7}, 16)
+
+	ciphertext, err := cache.Seal(encrypt.AlgorithmSM4, key, []byte("tenant data"), []byte("tenant-1"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	plaintext, err := encrypt.SM4GCMDecrypt(key, ciphertext, []byte("tenant-1"))
+	if err != nil || string(plaintext) != "tenant data" {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	block, err := cache.Block(encrypt.Algorithm3DES, bytes.Repeat([]byte{9}, 24))
+	if err != nil || block.BlockSize() != 8 {
+		t.Fatalf("获取3DES实例失败: %v", err)
+	}
+	if _, err := cache.AEAD(encrypt.AlgorithmDES, key[:8]); err == nil {
+		t.Fatalf("DES不支持AEAD")
+	}
+
+	cache.Remove(encrypt.AlgorithmSM4, key)
+	if _, err := cache.Open(encrypt.AlgorithmSM4, key, ciphertext, []byte("tenant-1")); err != nil {
+		t.Fatalf("移除后重新创建实例失败: %v", err)
+	}
+}
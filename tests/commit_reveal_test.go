@@ -0,0 +1,122 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCommitReveal 测试承诺、公开两个阶段的时间窗口与结果复核
+func TestCommitReveal(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	round := encrypt.NewCommitReveal("table-7:hand-42", time.Minute, 30*time.Second).
+		WithClock(func() time.Time { return now }).
+		WithVRF(priv)
+
+	values := map[string][]byte{"alice": []byte("seed-a"), "bob": []byte("seed-b"), "carol": []byte("seed-c")}
+	randomness := make(map[string][]byte)
+	for player, value := range values {
+		commitment, r, _ := round.Committer().CommitRandom(value)
+		randomness[player] = r
+		if err := round.Commit(player, commitment); err != nil {
+			t.Fatalf("%s提交承诺失败: %v", player, err)
+		}
+	}
+	if err := round.Commit("alice", []byte("again")); err == nil {
+		t.Fatal("重复承诺应被拒绝")
+	}
+
+	// 承诺阶段不接受公开，也不能得到结果
+	if err := round.Reveal("alice", values["alice"], randomness["alice"]); !errors.Is(err, encrypt.ErrCommitRevealPhase) {
+		t.Fatalf("承诺阶段公开应被拒绝: %v", err)
+	}
+	if _, err := round.Result(); !errors.Is(err, encrypt.ErrCommitRevealPhase) {
+		t.Fatalf("承诺阶段不应得到结果: %v", err)
+	}
+
+	// 进入公开阶段后不再接受承诺
+	now = now.Add(time.Minute)
+	if round.Phase() != encrypt.PhaseReveal {
+		t.Fatalf("阶段不正确: %s", round.Phase())
+	}
+	if err := round.Commit("dave", []byte("late")); !errors.Is(err, encrypt.ErrCommitRevealPhase) {
+		t.Fatalf("超时承诺应被拒绝: %v", err)
+	}
+	if err := round.Reveal("alice", []byte("seed-x"), randomness["alice"]); !errors.Is(err, encrypt.ErrCommitRevealMismatch) {
+		t.Fatalf("与承诺不符的公开应被拒绝: %v", err)
+	}
+	for _, player := range []string{"alice", "bob"} {
+		if err := round.Reveal(player, values[player], randomness[player]); err != nil {
+			t.Fatalf("%s公开失败: %v", player, err)
+		}
+	}
+	if _, err := round.Result(); !errors.Is(err, encrypt.ErrCommitRevealPhase) {
+		t.Fatalf("仍有参与方未公开时不应得到结果: %v", err)
+	}
+
+	// 公开阶段结束，未公开的carol被列出
+	now = now.Add(30 * time.Second)
+	if err := round.Reveal("carol", values["carol"], randomness["carol"]); !errors.Is(err, encrypt.ErrCommitRevealPhase) {
+		t.Fatalf("超时公开应被拒绝: %v", err)
+	}
+	result, err := round.Result()
+	if err != nil {
+		t.Fatalf("获取结果失败: %v", err)
+	}
+	if len(result.Forfeited) != 1 || result.Forfeited[0] != "carol" || len(result.Openings) != 2 {
+		t.Fatalf("结果不正确: %+v", result)
+	}
+	if len(result.Randomness) != 32 || len(result.VRFProof) != encrypt.VRFProofSize {
+		t.Fatalf("随机数或VRF证明长度不正确")
+	}
+
+	// 任何人可以复核结果，篡改后复核失败
+	if err := encrypt.VerifyCommitRevealResult(result, pub); err != nil {
+		t.Fatalf("复核失败: %v", err)
+	}
+	if err := encrypt.VerifyCommitRevealResult(result, nil); err == nil {
+		t.Fatal("缺少VRF公钥时应复核失败")
+	}
+	tampered := *result
+	tampered.Randomness = bytes.Clone(result.Randomness)
+	tampered.Randomness[0] ^= 0x01
+	if err := encrypt.VerifyCommitRevealResult(&tampered, pub); err == nil {
+		t.Fatal("篡改的随机数应复核失败")
+	}
+}
+
+// TestCommitRevealAllRevealed 全部参与方公开后无需等待公开阶段结束
+func TestCommitRevealAllRevealed(t *testing.T) {
+	now := time.Now()
+	round := encrypt.NewCommitReveal("dice:1001", time.Second, time.Hour).
+		WithClock(func() time.Time { return now })
+
+	commitment, r, _ := round.Committer().CommitRandom([]byte("player-seed"))
+	_ = round.Commit("player", commitment)
+	now = now.Add(time.Second)
+	if err := round.Reveal("player", []byte("player-seed"), r); err != nil {
+		t.Fatalf("公开失败: %v", err)
+	}
+
+	result, err := round.Result()
+	if err != nil {
+		t.Fatalf("全部公开后应得到结果: %v", err)
+	}
+	if err := encrypt.VerifyCommitRevealResult(result, nil); err != nil {
+		t.Fatalf("复核失败: %v", err)
+	}
+
+	// 其他轮次的承诺不能在本轮使用
+	other := encrypt.NewCommitReveal("dice:1002", time.Second, time.Hour).WithClock(func() time.Time { return now })
+	_ = other.Commit("player", commitment)
+	now = now.Add(time.Second)
+	if err := other.Reveal("player", []byte("player-seed"), r); !errors.Is(err, encrypt.ErrCommitRevealMismatch) {
+		t.Fatalf("跨轮次的承诺应被拒绝: %v", err)
+	}
+}
@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCommitment 测试哈希承诺的生成与验证
+func TestCommitment(t *testing.T) {
+	randomness, _ := encrypt.GenerateRandomBytes(32)
+	commitment, err := encrypt.Commit([]byte("bid:1000"), randomness)
+	if err != nil {
+		t.Fatalf("承诺失败: %v", err)
+	}
+	if !encrypt.VerifyCommitment(commitment, []byte("bid:1000"), randomness) {
+		t.Fatal("正确的值应验证通过")
+	}
+	if encrypt.VerifyCommitment(commitment, []byte("bid:1001"), randomness) {
+		t.Fatal("错误的值不应验证通过")
+	}
+	if _, err := encrypt.Commit([]byte("bid"), randomness[:16]); err == nil {
+		t.Fatal("过短的随机数应被拒绝")
+	}
+
+	// 域分离：其他场次的承诺不能使用
+	round1 := encrypt.NewCommitter("auction:001")
+	round2 := encrypt.NewCommitter("auction:002")
+	c1, r1, err := round1.CommitRandom([]byte("bid:500"))
+	if err != nil {
+		t.Fatalf("承诺失败: %v", err)
+	}
+	if !round1.Verify(c1, []byte("bid:500"), r1) || round2.Verify(c1, []byte("bid:500"), r1) {
+		t.Fatal("承诺应只在本域内有效")
+	}
+
+	sm3 := encrypt.NewCommitter("auction:001").SM3()
+	c2, _ := sm3.Commit([]byte("bid:500"), r1)
+	if len(c2) != 32 || string(c2) == string(c1) || !sm3.Verify(c2, []byte("bid:500"), r1) {
+		t.Fatal("SM3承诺不正确")
+	}
+}
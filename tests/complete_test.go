@@ -185,7 +185,7 @@ func TestRSAFeatures(t *testing.T) {
 	if err != nil {
 		t.Fatalf("创建RSA失败: %v", err)
 	}
-	rsaEncryptor = rsaEncryptor.WithKeySize(2048).Base64()
+	rsaEncryptor = rsaEncryptor.(encrypt.IKeySizer).WithKeySize(2048).Base64()
 	
 	// 2. 生成密钥对
 	pubKey, privKey, err := rsaEncryptor.GenerateKeyPair()
@@ -363,7 +363,7 @@ func TestSM2Features(t *testing.T) {
 	
 	// 7. 测试自定义UID
 	customUID := []byte("custom-uid-for-sm2-test")
-	sm2WithUID := sm2Encryptor.WithPublicKey(pubKey).WithPrivateKey(privKey).(encrypt.IAsymmetric).WithUID(customUID)
+	sm2WithUID := sm2Encryptor.WithPublicKey(pubKey).WithPrivateKey(privKey).(encrypt.IUIDSetter).WithUID(customUID)
 	
 	// 使用自定义UID签名
 	signatureWithUID, err := sm2WithUID.Sign(plaintext)
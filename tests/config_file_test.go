@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// newConfigRing 创建配置加密使用的密钥环
+func newConfigRing(t *testing.T) *encrypt.KeyRing {
+	t.Helper()
+	key, err := encrypt.GenerateRandomBytes(32)
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	ring := encrypt.NewKeyRing()
+	if err := ring.Add(encrypt.KeyEntry{ID: "config", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	return ring
+}
+
+// TestConfigYAMLRoundTrip 测试YAML值加密后键可读、解密后类型与顺序不变
+func TestConfigYAMLRoundTrip(t *testing.T) {
+	ring := newConfigRing(t)
+	ctx := context.Background()
+	plain := []byte("name: demo\ndatabase:\n  host: db.local\n  port: 5432\n  password: \"123456\"\n  tls: true\nhosts:\n  - a\n  - b\n")
+
+	sealed, err := encrypt.NewConfigEncrypter(ring, "config").Encrypt(ctx, plain, encrypt.ConfigFormatYAML)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	text := string(sealed)
+	if !strings.Contains(text, "password: ENC[v1,str,") || !strings.Contains(text, "port: ENC[v1,int,") {
+		t.Fatalf("键应保持可读、值应被加密:\n%s", text)
+	}
+	if strings.Contains(text, "db.local") {
+		t.Fatal("明文值泄露")
+	}
+
+	opened, err := encrypt.DecryptConfigData(ctx, ring, sealed, encrypt.ConfigFormatYAML)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(opened, plain) {
+		t.Fatalf("解密结果不一致:\n%s", opened)
+	}
+
+	if _, err := encrypt.NewConfigEncrypter(ring, "config").Encrypt(ctx, sealed, encrypt.ConfigFormatYAML); err == nil {
+		t.Fatal("重复加密应失败")
+	}
+}
+
+// TestConfigJSONKeyPattern 测试JSON按键名匹配加密
+func TestConfigJSONKeyPattern(t *testing.T) {
+	ring := newConfigRing(t)
+	ctx := context.Background()
+	plain := []byte("{\n  \"name\": \"demo\",\n  \"port\": 8080,\n  \"api_token\": \"t<o>k\",\n  \"secrets\": {\n    \"a\": 1.5,\n    \"b\": null\n  }\n}\n")
+
+	sealed, err := encrypt.NewConfigEncrypter(ring, "config").WithKeyPattern(`token|^secrets$`).Encrypt(ctx, plain, encrypt.ConfigFormatJSON)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	text := string(sealed)
+	if !strings.Contains(text, `"name": "demo"`) || !strings.Contains(text, `"port": 8080`) {
+		t.Fatalf("未匹配的值应保持明文:\n%s", text)
+	}
+	if !strings.Contains(text, `"api_token": "ENC[v1,str,`) || !strings.Contains(text, `"a": "ENC[v1,float,`) || !strings.Contains(text, `"b": null`) {
+		t.Fatalf("匹配的值应被加密:\n%s", text)
+	}
+
+	opened, err := encrypt.DecryptConfigData(ctx, ring, sealed, encrypt.ConfigFormatJSON)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(opened, plain) {
+		t.Fatalf("解密结果不一致:\n%s", opened)
+	}
+}
+
+// TestConfigTamper 测试挪动或删除加密值被发现
+func TestConfigTamper(t *testing.T) {
+	ring := newConfigRing(t)
+	ctx := context.Background()
+	sealed, err := encrypt.NewConfigEncrypter(ring, "config").Encrypt(ctx, []byte("user: admin\npassword: secret\n"), encrypt.ConfigFormatYAML)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	lines := strings.Split(string(sealed), "\n")
+	user := strings.TrimPrefix(lines[0], "user: ")
+	password := strings.TrimPrefix(lines[1], "password: ")
+
+	// 交换两个字段的密文
+	swapped := strings.Replace(strings.Replace(string(sealed), user, "X", 1), password, user, 1)
+	swapped = strings.Replace(swapped, "X", password, 1)
+	if _, err := encrypt.DecryptConfigData(ctx, ring, []byte(swapped), encrypt.ConfigFormatYAML); err == nil {
+		t.Fatal("挪动字段的密文应解密失败")
+	}
+
+	// 删除一个加密值
+	removed := strings.Replace(string(sealed), lines[0]+"\n", "", 1)
+	if _, err := encrypt.DecryptConfigData(ctx, ring, []byte(removed), encrypt.ConfigFormatYAML); err == nil {
+		t.Fatal("删除加密值应导致MAC校验失败")
+	}
+}
+
+// TestConfigTamperPlain 测试修改未加密的值、键匹配规则或把加密值换成明文被发现
+func TestConfigTamperPlain(t *testing.T) {
+	ring := newConfigRing(t)
+	ctx := context.Background()
+	plain := []byte("{\n  \"port\": 8080,\n  \"api_token\": \"tok\"\n}\n")
+	sealed, err := encrypt.NewConfigEncrypter(ring, "config").WithKeyPattern(`token`).Encrypt(ctx, plain, encrypt.ConfigFormatJSON)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	text := string(sealed)
+	start := strings.Index(text, `"ENC[v1,`)
+	end := strings.Index(text[start+1:], `"`) + start + 2
+
+	for _, c := range []struct {
+		name     string
+		tampered string
+	}{
+		{"修改明文值", strings.Replace(text, `"port": 8080`, `"port": 9090`, 1)},
+		{"修改明文值类型", strings.Replace(text, `"port": 8080`, `"port": "8080"`, 1)},
+		{"修改键匹配规则", strings.Replace(text, `key_pattern": "token"`, `key_pattern": "^none$"`, 1)},
+		{"删除键匹配规则", strings.Replace(text, `"key_pattern": "token",`, ``, 1)},
+		{"加密值替换为明文", text[:start] + `"tok"` + text[end:]},
+	} {
+		if c.tampered == text {
+			t.Fatalf("%s: 替换未生效", c.name)
+		}
+		if _, err := encrypt.DecryptConfigData(ctx, ring, []byte(c.tampered), encrypt.ConfigFormatJSON); err == nil {
+			t.Fatalf("%s 应导致MAC校验失败", c.name)
+		}
+	}
+}
+
+// TestConfigInvalidPattern 测试无效的键匹配规则返回错误
+func TestConfigInvalidPattern(t *testing.T) {
+	ring := newConfigRing(t)
+	_, err := encrypt.NewConfigEncrypter(ring, "config").WithKeyPattern(`(`).Encrypt(context.Background(), []byte("a: 1\n"), encrypt.ConfigFormatYAML)
+	if err == nil {
+		t.Fatal("无效的正则应返回错误")
+	}
+}
+
+// TestEditConfig 测试原地编辑
+func TestEditConfig(t *testing.T) {
+	ring := newConfigRing(t)
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.yaml")
+	if err := os.WriteFile(path, []byte("password: old\n"), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := encrypt.NewConfigEncrypter(ring, "config").EncryptFile(ctx, path); err != nil {
+		t.Fatalf("加密文件失败: %v", err)
+	}
+
+	err := encrypt.EditConfig(ctx, path, ring, func(plaintext []byte) ([]byte, error) {
+		return bytes.Replace(plaintext, []byte("old"), []byte("new"), 1), nil
+	})
+	if err != nil {
+		t.Fatalf("编辑失败: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if bytes.Contains(data, []byte("new")) {
+		t.Fatal("编辑后的文件应重新加密")
+	}
+	opened, err := encrypt.DecryptConfig(ctx, path, ring)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if string(opened) != "password: new\n" {
+		t.Fatalf("编辑结果不正确: %s", opened)
+	}
+	// 篡改键匹配规则后编辑应失败，不能以放宽的规则明文写回
+	tampered := strings.Replace(string(data), "version: 1\n", "version: 1\n  key_pattern: ^none$\n", 1)
+	if tampered == string(data) {
+		t.Fatal("替换未生效")
+	}
+	if err := os.WriteFile(path, []byte(tampered), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	err = encrypt.EditConfig(ctx, path, ring, func(plaintext []byte) ([]byte, error) {
+		return append(plaintext, "extra: 1\n"...), nil
+	})
+	if err == nil {
+		t.Fatal("键匹配规则被篡改时编辑应失败")
+	}
+}
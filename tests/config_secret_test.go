@@ -0,0 +1,41 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestConfigSecret 测试配置密文加解密
+func TestConfigSecret(t *testing.T) {
+	params := encrypt.KeystoreKDFParams{Time: 1, Memory: 1024, Threads: 1}
+	password := []byte("config-password")
+
+	sealed, err := encrypt.SealConfigSecretWithParams(password, []byte("db-password"), params)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if !encrypt.IsSealedConfigSecret(sealed) || strings.ContainsAny(sealed, " \n") {
+		t.Fatalf("配置密文格式不正确: %s", sealed)
+	}
+
+	plaintext, err := encrypt.UnsealConfigSecret(password, sealed)
+	if err != nil || string(plaintext) != "db-password" {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	if _, err := encrypt.UnsealConfigSecret([]byte("wrong"), sealed); err == nil {
+		t.Fatalf("错误口令不应解密成功")
+	}
+
+	// 篡改头部中的argon2id参数
+	tampered := []byte(sealed)
+	tampered[len(encrypt.ConfigSecretPrefix)+2] ^= 1
+	if _, err := encrypt.UnsealConfigSecret(password, string(tampered)); err == nil {
+		t.Fatalf("篡改后的密文不应解密成功")
+	}
+	if _, err := encrypt.UnsealConfigSecret(password, "plain-value"); err == nil {
+		t.Fatalf("非密文格式应返回错误")
+	}
+}
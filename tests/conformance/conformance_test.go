@@ -0,0 +1,243 @@
+package conformance
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// 互通一致性测试
+// testdata中的黄金向量由外部实现（OpenSSL、国标附录、NIST、FIPS）生成，
+// 用于防止IV拼接方式、填充等改动破坏跨语言解密。新增向量见 testdata/gen_openssl.sh；
+// BouncyCastle与GmSSL的向量分别由 testdata/GenBouncyCastle.java、testdata/gen_gmssl.sh 生成，
+// 生成的JSON提交到testdata后需加入cipherVectorFiles，列表中的文件缺失时测试失败
+//
+// 3DES设置IV后仍输出 IV||密文（历史格式，与AES、DES不同），比对时按该格式拼接
+
+// cipherVector 对称加密测试向量，字段均为十六进制
+type cipherVector struct {
+	Source     string `json:"source"`
+	Algorithm  string `json:"algorithm"`
+	Mode       string `json:"mode"`
+	Padding    string `json:"padding"`
+	Key        string `json:"key"`
+	IV         string `json:"iv"`
+	Plaintext  string `json:"plaintext"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+// hashVector 摘要测试向量，字段均为十六进制
+type hashVector struct {
+	Source    string `json:"source"`
+	Algorithm string `json:"algorithm"`
+	Message   string `json:"message"`
+	Digest    string `json:"digest"`
+}
+
+var (
+	constructors = map[string]func([]byte, ...encrypt.Option) (encrypt.ISymmetric, error){
+		"AES":  encrypt.AES,
+		"DES":  encrypt.DES,
+		"3DES": encrypt.TripleDES,
+		"SM4":  encrypt.SM4,
+	}
+
+	modes = map[string]encrypt.Mode{
+		"ECB": encrypt.ModeECB,
+		"CBC": encrypt.ModeCBC,
+		"CFB": encrypt.ModeCFB,
+		"OFB": encrypt.ModeOFB,
+		"CTR": encrypt.ModeCTR,
+		"GCM": encrypt.ModeGCM,
+	}
+
+	// cipherVectorFiles 对称加密向量文件
+	cipherVectorFiles = []string{"openssl.json", "standard.json"}
+
+	paddings = map[string]encrypt.PaddingMode{
+		"None":  encrypt.PaddingNone,
+		"PKCS7": encrypt.PaddingPKCS7,
+		"Zero":  encrypt.PaddingZero,
+	}
+)
+
+// TestCipherVectors 使用外部生成的向量校验对称加密的加密与解密结果
+func TestCipherVectors(t *testing.T) {
+	for _, file := range cipherVectorFiles {
+		var vectors []cipherVector
+		loadVectors(t, file, &vectors)
+
+		for i, v := range vectors {
+			name := fmt.Sprintf("%s/%s-%s-%s/%d", v.Source, v.Algorithm, v.Mode, v.Padding, i)
+			t.Run(name, func(t *testing.T) {
+				key, iv := mustHex(t, v.Key), mustHex(t, v.IV)
+				plaintext, ciphertext := mustHex(t, v.Plaintext), mustHex(t, v.Ciphertext)
+
+				if v.Mode == "GCM" {
+					checkGCMVector(t, v.Algorithm, key, iv, plaintext, ciphertext)
+					return
+				}
+
+				enc := newEncryptor(t, v, key, iv)
+				defer enc.Release()
+
+				if v.Algorithm == "3DES" && len(iv) > 0 {
+					ciphertext = append(bytes.Clone(iv), ciphertext...)
+				}
+
+				got, err := enc.Encrypt(plaintext)
+				if err != nil {
+					t.Fatalf("加密失败: %v", err)
+				}
+				if !bytes.Equal(got, ciphertext) {
+					t.Fatalf("密文不一致\n期望: %x\n实际: %x", ciphertext, got)
+				}
+
+				decrypted, err := enc.Decrypt(ciphertext)
+				if err != nil {
+					t.Fatalf("解密失败: %v", err)
+				}
+				if !bytes.Equal(decrypted, plaintext) {
+					t.Fatalf("明文不一致\n期望: %x\n实际: %x", plaintext, decrypted)
+				}
+			})
+		}
+	}
+}
+
+// TestEmbeddedIVVectors 校验未设置IV时的密文格式为 IV||密文，与外部实现可直接互通
+func TestEmbeddedIVVectors(t *testing.T) {
+	var vectors []cipherVector
+	loadVectors(t, "openssl.json", &vectors)
+
+	for i, v := range vectors {
+		// SM4链式实现不拼接IV
+		if v.IV == "" || v.Algorithm == "SM4" {
+			continue
+		}
+
+		name := fmt.Sprintf("%s-%s-%s/%d", v.Algorithm, v.Mode, v.Padding, i)
+		t.Run(name, func(t *testing.T) {
+			key, iv := mustHex(t, v.Key), mustHex(t, v.IV)
+			plaintext, ciphertext := mustHex(t, v.Plaintext), mustHex(t, v.Ciphertext)
+
+			enc := newEncryptor(t, v, key, nil)
+			defer enc.Release()
+
+			decrypted, err := enc.Decrypt(append(iv, ciphertext...))
+			if err != nil {
+				t.Fatalf("解密失败: %v", err)
+			}
+			if !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("明文不一致\n期望: %x\n实际: %x", plaintext, decrypted)
+			}
+		})
+	}
+}
+
+// TestHashVectors 使用国标附录向量校验摘要算法
+func TestHashVectors(t *testing.T) {
+	var vectors []hashVector
+	loadVectors(t, "hash.json", &vectors)
+
+	for i, v := range vectors {
+		t.Run(fmt.Sprintf("%s/%d", v.Source, i), func(t *testing.T) {
+			if v.Algorithm != "SM3" {
+				t.Fatalf("不支持的摘要算法: %s", v.Algorithm)
+			}
+
+			got, err := encrypt.NewSM3().Hex().Sum(mustHex(t, v.Message))
+			if err != nil {
+				t.Fatalf("计算摘要失败: %v", err)
+			}
+			if got != v.Digest {
+				t.Fatalf("摘要不一致\n期望: %s\n实际: %s", v.Digest, got)
+			}
+		})
+	}
+}
+
+// checkGCMVector GCM向量的nonce固定，只能校验解密方向
+func checkGCMVector(t *testing.T, algorithm string, key, nonce, plaintext, ciphertext []byte) {
+	t.Helper()
+
+	decrypt := encrypt.AESGCMDecrypt
+	if algorithm == "SM4" {
+		decrypt = encrypt.SM4GCMDecrypt
+	}
+
+	decrypted, err := decrypt(key, append(nonce, ciphertext...), nil)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("明文不一致\n期望: %x\n实际: %x", plaintext, decrypted)
+	}
+}
+
+// newEncryptor 按向量描述创建无编码的加密器，iv为nil时不设置IV
+func newEncryptor(t *testing.T, v cipherVector, key, iv []byte) encrypt.ISymmetric {
+	t.Helper()
+
+	constructor, ok := constructors[v.Algorithm]
+	if !ok {
+		t.Fatalf("不支持的算法: %s", v.Algorithm)
+	}
+	mode, ok := modes[v.Mode]
+	if !ok {
+		t.Fatalf("不支持的模式: %s", v.Mode)
+	}
+	padding, ok := paddings[v.Padding]
+	if !ok {
+		t.Fatalf("不支持的填充: %s", v.Padding)
+	}
+
+	opts := []encrypt.Option{
+		encrypt.WithMode(mode),
+		encrypt.WithPadding(padding),
+		encrypt.WithEncoding(encrypt.EncodingNone),
+	}
+	if len(iv) > 0 {
+		opts = append(opts, encrypt.WithIV(iv))
+	}
+
+	enc, err := constructor(key, opts...)
+	if err != nil {
+		t.Fatalf("创建加密器失败: %v", err)
+	}
+	return enc
+}
+
+// loadVectors 读取testdata下的JSON向量文件，文件缺失或为空时测试失败
+func loadVectors(t *testing.T, name string, out interface{}) {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("读取向量文件%s失败: %v", name, err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		t.Fatalf("解析向量文件%s失败: %v", name, err)
+	}
+	if reflect.ValueOf(out).Elem().Len() == 0 {
+		t.Fatalf("向量文件%s为空", name)
+	}
+}
+
+// mustHex 解码十六进制字段
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("十六进制解码失败: %v", err)
+	}
+	return b
+}
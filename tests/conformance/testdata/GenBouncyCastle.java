@@ -0,0 +1,66 @@
+// 使用BouncyCastle生成对称加密互通测试向量，输出到 bouncycastle.json
+// 用法（JDK 11+）: java -cp bcprov-jdk18on.jar GenBouncyCastle.java > bouncycastle.json
+
+import java.nio.charset.StandardCharsets;
+import java.security.Security;
+import java.util.HexFormat;
+import javax.crypto.Cipher;
+import javax.crypto.spec.GCMParameterSpec;
+import javax.crypto.spec.IvParameterSpec;
+import javax.crypto.spec.SecretKeySpec;
+
+import org.bouncycastle.jce.provider.BouncyCastleProvider;
+
+public class GenBouncyCastle {
+    static final HexFormat HEX = HexFormat.of();
+    static final String PT_BLOCK = "00112233445566778899aabbccddeeff0123456789abcdeffedcba9876543210";
+    static final String PT_TEXT = HEX.formatHex("BouncyCastle interop vector, 42 bytes!!!!!".getBytes(StandardCharsets.US_ASCII));
+    static boolean first = true;
+
+    public static void main(String[] args) throws Exception {
+        Security.addProvider(new BouncyCastleProvider());
+
+        String aes = "2b7e151628aed2a6abf7158809cf4f3c";
+        String sm4 = "0123456789abcdeffedcba9876543210";
+        String des3 = "0123456789abcdef23456789abcdef01456789abcdef0123";
+        String iv16 = "000102030405060708090a0b0c0d0e0f";
+        String iv8 = "1234567890abcdef";
+        String nonce = "000102030405060708090a0b";
+
+        System.out.println("[");
+        for (String[] spec : new String[][] {{"AES", aes}, {"SM4", sm4}}) {
+            emit(spec[0], "ECB", "PKCS7", spec[1], "", PT_TEXT);
+            emit(spec[0], "CBC", "PKCS7", spec[1], iv16, PT_TEXT);
+            emit(spec[0], "CBC", "None", spec[1], iv16, PT_BLOCK);
+            emit(spec[0], "CFB", "None", spec[1], iv16, PT_BLOCK);
+            emit(spec[0], "OFB", "None", spec[1], iv16, PT_BLOCK);
+            emit(spec[0], "CTR", "None", spec[1], iv16, PT_BLOCK);
+            emit(spec[0], "GCM", "None", spec[1], nonce, PT_TEXT);
+        }
+        emit("3DES", "CBC", "PKCS7", des3, iv8, PT_TEXT);
+        emit("3DES", "CFB", "None", des3, iv8, PT_BLOCK);
+        System.out.println("\n]");
+    }
+
+    static void emit(String algorithm, String mode, String padding, String key, String iv, String plaintext) throws Exception {
+        String jceAlgorithm = algorithm.equals("3DES") ? "DESede" : algorithm;
+        String jcePadding = padding.equals("PKCS7") ? "PKCS7Padding" : "NoPadding";
+        Cipher cipher = Cipher.getInstance(jceAlgorithm + "/" + mode + "/" + jcePadding, "BC");
+        SecretKeySpec secretKey = new SecretKeySpec(HEX.parseHex(key), jceAlgorithm);
+        if (iv.isEmpty()) {
+            cipher.init(Cipher.ENCRYPT_MODE, secretKey);
+        } else if (mode.equals("GCM")) {
+            cipher.init(Cipher.ENCRYPT_MODE, secretKey, new GCMParameterSpec(128, HEX.parseHex(iv)));
+        } else {
+            cipher.init(Cipher.ENCRYPT_MODE, secretKey, new IvParameterSpec(HEX.parseHex(iv)));
+        }
+        String ciphertext = HEX.formatHex(cipher.doFinal(HEX.parseHex(plaintext)));
+
+        if (!first) {
+            System.out.print(",\n");
+        }
+        first = false;
+        System.out.printf("  {\"source\": \"bouncycastle\", \"algorithm\": \"%s\", \"mode\": \"%s\", \"padding\": \"%s\", \"key\": \"%s\", \"iv\": \"%s\", \"plaintext\": \"%s\", \"ciphertext\": \"%s\"}",
+                algorithm, mode, padding, key, iv, plaintext, ciphertext);
+    }
+}
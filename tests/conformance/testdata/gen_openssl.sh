@@ -0,0 +1,73 @@
+#!/bin/sh
+# 使用OpenSSL生成对称加密互通测试向量，输出到 openssl.json
+# 用法: sh gen_openssl.sh > openssl.json
+set -e
+
+hexstr() { printf '%s' "$1" | od -An -tx1 | tr -d ' \n'; }
+
+PT_BLOCK="00112233445566778899aabbccddeeff0123456789abcdeffedcba9876543210"
+PT_TEXT=$(hexstr "OpenSSL interop vector, 37 bytes!!!!")
+
+# zeropad 按块大小补零（OpenSSL不支持零填充，补齐后以-nopad加密），$1=十六进制数据 $2=块大小
+zeropad() {
+	n=$(( ${#1} / 2 ))
+	pad=$(( $2 - n % $2 ))
+	printf '%s' "$1"
+	i=0
+	while [ $i -lt $pad ]; do printf '00'; i=$((i + 1)); done
+}
+
+first=1
+emit() {
+	# $1=algorithm $2=mode $3=padding $4=key $5=iv $6=plaintext $7=openssl cipher name $8=extra flags
+	if [ -n "$5" ]; then ivflag="-iv $5"; else ivflag=""; fi
+	input="$6"
+	if [ "$3" = "Zero" ]; then
+		case "$1" in DES|3DES) bs=8 ;; *) bs=16 ;; esac
+		input=$(zeropad "$6" $bs)
+	fi
+	ct=$(printf '%s' "$input" | xxd -r -p | openssl enc -e "-$7" -K "$4" $ivflag $8 | od -An -tx1 | tr -d ' \n')
+	if [ $first -eq 0 ]; then printf ',\n'; fi
+	first=0
+	printf '  {"source": "openssl", "algorithm": "%s", "mode": "%s", "padding": "%s", "key": "%s", "iv": "%s", "plaintext": "%s", "ciphertext": "%s"}' \
+		"$1" "$2" "$3" "$4" "$5" "$6" "$ct"
+}
+
+AES128="2b7e151628aed2a6abf7158809cf4f3c"
+AES256="603deb1015ca71be2b73aef0857d77811f352c073b6108d72d9810a30914dff4"
+SM4KEY="0123456789abcdeffedcba9876543210"
+DESKEY="133457799bbcdff1"
+DES3KEY="0123456789abcdef23456789abcdef01456789abcdef0123"
+IV16="000102030405060708090a0b0c0d0e0f"
+IV8="1234567890abcdef"
+
+printf '[\n'
+for spec in "AES:$AES128:aes-128" "AES:$AES256:aes-256" "SM4:$SM4KEY:sm4"; do
+	alg=${spec%%:*}; rest=${spec#*:}; key=${rest%%:*}; name=${rest#*:}
+	emit "$alg" ECB PKCS7 "$key" "" "$PT_TEXT" "$name-ecb" ""
+	emit "$alg" ECB None "$key" "" "$PT_BLOCK" "$name-ecb" "-nopad"
+	emit "$alg" CBC PKCS7 "$key" "$IV16" "$PT_TEXT" "$name-cbc" ""
+	emit "$alg" CBC None "$key" "$IV16" "$PT_BLOCK" "$name-cbc" "-nopad"
+	emit "$alg" CFB None "$key" "$IV16" "$PT_BLOCK" "$name-cfb" ""
+	emit "$alg" OFB None "$key" "$IV16" "$PT_BLOCK" "$name-ofb" ""
+	emit "$alg" CTR None "$key" "$IV16" "$PT_BLOCK" "$name-ctr" ""
+done
+emit 3DES ECB PKCS7 "$DES3KEY" "" "$PT_TEXT" "des-ede3-ecb" ""
+emit 3DES CBC PKCS7 "$DES3KEY" "$IV8" "$PT_TEXT" "des-ede3-cbc" ""
+emit 3DES CFB None "$DES3KEY" "$IV8" "$PT_BLOCK" "des-ede3-cfb" ""
+emit 3DES OFB None "$DES3KEY" "$IV8" "$PT_BLOCK" "des-ede3-ofb" ""
+# OpenSSL 3.x中单DES位于legacy提供者
+LEGACY="-provider legacy -provider default"
+emit DES ECB PKCS7 "$DESKEY" "" "$PT_TEXT" "des-ecb" "$LEGACY"
+emit DES ECB None "$DESKEY" "" "$PT_BLOCK" "des-ecb" "-nopad $LEGACY"
+emit DES CBC PKCS7 "$DESKEY" "$IV8" "$PT_TEXT" "des-cbc" "$LEGACY"
+emit DES CBC None "$DESKEY" "$IV8" "$PT_BLOCK" "des-cbc" "-nopad $LEGACY"
+emit DES CFB None "$DESKEY" "$IV8" "$PT_BLOCK" "des-cfb" "$LEGACY"
+emit DES OFB None "$DESKEY" "$IV8" "$PT_BLOCK" "des-ofb" "$LEGACY"
+# 零填充：明文不是块大小的整数倍，末尾补0x00至整块
+emit AES ECB Zero "$AES128" "" "$PT_TEXT" "aes-128-ecb" "-nopad"
+emit AES CBC Zero "$AES128" "$IV16" "$PT_TEXT" "aes-128-cbc" "-nopad"
+emit SM4 CBC Zero "$SM4KEY" "$IV16" "$PT_TEXT" "sm4-cbc" "-nopad"
+emit DES CBC Zero "$DESKEY" "$IV8" "$PT_TEXT" "des-cbc" "-nopad $LEGACY"
+emit 3DES CBC Zero "$DES3KEY" "$IV8" "$PT_TEXT" "des-ede3-cbc" "-nopad"
+printf '\n]\n'
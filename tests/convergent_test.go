@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestConvergentEncryption 测试收敛加密的确定性与去重
+func TestConvergentEncryption(t *testing.T) {
+	tenantA, _ := encrypt.NewConvergentCipher(bytes.Repeat([]byte{1}, 32))
+	tenantB, _ := encrypt.NewConvergentCipher(bytes.Repeat([]byte{2}, 32))
+	data := bytes.Repeat([]byte("backup block "), 100)
+
+	first, err := tenantA.Seal(data)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	second, _ := tenantA.Seal(data)
+	if first.ID != second.ID || !bytes.Equal(first.Ciphertext, second.Ciphertext) {
+		t.Fatal("同一域内相同明文应得到相同密文")
+	}
+	if encrypt.ConvergentID(first.Ciphertext) != first.ID {
+		t.Fatal("内容ID与密文不一致")
+	}
+	other, _ := tenantB.Seal(data)
+	if other.ID == first.ID {
+		t.Fatal("不同域的密文不应相同")
+	}
+	different, _ := tenantA.Seal(append(data, '!'))
+	if different.ID == first.ID {
+		t.Fatal("不同明文的内容ID不应相同")
+	}
+
+	plaintext, err := tenantA.Open(first.Key, first.Ciphertext)
+	if err != nil || !bytes.Equal(plaintext, data) {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if _, err := tenantA.Open(first.Key, different.Ciphertext); err == nil {
+		t.Fatal("文件密钥与密文不匹配应当失败")
+	}
+	// 其他域的文件密钥即使能解密，也无法通过明文校验
+	if _, err := tenantB.Open(first.Key, first.Ciphertext); err == nil {
+		t.Fatal("其他域应当无法确认明文")
+	}
+
+	sm4, _ := encrypt.NewConvergentCipher(bytes.Repeat([]byte{1}, 32))
+	blob, _ := sm4.SM4().Seal(data)
+	if blob.ID == first.ID {
+		t.Fatal("SM4与AES的密文不应相同")
+	}
+	if plaintext, err := sm4.Open(blob.Key, blob.Ciphertext); err != nil || !bytes.Equal(plaintext, data) {
+		t.Fatalf("SM4解密失败: %v", err)
+	}
+	if _, err := encrypt.NewConvergentCipher([]byte("short")); err == nil {
+		t.Fatal("过短的域密钥应当失败")
+	}
+}
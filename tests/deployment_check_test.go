@@ -0,0 +1,59 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestValidateDeployment 测试启动自检
+func TestValidateDeployment(t *testing.T) {
+	aesKey, _ := encrypt.GenerateRandomKey(32)
+	pubPEM, privPEM, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	otherPub, _, _ := encrypt.MustNewSM2().GenerateKeyPair()
+
+	ring := encrypt.NewKeyRing()
+	_ = ring.Add(encrypt.KeyEntry{ID: "data-1", Algorithm: encrypt.AlgorithmAES, Key: aesKey, Usage: encrypt.KeyUsageCipher})
+	_ = ring.Add(encrypt.KeyEntry{ID: "sign-1", Algorithm: encrypt.AlgorithmSM2, Key: privPEM, PublicKey: pubPEM, Usage: encrypt.KeyUsageSignature})
+	_ = ring.Add(encrypt.KeyEntry{ID: "partner", Algorithm: encrypt.AlgorithmSM2, PublicKey: otherPub, Usage: encrypt.KeyUsageVerify})
+
+	stored, _ := ring.Encrypt([]byte("canary"), []byte("probe"))
+	probe := encrypt.DeploymentProbe{Name: "canary", Ciphertext: stored, AAD: []byte("probe"), Plaintext: []byte("canary")}
+	if err := encrypt.ValidateDeployment(ring, []encrypt.DeploymentProbe{probe}); err != nil {
+		t.Fatalf("健康的密钥环应通过自检: %v", err)
+	}
+
+	// 同一ID换了密钥材料、公私钥配错、主密钥过期
+	broken := encrypt.NewKeyRing().WithClock(func() time.Time { return time.Now().Add(48 * time.Hour) })
+	otherKey, _ := encrypt.GenerateRandomKey(32)
+	_ = broken.Add(encrypt.KeyEntry{ID: "data-1", Algorithm: encrypt.AlgorithmAES, Key: otherKey, Usage: encrypt.KeyUsageCipher, NotAfter: time.Now().Add(time.Hour)})
+	_ = broken.Add(encrypt.KeyEntry{ID: "sign-1", Algorithm: encrypt.AlgorithmSM2, Key: privPEM, PublicKey: otherPub, Usage: encrypt.KeyUsageSignature})
+	_ = broken.Add(encrypt.KeyEntry{ID: "short", Algorithm: encrypt.AlgorithmAES, Key: aesKey[:10], Usage: encrypt.KeyUsageDecrypt})
+
+	err := encrypt.ValidateDeployment(broken, []encrypt.DeploymentProbe{probe})
+	var deployErr *encrypt.DeploymentError
+	if !errors.As(err, &deployErr) {
+		t.Fatalf("应返回DeploymentError，实际为%v", err)
+	}
+	if len(deployErr.Failures) != 4 {
+		t.Fatalf("应发现4项问题，实际为:\n%v", err)
+	}
+	if !errors.Is(err, encrypt.ErrKeyExpired) {
+		t.Fatal("应能判断主密钥过期")
+	}
+
+	ops := make(map[string]string)
+	for _, failure := range deployErr.Failures {
+		ops[failure.Operation] = failure.KeyID
+	}
+	if ops[encrypt.ProbeOpPrimary] != "data-1" || ops[encrypt.ProbeOpVerify] != "sign-1" ||
+		ops[encrypt.ProbeOpEncrypt] != "short" || ops[encrypt.ProbeOpProbe] != "data-1" {
+		t.Fatalf("问题定位不正确: %v", ops)
+	}
+
+	if err := encrypt.ValidateDeployment(encrypt.NewKeyRing(), nil); err == nil {
+		t.Fatal("没有主密钥应自检失败")
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestTripleDESDoubleLengthKey 测试16字节双倍长3DES密钥与展开后的24字节密钥等价
+func TestTripleDESDoubleLengthKey(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	expanded, err := encrypt.NormalizeTripleDESKey(key)
+	if err != nil || !bytes.Equal(expanded, append(append([]byte(nil), key...), key[:8]...)) {
+		t.Fatalf("密钥展开不正确: %v %X", err, expanded)
+	}
+	if _, err := encrypt.NormalizeTripleDESKey(key[:8]); err == nil {
+		t.Fatal("8字节3DES密钥应当失败")
+	}
+
+	iv := make([]byte, 8)
+	double, err := encrypt.New3DES(key)
+	if err != nil {
+		t.Fatalf("16字节3DES密钥应当可用: %v", err)
+	}
+	ciphertext, err := double.WithIV(iv).Encrypt([]byte("hsm exported key"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	triple, _ := encrypt.New3DES(expanded)
+	plaintext, err := triple.WithIV(iv).Decrypt(ciphertext)
+	if err != nil || string(plaintext) != "hsm exported key" {
+		t.Fatalf("24字节密钥解密失败: %v %q", err, plaintext)
+	}
+
+	if err := encrypt.ValidateKey(encrypt.Algorithm3DES, key); err != nil {
+		t.Fatalf("双倍长密钥校验失败: %v", err)
+	}
+	if err := encrypt.ValidateKey(encrypt.Algorithm3DES, append(key[:8:8], key[:8]...)); err == nil {
+		t.Fatal("K1==K2的双倍长密钥应当被拒绝")
+	}
+}
+
+// TestDESParity 测试DES密钥奇校验修正与校验
+func TestDESParity(t *testing.T) {
+	key, _ := hex.DecodeString("0023456789ABCDEFFEDCBA9876543210")
+	if err := encrypt.CheckDESParity(key); err == nil {
+		t.Fatal("校验位不正确的密钥应当失败")
+	}
+
+	fixed := encrypt.FixDESParity(key)
+	if hex.EncodeToString(fixed) != "0123456789abcdeffedcba9876543210" {
+		t.Fatalf("校验位修正不正确: %X", fixed)
+	}
+	if key[0] != 0x00 {
+		t.Fatal("FixDESParity不应修改原密钥")
+	}
+	if err := encrypt.CheckDESParity(fixed); err != nil {
+		t.Fatalf("修正后的密钥校验失败: %v", err)
+	}
+	if err := encrypt.CheckDESParity(fixed[:5]); err == nil {
+		t.Fatal("长度不是8的整数倍应当失败")
+	}
+}
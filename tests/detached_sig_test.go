@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestDetachedSignature 测试文件分离式签名
+func TestDetachedSignature(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "release.tar.gz")
+	if err := os.WriteFile(path, []byte("release artifact v1.2.3"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	testCases := []struct {
+		name      string
+		generate  func() ([]byte, []byte, error)
+		hashAlgo  encrypt.HashAlgorithm
+		algorithm string
+	}{
+		{"RSA", func() ([]byte, []byte, error) { return encrypt.MustNewRSA().GenerateKeyPair() }, encrypt.HashSHA256, "RSA"},
+		{"SM2", func() ([]byte, []byte, error) { return encrypt.MustNewSM2().GenerateKeyPair() }, encrypt.HashSM3, "SM2"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pubPEM, privPEM, err := tc.generate()
+			if err != nil {
+				t.Fatalf("生成密钥失败: %v", err)
+			}
+			signer, err := encrypt.ParseSigner(privPEM)
+			if err != nil {
+				t.Fatalf("解析私钥失败: %v", err)
+			}
+
+			sig, err := encrypt.SignFile(signer, path, tc.hashAlgo)
+			if err != nil {
+				t.Fatalf("签名失败: %v", err)
+			}
+			if sig.Signatures[0].Algorithm != tc.algorithm {
+				t.Fatalf("签名算法记录不正确: %s", sig.Signatures[0].Algorithm)
+			}
+			if err := sig.WriteFile(path); err != nil {
+				t.Fatalf("写入签名文件失败: %v", err)
+			}
+
+			data, err := os.ReadFile(path + encrypt.DetachedSignatureExt)
+			if err != nil {
+				t.Fatalf("读取签名文件失败: %v", err)
+			}
+			parsed, err := encrypt.ParseDetachedSignature(data)
+			if err != nil {
+				t.Fatalf("解析签名文件失败: %v", err)
+			}
+			if err := encrypt.VerifyFile(path, parsed, pubPEM); err != nil {
+				t.Fatalf("验签失败: %v", err)
+			}
+
+			// 篡改元数据
+			tampered := *parsed
+			tampered.Signatures = append([]encrypt.SignatureRecord(nil), parsed.Signatures...)
+			tampered.Signatures[0].SignedAt = tampered.Signatures[0].SignedAt.Add(-24 * time.Hour)
+			if err := encrypt.VerifyFile(path, &tampered, pubPEM); err == nil {
+				t.Fatalf("篡改签名时间后应该验签失败")
+			}
+
+			// 其他公钥
+			otherPub, _, _ := tc.generate()
+			if err := encrypt.VerifyFile(path, parsed, otherPub); err == nil {
+				t.Fatalf("使用其他公钥应该验签失败")
+			}
+		})
+	}
+
+	// 篡改文件内容
+	pubPEM, privPEM, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	signer, _ := encrypt.ParseSigner(privPEM)
+	sig, _ := encrypt.SignFile(signer, path, encrypt.HashSM3)
+	os.WriteFile(path, []byte("release artifact v1.2.4"), 0644)
+	if err := encrypt.VerifyFile(path, sig, pubPEM); err == nil {
+		t.Fatalf("文件被篡改后应该验签失败")
+	}
+}
@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSignDigest 测试预计算摘要的签名与验签
+func TestSignDigest(t *testing.T) {
+	data := []byte("流式管道中已计算摘要的数据")
+
+	t.Run("RSA", func(t *testing.T) {
+		rsaEncryptor := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+		if _, _, err := rsaEncryptor.GenerateKeyPair(); err != nil {
+			t.Fatalf("RSA密钥生成失败: %v", err)
+		}
+
+		digest := sha256.Sum256(data)
+		signature, err := rsaEncryptor.SignDigest(digest[:])
+		if err != nil {
+			t.Fatalf("RSA摘要签名失败: %v", err)
+		}
+
+		// 摘要签名可用原文验证
+		valid, err := rsaEncryptor.Verify(data, signature)
+		if err != nil || !valid {
+			t.Fatalf("RSA签名验证失败: %v, 结果: %v", err, valid)
+		}
+
+		signature, _ = rsaEncryptor.Sign(data)
+		valid, err = rsaEncryptor.VerifyDigest(digest[:], signature)
+		if err != nil || !valid {
+			t.Fatalf("RSA摘要验签失败: %v, 结果: %v", err, valid)
+		}
+	})
+
+	t.Run("SM2", func(t *testing.T) {
+		sm2Encryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+		if _, _, err := sm2Encryptor.GenerateKeyPair(); err != nil {
+			t.Fatalf("SM2密钥生成失败: %v", err)
+		}
+
+		// e = SM3(Z || M)，使用默认UID
+		digest, err := sm2Encryptor.ComputeDigest(data)
+		if err != nil {
+			t.Fatalf("计算SM2摘要失败: %v", err)
+		}
+
+		signature, err := sm2Encryptor.SignDigest(digest)
+		if err != nil {
+			t.Fatalf("SM2摘要签名失败: %v", err)
+		}
+		valid, err := sm2Encryptor.Verify(data, signature)
+		if err != nil || !valid {
+			t.Fatalf("SM2签名验证失败: %v, 结果: %v", err, valid)
+		}
+
+		signature, _ = sm2Encryptor.Sign(data)
+		valid, err = sm2Encryptor.VerifyDigest(digest, signature)
+		if err != nil || !valid {
+			t.Fatalf("SM2摘要验签失败: %v, 结果: %v", err, valid)
+		}
+	})
+}
+
+// TestComputeZA 测试SM2 Z值与摘要计算
+func TestComputeZA(t *testing.T) {
+	sm2Encryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	pubKey, _, err := sm2Encryptor.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+
+	za, err := encrypt.ComputeZA(pubKey, nil)
+	if err != nil || len(za) != 32 {
+		t.Fatalf("计算Z值失败: %v", err)
+	}
+	fromEncryptor, _ := sm2Encryptor.ComputeZA()
+	if string(za) != string(fromEncryptor) {
+		t.Fatalf("默认UID的Z值不一致")
+	}
+
+	customZA, _ := encrypt.ComputeZA(pubKey, []byte("partner@bank"))
+	if string(customZA) == string(za) {
+		t.Fatalf("不同UID的Z值不应相同")
+	}
+
+	// 使用自定义UID计算的摘要可被相同UID验签
+	data := []byte("互通调试数据")
+	digest, err := encrypt.ComputeSM2Digest(pubKey, []byte("partner@bank"), data)
+	if err != nil {
+		t.Fatalf("计算摘要失败: %v", err)
+	}
+	signature, err := sm2Encryptor.SignDigest(digest)
+	if err != nil {
+		t.Fatalf("SM2摘要签名失败: %v", err)
+	}
+	valid, _ := sm2Encryptor.WithUID([]byte("partner@bank")).Verify(data, signature)
+	if !valid {
+		t.Fatalf("自定义UID验签失败")
+	}
+}
@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("十六进制解码失败: %v", err)
+	}
+	return b
+}
+
+// TestHMACDRBGVector 使用NIST CAVP HMAC_DRBG SHA-256向量（无预测抗性）
+func TestHMACDRBGVector(t *testing.T) {
+	d, err := encrypt.NewHMACDRBG(
+		mustHex(t, "ca851911349384bffe89de1cbdc46e6831e44d34a4fb935ee285dd14b71a7488"),
+		mustHex(t, "659ba96c601dc69fc902940805ec0ca8"), nil)
+	if err != nil {
+		t.Fatalf("创建DRBG失败: %v", err)
+	}
+
+	out := make([]byte, 128)
+	d.Generate(out, nil)
+	d.Generate(out, nil)
+	expected := "e528e9abf2dece54d47c7e75e5fe302149f817ea9fb4bee6f4199697d04d5b89d54fbb978a15b5c443c9ec21036d2460b6f73ebad0dc2aba6e624abf07745bc107694bb7547bb0995f70de25d6b29e2d3011bb19d27676c07162c8b5ccde0668961df86803482cb37ed6d5c0bb8d50cf1f50d476aa0458bdaba806f48be9dcb8"
+	if hex.EncodeToString(out) != expected {
+		t.Fatalf("输出与NIST向量不一致: %x", out)
+	}
+}
+
+// TestCTRDRBGVector 使用NIST CAVP CTR_DRBG AES-256（使用派生函数）向量
+func TestCTRDRBGVector(t *testing.T) {
+	d, err := encrypt.NewCTRDRBG(
+		mustHex(t, "36401940fa8b1fba91a1661f211d78a0b9389a74e5bccfece8d766af1a6d3b14"),
+		mustHex(t, "496f25b0f1301b4f501be30380a137eb"), nil)
+	if err != nil {
+		t.Fatalf("创建DRBG失败: %v", err)
+	}
+
+	out := make([]byte, 64)
+	d.Generate(out, nil)
+	d.Generate(out, nil)
+	expected := "5862eb38bd558dd978a696e6df164782ddd887e7e9a6c9f3f1fbafb78941b535a64912dfd224c6dc7454e5250b3d97165e16260c2faf1cc7735cb75fb4f07e1d"
+	if hex.EncodeToString(out) != expected {
+		t.Fatalf("输出与NIST向量不一致: %x", out)
+	}
+}
+
+// TestDRBGReseed 测试重播种间隔与自动重播种
+func TestDRBGReseed(t *testing.T) {
+	entropy := bytes.Repeat([]byte{1}, 32)
+	d, _ := encrypt.NewCTRDRBG(entropy, nil, []byte("test"))
+	d.WithReseedInterval(2)
+
+	buf := make([]byte, 16)
+	if err := d.Generate(buf, nil); err != nil {
+		t.Fatalf("生成失败: %v", err)
+	}
+	d.Generate(buf, nil)
+	if err := d.Generate(buf, nil); !errors.Is(err, encrypt.ErrDRBGReseedRequired) {
+		t.Fatalf("达到重播种间隔应返回错误: %v", err)
+	}
+
+	d.WithEntropySource(encrypt.OSEntropySource)
+	if _, err := d.Read(make([]byte, 100000)); err != nil {
+		t.Fatalf("自动重播种失败: %v", err)
+	}
+
+	// 相同输入产生相同序列
+	a, _ := encrypt.NewHMACDRBG(entropy, nil, nil)
+	b, _ := encrypt.NewHMACDRBG(entropy, nil, nil)
+	x, y := make([]byte, 70000), make([]byte, 70000)
+	a.Read(x)
+	b.Read(y)
+	if !bytes.Equal(x, y) {
+		t.Fatalf("相同种子应产生相同序列")
+	}
+}
@@ -0,0 +1,129 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestTDESDUKPT 测试ANSI X9.24-1附录A的TDES DUKPT样例
+func TestTDESDUKPT(t *testing.T) {
+	bdk, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	dukpt, err := encrypt.NewTDESDUKPT(bdk)
+	if err != nil {
+		t.Fatalf("创建DUKPT失败: %v", err)
+	}
+
+	ksn, _ := hex.DecodeString("FFFF9876543210E00000")
+	ipek, _ := dukpt.InitialKey(ksn)
+	if strings.ToUpper(hex.EncodeToString(ipek)) != "6AC292FAA1315B4D858AB3A3D7D5933A" {
+		t.Fatalf("IPEK不正确: %X", ipek)
+	}
+
+	for ksnHex, expected := range map[string]string{
+		"FFFF9876543210E00001": "1B9C1845EB993A7A",
+		"FFFF9876543210E00002": "10A01C8D02C69107",
+		"FFFF9876543210E00003": "18DC07B94797B466",
+	} {
+		ksn, _ := hex.DecodeString(ksnHex)
+		block, err := dukpt.EncryptPIN(ksn, "1234", "4012345678909")
+		if err != nil || strings.ToUpper(hex.EncodeToString(block)) != expected {
+			t.Fatalf("KSN %s的PIN块不正确: %v %X", ksnHex, err, block)
+		}
+		pin, err := dukpt.DecryptPIN(ksn, block, "4012345678909")
+		if err != nil || pin != "1234" {
+			t.Fatalf("PIN解密失败: %v %s", err, pin)
+		}
+	}
+
+	ksn, _ = hex.DecodeString("FFFF9876543210E00001")
+	mac, err := dukpt.MAC(ksn, encrypt.DUKPTMACRequest, []byte("4012345678909D987"))
+	if err != nil || len(mac) != 8 {
+		t.Fatalf("计算MAC失败: %v", err)
+	}
+	if !dukpt.VerifyMAC(ksn, encrypt.DUKPTMACRequest, []byte("4012345678909D987"), mac[:4]) {
+		t.Fatal("截断的MAC应验证通过")
+	}
+	if dukpt.VerifyMAC(ksn, encrypt.DUKPTMACResponse, []byte("4012345678909D987"), mac) {
+		t.Fatal("响应MAC密钥不应验证请求MAC")
+	}
+}
+
+// TestAESDUKPT 测试ANSI X9.24-3的AES DUKPT
+func TestAESDUKPT(t *testing.T) {
+	bdk, _ := hex.DecodeString("FEDCBA9876543210F1F1F1F1F1F1F1F1")
+	dukpt, err := encrypt.NewAESDUKPT(bdk)
+	if err != nil {
+		t.Fatalf("创建DUKPT失败: %v", err)
+	}
+
+	ksn, _ := hex.DecodeString("123456789012345600000001")
+	initial, _ := dukpt.InitialKey(ksn)
+	if strings.ToUpper(hex.EncodeToString(initial)) != "1273671EA26AC29AFA4D1084127652A1" {
+		t.Fatalf("初始密钥不正确: %X", initial)
+	}
+	pinKey, err := dukpt.WorkingKey(ksn, encrypt.DUKPTPIN)
+	if err != nil || strings.ToUpper(hex.EncodeToString(pinKey)) != "AF8CB133A78F8DC2D1359F18527593FB" {
+		t.Fatalf("PIN密钥不正确: %v %X", err, pinKey)
+	}
+
+	block, err := dukpt.EncryptPIN(ksn, "123456", "4111111111111111")
+	if err != nil || len(block) != 16 {
+		t.Fatalf("PIN加密失败: %v", err)
+	}
+	again, _ := dukpt.EncryptPIN(ksn, "123456", "4111111111111111")
+	if bytes.Equal(block, again) {
+		t.Fatal("格式4 PIN块应包含随机填充")
+	}
+	if pin, err := dukpt.DecryptPIN(ksn, block, "4111111111111111"); err != nil || pin != "123456" {
+		t.Fatalf("PIN解密失败: %v %s", err, pin)
+	}
+
+	next, _ := hex.DecodeString("123456789012345600000002")
+	mac, _ := dukpt.MAC(next, encrypt.DUKPTMACRequest, []byte("message"))
+	if !dukpt.VerifyMAC(next, encrypt.DUKPTMACRequest, []byte("message"), mac[:8]) || dukpt.VerifyMAC(ksn, encrypt.DUKPTMACRequest, []byte("message"), mac) {
+		t.Fatal("MAC应只对本笔交易有效")
+	}
+
+	zero, _ := hex.DecodeString("123456789012345600000000")
+	if _, err := dukpt.WorkingKey(zero, encrypt.DUKPTPIN); err == nil {
+		t.Fatal("计数器为0应被拒绝")
+	}
+}
+
+// TestCMAC 测试RFC 4493 AES-CMAC样例
+func TestCMAC(t *testing.T) {
+	key, _ := hex.DecodeString("2b7e151628aed2a6abf7158809cf4f3c")
+	message, _ := hex.DecodeString("6bc1bee22e409f96e93d7e117393172aae2d8a571e03ac9c9eb76fac45af8e5130c81c46a35ce411")
+	for length, expected := range map[int]string{
+		0:  "bb1d6929e95937287fa37d129b756746",
+		16: "070a16b46b4d4144f79bdd9dd04a287c",
+		40: "dfa66747de9ae63030ca32611497c827",
+	} {
+		mac, err := encrypt.CMAC(encrypt.AlgorithmAES, key, message[:length])
+		if err != nil || hex.EncodeToString(mac) != expected {
+			t.Fatalf("长度%d的CMAC不正确: %v %x", length, err, mac)
+		}
+	}
+}
+
+// TestRetailMAC 测试ISO/IEC 9797-1附录B的MAC算法3样例（填充方法1）
+func TestRetailMAC(t *testing.T) {
+	key, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	for message, expected := range map[string]string{
+		"Now is the time for all ": "A1C72E74EA3FA9B6",
+		"Now is the time for it":   "2E2B1428CC78254F",
+	} {
+		mac, err := encrypt.RetailMAC(key, []byte(message))
+		if err != nil || strings.ToUpper(hex.EncodeToString(mac)) != expected {
+			t.Fatalf("%q的零售MAC不正确: %v %X", message, err, mac)
+		}
+	}
+
+	if _, err := encrypt.RetailMAC(key[:8], []byte("message")); err == nil {
+		t.Fatal("单倍长密钥应被拒绝")
+	}
+}
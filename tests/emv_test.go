@@ -0,0 +1,116 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/des"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestEMVCardKey 测试选项A卡片主密钥分散
+func TestEMVCardKey(t *testing.T) {
+	imk, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	mk, err := encrypt.DeriveEMVCardKey(imk, "5413330089020011", "00")
+	if err != nil {
+		t.Fatalf("分散卡片主密钥失败: %v", err)
+	}
+
+	// 手工按选项A计算：Y取卡号||卡序列号最右16位
+	expanded := append(append([]byte(nil), imk...), imk[:8]...)
+	block, _ := des.NewTripleDESCipher(expanded)
+	y, _ := hex.DecodeString("1333008902001100")
+	expected := make([]byte, 16)
+	block.Encrypt(expected[:8], y)
+	for i := range y {
+		y[i] ^= 0xff
+	}
+	block.Encrypt(expected[8:], y)
+	for i, b := range mk {
+		if b&0xfe != expected[i]&0xfe {
+			t.Fatalf("卡片主密钥不正确: %X", mk)
+		}
+		ones := 0
+		for v := b; v != 0; v >>= 1 {
+			ones += int(v & 1)
+		}
+		if ones%2 != 1 {
+			t.Fatalf("卡片主密钥不是奇校验: %X", mk)
+		}
+	}
+
+	if _, err := encrypt.DeriveEMVCardKey(imk, "54133300890200AA", "00"); err == nil {
+		t.Fatal("非数字卡号应当失败")
+	}
+}
+
+// TestEMVARQC 测试会话密钥派生、ARQC验证与ARPC
+func TestEMVARQC(t *testing.T) {
+	field55, _ := hex.DecodeString("9F02060000000010009F03060000000000009F1A0201569505000000000" +
+		"05F2A0201569A032610169C01009F37041234567882027C009F3602001D")
+	tags, err := encrypt.ParseEMVTLV(field55)
+	if err != nil {
+		t.Fatalf("解析TLV失败: %v", err)
+	}
+	if !bytes.Equal(tags["9F36"], []byte{0x00, 0x1d}) || len(tags["95"]) != 5 {
+		t.Fatalf("TLV标签不正确: %X", tags["9F36"])
+	}
+	data, err := encrypt.EMVTagData(tags, "9F02", "9F03", "9F1A", "95", "5F2A", "9A", "9C", "9F37", "82", "9F36")
+	if err != nil {
+		t.Fatalf("拼接交易数据失败: %v", err)
+	}
+	if _, err := encrypt.EMVTagData(tags, "9F10"); err == nil {
+		t.Fatal("缺少标签应当失败")
+	}
+
+	for _, tc := range []struct {
+		alg encrypt.Algorithm
+		key string
+	}{
+		{encrypt.Algorithm3DES, "0123456789ABCDEFFEDCBA9876543210"},
+		{encrypt.AlgorithmAES, "000102030405060708090A0B0C0D0E0F"},
+	} {
+		mk, _ := hex.DecodeString(tc.key)
+		sk, err := encrypt.DeriveEMVSessionKey(tc.alg, mk, tags["9F36"])
+		if err != nil || len(sk) != len(mk) {
+			t.Fatalf("派生会话密钥失败: %v", err)
+		}
+		other, _ := encrypt.DeriveEMVSessionKey(tc.alg, mk, []byte{0x00, 0x1e})
+		if bytes.Equal(sk, other) {
+			t.Fatal("不同ATC的会话密钥应当不同")
+		}
+
+		arqc, err := encrypt.GenerateARQC(tc.alg, sk, data)
+		if err != nil || len(arqc) != 8 {
+			t.Fatalf("生成ARQC失败: %v", err)
+		}
+		if !encrypt.VerifyARQC(tc.alg, sk, data, arqc) {
+			t.Fatal("ARQC验证失败")
+		}
+		data[0] ^= 1
+		if encrypt.VerifyARQC(tc.alg, sk, data, arqc) {
+			t.Fatal("篡改交易数据后ARQC验证应当失败")
+		}
+		data[0] ^= 1
+
+		arpc, err := encrypt.GenerateARPCMethod2(tc.alg, sk, arqc, []byte{0x00, 0x82, 0x00, 0x00}, nil)
+		if err != nil || len(arpc) != 4 {
+			t.Fatalf("生成ARPC方法2失败: %v", err)
+		}
+	}
+
+	// ARPC方法1：3DES加密 ARQC ⊕ ARC
+	sk, _ := hex.DecodeString("0123456789ABCDEFFEDCBA9876543210")
+	arqc, _ := hex.DecodeString("1122334455667788")
+	arpc, err := encrypt.GenerateARPCMethod1(sk, arqc, []byte("00"))
+	if err != nil {
+		t.Fatalf("生成ARPC方法1失败: %v", err)
+	}
+	block, _ := des.NewTripleDESCipher(append(append([]byte(nil), sk...), sk[:8]...))
+	expected, _ := hex.DecodeString("2112334455667788")
+	block.Encrypt(expected, expected)
+	if !bytes.Equal(arpc, expected) {
+		t.Fatalf("ARPC方法1不正确: %X", arpc)
+	}
+}
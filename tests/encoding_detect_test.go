@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestDetectEncoding 测试编码识别
+func TestDetectEncoding(t *testing.T) {
+	raw := []byte{0xff, 0x00, 0x10, 0x7f, 0xfb, 0xef, 0x3e, 0x01, 0x02}
+	cases := []struct {
+		input    []byte
+		expected encrypt.EncodingMode
+	}{
+		{[]byte(hex.EncodeToString(raw)), encrypt.EncodingHex},
+		{[]byte(base64.StdEncoding.EncodeToString(raw)), encrypt.EncodingBase64},
+		{[]byte(base64.RawStdEncoding.EncodeToString(raw[:7])), encrypt.EncodingBase64},
+		{[]byte(base64.URLEncoding.EncodeToString(raw)), encrypt.EncodingBase64Safe},
+		{[]byte(base64.RawURLEncoding.EncodeToString(raw[:4])), encrypt.EncodingBase64Safe},
+		{raw, encrypt.EncodingNone},
+		{[]byte("abc=def"), encrypt.EncodingNone},
+		{[]byte("a"), encrypt.EncodingNone},
+	}
+	for _, c := range cases {
+		if got := encrypt.DetectEncoding(c.input); got != c.expected {
+			t.Errorf("%q 识别为 %d，期望 %d", c.input, got, c.expected)
+		}
+		decoded, err := encrypt.DecodeAuto(c.input)
+		if err != nil {
+			t.Errorf("%q 解码失败: %v", c.input, err)
+		}
+		if c.expected != encrypt.EncodingNone && !bytes.HasPrefix(raw, decoded) {
+			t.Errorf("%q 解码结果不正确", c.input)
+		}
+	}
+
+	// 折行的Base64
+	wrapped := base64.StdEncoding.EncodeToString(bytes.Repeat(raw, 10))
+	wrapped = wrapped[:40] + "\r\n" + wrapped[40:] + "\n"
+	if decoded, err := encrypt.DecodeAuto([]byte(wrapped)); err != nil || !bytes.Equal(decoded, bytes.Repeat(raw, 10)) {
+		t.Fatalf("折行Base64解码失败: %v", err)
+	}
+}
+
+// TestAutoDecode 测试解密时自动识别密文编码
+func TestAutoDecode(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	plaintext := []byte("来自不同上游的密文")
+
+	producer, _ := encrypt.SM4(key, encrypt.WithMode(encrypt.ModeGCM), encrypt.WithEncoding(encrypt.EncodingNone))
+	ciphertext, err := producer.Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	consumer, _ := encrypt.SM4(key, encrypt.WithMode(encrypt.ModeGCM), encrypt.WithAutoDecode())
+	inputs := [][]byte{
+		ciphertext,
+		[]byte(hex.EncodeToString(ciphertext)),
+		[]byte(base64.StdEncoding.EncodeToString(ciphertext)),
+		[]byte(base64.RawURLEncoding.EncodeToString(ciphertext)),
+	}
+	for _, input := range inputs {
+		decrypted, err := consumer.Decrypt(input)
+		if err != nil || !bytes.Equal(decrypted, plaintext) {
+			t.Fatalf("自动识别解密失败: %v", err)
+		}
+	}
+	// 加密输出仍使用原有编码（默认Base64）
+	output, _ := consumer.Encrypt(plaintext)
+	if encrypt.DetectEncoding(output) != encrypt.EncodingBase64 {
+		t.Fatal("加密输出应保持Base64编码")
+	}
+
+	sm2 := encrypt.MustNewSM2()
+	if _, _, err := sm2.GenerateKeyPair(); err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+	signature, _ := sm2.Hex().Sign(plaintext)
+	if ok, err := sm2.Base64().(encrypt.IAsymmetricAutoDecoder).AutoDecode().Verify(plaintext, signature); err != nil || !ok {
+		t.Fatalf("自动识别验签失败: %v", err)
+	}
+}
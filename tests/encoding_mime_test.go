@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/pem"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestBase64Wrapped 测试折行Base64编码
+func TestBase64Wrapped(t *testing.T) {
+	data := bytes.Repeat([]byte{0xab, 0xcd, 0xef}, 100)
+
+	encoded, _ := encrypt.Base64MIME.Encode(data)
+	lines := strings.Split(string(encoded), "\r\n")
+	if len(lines) != 6 || len(lines[0]) != 76 || len(lines[5]) != 20 {
+		t.Fatalf("MIME折行不正确: %d行", len(lines))
+	}
+	// 缩进、LF与多余空白都应被忽略
+	messy := "  " + strings.ReplaceAll(string(encoded), "\r\n", "\n\t ") + "\n"
+	if decoded, err := encrypt.Base64MIME.Decode([]byte(messy)); err != nil || !bytes.Equal(decoded, data) {
+		t.Fatalf("宽松解码失败: %v", err)
+	}
+
+	// PEM正文可以被标准库解析
+	body := encrypt.EncodePEMBody(data)
+	block, _ := pem.Decode([]byte("-----BEGIN DATA-----\n" + string(body) + "-----END DATA-----\n"))
+	if block == nil || !bytes.Equal(block.Bytes, data) {
+		t.Fatal("PEM正文不正确")
+	}
+	if expected := pem.EncodeToMemory(&pem.Block{Type: "DATA", Bytes: data}); !bytes.Contains(expected, body) {
+		t.Fatal("PEM正文应与标准库一致")
+	}
+	if len(encrypt.EncodePEMBody(nil)) != 0 {
+		t.Fatal("空数据应得到空正文")
+	}
+}
+
+// TestBase64MIMEEncryptor 测试加密器使用折行Base64
+func TestBase64MIMEEncryptor(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 16)
+	plaintext := bytes.Repeat([]byte("SOAP payload "), 20)
+
+	aes, _ := encrypt.AES(key, encrypt.WithMode(encrypt.ModeGCM), encrypt.WithEncoding(encrypt.EncodingBase64MIME))
+	ciphertext, err := aes.Encrypt(plaintext)
+	if err != nil || !bytes.Contains(ciphertext, []byte("\r\n")) {
+		t.Fatalf("加密输出应折行: %v", err)
+	}
+	if decrypted, err := aes.Decrypt(ciphertext); err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	sm4 := encrypt.MustNewSM4(key).(encrypt.IGCMSetter).GCM().(encrypt.IWrappedEncoder).Base64PEM()
+	ciphertext, _ = sm4.Encrypt(plaintext)
+	for _, line := range strings.Split(string(ciphertext), "\n") {
+		if len(line) > 64 {
+			t.Fatalf("PEM行宽超过64: %d", len(line))
+		}
+	}
+	if decrypted, err := sm4.Decrypt(ciphertext); err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("解密失败: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// stuckSource 在输出指定数量的正常样本后卡在固定值
+type stuckSource struct {
+	good int
+	n    int
+}
+
+func (s *stuckSource) Read(p []byte) (int, error) {
+	for i := range p {
+		if s.n < s.good {
+			p[i] = byte(s.n*7 + s.n/256)
+		} else {
+			p[i] = 0xAA
+		}
+		s.n++
+	}
+	return len(p), nil
+}
+
+func (s *stuckSource) MinEntropy() float64 { return 8 }
+
+// TestHealthTestedSourceStartup 测试启动测试拒绝故障熵源
+func TestHealthTestedSourceStartup(t *testing.T) {
+	_, err := encrypt.NewHealthTestedSource(&stuckSource{good: 100})
+	if !errors.Is(err, encrypt.ErrEntropyHealth) {
+		t.Fatalf("故障熵源应无法通过启动测试: %v", err)
+	}
+
+	if _, err := encrypt.NewHealthTestedSource(encrypt.OSEntropySource); err != nil {
+		t.Fatalf("操作系统熵源应通过启动测试: %v", err)
+	}
+}
+
+// TestHealthTestedSourceFailure 测试运行中故障的报警与回调
+func TestHealthTestedSourceFailure(t *testing.T) {
+	source, err := encrypt.NewHealthTestedSource(&stuckSource{good: 1100})
+	if err != nil {
+		t.Fatalf("启动测试失败: %v", err)
+	}
+
+	var reported *encrypt.EntropyHealthError
+	source.OnFailure(func(e *encrypt.EntropyHealthError) { reported = e })
+
+	buf := make([]byte, 1024)
+	if _, err := source.Read(buf); err == nil {
+		t.Fatalf("熵源卡死后应报警")
+	}
+	if reported == nil || reported.Test != "RCT" || reported.Sample != 0xAA {
+		t.Fatalf("故障回调不正确: %+v", reported)
+	}
+	if _, err := source.Read(buf); !errors.Is(err, encrypt.ErrEntropyHealth) {
+		t.Fatalf("故障后应持续返回错误: %v", err)
+	}
+}
+
+// TestMixedEntropySource 测试混合熵源作为默认随机数生成器
+func TestMixedEntropySource(t *testing.T) {
+	if err := encrypt.SetEntropySource(encrypt.OSEntropySource, encrypt.NewJitterEntropySource()); err != nil {
+		t.Fatalf("设置熵源失败: %v", err)
+	}
+	defer encrypt.SetRandomReader(nil)
+
+	a, err := encrypt.GenerateRandomBytes(100)
+	if err != nil {
+		t.Fatalf("生成随机数失败: %v", err)
+	}
+	b, _ := encrypt.GenerateRandomBytes(100)
+	if string(a) == string(b) {
+		t.Fatalf("两次随机输出不应相同")
+	}
+}
+
+// TestEntropySourceCoversIV 测试IV与nonce生成遵循SetRandomReader与SetEntropySource
+func TestEntropySourceCoversIV(t *testing.T) {
+	defer encrypt.SetRandomReader(nil)
+	key, _ := encrypt.GenerateRandomBytes(16)
+	cipher, err := encrypt.NewSmallCipher(encrypt.AlgorithmAES, key)
+	if err != nil {
+		t.Fatalf("创建加密器失败: %v", err)
+	}
+
+	iv := bytes.Repeat([]byte{0x5A}, 16)
+	encrypt.SetRandomReader(bytes.NewReader(iv))
+	out, err := cipher.Encrypt(nil, []byte("hello"))
+	if err != nil || !bytes.Equal(out[:16], iv) {
+		t.Fatalf("IV应来自设置的随机数生成器: %v %x", err, out)
+	}
+
+	// 启动测试之后立即卡死的熵源
+	if err := encrypt.SetEntropySource(&stuckSource{good: 1024}); err != nil {
+		t.Fatalf("设置熵源失败: %v", err)
+	}
+	if _, err := cipher.Encrypt(nil, []byte("hello")); !errors.Is(err, encrypt.ErrEntropyHealth) {
+		t.Fatalf("熵源故障时生成IV应失败: %v", err)
+	}
+}
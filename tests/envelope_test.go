@@ -0,0 +1,172 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/aes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestEnvelope 测试版本化信封的加解密
+func TestEnvelope(t *testing.T) {
+	plaintext := []byte("存储多年的数据")
+
+	testCases := []struct {
+		name      string
+		algorithm encrypt.Algorithm
+		mode      encrypt.Mode
+		key       []byte
+	}{
+		{"AES-CBC", encrypt.AlgorithmAES, encrypt.ModeCBC, []byte("0123456789ABCDEF")},
+		{"AES-GCM", encrypt.AlgorithmAES, encrypt.ModeGCM, []byte("0123456789ABCDEF")},
+		{"SM4-CTR", encrypt.AlgorithmSM4, encrypt.ModeCTR, []byte("0123456789ABCDEF")},
+		{"3DES-ECB", encrypt.Algorithm3DES, encrypt.ModeECB, []byte("0123456789ABCDEFGHIJKLMN")},
+		{"3DES-CBC", encrypt.Algorithm3DES, encrypt.ModeCBC, []byte("0123456789ABCDEFGHIJKLMN")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sealed, err := encrypt.SealEnvelope(tc.algorithm, tc.mode, tc.key, plaintext)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+
+			env, err := encrypt.ParseEnvelope(sealed)
+			if err != nil {
+				t.Fatalf("解析信封失败: %v", err)
+			}
+			if env.Version != encrypt.CurrentFormatVersion || env.Algorithm != tc.algorithm || env.Mode != tc.mode {
+				t.Fatalf("信封头部不正确: %+v", env)
+			}
+			// IV只写入头部，密文中不再重复
+			if len(env.IV) > 0 && bytes.HasPrefix(env.Ciphertext, env.IV) {
+				t.Fatal("密文中不应再包含IV")
+			}
+
+			opened, err := encrypt.OpenEnvelope(tc.key, sealed, nil)
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Fatalf("解密失败: %v", err)
+			}
+		})
+	}
+}
+
+// TestUpgradeCiphertext 测试旧格式密文的解密与迁移
+func TestUpgradeCiphertext(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	plaintext := []byte("旧版本写入的数据")
+
+	// 旧格式：链式API未设置IV，输出 IV||密文
+	aes := encrypt.MustNewAES(key).CBC().NoEncoding()
+	embedded, err := aes.Encrypt(plaintext)
+	aes.Release()
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	// 旧格式：SM4设置WithIV，IV单独保存
+	iv := []byte("fedcba9876543210")
+	sm4 := encrypt.MustNewSM4(key).CBC().WithIV(iv).NoEncoding()
+	detached, err := sm4.Encrypt(plaintext)
+	sm4.Release()
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	testCases := []struct {
+		name   string
+		data   []byte
+		legacy *encrypt.LegacyFormat
+	}{
+		{"IV内嵌", embedded, &encrypt.LegacyFormat{
+			Version: encrypt.FormatLegacyEmbedded, Algorithm: encrypt.AlgorithmAES,
+			Mode: encrypt.ModeCBC, Padding: encrypt.PaddingPKCS7,
+		}},
+		{"IV分离", detached, &encrypt.LegacyFormat{
+			Version: encrypt.FormatLegacyDetached, Algorithm: encrypt.AlgorithmSM4,
+			Mode: encrypt.ModeCBC, Padding: encrypt.PaddingPKCS7, IV: iv,
+		}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := encrypt.OpenEnvelope(key, tc.data, nil); err == nil {
+				t.Fatalf("缺少旧格式描述时应该失败")
+			}
+
+			opened, err := encrypt.OpenEnvelope(key, tc.data, tc.legacy)
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Fatalf("解密旧格式失败: %v", err)
+			}
+
+			upgraded, err := encrypt.UpgradeCiphertext(key, tc.data, tc.legacy)
+			if err != nil {
+				t.Fatalf("迁移失败: %v", err)
+			}
+			if !encrypt.IsEnvelope(upgraded) {
+				t.Fatalf("迁移结果应该是信封格式")
+			}
+
+			// 已是当前版本的数据原样返回
+			again, err := encrypt.UpgradeCiphertext(key, upgraded, nil)
+			if err != nil || !bytes.Equal(again, upgraded) {
+				t.Fatalf("重复迁移应该原样返回: %v", err)
+			}
+
+			opened, err = encrypt.OpenEnvelope(key, upgraded, nil)
+			if err != nil || !bytes.Equal(opened, plaintext) {
+				t.Fatalf("解密迁移结果失败: %v", err)
+			}
+		})
+	}
+}
+
+// TestEnvelopeLegacyMagicCollision 旧格式密文恰好以信封魔数开头时按旧格式解密与迁移
+func TestEnvelopeLegacyMagicCollision(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	plaintext := []byte("legacy record with 32 bytes.....")
+
+	// 选择IV使CBC密文第一块恰好是一个可以解析的信封头部
+	header := []byte{0x53, 0x45, byte(encrypt.FormatV1), byte(encrypt.AlgorithmAES), byte(encrypt.ModeECB), byte(encrypt.PaddingPKCS7), 0}
+	header = append(header, bytes.Repeat([]byte{'x'}, aes.BlockSize-len(header))...)
+	block, _ := aes.NewCipher(key)
+	iv := make([]byte, aes.BlockSize)
+	block.Decrypt(iv, header)
+	for i := range iv {
+		iv[i] ^= plaintext[i]
+	}
+
+	encryptor := encrypt.MustNewAES(key).CBC().WithIV(append([]byte(nil), iv...)).NoEncoding()
+	legacyData, err := encryptor.Encrypt(plaintext)
+	encryptor.Release()
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if !bytes.HasPrefix(legacyData, header) {
+		t.Fatal("构造的旧格式密文应以信封头部开头")
+	}
+	if _, err := encrypt.ParseEnvelope(legacyData); err != nil {
+		t.Fatalf("构造的头部应能被解析: %v", err)
+	}
+
+	legacy := &encrypt.LegacyFormat{
+		Version: encrypt.FormatLegacyDetached, Algorithm: encrypt.AlgorithmAES,
+		Mode: encrypt.ModeCBC, Padding: encrypt.PaddingPKCS7, IV: iv,
+	}
+	opened, err := encrypt.OpenEnvelope(key, legacyData, legacy)
+	if err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("应回退到旧格式解密: %v", err)
+	}
+
+	upgraded, err := encrypt.UpgradeCiphertext(key, legacyData, legacy)
+	if err != nil {
+		t.Fatalf("迁移失败: %v", err)
+	}
+	if bytes.Equal(upgraded, legacyData) {
+		t.Fatal("旧格式密文不应被当作当前版本原样返回")
+	}
+	if opened, err := encrypt.OpenEnvelope(key, upgraded, nil); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("解密迁移结果失败: %v", err)
+	}
+}
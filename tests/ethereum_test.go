@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestKeccak256 测试Keccak-256（与SHA3-256不同）
+func TestKeccak256(t *testing.T) {
+	if got := hex.EncodeToString(encrypt.Keccak256(nil)); got != "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470" {
+		t.Fatalf("空输入的Keccak-256不正确: %s", got)
+	}
+	if got := hex.EncodeToString(encrypt.Keccak256([]byte("hello"))); got != "1c8aff950685c2ed4bc3174f3472287b56d9517b9c948127319a09a7a36deac8" {
+		t.Fatalf("Keccak-256不正确: %s", got)
+	}
+}
+
+// TestEthereumPersonalSign 使用web3.js文档中的示例验证personal_sign
+func TestEthereumPersonalSign(t *testing.T) {
+	signer, err := encrypt.NewEthereumSignerFromHex("0x4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	if err != nil {
+		t.Fatalf("创建签名器失败: %v", err)
+	}
+	if signer.Address() != "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23" {
+		t.Fatalf("地址不正确: %s", signer.Address())
+	}
+
+	message := []byte("Some data")
+	if got := hex.EncodeToString(encrypt.EthereumMessageHash(message)); got != "1da44b586eb0729ff70a73c326926f6ed5a25f5b056e7f47fbc6e58d86871655" {
+		t.Fatalf("消息摘要不正确: %s", got)
+	}
+
+	sig, err := signer.PersonalSign(message)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	expected := "b91467e570a6466aa9e9876cbcd013baba02900b8979d43fe208a4a4f339f5fd6007e74cd82e037b800186422fc2da167c747ef045e5d18a5f5d4300f8e1a0291c"
+	if hex.EncodeToString(sig) != expected {
+		t.Fatalf("签名与web3.js不一致: %x", sig)
+	}
+
+	if !encrypt.VerifyPersonalSign("0x2c7536e3605d9c16a7a3d7b1898e529396a65c23", message, sig) {
+		t.Fatalf("验证签名失败")
+	}
+	if encrypt.VerifyPersonalSign(signer.Address(), []byte("Other data"), sig) {
+		t.Fatalf("不同消息不应验证通过")
+	}
+}
+
+// TestEthereumSignHash 测试摘要签名与地址恢复
+func TestEthereumSignHash(t *testing.T) {
+	signer, err := encrypt.GenerateEthereumSigner()
+	if err != nil {
+		t.Fatalf("生成私钥失败: %v", err)
+	}
+
+	hash := encrypt.Keccak256([]byte("transaction"))
+	sig, err := signer.SignHash(hash)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if sig[64] > 1 {
+		t.Fatalf("SignHash的V应为0或1，实际为%d", sig[64])
+	}
+
+	address, err := encrypt.RecoverEthereumAddress(hash, sig)
+	if err != nil || address != signer.Address() {
+		t.Fatalf("恢复地址失败: %v %s", err, address)
+	}
+
+	restored, err := encrypt.NewEthereumSigner(signer.PrivateKey())
+	if err != nil || restored.Address() != signer.Address() {
+		t.Fatalf("从私钥恢复签名器失败: %v", err)
+	}
+}
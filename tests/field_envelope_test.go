@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestFieldEnvelope 测试按角色分字段加密与部分解密
+func TestFieldEnvelope(t *testing.T) {
+	ctx := context.Background()
+	financeKey := encrypt.KeyEntry{ID: "kek-finance", Algorithm: encrypt.AlgorithmAES, Key: bytes.Repeat([]byte{1}, 32), Usage: encrypt.KeyUsageCipher}
+	supportKey := encrypt.KeyEntry{ID: "kek-support", Algorithm: encrypt.AlgorithmSM4, Key: bytes.Repeat([]byte{2}, 16), Usage: encrypt.KeyUsageCipher}
+
+	all := encrypt.NewKeyRing()
+	_ = all.Add(financeKey)
+	_ = all.Add(supportKey)
+	support := encrypt.NewKeyRing()
+	_ = support.Add(supportKey)
+
+	envelope := encrypt.NewFieldEnvelope(all).
+		WithRole("finance", "kek-finance", "card_no", "amount").
+		WithRole("support", "kek-support", "phone")
+	record := map[string][]byte{
+		"card_no": []byte("6222000011112222"),
+		"amount":  []byte("199.00"),
+		"phone":   []byte("13800000000"),
+	}
+	aad := []byte("order-42")
+	sealed, err := envelope.Seal(ctx, record, aad)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if bytes.Contains(sealed, record["card_no"]) {
+		t.Fatal("密文中不应包含明文")
+	}
+
+	values, err := encrypt.OpenFields(ctx, all, sealed, aad)
+	if err != nil || len(values) != 3 || !bytes.Equal(values["card_no"], record["card_no"]) {
+		t.Fatalf("全部解密失败: %v", err)
+	}
+
+	// 客服只能解密自己的字段
+	values, err = encrypt.OpenFields(ctx, support, sealed, aad, "phone")
+	if err != nil || len(values) != 1 || !bytes.Equal(values["phone"], record["phone"]) {
+		t.Fatalf("部分解密失败: %v", err)
+	}
+	if _, err := encrypt.OpenFields(ctx, support, sealed, aad, "card_no"); !errors.Is(err, encrypt.ErrFieldAccessDenied) {
+		t.Fatalf("越权解密应被拒绝: %v", err)
+	}
+	values, denied, err := encrypt.OpenAccessibleFields(ctx, support, sealed, aad)
+	if err != nil || len(values) != 1 || strings.Join(denied, ",") != "amount,card_no" {
+		t.Fatalf("可访问字段不正确: %v %v", denied, err)
+	}
+
+	roles, _ := encrypt.FieldEnvelopeRoles(sealed)
+	if roles["amount"] != "finance" || roles["phone"] != "support" {
+		t.Fatalf("字段角色不正确: %v", roles)
+	}
+
+	// 附加认证数据不一致或字段被挪动时解密失败
+	if _, err := encrypt.OpenFields(ctx, all, sealed, []byte("order-43")); err == nil {
+		t.Fatal("附加认证数据不一致应解密失败")
+	}
+	swapped := bytes.Replace(sealed, []byte(`"amount"`), []byte(`"amoun_"`), 1)
+	swapped = bytes.Replace(swapped, []byte(`"card_no"`), []byte(`"amount"`), 1)
+	swapped = bytes.Replace(swapped, []byte(`"amoun_"`), []byte(`"card_no"`), 1)
+	if _, err := encrypt.OpenFields(ctx, all, swapped, aad); err == nil {
+		t.Fatal("交换字段的密文应解密失败")
+	}
+
+	if _, err := envelope.Seal(ctx, map[string][]byte{"email": nil}, aad); err == nil {
+		t.Fatal("未声明角色的字段应报错")
+	}
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestPublicKeyFingerprint 测试公钥指纹计算与渲染
+func TestPublicKeyFingerprint(t *testing.T) {
+	sm2Encryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	pubKey, _, err := sm2Encryptor.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+
+	for _, algo := range []encrypt.HashAlgorithm{encrypt.HashSHA256, encrypt.HashSM3} {
+		fromPEM, err := encrypt.PublicKeyFingerprint(pubKey, algo)
+		if err != nil {
+			t.Fatalf("计算指纹失败: %v", err)
+		}
+		fromEncryptor, err := sm2Encryptor.Fingerprint(algo)
+		if err != nil {
+			t.Fatalf("计算指纹失败: %v", err)
+		}
+		if !bytes.Equal(fromPEM, fromEncryptor) || len(fromPEM) != 32 {
+			t.Fatalf("指纹不一致")
+		}
+	}
+
+	// RSA的PKCS#1公钥与加密器计算结果一致
+	rsaEncryptor := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+	rsaPub, _, err := rsaEncryptor.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	fromPEM, err := encrypt.PublicKeyFingerprint(rsaPub, encrypt.HashSHA256)
+	if err != nil {
+		t.Fatalf("计算指纹失败: %v", err)
+	}
+	fromEncryptor, _ := rsaEncryptor.Fingerprint(encrypt.HashSHA256)
+	if !bytes.Equal(fromPEM, fromEncryptor) {
+		t.Fatalf("RSA指纹不一致")
+	}
+
+	if got := encrypt.FormatFingerprint([]byte{0xab, 0x01}); got != "AB:01" {
+		t.Errorf("格式化指纹错误: %s", got)
+	}
+	if got := encrypt.ShortFingerprint([]byte{0xab, 0xcd, 0xef, 0x01, 0x23, 0x45, 0x67, 0x89, 0xff}); got != "ABCD EF01 2345 6789" {
+		t.Errorf("短指纹错误: %s", got)
+	}
+
+	art := encrypt.RandomArt(fromPEM, "RSA 2048")
+	lines := strings.Split(art, "\n")
+	if len(lines) != 11 || !strings.Contains(lines[0], "[RSA 2048]") || !strings.Contains(art, "S") {
+		t.Errorf("随机图格式错误:\n%s", art)
+	}
+}
@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestFirmwareSignVerify 测试固件镜像签名与验证
+func TestFirmwareSignVerify(t *testing.T) {
+	edPub, edPriv := newEd25519PEM(t)
+	sm2Pub, sm2Priv, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	sections := []encrypt.FirmwareSection{
+		{Name: "bootloader", Data: bytes.Repeat([]byte{0xB0}, 1000)},
+		{Name: "kernel", Data: bytes.Repeat([]byte{0x4B}, 4096)},
+		{Name: "rootfs", Data: bytes.Repeat([]byte{0x52}, 3000)},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		pub      []byte
+		priv     []byte
+		hashAlgo encrypt.HashAlgorithm
+	}{
+		{"Ed25519-SHA256", edPub, edPriv, encrypt.HashSHA256},
+		{"SM2-SM3", sm2Pub, sm2Priv, encrypt.HashSM3},
+	} {
+		signer, _ := encrypt.ParseSigner(tc.priv)
+		image, err := encrypt.SignFirmware(signer, "2.4.1", 42, sections, tc.hashAlgo)
+		if err != nil {
+			t.Fatalf("%s签名失败: %v", tc.name, err)
+		}
+
+		verifier, _ := encrypt.NewFirmwareVerifier(tc.pub)
+		manifest, err := verifier.WithMinCounter(41).Verify(image)
+		if err != nil {
+			t.Fatalf("%s验证失败: %v", tc.name, err)
+		}
+		if manifest.Version != "2.4.1" || manifest.Counter != 42 || len(manifest.Sections) != 3 {
+			t.Fatalf("%s头部信息不正确: %+v", tc.name, manifest)
+		}
+
+		// 相同计数器允许重新安装，更小的计数器视为回滚
+		if _, err := verifier.WithMinCounter(42).Verify(image); err != nil {
+			t.Fatalf("%s相同计数器应当允许: %v", tc.name, err)
+		}
+		if _, err := verifier.WithMinCounter(43).Verify(image); !errors.Is(err, encrypt.ErrFirmwareRollback) {
+			t.Fatalf("%s回滚应当失败: %v", tc.name, err)
+		}
+		verifier.WithMinCounter(0)
+
+		// 分区数据被修改
+		tampered := append([]byte(nil), image...)
+		tampered[len(tampered)-1] ^= 1
+		if _, err := verifier.Verify(tampered); err == nil {
+			t.Fatalf("%s分区数据被修改应当失败", tc.name)
+		}
+		// 头部计数器被修改
+		tampered = append([]byte(nil), image...)
+		tampered[13] ^= 1
+		if _, err := verifier.Verify(tampered); err == nil {
+			t.Fatalf("%s头部被修改应当失败", tc.name)
+		}
+		if _, err := verifier.Verify(image[:len(image)-1]); err == nil {
+			t.Fatalf("%s截断的镜像应当失败", tc.name)
+		}
+
+		// 边接收边校验：先验证头部，再逐个校验分区
+		header, err := verifier.VerifyHeader(image[:manifest.HeaderSize])
+		if err != nil {
+			t.Fatalf("%s仅验证头部失败: %v", tc.name, err)
+		}
+		if err := header.VerifySection("kernel", sections[1].Data); err != nil {
+			t.Fatalf("%s分区校验失败: %v", tc.name, err)
+		}
+		if err := header.VerifySection("kernel", sections[2].Data); err == nil {
+			t.Fatalf("%s错误的分区数据应当失败", tc.name)
+		}
+	}
+
+	otherPub, _ := newEd25519PEM(t)
+	signer, _ := encrypt.ParseSigner(edPriv)
+	image, _ := encrypt.SignFirmware(signer, "1.0.0", 1, sections[:1], encrypt.HashSHA256)
+	verifier, _ := encrypt.NewFirmwareVerifier(otherPub)
+	if _, err := verifier.Verify(image); err == nil {
+		t.Fatal("其他公钥应当验证失败")
+	}
+	if _, err := encrypt.SignFirmware(signer, "1.0.0", 1, []encrypt.FirmwareSection{{Name: "a"}, {Name: "a"}}, encrypt.HashSHA256); err == nil {
+		t.Fatal("重复的分区名称应当失败")
+	}
+}
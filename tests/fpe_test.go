@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestFF1Vectors 测试NIST SP 800-38G FF1样例
+func TestFF1Vectors(t *testing.T) {
+	cases := []struct {
+		key, tweak, alphabet, plaintext, ciphertext string
+	}{
+		{"2b7e151628aed2a6abf7158809cf4f3c", "", encrypt.FPEDigits, "0123456789", "2433477484"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", "39383736353433323130", encrypt.FPEDigits, "0123456789", "6124200773"},
+		{"2b7e151628aed2a6abf7158809cf4f3c", "3737373770717273373737", encrypt.FPEAlphanumeric, "0123456789abcdefghi", "a9tv40mll9kdu509eum"},
+		{"2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f", "", encrypt.FPEDigits, "0123456789", "2830668132"},
+		{"2b7e151628aed2a6abf7158809cf4f3cef4359d8d580aa4f7f036d6f04fc6a94", "3737373770717273373737", encrypt.FPEAlphanumeric, "0123456789abcdefghi", "xs8a0azh2avyalyzuwd"},
+	}
+	for _, c := range cases {
+		key, _ := hex.DecodeString(c.key)
+		tweak, _ := hex.DecodeString(c.tweak)
+		fpe, err := encrypt.NewFPE(encrypt.AlgorithmAES, key, c.alphabet)
+		if err != nil {
+			t.Fatalf("创建FF1失败: %v", err)
+		}
+		ciphertext, err := fpe.Encrypt(c.plaintext, tweak)
+		if err != nil || ciphertext != c.ciphertext {
+			t.Fatalf("加密结果不正确: %v %s，期望%s", err, ciphertext, c.ciphertext)
+		}
+		plaintext, err := fpe.Decrypt(ciphertext, tweak)
+		if err != nil || plaintext != c.plaintext {
+			t.Fatalf("解密结果不正确: %v %s", err, plaintext)
+		}
+	}
+}
+
+// TestFF1Limits 测试FF1长度与字符表限制
+func TestFF1Limits(t *testing.T) {
+	key, _ := encrypt.GenerateRandomBytes(16)
+	fpe, err := encrypt.NewFPE(encrypt.AlgorithmSM4, key, encrypt.FPEDigits)
+	if err != nil {
+		t.Fatalf("创建FF1失败: %v", err)
+	}
+	if fpe.MinLength() != 6 {
+		t.Fatalf("十进制最小长度应为6，实际为%d", fpe.MinLength())
+	}
+	if _, err := fpe.Encrypt("12345", nil); err == nil {
+		t.Fatal("过短的输入应被拒绝")
+	}
+	if _, err := fpe.Encrypt("12345a", nil); err == nil {
+		t.Fatal("字符表外的字符应被拒绝")
+	}
+	if _, err := encrypt.NewFPE(encrypt.AlgorithmAES, make([]byte, 16), "0012"); err == nil {
+		t.Fatal("重复字符应被拒绝")
+	}
+}
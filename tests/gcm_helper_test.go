@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestGCMHelpers 测试无状态GCM便捷函数
+func TestGCMHelpers(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	plaintext := []byte("一次调用完成加密")
+	aad := []byte("user-42")
+
+	testCases := []struct {
+		name    string
+		encrypt func(key, plaintext, aad []byte) ([]byte, error)
+		decrypt func(key, ciphertext, aad []byte) ([]byte, error)
+	}{
+		{"AES", encrypt.AESGCMEncrypt, encrypt.AESGCMDecrypt},
+		{"SM4", encrypt.SM4GCMEncrypt, encrypt.SM4GCMDecrypt},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ciphertext, err := tc.encrypt(key, plaintext, aad)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+
+			decrypted, err := tc.decrypt(key, ciphertext, aad)
+			if err != nil || !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("解密失败: %v", err)
+			}
+
+			if _, err := tc.decrypt(key, ciphertext, []byte("user-43")); err == nil {
+				t.Fatalf("aad不一致时应该解密失败")
+			}
+		})
+	}
+
+	// 与SM4链式API的GCM输出格式兼容
+	chained, err := encrypt.MustNewSM4(key).(encrypt.IGCMSetter).GCM().NoEncoding().Encrypt(plaintext)
+	if err != nil {
+		t.Fatalf("链式加密失败: %v", err)
+	}
+	decrypted, err := encrypt.SM4GCMDecrypt(key, chained, nil)
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("解密链式API密文失败: %v", err)
+	}
+}
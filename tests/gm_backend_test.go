@@ -0,0 +1,39 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestGMBackend 测试国密后端注册与切换
+func TestGMBackend(t *testing.T) {
+	if encrypt.CurrentGMBackend() == nil {
+		t.Fatalf("未设置默认国密后端")
+	}
+
+	found := false
+	for _, name := range encrypt.GMBackends() {
+		if name == "tjfoc" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("tjfoc后端未注册")
+	}
+
+	if err := encrypt.UseGMBackend("not-exist"); err == nil {
+		t.Fatalf("切换到未注册的后端应该返回错误")
+	}
+
+	previous := encrypt.CurrentGMBackend().Name()
+	defer encrypt.UseGMBackend(previous)
+	if err := encrypt.UseGMBackend("tjfoc"); err != nil {
+		t.Fatalf("切换后端失败: %v", err)
+	}
+
+	sum, err := encrypt.NewSM3().Hex().SumString("abc")
+	if err != nil || sum != "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0" {
+		t.Fatalf("SM3计算错误: %v, %s", err, sum)
+	}
+}
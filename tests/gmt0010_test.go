@@ -0,0 +1,118 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+	gmx509 "github.com/tjfoc/gmsm/x509"
+)
+
+// newSM2CertPEM 生成自签名SM2证书与私钥
+func newSM2CertPEM(t *testing.T, serial int64) ([]byte, []byte) {
+	t.Helper()
+	sm2Encryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	pubKey, privKey, err := sm2Encryptor.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+	signer, err := sm2Encryptor.Signer()
+	if err != nil {
+		t.Fatalf("获取SM2 Signer失败: %v", err)
+	}
+	sm2PubKey, err := gmx509.ReadPublicKeyFromPem(pubKey)
+	if err != nil {
+		t.Fatalf("解析SM2公钥失败: %v", err)
+	}
+	template := &gmx509.Certificate{
+		SerialNumber:       big.NewInt(serial),
+		Subject:            pkix.Name{CommonName: "CFCA TEST"},
+		NotBefore:          time.Now(),
+		NotAfter:           time.Now().Add(time.Hour),
+		SignatureAlgorithm: gmx509.SM2WithSM3,
+	}
+	certPEM, err := gmx509.CreateCertificateToPem(template, template, sm2PubKey, signer)
+	if err != nil {
+		t.Fatalf("创建SM2证书失败: %v", err)
+	}
+	return certPEM, privKey
+}
+
+// TestSM2Envelope 测试GM/T 0010数字信封
+func TestSM2Envelope(t *testing.T) {
+	certA, keyA := newSM2CertPEM(t, 1)
+	certB, keyB := newSM2CertPEM(t, 2)
+	certC, keyC := newSM2CertPEM(t, 3)
+	plaintext := []byte("转账指令：账户6222****0001，金额100.00元")
+
+	envelope, err := encrypt.SealSM2Envelope(plaintext, certA, certB)
+	if err != nil {
+		t.Fatalf("生成数字信封失败: %v", err)
+	}
+	for _, recipient := range [][2][]byte{{certA, keyA}, {certB, keyB}} {
+		opened, err := encrypt.OpenSM2Envelope(envelope, recipient[0], recipient[1])
+		if err != nil || !bytes.Equal(opened, plaintext) {
+			t.Fatalf("解开数字信封失败: %v", err)
+		}
+	}
+
+	// 工具包常以Base64文本输出
+	encoded := []byte(base64.StdEncoding.EncodeToString(envelope))
+	if opened, err := encrypt.OpenSM2Envelope(encoded, certA, keyA); err != nil || !bytes.Equal(opened, plaintext) {
+		t.Fatalf("解开Base64数字信封失败: %v", err)
+	}
+	if _, err := encrypt.OpenSM2Envelope(envelope, certC, keyC); err == nil {
+		t.Fatal("非接收者应当无法解开")
+	}
+	if _, err := encrypt.OpenSM2Envelope(envelope, certA, keyB); err == nil {
+		t.Fatal("私钥与证书不匹配应当失败")
+	}
+	if _, err := encrypt.SealSM2Envelope(plaintext); err == nil {
+		t.Fatal("没有接收者应当失败")
+	}
+}
+
+// TestSM2SignedData 测试GM/T 0010签名数据
+func TestSM2SignedData(t *testing.T) {
+	cert, key := newSM2CertPEM(t, 7)
+	content := []byte("签名原文")
+
+	attached, err := encrypt.SignSM2Data(content, cert, key, false)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	result, err := encrypt.VerifySM2SignedData(attached, nil)
+	if err != nil || !bytes.Equal(result.Content, content) || len(result.Signers) != 1 {
+		t.Fatalf("验证带原文签名失败: %v", err)
+	}
+	if result.Signers[0].SerialNumber.Int64() != 7 {
+		t.Fatal("签名者证书不正确")
+	}
+
+	detached, err := encrypt.SignSM2Data(content, cert, key, true)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if _, err := encrypt.VerifySM2SignedData(detached, nil); err == nil {
+		t.Fatal("分离式签名缺少原文应当失败")
+	}
+	if _, err := encrypt.VerifySM2SignedData(detached, content); err != nil {
+		t.Fatalf("验证分离式签名失败: %v", err)
+	}
+	if _, err := encrypt.VerifySM2SignedData(detached, []byte("篡改的原文")); err == nil {
+		t.Fatal("原文被篡改应当失败")
+	}
+	encoded := []byte(base64.StdEncoding.EncodeToString(detached))
+	if _, err := encrypt.VerifySM2SignedData(encoded, content); err != nil {
+		t.Fatalf("验证Base64签名失败: %v", err)
+	}
+
+	envelope, _ := encrypt.SealSM2Envelope(content, cert)
+	if _, err := encrypt.VerifySM2SignedData(envelope, content); err == nil {
+		t.Fatal("数字信封不应当作签名数据")
+	}
+}
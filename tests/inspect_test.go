@@ -0,0 +1,76 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestInspect 测试密文诊断
+func TestInspect(t *testing.T) {
+	key := bytes.Repeat([]byte{3}, 32)
+	secret := []byte("top secret value")
+
+	// 版本化信封（CBC）
+	sealed, _ := encrypt.SealEnvelope(encrypt.AlgorithmAES, encrypt.ModeCBC, key, secret)
+	info := encrypt.Inspect(sealed)
+	if info.Format != encrypt.InspectFormatEnvelope || info.Algorithm != "AES" || info.Mode != "CBC" ||
+		info.Padding != "PKCS7" || len(info.Nonce) != 16 || info.HasTag || info.BodySize != 32 {
+		t.Fatalf("信封解析不正确: %+v", info)
+	}
+
+	// Base64外层编码的GCM信封
+	sealed, _ = encrypt.SealEnvelope(encrypt.AlgorithmSM4, encrypt.ModeGCM, key[:16], secret)
+	info = encrypt.Inspect([]byte(base64.StdEncoding.EncodeToString(sealed)))
+	if info.Encoding != encrypt.EncodingBase64 || info.Algorithm != "SM4" || !info.HasTag ||
+		len(info.Nonce) != 12 || info.BodySize != len(secret) {
+		t.Fatalf("GCM信封解析不正确: %+v", info)
+	}
+
+	// 密钥环
+	ring := encrypt.NewKeyRing()
+	_ = ring.Add(encrypt.KeyEntry{ID: "data-2024", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher})
+	ciphertext, _ := ring.Encrypt(secret, nil)
+	info = encrypt.Inspect(ciphertext)
+	if info.Format != encrypt.InspectFormatKeyRing || info.KeyID != "data-2024" || info.BodySize != len(secret) {
+		t.Fatalf("密钥环密文解析不正确: %+v", info)
+	}
+
+	// KMS数据密钥信封
+	enveloped, err := encrypt.EnvelopeEncrypt(context.Background(), ring, "data-2024", secret, nil)
+	if err != nil {
+		t.Fatalf("信封加密失败: %v", err)
+	}
+	info = encrypt.Inspect(enveloped)
+	if info.Format != encrypt.InspectFormatKMSEnvelope || info.KeyID != "data-2024" || info.BodySize != len(secret) {
+		t.Fatalf("KMS信封解析不正确: %+v", info)
+	}
+
+	// 分块GCM
+	chunked, _ := encrypt.ChunkedEncrypt(encrypt.AlgorithmAES, key, bytes.Repeat([]byte{1}, 100), 64)
+	info = encrypt.Inspect(chunked)
+	if info.Format != encrypt.InspectFormatChunked || info.BodySize != 100 {
+		t.Fatalf("分块密文解析不正确: %+v", info)
+	}
+
+	// 配置密文
+	config, _ := encrypt.SealConfigSecretWithParams([]byte("pw"), secret, testKeystoreParams)
+	info = encrypt.Inspect([]byte(config))
+	if info.Format != encrypt.InspectFormatConfigSecret || len(info.Nonce) != 24 || info.BodySize != len(secret) {
+		t.Fatalf("配置密文解析不正确: %+v", info)
+	}
+
+	// 无法识别时给出长度提示，输出中不包含明文
+	info = encrypt.Inspect(bytes.Repeat([]byte{0xff}, 33))
+	if info.Format != encrypt.InspectFormatUnknown || len(info.Notes) == 0 {
+		t.Fatalf("未知格式应给出提示: %+v", info)
+	}
+	report := encrypt.Inspect(sealed).String()
+	if strings.Contains(report, string(secret)) || !strings.Contains(report, "SM4") {
+		t.Fatalf("诊断输出不正确:\n%s", report)
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCanonicalizeJSON 测试RFC 8785规范化（用例取自RFC附录）
+func TestCanonicalizeJSON(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			`{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001],
+			  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+			  "literals": [null, true, false]}`,
+			`{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		{
+			`{"€": "Euro Sign", "\r": "Carriage Return", "\ufb33": "Hebrew Letter Dalet With Dagesh",
+			  "1": "One", "😀": "Emoji: Grinning Face", "\u0080": "Control", "ö": "Latin Small Letter O With Diaeresis"}`,
+			`{"\r":"Carriage Return","1":"One","` + "\u0080" + `":"Control","ö":"Latin Small Letter O With Diaeresis","€":"Euro Sign","😀":"Emoji: Grinning Face","` + "\ufb33" + `":"Hebrew Letter Dalet With Dagesh"}`,
+		},
+		{`[-0, 1e21, 1e20, 1e-7, 0.000001, 123456789012345678, -1.5e-10]`, `[0,1e+21,100000000000000000000,1e-7,0.000001,123456789012345680,-1.5e-10]`},
+		{` { "b" : { "d":1, "c":[ ] }, "a" : "" } `, `{"a":"","b":{"c":[],"d":1}}`},
+	}
+	for _, c := range cases {
+		got, err := encrypt.CanonicalizeJSON([]byte(c.input))
+		if err != nil {
+			t.Fatalf("规范化失败: %v", err)
+		}
+		if string(got) != c.expected {
+			t.Errorf("规范化结果不正确:\n got: %s\nwant: %s", got, c.expected)
+		}
+	}
+
+	for _, invalid := range []string{`{"a":1,"a":2}`, `{"a":1} {}`, `[1e400]`, "\"\xff\""} {
+		if _, err := encrypt.CanonicalizeJSON([]byte(invalid)); err == nil {
+			t.Errorf("%q 应被拒绝", invalid)
+		}
+	}
+}
+
+// TestJSONDetachedSignature 测试JSON文档的分离式JWS
+func TestJSONDetachedSignature(t *testing.T) {
+	rsaPub, rsaPriv, _ := encrypt.MustNewRSA().GenerateKeyPair()
+	sm2Pub, sm2Priv, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	document := []byte(`{"order_id": "20240001", "amount": 100.00, "items": [{"sku": "A1", "qty": 2}]}`)
+	// 字段顺序、缩进与数字写法不同，但语义相同
+	reserialized := []byte("{\n  \"items\": [{\"qty\": 2, \"sku\": \"A1\"}],\n  \"amount\": 1e2,\n  \"order_id\": \"20240001\"\n}")
+
+	rsaSigner := mustSigner(t, rsaPriv)
+	signature, err := encrypt.SignJSONDetached(rsaSigner, document, "key-2024")
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if parts := strings.Split(signature, "."); len(parts) != 3 || parts[1] != "" {
+		t.Fatalf("应为载荷分离的JWS: %s", signature)
+	}
+	if header, err := encrypt.ParseJWSHeader(signature); err != nil || header.Algorithm != encrypt.JWSAlgorithmRS256 || header.KeyID != "key-2024" {
+		t.Fatalf("JWS头不正确: %+v %v", header, err)
+	}
+	if err := encrypt.VerifyJSONDetached(signature, reserialized, rsaPub); err != nil {
+		t.Fatalf("重新序列化后验签失败: %v", err)
+	}
+	if encrypt.VerifyJSONDetached(signature, []byte(`{"order_id":"20240001","amount":900,"items":[{"sku":"A1","qty":2}]}`), rsaPub) == nil {
+		t.Fatal("篡改文档后验签应失败")
+	}
+	if encrypt.VerifyJSONDetached(signature, document, sm2Pub) == nil {
+		t.Fatal("算法与公钥类型不一致时应失败")
+	}
+
+	for _, c := range []struct {
+		signer    crypto.Signer
+		algorithm string
+	}{
+		{mustSigner(t, sm2Priv), encrypt.JWSAlgorithmSM2},
+		{ecKey, encrypt.JWSAlgorithmES256},
+		{edKey, encrypt.JWSAlgorithmEdDSA},
+	} {
+		signature, err := encrypt.SignJSONDetached(c.signer, document, "")
+		if err != nil {
+			t.Fatalf("%s 签名失败: %v", c.algorithm, err)
+		}
+		if header, _ := encrypt.ParseJWSHeader(signature); header == nil || header.Algorithm != c.algorithm || header.KeyID != "" {
+			t.Fatalf("%s JWS头不正确", c.algorithm)
+		}
+		if err := encrypt.VerifyJSONDetachedWithKey(signature, reserialized, c.signer.Public()); err != nil {
+			t.Fatalf("%s 验签失败: %v", c.algorithm, err)
+		}
+	}
+
+	// ES256签名为定长r||s
+	signature, _ = encrypt.SignJSONDetached(ecKey, document, "")
+	if encoded := signature[strings.LastIndex(signature, ".")+1:]; len(encoded) != 86 {
+		t.Fatalf("ES256签名长度不正确: %d", len(encoded))
+	}
+}
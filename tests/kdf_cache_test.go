@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// TestKDFCache 测试派生结果缓存
+func TestKDFCache(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	cache, err := encrypt.NewKDFCache(2, time.Minute)
+	if err != nil {
+		t.Fatalf("创建缓存失败: %v", err)
+	}
+	cache.WithClock(func() time.Time { return now })
+
+	password, salt := []byte("correct horse"), bytes.Repeat([]byte{1}, 16)
+	params := encrypt.KeystoreKDFParams{Time: 1, Memory: 64, Threads: 1}
+
+	key, err := cache.Argon2id(password, salt, params, 32)
+	if err != nil || !bytes.Equal(key, argon2.IDKey(password, salt, 1, 64, 1, 32)) {
+		t.Fatalf("argon2id派生结果不正确: %v", err)
+	}
+	// 调用方清零返回值不影响缓存
+	for i := range key {
+		key[i] = 0
+	}
+	again, _ := cache.Argon2id(password, salt, params, 32)
+	if !bytes.Equal(again, argon2.IDKey(password, salt, 1, 64, 1, 32)) {
+		t.Fatal("缓存的派生密钥被调用方修改")
+	}
+	if stats := cache.Stats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Fatalf("统计不正确: %+v", stats)
+	}
+
+	// 任一输入不同都不会命中
+	_, _ = cache.Argon2id([]byte("wrong"), salt, params, 32)
+	derived, _ := cache.PBKDF2(password, salt, encrypt.HashSHA256, 1000, 32)
+	if !bytes.Equal(derived, pbkdf2.Key(password, salt, 1000, 32, sha256.New)) {
+		t.Fatal("PBKDF2派生结果不正确")
+	}
+	if stats := cache.Stats(); stats.Misses != 3 || stats.Size != 2 || stats.Evictions != 1 {
+		t.Fatalf("统计不正确: %+v", stats)
+	}
+
+	// 过期后重新派生
+	now = now.Add(2 * time.Minute)
+	cache.Cleanup()
+	if stats := cache.Stats(); stats.Size != 0 {
+		t.Fatalf("过期条目应被清除: %+v", stats)
+	}
+
+	// 并发未命中只派生一次
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _ = cache.Argon2id(password, salt, params, 32)
+		}()
+	}
+	wg.Wait()
+	if stats := cache.Stats(); stats.Misses != 4 {
+		t.Fatalf("并发请求应只派生一次: %+v", stats)
+	}
+
+	if _, err := encrypt.NewKDFCache(10, 0); err == nil {
+		t.Fatal("未设置有效期应报错")
+	}
+}
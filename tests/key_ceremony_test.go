@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestShamir 测试Shamir拆分与恢复
+func TestShamir(t *testing.T) {
+	secret := []byte("master key material 0123456789!!")
+	shares, err := encrypt.SplitSecret(secret, 5, 3)
+	if err != nil {
+		t.Fatalf("拆分失败: %v", err)
+	}
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		picked := make([][]byte, 0, len(subset))
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		recovered, err := encrypt.CombineShares(picked)
+		if err != nil || !bytes.Equal(recovered, secret) {
+			t.Fatalf("份额%v恢复失败: %v", subset, err)
+		}
+	}
+
+	recovered, _ := encrypt.CombineShares(shares[:2])
+	if bytes.Equal(recovered, secret) {
+		t.Fatal("少于门限的份额不应恢复秘密")
+	}
+	if _, err := encrypt.CombineShares([][]byte{shares[0], shares[0]}); err == nil {
+		t.Fatal("重复份额应被拒绝")
+	}
+}
+
+// TestKeyCheckValue 测试KCV
+func TestKeyCheckValue(t *testing.T) {
+	// 3DES双倍长测试密钥 0123456789ABCDEF FEDCBA9876543210 的KCV为08D7B4
+	key, _ := hex.DecodeString("0123456789ABCDEFFEDCBA98765432100123456789ABCDEF")
+	kcv, err := encrypt.KeyCheckValue(encrypt.Algorithm3DES, key)
+	if err != nil || kcv != "08D7B4" {
+		t.Fatalf("KCV不正确: %v %s", err, kcv)
+	}
+}
+
+// TestKeyCeremony 测试XOR与Shamir仪式写入密钥库
+func TestKeyCeremony(t *testing.T) {
+	key, _ := encrypt.GenerateRandomBytes(32)
+	expected, _ := encrypt.KeyCheckValue(encrypt.AlgorithmAES, key)
+
+	components, err := encrypt.SplitKeyComponents(key, 3)
+	if err != nil {
+		t.Fatalf("拆分分量失败: %v", err)
+	}
+	ceremony, err := encrypt.NewXORCeremony(encrypt.AlgorithmAES, 32, 3)
+	if err != nil {
+		t.Fatalf("创建仪式失败: %v", err)
+	}
+	for _, component := range components {
+		if _, err := ceremony.AddComponent(component); err != nil {
+			t.Fatalf("输入分量失败: %v", err)
+		}
+	}
+
+	ks, err := encrypt.CreateKeystoreWithParams(filepath.Join(t.TempDir(), "ks.json"), []byte("pw"),
+		encrypt.KeystoreKDFParams{Time: 1, Memory: 1024, Threads: 1})
+	if err != nil {
+		t.Fatalf("创建密钥库失败: %v", err)
+	}
+	kcv, err := ceremony.StoreInKeystore(ks, "master", encrypt.KeyUsageCipher)
+	if err != nil || kcv != expected {
+		t.Fatalf("写入密钥库失败: %v %s", err, kcv)
+	}
+	entry, err := ks.KeyRing().Get("master")
+	if err != nil || !bytes.Equal(entry.Key, key) {
+		t.Fatal("密钥库中的密钥不正确")
+	}
+
+	shares, _ := encrypt.SplitSecret(key, 5, 2)
+	shamir, _ := encrypt.NewShamirCeremony(encrypt.AlgorithmAES, 32, 2)
+	shamir.AddComponent(shares[3])
+	if _, _, err := shamir.Combine(); err == nil {
+		t.Fatal("分量不足时应无法合成")
+	}
+	if _, err := shamir.AddComponent(shares[3]); err == nil {
+		t.Fatal("重复分量应被拒绝")
+	}
+	shamir.AddComponent(shares[1])
+	combined, kcv, err := shamir.Combine()
+	if err != nil || !bytes.Equal(combined, key) || kcv != expected {
+		t.Fatalf("Shamir合成失败: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestKeyObfuscatorRefresh 测试份额刷新后密钥不变
+func TestKeyObfuscatorRefresh(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	obf, err := encrypt.NewKeyObfuscator(key)
+	if err != nil {
+		t.Fatalf("创建混淆密钥失败: %v", err)
+	}
+	defer obf.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := obf.Refresh(); err != nil {
+			t.Fatalf("刷新份额失败: %v", err)
+		}
+	}
+	obf.WithShares(5).WithRefreshInterval(time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	revealed, err := obf.Reveal()
+	if err != nil || !bytes.Equal(revealed, key) {
+		t.Fatalf("还原密钥不正确: %v", err)
+	}
+}
+
+// TestObfuscatedCipher 测试混淆密钥加密器与普通加密器互通
+func TestObfuscatedCipher(t *testing.T) {
+	key := []byte("0123456789abcdef")
+	obf, err := encrypt.NewKeyObfuscator(key)
+	if err != nil {
+		t.Fatalf("创建混淆密钥失败: %v", err)
+	}
+
+	cipher, err := obf.Cipher(encrypt.AlgorithmAES, encrypt.WithMode(encrypt.ModeGCM))
+	if err != nil {
+		t.Fatalf("创建加密器失败: %v", err)
+	}
+	ciphertext, err := cipher.EncryptString("secret data")
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	plain, err := encrypt.AES(key, encrypt.WithMode(encrypt.ModeGCM))
+	if err != nil {
+		t.Fatalf("创建AES失败: %v", err)
+	}
+	defer plain.Release()
+	decrypted, err := plain.DecryptString(ciphertext)
+	if err != nil || decrypted != "secret data" {
+		t.Fatalf("普通加密器解密失败: %v", err)
+	}
+
+	sm4, err := obf.Cipher(encrypt.AlgorithmSM4, encrypt.WithMode(encrypt.ModeGCM))
+	if err != nil {
+		t.Fatalf("创建SM4加密器失败: %v", err)
+	}
+	out, err := sm4.Encrypt([]byte("hello"))
+	if err != nil {
+		t.Fatalf("SM4加密失败: %v", err)
+	}
+	back, err := sm4.Decrypt(out)
+	if err != nil || string(back) != "hello" {
+		t.Fatalf("SM4解密失败: %v", err)
+	}
+
+	obf.Close()
+	if _, err := cipher.Encrypt([]byte("x")); err == nil {
+		t.Fatalf("密钥销毁后不应能加密")
+	}
+	if _, err := obf.Cipher(encrypt.AlgorithmDES); err == nil {
+		t.Fatalf("密钥销毁后不应能创建加密器")
+	}
+}
@@ -0,0 +1,134 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestLoadKey 测试从环境变量与文件加载密钥
+func TestLoadKey(t *testing.T) {
+	t.Setenv("APP_AES_KEY", "  000102030405060708090a0b0c0d0e0f\n")
+	key, err := encrypt.LoadKeyFromEnv("APP_AES_KEY", encrypt.HexEncoding)
+	if err != nil || len(key) != 16 || key[15] != 0x0f {
+		t.Fatalf("从环境变量加载密钥失败: %v", err)
+	}
+	if _, err := encrypt.LoadKeyFromEnv("APP_MISSING_KEY", encrypt.HexEncoding); err == nil {
+		t.Fatalf("未设置的环境变量应返回错误")
+	}
+
+	var warned []string
+	warn := encrypt.WithKeyFileWarning(func(path string, perm os.FileMode) { warned = append(warned, path) })
+
+	dir := t.TempDir()
+	private := filepath.Join(dir, "private.key")
+	public := filepath.Join(dir, "public.key")
+	encoded := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32))
+	if err := os.WriteFile(private, []byte(encoded), 0600); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+	if err := os.WriteFile(public, []byte(encoded), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	for _, path := range []string{private, public} {
+		key, err := encrypt.LoadKeyFromFile(path, encrypt.Base64Encoding, warn)
+		if err != nil || !bytes.Equal(key, bytes.Repeat([]byte{7}, 32)) {
+			t.Fatalf("从文件加载密钥失败: %v", err)
+		}
+	}
+	if len(warned) != 1 || warned[0] != public {
+		t.Fatalf("只应对组或其他用户可读的文件发出警告: %v", warned)
+	}
+
+	// 仅组可读同样发出警告，处理函数为nil时关闭警告
+	if err := os.Chmod(public, 0640); err != nil {
+		t.Fatalf("修改文件权限失败: %v", err)
+	}
+	if _, err := encrypt.LoadKeyFromFile(public, encrypt.Base64Encoding, warn); err != nil || len(warned) != 2 {
+		t.Fatalf("组可读的文件应发出警告: %v %v", err, warned)
+	}
+	if _, err := encrypt.LoadKeyFromFile(public, encrypt.Base64Encoding, encrypt.WithKeyFileWarning(nil)); err != nil || len(warned) != 2 {
+		t.Fatalf("关闭警告后不应调用处理函数: %v %v", err, warned)
+	}
+
+	// 默认不发出警告，也不写入标准日志
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	if _, err := encrypt.LoadKeyFromFile(public, encrypt.Base64Encoding); err != nil || logged.Len() != 0 {
+		t.Fatalf("默认不应写入日志: %v %q", err, logged.String())
+	}
+}
+
+// TestVaultTransit 测试Vault Transit包装与解包数据密钥
+func TestVaultTransit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "s.test" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/app":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]},
+			})
+		case "/v1/transit/decrypt/app":
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	var provider encrypt.KeyProvider = encrypt.NewVaultTransit(server.URL, "s.test")
+	dataKey := bytes.Repeat([]byte{1}, 32)
+
+	wrapped, err := provider.WrapKey(context.Background(), "app", dataKey)
+	if err != nil || !strings.HasPrefix(string(wrapped), "vault:v1:") {
+		t.Fatalf("包装数据密钥失败: %v", err)
+	}
+	unwrapped, err := provider.UnwrapKey(context.Background(), "app", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, dataKey) {
+		t.Fatalf("解包数据密钥失败: %v", err)
+	}
+
+	if _, err := encrypt.NewVaultTransit(server.URL, "bad").WrapKey(context.Background(), "app", dataKey); err == nil ||
+		!strings.Contains(err.Error(), "permission denied") {
+		t.Fatalf("应返回Vault错误信息，实际为%v", err)
+	}
+}
+
+// TestKeyRingProvider 测试密钥环作为本地密钥提供者
+func TestKeyRingProvider(t *testing.T) {
+	ring := encrypt.NewKeyRing()
+	key, _ := encrypt.GenerateRandomKey(16)
+	if err := ring.Add(encrypt.KeyEntry{ID: "kek", Algorithm: encrypt.AlgorithmSM4, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+
+	var provider encrypt.KeyProvider = ring
+	wrapped, err := provider.WrapKey(context.Background(), "kek", []byte("data key"))
+	if err != nil {
+		t.Fatalf("包装失败: %v", err)
+	}
+	if dataKey, err := provider.UnwrapKey(context.Background(), "kek", wrapped); err != nil || string(dataKey) != "data key" {
+		t.Fatalf("解包失败: %v", err)
+	}
+}
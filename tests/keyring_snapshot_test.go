@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestKeyRingSnapshot 测试密钥环快照的导出与导入
+func TestKeyRingSnapshot(t *testing.T) {
+	ring := encrypt.NewKeyRing()
+	oldKey, _ := encrypt.GenerateRandomKey(32)
+	newKey, _ := encrypt.GenerateRandomKey(32)
+	_ = ring.Add(encrypt.KeyEntry{ID: "data-1", Algorithm: encrypt.AlgorithmAES, Key: oldKey, Usage: encrypt.KeyUsageCipher})
+	_ = ring.Add(encrypt.KeyEntry{ID: "data-2", Algorithm: encrypt.AlgorithmAES, Key: newKey, Usage: encrypt.KeyUsageCipher})
+	pubPEM, privPEM, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	_ = ring.Add(encrypt.KeyEntry{ID: "sign-1", Algorithm: encrypt.AlgorithmSM2, Key: privPEM, PublicKey: pubPEM, Usage: encrypt.KeyUsageSignature})
+	_ = ring.SetPrimary("data-2")
+	_ = ring.Revoke("data-1")
+
+	ciphertext, _ := ring.Encrypt([]byte("secret"), []byte("aad"))
+	password := []byte("correct horse")
+
+	for _, compression := range []string{encrypt.SnapshotCompressionGzip, encrypt.SnapshotCompressionNone} {
+		snapshot, err := encrypt.ExportKeyRingWithOptions(ring, password, encrypt.KeyRingExportOptions{
+			Compression: compression,
+			KDF:         testKeystoreParams,
+		})
+		if err != nil {
+			t.Fatalf("导出失败: %v", err)
+		}
+		if bytes.Contains(snapshot, []byte("PRIVATE KEY")) {
+			t.Fatal("快照中不应出现明文私钥")
+		}
+
+		imported, err := encrypt.ImportKeyRing(snapshot, password)
+		if err != nil {
+			t.Fatalf("导入失败: %v", err)
+		}
+		if imported.Primary() != "data-2" {
+			t.Fatalf("主密钥应为data-2，实际为%s", imported.Primary())
+		}
+		if plaintext, err := imported.Decrypt(ciphertext, []byte("aad")); err != nil || string(plaintext) != "secret" {
+			t.Fatalf("导入后解密失败: %v", err)
+		}
+		if _, err := imported.Use("data-1", encrypt.KeyUsageDecrypt); !errors.Is(err, encrypt.ErrKeyRevoked) {
+			t.Fatalf("吊销状态应保留，实际为%v", err)
+		}
+		signature, _ := ring.Sign("sign-1", []byte("message"))
+		if err := imported.Verify("sign-1", []byte("message"), signature); err != nil {
+			t.Fatalf("导入后验签失败: %v", err)
+		}
+	}
+
+	// 导出后原密钥环仍可用
+	if plaintext, err := ring.Decrypt(ciphertext, []byte("aad")); err != nil || string(plaintext) != "secret" {
+		t.Fatalf("导出不应影响原密钥环: %v", err)
+	}
+
+	snapshot, _ := encrypt.ExportKeyRingWithOptions(ring, password, encrypt.KeyRingExportOptions{KDF: testKeystoreParams})
+	if _, err := encrypt.ImportKeyRing(snapshot, []byte("wrong")); err == nil {
+		t.Fatal("错误密码应导入失败")
+	}
+
+	// 元数据受认证保护
+	var fields map[string]any
+	_ = json.Unmarshal(snapshot, &fields)
+	fields["key_count"] = 2
+	tampered, _ := json.Marshal(fields)
+	if _, err := encrypt.ImportKeyRing(tampered, password); err == nil {
+		t.Fatal("篡改元数据应导入失败")
+	}
+
+	// 快照中的argon2id参数在派生前检查，不会panic或耗尽内存
+	for _, params := range []map[string]any{{"threads": 0}, {"time": 0}, {"memory": 4294967295}} {
+		_ = json.Unmarshal(snapshot, &fields)
+		kdf := fields["kdf"].(map[string]any)
+		for name, value := range params {
+			kdf[name] = value
+		}
+		tampered, _ := json.Marshal(fields)
+		if _, err := encrypt.ImportKeyRing(tampered, password); err == nil {
+			t.Fatalf("参数%v应导入失败", params)
+		}
+	}
+
+	if _, err := encrypt.ExportKeyRingWithOptions(ring, password, encrypt.KeyRingExportOptions{Compression: "zstd"}); err == nil {
+		t.Fatal("未注册的压缩算法应报错")
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestKeyRingUsage 测试密钥用途限制
+func TestKeyRingUsage(t *testing.T) {
+	ring := encrypt.NewKeyRing()
+
+	aesKey, _ := encrypt.GenerateRandomKey(32)
+	if err := ring.Add(encrypt.KeyEntry{ID: "data-1", Algorithm: encrypt.AlgorithmAES, Key: aesKey, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+
+	pubPEM, privPEM, err := encrypt.MustNewSM2().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	if err := ring.Add(encrypt.KeyEntry{ID: "sign-1", Algorithm: encrypt.AlgorithmSM2, Key: privPEM, PublicKey: pubPEM, Usage: encrypt.KeyUsageSignature}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	if ring.Primary() != "data-1" {
+		t.Fatalf("第一把加密密钥应成为主密钥，实际为%s", ring.Primary())
+	}
+
+	ciphertext, err := ring.Encrypt([]byte("secret"), nil)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	plaintext, err := ring.Decrypt(ciphertext, nil)
+	if err != nil || string(plaintext) != "secret" {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	signature, err := ring.Sign("sign-1", []byte("message"))
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if err := ring.Verify("sign-1", []byte("message"), signature); err != nil {
+		t.Fatalf("验签失败: %v", err)
+	}
+
+	// 加密密钥不能签名，签名密钥不能成为主密钥
+	if _, err := ring.Sign("data-1", []byte("message")); !errors.Is(err, encrypt.ErrKeyUsage) {
+		t.Fatalf("加密密钥签名应返回ErrKeyUsage，实际为%v", err)
+	}
+	if err := ring.SetPrimary("sign-1"); !errors.Is(err, encrypt.ErrKeyUsage) {
+		t.Fatalf("签名密钥不应成为主密钥，实际为%v", err)
+	}
+
+	var keyErr *encrypt.KeyError
+	if _, err := ring.Use("missing", encrypt.KeyUsageDecrypt); !errors.As(err, &keyErr) || keyErr.KeyID != "missing" {
+		t.Fatalf("应返回带密钥ID的KeyError，实际为%v", err)
+	}
+}
+
+// TestKeyRingValidity 测试有效期与吊销
+func TestKeyRingValidity(t *testing.T) {
+	now := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	ring := encrypt.NewKeyRing().WithClock(func() time.Time { return now })
+
+	key, _ := encrypt.GenerateRandomKey(16)
+	if err := ring.Add(encrypt.KeyEntry{
+		ID:        "sm4-2025",
+		Algorithm: encrypt.AlgorithmSM4,
+		Key:       key,
+		Usage:     encrypt.KeyUsageCipher,
+		NotBefore: now.AddDate(0, -1, 0),
+		NotAfter:  now.AddDate(0, 1, 0),
+	}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+
+	ciphertext, err := ring.Encrypt([]byte("data"), []byte("aad"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	now = now.AddDate(0, 2, 0)
+	if _, err := ring.Decrypt(ciphertext, []byte("aad")); !errors.Is(err, encrypt.ErrKeyExpired) {
+		t.Fatalf("过期密钥应返回ErrKeyExpired，实际为%v", err)
+	}
+
+	now = now.AddDate(0, -4, 0)
+	if _, err := ring.Encrypt([]byte("data"), nil); !errors.Is(err, encrypt.ErrKeyNotYetValid) {
+		t.Fatalf("未生效密钥应返回ErrKeyNotYetValid，实际为%v", err)
+	}
+
+	now = now.AddDate(0, 2, 0)
+	if err := ring.Revoke("sm4-2025"); err != nil {
+		t.Fatalf("吊销失败: %v", err)
+	}
+	if _, err := ring.Decrypt(ciphertext, []byte("aad")); !errors.Is(err, encrypt.ErrKeyRevoked) {
+		t.Fatalf("吊销密钥应返回ErrKeyRevoked，实际为%v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// testKeystoreParams 测试使用的低成本argon2id参数
+var testKeystoreParams = encrypt.KeystoreKDFParams{Time: 1, Memory: 1024, Threads: 1}
+
+// TestKeystore 测试密钥库的保存、加载与修改主密码
+func TestKeystore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+
+	ks, err := encrypt.CreateKeystoreWithParams(path, []byte("correct horse"), testKeystoreParams)
+	if err != nil {
+		t.Fatalf("创建密钥库失败: %v", err)
+	}
+
+	aesKey, _ := encrypt.GenerateRandomKey(32)
+	sm4Key, _ := encrypt.GenerateRandomKey(16)
+	if err := ks.AddKey(encrypt.KeyEntry{ID: "aes-1", Algorithm: encrypt.AlgorithmAES, Key: aesKey, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	if err := ks.AddKey(encrypt.KeyEntry{ID: "sm4-1", Algorithm: encrypt.AlgorithmSM4, Key: sm4Key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	if err := ks.KeyRing().SetPrimary("sm4-1"); err != nil {
+		t.Fatalf("设置主密钥失败: %v", err)
+	}
+	if err := ks.KeyRing().Revoke("aes-1"); err != nil {
+		t.Fatalf("吊销失败: %v", err)
+	}
+
+	ciphertext, err := ks.KeyRing().Encrypt([]byte("persisted"), nil)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if err := ks.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	ks.Close()
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("密钥库文件不存在: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("密钥库文件权限应为0600，实际为%o", info.Mode().Perm())
+	}
+
+	if _, err := encrypt.LoadKeystore(path, []byte("wrong")); err == nil {
+		t.Fatalf("错误的主密码不应打开密钥库")
+	}
+
+	loaded, err := encrypt.LoadKeystore(path, []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("加载失败: %v", err)
+	}
+	ring := loaded.KeyRing()
+	if ring.Primary() != "sm4-1" {
+		t.Fatalf("主密钥应为sm4-1，实际为%s", ring.Primary())
+	}
+	if plaintext, err := ring.Decrypt(ciphertext, nil); err != nil || string(plaintext) != "persisted" {
+		t.Fatalf("加载后解密失败: %v", err)
+	}
+	if _, err := ring.Use("aes-1", encrypt.KeyUsageDecrypt); !errors.Is(err, encrypt.ErrKeyRevoked) {
+		t.Fatalf("吊销状态应被保存，实际为%v", err)
+	}
+
+	// 修改主密码并移除密钥
+	if err := loaded.RemoveKey("aes-1"); err != nil {
+		t.Fatalf("移除密钥失败: %v", err)
+	}
+	if err := loaded.ChangePassword([]byte("battery staple")); err != nil {
+		t.Fatalf("修改主密码失败: %v", err)
+	}
+	if err := loaded.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+
+	if _, err := encrypt.LoadKeystore(path, []byte("correct horse")); err == nil {
+		t.Fatalf("旧主密码不应再能打开密钥库")
+	}
+	reloaded, err := encrypt.LoadKeystore(path, []byte("battery staple"))
+	if err != nil {
+		t.Fatalf("新主密码加载失败: %v", err)
+	}
+	if ids := reloaded.KeyRing().IDs(); len(ids) != 1 || ids[0] != "sm4-1" {
+		t.Fatalf("密钥列表不正确: %v", ids)
+	}
+
+	if _, err := encrypt.CreateKeystore(path, []byte("x")); err == nil {
+		t.Fatalf("不应覆盖已存在的密钥库")
+	}
+}
+
+// TestKeystoreTampered 测试篡改元数据或argon2id参数的密钥库文件被拒绝
+func TestKeystoreTampered(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.json")
+	ks, _ := encrypt.CreateKeystoreWithParams(path, []byte("pw"), testKeystoreParams)
+	key, _ := encrypt.GenerateRandomKey(32)
+	_ = ks.AddKey(encrypt.KeyEntry{ID: "aes-1", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher})
+	_ = ks.KeyRing().Revoke("aes-1")
+	if err := ks.Save(); err != nil {
+		t.Fatalf("保存失败: %v", err)
+	}
+	original, _ := os.ReadFile(path)
+
+	cases := map[string][2]string{
+		"撤销吊销":   {`"revoked": true`, `"revoked": false`},
+		"并行度为0":  {`"threads": 1`, `"threads": 0`},
+		"迭代次数为0": {`"time": 1`, `"time": 0`},
+		"内存过大":   {`"memory": 1024`, `"memory": 4294967295`},
+	}
+	for name, edit := range cases {
+		t.Run(name, func(t *testing.T) {
+			tampered := strings.Replace(string(original), edit[0], edit[1], 1)
+			if tampered == string(original) {
+				t.Fatalf("测试数据中没有%s", edit[0])
+			}
+			_ = os.WriteFile(path, []byte(tampered), 0600)
+			if _, err := encrypt.LoadKeystore(path, []byte("pw")); err == nil {
+				t.Fatal("篡改的密钥库不应加载成功")
+			}
+		})
+	}
+}
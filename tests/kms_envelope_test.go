@@ -0,0 +1,79 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// fakeKMS 模拟支持GenerateDataKey的KMS，包装方式为与固定字节异或
+type fakeKMS struct {
+	generated int
+}
+
+func (f *fakeKMS) GenerateDataKey(ctx context.Context, keyID string, size int) ([]byte, []byte, error) {
+	f.generated++
+	plaintext := bytes.Repeat([]byte{byte(f.generated)}, size)
+	wrapped, _ := f.WrapKey(ctx, keyID, plaintext)
+	return plaintext, wrapped, nil
+}
+
+func (f *fakeKMS) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	out := make([]byte, len(dataKey))
+	for i := range dataKey {
+		out[i] = dataKey[i] ^ 0x5a
+	}
+	return out, nil
+}
+
+func (f *fakeKMS) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	return f.WrapKey(ctx, keyID, wrapped)
+}
+
+// TestEnvelopeEncrypt 测试数据密钥信封
+func TestEnvelopeEncrypt(t *testing.T) {
+	ctx := context.Background()
+
+	kms := &fakeKMS{}
+	data, err := encrypt.EnvelopeEncrypt(ctx, kms, "alias/app", []byte("payload"), []byte("order-1"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if kms.generated != 1 {
+		t.Fatalf("应通过GenerateDataKey生成数据密钥")
+	}
+	if keyID, err := encrypt.EnvelopeKeyID(data); err != nil || keyID != "alias/app" {
+		t.Fatalf("读取密钥ID失败: %v %s", err, keyID)
+	}
+
+	plaintext, err := encrypt.EnvelopeDecrypt(ctx, kms, data, []byte("order-1"))
+	if err != nil || string(plaintext) != "payload" {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if _, err := encrypt.EnvelopeDecrypt(ctx, kms, data, []byte("order-2")); err == nil {
+		t.Fatalf("附加认证数据不同时不应解密成功")
+	}
+
+	// 篡改头部中的包装密钥
+	tampered := append([]byte(nil), data...)
+	tampered[len("alias/app")+7] ^= 1
+	if _, err := encrypt.EnvelopeDecrypt(ctx, kms, tampered, []byte("order-1")); err == nil {
+		t.Fatalf("篡改头部后不应解密成功")
+	}
+
+	// 不支持GenerateDataKey的提供者在本地生成数据密钥
+	ring := encrypt.NewKeyRing()
+	key, _ := encrypt.GenerateRandomKey(32)
+	if err := ring.Add(encrypt.KeyEntry{ID: "kek", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	data, err = encrypt.EnvelopeEncrypt(ctx, ring, "kek", []byte("local"), nil)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if plaintext, err := encrypt.EnvelopeDecrypt(ctx, ring, data, nil); err != nil || string(plaintext) != "local" {
+		t.Fatalf("解密失败: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// newEd25519PEM 生成PEM编码的Ed25519密钥对
+func newEd25519PEM(t *testing.T) ([]byte, []byte) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("生成Ed25519密钥失败: %v", err)
+	}
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+}
+
+// TestLicense 测试许可证签发与离线验证
+func TestLicense(t *testing.T) {
+	edPub, edPriv := newEd25519PEM(t)
+	sm2Pub, sm2Priv, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	now := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, keys := range map[string][2][]byte{"Ed25519": {edPub, edPriv}, "SM2": {sm2Pub, sm2Priv}} {
+		license, err := encrypt.IssueLicense(keys[1], encrypt.LicenseClaims{
+			ID:        "LIC-0001",
+			Licensee:  "ACME",
+			Product:   "gateway",
+			Features:  []string{"sm-crypto", "cluster"},
+			MachineID: "host-01",
+			IssuedAt:  now.Unix(),
+			ExpiresAt: now.AddDate(1, 0, 0).Unix(),
+		})
+		if err != nil {
+			t.Fatalf("%s签发失败: %v", name, err)
+		}
+		if strings.Trim(license, "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567-") != "" || license[5] != '-' {
+			t.Fatalf("%s许可证格式不正确: %s", name, license)
+		}
+
+		verifier, err := encrypt.NewLicenseVerifier(keys[0])
+		if err != nil {
+			t.Fatalf("%s创建验证器失败: %v", name, err)
+		}
+		verifier.WithMachineID("host-01").WithProduct("gateway").WithClock(func() time.Time { return now.AddDate(0, 6, 0) })
+
+		// 大小写与分隔符不影响验证
+		claims, err := verifier.Verify(strings.ToLower(strings.ReplaceAll(license, "-", " ")))
+		if err != nil || claims.Licensee != "ACME" || !claims.HasFeature("cluster") || claims.HasFeature("audit") {
+			t.Fatalf("%s验证失败: %v %+v", name, err, claims)
+		}
+
+		if _, err := verifier.WithMachineID("host-02").Verify(license); !errors.Is(err, encrypt.ErrLicenseMachine) {
+			t.Fatalf("%s机器码不符应当失败: %v", name, err)
+		}
+		verifier.WithMachineID("host-01").WithClock(func() time.Time { return now.AddDate(2, 0, 0) })
+		if _, err := verifier.Verify(license); !errors.Is(err, encrypt.ErrLicenseExpired) {
+			t.Fatalf("%s过期应当失败: %v", name, err)
+		}
+
+		tampered := []rune(license)
+		if tampered[20] == 'A' {
+			tampered[20] = 'B'
+		} else {
+			tampered[20] = 'A'
+		}
+		if _, err := verifier.Verify(string(tampered)); !errors.Is(err, encrypt.ErrLicenseInvalid) {
+			t.Fatalf("%s篡改应当失败: %v", name, err)
+		}
+	}
+
+	// 其他密钥签发的许可证无法通过验证
+	otherPub, _ := newEd25519PEM(t)
+	license, _ := encrypt.IssueLicense(edPriv, encrypt.LicenseClaims{Licensee: "ACME"})
+	verifier, _ := encrypt.NewLicenseVerifier(otherPub)
+	if _, err := verifier.Verify(license); !errors.Is(err, encrypt.ErrLicenseInvalid) {
+		t.Fatalf("其他公钥应当验证失败: %v", err)
+	}
+	rsaPub, rsaPriv, _ := encrypt.MustNewRSA().GenerateKeyPair()
+	if _, err := encrypt.IssueLicense(rsaPriv, encrypt.LicenseClaims{Licensee: "ACME"}); err == nil {
+		t.Fatal("RSA密钥应当不支持")
+	}
+	if _, err := encrypt.NewLicenseVerifier(rsaPub); err == nil {
+		t.Fatal("RSA公钥应当不支持")
+	}
+}
@@ -0,0 +1,98 @@
+package tests
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestLogRedactor 测试日志字段哈希与确定性加密
+func TestLogRedactor(t *testing.T) {
+	key, _ := encrypt.GenerateRandomBytes(32)
+	redactor, err := encrypt.NewLogRedactor(key)
+	if err != nil {
+		t.Fatalf("创建脱敏器失败: %v", err)
+	}
+	redactor.Hash("id_card").Encrypt("Phone")
+
+	if value, ok := redactor.Redact("message", "hello"); ok || value != "hello" {
+		t.Fatal("未配置的字段不应脱敏")
+	}
+
+	hashed, ok := redactor.Redact("id_card", "110101199003074514")
+	if !ok || !strings.HasPrefix(hashed, "hmac:") || strings.Contains(hashed, "1101011990") {
+		t.Fatalf("哈希结果不正确: %s", hashed)
+	}
+	again, _ := redactor.Redact("ID_CARD", "110101199003074514")
+	if again != hashed {
+		t.Fatal("相同的值应得到相同的哈希，字段名不区分大小写")
+	}
+	if _, err := redactor.Reveal(hashed); err == nil {
+		t.Fatal("哈希值不应可以还原")
+	}
+
+	sealed, ok := redactor.Redact("phone", "13800138000")
+	if !ok || !strings.HasPrefix(sealed, "enc:") || strings.Contains(sealed, "13800138000") {
+		t.Fatalf("加密结果不正确: %s", sealed)
+	}
+	if again, _ := redactor.Redact("phone", "13800138000"); again != sealed {
+		t.Fatal("确定性加密的结果应一致")
+	}
+	if other, _ := redactor.Redact("phone", "13800138001"); other == sealed {
+		t.Fatal("不同的值应得到不同的密文")
+	}
+	revealed, err := redactor.Reveal(sealed)
+	if err != nil || revealed != "13800138000" {
+		t.Fatalf("还原失败: %v %s", err, revealed)
+	}
+
+	otherKey, _ := encrypt.GenerateRandomBytes(32)
+	other, _ := encrypt.NewLogRedactor(otherKey)
+	if _, err := other.Reveal(sealed); err == nil {
+		t.Fatal("错误的密钥不应还原")
+	}
+}
+
+// TestLogRedactorValue 测试嵌套字段脱敏
+func TestLogRedactorValue(t *testing.T) {
+	key, _ := encrypt.GenerateRandomBytes(32)
+	redactor, _ := encrypt.NewLogRedactor(key)
+	redactor.Encrypt("phone")
+
+	if _, changed := redactor.RedactValue("13800138000"); changed {
+		t.Fatal("标量值不应处理")
+	}
+	plain := map[string]interface{}{"name": "alice", "tags": []interface{}{"a"}}
+	if _, changed := redactor.RedactValue(plain); changed {
+		t.Fatal("没有配置字段时不应替换")
+	}
+
+	value := map[string]interface{}{
+		"name":  "alice",
+		"items": []interface{}{map[string]interface{}{"Phone": "13800138000"}},
+	}
+	redacted, changed := redactor.RedactValue(value)
+	if !changed {
+		t.Fatal("嵌套的配置字段应被替换")
+	}
+	item := redacted.(map[string]interface{})["items"].([]interface{})[0].(map[string]interface{})
+	if revealed, err := redactor.Reveal(item["Phone"].(string)); err != nil || revealed != "13800138000" {
+		t.Fatalf("嵌套字段还原失败: %v %s", err, revealed)
+	}
+	if value["items"].([]interface{})[0].(map[string]interface{})["Phone"] != "13800138000" {
+		t.Fatal("不应修改原值")
+	}
+
+	type contact struct {
+		Phone string `json:"phone"`
+	}
+	redacted, changed = redactor.RedactValue(struct{ Contacts []contact }{[]contact{{"13800138000"}}})
+	if !changed {
+		t.Fatal("结构体中的配置字段应被替换")
+	}
+	if strings.Contains(fmt.Sprint(redacted), "13800138000") {
+		t.Fatalf("结构体脱敏后仍包含明文: %v", redacted)
+	}
+}
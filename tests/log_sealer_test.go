@@ -0,0 +1,80 @@
+package tests
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestLogSealer 测试审计日志哈希链与检查点
+func TestLogSealer(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	opts := encrypt.LogVerifyOptions{PublicKeys: []crypto.PublicKey{pub}, CheckpointEvery: 3}
+
+	var log bytes.Buffer
+	sealer := encrypt.NewLogSealer(encrypt.HashSHA256).WithCheckpoints(priv, 3)
+	for _, record := range []string{"login alice", "grant admin bob", "export users", "logout alice"} {
+		if err := sealer.Append(&log, []byte(record)); err != nil {
+			t.Fatalf("写入失败: %v", err)
+		}
+	}
+
+	result, err := encrypt.VerifyLog(bytes.NewReader(log.Bytes()), opts)
+	if err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if result.Records != 4 || result.LastCheckpoint != 3 || result.Last.Record != "logout alice" {
+		t.Fatalf("校验结果不正确: %+v", result)
+	}
+
+	// 重启后继续写入
+	resumed := encrypt.NewLogSealer(encrypt.HashSHA256).WithCheckpoints(priv, 3)
+	if err := resumed.Resume(result.Last); err != nil {
+		t.Fatalf("恢复失败: %v", err)
+	}
+	_ = resumed.Append(&log, []byte("login carol"))
+	if result, err = encrypt.VerifyLog(bytes.NewReader(log.Bytes()), opts); err != nil || result.Records != 5 {
+		t.Fatalf("恢复后校验失败: %v", err)
+	}
+
+	lines := strings.SplitAfter(strings.TrimSpace(log.String()), "\n")
+	tampered := map[string]string{
+		"修改记录": strings.Replace(log.String(), "grant admin bob", "grant admin eve", 1),
+		"删除记录": lines[0] + strings.Join(lines[2:], ""),
+		"交换记录": lines[1] + lines[0] + strings.Join(lines[2:], ""),
+	}
+	for name, data := range tampered {
+		if _, err := encrypt.VerifyLog(strings.NewReader(data), opts); !errors.Is(err, encrypt.ErrLogTampered) {
+			t.Fatalf("%s应被检测到: %v", name, err)
+		}
+	}
+
+	// 重算整条链但没有私钥，无法伪造检查点
+	var forged bytes.Buffer
+	other := encrypt.NewLogSealer(encrypt.HashSHA256)
+	for _, record := range []string{"login alice", "grant admin eve", "export users"} {
+		_ = other.Append(&forged, []byte(record))
+	}
+	if _, err := encrypt.VerifyLog(&forged, opts); !errors.Is(err, encrypt.ErrLogTampered) {
+		t.Fatalf("缺少检查点应被检测到: %v", err)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+	forged.Reset()
+	other = encrypt.NewLogSealer(encrypt.HashSHA256).WithCheckpoints(otherKey, 3)
+	for _, record := range []string{"login alice", "grant admin eve", "export users"} {
+		_ = other.Append(&forged, []byte(record))
+	}
+	if _, err := encrypt.VerifyLog(&forged, opts); !errors.Is(err, encrypt.ErrLogTampered) {
+		t.Fatalf("未知密钥的检查点应被拒绝: %v", err)
+	}
+
+	if _, err := sealer.Seal([]byte("line\nbreak")); err == nil {
+		t.Fatal("包含换行的记录应被拒绝")
+	}
+}
@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestMerkleTree 测试Merkle树构建与证明
+func TestMerkleTree(t *testing.T) {
+	for _, hashAlgo := range []encrypt.HashAlgorithm{encrypt.HashSHA256, encrypt.HashSM3} {
+		for _, count := range []int{1, 2, 3, 5, 8, 13} {
+			var leaves [][]byte
+			for i := 0; i < count; i++ {
+				leaves = append(leaves, []byte(fmt.Sprintf("audit-log-%d", i)))
+			}
+
+			tree, err := encrypt.BuildMerkleTree(leaves, hashAlgo)
+			if err != nil {
+				t.Fatalf("构建Merkle树失败: %v", err)
+			}
+			root := tree.Root()
+
+			for i, leaf := range leaves {
+				proof, err := tree.Proof(i)
+				if err != nil {
+					t.Fatalf("生成证明失败: %v", err)
+				}
+				if !encrypt.VerifyMerkleProof(root, leaf, proof, hashAlgo) {
+					t.Fatalf("叶子%d/%d的证明验证失败", i, count)
+				}
+				if encrypt.VerifyMerkleProof(root, []byte("tampered"), proof, hashAlgo) {
+					t.Fatalf("篡改的叶子不应通过验证")
+				}
+			}
+
+			// 修改任一叶子都会改变根哈希
+			leaves[count-1] = []byte("tampered")
+			tampered, _ := encrypt.BuildMerkleTree(leaves, hashAlgo)
+			if bytes.Equal(root, tampered.Root()) {
+				t.Fatalf("修改叶子后根哈希应该变化")
+			}
+		}
+	}
+
+	// 重复最后一个叶子不应得到相同的根哈希
+	a, _ := encrypt.BuildMerkleTree([][]byte{[]byte("a"), []byte("b"), []byte("c")}, encrypt.HashSHA256)
+	b, _ := encrypt.BuildMerkleTree([][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("c")}, encrypt.HashSHA256)
+	if bytes.Equal(a.Root(), b.Root()) {
+		t.Fatalf("重复叶子不应得到相同根哈希")
+	}
+
+	if _, err := encrypt.BuildMerkleTree(nil, encrypt.HashSHA256); err == nil {
+		t.Fatalf("空叶子应该失败")
+	}
+}
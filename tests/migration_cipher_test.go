@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestMigrationCipher 测试新旧加密器的双写与双读
+func TestMigrationCipher(t *testing.T) {
+	oldKey := bytes.Repeat([]byte{1}, 16)
+	newKey := bytes.Repeat([]byte{2}, 32)
+	legacy, _ := encrypt.AES(oldKey, encrypt.WithMode(encrypt.ModeCBC))
+	current, _ := encrypt.SM4(newKey[:16], encrypt.WithMode(encrypt.ModeGCM))
+
+	oldCiphertext, _ := legacy.Encrypt([]byte("legacy record"))
+
+	var hits [][]byte
+	var written [][]byte
+	migration := encrypt.NewMigrationCipher(current, legacy).
+		OnOldFormat(func(ciphertext []byte) { hits = append(hits, ciphertext) })
+
+	// 旧格式数据可读，并触发回调
+	plaintext, old, err := migration.DecryptWithFormat(oldCiphertext)
+	if err != nil || !old || string(plaintext) != "legacy record" {
+		t.Fatalf("旧格式解密失败: %v", err)
+	}
+	if len(hits) != 1 || !bytes.Equal(hits[0], oldCiphertext) {
+		t.Fatal("命中旧格式应调用回调")
+	}
+
+	// 新写入使用新格式，旧加密器无法解密
+	newCiphertext, err := migration.Encrypt([]byte("new record"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if decrypted, err := current.Decrypt(newCiphertext); err != nil || string(decrypted) != "new record" {
+		t.Fatalf("新写入应为新格式: %v", err)
+	}
+	if decrypted, err := migration.DecryptString(string(newCiphertext)); err != nil || decrypted != "new record" {
+		t.Fatalf("新格式解密失败: %v", err)
+	}
+
+	// 双写时旧格式交给回调
+	migration.WithDualWrite(func(legacyCiphertext []byte) error {
+		written = append(written, legacyCiphertext)
+		return nil
+	})
+	if _, err := migration.Encrypt([]byte("both")); err != nil {
+		t.Fatalf("双写失败: %v", err)
+	}
+	if len(written) != 1 {
+		t.Fatal("双写应输出旧格式密文")
+	}
+	if decrypted, err := legacy.Decrypt(written[0]); err != nil || string(decrypted) != "both" {
+		t.Fatalf("旧服务应能解密双写的密文: %v", err)
+	}
+
+	if _, err := migration.Decrypt([]byte("bm90IGNpcGhlcnRleHQ=")); err == nil {
+		t.Fatal("无效密文应解密失败")
+	}
+
+	metrics := migration.GetMetrics()
+	if metrics["old_hits"] != 1 || metrics["new_hits"] != 1 || metrics["failures"] != 1 || metrics["dual_writes"] != 1 {
+		t.Fatalf("指标不正确: %v", metrics)
+	}
+}
@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestMultiKeyHMACRotation 测试轮换后旧签名在保留期内仍可验证
+func TestMultiKeyHMACRotation(t *testing.T) {
+	signer, err := encrypt.NewMultiKeyHMAC("2024-01", bytes.Repeat([]byte{1}, 32))
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+	signer.KeepPrevious(1)
+
+	data := []byte(`{"order":"1001","amount":100}`)
+	oldToken := signer.Sign(data)
+	if !strings.HasPrefix(oldToken, "2024-01:") {
+		t.Fatalf("签名缺少密钥ID前缀: %s", oldToken)
+	}
+
+	if err := signer.Rotate("2024-02", bytes.Repeat([]byte{2}, 32)); err != nil {
+		t.Fatalf("轮换失败: %v", err)
+	}
+	newToken := signer.Sign(data)
+	if !strings.HasPrefix(newToken, "2024-02:") {
+		t.Fatalf("轮换后应使用新密钥: %s", newToken)
+	}
+	if id, err := signer.Verify(data, oldToken); err != nil || id != "2024-01" {
+		t.Fatalf("旧签名在保留期内应当有效: %v", err)
+	}
+	if id, err := signer.Verify(data, newToken); err != nil || id != "2024-02" {
+		t.Fatalf("新签名验证失败: %v", err)
+	}
+	if _, err := signer.Verify([]byte("tampered"), newToken); !errors.Is(err, encrypt.ErrInvalidMAC) {
+		t.Fatalf("篡改数据应当验证失败: %v", err)
+	}
+
+	// 超出保留数量后最旧的密钥被淘汰
+	if err := signer.Rotate("2024-03", bytes.Repeat([]byte{3}, 32)); err != nil {
+		t.Fatalf("轮换失败: %v", err)
+	}
+	if _, err := signer.Verify(data, oldToken); !errors.Is(err, encrypt.ErrKeyNotFound) {
+		t.Fatalf("淘汰的密钥应当无法验证: %v", err)
+	}
+	if ids := signer.IDs(); len(ids) != 2 || ids[0] != "2024-03" {
+		t.Fatalf("密钥列表不正确: %v", ids)
+	}
+
+	if err := signer.Retire("2024-02"); err != nil {
+		t.Fatalf("淘汰旧密钥失败: %v", err)
+	}
+	if _, err := signer.Verify(data, newToken); !errors.Is(err, encrypt.ErrKeyNotFound) {
+		t.Fatalf("提前淘汰后应当无法验证: %v", err)
+	}
+	if err := signer.Retire("2024-03"); err == nil {
+		t.Fatal("不应允许淘汰当前密钥")
+	}
+	if err := signer.Rotate("2024-03", bytes.Repeat([]byte{4}, 32)); err == nil {
+		t.Fatal("重复的密钥ID应当失败")
+	}
+	if _, err := encrypt.NewMultiKeyHMAC("a:b", bytes.Repeat([]byte{1}, 32)); err == nil {
+		t.Fatal("包含分隔符的密钥ID应当失败")
+	}
+}
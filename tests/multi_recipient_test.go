@@ -0,0 +1,69 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestMultiRecipient 测试混合类型的多接收方信封
+func TestMultiRecipient(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	sm2Pub, sm2Priv, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	boxPub, boxPriv, _ := encrypt.GenerateBoxKeyPair()
+
+	sm2Recipient, err := encrypt.NewRecipientPEM(sm2Pub)
+	if err != nil {
+		t.Fatalf("解析SM2公钥失败: %v", err)
+	}
+	boxRecipient, _ := encrypt.NewBoxRecipient(boxPub)
+	recipients := []encrypt.Recipient{{PublicKey: &rsaKey.PublicKey}, sm2Recipient, boxRecipient}
+
+	plaintext := []byte("quarterly report")
+	aad := []byte("report-2024Q3")
+	sealed, err := encrypt.SealForRecipients(plaintext, aad, recipients...)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	infos, _ := encrypt.ListRecipients(sealed)
+	if len(infos) != 3 || infos[0].Algorithm != "RSA" || infos[1].Algorithm != "SM2" || infos[2].Algorithm != "X25519" {
+		t.Fatalf("接收方列表不正确: %+v", infos)
+	}
+
+	// 每个接收方都能用自己的私钥解密
+	boxKey, _ := ecdh.X25519().NewPrivateKey(boxPriv)
+	if got, err := encrypt.OpenForRecipient(sealed, aad, rsaKey); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("RSA接收方解密失败: %v", err)
+	}
+	if got, err := encrypt.OpenForRecipientPEM(sealed, aad, sm2Priv); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("SM2接收方解密失败: %v", err)
+	}
+	if got, err := encrypt.OpenForRecipient(sealed, aad, boxKey); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("X25519接收方解密失败: %v", err)
+	}
+
+	// 非接收方无法解密
+	outsider, _ := rsa.GenerateKey(rand.Reader, 2048)
+	if _, err := encrypt.OpenForRecipient(sealed, aad, outsider); err == nil {
+		t.Fatal("非接收方不应能解密")
+	}
+
+	// 附加认证数据不一致或头部被修改时解密失败
+	if _, err := encrypt.OpenForRecipient(sealed, []byte("other"), rsaKey); err == nil {
+		t.Fatal("附加认证数据不一致应解密失败")
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[6] ^= 1 // 第一个接收方的指纹
+	if _, err := encrypt.OpenForRecipient(tampered, aad, boxKey); err == nil {
+		t.Fatal("头部被修改应解密失败")
+	}
+
+	if _, err := encrypt.SealForRecipients(plaintext, nil, boxRecipient, boxRecipient); err == nil {
+		t.Fatal("重复的接收方应报错")
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"crypto"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// newTestSigner 生成SM2密钥对并返回签名器与公钥PEM
+func newTestSigner(t *testing.T) (crypto.Signer, []byte) {
+	t.Helper()
+
+	pubPEM, privPEM, err := encrypt.MustNewSM2().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("生成密钥失败: %v", err)
+	}
+	signer, err := encrypt.ParseSigner(privPEM)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	return signer, pubPEM
+}
+
+// TestMultiSignature 测试多签名策略与副署
+func TestMultiSignature(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "release.bin")
+	if err := os.WriteFile(path, []byte("dual control release"), 0644); err != nil {
+		t.Fatalf("写入文件失败: %v", err)
+	}
+
+	builder, builderPub := newTestSigner(t)
+	approver, approverPub := newTestSigner(t)
+	_, outsiderPub := newTestSigner(t)
+
+	sig, err := encrypt.SignFile(builder, path, encrypt.HashSM3)
+	if err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+
+	// 只有一个签名时
+	if err := encrypt.VerifyFileAny(path, sig, [][]byte{builderPub, approverPub}); err != nil {
+		t.Fatalf("任意一个签名有效时应该通过: %v", err)
+	}
+	if err := encrypt.VerifyFileAll(path, sig, [][]byte{builderPub, approverPub}); err == nil {
+		t.Fatalf("缺少审批人签名时不应通过")
+	}
+
+	if err := sig.AddSignature(approver); err != nil {
+		t.Fatalf("追加签名失败: %v", err)
+	}
+	if err := encrypt.VerifyFileAll(path, sig, [][]byte{builderPub, approverPub}); err != nil {
+		t.Fatalf("全部签名有效时应该通过: %v", err)
+	}
+	if err := encrypt.VerifyFileThreshold(path, sig, [][]byte{builderPub, approverPub, outsiderPub}, 2); err != nil {
+		t.Fatalf("满足门限时应该通过: %v", err)
+	}
+	if err := encrypt.VerifyFileThreshold(path, sig, [][]byte{builderPub, builderPub}, 2); err == nil {
+		t.Fatalf("重复公钥不应重复计数")
+	}
+
+	// 副署
+	if err := sig.VerifyCountersignature(approverPub, builderPub); err == nil {
+		t.Fatalf("尚未副署时应该失败")
+	}
+	if err := sig.Countersign(approver, builderPub); err != nil {
+		t.Fatalf("副署失败: %v", err)
+	}
+	if err := sig.VerifyCountersignature(approverPub, builderPub); err != nil {
+		t.Fatalf("验证副署失败: %v", err)
+	}
+	if err := sig.Countersign(approver, outsiderPub); err == nil {
+		t.Fatalf("被副署者没有签名时应该失败")
+	}
+
+	// 替换被副署的签名后副署失效
+	data, _ := sig.Bytes()
+	tampered, _ := encrypt.ParseDetachedSignature(data)
+	tampered.Signatures[0].Signature = tampered.Signatures[1].Signature
+	if err := tampered.VerifyCountersignature(approverPub, builderPub); err == nil {
+		t.Fatalf("被副署签名被替换后应该失败")
+	}
+}
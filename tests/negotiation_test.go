@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+var (
+	suiteAES256GCM = encrypt.Suite{Algorithm: encrypt.AlgorithmAES, KeySize: 32, Mode: encrypt.ModeGCM}
+	suiteAES128GCM = encrypt.Suite{Algorithm: encrypt.AlgorithmAES, KeySize: 16, Mode: encrypt.ModeGCM}
+	suiteSM4GCM    = encrypt.Suite{Algorithm: encrypt.AlgorithmSM4, KeySize: 16, Mode: encrypt.ModeGCM}
+	suiteSM4CBC    = encrypt.Suite{Algorithm: encrypt.AlgorithmSM4, KeySize: 16, Mode: encrypt.ModeCBC, Padding: encrypt.PaddingPKCS7}
+	suite3DESCBC   = encrypt.Suite{Algorithm: encrypt.Algorithm3DES, KeySize: 24, Mode: encrypt.ModeCBC, Padding: encrypt.PaddingPKCS7}
+)
+
+// TestNegotiate 测试不同策略下的套件协商
+func TestNegotiate(t *testing.T) {
+	local := []encrypt.Suite{suiteSM4GCM, suiteSM4CBC, suiteAES128GCM, suiteAES256GCM, suite3DESCBC}
+	peer := []encrypt.Suite{suite3DESCBC, suiteSM4CBC, suiteAES128GCM, suiteSM4GCM, suiteAES256GCM}
+
+	for _, tc := range []struct {
+		name     string
+		policy   encrypt.NegotiationPolicy
+		expected encrypt.Suite
+	}{
+		{"强度优先", encrypt.NegotiateStrongest, suiteAES256GCM},
+		{"本地顺序", encrypt.NegotiateLocalOrder, suiteSM4GCM},
+		{"对端顺序", encrypt.NegotiatePeerOrder, suiteSM4CBC},
+	} {
+		suite, err := encrypt.NewNegotiator(local...).WithPolicy(tc.policy).Negotiate(peer)
+		if err != nil || suite != tc.expected {
+			t.Fatalf("%s协商结果不正确: %v %+v", tc.name, err, suite)
+		}
+	}
+
+	// 弱套件默认不参与协商
+	_, err := encrypt.NewNegotiator(local...).Negotiate([]encrypt.Suite{suite3DESCBC})
+	if !errors.Is(err, encrypt.ErrNoCommonSuite) {
+		t.Fatalf("弱套件不应被选中: %v", err)
+	}
+	suite, err := encrypt.NewNegotiator(local...).AllowWeak().Negotiate([]encrypt.Suite{suite3DESCBC})
+	if err != nil || suite != suite3DESCBC {
+		t.Fatalf("AllowWeak后应选中3DES: %v", err)
+	}
+}
+
+// TestSuiteNewCipher 测试协商结果创建加密器
+func TestSuiteNewCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 16)
+	for _, suite := range []encrypt.Suite{suiteSM4GCM, suiteSM4CBC, suiteAES128GCM} {
+		cipher, err := suite.NewCipher(key)
+		if err != nil {
+			t.Fatalf("创建加密器失败: %v", err)
+		}
+		ciphertext, err := cipher.Encrypt([]byte("negotiated"))
+		if err != nil {
+			t.Fatalf("加密失败: %v", err)
+		}
+		plaintext, err := cipher.Decrypt(ciphertext)
+		if err != nil || string(plaintext) != "negotiated" {
+			t.Fatalf("解密失败: %v", err)
+		}
+	}
+
+	if _, err := suiteAES256GCM.NewCipher(key); err == nil {
+		t.Fatal("密钥长度与套件不符应当失败")
+	}
+	invalid := encrypt.Suite{Algorithm: encrypt.Algorithm3DES, KeySize: 24, Mode: encrypt.ModeGCM}
+	if err := invalid.Validate(); err == nil {
+		t.Fatal("3DES-GCM应当无效")
+	}
+}
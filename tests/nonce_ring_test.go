@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestNonceRing 测试随机数预生成环
+func TestNonceRing(t *testing.T) {
+	ring := encrypt.NewNonceRing(64, 2)
+	defer ring.Close()
+
+	// 等待后台填满缓存
+	time.Sleep(20 * time.Millisecond)
+	seen := make(map[string]bool)
+	buf := make([]byte, 12)
+	for i := 0; i < 10; i++ {
+		if _, err := ring.Read(buf); err != nil {
+			t.Fatalf("读取失败: %v", err)
+		}
+		if seen[string(buf)] {
+			t.Fatal("随机数重复")
+		}
+		seen[string(buf)] = true
+	}
+
+	// 超过缓存容量的读取同步补齐
+	large := make([]byte, 1024)
+	if n, err := ring.Read(large); err != nil || n != len(large) {
+		t.Fatalf("大块读取失败: %d %v", n, err)
+	}
+	if ring.Fallbacks() == 0 {
+		t.Fatal("缓存耗尽时应记录同步读取")
+	}
+
+	ring.Close()
+	if _, err := ring.Read(buf); err != nil {
+		t.Fatalf("关闭后应同步读取: %v", err)
+	}
+}
+
+// TestNoncePregeneration 测试启用预生成后的并发加密
+func TestNoncePregeneration(t *testing.T) {
+	encrypt.EnableNoncePregeneration(256, 4)
+	defer encrypt.DisableNoncePregeneration()
+
+	key := bytes.Repeat([]byte{9}, 16)
+	plaintext := []byte("user-1024")
+
+	var mu sync.Mutex
+	nonces := make(map[string]bool)
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gcm, _ := encrypt.AES(key, encrypt.WithMode(encrypt.ModeGCM), encrypt.WithEncoding(encrypt.EncodingNone))
+			cbc, _ := encrypt.SM4(key, encrypt.WithMode(encrypt.ModeCBC), encrypt.WithEncoding(encrypt.EncodingNone))
+			for i := 0; i < 200; i++ {
+				ciphertext, err := gcm.Encrypt(plaintext)
+				if err != nil {
+					t.Errorf("GCM加密失败: %v", err)
+					return
+				}
+				if decrypted, err := gcm.Decrypt(ciphertext); err != nil || !bytes.Equal(decrypted, plaintext) {
+					t.Errorf("GCM解密失败: %v", err)
+					return
+				}
+				mu.Lock()
+				if nonces[string(ciphertext[:12])] {
+					t.Error("GCM nonce重复")
+				}
+				nonces[string(ciphertext[:12])] = true
+				mu.Unlock()
+
+				ciphertext, err = cbc.Encrypt(plaintext)
+				if err != nil {
+					t.Errorf("CBC加密失败: %v", err)
+					return
+				}
+				if decrypted, err := cbc.Decrypt(ciphertext); err != nil || !bytes.Equal(decrypted, plaintext) {
+					t.Errorf("CBC解密失败: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,90 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestFunctionalOptions 测试函数式选项构造方法
+func TestFunctionalOptions(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	plaintext := []byte("函数式选项")
+
+	testCases := []struct {
+		name    string
+		factory func([]byte, ...encrypt.Option) (encrypt.ISymmetric, error)
+		opts    []encrypt.Option
+	}{
+		{"AES默认", encrypt.AES, nil},
+		{"AES-GCM-Hex", encrypt.AES, []encrypt.Option{encrypt.WithMode(encrypt.ModeGCM), encrypt.WithEncoding(encrypt.EncodingHex)}},
+		{"AES-CBC-IV", encrypt.AES, []encrypt.Option{encrypt.WithIV([]byte("FEDCBA9876543210"))}},
+		{"SM4-CTR", encrypt.SM4, []encrypt.Option{encrypt.WithMode(encrypt.ModeCTR), encrypt.WithEncoding(encrypt.EncodingBase64Safe)}},
+		{"DES-ECB", encrypt.DES, []encrypt.Option{encrypt.WithMode(encrypt.ModeECB), encrypt.WithPadding(encrypt.PaddingPKCS7)}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			k := key
+			if tc.name == "DES-ECB" {
+				k = key[:8]
+			}
+			encryptor := encrypt.Must(tc.factory(k, tc.opts...))
+			defer encryptor.Release()
+
+			result := encrypt.Then(encrypt.Try(encryptor.Encrypt(plaintext)), encryptor.Decrypt)
+			decrypted, err := result.Unwrap()
+			if err != nil || string(decrypted) != string(plaintext) {
+				t.Fatalf("加解密失败: %v", err)
+			}
+		})
+	}
+
+	if _, err := encrypt.AES(key, encrypt.WithMode(encrypt.Mode(99))); err == nil {
+		t.Fatalf("不支持的模式应该返回错误")
+	}
+}
+
+// TestCapabilityInterfaces 测试仅部分算法支持的设置通过能力接口提供
+func TestCapabilityInterfaces(t *testing.T) {
+	for _, encryptor := range []encrypt.ISymmetric{encrypt.MustNewAES([]byte("0123456789ABCDEF")), encrypt.MustNewSM4([]byte("0123456789ABCDEF"))} {
+		if _, ok := encryptor.(encrypt.IGCMSetter); !ok {
+			t.Fatalf("%v应支持GCM模式", encryptor.Algorithm())
+		}
+	}
+	des, _ := encrypt.NewDES([]byte("01234567"))
+	if _, ok := des.(encrypt.IGCMSetter); ok {
+		t.Fatal("DES不应提供GCM模式")
+	}
+	if _, err := encrypt.TripleDES([]byte("0123456789ABCDEF01234567"), encrypt.WithMode(encrypt.ModeGCM)); err == nil {
+		t.Fatal("3DES选择GCM模式应该返回错误")
+	}
+
+	if _, ok := encrypt.MustNewRSA().(encrypt.IUIDSetter); ok {
+		t.Fatal("RSA不应提供WithUID")
+	}
+	if _, ok := encrypt.MustNewSM2().(encrypt.IKeySizer); ok {
+		t.Fatal("SM2不应提供WithKeySize")
+	}
+}
+
+// TestResult 测试泛型结果包装
+func TestResult(t *testing.T) {
+	failed := encrypt.Try("", errors.New("失败"))
+	if failed.Ok() || failed.OrElse("默认") != "默认" {
+		t.Fatalf("失败结果处理错误")
+	}
+
+	chained := encrypt.Then(failed, func(s string) (int, error) { return len(s), nil })
+	if chained.Err == nil {
+		t.Fatalf("错误应该沿链传递")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("Must应该panic")
+		}
+	}()
+	failed.Must()
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestAuditPasswordHashes 测试密码哈希审计报告
+func TestAuditPasswordHashes(t *testing.T) {
+	current := encrypt.NewPasswordHasher().Argon2id(2, 1024, 1)
+	modern, _ := current.Hash([]byte("pw"))
+	legacy, _ := encrypt.NewPasswordHasher().PBKDF2(encrypt.HashSHA1, 1000).Hash([]byte("pw"))
+	bcrypt, _ := encrypt.NewPasswordHasher().Bcrypt(4).Hash([]byte("pw"))
+
+	stored := map[string]string{
+		"u1": modern, "u2": modern, "u3": modern,
+		"u4": legacy, "u5": legacy,
+		"u6": bcrypt,
+		"u7": "5f4dcc3b5aa765d61d8327deb882cf99",
+	}
+	policy := encrypt.DefaultPasswordHashPolicy
+	policy.MinArgon2Memory = 1024
+
+	iterate := func(yield func(id, encoded string) error) error {
+		for i := 1; i <= len(stored); i++ {
+			id := fmt.Sprintf("u%d", i)
+			if err := yield(id, stored[id]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	report, err := encrypt.AuditPasswordHashes(iterate, policy, encrypt.PasswordAuditOptions{Hasher: current, MaxSamples: 2})
+	if err != nil {
+		t.Fatalf("审计失败: %v", err)
+	}
+	if report.Total != 7 || report.Compliant != 3 || report.BelowPolicy != 4 || report.Unrecognized != 1 || report.NeedsRehash != 4 {
+		t.Fatalf("统计不正确: %+v", report)
+	}
+
+	first := report.Classes[0]
+	if first.Class != "argon2id m=1024,t=2,p=1" || first.Count != 3 || first.BelowPolicy || len(first.SampleIDs) != 2 {
+		t.Fatalf("类别不正确: %+v", first)
+	}
+	second := report.Classes[1]
+	if second.Class != "pbkdf2-sha1 i=1000" || !second.BelowPolicy || len(second.Reasons) != 1 {
+		t.Fatalf("类别不正确: %+v", second)
+	}
+
+	var out bytes.Buffer
+	_ = report.WriteText(&out)
+	if !strings.Contains(out.String(), "bcrypt cost=4") || strings.Contains(out.String(), modern) {
+		t.Fatalf("报告输出不正确:\n%s", out.String())
+	}
+
+	// 迭代函数的错误原样返回
+	failing := func(yield func(id, encoded string) error) error {
+		return errors.New("数据库连接中断")
+	}
+	if _, err := encrypt.AuditPasswordHashes(failing, policy, encrypt.PasswordAuditOptions{}); err == nil {
+		t.Fatal("读取失败应返回错误")
+	}
+}
@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestPasswordHasher 测试各算法的哈希与校验
+func TestPasswordHasher(t *testing.T) {
+	password := []byte("correct horse battery staple")
+	for _, tc := range []struct {
+		name   string
+		hasher *encrypt.PasswordHasher
+		prefix string
+	}{
+		{"argon2id", encrypt.NewPasswordHasher().Argon2id(1, 8*1024, 1), "$argon2id$v=19$m=8192,t=1,p=1$"},
+		{"bcrypt", encrypt.NewPasswordHasher().Bcrypt(4), "$2a$04$"},
+		{"pbkdf2", encrypt.NewPasswordHasher().PBKDF2(encrypt.HashSHA256, 1000), "$pbkdf2-sha256$i=1000,l=32$"},
+		{"pbkdf2-sm3", encrypt.NewPasswordHasher().PBKDF2(encrypt.HashSM3, 1000), "$pbkdf2-sm3$i=1000,l=32$"},
+	} {
+		encoded, err := tc.hasher.Hash(password)
+		if err != nil {
+			t.Fatalf("%s生成哈希失败: %v", tc.name, err)
+		}
+		if !strings.HasPrefix(encoded, tc.prefix) {
+			t.Fatalf("%s哈希格式不正确: %s", tc.name, encoded)
+		}
+		valid, rehash, err := tc.hasher.Verify(password, encoded)
+		if err != nil || !valid || rehash {
+			t.Fatalf("%s校验失败: %v %v %v", tc.name, valid, rehash, err)
+		}
+		valid, _, err = tc.hasher.Verify([]byte("wrong password"), encoded)
+		if err != nil || valid {
+			t.Fatalf("%s错误密码应当校验失败: %v", tc.name, err)
+		}
+	}
+
+	if _, _, err := encrypt.NewPasswordHasher().Verify(password, "plain-md5-hash"); err == nil {
+		t.Fatal("无法识别的哈希格式应当报错")
+	}
+	if _, err := encrypt.NewPasswordHasher().PBKDF2(encrypt.HashSHA256, 100).Hash(password); err == nil {
+		t.Fatal("迭代次数过少应当失败")
+	}
+}
+
+// TestPasswordHasherUpgrade 测试旧哈希在校验时升级
+func TestPasswordHasherUpgrade(t *testing.T) {
+	password := []byte("p@ssw0rd")
+	legacy, _ := encrypt.NewPasswordHasher().PBKDF2(encrypt.HashSHA1, 1000).Hash(password)
+	oldBcrypt, _ := encrypt.NewPasswordHasher().Bcrypt(4).Hash(password)
+	current := encrypt.NewPasswordHasher().Argon2id(1, 8*1024, 1)
+
+	for _, encoded := range []string{legacy, oldBcrypt} {
+		if !current.NeedsRehash(encoded) {
+			t.Fatalf("旧哈希应当需要升级: %s", encoded)
+		}
+		valid, upgraded, err := current.VerifyAndUpgrade(password, encoded)
+		if err != nil || !valid || !strings.HasPrefix(upgraded, "$argon2id$") {
+			t.Fatalf("升级失败: %v %v %s", err, valid, upgraded)
+		}
+		valid, rehash, err := current.Verify(password, upgraded)
+		if err != nil || !valid || rehash {
+			t.Fatalf("升级后的哈希校验失败: %v", err)
+		}
+
+		// 密码错误时不生成新哈希
+		valid, upgraded, err = current.VerifyAndUpgrade([]byte("guess"), encoded)
+		if err != nil || valid || upgraded != "" {
+			t.Fatalf("错误密码不应升级: %v", err)
+		}
+	}
+
+	// 参数提高后同算法的哈希也需要升级
+	weaker, _ := encrypt.NewPasswordHasher().Argon2id(1, 8*1024, 1).Hash(password)
+	stronger := encrypt.NewPasswordHasher().Argon2id(2, 8*1024, 1)
+	if _, rehash, _ := stronger.Verify(password, weaker); !rehash {
+		t.Fatal("参数变化后应当需要升级")
+	}
+
+	info, err := encrypt.ParsePasswordHash(legacy)
+	if err != nil || info.Algorithm != encrypt.PasswordPBKDF2 || info.Hash != encrypt.HashSHA1 || info.Iterations != 1000 {
+		t.Fatalf("解析哈希参数不正确: %v %+v", err, info)
+	}
+	if info.String() != legacy {
+		t.Fatal("哈希参数往返格式化不一致")
+	}
+}
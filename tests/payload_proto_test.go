@@ -0,0 +1,123 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestEncryptedPayloadProto 测试加密载荷与来源格式、protobuf之间的往返
+func TestEncryptedPayloadProto(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 32)
+	secret := []byte("card 6222 0000 1111 2222")
+
+	ring := encrypt.NewKeyRing()
+	_ = ring.Add(encrypt.KeyEntry{ID: "pay-2024", Algorithm: encrypt.AlgorithmAES, Key: key, Usage: encrypt.KeyUsageCipher})
+
+	sealed, _ := encrypt.SealEnvelope(encrypt.AlgorithmAES, encrypt.ModeCBC, key, secret)
+	keyRing, _ := ring.Encrypt(secret, nil)
+	kms, err := encrypt.EnvelopeEncrypt(context.Background(), ring, "pay-2024", secret, []byte("order-1"))
+	if err != nil {
+		t.Fatalf("信封加密失败: %v", err)
+	}
+
+	cases := []struct {
+		name  string
+		data  []byte
+		parse func([]byte) (*encrypt.EncryptedPayload, error)
+	}{
+		{"envelope", sealed, encrypt.PayloadFromEnvelope},
+		{"keyring", keyRing, encrypt.PayloadFromKeyRing},
+		{"kms", kms, encrypt.PayloadFromKMSEnvelope},
+	}
+	for _, c := range cases {
+		payload, err := c.parse(c.data)
+		if err != nil {
+			t.Fatalf("%s: 拆分失败: %v", c.name, err)
+		}
+		payload.WithMetadata("content-type", "text/plain").WithMetadata("trace-id", "abc")
+
+		for _, codec := range []encrypt.PayloadCodec{encrypt.PayloadCodecProto, encrypt.PayloadCodecJSON} {
+			data, err := codec.Marshal(payload)
+			if err != nil {
+				t.Fatalf("%s: 序列化失败: %v", c.name, err)
+			}
+			decoded, err := codec.Unmarshal(data)
+			if err != nil {
+				t.Fatalf("%s: 解析失败: %v", c.name, err)
+			}
+			if !reflect.DeepEqual(decoded, payload) {
+				t.Fatalf("%s: 往返不一致\n%+v\n%+v", c.name, decoded, payload)
+			}
+			restored, err := decoded.Bytes()
+			if err != nil || !bytes.Equal(restored, c.data) {
+				t.Fatalf("%s: 还原的密文不一致: %v", c.name, err)
+			}
+		}
+	}
+
+	// 还原后可以直接解密，KMS信封头部参与认证
+	payload, _ := encrypt.PayloadFromKMSEnvelope(kms)
+	data, _ := payload.Marshal()
+	decoded, _ := encrypt.UnmarshalEncryptedPayload(data)
+	restored, _ := decoded.Bytes()
+	if plaintext, err := encrypt.EnvelopeDecrypt(context.Background(), ring, restored, []byte("order-1")); err != nil || !bytes.Equal(plaintext, secret) {
+		t.Fatalf("还原后解密失败: %v", err)
+	}
+
+	// 元数据顺序不影响输出
+	a := &encrypt.EncryptedPayload{Format: encrypt.PayloadFormatKeyRing, KeyID: "k", Ciphertext: []byte{1}}
+	b := &encrypt.EncryptedPayload{Format: encrypt.PayloadFormatKeyRing, KeyID: "k", Ciphertext: []byte{1}}
+	for _, k := range []string{"a", "b", "c", "d"} {
+		a.WithMetadata(k, "v")
+	}
+	for _, k := range []string{"d", "c", "b", "a"} {
+		b.WithMetadata(k, "v")
+	}
+	first, _ := a.Marshal()
+	second, _ := b.Marshal()
+	if !bytes.Equal(first, second) {
+		t.Fatal("相同载荷的序列化结果应一致")
+	}
+}
+
+// TestEncryptedPayloadWireFormat 测试线路格式与protoc生成代码一致
+func TestEncryptedPayloadWireFormat(t *testing.T) {
+	payload := &encrypt.EncryptedPayload{
+		Format:     encrypt.PayloadFormatEnvelope,
+		Version:    2,
+		Algorithm:  encrypt.AlgorithmSM4,
+		Mode:       encrypt.ModeGCM,
+		IV:         []byte{0xaa},
+		Ciphertext: []byte{0x01, 0x02},
+		Metadata:   map[string]string{"k": "v"},
+	}
+	data, _ := payload.Marshal()
+	expected := []byte{
+		0x08, 0x01, // format = ENVELOPE
+		0x10, 0x02, // version = 2
+		0x18, 0x04, // algorithm = SM4
+		0x20, 0x06, // mode = GCM
+		0x32, 0x01, 0xaa, // iv
+		0x4a, 0x02, 0x01, 0x02, // ciphertext
+		0x52, 0x06, 0x0a, 0x01, 'k', 0x12, 0x01, 'v', // metadata
+	}
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("线路格式不正确: %x", data)
+	}
+
+	// 未知字段被忽略，截断的数据报错
+	withUnknown := append(append([]byte(nil), data...), 0x78, 0x01)
+	if decoded, err := encrypt.UnmarshalEncryptedPayload(withUnknown); err != nil || decoded.Mode != encrypt.ModeGCM {
+		t.Fatalf("应忽略未知字段: %v", err)
+	}
+	if _, err := encrypt.UnmarshalEncryptedPayload(data[:len(data)-3]); err == nil {
+		t.Fatal("截断的数据应报错")
+	}
+	if _, err := (&encrypt.EncryptedPayload{}).Bytes(); err == nil {
+		t.Fatal("未指定格式应报错")
+	}
+}
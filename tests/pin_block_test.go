@@ -0,0 +1,95 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestPINBlockFormat0 测试ISO 9564-1格式0样例
+func TestPINBlockFormat0(t *testing.T) {
+	for _, c := range []struct {
+		pin, pan, expected string
+	}{
+		// PIN字段 041234FFFFFFFFFF，卡号字段 0000987654321098
+		{"1234", "43219876543210987", "0412AC89ABCDEF67"},
+		// PIN字段 041234FFFFFFFFFF，卡号字段 0000111111111111
+		{"1234", "4111111111111111", "041225EEEEEEEEEE"},
+		// 12位PIN，PIN字段 0C123456789012FF
+		{"123456789012", "4111111111111111", "0C122547698103EE"},
+	} {
+		block, err := encrypt.PINBlockFormat0(c.pin, c.pan)
+		if err != nil || strings.ToUpper(hex.EncodeToString(block)) != c.expected {
+			t.Fatalf("PIN %s 卡号 %s 的PIN块不正确: %v %X", c.pin, c.pan, err, block)
+		}
+		pin, err := encrypt.ParsePINBlockFormat0(block, c.pan)
+		if err != nil || pin != c.pin {
+			t.Fatalf("解析PIN块失败: %v %s", err, pin)
+		}
+	}
+
+	for _, c := range []struct{ pin, pan string }{
+		{"123", "4111111111111111"},
+		{"1234567890123", "4111111111111111"},
+		{"12a4", "4111111111111111"},
+		{"1234", "411111111111"},
+	} {
+		if _, err := encrypt.PINBlockFormat0(c.pin, c.pan); err == nil {
+			t.Fatalf("PIN %s 卡号 %s 应被拒绝", c.pin, c.pan)
+		}
+	}
+
+	block, _ := hex.DecodeString("0412AC89ABCDEF67")
+	if _, err := encrypt.ParsePINBlockFormat0(block, "4111111111111111"); err == nil {
+		t.Fatal("卡号不匹配时解析应失败")
+	}
+}
+
+// TestPINBlockFormat4 测试ISO 9564-1格式4样例
+func TestPINBlockFormat4(t *testing.T) {
+	key, _ := hex.DecodeString("00112233445566778899AABBCCDDEEFF")
+	random, _ := hex.DecodeString("2F69ADDE2E9E7ACE")
+	pan := "432198765432109870"
+
+	// 标准中的明文字段，按 E(K, E(K, PIN字段) ⊕ 卡号字段) 计算期望值
+	pinField, _ := hex.DecodeString("441234AAAAAAAAAA2F69ADDE2E9E7ACE")
+	panField, _ := hex.DecodeString("64321987654321098700000000000000")
+	block, _ := aes.NewCipher(key)
+	expected := make([]byte, 16)
+	block.Encrypt(expected, pinField)
+	for i := range expected {
+		expected[i] ^= panField[i]
+	}
+	block.Encrypt(expected, expected)
+	// 与OpenSSL AES-128-ECB分步计算的结果一致
+	if strings.ToUpper(hex.EncodeToString(expected)) != "46FAB89E6A2B47336B4420F7B465419D" {
+		t.Fatalf("期望值计算不正确: %X", expected)
+	}
+
+	encrypt.SetRandomReader(bytes.NewReader(random))
+	encrypted, err := encrypt.EncryptPINBlockFormat4(key, "1234", pan)
+	encrypt.SetRandomReader(nil)
+	if err != nil || !bytes.Equal(encrypted, expected) {
+		t.Fatalf("格式4 PIN块不正确: %v %X", err, encrypted)
+	}
+	if pin, err := encrypt.DecryptPINBlockFormat4(key, encrypted, pan); err != nil || pin != "1234" {
+		t.Fatalf("格式4 PIN块解密失败: %v %s", err, pin)
+	}
+
+	// 不足12位的卡号左补0
+	short := "123456789"
+	encrypted, err = encrypt.EncryptPINBlockFormat4(key, "123456", short)
+	if err != nil {
+		t.Fatalf("格式4 PIN块加密失败: %v", err)
+	}
+	if pin, err := encrypt.DecryptPINBlockFormat4(key, encrypted, short); err != nil || pin != "123456" {
+		t.Fatalf("格式4 PIN块解密失败: %v %s", err, pin)
+	}
+	if _, err := encrypt.DecryptPINBlockFormat4(key, encrypted, "123456780"); err == nil {
+		t.Fatal("卡号不匹配时解密应失败")
+	}
+}
@@ -149,7 +149,7 @@ func BenchmarkRSA_WithoutPool(b *testing.B) {
 
 	// 生成RSA密钥对
 	rsa, _ := encrypt.NewRSA()
-	pubKey, privKey, _ := rsa.WithKeySize(2048).GenerateKeyPair()
+	pubKey, privKey, _ := rsa.(encrypt.IKeySizer).WithKeySize(2048).GenerateKeyPair()
 
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -173,7 +173,7 @@ func BenchmarkRSA_WithPool(b *testing.B) {
 
 	// 生成RSA密钥对
 	rsa, _ := encrypt.NewRSA()
-	pubKey, privKey, _ := rsa.WithKeySize(2048).GenerateKeyPair()
+	pubKey, privKey, _ := rsa.(encrypt.IKeySizer).WithKeySize(2048).GenerateKeyPair()
 
 	b.ReportAllocs()
 	b.ResetTimer()
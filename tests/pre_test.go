@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestProxyReEncrypt 测试代理重加密：Alice授权Bob，代理转换密文
+func TestProxyReEncrypt(t *testing.T) {
+	alice, _ := encrypt.GeneratePREKey()
+	bob, _ := encrypt.GeneratePREKey()
+	carol, _ := encrypt.GeneratePREKey()
+
+	plaintext := []byte("shared contract draft")
+	aad := []byte("doc-42")
+	ciphertext, err := encrypt.PREEncrypt(alice.PublicKey(), plaintext, aad)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if got, err := encrypt.PREDecrypt(alice, ciphertext, aad); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("Alice解密失败: %v", err)
+	}
+
+	reKey, err := encrypt.PREReKey(alice, bob.PublicKey())
+	if err != nil {
+		t.Fatalf("生成重加密密钥失败: %v", err)
+	}
+	transformed, err := encrypt.PREReEncrypt(reKey, ciphertext)
+	if err != nil {
+		t.Fatalf("重加密失败: %v", err)
+	}
+	got, err := encrypt.PREDecryptReEncrypted(bob, alice.PublicKey(), transformed, aad)
+	if err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("Bob解密失败: %v", err)
+	}
+
+	// 未被授权的Carol、错误的aad、错误的授权方公钥都无法解密
+	if _, err := encrypt.PREDecryptReEncrypted(carol, alice.PublicKey(), transformed, aad); err == nil {
+		t.Fatal("未授权方不应解密成功")
+	}
+	if _, err := encrypt.PREDecryptReEncrypted(bob, alice.PublicKey(), transformed, []byte("doc-43")); err == nil {
+		t.Fatal("aad不同时不应解密成功")
+	}
+	if _, err := encrypt.PREDecryptReEncrypted(bob, carol.PublicKey(), transformed, aad); err == nil {
+		t.Fatal("授权方公钥不符时应报错")
+	}
+
+	// 密文类型与解密方式不符
+	if _, err := encrypt.PREDecrypt(bob, transformed, aad); err == nil {
+		t.Fatal("重加密密文不能直接解密")
+	}
+	if _, err := encrypt.PREReEncrypt(reKey, transformed); err == nil {
+		t.Fatal("不应重复重加密")
+	}
+
+	// 篡改胶囊后代理拒绝转换
+	tampered := bytes.Clone(ciphertext)
+	tampered[70] ^= 0x01
+	if _, err := encrypt.PREReEncrypt(reKey, tampered); err == nil {
+		t.Fatal("篡改的胶囊应被代理拒绝")
+	}
+
+	// 私钥可以序列化后恢复
+	restored, err := encrypt.NewPREPrivateKey(bob.Bytes())
+	if err != nil || !bytes.Equal(restored.PublicKey(), bob.PublicKey()) {
+		t.Fatalf("恢复私钥失败: %v", err)
+	}
+	if got, err := encrypt.PREDecryptReEncrypted(restored, alice.PublicKey(), transformed, aad); err != nil || !bytes.Equal(got, plaintext) {
+		t.Fatalf("恢复的私钥解密失败: %v", err)
+	}
+}
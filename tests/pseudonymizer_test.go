@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestPseudonymizer 测试按字段策略的令牌化、假名化与掩码
+func TestPseudonymizer(t *testing.T) {
+	key, _ := encrypt.GenerateRandomBytes(32)
+	p, err := encrypt.NewPseudonymizer(key)
+	if err != nil {
+		t.Fatalf("创建假名化器失败: %v", err)
+	}
+	for _, field := range []string{"phone", "id_card"} {
+		if _, err := p.Tokenize(field, encrypt.FPEDigits); err != nil {
+			t.Fatalf("配置令牌化字段失败: %v", err)
+		}
+	}
+	p.Pseudonymize("email").Mask("name", 1, 0)
+
+	record := map[string]string{
+		"phone":   "13800138000",
+		"id_card": "11010119900307451X",
+		"email":   "user@example.com",
+		"name":    "张三丰",
+		"city":    "北京",
+	}
+	out, err := p.ApplyRecord(record)
+	if err != nil {
+		t.Fatalf("处理记录失败: %v", err)
+	}
+
+	if !regexp.MustCompile(`^\d{11}$`).MatchString(out["phone"]) || out["phone"] == record["phone"] {
+		t.Fatalf("手机号令牌应保持11位数字: %s", out["phone"])
+	}
+	if !regexp.MustCompile(`^\d{17}X$`).MatchString(out["id_card"]) {
+		t.Fatalf("身份证令牌应保留末位X: %s", out["id_card"])
+	}
+	if len(out["email"]) != 32 {
+		t.Fatalf("假名应为128位十六进制: %s", out["email"])
+	}
+	if out["name"] != "张**" || out["city"] != "北京" {
+		t.Fatalf("掩码或未配置字段不正确: %s %s", out["name"], out["city"])
+	}
+
+	again, _ := p.Apply("phone", "13800138000")
+	if again != out["phone"] {
+		t.Fatal("令牌化应是确定性的")
+	}
+	phone, err := p.Reveal("phone", out["phone"])
+	if err != nil || phone != "13800138000" {
+		t.Fatalf("还原失败: %v %s", err, phone)
+	}
+	if _, err := p.Reveal("email", out["email"]); err == nil {
+		t.Fatal("假名不应可以还原")
+	}
+
+	// 不同字段的相同值结果不同
+	if _, err := p.Tokenize("backup_phone", encrypt.FPEDigits); err != nil {
+		t.Fatalf("配置令牌化字段失败: %v", err)
+	}
+	if other, _ := p.Apply("backup_phone", "13800138000"); other == out["phone"] {
+		t.Fatal("不同字段应使用不同的tweak")
+	}
+
+	if masked := encrypt.MaskString("13800138000", 3, 4); masked != "138****8000" {
+		t.Fatalf("掩码结果不正确: %s", masked)
+	}
+}
+
+// TestPseudonymizerInvalidAlphabet 测试无效的令牌化字符表返回错误
+func TestPseudonymizerInvalidAlphabet(t *testing.T) {
+	key, _ := encrypt.GenerateRandomBytes(32)
+	p, _ := encrypt.NewPseudonymizer(key)
+	p.Mask("phone", 3, 4)
+
+	for _, alphabet := range []string{"", "0", "0120"} {
+		if _, err := p.Tokenize("phone", alphabet); err == nil {
+			t.Fatalf("字符表%q应被拒绝", alphabet)
+		}
+	}
+	if p.Policy("phone") != encrypt.PolicyMask {
+		t.Fatal("配置失败时不应修改字段规则")
+	}
+	if _, err := p.Tokenize("phone", "01"); err != nil {
+		t.Fatalf("二进制字符表应可以使用: %v", err)
+	}
+}
@@ -0,0 +1,49 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestExperimentalInsecureRangeIndex 测试范围索引的比较结果与明文一致
+func TestExperimentalInsecureRangeIndex(t *testing.T) {
+	index, err := encrypt.NewExperimentalInsecureRangeIndex([]byte("0123456789ABCDEF"))
+	if err != nil {
+		t.Fatalf("创建范围索引失败: %v", err)
+	}
+
+	values := []int64{-1 << 40, -5, -1, 0, 1, 2, 3, 1000, 1 << 40, 1<<62 + 7}
+	for _, x := range values {
+		for _, y := range values {
+			want := 0
+			if x < y {
+				want = -1
+			} else if x > y {
+				want = 1
+			}
+
+			got, err := encrypt.CompareRangeIndex(index.EncryptInt64(x), index.EncryptInt64(y))
+			if err != nil {
+				t.Fatalf("比较失败: %v", err)
+			}
+			if got != want {
+				t.Fatalf("比较%d与%d: 期望%d，实际%d", x, y, want, got)
+			}
+		}
+	}
+
+	now := time.Now()
+	got, _ := encrypt.CompareRangeIndex(index.EncryptTime(now), index.EncryptTime(now.Add(time.Second)))
+	if got != -1 {
+		t.Fatalf("时间戳比较结果不正确: %d", got)
+	}
+
+	if _, err := encrypt.CompareRangeIndex([]byte("short"), index.Encrypt(1)); err == nil {
+		t.Fatalf("长度不正确时应该失败")
+	}
+	if _, err := encrypt.NewExperimentalInsecureRangeIndex([]byte("short")); err == nil {
+		t.Fatalf("密钥过短时应该失败")
+	}
+}
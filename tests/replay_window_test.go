@@ -0,0 +1,93 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestReplayWindow 测试滑动窗口防重放
+func TestReplayWindow(t *testing.T) {
+	window, err := encrypt.NewReplayWindow(64)
+	if err != nil {
+		t.Fatalf("创建窗口失败: %v", err)
+	}
+
+	steps := []struct {
+		seq    uint64
+		accept bool
+	}{
+		{0, true},
+		{0, false},  // 重放
+		{5, true},   // 跳跃
+		{3, true},   // 窗口内乱序
+		{3, false},  // 重放
+		{70, true},  // 窗口滑动
+		{5, false},  // 已滑出窗口
+		{7, true},   // 仍在窗口内
+		{200, true}, // 一次滑过整个窗口
+		{70, false}, // 已滑出窗口
+		{199, true},
+	}
+
+	for i, step := range steps {
+		err := window.Update(step.seq)
+		if step.accept && err != nil {
+			t.Fatalf("步骤%d: 序号%d应该被接受: %v", i, step.seq, err)
+		}
+		if !step.accept && err == nil {
+			t.Fatalf("步骤%d: 序号%d应该被拒绝", i, step.seq)
+		}
+	}
+
+	if top, ok := window.Highest(); !ok || top != 200 {
+		t.Fatalf("最大序号不正确: %d", top)
+	}
+
+	window.Reset()
+	if err := window.Update(0); err != nil {
+		t.Fatalf("重置后应该接受任意序号: %v", err)
+	}
+}
+
+// TestSessionCipherOpenWindow 测试数据报解密的防重放
+func TestSessionCipherOpenWindow(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	client, _ := encrypt.NewSessionCipher(encrypt.AlgorithmAES, key, true)
+	server, _ := encrypt.NewSessionCipher(encrypt.AlgorithmAES, key, false)
+	window, _ := encrypt.NewReplayWindow(encrypt.DefaultReplayWindowSize)
+
+	type datagram struct {
+		seq    uint64
+		record []byte
+	}
+	var datagrams []datagram
+	for i := 0; i < 3; i++ {
+		seq := client.SendSequence()
+		record, err := client.Seal([]byte("datagram"), nil)
+		if err != nil {
+			t.Fatalf("加密失败: %v", err)
+		}
+		datagrams = append(datagrams, datagram{seq, record})
+	}
+
+	// 乱序到达
+	for _, i := range []int{2, 0, 1} {
+		if _, err := server.OpenWindow(window, datagrams[i].seq, datagrams[i].record, nil); err != nil {
+			t.Fatalf("解密失败: %v", err)
+		}
+	}
+
+	// 重放
+	if _, err := server.OpenWindow(window, datagrams[1].seq, datagrams[1].record, nil); err == nil {
+		t.Fatalf("重放消息应该被拒绝")
+	}
+
+	// 伪造的消息不应推动窗口
+	if _, err := server.OpenWindow(window, 1000, []byte("forged datagram"), nil); err == nil {
+		t.Fatalf("伪造消息应该解密失败")
+	}
+	if top, _ := window.Highest(); top != 2 {
+		t.Fatalf("伪造消息不应更新窗口: %d", top)
+	}
+}
@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestRequestSigner 测试请求签名与验证
+func TestRequestSigner(t *testing.T) {
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	newRequest := func() *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, "https://api.internal/v1/orders/a b?z=1&a=2&a=1", strings.NewReader(`{"sku":"x"}`))
+		req.Header.Set("Content-Type", "application/json")
+		return req
+	}
+	secret := []byte("shared-secret-0123456789")
+	signer := encrypt.NewRequestSigner("team-a", secret).WithScope("cn-north/orders").WithClock(clock)
+	verifier := encrypt.NewRequestSigner("", nil).WithScope("cn-north/orders").WithClock(clock).
+		WithKeyLookup(func(keyID string) ([]byte, error) {
+			if keyID != "team-a" {
+				return nil, errors.New("未知调用方")
+			}
+			return secret, nil
+		})
+
+	req := newRequest()
+	if err := signer.Sign(req); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "HMAC-SHA256 Credential=team-a/20240301/cn-north/orders, SignedHeaders=content-type;host;x-sign-date, Signature=") {
+		t.Fatalf("Authorization头不正确: %s", auth)
+	}
+	if id, err := verifier.Verify(req); err != nil || id != "team-a" {
+		t.Fatalf("验证失败: %v", err)
+	}
+
+	canonical := signer.CanonicalRequest(req, []string{"host"}, nil)
+	if !strings.HasPrefix(canonical, "POST\n/v1/orders/a%20b\na=1&a=2&z=1\nhost:api.internal\n") {
+		t.Fatalf("规范请求不正确: %q", canonical)
+	}
+
+	// 篡改请求的任一部分都会导致验证失败
+	tamper := map[string]func(r *http.Request){
+		"查询串": func(r *http.Request) { r.URL.RawQuery = "z=1&a=2&a=3" },
+		"路径":  func(r *http.Request) { r.URL.Path = "/v1/orders/other" },
+		"方法":  func(r *http.Request) { r.Method = http.MethodPut },
+		"头部":  func(r *http.Request) { r.Header.Set("Content-Type", "text/plain") },
+		"请求体": func(r *http.Request) { r.Body = http.NoBody },
+	}
+	for name, modify := range tamper {
+		req := newRequest()
+		_ = signer.Sign(req)
+		modify(req)
+		if _, err := verifier.Verify(req); !errors.Is(err, encrypt.ErrInvalidMAC) {
+			t.Fatalf("篡改%s应当验证失败: %v", name, err)
+		}
+	}
+
+	// 过期与范围不符
+	req = newRequest()
+	_ = signer.Sign(req)
+	late := encrypt.NewRequestSigner("team-a", secret).WithScope("cn-north/orders").
+		WithClock(func() time.Time { return now.Add(10 * time.Minute) })
+	if _, err := late.Verify(req); err == nil {
+		t.Fatal("超出容忍范围应当失败")
+	}
+	if _, err := encrypt.NewRequestSigner("team-a", secret).WithClock(clock).Verify(req); err == nil {
+		t.Fatal("凭证范围不符应当失败")
+	}
+	if _, err := verifier.WithSignedHeaders("X-Tenant").Verify(func() *http.Request {
+		r := newRequest()
+		_ = signer.Sign(r)
+		r.Header.Set("X-Tenant", "t1")
+		return r
+	}()); err == nil {
+		t.Fatal("必须签名的头部未签名时应当失败")
+	}
+}
+
+// TestRequestSignerKeyDerivation 测试SigV4方式的密钥派生与SM3
+func TestRequestSignerKeyDerivation(t *testing.T) {
+	// AWS文档中的派生示例
+	derive := encrypt.SigV4KeyDerivation("AWS4", "aws4_request")
+	key := derive([]byte("wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"), "20120215", "us-east-1/iam", encrypt.HashSHA256)
+	if hex.EncodeToString(key) != "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d" {
+		t.Fatalf("派生密钥与参考值不一致: %x", key)
+	}
+
+	now := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	newSigner := func() *encrypt.RequestSigner {
+		return encrypt.NewRequestSigner("svc", []byte("sm3-secret")).WithHash(encrypt.HashSM3).
+			WithScope("gm").WithKeyDerivation(encrypt.SigV4KeyDerivation("GM", "request")).
+			WithClock(func() time.Time { return now })
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://api.internal/v1/ping", nil)
+	if err := newSigner().Sign(req); err != nil {
+		t.Fatalf("签名失败: %v", err)
+	}
+	if !strings.HasPrefix(req.Header.Get("Authorization"), "HMAC-SM3 ") {
+		t.Fatalf("算法标识不正确: %s", req.Header.Get("Authorization"))
+	}
+	if _, err := newSigner().Verify(req); err != nil {
+		t.Fatalf("验证失败: %v", err)
+	}
+	if _, err := encrypt.NewRequestSigner("svc", []byte("sm3-secret")).WithHash(encrypt.HashSM3).WithScope("gm").
+		WithClock(func() time.Time { return now }).Verify(req); !errors.Is(err, encrypt.ErrInvalidMAC) {
+		t.Fatalf("派生方式不同应当验证失败: %v", err)
+	}
+}
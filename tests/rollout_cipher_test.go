@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// brokenCipher 总是失败的加密器，模拟配置错误的新算法
+type brokenCipher struct{}
+
+func (brokenCipher) Encrypt([]byte) ([]byte, error) { return nil, errors.New("broken") }
+func (brokenCipher) Decrypt([]byte) ([]byte, error) { return nil, errors.New("broken") }
+
+// TestRolloutCipher 测试按比例灰度与按ID解密
+func TestRolloutCipher(t *testing.T) {
+	stable, _ := encrypt.AES(bytes.Repeat([]byte{1}, 16), encrypt.WithMode(encrypt.ModeGCM))
+	canary, _ := encrypt.SM4(bytes.Repeat([]byte{2}, 16), encrypt.WithMode(encrypt.ModeGCM))
+
+	rollout, err := encrypt.NewRolloutCipher("aes-v1", stable, "sm4-v2", canary)
+	if err != nil {
+		t.Fatalf("创建失败: %v", err)
+	}
+
+	// 比例为0时全部使用稳定配置
+	ciphertext, _ := rollout.Encrypt([]byte("hello"))
+	if id, _, _ := encrypt.SplitKeyID(ciphertext); id != "aes-v1" {
+		t.Fatalf("比例为0时应使用稳定配置，实际为%s", id)
+	}
+
+	rollout.WithPercent(100)
+	canaryCiphertext, _ := rollout.Encrypt([]byte("hello"))
+	if id, _, _ := encrypt.SplitKeyID(canaryCiphertext); id != "sm4-v2" {
+		t.Fatalf("比例为100时应使用金丝雀配置，实际为%s", id)
+	}
+
+	// 调整比例后两种密文都能解密
+	rollout.WithPercent(30)
+	for _, data := range [][]byte{ciphertext, canaryCiphertext} {
+		if plaintext, err := rollout.Decrypt(data); err != nil || string(plaintext) != "hello" {
+			t.Fatalf("解密失败: %v", err)
+		}
+	}
+	for i := 0; i < 400; i++ {
+		if _, err := rollout.EncryptString("x"); err != nil {
+			t.Fatalf("加密失败: %v", err)
+		}
+	}
+	metrics := rollout.GetMetrics()
+	if metrics["canary_writes"] < 60 || metrics["canary_writes"] > 200 {
+		t.Fatalf("金丝雀比例偏离过大: %v", metrics)
+	}
+
+	if _, err := encrypt.NewRolloutCipher("same", stable, "same", canary); err == nil {
+		t.Fatal("相同的ID应报错")
+	}
+}
+
+// TestRolloutCipherFallback 测试错误率过高时自动回退
+func TestRolloutCipherFallback(t *testing.T) {
+	stable, _ := encrypt.AES(bytes.Repeat([]byte{1}, 16), encrypt.WithMode(encrypt.ModeGCM))
+
+	var fallbackRate float64
+	rollout, _ := encrypt.NewRolloutCipher("aes-v1", stable, "broken-v2", brokenCipher{})
+	rollout.WithPercent(100).
+		WithAutoFallback(0.2, 5).
+		OnFallback(func(rate float64) { fallbackRate = rate })
+
+	// 金丝雀失败时改用稳定配置，业务请求不受影响
+	for i := 0; i < 10; i++ {
+		ciphertext, err := rollout.Encrypt([]byte("hello"))
+		if err != nil {
+			t.Fatalf("金丝雀失败时应回落到稳定配置: %v", err)
+		}
+		if id, _, _ := encrypt.SplitKeyID(ciphertext); id != "aes-v1" {
+			t.Fatalf("应使用稳定配置，实际为%s", id)
+		}
+	}
+	if !rollout.FellBack() || rollout.Percent() != 0 || fallbackRate != 1 {
+		t.Fatalf("应自动回退: %v %v %v", rollout.FellBack(), rollout.Percent(), fallbackRate)
+	}
+	if metrics := rollout.GetMetrics(); metrics["canary_ops"] != 5 {
+		t.Fatalf("回退后不应再路由到金丝雀: %v", metrics)
+	}
+
+	rollout.Resume()
+	if rollout.FellBack() || rollout.Percent() != 100 {
+		t.Fatal("Resume应恢复路由")
+	}
+}
@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestRSABlindSignature 测试RFC 9474盲签名的发放与验证流程
+func TestRSABlindSignature(t *testing.T) {
+	issuer := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+	publicPEM, _, err := issuer.GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	// 用户只持有签发方公钥
+	client := encrypt.MustNewRSA().WithPublicKey(publicPEM).(*encrypt.RSAEncryptor)
+	voucher := []byte("voucher-7f3a9c")
+
+	variants := []encrypt.BlindRSAVariant{
+		encrypt.BlindRSASHA384PSSRandomized,
+		encrypt.BlindRSASHA384PSSZeroRandomized,
+		encrypt.BlindRSASHA384PSSDeterministic,
+		encrypt.BlindRSASHA384PSSZeroDeterministic,
+	}
+	for _, variant := range variants {
+		t.Run(variant.String(), func(t *testing.T) {
+			blinded, state, err := client.Blind(variant, voucher)
+			if err != nil {
+				t.Fatalf("盲化失败: %v", err)
+			}
+			blindSig, err := issuer.BlindSign(blinded)
+			if err != nil {
+				t.Fatalf("盲签名失败: %v", err)
+			}
+			signature, err := client.Unblind(state, blindSig)
+			if err != nil {
+				t.Fatalf("去盲化失败: %v", err)
+			}
+
+			msg := state.Message()
+			if randomized := len(msg) != len(voucher); randomized != (variant == encrypt.BlindRSASHA384PSSRandomized || variant == encrypt.BlindRSASHA384PSSZeroRandomized) {
+				t.Fatalf("消息前缀不正确: %d字节", len(msg))
+			}
+			if valid, err := issuer.VerifyBlindSignature(variant, msg, signature); err != nil || !valid {
+				t.Fatalf("验证签名失败: %v, 结果: %v", err, valid)
+			}
+			if valid, _ := issuer.VerifyBlindSignature(variant, []byte("voucher-other"), signature); valid {
+				t.Fatal("其他消息不应验证通过")
+			}
+		})
+	}
+
+	// 签名是标准的RSASSA-PSS签名
+	raw := encrypt.MustNewRSA().WithPublicKey(publicPEM).NoEncoding().(*encrypt.RSAEncryptor)
+	rawIssuer := issuer.NoEncoding().(*encrypt.RSAEncryptor)
+	blinded, state, _ := raw.Blind(encrypt.BlindRSASHA384PSSRandomized, voucher)
+	blindSig, _ := rawIssuer.BlindSign(blinded)
+	signature, err := raw.Unblind(state, blindSig)
+	if err != nil {
+		t.Fatalf("去盲化失败: %v", err)
+	}
+	block, _ := pem.Decode(publicPEM)
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		pub, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	}
+	if err != nil {
+		t.Fatalf("解析公钥失败: %v", err)
+	}
+	digest := sha512.Sum384(state.Message())
+	if err := rsa.VerifyPSS(pub.(*rsa.PublicKey), crypto.SHA384, digest[:], signature, &rsa.PSSOptions{SaltLength: sha512.Size384}); err != nil {
+		t.Fatalf("标准PSS验签失败: %v", err)
+	}
+
+	// 盲化消息不泄露原文：同一消息两次盲化结果不同
+	first, _, _ := raw.Blind(encrypt.BlindRSASHA384PSSZeroDeterministic, voucher)
+	second, _, _ := raw.Blind(encrypt.BlindRSASHA384PSSZeroDeterministic, voucher)
+	if bytes.Equal(first, second) {
+		t.Fatal("盲化结果不应相同")
+	}
+
+	// 签名被篡改后去盲化失败
+	blindSig[len(blindSig)-1] ^= 0x01
+	if _, err := raw.Unblind(state, blindSig); err == nil {
+		t.Fatal("篡改的盲签名应被拒绝")
+	}
+}
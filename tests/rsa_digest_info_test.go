@@ -0,0 +1,75 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestDigestInfo 测试DigestInfo编码与解析
+func TestDigestInfo(t *testing.T) {
+	digest := sha512.Sum512([]byte("digest info"))
+	info, err := encrypt.EncodeDigestInfo(encrypt.HashSHA512, digest[:])
+	if err != nil {
+		t.Fatalf("编码DigestInfo失败: %v", err)
+	}
+	if len(info) != 83 || info[0] != 0x30 {
+		t.Fatalf("DigestInfo格式不正确: %X", info)
+	}
+
+	hashAlgo, parsed, err := encrypt.ParseDigestInfo(info)
+	if err != nil || hashAlgo != encrypt.HashSHA512 || !bytes.Equal(parsed, digest[:]) {
+		t.Fatalf("解析DigestInfo失败: %v", err)
+	}
+	if _, err := encrypt.EncodeDigestInfo(encrypt.HashSHA256, digest[:]); err == nil {
+		t.Fatal("摘要长度不匹配应当失败")
+	}
+	if _, _, err := encrypt.ParseDigestInfo(info[1:]); err == nil {
+		t.Fatal("格式错误的DigestInfo应当失败")
+	}
+}
+
+// TestSignRaw 测试NONEwithRSA签名与标准PKCS#1 v1.5签名互通
+func TestSignRaw(t *testing.T) {
+	rsaEncryptor := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+	if _, _, err := rsaEncryptor.GenerateKeyPair(); err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	data := []byte("智能卡中间件预先构造DigestInfo")
+
+	// 中间件构造的SHA-256 DigestInfo签名可用原文验证
+	digest := sha256.Sum256(data)
+	info, _ := encrypt.EncodeDigestInfo(encrypt.HashSHA256, digest[:])
+	signature, err := rsaEncryptor.SignRaw(info)
+	if err != nil {
+		t.Fatalf("NONEwithRSA签名失败: %v", err)
+	}
+	valid, err := rsaEncryptor.Verify(data, signature)
+	if err != nil || !valid {
+		t.Fatalf("原文验签失败: %v, 结果: %v", err, valid)
+	}
+	valid, _ = rsaEncryptor.VerifyRaw(info, signature)
+	if !valid {
+		t.Fatal("NONEwithRSA验签失败")
+	}
+
+	// 其他摘要算法
+	sum, _ := encrypt.NewSM3().NoEncoding().Sum(data)
+	sm3 := []byte(sum)
+	signature, err = rsaEncryptor.SignDigestWith(encrypt.HashSM3, sm3)
+	if err != nil {
+		t.Fatalf("SM3摘要签名失败: %v", err)
+	}
+	valid, _ = rsaEncryptor.VerifyDigestWith(encrypt.HashSM3, sm3, signature)
+	if !valid {
+		t.Fatal("SM3摘要验签失败")
+	}
+	sm3[0] ^= 1
+	valid, _ = rsaEncryptor.VerifyDigestWith(encrypt.HashSM3, sm3, signature)
+	if valid {
+		t.Fatal("篡改摘要后验签应当失败")
+	}
+}
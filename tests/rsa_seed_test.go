@@ -0,0 +1,42 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestRSAGenerateKeyPairFromSeed 测试从种子确定性生成RSA密钥对
+func TestRSAGenerateKeyPairFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte("fixture-seed"), 4)
+
+	generate := func(seed []byte) ([]byte, []byte) {
+		rsaEncryptor := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+		rsaEncryptor.WithKeySize(1024)
+		pubKey, privKey, err := rsaEncryptor.GenerateKeyPairFromSeed(seed)
+		if err != nil {
+			t.Fatalf("从种子生成RSA密钥失败: %v", err)
+		}
+		return pubKey, privKey
+	}
+
+	pub1, priv1 := generate(seed)
+	pub2, priv2 := generate(seed)
+	if !bytes.Equal(pub1, pub2) || !bytes.Equal(priv1, priv2) {
+		t.Fatalf("相同种子生成的密钥不一致")
+	}
+
+	pub3, _ := generate(append([]byte("x"), seed...))
+	if bytes.Equal(pub1, pub3) {
+		t.Fatalf("不同种子生成了相同的密钥")
+	}
+
+	if err := encrypt.ValidateKeyPair(pub1, priv1); err != nil {
+		t.Fatalf("生成的密钥对校验失败: %v", err)
+	}
+
+	if _, _, err := encrypt.MustNewRSA().(*encrypt.RSAEncryptor).GenerateKeyPairFromSeed([]byte("short")); err == nil {
+		t.Fatalf("过短的种子应该返回错误")
+	}
+}
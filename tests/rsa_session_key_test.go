@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestRSADecryptSessionKey 测试常量行为的会话密钥解密
+func TestRSADecryptSessionKey(t *testing.T) {
+	rsaEncryptor := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+	if _, _, err := rsaEncryptor.GenerateKeyPair(); err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+
+	sessionKey, _ := encrypt.GenerateRandomBytes(32)
+	wrapped, err := rsaEncryptor.Encrypt(sessionKey)
+	if err != nil {
+		t.Fatalf("加密会话密钥失败: %v", err)
+	}
+	key, err := rsaEncryptor.DecryptSessionKey(wrapped, 32)
+	if err != nil || !bytes.Equal(key, sessionKey) {
+		t.Fatalf("解密会话密钥失败: %v", err)
+	}
+
+	// 长度不符与填充错误都不报错，返回随机密钥
+	key, err = rsaEncryptor.DecryptSessionKey(wrapped, 16)
+	if err != nil || len(key) != 16 {
+		t.Fatalf("长度不符时应返回随机密钥: %v", err)
+	}
+	tampered, _ := rsaEncryptor.NoEncoding().Encrypt(sessionKey)
+	tampered[len(tampered)-1] ^= 1
+	key, err = rsaEncryptor.DecryptSessionKey(tampered, 32)
+	if err != nil || len(key) != 32 || bytes.Equal(key, sessionKey) {
+		t.Fatalf("填充错误时应返回随机密钥: %v", err)
+	}
+
+	// 密文长度与模长不符属于公开信息，可以报错
+	if _, err := rsaEncryptor.DecryptSessionKey(tampered[1:], 32); err == nil {
+		t.Fatal("密文长度不正确应当失败")
+	}
+}
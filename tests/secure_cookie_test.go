@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+type cookieSession struct {
+	UserID int64    `json:"uid"`
+	Roles  []string `json:"roles"`
+}
+
+// TestCookieCodec 测试Cookie编解码与有效期
+func TestCookieCodec(t *testing.T) {
+	now := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	ring := encrypt.NewKeyRing()
+	_ = ring.Add(encrypt.KeyEntry{ID: "cookie-1", Algorithm: encrypt.AlgorithmAES, Key: bytes.Repeat([]byte{1}, 32), Usage: encrypt.KeyUsageCipher})
+	codec := encrypt.NewCookieCodec(ring).MaxAge(time.Hour).WithClock(func() time.Time { return now })
+
+	encoded, err := codec.Encode("session", cookieSession{UserID: 42, Roles: []string{"admin"}})
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+	if strings.ContainsAny(encoded, "+/=") {
+		t.Fatalf("编码结果应可直接写入Cookie: %s", encoded)
+	}
+
+	var session cookieSession
+	if err := codec.Decode("session", encoded, &session); err != nil || session.UserID != 42 || session.Roles[0] != "admin" {
+		t.Fatalf("解码失败: %v %+v", err, session)
+	}
+	if err := codec.Decode("other", encoded, &session); !errors.Is(err, encrypt.ErrCookieInvalid) {
+		t.Fatalf("名称不同应当失败: %v", err)
+	}
+	if err := codec.Decode("session", encoded[:len(encoded)-2]+"AA", &session); !errors.Is(err, encrypt.ErrCookieInvalid) {
+		t.Fatalf("篡改的Cookie应当失败: %v", err)
+	}
+
+	later := encrypt.NewCookieCodec(ring).MaxAge(time.Hour).WithClock(func() time.Time { return now.Add(2 * time.Hour) })
+	if err := later.Decode("session", encoded, &session); !errors.Is(err, encrypt.ErrCookieExpired) {
+		t.Fatalf("过期的Cookie应当失败: %v", err)
+	}
+	earlier := encrypt.NewCookieCodec(ring).WithClock(func() time.Time { return now.Add(-time.Hour) })
+	if err := earlier.Decode("session", encoded, &session); !errors.Is(err, encrypt.ErrCookieTimeSkew) {
+		t.Fatalf("来自未来的Cookie应当失败: %v", err)
+	}
+
+	if _, err := codec.MaxLength(64).Encode("session", cookieSession{Roles: []string{strings.Repeat("r", 100)}}); !errors.Is(err, encrypt.ErrCookieTooLong) {
+		t.Fatalf("超长Cookie应当失败: %v", err)
+	}
+}
+
+// TestCookieCodecRotation 测试密钥轮换与序列化方式
+func TestCookieCodecRotation(t *testing.T) {
+	ring := encrypt.NewKeyRing()
+	_ = ring.Add(encrypt.KeyEntry{ID: "old", Algorithm: encrypt.AlgorithmSM4, Key: bytes.Repeat([]byte{1}, 16), Usage: encrypt.KeyUsageCipher})
+	codec := encrypt.NewCookieCodec(ring).WithSerializer(encrypt.GobCookieSerializer{})
+
+	oldCookie, err := codec.Encode("prefs", map[string]string{"lang": "zh"})
+	if err != nil {
+		t.Fatalf("编码失败: %v", err)
+	}
+
+	_ = ring.Add(encrypt.KeyEntry{ID: "new", Algorithm: encrypt.AlgorithmAES, Key: bytes.Repeat([]byte{2}, 32), Usage: encrypt.KeyUsageCipher})
+	_ = ring.SetPrimary("new")
+	newCookie, _ := codec.Encode("prefs", map[string]string{"lang": "en"})
+
+	for cookie, lang := range map[string]string{oldCookie: "zh", newCookie: "en"} {
+		var prefs map[string]string
+		if err := codec.Decode("prefs", cookie, &prefs); err != nil || prefs["lang"] != lang {
+			t.Fatalf("轮换后解码失败: %v", err)
+		}
+	}
+
+	_ = ring.Revoke("old")
+	var prefs map[string]string
+	if err := codec.Decode("prefs", oldCookie, &prefs); !errors.Is(err, encrypt.ErrKeyRevoked) {
+		t.Fatalf("吊销密钥签发的Cookie应当失败: %v", err)
+	}
+
+	raw := encrypt.NewCookieCodec(ring).WithSerializer(encrypt.NopCookieSerializer{})
+	encoded, _ := raw.Encode("csrf", []byte("token"))
+	var out []byte
+	if err := raw.Decode("csrf", encoded, &out); err != nil || string(out) != "token" {
+		t.Fatalf("原始字节编解码失败: %v", err)
+	}
+}
@@ -0,0 +1,130 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSessionCipher 测试计数器nonce会话加密
+func TestSessionCipher(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+
+	for _, algorithm := range []encrypt.Algorithm{encrypt.AlgorithmAES, encrypt.AlgorithmSM4} {
+		client, err := encrypt.NewSessionCipher(algorithm, key, true)
+		if err != nil {
+			t.Fatalf("创建会话失败: %v", err)
+		}
+		server, err := encrypt.NewSessionCipher(algorithm, key, false)
+		if err != nil {
+			t.Fatalf("创建会话失败: %v", err)
+		}
+
+		// 两个方向各自计数
+		for i := 0; i < 3; i++ {
+			request := []byte("ping")
+			record, err := client.Seal(request, nil)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+			got, err := server.Open(record, nil)
+			if err != nil || !bytes.Equal(got, request) {
+				t.Fatalf("服务端解密失败: %v", err)
+			}
+
+			response := []byte("pong")
+			record, err = server.Seal(response, nil)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+			got, err = client.Open(record, nil)
+			if err != nil || !bytes.Equal(got, response) {
+				t.Fatalf("客户端解密失败: %v", err)
+			}
+		}
+		if client.SendSequence() != 3 || server.ReceiveSequence() != 3 {
+			t.Fatalf("序号不正确: %d %d", client.SendSequence(), server.ReceiveSequence())
+		}
+
+		// 同一方向的消息不能被当作反方向的消息解密
+		record, _ := client.Seal([]byte("reflect"), nil)
+		if _, err := client.Open(record, nil); err == nil {
+			t.Fatalf("反射的消息应该解密失败")
+		}
+
+		// 乱序到达的消息只能按显式序号解密
+		first, _ := client.Seal([]byte("first"), nil)
+		second, _ := client.Seal([]byte("second"), nil)
+		if _, err := server.Open(second, nil); err == nil {
+			t.Fatalf("乱序消息应该解密失败")
+		}
+		if got, err := server.OpenSequence(5, second, nil); err != nil || string(got) != "second" {
+			t.Fatalf("按序号解密失败: %v", err)
+		}
+		if got, err := server.Open(first, nil); err == nil {
+			t.Fatalf("跳过的序号不应被接受: %s", got)
+		}
+	}
+}
+
+// TestSessionCipherState 测试会话状态的序列化与恢复
+func TestSessionCipherState(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+
+	client, _ := encrypt.NewSessionCipher(encrypt.AlgorithmSM4, key, true)
+	server, _ := encrypt.NewSessionCipher(encrypt.AlgorithmSM4, key, false)
+	for i := 0; i < 2; i++ {
+		record, _ := client.Seal([]byte("msg"), nil)
+		if _, err := server.Open(record, nil); err != nil {
+			t.Fatalf("解密失败: %v", err)
+		}
+	}
+
+	state, err := client.MarshalBinary()
+	if err != nil {
+		t.Fatalf("序列化状态失败: %v", err)
+	}
+
+	// 保存后原加密器仍可在预留范围内继续发送，这些序号在恢复后不会再被使用
+	record, _ := client.Seal([]byte("before crash"), nil)
+	if got, err := server.Open(record, nil); err != nil || string(got) != "before crash" {
+		t.Fatalf("解密失败: %v", err)
+	}
+
+	restored, _ := encrypt.NewSessionCipher(encrypt.AlgorithmSM4, key, true)
+	if err := restored.UnmarshalBinary(state); err != nil {
+		t.Fatalf("恢复状态失败: %v", err)
+	}
+	if restored.SendSequence() != 2+encrypt.SessionSendReserve {
+		t.Fatalf("恢复后应从预留上限继续: %d", restored.SendSequence())
+	}
+	if _, err := restored.Seal([]byte("unsaved"), nil); err == nil {
+		t.Fatal("恢复后未重新保存状态时不应发送")
+	}
+	if _, err := restored.MarshalBinary(); err != nil {
+		t.Fatalf("序列化状态失败: %v", err)
+	}
+	seq := restored.SendSequence()
+	record, _ = restored.Seal([]byte("after restart"), nil)
+	if got, err := server.OpenSequence(seq, record, nil); err != nil || string(got) != "after restart" {
+		t.Fatalf("恢复后解密失败: %v", err)
+	}
+
+	// 版本1的状态保存的是当前序号，拒绝恢复
+	legacy := bytes.Clone(state)
+	legacy[0] = 1
+	if err := restored.UnmarshalBinary(legacy); err == nil {
+		t.Fatal("版本1的状态应拒绝恢复")
+	}
+
+	// 方向或算法不一致时拒绝恢复
+	wrongRole, _ := encrypt.NewSessionCipher(encrypt.AlgorithmSM4, key, false)
+	if err := wrongRole.UnmarshalBinary(state); err == nil {
+		t.Fatalf("方向不一致时应该失败")
+	}
+	wrongAlgorithm, _ := encrypt.NewSessionCipher(encrypt.AlgorithmAES, key, true)
+	if err := wrongAlgorithm.UnmarshalBinary(state); err == nil {
+		t.Fatalf("算法不一致时应该失败")
+	}
+}
@@ -0,0 +1,51 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSignAndEncrypt 测试签密与解密验证
+func TestSignAndEncrypt(t *testing.T) {
+	recipientPub, recipientPriv, _ := encrypt.GenerateBoxKeyPair()
+	otherPub, otherPriv, _ := encrypt.GenerateBoxKeyPair()
+
+	for name, generate := range map[string]func() ([]byte, []byte, error){
+		"SM2": encrypt.MustNewSM2().GenerateKeyPair,
+		"RSA": encrypt.MustNewRSA().GenerateKeyPair,
+	} {
+		senderPub, senderPriv, err := generate()
+		if err != nil {
+			t.Fatalf("%s生成密钥失败: %v", name, err)
+		}
+		impostorPub, _, _ := generate()
+
+		sealed, err := encrypt.SignAndEncrypt(recipientPub, senderPriv, []byte("转账100元"))
+		if err != nil {
+			t.Fatalf("%s签密失败: %v", name, err)
+		}
+		data, err := encrypt.DecryptAndVerify(sealed, recipientPriv, senderPub)
+		if err != nil || string(data) != "转账100元" {
+			t.Fatalf("%s解密验证失败: %v", name, err)
+		}
+		if _, err := encrypt.DecryptAndVerify(sealed, recipientPriv, impostorPub); err == nil {
+			t.Fatalf("%s错误的发送方公钥应验证失败", name)
+		}
+		if _, err := encrypt.DecryptAndVerify(sealed, otherPriv, senderPub); err == nil {
+			t.Fatalf("%s其他接收方不应解密", name)
+		}
+	}
+
+	// 接收方把签名后的载荷重新加密转发给第三方：签名绑定了原接收方公钥，验证失败
+	senderPub, senderPriv, _ := encrypt.MustNewSM2().GenerateKeyPair()
+	sealed, _ := encrypt.SignAndEncrypt(recipientPub, senderPriv, []byte("data"))
+	payload, err := encrypt.BoxOpen(sealed, recipientPriv)
+	if err != nil {
+		t.Fatalf("解密失败: %v", err)
+	}
+	forwarded, _ := encrypt.BoxSeal(payload, otherPub)
+	if _, err := encrypt.DecryptAndVerify(forwarded, otherPriv, senderPub); err == nil {
+		t.Fatal("转发的签密消息应验证失败")
+	}
+}
@@ -0,0 +1,81 @@
+package tests
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestStateCodec 测试签名与加密模式的状态参数
+func TestStateCodec(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	signed, _ := encrypt.NewSignedState(bytes.Repeat([]byte{1}, 32))
+	encrypted, _ := encrypt.NewEncryptedState(bytes.Repeat([]byte{2}, 32))
+
+	for name, codec := range map[string]*encrypt.StateCodec{"签名": signed, "加密": encrypted} {
+		codec.WithClock(clock).WithTTL(5 * time.Minute)
+		payload := []byte(`{"redirect":"/home"}`)
+		state, err := codec.Issue("oauth:github", payload)
+		if err != nil {
+			t.Fatalf("%s模式生成失败: %v", name, err)
+		}
+		again, _ := codec.Issue("oauth:github", payload)
+		if again == state {
+			t.Fatalf("%s模式相同载荷应生成不同令牌", name)
+		}
+
+		opened, err := codec.Open("oauth:github", state)
+		if err != nil || !bytes.Equal(opened, payload) {
+			t.Fatalf("%s模式校验失败: %v", name, err)
+		}
+		if _, err := codec.Open("oauth:google", state); !errors.Is(err, encrypt.ErrStateInvalid) {
+			t.Fatalf("%s模式用途不符应当失败: %v", name, err)
+		}
+		tampered := []byte(state)
+		tampered[len(tampered)/2] ^= 1
+		if _, err := codec.Open("oauth:github", string(tampered)); !errors.Is(err, encrypt.ErrStateInvalid) {
+			t.Fatalf("%s模式篡改应当失败: %v", name, err)
+		}
+
+		codec.WithClock(func() time.Time { return now.Add(6 * time.Minute) })
+		if _, err := codec.Open("oauth:github", state); !errors.Is(err, encrypt.ErrStateExpired) {
+			t.Fatalf("%s模式过期应当失败: %v", name, err)
+		}
+	}
+
+	// 两种模式的令牌不能互换
+	state, _ := signed.WithClock(clock).Issue("p", nil)
+	other, _ := encrypt.NewEncryptedState(bytes.Repeat([]byte{1}, 32))
+	if _, err := other.Open("p", state); !errors.Is(err, encrypt.ErrStateInvalid) {
+		t.Fatalf("不同模式的令牌应当失败: %v", err)
+	}
+	if _, err := encrypt.NewSignedState([]byte("short")); err == nil {
+		t.Fatal("过短的签名密钥应当失败")
+	}
+}
+
+// TestCSRFDoubleSubmit 测试CSRF双重提交令牌
+func TestCSRFDoubleSubmit(t *testing.T) {
+	codec, _ := encrypt.NewSignedState(bytes.Repeat([]byte{3}, 32))
+	token, err := codec.CSRFToken("session-1")
+	if err != nil {
+		t.Fatalf("生成CSRF令牌失败: %v", err)
+	}
+	if err := codec.VerifyCSRF("session-1", token, token); err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+	if err := codec.VerifyCSRF("session-2", token, token); !errors.Is(err, encrypt.ErrStateInvalid) {
+		t.Fatalf("其他会话的令牌应当失败: %v", err)
+	}
+	another, _ := codec.CSRFToken("session-1")
+	if err := codec.VerifyCSRF("session-1", token, another); !errors.Is(err, encrypt.ErrStateInvalid) {
+		t.Fatalf("Cookie与请求不一致应当失败: %v", err)
+	}
+	if err := codec.VerifyCSRF("session-1", "", ""); !errors.Is(err, encrypt.ErrStateInvalid) {
+		t.Fatalf("空令牌应当失败: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCryptoSignerAdapters 测试crypto.Signer/crypto.Decrypter适配器
+func TestCryptoSignerAdapters(t *testing.T) {
+	t.Run("RSA", func(t *testing.T) {
+		rsaEncryptor := encrypt.MustNewRSA().(*encrypt.RSAEncryptor)
+		if _, _, err := rsaEncryptor.GenerateKeyPair(); err != nil {
+			t.Fatalf("RSA密钥生成失败: %v", err)
+		}
+
+		signer, err := rsaEncryptor.Signer()
+		if err != nil {
+			t.Fatalf("获取RSA Signer失败: %v", err)
+		}
+		hash := sha256.Sum256([]byte("测试数据"))
+		signature, err := signer.Sign(rand.Reader, hash[:], crypto.SHA256)
+		if err != nil {
+			t.Fatalf("RSA Signer签名失败: %v", err)
+		}
+
+		// 标准库签名应能被加密器验证
+		encoded, _ := encrypt.Base64Encoding.Encode(signature)
+		valid, err := rsaEncryptor.Verify([]byte("测试数据"), encoded)
+		if err != nil || !valid {
+			t.Fatalf("RSA签名验证失败: %v, 结果: %v", err, valid)
+		}
+
+		decrypter, err := rsaEncryptor.Decrypter()
+		if err != nil {
+			t.Fatalf("获取RSA Decrypter失败: %v", err)
+		}
+		ciphertext, _ := rsaEncryptor.NoEncoding().Encrypt([]byte("明文"))
+		plaintext, err := decrypter.Decrypt(rand.Reader, ciphertext, nil)
+		if err != nil || string(plaintext) != "明文" {
+			t.Fatalf("RSA Decrypter解密失败: %v", err)
+		}
+	})
+
+	t.Run("SM2", func(t *testing.T) {
+		sm2Encryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+		_, privKey, err := sm2Encryptor.GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("SM2密钥生成失败: %v", err)
+		}
+
+		signer, err := sm2Encryptor.Signer()
+		if err != nil {
+			t.Fatalf("获取SM2 Signer失败: %v", err)
+		}
+		data := []byte("测试数据")
+		signature, err := signer.Sign(rand.Reader, data, crypto.Hash(0))
+		if err != nil {
+			t.Fatalf("SM2 Signer签名失败: %v", err)
+		}
+		valid, err := sm2Encryptor.NoEncoding().Verify(data, signature)
+		if err != nil || !valid {
+			t.Fatalf("SM2签名验证失败: %v, 结果: %v", err, valid)
+		}
+
+		decrypter, err := sm2Encryptor.Decrypter()
+		if err != nil {
+			t.Fatalf("获取SM2 Decrypter失败: %v", err)
+		}
+		ciphertext, _ := sm2Encryptor.Encrypt([]byte("明文"))
+		plaintext, err := decrypter.Decrypt(rand.Reader, ciphertext, nil)
+		if err != nil || string(plaintext) != "明文" {
+			t.Fatalf("SM2 Decrypter解密失败: %v", err)
+		}
+
+		parsed, err := encrypt.ParseSigner(privKey)
+		if err != nil {
+			t.Fatalf("解析SM2 Signer失败: %v", err)
+		}
+		if _, err := parsed.Sign(rand.Reader, data, nil); err != nil {
+			t.Fatalf("解析的SM2 Signer签名失败: %v", err)
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestCiphertextLen 测试长度估算与实际加密结果一致
+func TestCiphertextLen(t *testing.T) {
+	suites := []string{
+		"AES-128-ECB-PKCS7", "AES-256-CBC-PKCS7", "AES-128-CBC-ZERO", "AES-128-CTR", "AES-256-GCM",
+		"SM4-CBC-PKCS7", "SM4-CFB", "SM4-GCM", "3DES-192-CBC-PKCS7", "DES-OFB",
+	}
+	encodings := []encrypt.EncodingMode{encrypt.EncodingNone, encrypt.EncodingHex, encrypt.EncodingBase64, encrypt.EncodingBase64MIME}
+
+	for _, name := range suites {
+		suite, err := encrypt.ParseSuite(name)
+		if err != nil {
+			t.Fatalf("解析套件%s失败: %v", name, err)
+		}
+		key := bytes.Repeat([]byte{7}, suite.KeySize)
+		for _, encoding := range encodings {
+			cipher, err := suite.NewCipher(key, encrypt.WithEncoding(encoding))
+			if err != nil {
+				t.Fatalf("创建%s失败: %v", name, err)
+			}
+			config := encrypt.SizeConfig{Suite: suite, Encoding: encoding}
+			for _, n := range []int{0, 1, 15, 16, 17, 100} {
+				ciphertext, _ := cipher.Encrypt(make([]byte, n))
+				if estimated, err := encrypt.CiphertextLen(config, n); err != nil || estimated != len(ciphertext) {
+					t.Fatalf("%s 编码%d 明文%d: 估算%d，实际%d (%v)", name, encoding, n, estimated, len(ciphertext), err)
+				}
+			}
+		}
+
+		config := encrypt.SizeConfig{Suite: suite, Layout: encrypt.LayoutEnvelope}
+		sealed, _ := encrypt.SealEnvelopeSuite(suite, key, make([]byte, 20))
+		if estimated, _ := encrypt.CiphertextLen(config, 20); estimated != len(sealed) {
+			t.Fatalf("%s 信封: 估算%d，实际%d", name, estimated, len(sealed))
+		}
+	}
+
+	ring := encrypt.NewKeyRing()
+	_ = ring.Add(encrypt.KeyEntry{ID: "k1", Algorithm: encrypt.AlgorithmSM4, Key: bytes.Repeat([]byte{1}, 16), Usage: encrypt.KeyUsageCipher})
+	ciphertext, _ := ring.Encrypt(make([]byte, 33), nil)
+	config := encrypt.SizeConfig{Suite: encrypt.Suite{Algorithm: encrypt.AlgorithmSM4}, Layout: encrypt.LayoutKeyRing, KeyIDLen: 2}
+	if estimated, _ := encrypt.CiphertextLen(config, 33); estimated != len(ciphertext) {
+		t.Fatalf("密钥环: 估算%d，实际%d", estimated, len(ciphertext))
+	}
+
+	// SM2按上限估算
+	sm2 := encrypt.MustNewSM2()
+	pub, priv, _ := sm2.GenerateKeyPair()
+	sm2 = sm2.WithPublicKey(pub).WithPrivateKey(priv)
+	config = encrypt.SizeConfig{Suite: encrypt.Suite{Algorithm: encrypt.AlgorithmSM2}}
+	for _, n := range []int{1, 100, 300} {
+		ciphertext, err := sm2.Encrypt(make([]byte, n))
+		if err != nil {
+			t.Fatalf("SM2加密失败: %v", err)
+		}
+		decoded, _ := encrypt.DecodeAuto(ciphertext)
+		if estimated, _ := encrypt.CiphertextLen(config, n); len(decoded) > estimated {
+			t.Fatalf("SM2 明文%d: 上限%d小于实际%d", n, estimated, len(decoded))
+		}
+	}
+}
+
+// TestMaxPlaintextLen 测试按列宽与RSA模数计算最大明文长度
+func TestMaxPlaintextLen(t *testing.T) {
+	suite, _ := encrypt.ParseSuite("AES-256-GCM")
+	config := encrypt.SizeConfig{Suite: suite, Encoding: encrypt.EncodingBase64}
+
+	// VARCHAR(255)
+	n, err := encrypt.MaxPlaintextLen(config, 255)
+	if err != nil {
+		t.Fatalf("计算失败: %v", err)
+	}
+	if size, _ := encrypt.CiphertextLen(config, n); size > 255 {
+		t.Fatalf("明文%d的密文%d超过上限", n, size)
+	}
+	if size, _ := encrypt.CiphertextLen(config, n+1); size <= 255 {
+		t.Fatalf("%d不是最大值", n)
+	}
+
+	if overhead, _ := encrypt.CiphertextOverhead(encrypt.SizeConfig{Suite: suite}); overhead != 12+16+16 {
+		t.Fatalf("AES-GCM开销应为44，实际为%d", overhead)
+	}
+	sm4, _ := encrypt.ParseSuite("SM4-GCM")
+	if overhead, _ := encrypt.CiphertextOverhead(encrypt.SizeConfig{Suite: sm4}); overhead != 28 {
+		t.Fatalf("SM4-GCM开销应为28，实际为%d", overhead)
+	}
+
+	// RSA受模数限制
+	rsa := encrypt.SizeConfig{Suite: encrypt.Suite{Algorithm: encrypt.AlgorithmRSA}, RSAKeyBits: 2048}
+	if n, _ := encrypt.MaxPlaintextLen(rsa, 1<<20); n != 245 {
+		t.Fatalf("2048位RSA最多加密245字节，实际为%d", n)
+	}
+	if _, err := encrypt.CiphertextLen(rsa, 246); err == nil {
+		t.Fatal("超过RSA上限应报错")
+	}
+
+	// 不填充时按分组对齐
+	noPadding, _ := encrypt.ParseSuite("AES-128-CBC-NOPADDING")
+	if n, _ := encrypt.MaxPlaintextLen(encrypt.SizeConfig{Suite: noPadding}, 100); n != 80 {
+		t.Fatalf("不填充时应按分组对齐，实际为%d", n)
+	}
+	if _, err := encrypt.MaxPlaintextLen(config, 10); err == nil {
+		t.Fatal("上限不足以容纳空明文应报错")
+	}
+}
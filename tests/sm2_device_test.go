@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// softDevice 以软件私钥模拟的UKey
+type softDevice struct {
+	key    *encrypt.SM2Encryptor
+	signs  int
+	closed bool
+}
+
+func (d *softDevice) PublicKey() (*sm2.PublicKey, error) {
+	return d.key.Public().(*sm2.PublicKey), nil
+}
+
+func (d *softDevice) SignDigest(digest []byte) (*big.Int, *big.Int, error) {
+	d.signs++
+	// 设备只接触摘要e
+	signature, err := d.key.SignDigest(digest)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sm2.SignDataToSignDigit(signature)
+}
+
+func (d *softDevice) Close() error {
+	d.closed = true
+	return nil
+}
+
+// TestSM2Device 测试委托硬件设备签名
+func TestSM2Device(t *testing.T) {
+	key := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	if _, _, err := key.GenerateKeyPair(); err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+	key.NoEncoding()
+	device := &softDevice{key: key}
+	encrypt.RegisterSM2DeviceDriver("soft", func(devicePath, pin string) (encrypt.SM2Device, error) {
+		if pin != "123456" {
+			return nil, encrypt.ErrKeyNotFound
+		}
+		return device, nil
+	})
+
+	signer := encrypt.MustNewSM2().(*encrypt.SM2Encryptor).WithDevice("soft:token", "123456").(*encrypt.SM2Encryptor)
+	signature, err := signer.Sign([]byte("data"))
+	if err != nil {
+		t.Fatalf("设备签名失败: %v", err)
+	}
+	if device.signs != 1 {
+		t.Fatal("签名应当委托给设备")
+	}
+
+	// 使用设备导出的公钥在本地验签
+	verifier := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	pubHex, _ := signer.ExportPublicKeyHex()
+	verifier.WithPublicKeyHex(pubHex)
+	if ok, err := verifier.Verify([]byte("data"), signature); err != nil || !ok {
+		t.Fatalf("验证设备签名失败: %v", err)
+	}
+
+	cryptoSigner, err := signer.Signer()
+	if err != nil {
+		t.Fatalf("获取Signer失败: %v", err)
+	}
+	if _, err := cryptoSigner.Sign(rand.Reader, []byte("data"), nil); err != nil || device.signs != 2 {
+		t.Fatalf("Signer应当委托给设备: %v", err)
+	}
+
+	if err := signer.CloseDevice(); err != nil || !device.closed {
+		t.Fatal("关闭设备失败")
+	}
+	if _, err := signer.Sign([]byte("data")); err == nil {
+		t.Fatal("关闭设备后签名应当失败")
+	}
+
+	if _, err := encrypt.OpenSM2Device("soft:token", "000000"); err == nil {
+		t.Fatal("PIN错误应当失败")
+	}
+	if _, err := encrypt.OpenSM2Device("unknown:token", "123456"); err == nil {
+		t.Fatal("未注册的驱动应当失败")
+	}
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSM2HexKeys 测试SM2十六进制裸密钥导入导出
+func TestSM2HexKeys(t *testing.T) {
+	source := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	if _, _, err := source.GenerateKeyPair(); err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+
+	privHex, err := source.ExportPrivateKeyHex()
+	if err != nil || len(privHex) != 64 {
+		t.Fatalf("导出私钥失败: %v, %s", err, privHex)
+	}
+	pubHex, err := source.ExportPublicKeyHex()
+	if err != nil || len(pubHex) != 130 {
+		t.Fatalf("导出公钥失败: %v, %s", err, pubHex)
+	}
+
+	// 用十六进制私钥签名，用十六进制公钥（去掉04前缀）验签
+	signer := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	signer.WithPrivateKeyHex(privHex)
+	data := []byte("银行接口测试数据")
+	signature, err := signer.Sign(data)
+	if err != nil {
+		t.Fatalf("SM2签名失败: %v", err)
+	}
+
+	verifier := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+	verifier.WithPublicKeyHex(pubHex[2:])
+	valid, err := verifier.Verify(data, signature)
+	if err != nil || !valid {
+		t.Fatalf("SM2验签失败: %v, 结果: %v", err, valid)
+	}
+
+	exported, _ := signer.ExportPublicKeyHex()
+	if exported != pubHex {
+		t.Fatalf("由私钥推导的公钥不一致")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("非法公钥应该panic")
+		}
+	}()
+	encrypt.MustNewSM2().(*encrypt.SM2Encryptor).WithPublicKeyHex("04" + pubHex[2:66] + pubHex[2:66])
+}
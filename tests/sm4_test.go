@@ -39,7 +39,7 @@ func TestSM4Features(t *testing.T) {
 			return sm4.CTR().NoPadding().NoEncoding() 
 		}},
 		{"SM4-GCM-NoPadding-Base64Safe", func() encrypt.ISymmetric { 
-			return sm4.GCM().NoPadding().Base64Safe() 
+			return sm4.(encrypt.IGCMSetter).GCM().NoPadding().Base64Safe() 
 		}},
 	}
 	
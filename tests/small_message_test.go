@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSmallCipher 测试短消息快速路径及与常规加密器的兼容性
+func TestSmallCipher(t *testing.T) {
+	key := bytes.Repeat([]byte{5}, 16)
+
+	for _, algorithm := range []encrypt.Algorithm{encrypt.AlgorithmAES, encrypt.AlgorithmSM4} {
+		small, err := encrypt.NewSmallCipher(algorithm, key)
+		if err != nil {
+			t.Fatalf("创建快速路径失败: %v", err)
+		}
+		newRegular := encrypt.AES
+		if algorithm == encrypt.AlgorithmSM4 {
+			newRegular = encrypt.SM4
+		}
+		cbc, _ := newRegular(key, encrypt.WithMode(encrypt.ModeCBC), encrypt.WithEncoding(encrypt.EncodingNone))
+		ecb, _ := newRegular(key, encrypt.WithMode(encrypt.ModeECB), encrypt.WithEncoding(encrypt.EncodingNone))
+
+		for _, plaintext := range [][]byte{{}, []byte("u-42"), []byte("0123456789abcde"), []byte("0123456789abcdef")} {
+			ciphertext, err := small.Encrypt(nil, plaintext)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+			if algorithm == encrypt.AlgorithmAES {
+				if decrypted, err := cbc.Decrypt(ciphertext); err != nil || !bytes.Equal(decrypted, plaintext) {
+					t.Fatalf("AES加密器无法解密快速路径密文: %v", err)
+				}
+			}
+			if decrypted, err := small.Decrypt([]byte("id:"), ciphertext); err != nil || string(decrypted) != "id:"+string(plaintext) {
+				t.Fatalf("快速路径解密失败: %v", err)
+			}
+
+			deterministic, _ := small.EncryptDeterministic(nil, plaintext)
+			again, _ := small.EncryptDeterministic(nil, plaintext)
+			if !bytes.Equal(deterministic, again) {
+				t.Fatal("确定性加密结果应相同")
+			}
+			if regular, _ := ecb.Encrypt(plaintext); !bytes.Equal(regular, deterministic) {
+				t.Fatal("确定性加密应与ECB-PKCS7一致")
+			}
+			if decrypted, err := small.DecryptDeterministic(nil, deterministic); err != nil || !bytes.Equal(decrypted, plaintext) {
+				t.Fatalf("确定性解密失败: %v", err)
+			}
+		}
+
+		if algorithm == encrypt.AlgorithmAES {
+			regular, _ := cbc.Encrypt([]byte("order-7"))
+			if decrypted, err := small.Decrypt(nil, regular); err != nil || string(decrypted) != "order-7" {
+				t.Fatalf("快速路径无法解密AES加密器的密文: %v", err)
+			}
+		}
+	}
+
+	small, _ := encrypt.NewSmallCipher(encrypt.AlgorithmAES, key)
+	if _, err := small.Encrypt(nil, make([]byte, 17)); err != encrypt.ErrSmallMessageTooLarge {
+		t.Fatalf("超长明文应返回ErrSmallMessageTooLarge: %v", err)
+	}
+	ciphertext, _ := small.EncryptDeterministic(nil, []byte("abc"))
+	ciphertext[15] ^= 1
+	if _, err := small.DecryptDeterministic(nil, ciphertext); err == nil {
+		t.Fatal("填充错误应解密失败")
+	}
+	if _, err := encrypt.NewSmallCipher(encrypt.AlgorithmDES, key[:8]); err == nil {
+		t.Fatal("DES不应支持快速路径")
+	}
+
+	// 预分配dst时不产生内存分配
+	plaintext := []byte("user-1024")
+	buf := make([]byte, 0, 64)
+	encrypted := make([]byte, 0, 32)
+	encrypted, _ = small.Encrypt(encrypted, plaintext)
+	allocs := testing.AllocsPerRun(100, func() {
+		out, _ := small.EncryptDeterministic(buf[:0], plaintext)
+		_, _ = small.DecryptDeterministic(out[len(out):], out)
+		_, _ = small.Decrypt(buf[:0], encrypted)
+	})
+	if allocs != 0 {
+		t.Fatalf("快速路径不应分配内存: %v", allocs)
+	}
+}
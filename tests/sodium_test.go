@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// 以下向量由libsodium 1.0.18生成（crypto_box_seal与crypto_secretbox_easy）
+const (
+	sodiumPublicKeyHex  = "ed0820ee0f697636107ff6820dbaceaade8edaa0da77868dbbd00a7636488662"
+	sodiumPrivateKeyHex = "04baab6fdef27cab8c2ac0e8a17cfeebe793a9725308803cb1645266bf9b2f07"
+	sodiumSealedHex     = "39a6ec57f8d1c0a2f3ebe3944b84405a0654f57addfc690c08783f0c0544271687b891ce182c3e18419254b8744f6ce92685d226758dedd77c3c9204479c7d1b17a6f002"
+	sodiumSecretBoxHex  = "000102030405060708090a0b0c0d0e0f10111213141516172a2d3275fee4403c1a39d8e52ad4c33c369a5423a8eac462d451af5201ed39f836cc33b2"
+)
+
+// TestSodiumCompat 测试解密libsodium生成的密文
+func TestSodiumCompat(t *testing.T) {
+	pub, _ := hex.DecodeString(sodiumPublicKeyHex)
+	priv, _ := hex.DecodeString(sodiumPrivateKeyHex)
+	sealed, _ := hex.DecodeString(sodiumSealedHex)
+
+	message, err := encrypt.SodiumSealOpen(sealed, pub, priv)
+	if err != nil || string(message) != "hello from libsodium" {
+		t.Fatalf("解密sealed box失败: %v", err)
+	}
+
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	data, _ := hex.DecodeString(sodiumSecretBoxHex)
+	message, err = encrypt.SecretBoxOpen(data, key)
+	if err != nil || string(message) != "hello from libsodium" {
+		t.Fatalf("解密secretbox失败: %v", err)
+	}
+
+	// 固定nonce时输出应与libsodium完全一致
+	sealedBox, err := encrypt.SecretBoxSealWithNonce([]byte("hello from libsodium"), data[:24], key)
+	if err != nil || !bytes.Equal(sealedBox, data[24:]) {
+		t.Fatalf("secretbox输出与libsodium不一致: %v", err)
+	}
+}
+
+// TestSodiumRoundTrip 测试加解密往返与篡改检测
+func TestSodiumRoundTrip(t *testing.T) {
+	pub, priv, err := encrypt.GenerateSodiumKeyPair()
+	if err != nil {
+		t.Fatalf("生成密钥对失败: %v", err)
+	}
+
+	sealed, err := encrypt.SodiumSeal([]byte("payload"), pub)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if len(sealed) != len("payload")+encrypt.SodiumSealOverhead {
+		t.Fatalf("密文长度不正确: %d", len(sealed))
+	}
+	sealed[len(sealed)-1] ^= 1
+	if _, err := encrypt.SodiumSealOpen(sealed, pub, priv); err == nil {
+		t.Fatalf("篡改后不应解密成功")
+	}
+
+	key, _ := encrypt.GenerateRandomKey(encrypt.SecretBoxKeySize)
+	data, err := encrypt.SecretBoxSeal([]byte("payload"), key)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if message, err := encrypt.SecretBoxOpen(data, key); err != nil || string(message) != "payload" {
+		t.Fatalf("解密失败: %v", err)
+	}
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// runSPAKE2 执行一次完整的SPAKE2交互
+func runSPAKE2(t *testing.T, passwordA, passwordB []byte) (*encrypt.SPAKE2, *encrypt.SPAKE2, error) {
+	t.Helper()
+
+	idA, idB := []byte("device-001"), []byte("provisioning-server")
+	a, err := encrypt.NewSPAKE2(encrypt.SPAKE2RoleA, passwordA, idA, idB)
+	if err != nil {
+		t.Fatalf("创建A失败: %v", err)
+	}
+	b, err := encrypt.NewSPAKE2(encrypt.SPAKE2RoleB, passwordB, idA, idB)
+	if err != nil {
+		t.Fatalf("创建B失败: %v", err)
+	}
+
+	confirmA, err := a.Finish(b.Message())
+	if err != nil {
+		t.Fatalf("A完成失败: %v", err)
+	}
+	confirmB, err := b.Finish(a.Message())
+	if err != nil {
+		t.Fatalf("B完成失败: %v", err)
+	}
+
+	if err := b.Verify(confirmA); err != nil {
+		return a, b, err
+	}
+	return a, b, a.Verify(confirmB)
+}
+
+// TestSPAKE2 测试口令认证密钥交换
+func TestSPAKE2(t *testing.T) {
+	a, b, err := runSPAKE2(t, []byte("123456"), []byte("123456"))
+	if err != nil {
+		t.Fatalf("口令一致时确认应该成功: %v", err)
+	}
+
+	keyA, err := a.SharedKey()
+	if err != nil {
+		t.Fatalf("获取会话密钥失败: %v", err)
+	}
+	keyB, err := b.SharedKey()
+	if err != nil {
+		t.Fatalf("获取会话密钥失败: %v", err)
+	}
+	if !bytes.Equal(keyA, keyB) || len(keyA) != 16 {
+		t.Fatalf("双方会话密钥不一致")
+	}
+
+	a, _, err = runSPAKE2(t, []byte("123456"), []byte("654321"))
+	if err == nil {
+		t.Fatalf("口令不一致时确认应该失败")
+	}
+	if _, err := a.SharedKey(); err == nil {
+		t.Fatalf("确认失败时不应返回会话密钥")
+	}
+}
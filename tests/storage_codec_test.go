@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestStorageCodec 测试值加密绑定命名空间与键，以及确定性键加密
+func TestStorageCodec(t *testing.T) {
+	ring := encrypt.NewKeyRing()
+	if err := ring.Add(encrypt.KeyEntry{ID: "local", Algorithm: encrypt.AlgorithmAES, Key: bytes.Repeat([]byte{1}, 32), Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	codec := encrypt.NewStorageCodec(ring)
+
+	// 未启用键加密时键原样返回
+	if key, _ := codec.EncodeKey([]byte("users"), []byte("u1")); string(key) != "u1" {
+		t.Fatalf("未启用键加密时不应改变键")
+	}
+
+	data, err := codec.EncodeValue([]byte("users"), []byte("u1"), []byte("alice"))
+	if err != nil {
+		t.Fatalf("加密值失败: %v", err)
+	}
+	if value, err := codec.DecodeValue([]byte("users"), []byte("u1"), data); err != nil || string(value) != "alice" {
+		t.Fatalf("解密值失败: %v", err)
+	}
+	if _, err := codec.DecodeValue([]byte("users"), []byte("u2"), data); err == nil {
+		t.Fatalf("挪到其他键下的值不应解密成功")
+	}
+
+	codec.WithDeterministicKeys(bytes.Repeat([]byte{2}, 32))
+	k1, _ := codec.EncodeKey([]byte("users"), []byte("u1"))
+	k2, _ := codec.EncodeKey([]byte("users"), []byte("u1"))
+	k3, _ := codec.EncodeKey([]byte("orders"), []byte("u1"))
+	if !bytes.Equal(k1, k2) || bytes.Equal(k1, k3) || bytes.Contains(k1, []byte("u1")) {
+		t.Fatalf("确定性键加密结果不正确")
+	}
+	if key, err := codec.DecodeKey([]byte("users"), k1); err != nil || string(key) != "u1" {
+		t.Fatalf("解密键失败: %v", err)
+	}
+	if _, err := codec.DecodeKey([]byte("orders"), k1); err == nil {
+		t.Fatalf("其他命名空间不应解密成功")
+	}
+}
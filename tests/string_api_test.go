@@ -0,0 +1,46 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestStringAPI 测试字符串版本的加解密方法
+func TestStringAPI(t *testing.T) {
+	key := []byte("0123456789ABCDEF")
+	plaintext := "表单提交的手机号: 13800138000"
+
+	for name, encryptor := range map[string]encrypt.ISymmetric{
+		"AES": encrypt.MustNewAES(key),
+		"SM4": encrypt.MustNewSM4(key),
+	} {
+		t.Run(name, func(t *testing.T) {
+			ciphertext, err := encryptor.CBC().Hex().EncryptString(plaintext)
+			if err != nil {
+				t.Fatalf("加密失败: %v", err)
+			}
+			decrypted, err := encryptor.DecryptString(ciphertext)
+			if err != nil || decrypted != plaintext {
+				t.Fatalf("解密失败: %v", err)
+			}
+		})
+	}
+
+	sm2Encryptor := encrypt.MustNewSM2()
+	if _, _, err := sm2Encryptor.GenerateKeyPair(); err != nil {
+		t.Fatalf("SM2密钥生成失败: %v", err)
+	}
+	signature, err := sm2Encryptor.SignString(plaintext)
+	if err != nil {
+		t.Fatalf("SM2签名失败: %v", err)
+	}
+	if valid, err := sm2Encryptor.VerifyString(plaintext, signature); err != nil || !valid {
+		t.Fatalf("SM2验签失败: %v", err)
+	}
+
+	sum, err := encrypt.NewSM3().Hex().SumString("abc")
+	if err != nil || sum != "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0" {
+		t.Fatalf("SM3字符串哈希错误: %v, %s", err, sum)
+	}
+}
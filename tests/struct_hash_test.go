@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// orderRequest 幂等令牌测试用的请求
+type orderRequest struct {
+	UserID  int64             `json:"user_id"`
+	Items   []string          `json:"items"`
+	Amount  float64           `json:"amount"`
+	Tags    map[string]string `json:"tags"`
+	TraceID string            `json:"-"`
+}
+
+// TestHashStruct 测试结构体确定性哈希
+func TestHashStruct(t *testing.T) {
+	a := orderRequest{UserID: 42, Items: []string{"book"}, Amount: 9.9, Tags: map[string]string{"b": "2", "a": "1"}, TraceID: "x"}
+	b := orderRequest{UserID: 42, Items: []string{"book"}, Amount: 9.9, Tags: map[string]string{"a": "1", "b": "2"}, TraceID: "y"}
+
+	digestA, err := encrypt.HashStructHex(a, encrypt.HashStructOptions{})
+	if err != nil {
+		t.Fatalf("计算摘要失败: %v", err)
+	}
+	digestB, _ := encrypt.HashStructHex(b, encrypt.HashStructOptions{})
+	if digestA != digestB {
+		t.Fatal("相同内容应得到相同摘要")
+	}
+
+	// 与规范JSON的SHA-256一致，其他语言可以复现
+	canonical := `{"amount":9.9,"items":["book"],"tags":{"a":"1","b":"2"},"user_id":42}`
+	expected := sha256.Sum256([]byte(canonical))
+	if digestA != hex.EncodeToString(expected[:]) {
+		t.Fatal("摘要应等于规范JSON的SHA-256")
+	}
+
+	// 结构体与等价的map得到相同结果
+	m := map[string]interface{}{"user_id": 42, "items": []string{"book"}, "amount": 9.9, "tags": map[string]string{"a": "1", "b": "2"}}
+	if digest, _ := encrypt.HashStructHex(m, encrypt.HashStructOptions{}); digest != digestA {
+		t.Fatal("等价的map应得到相同摘要")
+	}
+
+	b.Amount = 9.90000001
+	if digest, _ := encrypt.HashStructHex(b, encrypt.HashStructOptions{}); digest == digestA {
+		t.Fatal("内容不同应得到不同摘要")
+	}
+
+	// 用途、密钥与算法都会改变摘要
+	cache, _ := encrypt.HashStructHex(a, encrypt.HashStructOptions{Domain: "cache"})
+	token, _ := encrypt.HashStructHex(a, encrypt.HashStructOptions{Domain: "idempotency"})
+	keyed, _ := encrypt.HashStructHex(a, encrypt.HashStructOptions{Key: []byte("secret")})
+	sm3, _ := encrypt.HashStruct(a, encrypt.HashStructOptions{Algorithm: encrypt.HashSM3})
+	if cache == token || cache == digestA || keyed == digestA || len(sm3) != 32 || hex.EncodeToString(sm3) == digestA {
+		t.Fatal("不同选项应得到不同摘要")
+	}
+
+	// 超出双精度精确范围的整数会丢失精度，应报错
+	if _, err := encrypt.HashStruct(orderRequest{UserID: 1<<53 + 1}, encrypt.HashStructOptions{}); err == nil {
+		t.Fatal("超出精确范围的整数应报错")
+	}
+	if _, err := encrypt.HashStruct(orderRequest{UserID: 1 << 53}, encrypt.HashStructOptions{}); err != nil {
+		t.Fatalf("2^53应可以精确表示: %v", err)
+	}
+}
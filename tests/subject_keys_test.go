@@ -0,0 +1,74 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestSubjectKeyShredding 测试删除主体密钥后密文无法解密
+func TestSubjectKeyShredding(t *testing.T) {
+	ctx := context.Background()
+	manager := encrypt.NewSubjectKeyManager(newConfigRing(t), "config", encrypt.NewMemorySubjectKeyStore())
+
+	alice, err := manager.Encrypt(ctx, "alice", []byte("alice@example.com"), []byte("users.email"))
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	bob, err := manager.Encrypt(ctx, "bob", []byte("bob@example.com"), nil)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+
+	plaintext, err := manager.Decrypt(ctx, "alice", alice, []byte("users.email"))
+	if err != nil || string(plaintext) != "alice@example.com" {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if _, err := manager.Decrypt(ctx, "bob", alice, []byte("users.email")); err == nil {
+		t.Fatal("其他主体的密钥不应解密")
+	}
+
+	subjects, err := manager.Subjects(ctx)
+	if err != nil || len(subjects) != 2 || subjects[0].Subject != "alice" || subjects[0].KeyID != "config" {
+		t.Fatalf("主体列表不正确: %v %+v", err, subjects)
+	}
+
+	if err := manager.DeleteSubjectKey(ctx, "alice"); err != nil {
+		t.Fatalf("删除失败: %v", err)
+	}
+	if _, err := manager.Decrypt(ctx, "alice", alice, []byte("users.email")); !errors.Is(err, encrypt.ErrSubjectKeyNotFound) {
+		t.Fatalf("删除后应无法解密: %v", err)
+	}
+	if ok, _ := manager.HasSubjectKey(ctx, "alice"); ok {
+		t.Fatal("删除后不应再有密钥")
+	}
+	if _, err := manager.Decrypt(ctx, "bob", bob, nil); err != nil {
+		t.Fatalf("其他主体不受影响: %v", err)
+	}
+}
+
+// TestSubjectKeyConcurrentCreate 测试并发首次加密只生成一把密钥
+func TestSubjectKeyConcurrentCreate(t *testing.T) {
+	ctx := context.Background()
+	manager := encrypt.NewSubjectKeyManager(newConfigRing(t), "config", encrypt.NewMemorySubjectKeyStore())
+
+	var wg sync.WaitGroup
+	ciphertexts := make([][]byte, 16)
+	for i := range ciphertexts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ciphertexts[i], _ = manager.Encrypt(ctx, "carol", []byte("data"), nil)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, ciphertext := range ciphertexts {
+		if _, err := manager.Decrypt(ctx, "carol", ciphertext, nil); err != nil {
+			t.Fatalf("第%d条密文无法解密: %v", i, err)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// TestSuiteString 测试套件字符串的格式化与解析
+func TestSuiteString(t *testing.T) {
+	for name, expected := range map[string]string{
+		"AES-256-GCM":           "AES-256-GCM",
+		"aes-128-cbc":           "AES-128-CBC-PKCS7",
+		"SM4-CBC-PKCS7":         "SM4-CBC-PKCS7",
+		"SM4-128-GCM":           "SM4-GCM",
+		"sm4-ecb-zero":          "SM4-ECB-ZERO",
+		"3DES-128-CBC":          "3DES-128-CBC-PKCS7",
+		"AES-192-CTR":           "AES-192-CTR",
+		"AES-128-CBC-NOPADDING": "AES-128-CBC-NOPADDING",
+	} {
+		suite, err := encrypt.ParseSuite(name)
+		if err != nil {
+			t.Fatalf("解析%s失败: %v", name, err)
+		}
+		if suite.String() != expected {
+			t.Fatalf("%s格式化结果不正确: %s", name, suite.String())
+		}
+		again, err := encrypt.ParseSuite(suite.String())
+		if err != nil || again != suite {
+			t.Fatalf("%s往返解析不一致: %v", name, err)
+		}
+	}
+
+	for _, name := range []string{"", "AES-GCM", "AES-100-GCM", "SM4-XTS", "AES-256-GCM-PKCS7", "3DES-192-GCM", "RC4-128-CTR"} {
+		if _, err := encrypt.ParseSuite(name); err == nil {
+			t.Fatalf("%s应当解析失败", name)
+		}
+	}
+
+	suites, err := encrypt.ParseSuiteList("AES-256-GCM, SM4-GCM,,SM4-CBC")
+	if err != nil || len(suites) != 3 {
+		t.Fatalf("解析套件列表失败: %v", err)
+	}
+	if encrypt.FormatSuiteList(suites) != "AES-256-GCM,SM4-GCM,SM4-CBC-PKCS7" {
+		t.Fatalf("套件列表格式化不正确: %s", encrypt.FormatSuiteList(suites))
+	}
+}
+
+// TestSuiteConfig 测试套件在配置文件中以字符串读写
+func TestSuiteConfig(t *testing.T) {
+	var config struct {
+		Suite encrypt.Suite `json:"suite" yaml:"suite"`
+	}
+	if err := yaml.Unmarshal([]byte("suite: sm4-gcm\n"), &config); err != nil {
+		t.Fatalf("读取YAML失败: %v", err)
+	}
+	if config.Suite.Algorithm != encrypt.AlgorithmSM4 || config.Suite.Mode != encrypt.ModeGCM {
+		t.Fatalf("YAML套件不正确: %v", config.Suite)
+	}
+	data, _ := json.Marshal(config)
+	if string(data) != `{"suite":"SM4-GCM"}` {
+		t.Fatalf("JSON输出不正确: %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"suite":"AES-GCM"}`), &config); err == nil {
+		t.Fatal("缺少密钥位数的AES套件应当失败")
+	}
+}
+
+// TestSuiteNegotiateAndEnvelope 测试协商与信封使用套件名称
+func TestSuiteNegotiateAndEnvelope(t *testing.T) {
+	local, _ := encrypt.ParseSuiteList("SM4-GCM,AES-256-GCM,AES-128-CBC")
+	negotiator := encrypt.NewNegotiator(local...)
+	suite, err := negotiator.NegotiateNames([]string{"CHACHA20-POLY1305", "AES-128-CBC-PKCS7", "SM4-GCM"})
+	if err != nil || suite.String() != "SM4-GCM" {
+		t.Fatalf("按名称协商失败: %v %s", err, suite)
+	}
+	if names := negotiator.SupportedNames(); len(names) != 3 || names[2] != "AES-128-CBC-PKCS7" {
+		t.Fatalf("声明的套件名称不正确: %v", names)
+	}
+
+	key := bytes.Repeat([]byte{5}, 16)
+	cbc, _ := encrypt.ParseSuite("AES-128-CBC-ZERO")
+	sealed, err := encrypt.SealEnvelopeSuite(cbc, key, []byte("suite envelope"))
+	if err != nil {
+		t.Fatalf("按套件加密失败: %v", err)
+	}
+	env, _ := encrypt.ParseEnvelope(sealed)
+	if env.Padding != encrypt.PaddingZero || env.Mode != encrypt.ModeCBC {
+		t.Fatalf("信封头部不正确: %+v", env)
+	}
+	plaintext, err := encrypt.OpenEnvelope(key, sealed, nil)
+	if err != nil || string(plaintext) != "suite envelope" {
+		t.Fatalf("解密失败: %v", err)
+	}
+	if _, err := encrypt.SealEnvelopeSuite(suite, key[:8], nil); err == nil {
+		t.Fatal("密钥长度不符应当失败")
+	}
+}
@@ -0,0 +1,167 @@
+/*
+ * 使用xmlsec1（libxmlsec1-openssl）生成XML签名与XML加密互通测试数据，也可用于校验本库的输出
+ *
+ * 编译: cc gen_xmlsec.c -o gen_xmlsec $(pkg-config --cflags --libs xmlsec1-openssl)
+ * 用法:
+ *   ./gen_xmlsec sign rsakey.pem doc.xml > signed.xml             RSA-SHA256 + 排他规范化封装签名
+ *   ./gen_xmlsec encrypt rsapub.pem doc.xml Body > encrypted.xml  RSA-OAEP传输AES-256-GCM密钥加密元素
+ *   ./gen_xmlsec verify rsapub.pem signed.xml                     验证封装签名
+ *   ./gen_xmlsec decrypt rsakey.pem encrypted.xml                 解密EncryptedData
+ */
+#include <stdio.h>
+#include <string.h>
+
+#include <libxml/parser.h>
+#include <libxml/tree.h>
+#include <xmlsec/xmlsec.h>
+#include <xmlsec/xmldsig.h>
+#include <xmlsec/xmlenc.h>
+#include <xmlsec/xmltree.h>
+#include <xmlsec/templates.h>
+#include <xmlsec/crypto.h>
+
+static xmlNodePtr find_element(xmlNodePtr node, const char *name) {
+    for (; node != NULL; node = node->next) {
+        if (node->type == XML_ELEMENT_NODE && xmlStrEqual(node->name, BAD_CAST name)) {
+            return node;
+        }
+        xmlNodePtr found = find_element(node->children, name);
+        if (found != NULL) {
+            return found;
+        }
+    }
+    return NULL;
+}
+
+static xmlSecKeyPtr load_key(const char *file) {
+    xmlSecKeyPtr key = xmlSecCryptoAppKeyLoad(file, xmlSecKeyDataFormatPem, NULL, NULL, NULL);
+    if (key == NULL) {
+        fprintf(stderr, "load key %s failed\n", file);
+    }
+    return key;
+}
+
+static int sign(xmlDocPtr doc, const char *keyFile) {
+    xmlNodePtr signNode = xmlSecTmplSignatureCreate(doc, xmlSecTransformExclC14NId, xmlSecTransformRsaSha256Id, NULL);
+    xmlAddChild(xmlDocGetRootElement(doc), signNode);
+    xmlNodePtr refNode = xmlSecTmplSignatureAddReference(signNode, xmlSecTransformSha256Id, NULL, BAD_CAST "", NULL);
+    xmlSecTmplReferenceAddTransform(refNode, xmlSecTransformEnvelopedId);
+    xmlSecTmplReferenceAddTransform(refNode, xmlSecTransformExclC14NId);
+
+    xmlSecDSigCtxPtr ctx = xmlSecDSigCtxCreate(NULL);
+    ctx->signKey = load_key(keyFile);
+    int ret = ctx->signKey != NULL ? xmlSecDSigCtxSign(ctx, signNode) : -1;
+    xmlSecDSigCtxDestroy(ctx);
+    return ret;
+}
+
+static int verify(xmlDocPtr doc, const char *keyFile) {
+    xmlNodePtr signNode = xmlSecFindNode(xmlDocGetRootElement(doc), xmlSecNodeSignature, xmlSecDSigNs);
+    if (signNode == NULL) {
+        return -1;
+    }
+    xmlSecDSigCtxPtr ctx = xmlSecDSigCtxCreate(NULL);
+    ctx->signKey = load_key(keyFile);
+    int ret = ctx->signKey != NULL ? xmlSecDSigCtxVerify(ctx, signNode) : -1;
+    if (ret == 0 && ctx->status != xmlSecDSigStatusSucceeded) {
+        ret = -1;
+    }
+    xmlSecDSigCtxDestroy(ctx);
+    return ret;
+}
+
+static xmlSecKeysMngrPtr keys_manager(const char *keyFile) {
+    xmlSecKeysMngrPtr mngr = xmlSecKeysMngrCreate();
+    xmlSecCryptoAppDefaultKeysMngrInit(mngr);
+    xmlSecKeyPtr key = load_key(keyFile);
+    if (key == NULL || xmlSecCryptoAppDefaultKeysMngrAdoptKey(mngr, key) < 0) {
+        xmlSecKeysMngrDestroy(mngr);
+        return NULL;
+    }
+    return mngr;
+}
+
+static int encrypt(xmlDocPtr doc, const char *keyFile, const char *name) {
+    xmlNodePtr target = find_element(xmlDocGetRootElement(doc), name);
+    if (target == NULL) {
+        return -1;
+    }
+    xmlNodePtr encNode = xmlSecTmplEncDataCreate(doc, xmlSecTransformAes256GcmId, NULL, xmlSecTypeEncElement, NULL, NULL);
+    xmlSecTmplEncDataEnsureCipherValue(encNode);
+    xmlNodePtr keyInfo = xmlSecTmplEncDataEnsureKeyInfo(encNode, NULL);
+    xmlNodePtr encKey = xmlSecTmplKeyInfoAddEncryptedKey(keyInfo, xmlSecTransformRsaOaepId, NULL, NULL, NULL);
+    xmlSecTmplEncDataEnsureCipherValue(encKey);
+
+    xmlSecKeysMngrPtr mngr = keys_manager(keyFile);
+    if (mngr == NULL) {
+        return -1;
+    }
+    xmlSecEncCtxPtr ctx = xmlSecEncCtxCreate(mngr);
+    ctx->encKey = xmlSecKeyGenerate(xmlSecKeyDataAesId, 256, xmlSecKeyDataTypeSession);
+    int ret = xmlSecEncCtxXmlEncrypt(ctx, encNode, target);
+    xmlSecEncCtxDestroy(ctx);
+    xmlSecKeysMngrDestroy(mngr);
+    return ret;
+}
+
+static int decrypt(xmlDocPtr doc, const char *keyFile) {
+    xmlNodePtr encNode = xmlSecFindNode(xmlDocGetRootElement(doc), xmlSecNodeEncryptedData, xmlSecEncNs);
+    if (encNode == NULL) {
+        return -1;
+    }
+    xmlSecKeysMngrPtr mngr = keys_manager(keyFile);
+    if (mngr == NULL) {
+        return -1;
+    }
+    xmlSecEncCtxPtr ctx = xmlSecEncCtxCreate(mngr);
+    int ret = xmlSecEncCtxDecrypt(ctx, encNode);
+    xmlSecEncCtxDestroy(ctx);
+    xmlSecKeysMngrDestroy(mngr);
+    return ret;
+}
+
+int main(int argc, char **argv) {
+    if (argc < 4) {
+        fprintf(stderr, "usage: %s sign|verify|encrypt|decrypt <key.pem> <doc.xml> [element]\n", argv[0]);
+        return 2;
+    }
+    xmlInitParser();
+    if (xmlSecInit() < 0 || xmlSecCryptoAppInit(NULL) < 0 || xmlSecCryptoInit() < 0) {
+        fprintf(stderr, "xmlsec init failed\n");
+        return 1;
+    }
+
+    xmlDocPtr doc = xmlReadFile(argv[3], NULL, XML_PARSE_NONET);
+    if (doc == NULL) {
+        fprintf(stderr, "parse %s failed\n", argv[3]);
+        return 1;
+    }
+
+    int ret;
+    if (strcmp(argv[1], "sign") == 0) {
+        ret = sign(doc, argv[2]);
+    } else if (strcmp(argv[1], "verify") == 0) {
+        ret = verify(doc, argv[2]);
+    } else if (strcmp(argv[1], "encrypt") == 0 && argc > 4) {
+        ret = encrypt(doc, argv[2], argv[4]);
+    } else if (strcmp(argv[1], "decrypt") == 0) {
+        ret = decrypt(doc, argv[2]);
+    } else {
+        fprintf(stderr, "unknown command %s\n", argv[1]);
+        ret = -1;
+    }
+    if (ret < 0) {
+        fprintf(stderr, "%s failed\n", argv[1]);
+    } else if (strcmp(argv[1], "verify") == 0) {
+        fprintf(stderr, "OK\n");
+    } else {
+        xmlDocDump(stdout, doc);
+    }
+
+    xmlFreeDoc(doc);
+    xmlSecCryptoShutdown();
+    xmlSecCryptoAppShutdown();
+    xmlSecShutdown();
+    xmlCleanupParser();
+    return ret < 0 ? 1 : 0;
+}
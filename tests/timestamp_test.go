@@ -0,0 +1,97 @@
+package tests
+
+import (
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// testdata/tsa 中的响应由 openssl ts -reply 生成，对应数据为 "hello timestamp"，请求未携带nonce
+
+// loadTSAFixture 读取TSA测试响应与根证书
+func loadTSAFixture(t *testing.T) ([]byte, *x509.CertPool) {
+	t.Helper()
+
+	resp, err := os.ReadFile("testdata/tsa/resp.tsr")
+	if err != nil {
+		t.Fatalf("读取时间戳响应失败: %v", err)
+	}
+	caPEM, err := os.ReadFile("testdata/tsa/ca.pem")
+	if err != nil {
+		t.Fatalf("读取根证书失败: %v", err)
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		t.Fatalf("加载根证书失败")
+	}
+	return resp, roots
+}
+
+// TestTimestampToken 测试解析与验证时间戳令牌
+func TestTimestampToken(t *testing.T) {
+	resp, roots := loadTSAFixture(t)
+
+	token, err := encrypt.ParseTimestampResponse(resp)
+	if err != nil {
+		t.Fatalf("解析时间戳响应失败: %v", err)
+	}
+	if token.HashAlgorithm != encrypt.HashSHA256 || token.Time.IsZero() {
+		t.Fatalf("令牌内容不正确: %+v", token)
+	}
+
+	if err := token.Verify([]byte("hello timestamp"), roots); err != nil {
+		t.Fatalf("验证时间戳失败: %v", err)
+	}
+	if err := token.Verify([]byte("other data"), roots); err == nil {
+		t.Fatalf("数据不一致时应该失败")
+	}
+	if err := token.Verify([]byte("hello timestamp"), x509.NewCertPool()); err == nil {
+		t.Fatalf("根证书不受信任时应该失败")
+	}
+
+	// 篡改令牌中的签名
+	tampered := append([]byte(nil), token.Raw...)
+	tampered[len(tampered)-10] ^= 0xff
+	if parsed, err := encrypt.ParseTimestampToken(tampered); err == nil {
+		if err := parsed.Verify([]byte("hello timestamp"), roots); err == nil {
+			t.Fatalf("篡改的令牌应该验证失败")
+		}
+	}
+}
+
+// TestTSAClient 测试时间戳客户端
+func TestTSAClient(t *testing.T) {
+	resp, roots := loadTSAFixture(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/timestamp-query" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(resp)
+	}))
+	defer server.Close()
+
+	token, err := encrypt.NewTSAClient(server.URL).NoNonce().Timestamp([]byte("hello timestamp"))
+	if err != nil {
+		t.Fatalf("请求时间戳失败: %v", err)
+	}
+	if err := token.Verify([]byte("hello timestamp"), roots); err != nil {
+		t.Fatalf("验证时间戳失败: %v", err)
+	}
+
+	// 响应与请求的摘要不一致
+	if _, err := encrypt.NewTSAClient(server.URL).NoNonce().Timestamp([]byte("other data")); err == nil {
+		t.Fatalf("摘要不一致时应该失败")
+	}
+	// 请求携带nonce而响应没有
+	if _, err := encrypt.NewTSAClient(server.URL).Timestamp([]byte("hello timestamp")); err == nil {
+		t.Fatalf("nonce不一致时应该失败")
+	}
+}
@@ -0,0 +1,88 @@
+package tests
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// tinkKeysetJSON 由Tink（Go v1.7.0）生成的AES128-GCM明文密钥集
+const tinkKeysetJSON = `{"primaryKeyId":2307575499, "key":[{"keyData":{"typeUrl":"type.googleapis.com/google.crypto.tink.AesGcmKey", "value":"GhBBC0Deftoa/UwC7sShX6ZN", "keyMaterialType":"SYMMETRIC"}, "status":"ENABLED", "keyId":2307575499, "outputPrefixType":"TINK"}]}`
+
+// tinkCiphertextHex Tink使用上述密钥集加密"shared ciphertext"（附加数据"tink"）的输出
+const tinkCiphertextHex = "01898acecb41f15ff3fd30e9f4b8ad8d1ead92430712f68643991b664c11a4dcff97386e1b7dcb35a08d5d9dd69b8aa521bb"
+
+// TestTinkInterop 测试解密Tink生成的密文
+func TestTinkInterop(t *testing.T) {
+	ks, err := encrypt.ParseTinkKeyset([]byte(tinkKeysetJSON))
+	if err != nil {
+		t.Fatalf("解析密钥集失败: %v", err)
+	}
+	primitive, err := ks.AEAD()
+	if err != nil {
+		t.Fatalf("创建AEAD失败: %v", err)
+	}
+
+	ciphertext, _ := hex.DecodeString(tinkCiphertextHex)
+	plaintext, err := primitive.Decrypt(ciphertext, []byte("tink"))
+	if err != nil || string(plaintext) != "shared ciphertext" {
+		t.Fatalf("解密Tink密文失败: %v", err)
+	}
+
+	// 二进制格式往返
+	binaryKs, err := encrypt.ParseTinkKeyset(ks.Binary())
+	if err != nil || binaryKs.PrimaryKeyID != 2307575499 {
+		t.Fatalf("二进制密钥集往返失败: %v", err)
+	}
+}
+
+// TestTinkKeyset 测试密钥集轮换、加密导出与导入密钥环
+func TestTinkKeyset(t *testing.T) {
+	ks, err := encrypt.NewTinkAESGCMKeyset(32)
+	if err != nil {
+		t.Fatalf("创建密钥集失败: %v", err)
+	}
+	oldAEAD, _ := ks.AEAD()
+	oldCiphertext, _ := oldAEAD.Encrypt([]byte("before rotation"), nil)
+
+	if _, err := ks.AddAESGCMKey(32, true); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	data, err := ks.JSON()
+	if err != nil {
+		t.Fatalf("导出JSON失败: %v", err)
+	}
+	parsed, err := encrypt.ParseTinkKeyset(data)
+	if err != nil {
+		t.Fatalf("解析JSON失败: %v", err)
+	}
+	primitive, _ := parsed.AEAD()
+	if plaintext, err := primitive.Decrypt(oldCiphertext, nil); err != nil || string(plaintext) != "before rotation" {
+		t.Fatalf("轮换后应能解密旧密文: %v", err)
+	}
+
+	// 加密密钥集
+	ring := encrypt.NewKeyRing()
+	kek, _ := encrypt.GenerateRandomKey(32)
+	if err := ring.Add(encrypt.KeyEntry{ID: "kek", Algorithm: encrypt.AlgorithmAES, Key: kek, Usage: encrypt.KeyUsageCipher}); err != nil {
+		t.Fatalf("添加密钥失败: %v", err)
+	}
+	encrypted, err := ks.EncryptJSON(context.Background(), ring, "kek")
+	if err != nil {
+		t.Fatalf("加密密钥集失败: %v", err)
+	}
+	decrypted, err := encrypt.ParseTinkEncryptedKeyset(context.Background(), encrypted, ring, "kek")
+	if err != nil || decrypted.PrimaryKeyID != ks.PrimaryKeyID || len(decrypted.Keys) != 2 {
+		t.Fatalf("解密密钥集失败: %v", err)
+	}
+
+	imported := encrypt.NewKeyRing()
+	if err := decrypted.ImportToKeyRing(imported); err != nil {
+		t.Fatalf("导入密钥环失败: %v", err)
+	}
+	if len(imported.IDs()) != 2 || imported.Primary() == "" {
+		t.Fatalf("导入的密钥不正确: %v", imported.IDs())
+	}
+}
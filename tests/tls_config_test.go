@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+	gmx509 "github.com/tjfoc/gmsm/x509"
+)
+
+// TestNewTLSConfig 测试从PEM构建标准TLS配置
+func TestNewTLSConfig(t *testing.T) {
+	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("生成RSA密钥失败: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &privKey.PublicKey, privKey)
+	if err != nil {
+		t.Fatalf("创建证书失败: %v", err)
+	}
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privKey)})
+
+	config, err := encrypt.NewTLSConfig(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("创建TLS配置失败: %v", err)
+	}
+	if len(config.Certificates) != 1 {
+		t.Fatalf("证书数量不正确: %d", len(config.Certificates))
+	}
+}
+
+// TestNewGMTLSServerConfig 测试构建国密双证书TLS配置
+func TestNewGMTLSServerConfig(t *testing.T) {
+	newSM2Cert := func() ([]byte, []byte) {
+		sm2Encryptor := encrypt.MustNewSM2().(*encrypt.SM2Encryptor)
+		pubKey, privKey, err := sm2Encryptor.GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("SM2密钥生成失败: %v", err)
+		}
+		signer, err := sm2Encryptor.Signer()
+		if err != nil {
+			t.Fatalf("获取SM2 Signer失败: %v", err)
+		}
+		sm2PubKey, err := gmx509.ReadPublicKeyFromPem(pubKey)
+		if err != nil {
+			t.Fatalf("解析SM2公钥失败: %v", err)
+		}
+
+		template := &gmx509.Certificate{
+			SerialNumber:       big.NewInt(1),
+			Subject:            pkix.Name{CommonName: "localhost"},
+			NotBefore:          time.Now(),
+			NotAfter:           time.Now().Add(time.Hour),
+			SignatureAlgorithm: gmx509.SM2WithSM3,
+		}
+		certPEM, err := gmx509.CreateCertificateToPem(template, template, sm2PubKey, signer)
+		if err != nil {
+			t.Fatalf("创建SM2证书失败: %v", err)
+		}
+		return certPEM, privKey
+	}
+
+	signCert, signKey := newSM2Cert()
+	encCert, encKey := newSM2Cert()
+
+	config, err := encrypt.NewGMTLSServerConfig(signCert, signKey, encCert, encKey)
+	if err != nil {
+		t.Fatalf("创建GMTLS配置失败: %v", err)
+	}
+	if len(config.Certificates) != 2 || config.GMSupport == nil {
+		t.Fatalf("GMTLS配置不正确")
+	}
+
+	if _, err := encrypt.NewGMTLSClientConfig(signCert, nil, nil); err != nil {
+		t.Fatalf("创建GMTLS客户端配置失败: %v", err)
+	}
+}
@@ -0,0 +1,66 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestTruncatedHMAC 测试截断HMAC及其长度限制
+func TestTruncatedHMAC(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	data := []byte("order:1024")
+
+	mac, err := encrypt.TruncatedHMAC(key, data, 10)
+	if err != nil {
+		t.Fatalf("计算失败: %v", err)
+	}
+	full := hmac.New(sha256.New, key)
+	full.Write(data)
+	if !bytes.Equal(mac, full.Sum(nil)[:10]) {
+		t.Fatal("应截取HMAC最左侧的字节")
+	}
+	if err := encrypt.VerifyTruncatedHMAC(key, data, mac); err != nil {
+		t.Fatalf("校验失败: %v", err)
+	}
+
+	// 篡改、过短的前缀都不能通过校验
+	mac[0] ^= 1
+	if err := encrypt.VerifyTruncatedHMAC(key, data, mac); !errors.Is(err, encrypt.ErrInvalidMAC) {
+		t.Fatalf("篡改后应返回ErrInvalidMAC，实际为%v", err)
+	}
+	mac[0] ^= 1
+	if err := encrypt.VerifyTruncatedHMAC(key, data, mac[:4]); err == nil {
+		t.Fatal("短于最小长度的MAC不应通过校验")
+	}
+
+	if _, err := encrypt.TruncatedHMAC(key, data, 7); err == nil {
+		t.Fatal("截断长度低于下限应报错")
+	}
+	if _, err := encrypt.TruncatedHMAC(key, data, 33); err == nil {
+		t.Fatal("截断长度超过哈希输出应报错")
+	}
+	if _, err := encrypt.TruncatedHMAC(key[:8], data, 8); err == nil {
+		t.Fatal("过短的密钥应报错")
+	}
+	if mac, _ := encrypt.TruncatedHMACWithHash(encrypt.HashSM3, key, data, 16); len(mac) != 16 {
+		t.Fatal("SM3截断长度不正确")
+	}
+
+	// 碰撞概率与长度选择
+	p := encrypt.TruncatedHMACCollisionProbability(8, 100_000_000)
+	if math.Abs(p-2.7e-4) > 0.1e-4 {
+		t.Fatalf("一亿个8字节标识的碰撞概率约为2.7e-4，实际为%g", p)
+	}
+	if n, _ := encrypt.MinTruncatedHMACSize(100_000_000, 1e-9); n != 11 {
+		t.Fatalf("一亿个标识碰撞概率不超过1e-9需要11字节，实际为%d", n)
+	}
+	if n, _ := encrypt.MinTruncatedHMACSize(10, 0.5); n != encrypt.TruncatedHMACMinSize {
+		t.Fatalf("不应低于最小长度，实际为%d", n)
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestUploadDigestS3ETag 测试与S3分片ETag一致
+func TestUploadDigestS3ETag(t *testing.T) {
+	part1 := bytes.Repeat([]byte("a"), 5*1024*1024)
+	part2 := bytes.Repeat([]byte("b"), 100)
+
+	digest := encrypt.NewS3UploadDigest()
+	// 分片乱序到达
+	if _, err := digest.AddPartReader(2, bytes.NewReader(part2)); err != nil {
+		t.Fatalf("添加分片失败: %v", err)
+	}
+	if _, err := digest.Final(); err == nil {
+		t.Fatal("缺少分片时应当失败")
+	}
+	if _, err := digest.AddPart(1, part1); err != nil {
+		t.Fatalf("添加分片失败: %v", err)
+	}
+	etag, err := digest.Final()
+	if err != nil || etag != "16cafe8d6b8c1c55c3d339c8d69326c6-2" {
+		t.Fatalf("ETag与S3不一致: %v %s", err, etag)
+	}
+}
+
+// TestUploadDigestKeyed 测试带密钥的分片摘要
+func TestUploadDigestKeyed(t *testing.T) {
+	key := bytes.Repeat([]byte{9}, 32)
+	parts := [][]byte{[]byte("part-one"), []byte("part-two"), []byte("part-three")}
+
+	// 客户端计算各分片摘要
+	client, _ := encrypt.NewKeyedUploadDigest(encrypt.HashSM3, key)
+	declared := make([][]byte, len(parts))
+	for i, part := range parts {
+		declared[i], _ = client.AddPart(i+1, part)
+	}
+	expected, _ := client.Final()
+
+	// 服务端并发校验分片
+	server, _ := encrypt.NewKeyedUploadDigest(encrypt.HashSM3, key)
+	var wg sync.WaitGroup
+	errs := make([]error, len(parts))
+	for i := range parts {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = server.VerifyPart(i+1, parts[i], declared[i])
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatalf("分片校验失败: %v", err)
+		}
+	}
+	final, err := server.Final()
+	if err != nil || final != expected || strings.Contains(final, "-") {
+		t.Fatalf("最终摘要不一致: %v %s", err, final)
+	}
+
+	// 调换分片顺序或篡改内容都会校验失败
+	if err := server.VerifyPart(1, parts[1], declared[1]); err == nil {
+		t.Fatal("调换分片顺序应当失败")
+	}
+	if err := server.VerifyPart(3, []byte("tampered"), declared[2]); err == nil {
+		t.Fatal("篡改分片应当失败")
+	}
+
+	plain := encrypt.NewUploadDigest(encrypt.HashSHA256)
+	_, _ = plain.AddPart(1, parts[0])
+	if final, _ := plain.Final(); !strings.HasSuffix(final, "-1") || len(final) != 66 {
+		t.Fatalf("普通摘要格式不正确: %s", final)
+	}
+	if _, err := plain.AddPart(0, nil); err == nil {
+		t.Fatal("分片号0应当失败")
+	}
+}
@@ -0,0 +1,72 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestValidateKeyPair 测试公私钥匹配校验
+func TestValidateKeyPair(t *testing.T) {
+	t.Run("RSA", func(t *testing.T) {
+		pub1, priv1, err := encrypt.MustNewRSA().GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("RSA密钥生成失败: %v", err)
+		}
+		pub2, _, err := encrypt.MustNewRSA().GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("RSA密钥生成失败: %v", err)
+		}
+
+		if err := encrypt.ValidateKeyPair(pub1, priv1); err != nil {
+			t.Errorf("匹配的RSA密钥校验失败: %v", err)
+		}
+		if err := encrypt.ValidateKeyPair(pub2, priv1); err == nil {
+			t.Errorf("不匹配的RSA密钥应该校验失败")
+		}
+	})
+
+	t.Run("SM2", func(t *testing.T) {
+		pub1, priv1, err := encrypt.MustNewSM2().GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("SM2密钥生成失败: %v", err)
+		}
+		pub2, _, err := encrypt.MustNewSM2().GenerateKeyPair()
+		if err != nil {
+			t.Fatalf("SM2密钥生成失败: %v", err)
+		}
+
+		if err := encrypt.ValidateKeyPair(pub1, priv1); err != nil {
+			t.Errorf("匹配的SM2密钥校验失败: %v", err)
+		}
+		if err := encrypt.ValidateKeyPair(pub2, priv1); err == nil {
+			t.Errorf("不匹配的SM2密钥应该校验失败")
+		}
+	})
+}
+
+// TestValidateKey 测试对称密钥校验
+func TestValidateKey(t *testing.T) {
+	testCases := []struct {
+		name      string
+		algorithm encrypt.Algorithm
+		key       []byte
+		valid     bool
+	}{
+		{"AES-128", encrypt.AlgorithmAES, []byte("0123456789ABCDEF"), true},
+		{"AES长度错误", encrypt.AlgorithmAES, []byte("0123456789"), false},
+		{"AES全零", encrypt.AlgorithmAES, make([]byte, 16), false},
+		{"SM4", encrypt.AlgorithmSM4, []byte("0123456789ABCDEF"), true},
+		{"3DES", encrypt.Algorithm3DES, []byte("0123456789ABCDEFGHIJKLMN"), true},
+		{"3DES退化", encrypt.Algorithm3DES, []byte("01234567012345670123456X"), false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := encrypt.ValidateKey(tc.algorithm, tc.key)
+			if (err == nil) != tc.valid {
+				t.Errorf("期望有效性 %v，实际错误: %v", tc.valid, err)
+			}
+		})
+	}
+}
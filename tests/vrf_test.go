@@ -0,0 +1,89 @@
+package tests
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestVRFVector 使用RFC 9381附录B.3的测试向量
+func TestVRFVector(t *testing.T) {
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	wantProof := "8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f" +
+		"26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805"
+	wantBeta := "90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff" +
+		"66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae"
+
+	privateKey := ed25519.NewKeyFromSeed(seed)
+	proof, beta, err := encrypt.VRFProve(privateKey, nil)
+	if err != nil {
+		t.Fatalf("计算证明失败: %v", err)
+	}
+	if hex.EncodeToString(proof) != wantProof || hex.EncodeToString(beta) != wantBeta {
+		t.Fatalf("与测试向量不一致:\nproof=%x\nbeta=%x", proof, beta)
+	}
+
+	verified, err := encrypt.VRFVerify(privateKey.Public().(ed25519.PublicKey), nil, proof)
+	if err != nil || !bytes.Equal(verified, beta) {
+		t.Fatalf("验证失败: %v", err)
+	}
+}
+
+// TestVRF 测试证明的验证与篡改检测
+func TestVRF(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	alpha := []byte("lottery-round-1024")
+
+	proof, beta, err := encrypt.VRFProve(priv, alpha)
+	if err != nil {
+		t.Fatalf("计算证明失败: %v", err)
+	}
+	if len(proof) != encrypt.VRFProofSize || len(beta) != encrypt.VRFOutputSize {
+		t.Fatalf("长度不正确: %d, %d", len(proof), len(beta))
+	}
+
+	// 同一输入的证明与输出唯一
+	again, _, _ := encrypt.VRFProve(priv, alpha)
+	if !bytes.Equal(again, proof) {
+		t.Fatal("同一输入的证明应相同")
+	}
+	if hash, _ := encrypt.VRFProofToHash(proof); !bytes.Equal(hash, beta) {
+		t.Fatal("VRFProofToHash结果不一致")
+	}
+
+	// 输入、公钥或证明不符时验证失败
+	if _, err := encrypt.VRFVerify(pub, []byte("lottery-round-1025"), proof); !errors.Is(err, encrypt.ErrVRFInvalidProof) {
+		t.Fatalf("不同输入应验证失败: %v", err)
+	}
+	otherPub, _, _ := ed25519.GenerateKey(rand.Reader)
+	if _, err := encrypt.VRFVerify(otherPub, alpha, proof); !errors.Is(err, encrypt.ErrVRFInvalidProof) {
+		t.Fatalf("不同公钥应验证失败: %v", err)
+	}
+	for _, i := range []int{0, 40, 79} {
+		tampered := bytes.Clone(proof)
+		tampered[i] ^= 0x01
+		if _, err := encrypt.VRFVerify(pub, alpha, tampered); err == nil {
+			t.Fatalf("篡改第%d字节后应验证失败", i)
+		}
+	}
+
+	// PEM编码的密钥
+	privDER, _ := x509.MarshalPKCS8PrivateKey(priv)
+	pubDER, _ := x509.MarshalPKIXPublicKey(pub)
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privDER})
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+	pemProof, _, err := encrypt.VRFProvePEM(privPEM, alpha)
+	if err != nil || !bytes.Equal(pemProof, proof) {
+		t.Fatalf("PEM私钥计算证明失败: %v", err)
+	}
+	if got, err := encrypt.VRFVerifyPEM(pubPEM, alpha, proof); err != nil || !bytes.Equal(got, beta) {
+		t.Fatalf("PEM公钥验证失败: %v", err)
+	}
+}
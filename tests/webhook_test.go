@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+// TestWebhookSignature 测试Webhook签名头的生成与验证
+func TestWebhookSignature(t *testing.T) {
+	secret := []byte("whsec_test")
+	body := []byte(`{"id":"evt_1"}`)
+	sentAt := time.Unix(1700000000, 0)
+
+	header := encrypt.SignWebhook(secret, sentAt, body)
+	if header != "t=1700000000,v1=c89214b5b5da833daed6f0b8c5bb6bd58cea9022bd80ccc78230f3942d632925" {
+		t.Fatalf("签名头与参考实现不一致: %s", header)
+	}
+
+	if err := encrypt.VerifyWebhookAt(secret, header, body, time.Minute, sentAt.Add(30*time.Second)); err != nil {
+		t.Fatalf("验证失败: %v", err)
+	}
+	if err := encrypt.VerifyWebhookAt(secret, header, body, time.Minute, sentAt.Add(2*time.Minute)); !errors.Is(err, encrypt.ErrWebhookTimestamp) {
+		t.Fatalf("过期的签名应当失败: %v", err)
+	}
+	if err := encrypt.VerifyWebhookAt(secret, header, body, 0, sentAt.Add(24*time.Hour)); err != nil {
+		t.Fatalf("容忍范围为0时不检查时间戳: %v", err)
+	}
+	if err := encrypt.VerifyWebhookAt(secret, header, []byte(`{"id":"evt_2"}`), time.Minute, sentAt); !errors.Is(err, encrypt.ErrInvalidMAC) {
+		t.Fatalf("篡改的内容应当失败: %v", err)
+	}
+
+	// 密钥轮换期间签名头包含多个v1
+	rotated := encrypt.SignWebhook([]byte("whsec_new"), sentAt, body)
+	multi := rotated + ",v1=" + header[len("t=1700000000,v1="):] + ",v0=ignored"
+	if err := encrypt.VerifyWebhookAt(secret, multi, body, time.Minute, sentAt); err != nil {
+		t.Fatalf("多个签名时应有一个匹配: %v", err)
+	}
+
+	for _, bad := range []string{"", "v1=abcd", "t=1700000000", "t=abc,v1=00", "t=1700000000,v1=zz"} {
+		if err := encrypt.VerifyWebhookAt(secret, bad, body, time.Minute, sentAt); err == nil {
+			t.Fatalf("格式错误的签名头应当失败: %q", bad)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package tests
+
+import (
+	"bytes"
+	"crypto"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sylphbyte/encrypt"
+)
+
+const xmlsecDocument = `<?xml version="1.0" encoding="UTF-8"?>
+<req:Request xmlns:req="urn:gov:payment" xmlns:unused="urn:unused" b="2" a="1">
+  <req:Header><req:Id>20240001</req:Id></req:Header>
+  <req:Body><req:Account>6222****0001</req:Account><req:Amount>100.00</req:Amount></req:Body>
+</req:Request>`
+
+// TestXMLSignature 测试XML封装签名
+func TestXMLSignature(t *testing.T) {
+	rsaPub, rsaPriv, err := encrypt.MustNewRSA().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	sm2Cert, sm2Priv := newSM2CertPEM(t, 1)
+
+	for _, c := range []struct {
+		name      string
+		priv      []byte
+		verifyKey []byte
+		cert      []byte
+		algorithm string
+	}{
+		{"RSA", rsaPriv, rsaPub, nil, encrypt.XMLSignatureRSASHA256},
+		{"SM2", sm2Priv, sm2Cert, sm2Cert, encrypt.XMLSignatureSM2SM3},
+	} {
+		signer, err := encrypt.ParseSigner(c.priv)
+		if err != nil {
+			t.Fatalf("%s 解析私钥失败: %v", c.name, err)
+		}
+		signed, err := encrypt.SignXML([]byte(xmlsecDocument), signer, c.cert)
+		if err != nil {
+			t.Fatalf("%s 签名失败: %v", c.name, err)
+		}
+		if !bytes.Contains(signed, []byte(c.algorithm)) {
+			t.Fatalf("%s 签名算法不正确", c.name)
+		}
+		if err := encrypt.VerifyXML(signed, c.verifyKey); err != nil {
+			t.Fatalf("%s 验签失败: %v", c.name, err)
+		}
+
+		// 属性顺序、无关命名空间声明与自闭合形式不影响规范化结果
+		reformatted := bytes.Replace(signed, []byte(`xmlns:unused="urn:unused" b="2" a="1"`), []byte(`a="1"  b="2"`), 1)
+		if err := encrypt.VerifyXML(reformatted, c.verifyKey); err != nil {
+			t.Fatalf("%s 等价文档验签失败: %v", c.name, err)
+		}
+
+		tampered := bytes.Replace(signed, []byte("100.00"), []byte("900.00"), 1)
+		if encrypt.VerifyXML(tampered, c.verifyKey) == nil {
+			t.Fatalf("%s 篡改内容后验签应失败", c.name)
+		}
+	}
+
+	if _, err := encrypt.SignXML([]byte(`<!DOCTYPE r [<!ENTITY x "y">]><r>&x;</r>`), mustSigner(t, rsaPriv), nil); err == nil {
+		t.Fatal("包含DTD的文档应被拒绝")
+	}
+	if _, err := encrypt.SignXML([]byte(`<r/>`), mustSigner(t, rsaPriv), nil); err == nil {
+		t.Fatal("空根元素应被拒绝")
+	}
+}
+
+// TestXMLEncryption 测试XML元素加密
+func TestXMLEncryption(t *testing.T) {
+	rsaPub, rsaPriv, err := encrypt.MustNewRSA().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	sm2Cert, sm2Priv := newSM2CertPEM(t, 2)
+
+	for _, c := range []struct {
+		name      string
+		recipient []byte
+		priv      []byte
+	}{
+		{"RSA", rsaPub, rsaPriv},
+		{"SM2", sm2Cert, sm2Priv},
+	} {
+		encrypted, err := encrypt.EncryptXMLElement([]byte(xmlsecDocument), "Body", c.recipient)
+		if err != nil {
+			t.Fatalf("%s 加密失败: %v", c.name, err)
+		}
+		if bytes.Contains(encrypted, []byte("6222****0001")) || !strings.Contains(string(encrypted), "<req:Header>") {
+			t.Fatalf("%s 只应加密目标元素", c.name)
+		}
+		decrypted, err := encrypt.DecryptXML(encrypted, c.priv)
+		if err != nil {
+			t.Fatalf("%s 解密失败: %v", c.name, err)
+		}
+		if string(decrypted) != xmlsecDocument {
+			t.Fatalf("%s 解密结果不一致: %s", c.name, decrypted)
+		}
+	}
+
+	if _, err := encrypt.EncryptXMLElement([]byte(xmlsecDocument), "Missing", rsaPub); err == nil {
+		t.Fatal("不存在的元素应返回错误")
+	}
+}
+
+// TestXMLDecryptionError 测试密钥错误、密文篡改与旧版SM4-CBC报文统一返回ErrXMLDecryption
+func TestXMLDecryptionError(t *testing.T) {
+	rsaPub, rsaPriv, err := encrypt.MustNewRSA().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	_, otherPriv, err := encrypt.MustNewRSA().GenerateKeyPair()
+	if err != nil {
+		t.Fatalf("RSA密钥生成失败: %v", err)
+	}
+	sm2Cert, sm2Priv := newSM2CertPEM(t, 3)
+
+	encrypted, err := encrypt.EncryptXMLElement([]byte(xmlsecDocument), "Body", rsaPub)
+	if err != nil {
+		t.Fatalf("加密失败: %v", err)
+	}
+	if _, err := encrypt.DecryptXML(encrypted, otherPriv); !errors.Is(err, encrypt.ErrXMLDecryption) {
+		t.Fatalf("私钥错误时应返回ErrXMLDecryption: %v", err)
+	}
+	if _, err := encrypt.DecryptXML(tamperXMLCipherValue(t, encrypted), rsaPriv); !errors.Is(err, encrypt.ErrXMLDecryption) {
+		t.Fatalf("密文篡改时应返回ErrXMLDecryption: %v", err)
+	}
+
+	sm2Encrypted, err := encrypt.EncryptXMLElement([]byte(xmlsecDocument), "Body", sm2Cert)
+	if err != nil {
+		t.Fatalf("SM2加密失败: %v", err)
+	}
+	if !bytes.Contains(sm2Encrypted, []byte(encrypt.XMLEncSM4GCM)) {
+		t.Fatal("SM2接收方应使用SM4-GCM")
+	}
+	if _, err := encrypt.DecryptXML(tamperXMLCipherValue(t, sm2Encrypted), sm2Priv); !errors.Is(err, encrypt.ErrXMLDecryption) {
+		t.Fatalf("SM4-GCM密文篡改时应返回ErrXMLDecryption: %v", err)
+	}
+	// 按旧版SM4-CBC解析时填充错误同样只返回ErrXMLDecryption
+	legacy := bytes.Replace(sm2Encrypted, []byte(encrypt.XMLEncSM4GCM), []byte(encrypt.XMLEncSM4CBC), 1)
+	if _, err := encrypt.DecryptXML(legacy, sm2Priv); !errors.Is(err, encrypt.ErrXMLDecryption) {
+		t.Fatalf("SM4-CBC解密失败时应返回ErrXMLDecryption: %v", err)
+	}
+}
+
+// TestXMLSecInterop 测试与xmlsec1生成的签名与加密报文互通
+// testdata/xmlsec 中的报文由 gen_xmlsec.c 基于libxmlsec1-openssl生成，原文为doc.xml
+func TestXMLSecInterop(t *testing.T) {
+	read := func(name string) []byte {
+		data, err := os.ReadFile("testdata/xmlsec/" + name)
+		if err != nil {
+			t.Fatalf("读取%s失败: %v", name, err)
+		}
+		return data
+	}
+	doc, pub, priv := read("doc.xml"), read("rsapub.pem"), read("rsakey.pem")
+
+	signed := read("signed.xml")
+	if err := encrypt.VerifyXML(signed, pub); err != nil {
+		t.Fatalf("验证xmlsec1签名失败: %v", err)
+	}
+	if encrypt.VerifyXML(bytes.Replace(signed, []byte("100.00"), []byte("900.00"), 1), pub) == nil {
+		t.Fatal("篡改xmlsec1签名的文档后验签应失败")
+	}
+
+	decrypted, err := encrypt.DecryptXML(read("encrypted.xml"), priv)
+	if err != nil {
+		t.Fatalf("解密xmlsec1加密报文失败: %v", err)
+	}
+	if !bytes.Equal(decrypted, doc) {
+		t.Fatalf("解密xmlsec1加密报文结果不一致: %s", decrypted)
+	}
+}
+
+// tamperXMLCipherValue 修改EncryptedData中最后一个CipherValue（数据密文）的首个Base64字符
+func tamperXMLCipherValue(t *testing.T, doc []byte) []byte {
+	t.Helper()
+	marker := []byte("CipherValue>")
+	i := bytes.LastIndex(doc, append([]byte("<xenc:"), marker...))
+	if i < 0 {
+		t.Fatal("未找到CipherValue")
+	}
+	out := append([]byte(nil), doc...)
+	pos := i + len("<xenc:") + len(marker)
+	if out[pos] == 'A' {
+		out[pos] = 'B'
+	} else {
+		out[pos] = 'A'
+	}
+	return out
+}
+
+// mustSigner 解析私钥为crypto.Signer
+func mustSigner(t *testing.T, privateKeyPEM []byte) crypto.Signer {
+	t.Helper()
+	signer, err := encrypt.ParseSigner(privateKeyPEM)
+	if err != nil {
+		t.Fatalf("解析私钥失败: %v", err)
+	}
+	return signer
+}
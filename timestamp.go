@@ -0,0 +1,520 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RFC 3161 时间戳客户端
+//
+// 向时间戳服务（TSA）提交数据摘要，获得由TSA签名的时间戳令牌，证明数据在该时间点之前已经存在。
+// 对签名值加盖时间戳（TimestampSignature）后，即使签名证书过期或被吊销，
+// 仍可证明签名产生于证书有效期内，这是长期签名验证的基础
+
+// 时间戳相关OID
+var (
+	oidTSTInfo           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidSignedData        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidAttrContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidAttrMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidHashSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidHashSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidHashSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	oidHashSM3    = asn1.ObjectIdentifier{1, 2, 156, 10197, 1, 401}
+)
+
+// tsaDefaultTimeout 默认请求超时
+const tsaDefaultTimeout = 30 * time.Second
+
+// TSAClient 时间戳服务客户端
+type TSAClient struct {
+	url        string
+	hashAlgo   HashAlgorithm
+	policy     asn1.ObjectIdentifier
+	httpClient *http.Client
+	nonce      bool
+}
+
+// TimestampToken 解析后的时间戳令牌
+type TimestampToken struct {
+	Raw           []byte // 完整的令牌（CMS SignedData），用于存档
+	Time          time.Time
+	SerialNumber  *big.Int
+	Policy        asn1.ObjectIdentifier
+	HashAlgorithm HashAlgorithm
+	HashedMessage []byte
+	Nonce         *big.Int
+	Certificates  []*x509.Certificate
+
+	signedData *tsaSignedData
+	eContent   []byte
+}
+
+// NewTSAClient 创建时间戳客户端，默认使用SHA-256并携带随机nonce
+func NewTSAClient(url string) *TSAClient {
+	return &TSAClient{
+		url:        url,
+		hashAlgo:   HashSHA256,
+		httpClient: &http.Client{Timeout: tsaDefaultTimeout},
+		nonce:      true,
+	}
+}
+
+// SHA256 使用SHA-256计算摘要
+func (c *TSAClient) SHA256() *TSAClient {
+	c.hashAlgo = HashSHA256
+	return c
+}
+
+// SHA512 使用SHA-512计算摘要
+func (c *TSAClient) SHA512() *TSAClient {
+	c.hashAlgo = HashSHA512
+	return c
+}
+
+// SM3 使用SM3计算摘要
+func (c *TSAClient) SM3() *TSAClient {
+	c.hashAlgo = HashSM3
+	return c
+}
+
+// WithPolicy 指定请求的时间戳策略
+func (c *TSAClient) WithPolicy(policy asn1.ObjectIdentifier) *TSAClient {
+	c.policy = policy
+	return c
+}
+
+// WithHTTPClient 设置HTTP客户端，用于配置代理、TLS或超时
+func (c *TSAClient) WithHTTPClient(client *http.Client) *TSAClient {
+	c.httpClient = client
+	return c
+}
+
+// NoNonce 不携带nonce，用于不支持nonce的TSA
+func (c *TSAClient) NoNonce() *TSAClient {
+	c.nonce = false
+	return c
+}
+
+// Timestamp 计算数据摘要并请求时间戳
+func (c *TSAClient) Timestamp(data []byte) (*TimestampToken, error) {
+	h := hashFunc(c.hashAlgo)()
+	h.Write(data)
+	return c.TimestampDigest(h.Sum(nil))
+}
+
+// TimestampSignature 为签名值请求时间戳（RFC 3161 附录A），用于长期签名验证
+func (c *TSAClient) TimestampSignature(signature []byte) (*TimestampToken, error) {
+	return c.Timestamp(signature)
+}
+
+// TimestampDigest 使用预先计算的摘要请求时间戳，摘要算法必须与客户端配置一致
+func (c *TSAClient) TimestampDigest(digest []byte) (*TimestampToken, error) {
+	hashOID, err := hashAlgorithmOID(c.hashAlgo)
+	if err != nil {
+		return nil, err
+	}
+	if len(digest) != hashFunc(c.hashAlgo)().Size() {
+		return nil, errors.New("摘要长度与摘要算法不匹配")
+	}
+
+	req := tsaRequest{
+		Version: 1,
+		MessageImprint: tsaMessageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: hashOID, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		ReqPolicy: c.policy,
+		CertReq:   true,
+	}
+	if c.nonce {
+		nonce, err := GenerateRandomBytes(8)
+		if err != nil {
+			return nil, err
+		}
+		req.Nonce = new(big.Int).SetBytes(nonce)
+	}
+
+	body, err := asn1.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "编码时间戳请求失败")
+	}
+
+	resp, err := c.httpClient.Post(c.url, "application/timestamp-query", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "请求时间戳服务失败")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("时间戳服务返回HTTP状态%d", resp.StatusCode)
+	}
+	respBody, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, errors.Wrap(err, "读取时间戳响应失败")
+	}
+
+	token, err := ParseTimestampResponse(respBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// 校验响应与请求对应
+	if !bytes.Equal(token.HashedMessage, digest) || token.HashAlgorithm != c.hashAlgo {
+		return nil, errors.New("时间戳响应的摘要与请求不一致")
+	}
+	if req.Nonce != nil && (token.Nonce == nil || token.Nonce.Cmp(req.Nonce) != 0) {
+		return nil, errors.New("时间戳响应的nonce与请求不一致")
+	}
+	if c.policy != nil && !token.Policy.Equal(c.policy) {
+		return nil, errors.New("时间戳响应的策略与请求不一致")
+	}
+	return token, nil
+}
+
+// ParseTimestampResponse 解析TSA返回的TimeStampResp
+func ParseTimestampResponse(data []byte) (*TimestampToken, error) {
+	var resp tsaResponse
+	if rest, err := asn1.Unmarshal(data, &resp); err != nil || len(rest) > 0 {
+		return nil, errors.New("解析时间戳响应失败")
+	}
+
+	// 0: granted，1: grantedWithMods
+	if resp.Status.Status != 0 && resp.Status.Status != 1 {
+		return nil, errors.Errorf("时间戳服务拒绝请求: 状态%d %v", resp.Status.Status, resp.Status.StatusString)
+	}
+	if len(resp.TimeStampToken.FullBytes) == 0 {
+		return nil, errors.New("时间戳响应不包含令牌")
+	}
+	return ParseTimestampToken(resp.TimeStampToken.FullBytes)
+}
+
+// ParseTimestampToken 解析时间戳令牌（CMS ContentInfo），不校验签名
+func ParseTimestampToken(raw []byte) (*TimestampToken, error) {
+	var ci tsaContentInfo
+	if rest, err := asn1.Unmarshal(raw, &ci); err != nil || len(rest) > 0 {
+		return nil, errors.New("解析时间戳令牌失败")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, errors.New("时间戳令牌不是SignedData")
+	}
+
+	var sd tsaSignedData
+	if _, err := asn1.Unmarshal(ci.Content.Bytes, &sd); err != nil {
+		return nil, errors.Wrap(err, "解析SignedData失败")
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, errors.New("时间戳令牌内容不是TSTInfo")
+	}
+
+	var info tsaTSTInfo
+	if _, err := asn1.Unmarshal(sd.EncapContentInfo.EContent, &info); err != nil {
+		return nil, errors.Wrap(err, "解析TSTInfo失败")
+	}
+
+	hashAlgo, err := hashAlgorithmFromOID(info.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		if certs, err = x509.ParseCertificates(sd.Certificates.Bytes); err != nil {
+			return nil, errors.Wrap(err, "解析时间戳证书失败")
+		}
+	}
+
+	return &TimestampToken{
+		Raw:           raw,
+		Time:          info.GenTime,
+		SerialNumber:  info.SerialNumber,
+		Policy:        info.Policy,
+		HashAlgorithm: hashAlgo,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		Nonce:         info.Nonce,
+		Certificates:  certs,
+		signedData:    &sd,
+		eContent:      sd.EncapContentInfo.EContent,
+	}, nil
+}
+
+// Verify 校验时间戳令牌：数据摘要一致、TSA签名有效，且TSA证书在时间戳时刻可链到roots
+func (t *TimestampToken) Verify(data []byte, roots *x509.CertPool) error {
+	h := hashFunc(t.HashAlgorithm)()
+	h.Write(data)
+	if !bytes.Equal(h.Sum(nil), t.HashedMessage) {
+		return errors.New("数据摘要与时间戳令牌不一致")
+	}
+	return t.VerifySignature(roots)
+}
+
+// VerifySignature 校验TSA签名与证书链，不校验数据摘要
+func (t *TimestampToken) VerifySignature(roots *x509.CertPool) error {
+	if len(t.signedData.SignerInfos) != 1 {
+		return errors.New("时间戳令牌必须只有一个签名者")
+	}
+	si := t.signedData.SignerInfos[0]
+
+	signer, err := t.findSigner(si.SID)
+	if err != nil {
+		return err
+	}
+
+	digestAlgo, err := hashAlgorithmFromOID(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return err
+	}
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return errors.New("时间戳令牌缺少签名属性")
+	}
+
+	// 签名属性中的messageDigest必须与TSTInfo的摘要一致
+	signedAttrs := append([]byte(nil), si.SignedAttrs.FullBytes...)
+	signedAttrs[0] = 0x31 // [0] IMPLICIT 还原为 SET OF 后参与签名
+	var attrs []tsaAttribute
+	if _, err := asn1.UnmarshalWithParams(signedAttrs, &attrs, "set"); err != nil {
+		return errors.Wrap(err, "解析签名属性失败")
+	}
+	if err := checkTimestampAttributes(attrs, digestAlgo, t.eContent); err != nil {
+		return err
+	}
+
+	h := hashFunc(digestAlgo)()
+	h.Write(signedAttrs)
+	if err := verifyTimestampSignature(signer.PublicKey, digestAlgo, h.Sum(nil), si.Signature); err != nil {
+		return err
+	}
+
+	// 校验证书链，以时间戳时刻作为验证时间
+	intermediates := x509.NewCertPool()
+	for _, cert := range t.Certificates {
+		intermediates.AddCert(cert)
+	}
+	if _, err := signer.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   t.Time,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}); err != nil {
+		return errors.Wrap(err, "TSA证书验证失败")
+	}
+	return nil
+}
+
+// findSigner 根据SignerIdentifier查找签名证书
+func (t *TimestampToken) findSigner(sid asn1.RawValue) (*x509.Certificate, error) {
+	switch {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		var ias tsaIssuerAndSerial
+		if _, err := asn1.Unmarshal(sid.FullBytes, &ias); err != nil {
+			return nil, errors.Wrap(err, "解析签名者标识失败")
+		}
+		for _, cert := range t.Certificates {
+			if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 && bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+				return cert, nil
+			}
+		}
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		for _, cert := range t.Certificates {
+			if bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+	}
+	return nil, errors.New("时间戳令牌中找不到签名证书，请求时需要certReq")
+}
+
+// checkTimestampAttributes 校验contentType与messageDigest签名属性
+func checkTimestampAttributes(attrs []tsaAttribute, digestAlgo HashAlgorithm, eContent []byte) error {
+	var contentTypeOK, digestOK bool
+	for _, attr := range attrs {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidAttrContentType):
+			var ct asn1.ObjectIdentifier
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &ct); err == nil {
+				contentTypeOK = ct.Equal(oidTSTInfo)
+			}
+		case attr.Type.Equal(oidAttrMessageDigest):
+			var digest []byte
+			if _, err := asn1.Unmarshal(attr.Values[0].FullBytes, &digest); err == nil {
+				h := hashFunc(digestAlgo)()
+				h.Write(eContent)
+				digestOK = bytes.Equal(h.Sum(nil), digest)
+			}
+		}
+	}
+
+	if !contentTypeOK {
+		return errors.New("签名属性contentType不正确")
+	}
+	if !digestOK {
+		return errors.New("签名属性messageDigest与TSTInfo不一致")
+	}
+	return nil
+}
+
+// verifyTimestampSignature 使用TSA公钥验证签名，支持RSA与ECDSA
+func verifyTimestampSignature(publicKey crypto.PublicKey, digestAlgo HashAlgorithm, digest, signature []byte) error {
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		hash, err := cryptoHash(digestAlgo)
+		if err != nil {
+			return err
+		}
+		if err := rsa.VerifyPKCS1v15(pub, hash, digest, signature); err != nil {
+			return errors.New("TSA签名验证失败")
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest, signature) {
+			return errors.New("TSA签名验证失败")
+		}
+	default:
+		return errors.New("不支持的TSA公钥类型")
+	}
+	return nil
+}
+
+// hashAlgorithmOID 获取摘要算法的OID
+func hashAlgorithmOID(algo HashAlgorithm) (asn1.ObjectIdentifier, error) {
+	switch algo {
+	case HashSHA1:
+		return oidHashSHA1, nil
+	case HashSHA256:
+		return oidHashSHA256, nil
+	case HashSHA512:
+		return oidHashSHA512, nil
+	case HashSM3:
+		return oidHashSM3, nil
+	default:
+		return nil, errors.New("不支持的摘要算法")
+	}
+}
+
+// hashAlgorithmFromOID 根据OID获取摘要算法
+func hashAlgorithmFromOID(oid asn1.ObjectIdentifier) (HashAlgorithm, error) {
+	switch {
+	case oid.Equal(oidHashSHA1):
+		return HashSHA1, nil
+	case oid.Equal(oidHashSHA256):
+		return HashSHA256, nil
+	case oid.Equal(oidHashSHA512):
+		return HashSHA512, nil
+	case oid.Equal(oidHashSM3):
+		return HashSM3, nil
+	default:
+		return 0, errors.Errorf("不支持的摘要算法OID: %s", oid)
+	}
+}
+
+// cryptoHash 将摘要算法映射为标准库crypto.Hash，SM3没有对应值
+func cryptoHash(algo HashAlgorithm) (crypto.Hash, error) {
+	switch algo {
+	case HashSHA1:
+		return crypto.SHA1, nil
+	case HashSHA256:
+		return crypto.SHA256, nil
+	case HashSHA512:
+		return crypto.SHA512, nil
+	default:
+		return 0, errors.New("该摘要算法不支持RSA PKCS#1 v1.5签名")
+	}
+}
+
+// RFC 3161 与 CMS（RFC 5652）的ASN.1结构
+
+type tsaMessageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+type tsaRequest struct {
+	Version        int
+	MessageImprint tsaMessageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+type tsaStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+type tsaResponse struct {
+	Status         tsaStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+type tsaAccuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+type tsaTSTInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint tsaMessageImprint
+	SerialNumber   *big.Int
+	GenTime        time.Time        `asn1:"generalized"`
+	Accuracy       tsaAccuracy      `asn1:"optional"`
+	Ordering       bool             `asn1:"optional,default:false"`
+	Nonce          *big.Int         `asn1:"optional"`
+	TSA            asn1.RawValue    `asn1:"optional,explicit,tag:0"`
+	Extensions     []pkix.Extension `asn1:"optional,tag:1"`
+}
+
+type tsaContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+type tsaEncapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+type tsaSignerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type tsaSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo tsaEncapContentInfo
+	Certificates     asn1.RawValue   `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue   `asn1:"optional,tag:1"`
+	SignerInfos      []tsaSignerInfo `asn1:"set"`
+}
+
+type tsaIssuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type tsaAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
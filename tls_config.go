@@ -0,0 +1,108 @@
+package encrypt
+
+import (
+	"crypto/tls"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/gmtls"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// NewTLSCertificate 从PEM编码的证书和私钥创建tls.Certificate
+// 支持RSA、ECDSA、Ed25519证书，私钥可以是PKCS#1、SEC1或PKCS#8格式
+func NewTLSCertificate(certPEM, keyPEM []byte) (tls.Certificate, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return tls.Certificate{}, errors.Wrap(err, "加载TLS证书失败")
+	}
+	return cert, nil
+}
+
+// NewTLSConfig 从PEM编码的证书和私钥创建服务端tls.Config
+// 默认要求TLS 1.2及以上版本
+func NewTLSConfig(certPEM, keyPEM []byte) (*tls.Config, error) {
+	cert, err := NewTLSCertificate(certPEM, keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+	}, nil
+}
+
+// NewGMTLSCertificate 从PEM编码的SM2证书和私钥创建gmtls.Certificate
+func NewGMTLSCertificate(certPEM, keyPEM []byte) (gmtls.Certificate, error) {
+	cert, err := gmtls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return gmtls.Certificate{}, errors.Wrap(err, "加载SM2证书失败")
+	}
+	return cert, nil
+}
+
+// NewGMTLSServerConfig 创建国密TLS(GMSSL)服务端配置
+// GMSSL要求双证书：签名证书用于身份认证，加密证书用于密钥交换
+func NewGMTLSServerConfig(signCertPEM, signKeyPEM, encCertPEM, encKeyPEM []byte) (*gmtls.Config, error) {
+	signCert, err := NewGMTLSCertificate(signCertPEM, signKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "加载签名证书失败")
+	}
+
+	encCert, err := NewGMTLSCertificate(encCertPEM, encKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "加载加密证书失败")
+	}
+
+	return &gmtls.Config{
+		GMSupport:    gmtls.NewGMSupport(),
+		Certificates: []gmtls.Certificate{signCert, encCert},
+	}, nil
+}
+
+// NewGMTLSAutoSwitchConfig 创建同时支持GMSSL与标准TLS的服务端配置
+// 客户端声明支持GMSSL时使用SM2双证书，否则使用标准证书
+func NewGMTLSAutoSwitchConfig(signCertPEM, signKeyPEM, encCertPEM, encKeyPEM, stdCertPEM, stdKeyPEM []byte) (*gmtls.Config, error) {
+	signCert, err := NewGMTLSCertificate(signCertPEM, signKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "加载签名证书失败")
+	}
+
+	encCert, err := NewGMTLSCertificate(encCertPEM, encKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "加载加密证书失败")
+	}
+
+	stdCert, err := gmtls.X509KeyPair(stdCertPEM, stdKeyPEM)
+	if err != nil {
+		return nil, errors.Wrap(err, "加载标准证书失败")
+	}
+
+	return gmtls.NewBasicAutoSwitchConfig(&signCert, &encCert, &stdCert)
+}
+
+// NewGMTLSClientConfig 创建国密TLS(GMSSL)客户端配置
+// caPEM为信任的根证书，可包含多个PEM块；authCertPEM/authKeyPEM用于双向认证，不需要时传nil
+func NewGMTLSClientConfig(caPEM, authCertPEM, authKeyPEM []byte) (*gmtls.Config, error) {
+	config := &gmtls.Config{
+		GMSupport: gmtls.NewGMSupport(),
+	}
+
+	if len(caPEM) > 0 {
+		certPool := x509.NewCertPool()
+		if !certPool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("解析根证书失败")
+		}
+		config.RootCAs = certPool
+	}
+
+	if len(authCertPEM) > 0 {
+		authCert, err := NewGMTLSCertificate(authCertPEM, authKeyPEM)
+		if err != nil {
+			return nil, errors.Wrap(err, "加载客户端证书失败")
+		}
+		config.Certificates = []gmtls.Certificate{authCert}
+	}
+
+	return config, nil
+}
@@ -55,12 +55,6 @@ func (t *TripleDESEncryptor) CTR() ISymmetric {
 	return t
 }
 
-// GCM 设置GCM模式
-func (t *TripleDESEncryptor) GCM() ISymmetric {
-	t.blockMode = NewGCMMode()
-	return t
-}
-
 // NoPadding 设置无填充
 func (t *TripleDESEncryptor) NoPadding() ISymmetric {
 	t.padding = DefaultNoPadding
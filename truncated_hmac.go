@@ -0,0 +1,85 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// 截断HMAC
+//
+// 短链接ID、布隆过滤器键、URL中的签名等场景常把HMAC截短使用，各团队截取的位置、长度与校验方式各不相同，
+// 出现过只比较前几个字节、接受任意长度前缀的校验。TruncatedHMAC 统一为：取HMAC输出最左侧n字节（RFC 2104 第5节），
+// n不少于TruncatedHMACMinSize；校验时要求长度完全一致并常量时间比较。
+//
+// 截断长度决定两类风险，用TruncatedHMACCollisionProbability与MinTruncatedHMACSize按业务规模选择n：
+//   - 伪造：攻击者不知道密钥时，每次猜测成功的概率为 2^(-8n)，需要配合限流
+//   - 碰撞：count个不同输入中出现相同标识的概率约为 1 - exp(-count²/2^(8n+1))（生日界），
+//     8字节截断在一亿个标识内碰撞概率约为2.7e-4，用作唯一键时应更长或在碰撞时回退到完整值比对
+
+// 截断HMAC的长度限制
+const (
+	TruncatedHMACMinSize = 8  // 最小截断长度（64位）
+	truncatedHMACMinKey  = 16 // 最小密钥长度
+)
+
+// TruncatedHMAC 计算HMAC-SHA256并截取最左侧n字节
+func TruncatedHMAC(key, data []byte, n int) ([]byte, error) {
+	return TruncatedHMACWithHash(HashSHA256, key, data, n)
+}
+
+// TruncatedHMACWithHash 使用指定哈希算法计算HMAC并截取最左侧n字节
+func TruncatedHMACWithHash(hashAlgo HashAlgorithm, key, data []byte, n int) ([]byte, error) {
+	if len(key) < truncatedHMACMinKey {
+		return nil, errors.Errorf("HMAC密钥长度至少%d字节", truncatedHMACMinKey)
+	}
+	h := hashFunc(hashAlgo)
+	if size := h().Size(); n < TruncatedHMACMinSize || n > size {
+		return nil, errors.Errorf("截断长度必须在%d到%d字节之间", TruncatedHMACMinSize, size)
+	}
+
+	mac := hmac.New(h, key)
+	mac.Write(data)
+	return mac.Sum(nil)[:n], nil
+}
+
+// VerifyTruncatedHMAC 常量时间校验HMAC-SHA256截断值，截断长度由mac的长度决定且不能短于最小长度
+func VerifyTruncatedHMAC(key, data, mac []byte) error {
+	return VerifyTruncatedHMACWithHash(HashSHA256, key, data, mac)
+}
+
+// VerifyTruncatedHMACWithHash 常量时间校验指定哈希算法的HMAC截断值
+func VerifyTruncatedHMACWithHash(hashAlgo HashAlgorithm, key, data, mac []byte) error {
+	expected, err := TruncatedHMACWithHash(hashAlgo, key, data, len(mac))
+	if err != nil {
+		return err
+	}
+	if !hmac.Equal(expected, mac) {
+		return ErrInvalidMAC
+	}
+	return nil
+}
+
+// TruncatedHMACCollisionProbability 估算count个不同输入截断为n字节后至少出现一次碰撞的概率
+func TruncatedHMACCollisionProbability(n, count int) float64 {
+	if count < 2 {
+		return 0
+	}
+	c := float64(count)
+	// -expm1(-x) 在x很小时比 1-exp(-x) 精确
+	return -math.Expm1(-c * (c - 1) / math.Pow(2, float64(8*n)+1))
+}
+
+// MinTruncatedHMACSize 计算count个输入的碰撞概率不超过maxProbability所需的最小截断长度（不低于最小长度）
+func MinTruncatedHMACSize(count int, maxProbability float64) (int, error) {
+	if maxProbability <= 0 || maxProbability >= 1 {
+		return 0, errors.New("碰撞概率必须在0到1之间")
+	}
+	for n := TruncatedHMACMinSize; n <= 64; n++ {
+		if TruncatedHMACCollisionProbability(n, count) <= maxProbability {
+			return n, nil
+		}
+	}
+	return 0, errors.New("所需截断长度超过任何哈希的输出长度")
+}
@@ -0,0 +1,150 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// 分片上传完整性
+//
+// 上传服务逐个接收分片，每个分片计算摘要并立即校验，全部分片到齐后组合出最终摘要：
+//
+//	S3 ETag：   hex(MD5(MD5(part1) || MD5(part2) || ...)) + "-" + 分片数
+//	普通摘要：  hex(H(H(part1) || H(part2) || ...)) + "-" + 分片数
+//	带密钥摘要：分片 HMAC(key, 0x00 || 分片号(4) || 数据)
+//	            最终 hex(HMAC(key, 0x01 || 分片数(4) || mac1 || mac2 || ...))
+//
+// 带密钥的变体把分片号纳入MAC，分片不能调换顺序，客户端不持有密钥时也无法伪造摘要。
+// 分片可以并发添加，最终摘要要求分片号从1开始连续
+
+// UploadDigest 分片上传摘要，并发安全
+type UploadDigest struct {
+	mu      sync.Mutex
+	newHash func() hash.Hash
+	key     []byte
+	parts   map[int][]byte
+}
+
+// NewUploadDigest 创建普通分片摘要
+func NewUploadDigest(hashAlgo HashAlgorithm) *UploadDigest {
+	return &UploadDigest{newHash: hashFunc(hashAlgo), parts: make(map[int][]byte)}
+}
+
+// NewS3UploadDigest 创建与S3分片上传ETag兼容的摘要（MD5）
+func NewS3UploadDigest() *UploadDigest {
+	return &UploadDigest{newHash: md5.New, parts: make(map[int][]byte)}
+}
+
+// NewKeyedUploadDigest 创建带密钥的分片摘要
+func NewKeyedUploadDigest(hashAlgo HashAlgorithm, key []byte) (*UploadDigest, error) {
+	if len(key) < 16 {
+		return nil, errors.New("HMAC密钥长度至少16字节")
+	}
+	return &UploadDigest{newHash: hashFunc(hashAlgo), key: append([]byte(nil), key...), parts: make(map[int][]byte)}, nil
+}
+
+// AddPart 计算并记录分片摘要，分片号从1开始，重复添加同一分片时覆盖（对应客户端重传）
+func (d *UploadDigest) AddPart(partNumber int, data []byte) ([]byte, error) {
+	return d.AddPartReader(partNumber, bytes.NewReader(data))
+}
+
+// AddPartReader 以流的方式计算并记录分片摘要
+func (d *UploadDigest) AddPartReader(partNumber int, r io.Reader) ([]byte, error) {
+	digest, err := d.partDigest(partNumber, r)
+	if err != nil {
+		return nil, err
+	}
+	d.mu.Lock()
+	d.parts[partNumber] = digest
+	d.mu.Unlock()
+	return append([]byte(nil), digest...), nil
+}
+
+// VerifyPart 校验分片数据与客户端声明的摘要一致，一致时才记录
+func (d *UploadDigest) VerifyPart(partNumber int, data, expected []byte) error {
+	digest, err := d.partDigest(partNumber, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(digest, expected) != 1 {
+		return errors.Errorf("分片%d摘要校验失败", partNumber)
+	}
+	d.mu.Lock()
+	d.parts[partNumber] = digest
+	d.mu.Unlock()
+	return nil
+}
+
+// PartCount 已记录的分片数
+func (d *UploadDigest) PartCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.parts)
+}
+
+// Final 组合最终摘要，分片号必须从1开始连续
+func (d *UploadDigest) Final() (string, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	numbers := make([]int, 0, len(d.parts))
+	for n := range d.parts {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	if len(numbers) == 0 {
+		return "", errors.New("没有任何分片")
+	}
+	for i, n := range numbers {
+		if n != i+1 {
+			return "", errors.Errorf("缺少分片%d", i+1)
+		}
+	}
+
+	var h hash.Hash
+	if d.key != nil {
+		h = hmac.New(d.newHash, d.key)
+		h.Write([]byte{0x01})
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(numbers))))
+	} else {
+		h = d.newHash()
+	}
+	for _, n := range numbers {
+		h.Write(d.parts[n])
+	}
+	final := hex.EncodeToString(h.Sum(nil))
+	if d.key != nil {
+		return final, nil
+	}
+	return final + "-" + strconv.Itoa(len(numbers)), nil
+}
+
+// partDigest 计算单个分片的摘要
+func (d *UploadDigest) partDigest(partNumber int, r io.Reader) ([]byte, error) {
+	if partNumber < 1 || partNumber > 10000 {
+		return nil, errors.New("分片号必须在1到10000之间")
+	}
+	var h hash.Hash
+	if d.key != nil {
+		h = hmac.New(d.newHash, d.key)
+		h.Write([]byte{0x00})
+		h.Write(binary.BigEndian.AppendUint32(nil, uint32(partNumber)))
+	} else {
+		h = d.newHash()
+	}
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, errors.Wrapf(err, "读取分片%d失败", partNumber)
+	}
+	return h.Sum(nil), nil
+}
@@ -0,0 +1,244 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"math/big"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+// 密钥校验工具
+// 公私钥不匹配时，加解密只会返回难以定位的失败信息，因此在加载密钥时提前校验
+
+// rsaMinKeyBits 可接受的最小RSA模数长度
+const rsaMinKeyBits = 1024
+
+// rsaMinExponent 可接受的最小RSA公钥指数
+const rsaMinExponent = 65537
+
+// smallPrimeLimit 小素因子检测的上限
+const smallPrimeLimit = 1 << 12
+
+// ValidateKeyPair 校验PEM编码的公私钥是否匹配且健康
+// 支持RSA、ECDSA、Ed25519以及SM2密钥
+func ValidateKeyPair(publicKeyPEM, privateKeyPEM []byte) error {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	privateKey, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	switch priv := privateKey.(type) {
+	case *rsa.PrivateKey:
+		pub, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return errors.New("公钥与私钥算法不一致")
+		}
+		return validateRSAKeyPair(pub, priv)
+	case *sm2.PrivateKey:
+		pub, ok := publicKey.(*sm2.PublicKey)
+		if !ok {
+			return errors.New("公钥与私钥算法不一致")
+		}
+		return validateECKeyPair(pub.Curve, pub.X, pub.Y, priv.X, priv.Y, priv.D)
+	case *ecdsa.PrivateKey:
+		pub, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return errors.New("公钥与私钥算法不一致")
+		}
+		if pub.Curve != priv.Curve {
+			return errors.New("公钥与私钥曲线不一致")
+		}
+		return validateECDSAKeyPair(pub, priv)
+	case ed25519.PrivateKey:
+		pub, ok := publicKey.(ed25519.PublicKey)
+		if !ok {
+			return errors.New("公钥与私钥算法不一致")
+		}
+		if !bytes.Equal(pub, priv.Public().(ed25519.PublicKey)) {
+			return errors.New("公钥与私钥不匹配")
+		}
+		return nil
+	default:
+		return errors.New("不支持的私钥类型")
+	}
+}
+
+// ValidatePublicKey 检查PEM编码的RSA公钥健康度（模数长度、公钥指数、小素因子）
+// 非RSA公钥仅校验点是否在曲线上
+func ValidatePublicKey(publicKeyPEM []byte) error {
+	publicKey, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		return validateRSAPublicKey(pub)
+	case *sm2.PublicKey:
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return errors.New("公钥点不在曲线上")
+		}
+	case *ecdsa.PublicKey:
+		if !ecdhSupported(pub.Curve) {
+			if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+				return errors.New("公钥点不在曲线上")
+			}
+			return nil
+		}
+		if _, err := pub.ECDH(); err != nil {
+			return errors.New("公钥点不在曲线上")
+		}
+	}
+	return nil
+}
+
+// validateRSAKeyPair 校验RSA公私钥匹配及私钥一致性
+func validateRSAKeyPair(pub *rsa.PublicKey, priv *rsa.PrivateKey) error {
+	if pub.N.Cmp(priv.N) != 0 || pub.E != priv.E {
+		return errors.New("RSA公钥与私钥不匹配：模数或指数不同")
+	}
+
+	if err := validateRSAPublicKey(pub); err != nil {
+		return err
+	}
+
+	if err := priv.Validate(); err != nil {
+		return errors.Wrap(err, "RSA私钥校验失败")
+	}
+	return nil
+}
+
+// validateRSAPublicKey 检查RSA公钥健康度
+func validateRSAPublicKey(pub *rsa.PublicKey) error {
+	if pub.N.BitLen() < rsaMinKeyBits {
+		return errors.Errorf("RSA模数长度过短: %d位，至少需要%d位", pub.N.BitLen(), rsaMinKeyBits)
+	}
+
+	if pub.E < rsaMinExponent || pub.E%2 == 0 {
+		return errors.Errorf("RSA公钥指数过弱: %d", pub.E)
+	}
+
+	if p := smallFactor(pub.N); p != 0 {
+		return errors.Errorf("RSA模数存在小素因子: %d", p)
+	}
+	return nil
+}
+
+// smallFactor 返回n的最小小素因子，没有则返回0
+func smallFactor(n *big.Int) int64 {
+	m := new(big.Int)
+	for p := int64(2); p < smallPrimeLimit; p++ {
+		if !big.NewInt(p).ProbablyPrime(0) {
+			continue
+		}
+		if m.Mod(n, big.NewInt(p)).Sign() == 0 {
+			return p
+		}
+	}
+	return 0
+}
+
+// validateECDSAKeyPair 校验NIST曲线公私钥匹配
+// P-256/P-384/P-521通过crypto/ecdh完成点与标量的合法性检查及公钥推导（常量时间实现），其他曲线沿用big.Int校验
+func validateECDSAKeyPair(pub *ecdsa.PublicKey, priv *ecdsa.PrivateKey) error {
+	if !ecdhSupported(pub.Curve) {
+		return validateECKeyPair(pub.Curve, pub.X, pub.Y, priv.X, priv.Y, priv.D)
+	}
+
+	pubKey, err := pub.ECDH()
+	if err != nil {
+		return errors.New("公钥点不在曲线上")
+	}
+	privKey, err := priv.ECDH()
+	if err != nil {
+		return errors.New("私钥标量超出有效范围")
+	}
+	if !pub.Equal(&priv.PublicKey) {
+		return errors.New("公钥与私钥不匹配")
+	}
+	if !privKey.PublicKey().Equal(pubKey) {
+		return errors.New("私钥与其携带的公钥不一致")
+	}
+	return nil
+}
+
+// ecdhSupported crypto/ecdh是否支持该曲线
+func ecdhSupported(curve elliptic.Curve) bool {
+	return curve == elliptic.P256() || curve == elliptic.P384() || curve == elliptic.P521()
+}
+
+// validateECKeyPair 校验椭圆曲线公私钥匹配，用于SM2等crypto/ecdh不支持的曲线
+// 检查公钥点在曲线上、私钥标量在[1, N-1]范围内且D·G等于公钥点
+func validateECKeyPair(curve elliptic.Curve, pubX, pubY, privX, privY, d *big.Int) error {
+	if d == nil || d.Sign() <= 0 || d.Cmp(curve.Params().N) >= 0 {
+		return errors.New("私钥标量超出有效范围")
+	}
+
+	if !curve.IsOnCurve(pubX, pubY) {
+		return errors.New("公钥点不在曲线上")
+	}
+
+	if pubX.Cmp(privX) != 0 || pubY.Cmp(privY) != 0 {
+		return errors.New("公钥与私钥不匹配")
+	}
+
+	x, y := curve.ScalarBaseMult(d.Bytes())
+	if x.Cmp(pubX) != 0 || y.Cmp(pubY) != 0 {
+		return errors.New("私钥与其携带的公钥不一致")
+	}
+	return nil
+}
+
+// ValidateKey 校验对称密钥
+// 检查密钥长度是否符合算法要求，并拒绝全零等明显不安全的密钥
+func ValidateKey(algorithm Algorithm, key []byte) error {
+	switch algorithm {
+	case AlgorithmAES:
+		if len(key) != 16 && len(key) != 24 && len(key) != 32 {
+			return errors.New("AES密钥长度必须是16、24或32字节")
+		}
+	case AlgorithmDES:
+		if len(key) != 8 {
+			return errors.New("DES密钥长度必须是8字节")
+		}
+	case Algorithm3DES:
+		if len(key) != 16 && len(key) != 24 {
+			return errors.New("3DES密钥长度必须是16或24字节")
+		}
+		// K1==K2或K2==K3时3DES退化为单DES
+		if bytes.Equal(key[:8], key[8:16]) || (len(key) == 24 && bytes.Equal(key[8:16], key[16:])) {
+			return errors.New("3DES密钥的子密钥重复，强度退化为单DES")
+		}
+	case AlgorithmSM4:
+		if len(key) != 16 {
+			return errors.New("SM4密钥长度必须是16字节")
+		}
+	default:
+		return errors.New("不支持的对称加密算法")
+	}
+
+	if isAllSameByte(key) {
+		return errors.New("密钥所有字节相同，不安全")
+	}
+	return nil
+}
+
+// isAllSameByte 判断数据是否由同一个字节重复组成（包括全零）
+func isAllSameByte(data []byte) bool {
+	for _, b := range data {
+		if b != data[0] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,141 @@
+package encrypt
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HashiCorp Vault Transit 引擎
+//
+// 主密钥保存在Vault中且不可导出，数据密钥通过transit的encrypt/decrypt接口包装与解包，
+// 包装结果为Vault格式的密文（vault:v1:...），Vault轮换主密钥后旧版本密文仍可解包
+
+// vaultDefaultTimeout 默认请求超时
+const vaultDefaultTimeout = 10 * time.Second
+
+// VaultTransit Vault Transit 密钥提供者
+type VaultTransit struct {
+	addr       string
+	token      string
+	mount      string
+	namespace  string
+	httpClient *http.Client
+}
+
+// NewVaultTransit 创建Vault Transit提供者，addr如 https://vault.example.com:8200
+func NewVaultTransit(addr, token string) *VaultTransit {
+	return &VaultTransit{
+		addr:       strings.TrimRight(addr, "/"),
+		token:      token,
+		mount:      "transit",
+		httpClient: &http.Client{Timeout: vaultDefaultTimeout},
+	}
+}
+
+// WithMount 设置transit引擎挂载路径，默认为transit
+func (v *VaultTransit) WithMount(mount string) *VaultTransit {
+	v.mount = strings.Trim(mount, "/")
+	return v
+}
+
+// WithNamespace 设置Vault企业版命名空间
+func (v *VaultTransit) WithNamespace(namespace string) *VaultTransit {
+	v.namespace = namespace
+	return v
+}
+
+// WithHTTPClient 设置HTTP客户端，用于配置TLS或超时
+func (v *VaultTransit) WithHTTPClient(client *http.Client) *VaultTransit {
+	v.httpClient = client
+	return v
+}
+
+// WrapKey 调用transit/encrypt包装数据密钥
+func (v *VaultTransit) WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"plaintext": base64.StdEncoding.EncodeToString(dataKey)}
+	if err := v.call(ctx, "encrypt", keyID, body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Data.Ciphertext == "" {
+		return nil, errors.New("Vault响应缺少ciphertext")
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+// UnwrapKey 调用transit/decrypt解包数据密钥
+func (v *VaultTransit) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	body := map[string]string{"ciphertext": string(wrapped)}
+	if err := v.call(ctx, "decrypt", keyID, body, &resp); err != nil {
+		return nil, err
+	}
+
+	dataKey, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil || len(dataKey) == 0 {
+		return nil, errors.New("Vault响应的plaintext格式不正确")
+	}
+	return dataKey, nil
+}
+
+// call 调用transit接口
+func (v *VaultTransit) call(ctx context.Context, op, keyID string, body interface{}, out interface{}) error {
+	if keyID == "" || strings.Contains(keyID, "/") {
+		return errors.Errorf("Vault密钥名称不合法: %q", keyID)
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return errors.Wrap(err, "编码Vault请求失败")
+	}
+
+	url := v.addr + "/v1/" + v.mount + "/" + op + "/" + keyID
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return errors.Wrap(err, "创建Vault请求失败")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "请求Vault失败")
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return errors.Wrap(err, "读取Vault响应失败")
+	}
+	if resp.StatusCode != http.StatusOK {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		_ = json.Unmarshal(data, &vaultErr)
+		return errors.Errorf("Vault返回HTTP状态%d %v", resp.StatusCode, vaultErr.Errors)
+	}
+
+	if err := json.Unmarshal(data, out); err != nil {
+		return errors.Wrap(err, "解析Vault响应失败")
+	}
+	return nil
+}
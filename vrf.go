@@ -0,0 +1,223 @@
+package encrypt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha512"
+
+	"filippo.io/edwards25519"
+	"github.com/pkg/errors"
+)
+
+// 可验证随机函数（RFC 9381 ECVRF-EDWARDS25519-SHA512-TAI）
+//
+// 抽奖与选主服务需要"只有私钥持有者能算出、任何人都能验证"的随机数：
+// 私钥持有者对输入alpha计算证明pi，由pi得到64字节输出beta；持有公钥的任何人可以验证pi并得到同一个beta。
+// 对同一密钥与alpha，beta是唯一确定的，持有者无法挑选对自己有利的结果；不知道私钥时beta与随机数不可区分。
+//
+// 私钥与公钥直接使用Ed25519密钥（与JWS、许可证签名等使用同一种PKCS#8/PKIX格式），
+// 但同一把密钥不应同时用于签名与VRF。alpha通常是公开的轮次号或区块高度，需要调用方保证各方使用相同的alpha
+
+// VRF长度常量
+const (
+	VRFProofSize  = 80 // Gamma(32) || c(16) || s(32)
+	VRFOutputSize = 64
+)
+
+// vrfSuite ECVRF-EDWARDS25519-SHA512-TAI的suite_string
+const vrfSuite = 0x03
+
+// ErrVRFInvalidProof VRF证明无效，可通过errors.Is判断
+var ErrVRFInvalidProof = errors.New("VRF证明无效")
+
+// VRFProve 计算alpha的证明与输出
+func VRFProve(privateKey ed25519.PrivateKey, alpha []byte) (proof, beta []byte, err error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, nil, errors.New("Ed25519私钥长度不正确")
+	}
+
+	// 与Ed25519相同的私钥展开：x为前半部分钳位后的标量，后半部分用于生成nonce
+	expanded := sha512.Sum512(privateKey.Seed())
+	defer zeroBytes(expanded[:])
+	x, err := edwards25519.NewScalar().SetBytesWithClamping(expanded[:32])
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "展开私钥失败")
+	}
+	publicKey := new(edwards25519.Point).ScalarBaseMult(x).Bytes()
+
+	h, err := vrfEncodeToCurve(publicKey, alpha)
+	if err != nil {
+		return nil, nil, err
+	}
+	hString := h.Bytes()
+	gamma := new(edwards25519.Point).ScalarMult(x, h)
+
+	nonce := sha512.New()
+	nonce.Write(expanded[32:])
+	nonce.Write(hString)
+	k, err := edwards25519.NewScalar().SetUniformBytes(nonce.Sum(nil))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "生成nonce失败")
+	}
+
+	kB := new(edwards25519.Point).ScalarBaseMult(k)
+	kH := new(edwards25519.Point).ScalarMult(k, h)
+	cBytes := vrfChallenge(publicKey, hString, gamma.Bytes(), kB.Bytes(), kH.Bytes())
+	c, err := vrfScalarFromChallenge(cBytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	s := edwards25519.NewScalar().MultiplyAdd(c, x, k)
+
+	proof = make([]byte, 0, VRFProofSize)
+	proof = append(proof, gamma.Bytes()...)
+	proof = append(proof, cBytes...)
+	proof = append(proof, s.Bytes()...)
+	return proof, vrfProofHash(gamma), nil
+}
+
+// VRFVerify 验证证明，成功时返回输出beta，证明无效时返回ErrVRFInvalidProof
+func VRFVerify(publicKey ed25519.PublicKey, alpha, proof []byte) ([]byte, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("Ed25519公钥长度不正确")
+	}
+	y, err := vrfDecodePoint(publicKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "公钥格式不正确")
+	}
+	// 拒绝小阶公钥，否则任意输出都能通过验证
+	if new(edwards25519.Point).MultByCofactor(y).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return nil, errors.New("公钥是小阶点")
+	}
+
+	gamma, c, s, err := vrfDecodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	h, err := vrfEncodeToCurve(publicKey, alpha)
+	if err != nil {
+		return nil, err
+	}
+
+	// U = s·B - c·Y，V = s·H - c·Gamma
+	negC := edwards25519.NewScalar().Negate(c)
+	u := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(negC, y, s)
+	v := new(edwards25519.Point).VarTimeMultiScalarMult([]*edwards25519.Scalar{s, negC}, []*edwards25519.Point{h, gamma})
+	expected := vrfChallenge(publicKey, h.Bytes(), gamma.Bytes(), u.Bytes(), v.Bytes())
+	if !bytes.Equal(expected, proof[32:48]) {
+		return nil, ErrVRFInvalidProof
+	}
+	return vrfProofHash(gamma), nil
+}
+
+// VRFProofToHash 从证明计算输出，不验证证明，只能用于已验证过的证明
+func VRFProofToHash(proof []byte) ([]byte, error) {
+	gamma, _, _, err := vrfDecodeProof(proof)
+	if err != nil {
+		return nil, err
+	}
+	return vrfProofHash(gamma), nil
+}
+
+// VRFProvePEM 使用PEM编码的Ed25519私钥计算证明与输出
+func VRFProvePEM(privateKeyPEM, alpha []byte) (proof, beta []byte, err error) {
+	key, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return nil, nil, err
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, nil, errors.New("VRF仅支持Ed25519私钥")
+	}
+	return VRFProve(privateKey, alpha)
+}
+
+// VRFVerifyPEM 使用PEM编码的Ed25519公钥验证证明
+func VRFVerifyPEM(publicKeyPEM, alpha, proof []byte) ([]byte, error) {
+	key, err := parsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, errors.New("VRF仅支持Ed25519公钥")
+	}
+	return VRFVerify(publicKey, alpha, proof)
+}
+
+// vrfEncodeToCurve try-and-increment：逐个计数器哈希，直到结果可以解码为曲线点，再乘以余因子
+func vrfEncodeToCurve(publicKey, alpha []byte) (*edwards25519.Point, error) {
+	for ctr := 0; ctr < 256; ctr++ {
+		h := sha512.New()
+		h.Write([]byte{vrfSuite, 0x01})
+		h.Write(publicKey)
+		h.Write(alpha)
+		h.Write([]byte{byte(ctr), 0x00})
+		point, err := vrfDecodePoint(h.Sum(nil)[:32])
+		if err != nil {
+			continue
+		}
+		return point.MultByCofactor(point), nil
+	}
+	return nil, errors.New("无法将输入映射到曲线")
+}
+
+// vrfChallenge c = SHA-512(suite || 0x02 || 各点 || 0x00)的前16字节
+func vrfChallenge(points ...[]byte) []byte {
+	h := sha512.New()
+	h.Write([]byte{vrfSuite, 0x02})
+	for _, point := range points {
+		h.Write(point)
+	}
+	h.Write([]byte{0x00})
+	return h.Sum(nil)[:16]
+}
+
+// vrfProofHash beta = SHA-512(suite || 0x03 || 8·Gamma || 0x00)
+func vrfProofHash(gamma *edwards25519.Point) []byte {
+	h := sha512.New()
+	h.Write([]byte{vrfSuite, 0x03})
+	h.Write(new(edwards25519.Point).MultByCofactor(gamma).Bytes())
+	h.Write([]byte{0x00})
+	return h.Sum(nil)
+}
+
+// vrfDecodeProof 解析 Gamma || c || s，s必须小于群阶
+func vrfDecodeProof(proof []byte) (gamma *edwards25519.Point, c, s *edwards25519.Scalar, err error) {
+	if len(proof) != VRFProofSize {
+		return nil, nil, nil, errors.Wrapf(ErrVRFInvalidProof, "证明长度必须是%d字节", VRFProofSize)
+	}
+	if gamma, err = vrfDecodePoint(proof[:32]); err != nil {
+		return nil, nil, nil, errors.Wrap(ErrVRFInvalidProof, err.Error())
+	}
+	if c, err = vrfScalarFromChallenge(proof[32:48]); err != nil {
+		return nil, nil, nil, errors.Wrap(ErrVRFInvalidProof, err.Error())
+	}
+	if s, err = edwards25519.NewScalar().SetCanonicalBytes(proof[48:]); err != nil {
+		return nil, nil, nil, errors.Wrap(ErrVRFInvalidProof, "s超出群阶范围")
+	}
+	return gamma, c, s, nil
+}
+
+// vrfDecodePoint 按RFC 8032解码点，拒绝非规范编码
+func vrfDecodePoint(data []byte) (*edwards25519.Point, error) {
+	point, err := new(edwards25519.Point).SetBytes(data)
+	if err != nil {
+		return nil, errors.New("无效的曲线点")
+	}
+	if !bytes.Equal(point.Bytes(), data) {
+		return nil, errors.New("曲线点编码不规范")
+	}
+	return point, nil
+}
+
+// vrfScalarFromChallenge 16字节小端整数转换为标量
+func vrfScalarFromChallenge(c []byte) (*edwards25519.Scalar, error) {
+	var buf [32]byte
+	copy(buf[:], c)
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(buf[:])
+	if err != nil {
+		return nil, errors.Wrap(err, "解析挑战值失败")
+	}
+	return s, nil
+}
@@ -0,0 +1,117 @@
+package encrypt
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Webhook签名
+//
+// 与Stripe等平台一致的签名头格式：
+//
+//	t=<Unix秒>,v1=<hex(HMAC-SHA256(secret, t + "." + body))>
+//
+// 签名头可以包含多个v1（如密钥轮换期间用新旧密钥各签一次），任意一个匹配即通过；
+// 未知的字段（如v0）会被忽略。时间戳参与签名，验证时检查容忍范围以防止重放
+
+// ErrWebhookTimestamp Webhook时间戳超出容忍范围
+var ErrWebhookTimestamp = errors.New("Webhook时间戳超出容忍范围")
+
+// DefaultWebhookTolerance 默认时间戳容忍范围
+const DefaultWebhookTolerance = 5 * time.Minute
+
+// webhookScheme 签名字段名
+const webhookScheme = "v1"
+
+// SignWebhook 生成Webhook签名头
+func SignWebhook(secret []byte, timestamp time.Time, body []byte) string {
+	t := timestamp.Unix()
+	return "t=" + strconv.FormatInt(t, 10) + "," + webhookScheme + "=" + hex.EncodeToString(webhookMAC(secret, t, body))
+}
+
+// VerifyWebhook 验证Webhook签名头，tolerance为时间戳容忍范围，小于等于0时不检查时间戳
+func VerifyWebhook(secret []byte, header string, body []byte, tolerance time.Duration) error {
+	return VerifyWebhookAt(secret, header, body, tolerance, time.Now())
+}
+
+// VerifyWebhookAt 以指定时间验证Webhook签名头，主要用于测试与重放历史事件
+func VerifyWebhookAt(secret []byte, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	timestamp, signatures, err := ParseWebhookHeader(header)
+	if err != nil {
+		return err
+	}
+
+	expected := webhookMAC(secret, timestamp.Unix(), body)
+	matched := false
+	for _, signature := range signatures {
+		// 逐个比较且不提前退出，比较耗时与匹配位置无关
+		if hmac.Equal(expected, signature) {
+			matched = true
+		}
+	}
+	if !matched {
+		return ErrInvalidMAC
+	}
+
+	if tolerance > 0 {
+		diff := now.Sub(timestamp)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff > tolerance {
+			return ErrWebhookTimestamp
+		}
+	}
+	return nil
+}
+
+// ParseWebhookHeader 解析签名头中的时间戳与全部v1签名
+func ParseWebhookHeader(header string) (time.Time, [][]byte, error) {
+	var (
+		timestamp  int64
+		found      bool
+		signatures [][]byte
+	)
+	for _, item := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok {
+			return time.Time{}, nil, errors.New("Webhook签名头格式不正确")
+		}
+		switch key {
+		case "t":
+			t, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return time.Time{}, nil, errors.Wrap(err, "Webhook时间戳格式不正确")
+			}
+			timestamp, found = t, true
+		case webhookScheme:
+			signature, err := hex.DecodeString(value)
+			if err != nil {
+				return time.Time{}, nil, errors.Wrap(err, "Webhook签名格式不正确")
+			}
+			signatures = append(signatures, signature)
+		}
+	}
+	if !found {
+		return time.Time{}, nil, errors.New("Webhook签名头缺少时间戳")
+	}
+	if len(signatures) == 0 {
+		return time.Time{}, nil, errors.New("Webhook签名头缺少v1签名")
+	}
+	return time.Unix(timestamp, 0), signatures, nil
+}
+
+// webhookMAC 计算 HMAC-SHA256(secret, t + "." + body)
+func webhookMAC(secret []byte, timestamp int64, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,343 @@
+package encrypt
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// XML解析与排他规范化（Exclusive XML Canonicalization 1.0，不含注释）
+//
+// 只实现XML签名需要的部分：
+//   - 不解析DTD，文档中出现DOCTYPE时直接拒绝，避免实体扩展攻击
+//   - 规范化只针对元素子树，根元素之外的处理指令与注释不参与
+//   - 命名空间只输出子树中实际使用（visibly utilized）且尚未由输出的祖先声明的前缀
+//   - 空元素输出为开始标签加结束标签，属性按命名空间URI与本地名排序
+
+// xmlNamespaceXML xml前缀固定绑定的命名空间
+const xmlNamespaceXML = "http://www.w3.org/XML/1998/namespace"
+
+// XML节点类型
+const (
+	xmlElementNode = iota
+	xmlTextNode
+	xmlCommentNode
+	xmlPINode
+)
+
+// xmlNode XML节点，名称与属性保留原始前缀（Name.Space为前缀）
+type xmlNode struct {
+	kind     int
+	name     xml.Name
+	attrs    []xml.Attr
+	text     string
+	parent   *xmlNode
+	children []*xmlNode
+
+	start      int64 // 元素开始标签的起始偏移
+	closeStart int64 // 元素结束标签的起始偏移，空元素时等于end
+	end        int64 // 元素结束标签之后的偏移
+}
+
+// parseXMLTree 解析XML文档，返回根元素
+func parseXMLTree(doc []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(doc))
+	decoder.Strict = true
+
+	var root, current *xmlNode
+	for {
+		offset := decoder.InputOffset()
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "解析XML失败")
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{kind: xmlElementNode, name: t.Name, attrs: t.Copy().Attr, parent: current, start: offset}
+			if current == nil {
+				if root != nil {
+					return nil, errors.New("XML文档只能有一个根元素")
+				}
+				root = node
+			} else {
+				current.children = append(current.children, node)
+			}
+			current = node
+		case xml.EndElement:
+			if current == nil || current.name != t.Name {
+				return nil, errors.Errorf("XML结束标签不匹配: %s", t.Name.Local)
+			}
+			current.closeStart = offset
+			current.end = decoder.InputOffset()
+			current = current.parent
+		case xml.CharData:
+			if current != nil {
+				current.children = append(current.children, &xmlNode{kind: xmlTextNode, text: string(t), parent: current})
+			}
+		case xml.Comment:
+			if current != nil {
+				current.children = append(current.children, &xmlNode{kind: xmlCommentNode, text: string(t), parent: current})
+			}
+		case xml.ProcInst:
+			if current != nil {
+				current.children = append(current.children, &xmlNode{kind: xmlPINode, name: xml.Name{Local: t.Target}, text: string(t.Inst), parent: current})
+			}
+		case xml.Directive:
+			return nil, errors.New("不支持包含DTD的XML文档")
+		}
+	}
+	if root == nil {
+		return nil, errors.New("XML文档没有根元素")
+	}
+	if current != nil {
+		return nil, errors.New("XML文档不完整")
+	}
+	return root, nil
+}
+
+// namespaces 节点处可见的命名空间绑定（前缀 -> URI，默认命名空间前缀为空）
+func (n *xmlNode) namespaces() map[string]string {
+	var chain []*xmlNode
+	for p := n; p != nil; p = p.parent {
+		chain = append(chain, p)
+	}
+	scope := map[string]string{"xml": xmlNamespaceXML}
+	for i := len(chain) - 1; i >= 0; i-- {
+		chain[i].declare(scope)
+	}
+	return scope
+}
+
+// declare 将节点上的命名空间声明写入scope
+func (n *xmlNode) declare(scope map[string]string) {
+	for _, attr := range n.attrs {
+		switch {
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			scope[""] = attr.Value
+		case attr.Name.Space == "xmlns":
+			scope[attr.Name.Local] = attr.Value
+		}
+	}
+}
+
+// namespaceURI 元素的命名空间URI
+func (n *xmlNode) namespaceURI() string {
+	return n.namespaces()[n.name.Space]
+}
+
+// is 判断元素的命名空间与本地名
+func (n *xmlNode) is(namespace, local string) bool {
+	return n.kind == xmlElementNode && n.name.Local == local && n.namespaceURI() == namespace
+}
+
+// child 查找第一个指定名称的子元素
+func (n *xmlNode) child(namespace, local string) *xmlNode {
+	for _, c := range n.children {
+		if c.is(namespace, local) {
+			return c
+		}
+	}
+	return nil
+}
+
+// attr 获取无前缀属性的值
+func (n *xmlNode) attr(local string) (string, bool) {
+	for _, a := range n.attrs {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// textContent 元素内的文本
+func (n *xmlNode) textContent() string {
+	var b strings.Builder
+	for _, c := range n.children {
+		switch c.kind {
+		case xmlTextNode:
+			b.WriteString(c.text)
+		case xmlElementNode:
+			b.WriteString(c.textContent())
+		}
+	}
+	return b.String()
+}
+
+// find 深度优先查找所有满足条件的元素
+func (n *xmlNode) find(match func(*xmlNode) bool) []*xmlNode {
+	var found []*xmlNode
+	if n.kind == xmlElementNode && match(n) {
+		found = append(found, n)
+	}
+	for _, c := range n.children {
+		if c.kind == xmlElementNode {
+			found = append(found, c.find(match)...)
+		}
+	}
+	return found
+}
+
+// canonicalizeXML 对元素子树做排他规范化，exclude中的元素（如封装签名）不输出
+func canonicalizeXML(n *xmlNode, exclude *xmlNode) []byte {
+	var buf bytes.Buffer
+	scope := map[string]string{"xml": xmlNamespaceXML}
+	if n.parent != nil {
+		scope = n.parent.namespaces()
+	}
+	writeCanonicalElement(&buf, n, exclude, scope, map[string]string{})
+	return buf.Bytes()
+}
+
+// writeCanonicalElement 输出规范化的元素
+func writeCanonicalElement(buf *bytes.Buffer, n *xmlNode, exclude *xmlNode, inherited, rendered map[string]string) {
+	scope := make(map[string]string, len(inherited)+2)
+	for k, v := range inherited {
+		scope[k] = v
+	}
+	n.declare(scope)
+
+	// 实际使用的前缀
+	used := map[string]bool{n.name.Space: true}
+	type canonicalAttr struct {
+		namespace string
+		attr      xml.Attr
+	}
+	var attrs []canonicalAttr
+	for _, a := range n.attrs {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") {
+			continue
+		}
+		namespace := ""
+		if a.Name.Space != "" {
+			used[a.Name.Space] = true
+			namespace = scope[a.Name.Space]
+		}
+		attrs = append(attrs, canonicalAttr{namespace: namespace, attr: a})
+	}
+	sort.SliceStable(attrs, func(i, j int) bool {
+		if attrs[i].namespace != attrs[j].namespace {
+			return attrs[i].namespace < attrs[j].namespace
+		}
+		return attrs[i].attr.Name.Local < attrs[j].attr.Name.Local
+	})
+
+	prefixes := make([]string, 0, len(used))
+	for prefix := range used {
+		if prefix != "xml" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	next := make(map[string]string, len(rendered)+len(prefixes))
+	for k, v := range rendered {
+		next[k] = v
+	}
+
+	buf.WriteByte('<')
+	writeQName(buf, n.name)
+	for _, prefix := range prefixes {
+		uri := scope[prefix]
+		if previous, ok := rendered[prefix]; ok && previous == uri {
+			continue
+		}
+		if prefix == "" && uri == "" && rendered[""] == "" {
+			continue
+		}
+		if prefix == "" {
+			buf.WriteString(` xmlns="`)
+		} else {
+			buf.WriteString(` xmlns:` + prefix + `="`)
+		}
+		writeCanonicalAttrValue(buf, uri)
+		buf.WriteByte('"')
+		next[prefix] = uri
+	}
+	for _, a := range attrs {
+		buf.WriteByte(' ')
+		writeQName(buf, a.attr.Name)
+		buf.WriteString(`="`)
+		writeCanonicalAttrValue(buf, a.attr.Value)
+		buf.WriteByte('"')
+	}
+	buf.WriteByte('>')
+
+	for _, c := range n.children {
+		switch c.kind {
+		case xmlElementNode:
+			if c != exclude {
+				writeCanonicalElement(buf, c, exclude, scope, next)
+			}
+		case xmlTextNode:
+			writeCanonicalText(buf, c.text)
+		case xmlPINode:
+			buf.WriteString("<?" + c.name.Local)
+			if c.text != "" {
+				buf.WriteString(" " + c.text)
+			}
+			buf.WriteString("?>")
+		}
+	}
+
+	buf.WriteString("</")
+	writeQName(buf, n.name)
+	buf.WriteByte('>')
+}
+
+// writeQName 输出带前缀的名称
+func writeQName(buf *bytes.Buffer, name xml.Name) {
+	if name.Space != "" {
+		buf.WriteString(name.Space)
+		buf.WriteByte(':')
+	}
+	buf.WriteString(name.Local)
+}
+
+// writeCanonicalText 按C14N规则转义文本
+func writeCanonicalText(buf *bytes.Buffer, text string) {
+	for _, r := range text {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '>':
+			buf.WriteString("&gt;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
+
+// writeCanonicalAttrValue 按C14N规则转义属性值
+func writeCanonicalAttrValue(buf *bytes.Buffer, value string) {
+	for _, r := range value {
+		switch r {
+		case '&':
+			buf.WriteString("&amp;")
+		case '<':
+			buf.WriteString("&lt;")
+		case '"':
+			buf.WriteString("&quot;")
+		case '\t':
+			buf.WriteString("&#x9;")
+		case '\n':
+			buf.WriteString("&#xA;")
+		case '\r':
+			buf.WriteString("&#xD;")
+		default:
+			buf.WriteRune(r)
+		}
+	}
+}
@@ -0,0 +1,447 @@
+package encrypt
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/pem"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tjfoc/gmsm/sm2"
+	"github.com/tjfoc/gmsm/x509"
+)
+
+// XML签名与XML加密（XML-DSig / XML-Enc 最小实现）
+//
+// 面向仍要求签名XML报文的政务接口，只支持最常用的形态：
+//   - 封装签名（enveloped）：签名作为根元素的最后一个子元素，Reference URI=""，
+//     变换为enveloped-signature与排他规范化，规范化方法为排他规范化（不含注释）
+//   - RSA-SHA256，以及SM3withSM2（GB/T 35276，签名值为DER编码的r、s）
+//   - 元素加密：RSA-OAEP传输AES-256-GCM密钥，SM2传输SM4-GCM密钥
+//
+// 早期版本对SM2接收方使用SM4-CBC，该格式没有完整性保护，解密方若暴露填充是否正确就构成填充预言机。
+// 现在只保留其解密以兼容旧报文，所有密钥传输与数据解密失败统一返回ErrXMLDecryption，不区分失败原因。
+//
+// 国密算法在W3C规范中没有标准URI，这里使用 urn:oid: 形式的算法标识，与对端约定不同时需要调整。
+// 验签只信任调用方传入的公钥或证书，KeyInfo中携带的证书仅供对端参考
+
+// XML签名与加密的命名空间与算法标识
+const (
+	XMLDSigNamespace = "http://www.w3.org/2000/09/xmldsig#"
+	XMLEncNamespace  = "http://www.w3.org/2001/04/xmlenc#"
+
+	XMLExcC14N            = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	XMLEnvelopedSignature = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	XMLDigestSHA256       = "http://www.w3.org/2001/04/xmlenc#sha256"
+	XMLDigestSM3          = "urn:oid:1.2.156.10197.1.401"
+	XMLSignatureRSASHA256 = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	XMLSignatureSM2SM3    = "urn:oid:1.2.156.10197.1.501"
+
+	XMLEncElement      = "http://www.w3.org/2001/04/xmlenc#Element"
+	XMLEncAES256GCM    = "http://www.w3.org/2009/xmlenc11#aes256-gcm"
+	XMLEncSM4GCM       = "urn:oid:1.2.156.10197.1.104.8"
+	XMLEncSM4CBC       = "urn:oid:1.2.156.10197.1.104.2"
+	XMLEncRSAOAEPMGF1P = "http://www.w3.org/2001/04/xmlenc#rsa-oaep-mgf1p"
+	XMLEncSM2          = "urn:oid:1.2.156.10197.1.301.3"
+)
+
+// ErrXMLDecryption XML元素解密失败，密钥传输与数据解密的各种失败不做区分
+var ErrXMLDecryption = errors.New("解密XML元素失败")
+
+// SignXML 对XML文档做封装签名，签名插入根元素末尾
+// signer支持RSA与SM2，certPEM非nil时在KeyInfo中附带证书
+func SignXML(doc []byte, signer crypto.Signer, certPEM []byte) ([]byte, error) {
+	root, err := parseXMLTree(doc)
+	if err != nil {
+		return nil, err
+	}
+	if root.closeStart == root.end {
+		return nil, errors.New("根元素不能是空元素")
+	}
+
+	var signatureMethod, digestMethod string
+	var hashAlgo HashAlgorithm
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		signatureMethod, digestMethod, hashAlgo = XMLSignatureRSASHA256, XMLDigestSHA256, HashSHA256
+	case *sm2.PublicKey:
+		signatureMethod, digestMethod, hashAlgo = XMLSignatureSM2SM3, XMLDigestSM3, HashSM3
+	default:
+		return nil, errors.New("XML签名只支持RSA与SM2密钥")
+	}
+
+	h := hashFunc(hashAlgo)()
+	h.Write(canonicalizeXML(root, nil))
+	digest := base64.StdEncoding.EncodeToString(h.Sum(nil))
+
+	var keyInfo string
+	if certPEM != nil {
+		block, _ := pem.Decode(certPEM)
+		if block == nil || block.Type != "CERTIFICATE" {
+			return nil, errors.New("无法解析PEM编码的证书")
+		}
+		keyInfo = "<ds:KeyInfo><ds:X509Data><ds:X509Certificate>" +
+			base64.StdEncoding.EncodeToString(block.Bytes) +
+			"</ds:X509Certificate></ds:X509Data></ds:KeyInfo>"
+	}
+
+	signedInfo := `<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + XMLExcC14N + `"></ds:CanonicalizationMethod>` +
+		`<ds:SignatureMethod Algorithm="` + signatureMethod + `"></ds:SignatureMethod>` +
+		`<ds:Reference URI=""><ds:Transforms>` +
+		`<ds:Transform Algorithm="` + XMLEnvelopedSignature + `"></ds:Transform>` +
+		`<ds:Transform Algorithm="` + XMLExcC14N + `"></ds:Transform>` +
+		`</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + digestMethod + `"></ds:DigestMethod>` +
+		`<ds:DigestValue>` + digest + `</ds:DigestValue>` +
+		`</ds:Reference></ds:SignedInfo>`
+
+	// 先解析签名元素，对规范化后的SignedInfo签名
+	sigTree, err := parseXMLTree([]byte(`<ds:Signature xmlns:ds="` + XMLDSigNamespace + `">` + signedInfo + `</ds:Signature>`))
+	if err != nil {
+		return nil, err
+	}
+	signature, err := signMessage(signer, canonicalizeXML(sigTree.child(XMLDSigNamespace, "SignedInfo"), nil))
+	if err != nil {
+		return nil, err
+	}
+
+	element := `<ds:Signature xmlns:ds="` + XMLDSigNamespace + `">` + signedInfo +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signature) + `</ds:SignatureValue>` +
+		keyInfo + `</ds:Signature>`
+
+	out := make([]byte, 0, len(doc)+len(element))
+	out = append(out, doc[:root.closeStart]...)
+	out = append(out, element...)
+	return append(out, doc[root.closeStart:]...), nil
+}
+
+// VerifyXML 验证XML文档的封装签名，publicKeyPEM可以是公钥或证书
+func VerifyXML(doc []byte, publicKeyPEM []byte) error {
+	publicKey, err := parseXMLVerificationKey(publicKeyPEM)
+	if err != nil {
+		return err
+	}
+	root, err := parseXMLTree(doc)
+	if err != nil {
+		return err
+	}
+
+	var signature *xmlNode
+	for _, c := range root.children {
+		if c.is(XMLDSigNamespace, "Signature") {
+			if signature != nil {
+				return errors.New("XML文档包含多个签名")
+			}
+			signature = c
+		}
+	}
+	if signature == nil {
+		return errors.New("XML文档没有封装签名")
+	}
+	signedInfo := signature.child(XMLDSigNamespace, "SignedInfo")
+	signatureValue := signature.child(XMLDSigNamespace, "SignatureValue")
+	if signedInfo == nil || signatureValue == nil {
+		return errors.New("XML签名结构不完整")
+	}
+
+	if algorithm := xmlAlgorithm(signedInfo, "CanonicalizationMethod"); algorithm != XMLExcC14N {
+		return errors.Errorf("不支持的规范化方法: %s", algorithm)
+	}
+	switch algorithm := xmlAlgorithm(signedInfo, "SignatureMethod"); algorithm {
+	case XMLSignatureRSASHA256:
+		if _, ok := publicKey.(*rsa.PublicKey); !ok {
+			return errors.New("签名算法与公钥类型不一致")
+		}
+	case XMLSignatureSM2SM3:
+		if _, ok := publicKey.(*sm2.PublicKey); !ok {
+			return errors.New("签名算法与公钥类型不一致")
+		}
+	default:
+		return errors.Errorf("不支持的签名算法: %s", algorithm)
+	}
+
+	var references []*xmlNode
+	for _, c := range signedInfo.children {
+		if c.is(XMLDSigNamespace, "Reference") {
+			references = append(references, c)
+		}
+	}
+	if len(references) != 1 {
+		return errors.New("只支持单个Reference")
+	}
+	reference := references[0]
+	if uri, _ := reference.attr("URI"); uri != "" {
+		return errors.Errorf("只支持引用整个文档，URI为: %s", uri)
+	}
+	enveloped := false
+	if transforms := reference.child(XMLDSigNamespace, "Transforms"); transforms != nil {
+		for _, transform := range transforms.children {
+			if transform.kind != xmlElementNode {
+				continue
+			}
+			switch algorithm, _ := transform.attr("Algorithm"); algorithm {
+			case XMLEnvelopedSignature:
+				enveloped = true
+			case XMLExcC14N:
+			default:
+				return errors.Errorf("不支持的变换: %s", algorithm)
+			}
+		}
+	}
+	if !enveloped {
+		return errors.New("缺少enveloped-signature变换")
+	}
+
+	var hashAlgo HashAlgorithm
+	switch algorithm := xmlAlgorithm(reference, "DigestMethod"); algorithm {
+	case XMLDigestSHA256:
+		hashAlgo = HashSHA256
+	case XMLDigestSM3:
+		hashAlgo = HashSM3
+	default:
+		return errors.Errorf("不支持的摘要算法: %s", algorithm)
+	}
+	digestValue := reference.child(XMLDSigNamespace, "DigestValue")
+	if digestValue == nil {
+		return errors.New("缺少DigestValue")
+	}
+	expected, err := base64.StdEncoding.DecodeString(strings.TrimSpace(digestValue.textContent()))
+	if err != nil {
+		return errors.Wrap(err, "解码DigestValue失败")
+	}
+	h := hashFunc(hashAlgo)()
+	h.Write(canonicalizeXML(root, signature))
+	if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+		return errors.New("XML文档摘要不一致，内容已被修改")
+	}
+
+	sigBytes, err := base64.StdEncoding.DecodeString(string(stripEncodingSpace([]byte(signatureValue.textContent()))))
+	if err != nil {
+		return errors.Wrap(err, "解码SignatureValue失败")
+	}
+	return verifyMessage(publicKey, canonicalizeXML(signedInfo, nil), sigBytes)
+}
+
+// EncryptXMLElement 加密文档中第一个本地名为localName的元素，替换为EncryptedData
+// recipientPEM可以是RSA/SM2公钥或证书：RSA使用RSA-OAEP与AES-256-GCM，SM2使用SM2与SM4-GCM
+func EncryptXMLElement(doc []byte, localName string, recipientPEM []byte) ([]byte, error) {
+	publicKey, err := parseXMLVerificationKey(recipientPEM)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseXMLTree(doc)
+	if err != nil {
+		return nil, err
+	}
+	matches := root.find(func(n *xmlNode) bool { return n.name.Local == localName })
+	if len(matches) == 0 {
+		return nil, errors.Errorf("XML文档中没有元素: %s", localName)
+	}
+	target := matches[0]
+	// 限制容量，避免分组填充时写入原文档后续内容
+	plaintext := doc[target.start:target.end:target.end]
+
+	var keyMethod, dataMethod string
+	var encryptedKey, ciphertext []byte
+	switch pub := publicKey.(type) {
+	case *rsa.PublicKey:
+		key, err := GenerateRandomBytes(32)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroBytes(key)
+		if encryptedKey, err = rsa.EncryptOAEP(sha1.New(), rand.Reader, pub, key, nil); err != nil {
+			return nil, errors.Wrap(err, "RSA-OAEP加密密钥失败")
+		}
+		if ciphertext, err = AESGCMEncrypt(key, plaintext, nil); err != nil {
+			return nil, err
+		}
+		keyMethod, dataMethod = XMLEncRSAOAEPMGF1P, XMLEncAES256GCM
+	case *sm2.PublicKey:
+		key, err := GenerateRandomBytes(16)
+		if err != nil {
+			return nil, err
+		}
+		defer zeroBytes(key)
+		if encryptedKey, err = pub.EncryptAsn1(key, rand.Reader); err != nil {
+			return nil, errors.Wrap(err, "SM2加密密钥失败")
+		}
+		if ciphertext, err = SM4GCMEncrypt(key, plaintext, nil); err != nil {
+			return nil, err
+		}
+		keyMethod, dataMethod = XMLEncSM2, XMLEncSM4GCM
+	default:
+		return nil, errors.New("XML加密只支持RSA与SM2公钥")
+	}
+
+	element := `<xenc:EncryptedData xmlns:xenc="` + XMLEncNamespace + `" Type="` + XMLEncElement + `">` +
+		`<xenc:EncryptionMethod Algorithm="` + dataMethod + `"></xenc:EncryptionMethod>` +
+		`<ds:KeyInfo xmlns:ds="` + XMLDSigNamespace + `"><xenc:EncryptedKey>` +
+		`<xenc:EncryptionMethod Algorithm="` + keyMethod + `"></xenc:EncryptionMethod>` +
+		`<xenc:CipherData><xenc:CipherValue>` + base64.StdEncoding.EncodeToString(encryptedKey) + `</xenc:CipherValue></xenc:CipherData>` +
+		`</xenc:EncryptedKey></ds:KeyInfo>` +
+		`<xenc:CipherData><xenc:CipherValue>` + base64.StdEncoding.EncodeToString(ciphertext) + `</xenc:CipherValue></xenc:CipherData>` +
+		`</xenc:EncryptedData>`
+
+	out := make([]byte, 0, len(doc)+len(element))
+	out = append(out, doc[:target.start]...)
+	out = append(out, element...)
+	return append(out, doc[target.end:]...), nil
+}
+
+// DecryptXML 解密文档中所有EncryptedData元素，恢复为原始元素
+// 密钥或密文不正确时返回ErrXMLDecryption，不区分是密钥传输还是数据解密失败
+func DecryptXML(doc []byte, privateKeyPEM []byte) ([]byte, error) {
+	privateKey, err := parsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+	root, err := parseXMLTree(doc)
+	if err != nil {
+		return nil, err
+	}
+	encrypted := root.find(func(n *xmlNode) bool { return n.is(XMLEncNamespace, "EncryptedData") })
+	if len(encrypted) == 0 {
+		return nil, errors.New("XML文档中没有加密元素")
+	}
+
+	out := append([]byte(nil), doc...)
+	// 从后向前替换，保持前面元素的偏移有效
+	for i := len(encrypted) - 1; i >= 0; i-- {
+		node := encrypted[i]
+		plaintext, err := decryptXMLElement(node, privateKey)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out[:node.start], append(plaintext, out[node.end:]...)...)
+	}
+	return out, nil
+}
+
+// decryptXMLElement 解密单个EncryptedData，结构错误分别报告，密码学失败统一返回ErrXMLDecryption
+func decryptXMLElement(node *xmlNode, privateKey crypto.PrivateKey) ([]byte, error) {
+	keyInfo := node.child(XMLDSigNamespace, "KeyInfo")
+	if keyInfo == nil {
+		return nil, errors.New("EncryptedData缺少KeyInfo")
+	}
+	encryptedKeyNode := keyInfo.child(XMLEncNamespace, "EncryptedKey")
+	if encryptedKeyNode == nil {
+		return nil, errors.New("EncryptedData缺少EncryptedKey")
+	}
+	encryptedKey, err := xmlCipherValue(encryptedKeyNode)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := xmlCipherValue(node)
+	if err != nil {
+		return nil, err
+	}
+
+	var key []byte
+	switch algorithm := xmlAlgorithm(encryptedKeyNode, "EncryptionMethod"); algorithm {
+	case XMLEncRSAOAEPMGF1P:
+		priv, ok := privateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("密钥传输算法与私钥类型不一致")
+		}
+		if key, err = rsa.DecryptOAEP(sha1.New(), nil, priv, encryptedKey, nil); err != nil {
+			return nil, ErrXMLDecryption
+		}
+	case XMLEncSM2:
+		priv, ok := privateKey.(*sm2.PrivateKey)
+		if !ok {
+			return nil, errors.New("密钥传输算法与私钥类型不一致")
+		}
+		if key, err = decryptSM2Flexible(priv, encryptedKey); err != nil {
+			return nil, ErrXMLDecryption
+		}
+	default:
+		return nil, errors.Errorf("不支持的密钥传输算法: %s", algorithm)
+	}
+	defer zeroBytes(key)
+
+	var plaintext []byte
+	switch algorithm := xmlAlgorithm(node, "EncryptionMethod"); algorithm {
+	case XMLEncAES256GCM:
+		plaintext, err = AESGCMDecrypt(key, ciphertext, nil)
+	case XMLEncSM4GCM:
+		plaintext, err = SM4GCMDecrypt(key, ciphertext, nil)
+	case XMLEncSM4CBC:
+		plaintext, err = xmlSM4CBCDecrypt(key, ciphertext)
+	default:
+		return nil, errors.Errorf("不支持的加密算法: %s", algorithm)
+	}
+	if err != nil {
+		return nil, ErrXMLDecryption
+	}
+	return plaintext, nil
+}
+
+// xmlSM4CBCDecrypt 解密旧版本生成的SM4-CBC密文，IV置于密文之前（XML-Enc的分组密码约定）
+// 该格式没有完整性保护，仅为兼容保留：开启CBC加固以常量时间去填充，调用方只能得到统一的ErrXMLDecryption
+func xmlSM4CBCDecrypt(key, data []byte) ([]byte, error) {
+	if len(data) < 32 || len(data)%16 != 0 {
+		return nil, errors.New("SM4-CBC密文长度不正确")
+	}
+	cipher, err := SM4(key, WithMode(ModeCBC), WithPadding(PaddingPKCS7), WithIV(append([]byte(nil), data[:16]...)),
+		WithEncoding(EncodingNone), WithCBCHardening(nil))
+	if err != nil {
+		return nil, err
+	}
+	defer cipher.Release()
+	return cipher.Decrypt(data[16:])
+}
+
+// xmlAlgorithm 获取子元素的Algorithm属性，DigestMethod等位于dsig命名空间，EncryptionMethod位于xenc命名空间
+func xmlAlgorithm(n *xmlNode, local string) string {
+	for _, namespace := range []string{XMLDSigNamespace, XMLEncNamespace} {
+		if c := n.child(namespace, local); c != nil {
+			algorithm, _ := c.attr("Algorithm")
+			return algorithm
+		}
+	}
+	return ""
+}
+
+// xmlCipherValue 获取CipherData/CipherValue中的数据
+func xmlCipherValue(n *xmlNode) ([]byte, error) {
+	cipherData := n.child(XMLEncNamespace, "CipherData")
+	if cipherData == nil {
+		return nil, errors.New("缺少CipherData")
+	}
+	cipherValue := cipherData.child(XMLEncNamespace, "CipherValue")
+	if cipherValue == nil {
+		return nil, errors.New("缺少CipherValue")
+	}
+	value, err := base64.StdEncoding.DecodeString(string(stripEncodingSpace([]byte(cipherValue.textContent()))))
+	if err != nil {
+		return nil, errors.Wrap(err, "解码CipherValue失败")
+	}
+	return value, nil
+}
+
+// parseXMLVerificationKey 解析公钥或证书中的公钥
+func parseXMLVerificationKey(keyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("无法解析PEM编码的公钥或证书")
+	}
+	if block.Type != "CERTIFICATE" {
+		return parsePublicKeyPEM(keyPEM)
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "解析证书失败")
+	}
+	if pub, ok := cert.PublicKey.(*ecdsa.PublicKey); ok && pub.Curve == sm2.P256Sm2() {
+		return sm2CertificatePublicKey(cert)
+	}
+	return cert.PublicKey, nil
+}